@@ -3,37 +3,204 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	v1 "k8s.io/api/core/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/metrics/pkg/client/clientset/versioned"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/admission"
+	"github.com/ochestra-tech/ochestra-ai/pkg/anomaly"
+	"github.com/ochestra-tech/ochestra-ai/pkg/approval"
+	"github.com/ochestra-tech/ochestra-ai/pkg/archive"
+	"github.com/ochestra-tech/ochestra-ai/pkg/auth"
+	"github.com/ochestra-tech/ochestra-ai/pkg/budget"
+	"github.com/ochestra-tech/ochestra-ai/pkg/commitments"
+	"github.com/ochestra-tech/ochestra-ai/pkg/compare"
+	"github.com/ochestra-tech/ochestra-ai/pkg/compliance"
+	"github.com/ochestra-tech/ochestra-ai/pkg/cost"
+	"github.com/ochestra-tech/ochestra-ai/pkg/drift"
+	"github.com/ochestra-tech/ochestra-ai/pkg/external"
+	"github.com/ochestra-tech/ochestra-ai/pkg/features"
+	"github.com/ochestra-tech/ochestra-ai/pkg/fleet"
+	"github.com/ochestra-tech/ochestra-ai/pkg/grpcapi"
+	clusterhealth "github.com/ochestra-tech/ochestra-ai/pkg/health"
+	"github.com/ochestra-tech/ochestra-ai/pkg/history"
+	"github.com/ochestra-tech/ochestra-ai/pkg/jira"
+	"github.com/ochestra-tech/ochestra-ai/pkg/latency"
+	"github.com/ochestra-tech/ochestra-ai/pkg/loadsignal"
+	"github.com/ochestra-tech/ochestra-ai/pkg/maintenance"
+	"github.com/ochestra-tech/ochestra-ai/pkg/multiarch"
+	"github.com/ochestra-tech/ochestra-ai/pkg/nodestate"
+	"github.com/ochestra-tech/ochestra-ai/pkg/notify"
+	"github.com/ochestra-tech/ochestra-ai/pkg/optimizer"
+	"github.com/ochestra-tech/ochestra-ai/pkg/orphans"
+	"github.com/ochestra-tech/ochestra-ai/pkg/owners"
+	"github.com/ochestra-tech/ochestra-ai/pkg/plugins"
+	"github.com/ochestra-tech/ochestra-ai/pkg/protection"
+	"github.com/ochestra-tech/ochestra-ai/pkg/regression"
+	"github.com/ochestra-tech/ochestra-ai/pkg/resources"
+	"github.com/ochestra-tech/ochestra-ai/pkg/rules"
+	"github.com/ochestra-tech/ochestra-ai/pkg/selfguard"
+	"github.com/ochestra-tech/ochestra-ai/pkg/showback"
+	"github.com/ochestra-tech/ochestra-ai/pkg/slo"
+	"github.com/ochestra-tech/ochestra-ai/pkg/snapshot"
+	"github.com/ochestra-tech/ochestra-ai/pkg/trends"
+	"github.com/ochestra-tech/ochestra-ai/pkg/watcher"
+	"github.com/ochestra-tech/ochestra-ai/pkg/whatif"
 )
 
 // Configuration options
 type Config struct {
-	KubeConfigPath   string
-	Interval         time.Duration
-	MetricsPort      int
-	OutputFile       string
-	EnableCostReport bool
-	PricingDataFile  string
+	KubeConfigPath       string
+	Protobuf             bool
+	Interval             time.Duration
+	MetricsPort          int
+	OutputFile           string
+	EnableCostReport     bool
+	PricingDataFile      string
+	ClusterName          string
+	HistoryDir           string
+	HistoryPostgres      string
+	HistoryRetention     history.RetentionPolicy
+	Trends               bool
+	Showback             bool
+	ShowbackLabel        string
+	BudgetConfigFile     string
+	WebhookURL           string
+	SlackWebhookURL      string
+	TeamsWebhookURL      string
+	DiscordWebhookURL    string
+	MattermostWebhookURL string
+	AlertTemplates       string
+	AlertRoutes          string
+	AlertmanagerURL      string
+	ExternalAlerts       bool
+	ExternalAlertWindow  time.Duration
+	CheckTTLs            string
+	PProf                bool
+	MemoryLimit          string
+	CPULimit             float64
+	SelfDegradeAt        float64
+	HeapProfileDir       string
+	SMTPAddr             string
+	EmailFrom            string
+	EmailTo              string
+	Opsgenie             bool
+	OpsgenieAPIURL       string
+	SplunkOnCallRouting  string
+	LedgerFile           string
+	ExportConfigFile     string
+	ExportDir            string
+	ExportPRProvider     string
+	ExportPRRepo         string
+	ExportPRBase         string
+	MaintenanceFile      string
+	SLOConfigFile        string
+	SLOStateFile         string
+	JiraConfigFile       string
+	RunbookFile          string
+	VersionDenylistFile  string
+	RulesFile            string
+	GitOps               bool
+	Helm                 bool
+	Pipelines            bool
+	DataServices         bool
+	BackupTargetsFile    string
+	PrometheusURL        string
+	StorageIOPSQuery     string
+	StorageThroughput    string
+	ProductionNamespaces string
+	LimitRatio           float64
+	IdleNamespaceAfter   time.Duration
+	OffHours             bool
+	BusinessHours        string
+	BusinessTimezone     string
+	KEDA                 bool
+	KEDAManifestDir      string
+	ARM                  bool
+	ARMDiscount          float64
+	Cleanup              bool
+	CleanupPolicyFile    string
+	CleanupApply         bool
+	CleanupApproval      bool
+	CleanupApprovalState string
+	CleanupBackupDir     string
+	CleanupConcurrency   int
+	CleanupQPS           float64
+	CleanupBatchState    string
+	ProtectedNamespaces  string
+	Owners               bool
+	OwnerTeamKeys        string
+	OwnerSlackKeys       string
+	OwnerEmailKeys       string
+	SidecarNames         string
+	DaemonSetsFile       string
+	JiraStateFile        string
+	Drift                bool
+	DriftStateFile       string
+	ArchiveConfigFile    string
+	FleetConfigFile      string
+	FleetReceive         bool
+	Agent                bool
+	AggregatorPort       int
+	AggregatorCert       string
+	AggregatorKey        string
+	AggregatorClientCA   string
+	FleetStaleAfter      time.Duration
+	FeatureGates         string
+	Anomaly              bool
+	AnomalyThreshold     float64
+	CostAnomaly          bool
+	APILatencyProbes     int
+	NodeFlapTransitions  int
+	ScoreRegression      int
+	ScoreRegressionAfter time.Duration
+	ScoreRegressionState string
+	CloudOrphans         string
+	AuthConfigFile       string
+	CloudOrphanInterval  time.Duration
+	Commitments          string
+	CommitmentInterval   time.Duration
+	FinalizerScan        time.Duration
+	RemoveFinalizers     bool
+	GRPCPort             int
+	GRPCCert             string
+	GRPCKey              string
+	AdmissionPort        int
+	AdmissionCert        string
+	AdmissionKey         string
+	AdmissionPolicy      string
+	LoadSignals          string
+	PluginsFile          string
+	Watch                bool
+	Compliance           bool
 }
 
 // Cost data for different node types and regions
 type PricingData struct {
-	Nodes map[string]NodePricing `json:"nodes"`
+	Nodes     map[string]NodePricing `json:"nodes"`
+	Discounts map[string]float64     `json:"discounts,omitempty"` // off the nodes' on-demand prices, keyed by purchase option: spot, reserved
+	// CrossZonePerGB is the price of a GB crossing zones, both sides together
+	CrossZonePerGB float64 `json:"crossZonePerGB,omitempty"`
 }
 
 type NodePricing struct {
@@ -45,16 +212,36 @@ type NodePricing struct {
 
 // ClusterHealth represents the health status of the cluster
 type ClusterHealth struct {
-	TotalNodes              int     `json:"totalNodes"`
-	ReadyNodes              int     `json:"readyNodes"`
-	ResourceUtilization     float64 `json:"resourceUtilization"`
-	PendingPods             int     `json:"pendingPods"`
-	FailedPods              int     `json:"failedPods"`
-	CriticalComponentsOK    bool    `json:"criticalComponentsOK"`
-	MemoryPressureNodes     int     `json:"memoryPressureNodes"`
-	DiskPressureNodes       int     `json:"diskPressureNodes"`
-	PIDPressureNodes        int     `json:"pidPressureNodes"`
-	NetworkUnavailableNodes int     `json:"networkUnavailableNodes"`
+	TotalNodes              int      `json:"totalNodes"`
+	ReadyNodes              int      `json:"readyNodes"`
+	ResourceUtilization     float64  `json:"resourceUtilization"`
+	PendingPods             int      `json:"pendingPods"`
+	FailedPods              int      `json:"failedPods"`
+	CriticalComponentsOK    bool     `json:"criticalComponentsOK"`
+	MemoryPressureNodes     int      `json:"memoryPressureNodes"`
+	DiskPressureNodes       int      `json:"diskPressureNodes"`
+	PIDPressureNodes        int      `json:"pidPressureNodes"`
+	NetworkUnavailableNodes int      `json:"networkUnavailableNodes"`
+	MaintenanceWindows      []string `json:"maintenanceWindows,omitempty"`
+
+	NodesByOS    []clusterhealth.OSPool `json:"nodesByOS,omitempty"`
+	VirtualNodes int                    `json:"virtualNodes,omitempty"` // virtual kubelets and serverless nodes
+
+	Efficiency          *clusterhealth.EfficiencyScore           `json:"efficiency,omitempty"`
+	NamespaceEfficiency map[string]clusterhealth.EfficiencyScore `json:"namespaceEfficiency,omitempty"`
+
+	Anomalies        []clusterhealth.HealthIssue       `json:"anomalies,omitempty"`
+	PluginIssues     []clusterhealth.HealthIssue       `json:"pluginIssues,omitempty"`
+	ConfigDrift      []clusterhealth.HealthIssue       `json:"configDrift,omitempty"`
+	APIServerLatency []latency.Status                  `json:"apiServerLatency,omitempty"`
+	Inventory        clusterhealth.InventoryStatus     `json:"inventory"`
+	NodeStates       []nodestate.State                 `json:"nodeStates,omitempty"`
+	Taints           clusterhealth.TaintStatus         `json:"taints"`
+	DaemonSets       []clusterhealth.DaemonSetCoverage `json:"daemonSets,omitempty"`
+	CloudOrphans     []orphans.Orphan                  `json:"cloudOrphans,omitempty"`
+	StaleFinalizers  []orphans.StaleFinalizer          `json:"staleFinalizers,omitempty"`
+	ExternalAlerts   []external.Correlation            `json:"externalAlerts,omitempty"`
+	Monitor          selfguard.Usage                   `json:"monitor"` // the monitor's own resource usage
 }
 
 // CostReport represents the estimated costs for the cluster
@@ -63,8 +250,14 @@ type CostReport struct {
 	TotalCostPerMonth  float64               `json:"totalCostPerMonth"`
 	CostByNamespace    map[string]float64    `json:"costByNamespace"`
 	CostByNodeType     map[string]float64    `json:"costByNodeType"`
+	CostByOS           map[string]float64    `json:"costByOS"` // node cost per operating system
 	EfficientWorkloads []string              `json:"efficientWorkloads"`
 	Recommendations    []CostOptimizationRec `json:"recommendations"`
+	Lifecycle          *cost.LifecycleReport `json:"lifecycle,omitempty"` // node cost by purchase option
+	Commitments        *commitments.Report   `json:"commitments,omitempty"`
+	Transfer           *cost.TransferReport  `json:"transfer,omitempty"` // cross-zone service traffic
+	Sidecars           []cost.SidecarCost    `json:"sidecars,omitempty"` // per namespace
+	ARM                *multiarch.Report     `json:"arm,omitempty"`      // workloads that could move to ARM nodes
 }
 
 // CostOptimizationRec represents a cost optimization recommendation
@@ -117,6 +310,70 @@ var (
 		},
 		[]string{"namespace", "resource_type"},
 	)
+
+	containerResourceUsageGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "k8s_health_manager_container_resource_usage",
+			Help: "Resource usage of each workload container, averaged over replicas (cores or bytes)",
+		},
+		[]string{"namespace", "workload", "container", "sidecar", "resource_type"},
+	)
+
+	containerResourceRequestGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "k8s_health_manager_container_resource_requests",
+			Help: "Resource requests of each workload container (cores or bytes)",
+		},
+		[]string{"namespace", "workload", "container", "sidecar", "resource_type"},
+	)
+
+	namespaceSidecarShareGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "k8s_health_manager_namespace_sidecar_share",
+			Help: "Fraction of a namespace's resource requests taken by sidecar containers",
+		},
+		[]string{"namespace", "resource_type"},
+	)
+
+	apiServerLatencyGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "k8s_health_manager_apiserver_latency_ms",
+			Help: "API server call latency percentiles over the recent window in milliseconds",
+		},
+		[]string{"verb", "quantile"},
+	)
+
+	namespaceEvaluationGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "k8s_health_manager_namespace_evaluation_seconds",
+			Help: "Time the detailed health check spent evaluating namespaces, in total and for the slowest namespace",
+		},
+		[]string{"stat"},
+	)
+
+	namespaceWorkersGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "k8s_health_manager_namespace_evaluation_count",
+			Help: "Namespaces evaluated by the detailed health check and the workers evaluating them",
+		},
+		[]string{"kind"},
+	)
+
+	selfUsageGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "k8s_health_manager_self_usage",
+			Help: "The monitor's own resource usage (memory_bytes, heap_bytes, cpu_cores, goroutines) and whether it is degraded (1) or skipping cycles (2)",
+		},
+		[]string{"resource"},
+	)
+
+	fleetAgentGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "k8s_health_manager_fleet_agent",
+			Help: "Seconds since each fleet agent was last heard from (last_seen_seconds) and whether its cluster is stale (stale, 1)",
+		},
+		[]string{"cluster", "metric"},
+	)
 )
 
 func init() {
@@ -126,137 +383,2059 @@ func init() {
 	prometheus.MustRegister(namespaceResourceUsageGauge)
 	prometheus.MustRegister(namespaceCostGauge)
 	prometheus.MustRegister(resourceEfficiencyGauge)
+	prometheus.MustRegister(containerResourceUsageGauge)
+	prometheus.MustRegister(containerResourceRequestGauge)
+	prometheus.MustRegister(namespaceSidecarShareGauge)
+	prometheus.MustRegister(apiServerLatencyGauge)
+	prometheus.MustRegister(namespaceEvaluationGauge)
+	prometheus.MustRegister(namespaceWorkersGauge)
+	prometheus.MustRegister(selfUsageGauge)
+	prometheus.MustRegister(fleetAgentGauge)
 }
 
 func main() {
+	// Compare two saved snapshots and exit
+	if len(os.Args) > 1 && os.Args[1] == "diff" {
+		os.Exit(runDiff(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "cleanup" {
+		os.Exit(runCleanupCommand(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "restore" {
+		os.Exit(runRestore(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "whatif" {
+		os.Exit(runWhatIf(os.Args[2:]))
+	}
+
 	// Parse command line flags
 	config := parseFlags()
+	if err := features.Configure(config.FeatureGates); err != nil {
+		log.Fatalf("Invalid --feature-gates: %v", err)
+	}
+	for _, gate := range features.All() {
+		if !gate.Enabled {
+			log.Printf("Feature %s is disabled", gate.Name)
+		}
+	}
+
+	// Require API callers to authenticate, scoping teams to their namespaces
+	var guard *auth.Guard
+	if config.AuthConfigFile != "" {
+		authConfig, err := auth.LoadConfig(config.AuthConfigFile)
+		if err != nil {
+			log.Fatalf("Failed to load auth config: %v", err)
+		}
+		authenticators := []auth.Authenticator{auth.NewTokenAuthenticator(authConfig)}
+		if authConfig.OIDC != nil {
+			// Accept provider ID tokens, and log browsers in through the provider
+			oidc, err := auth.NewOIDCAuthenticator(context.Background(), *authConfig.OIDC)
+			if err != nil {
+				log.Fatalf("Failed to set up OIDC: %v", err)
+			}
+			authenticators = append(authenticators, oidc)
+			http.Handle(auth.LoginPath, oidc.LoginHandler())
+			http.Handle(auth.CallbackPath, oidc.CallbackHandler())
+			http.Handle(auth.LogoutPath, oidc.LogoutHandler())
+		}
+		guard = auth.NewGuard(authConfig, authenticators...)
+	}
 
 	// Start metrics server
-	startMetricsServer(config.MetricsPort)
+	startMetricsServer(config.MetricsPort, guard)
+	http.Handle("/status", guard.Protect(features.StatusHandler{Cluster: config.ClusterName, StartedAt: time.Now()}, false))
+
+	// Serve runtime profiles; they expose the monitor's internals, so only to admins
+	if config.PProf {
+		if guard == nil {
+			log.Fatalf("--pprof needs --auth-config, so profiles are only served to admins")
+		}
+		http.Handle("/debug/pprof/", guard.Protect(selfguard.ProfileHandler{}, true))
+	}
+
+	// Track the monitor's own usage, switching to lean scans near its limits
+	limits := selfguard.Limits{CPUCores: config.CPULimit, DegradeAt: config.SelfDegradeAt, HeapProfileDir: config.HeapProfileDir}
+	if config.MemoryLimit != "" {
+		quantity, err := resource.ParseQuantity(config.MemoryLimit)
+		if err != nil {
+			log.Fatalf("Failed to parse memory limit: %v", err)
+		}
+		limits.MemoryBytes = quantity.Value()
+	}
+	selfGuard := selfguard.New(limits)
+
+	// Stream results to platform services over gRPC; like /metrics it spans every
+	// namespace, so only admins may call it
+	var grpcServer *grpcapi.Server
+	if config.GRPCPort != 0 {
+		if guard != nil && config.GRPCCert == "" {
+			log.Printf("gRPC API serves plaintext HTTP/2 and bearer tokens cross the network in the clear unless TLS is terminated in front of it; set --grpc-tls-cert to serve TLS")
+		}
+		grpcServer = grpcapi.NewServer()
+		go func() {
+			log.Printf("Starting gRPC server on port %d", config.GRPCPort)
+			if err := grpcapi.ListenAndServe(config.GRPCPort, guard.Protect(grpcServer, true), config.GRPCCert, config.GRPCKey); err != nil {
+				log.Fatalf("Failed to start gRPC server: %v", err)
+			}
+		}()
+	}
 
 	// Load pricing data for cost estimation
 	pricingData := loadPricingData(config.PricingDataFile)
 
+	for option, discount := range pricingData.Discounts {
+		cost.PurchaseDiscounts[option] = discount
+	}
+	if pricingData.CrossZonePerGB > 0 {
+		cost.CrossZoneCostPerGB = pricingData.CrossZonePerGB
+	}
+
 	// Initialize Kubernetes client
-	clientset, metricsClient := initKubernetesClient(config.KubeConfigPath)
+	clientset, metricsClient := initKubernetesClient(config.KubeConfigPath, config.Protobuf)
+
+	// Serve cost allocations in the OpenCost API shape
+	resourcePricing := toResourcePricing(pricingData)
+	allocationHandler := cost.NewAllocationHandler(clientset, metricsClient, resourcePricing, config.ClusterName)
+	http.Handle("/allocation", guard.Protect(features.Handler(features.Dashboard, allocationHandler), false))
+	http.Handle("/allocation/compute", guard.Protect(features.Handler(features.Dashboard, allocationHandler), false))
+
+	// Estimate what posted manifests would cost, packed onto the last cycle's cluster
+	whatIfHandler := whatif.NewHandler(resourcePricing)
+	http.Handle("/whatif", guard.Protect(features.Handler(features.Dashboard, whatIfHandler), false))
+
+	// Point issues at the team's own runbooks
+	if config.RunbookFile != "" {
+		suggestions, err := clusterhealth.LoadSuggestions(config.RunbookFile)
+		if err != nil {
+			log.Fatalf("Failed to load runbooks: %v", err)
+		}
+		clusterhealth.Suggestions = suggestions
+	}
+
+	// Flag nodes running component versions with known critical bugs
+	if config.VersionDenylistFile != "" {
+		denylist, err := clusterhealth.LoadVersionDenylist(config.VersionDenylistFile)
+		if err != nil {
+			log.Fatalf("Failed to load version denylist: %v", err)
+		}
+		clusterhealth.VersionDenylist = denylist
+	}
+
+	// Evaluate user-defined health and compliance rules each check
+	if config.RulesFile != "" {
+		ruleSet, err := rules.LoadRules(config.RulesFile)
+		if err != nil {
+			log.Fatalf("Failed to load rules: %v", err)
+		}
+		clusterhealth.CustomRules = ruleSet
+	}
+
+	// Run expensive checks at their own, longer intervals, reusing their last results between
+	if config.CheckTTLs != "" {
+		ttls, err := clusterhealth.ParseCheckTTLs(config.CheckTTLs)
+		if err != nil {
+			log.Fatalf("Failed to load check TTLs: %v", err)
+		}
+		clusterhealth.CheckTTLs = ttls
+	}
+
+	// Tell injected proxies and agents apart from the applications they run next to
+	snapshot.SidecarNames = splitList(config.SidecarNames)
+
+	// Report Argo CD and Flux sync state alongside namespace health
+	clusterhealth.GitOpsEnabled = config.GitOps
+	clusterhealth.HelmEnabled = config.Helm
+
+	// Report failing, stuck and pending Argo Workflows and Tekton PipelineRuns
+	clusterhealth.PipelinesEnabled = config.Pipelines
+
+	// Report CloudNativePG, Percona XtraDB and Redis cluster health
+	clusterhealth.DataServicesEnabled = config.DataServices
+
+	// Report BestEffort production workloads, bursting limits and CPU throttling
+	clusterhealth.PrometheusURL = config.PrometheusURL
+	clusterhealth.ProductionNamespaces = strings.Split(config.ProductionNamespaces, ",")
+	clusterhealth.LimitRequestRatioWarn = config.LimitRatio
+
+	// Report idle namespaces, pricing their volumes like the rest of the cluster's storage
+	clusterhealth.IdleNamespaceAfter = config.IdleNamespaceAfter
+	if pricing, ok := pricingData.Nodes["default"]; ok && pricing.StorageCostPerGBHr > 0 {
+		clusterhealth.IdleStorageCostPerGBHour = pricing.StorageCostPerGBHr
+	}
+
+	// Check the namespaces that must be backed up against their recovery-point objectives
+	if config.BackupTargetsFile != "" {
+		targets, err := clusterhealth.LoadBackupTargets(config.BackupTargetsFile)
+		if err != nil {
+			log.Fatalf("Failed to load backup targets: %v", err)
+		}
+		clusterhealth.BackupTargets = targets
+	}
+
+	// Replace the built-in list of DaemonSets that must run on every eligible node
+	if config.DaemonSetsFile != "" {
+		daemonSets, err := clusterhealth.LoadCriticalDaemonSets(config.DaemonSetsFile)
+		if err != nil {
+			log.Fatalf("Failed to load critical DaemonSets: %v", err)
+		}
+		clusterhealth.CriticalDaemonSets = daemonSets
+	}
+
+	// Run company-specific checks, sinks and remediations as external executables
+	var pluginManager *plugins.Manager
+	if config.PluginsFile != "" {
+		pluginConfig, err := plugins.LoadConfig(config.PluginsFile)
+		if err != nil {
+			log.Fatalf("Failed to load plugins: %v", err)
+		}
+		pluginManager = plugins.NewManager(pluginConfig, config.ClusterName)
+	}
+
+	// Set up alert delivery and allocation history
+	formatter := buildFormatter(clientset, config)
+	notifier := buildNotifier(config, formatter)
+	if pluginManager != nil {
+		notifier = notify.MultiNotifier{notifier, pluginManager}
+	}
+
+	// Name the team owning each issue's workload, from annotations and labels on its
+	// top-level controller, the objects below it and its namespace
+	if config.Owners {
+		owners.Configure(owners.Keys{
+			Team:  splitList(config.OwnerTeamKeys),
+			Slack: splitList(config.OwnerSlackKeys),
+			Email: splitList(config.OwnerEmailKeys),
+		})
+		notifier = notify.OwnerNotifier{Next: notifier}
+	}
+	store := openHistoryStore(config.HistoryDir, config.HistoryPostgres)
+
+	// Serve moving averages, week-over-week changes and forecasts from the history
+	http.Handle("/trends", guard.Protect(features.Handler(features.Dashboard, trends.NewHandler(store, config.ClusterName)), false))
+
+	// Review new workloads at admission against the peak usage of similar ones. The API
+	// server calls the webhook over TLS on its own port, outside the API's auth.
+	var admissionWebhook *admission.Webhook
+	if config.AdmissionPort != 0 {
+		policy := admission.DefaultPolicy
+		if config.AdmissionPolicy != "" {
+			loaded, err := admission.LoadPolicy(config.AdmissionPolicy)
+			if err != nil {
+				log.Fatalf("Failed to load admission policy: %v", err)
+			}
+			policy = *loaded
+		}
+		admissionWebhook = admission.NewWebhook(policy, store, config.ClusterName)
+		mux := http.NewServeMux()
+		mux.Handle("/validate", admissionWebhook)
+		mux.Handle("/mutate", admissionWebhook)
+		go func() {
+			log.Printf("Starting admission webhook on port %d", config.AdmissionPort)
+			if err := http.ListenAndServeTLS(fmt.Sprintf(":%d", config.AdmissionPort), config.AdmissionCert, config.AdmissionKey, mux); err != nil {
+				log.Fatalf("Failed to start admission webhook: %v", err)
+			}
+		}()
+	}
+
+	// Read workload-specific load signals so right-sizing and anomaly detection see load,
+	// not just CPU
+	var loadSignals *loadsignal.Collector
+	if config.LoadSignals != "" {
+		signalConfig, err := loadsignal.LoadConfig(config.LoadSignals)
+		if err != nil {
+			log.Fatalf("Failed to load load signals: %v", err)
+		}
+		loadSignals, err = loadsignal.NewCollector(signalConfig, clientset, config.PrometheusURL, store, config.ClusterName)
+		if err != nil {
+			log.Fatalf("Failed to set up load signals: %v", err)
+		}
+	}
+
+	// Read image manifests for the platforms they are published for, once a day per image
+	var armResolver *multiarch.Resolver
+	if config.ARM {
+		armResolver = multiarch.NewResolver(24 * time.Hour)
+	}
+
+	// Recommend scaling workloads that are only busy in business hours to zero outside them
+	var offHours *optimizer.OffHoursOptions
+	if config.OffHours {
+		hours, err := optimizer.ParseBusinessHours(config.BusinessHours, config.BusinessTimezone)
+		if err != nil {
+			log.Fatalf("Failed to load business hours: %v", err)
+		}
+		options := optimizer.DefaultOffHoursOptions
+		options.Hours = hours
+		offHours = &options
+	}
+
+	// Compare posted snapshots, e.g. from before and after an upgrade
+	http.Handle("/compare", guard.Protect(features.Handler(features.Dashboard, compare.Handler{}), false))
+
+	// Silence alerts during maintenance windows
+	var maintenanceSchedule *maintenance.Schedule
+	if config.MaintenanceFile != "" {
+		var err error
+		maintenanceSchedule, err = maintenance.LoadSchedule(config.MaintenanceFile)
+		if err != nil {
+			log.Fatalf("Failed to load maintenance windows: %v", err)
+		}
+		notifier = &maintenance.SilencingNotifier{Next: notifier, Schedule: maintenanceSchedule, Cluster: config.ClusterName}
+	}
+
+	var budgetMonitor *budget.Monitor
+	labelKeys := []string{}
+	if config.BudgetConfigFile != "" {
+		budgetConfig, err := budget.LoadConfig(config.BudgetConfigFile)
+		if err != nil {
+			log.Fatalf("Failed to load budgets: %v", err)
+		}
+		labelKeys = budgetConfig.LabelKeys()
+		budgetMonitor = budget.NewMonitor(budgetConfig, store, notifier, config.ClusterName)
+	}
+
+	// Serve each team's burn rate, trend, top workloads and waste, scoped to the caller's
+	// namespaces; the team label's costs are recorded for the trends
+	var showbackHandler *showback.Handler
+	if config.Showback {
+		showbackHandler = showback.NewHandler(store, config.ClusterName, config.ShowbackLabel)
+		http.Handle("/showback", guard.Protect(features.Handler(features.Dashboard, showbackHandler), false))
+		http.Handle("/showback/dashboard", guard.Protect(features.Handler(features.Dashboard, showbackHandler), false))
+		if !slices.Contains(labelKeys, config.ShowbackLabel) {
+			labelKeys = append(labelKeys, config.ShowbackLabel)
+		}
+	}
+
+	var sloTracker *slo.Tracker
+	if config.SLOConfigFile != "" {
+		sloConfig, err := slo.LoadConfig(config.SLOConfigFile)
+		if err != nil {
+			log.Fatalf("Failed to load SLOs: %v", err)
+		}
+		sloTracker, err = slo.LoadTracker(sloConfig, config.SLOStateFile, notifier, config.ClusterName)
+		if err != nil {
+			log.Fatalf("Failed to load SLO state: %v", err)
+		}
+	}
+
+	var jiraTracker *jira.Tracker
+	if config.JiraConfigFile != "" {
+		jiraConfig, err := jira.LoadConfig(config.JiraConfigFile)
+		if err != nil {
+			log.Fatalf("Failed to load Jira config: %v", err)
+		}
+		client := jira.NewClient(jiraConfig.URL, os.Getenv("JIRA_EMAIL"), os.Getenv("JIRA_API_TOKEN"))
+		jiraTracker, err = jira.LoadTracker(jiraConfig, client, config.JiraStateFile, config.ClusterName)
+		if err != nil {
+			log.Fatalf("Failed to load Jira state: %v", err)
+		}
+	}
+
+	// Detect configuration changes between checks
+	var driftTracker *drift.Tracker
+	if config.Drift {
+		var err error
+		driftTracker, err = drift.LoadTracker(config.DriftStateFile)
+		if err != nil {
+			log.Fatalf("Failed to load drift state: %v", err)
+		}
+	}
+
+	// Archive health and optimization reports to object storage
+	var archiver *archive.Archiver
+	if config.ArchiveConfigFile != "" {
+		archiveConfig, err := archive.LoadConfig(config.ArchiveConfigFile)
+		if err != nil {
+			log.Fatalf("Failed to load archive config: %v", err)
+		}
+		archiver = archive.NewArchiver(archiveConfig, archive.CLIUploader{Config: archiveConfig}, config.ClusterName)
+	}
+
+	// Push health, optimization and, for agents, cost reports to a central monitor,
+	// buffering them on disk while it is unreachable
+	var fleetPusher *fleet.Pusher
+	if config.Agent && config.FleetConfigFile == "" {
+		log.Fatalf("--agent needs --fleet to know where to push reports")
+	}
+	if config.FleetConfigFile != "" {
+		fleetConfig, err := fleet.LoadConfig(config.FleetConfigFile)
+		if err != nil {
+			log.Fatalf("Failed to load fleet config: %v", err)
+		}
+		registration := fleet.Registration{Cluster: config.ClusterName, Interval: config.Interval}
+		if version, err := clientset.Discovery().ServerVersion(); err == nil {
+			registration.Version = version.GitVersion
+		}
+		fleetPusher, err = fleet.NewPusher(fleetConfig, registration)
+		if err != nil {
+			log.Fatalf("Failed to open fleet buffer: %v", err)
+		}
+		if n := fleetPusher.Buffered(); n > 0 {
+			log.Printf("%d fleet reports buffered from before the restart", n)
+		}
+	}
+
+	// Aggregate the reports agents push: through the API behind its tokens, and on a port
+	// of its own where agents authenticate with client certificates. Reports drive alerts,
+	// so neither is ever open.
+	var aggregator *fleet.Aggregator
+	if config.FleetReceive || config.AggregatorPort != 0 {
+		aggregator = fleet.NewAggregator(config.FleetStaleAfter, notifier)
+		http.Handle("/fleet/clusters", guard.Protect(features.Handler(features.Dashboard, fleet.ClustersHandler{Aggregator: aggregator}), true))
+	}
+	if config.FleetReceive {
+		if guard == nil {
+			log.Fatalf("--fleet-receive needs --auth-config, so only agents with a token can register clusters and push reports; or use --aggregator-port")
+		}
+		http.Handle("/fleet/register", guard.Protect(aggregator, true))
+		http.Handle("/fleet/ingest", guard.Protect(aggregator, true))
+	}
+	if config.AggregatorPort != 0 {
+		tlsConfig, err := fleet.ServerTLS(config.AggregatorCert, config.AggregatorKey, config.AggregatorClientCA)
+		if err != nil {
+			log.Fatalf("Failed to configure the fleet aggregator: %v", err)
+		}
+		mux := http.NewServeMux()
+		mux.Handle("/fleet/register", aggregator)
+		mux.Handle("/fleet/ingest", aggregator)
+		server := &http.Server{Addr: fmt.Sprintf(":%d", config.AggregatorPort), Handler: mux, TLSConfig: tlsConfig}
+		go func() {
+			log.Printf("Starting fleet aggregator on port %d", config.AggregatorPort)
+			if err := server.ListenAndServeTLS("", ""); err != nil {
+				log.Fatalf("Failed to start fleet aggregator: %v", err)
+			}
+		}()
+	}
+
+	// Flag workloads deviating from their own baselines
+	var anomalyDetector *anomaly.Detector
+	if config.Anomaly {
+		anomalyConfig := anomaly.DefaultConfig
+		anomalyConfig.Threshold = config.AnomalyThreshold
+		anomalyDetector = anomaly.NewDetector(anomalyConfig, store, notifier, config.ClusterName)
+	}
+
+	// Flag namespace cost spikes and node count jumps against daily baselines
+	var costDetector *anomaly.CostDetector
+	if config.CostAnomaly {
+		costDetector = anomaly.NewCostDetector(anomaly.DefaultCostConfig, store, notifier, config.ClusterName)
+	}
+
+	// Track how long nodes stay in their readiness state and detect flapping
+	var nodeTracker *nodestate.Tracker
+	if config.NodeFlapTransitions > 0 {
+		nodeConfig := nodestate.DefaultConfig
+		nodeConfig.FlapTransitions = config.NodeFlapTransitions
+		nodeTracker = nodestate.NewTracker(nodeConfig, store, notifier, config.ClusterName)
+	}
+
+	// Alert when a namespace's health score regresses, attributing the drop to its new issues
+	var regressionTracker *regression.Tracker
+	if config.ScoreRegression > 0 {
+		regressionConfig := regression.Config{Drop: config.ScoreRegression, Window: config.ScoreRegressionAfter}
+		var err error
+		regressionTracker, err = regression.LoadTracker(regressionConfig, config.ScoreRegressionState, notifier, config.ClusterName)
+		if err != nil {
+			log.Fatalf("Failed to load score history: %v", err)
+		}
+	}
+
+	// Accept alerts from Alertmanager and cloud monitors, and correlate them with the
+	// issues found on the same nodes and namespaces
+	var externalAlerts *external.Receiver
+	if config.ExternalAlerts {
+		if guard == nil {
+			log.Fatalf("--external-alerts needs --auth-config, so only senders with an admin token can post alerts into incident reports")
+		}
+		externalAlerts = external.NewReceiver(config.ExternalAlertWindow)
+		http.Handle("/alerts/external", guard.Protect(externalAlerts, true))
+	}
+
+	// Probe API server latency across verbs each cycle
+	var latencyTracker *latency.Tracker
+	if config.APILatencyProbes > 0 {
+		latencyConfig := latency.DefaultConfig
+		latencyConfig.ProbesPerCycle = config.APILatencyProbes
+		latencyTracker = latency.NewTracker(latencyConfig, clientset, notifier, config.ClusterName)
+	}
+
+	// Find cloud load balancers, disks and IPs left behind by deleted services and volumes
+	var orphanScanner *orphans.Scanner
+	switch config.CloudOrphans {
+	case "":
+	case "aws":
+		orphanScanner = orphans.NewScanner(orphans.AWSSource{Cluster: config.ClusterName}, clientset, config.CloudOrphanInterval)
+	default:
+		orphanScanner = orphans.NewScanner(orphans.FileSource{Path: config.CloudOrphans}, clientset, config.CloudOrphanInterval)
+	}
+
+	// Compare steady node spend in the history with reservations and savings plans
+	var commitmentAnalyzer *commitments.Analyzer
+	switch config.Commitments {
+	case "":
+	case "aws":
+		commitmentAnalyzer = commitments.NewAnalyzer(commitments.AWSSource{}, store, config.ClusterName, config.CommitmentInterval, commitments.DefaultOptions)
+	default:
+		commitmentAnalyzer = commitments.NewAnalyzer(commitments.FileSource{Path: config.Commitments}, store, config.ClusterName, config.CommitmentInterval, commitments.DefaultOptions)
+	}
+
+	// Find finalizers left behind by uninstalled operators and deleted CRDs
+	var finalizerScanner *orphans.FinalizerScanner
+	if config.FinalizerScan > 0 {
+		resourceClient, err := resources.Shared()
+		if err != nil {
+			log.Fatalf("Failed to set up finalizer scan: %v", err)
+		}
+		finalizerScanner = orphans.NewFinalizerScanner(resourceClient, config.FinalizerScan, config.RemoveFinalizers && features.Enabled(features.Remediation))
+	}
+
+	// Track recommendations against observed workload changes
+	resourceOptimizer := optimizer.NewResourceOptimizer(clientset, metricsClient)
+	ledger, err := optimizer.LoadLedger(config.LedgerFile)
+	if err != nil {
+		log.Fatalf("Failed to load savings ledger: %v", err)
+	}
+
+	// Evaluate cleanup rules each run, previewing deletions unless --cleanup-apply is set
+	var cleanupPolicy *optimizer.CleanupPolicy
+	if config.CleanupPolicyFile != "" {
+		if cleanupPolicy, err = optimizer.LoadCleanupPolicy(config.CleanupPolicyFile); err != nil {
+			log.Fatalf("Failed to load cleanup policy: %v", err)
+		}
+	} else if config.Cleanup {
+		policy := optimizer.DefaultCleanupPolicy
+		cleanupPolicy = &policy
+	}
+
+	// Refuse to delete, remediate or export changes to protected namespaces and objects
+	if err := protection.Configure(splitList(config.ProtectedNamespaces)); err != nil {
+		log.Fatalf("Failed to load protected namespaces: %v", err)
+	}
+
+	// Back up every resource before cleanup deletes it, so `restore` can undo the deletion
+	cleanupBackups := &optimizer.CleanupBackups{Dir: config.CleanupBackupDir, Archiver: archiver}
+
+	// Delete in the background with a rate-limited worker pool, resuming an interrupted batch
+	cleanupBatch, err := optimizer.LoadCleanupBatch(config.CleanupBatchState)
+	if err != nil {
+		log.Fatalf("Failed to load cleanup batch: %v", err)
+	}
+	if cleanupPolicy != nil {
+		http.Handle("/cleanup/progress", guard.Protect(cleanupBatch, false))
+	}
+
+	// Queue cleanup candidates for approval over the API, the CLI or Slack buttons
+	var approvals *approval.Queue
+	if cleanupPolicy != nil && config.CleanupApproval {
+		if guard == nil {
+			log.Fatalf("--cleanup-approval needs --auth-config, so decisions are made and audited by authenticated callers")
+		}
+		if approvals, err = approval.LoadQueue(config.CleanupApprovalState); err != nil {
+			log.Fatalf("Failed to load cleanup approvals: %v", err)
+		}
+		http.Handle("/cleanup/", guard.Protect(approval.Handler{Queue: approvals}, false))
+		if secret := os.Getenv("SLACK_SIGNING_SECRET"); secret != "" {
+			http.Handle("/cleanup/slack", approval.SlackHandler{Queue: approvals, SigningSecret: secret})
+		}
+	}
+
+	// Export mode renders recommendations for a GitOps repository and exits
+	if config.ExportConfigFile != "" {
+		if !features.Enabled(features.Optimizer) {
+			log.Fatalf("Exporting recommendations needs the %s feature", features.Optimizer)
+		}
+		if err := exportRecommendations(clientset, resourceOptimizer, config); err != nil {
+			log.Fatalf("Failed to export recommendations: %v", err)
+		}
+		return
+	}
+
+	// Compliance mode audits the cluster against the CIS benchmark subset and exits
+	if config.Compliance {
+		if err := runCompliance(clientset, metricsClient, config.OutputFile); err != nil {
+			log.Fatalf("Failed to run compliance checks: %v", err)
+		}
+		return
+	}
+
+	// Roll up and expire old history in the background
+	if config.HistoryRetention.Raw > 0 {
+		if err := config.HistoryRetention.Validate(); err != nil {
+			log.Fatalf("Invalid history retention: %v", err)
+		}
+		go compactHistory(store, config.HistoryRetention)
+	}
+
+	// Detect issues between intervals from watch events
+	var issueWatcher *watcher.Watcher
+	if config.Watch {
+		issueWatcher = watcher.NewWatcher(clientset, notifier, config.ClusterName)
+		go func() {
+			if err := issueWatcher.Run(context.Background()); err != nil {
+				log.Printf("Issue watcher stopped: %v", err)
+			}
+		}()
+	}
 
 	// Run continuous health and cost checks
 	ticker := time.NewTicker(config.Interval)
 	defer ticker.Stop()
 
+	// Wait for the next interval, or for a check requested through the gRPC API
+	var triggered <-chan struct{}
+	if grpcServer != nil {
+		triggered = grpcServer.Triggered()
+	}
+	wait := func() {
+		select {
+		case <-ticker.C:
+		case <-triggered:
+		}
+	}
+
 	for {
+		// Skip the cycle when over a limit, and read only nodes and pods when near one
+		usage := selfGuard.Sample(time.Now())
+		updateSelfMetrics(usage)
+		if usage.Mode == selfguard.ModeAbort {
+			log.Printf("Skipping check: %s", usage.Reason)
+			wait()
+			continue
+		}
+		degraded := usage.Mode == selfguard.ModeDegraded
+		snapshot.Lean = degraded
+
+		// Read the cluster once; every check in this cycle works from the snapshot
+		snap, err := snapshot.Take(context.Background(), clientset, metricsClient)
+		if err != nil {
+			log.Printf("Failed to take cluster snapshot: %v", err)
+			wait()
+			continue
+		}
+
+		formatter.UpdateSnapshot(snap)
+
+		// Pick up namespaces newly labeled as protected before anything mutates the cluster
+		if cleanupPolicy != nil || pluginManager != nil || config.RemoveFinalizers {
+			if err := protection.Refresh(context.Background(), clientset); err != nil {
+				log.Printf("Failed to refresh protected namespaces: %v", err)
+			}
+		}
+
+		// Reload owner metadata before any issue or recommendation of this cycle is raised;
+		// lean snapshots keep the owners already loaded
+		if config.Owners && !degraded {
+			if err := owners.Refresh(context.Background(), clientset, snap); err != nil {
+				log.Printf("Failed to refresh workload owners: %v", err)
+			}
+		}
+
 		// Check cluster health
-		health := checkClusterHealth(clientset)
+		health := checkClusterHealth(snap)
+		health.Monitor = usage
+		if maintenanceSchedule != nil {
+			health.MaintenanceWindows = maintenance.Names(maintenanceSchedule.Active(time.Now(), config.ClusterName))
+		}
+		if issueWatcher != nil {
+			issueWatcher.Resync(context.Background(), snap)
+		}
+		if latencyTracker != nil {
+			health.APIServerLatency, err = latencyTracker.Record(context.Background(), time.Now())
+			if err != nil {
+				log.Printf("API latency tracking failed: %v", err)
+			}
+			for _, status := range health.APIServerLatency {
+				apiServerLatencyGauge.WithLabelValues(status.Verb, "0.5").Set(status.P50)
+				apiServerLatencyGauge.WithLabelValues(status.Verb, "0.95").Set(status.P95)
+				apiServerLatencyGauge.WithLabelValues(status.Verb, "0.99").Set(status.P99)
+			}
+		}
+
+		if orphanScanner != nil {
+			health.CloudOrphans, err = orphanScanner.Scan(context.Background(), snap, time.Now())
+			if err != nil {
+				log.Printf("Cloud orphan scan failed: %v", err)
+			}
+		}
+		if finalizerScanner != nil && !degraded {
+			health.StaleFinalizers, err = finalizerScanner.Scan(context.Background(), time.Now())
+			if err != nil {
+				log.Printf("Finalizer scan incomplete: %v", err)
+			}
+		}
+
+		if pluginManager != nil {
+			health.PluginIssues = pluginManager.Check(context.Background(), time.Now())
+		}
+
+		if driftTracker != nil && !degraded {
+			changes, err := driftTracker.Check(context.Background(), clientset, snap, time.Now())
+			if err != nil {
+				log.Printf("Configuration drift check failed: %v", err)
+			}
+			health.ConfigDrift = drift.Issues(changes)
+		}
+
+		// Record cluster and namespace usage for trends
+		var trendSeries map[string]float64
+		if config.Trends {
+			trendSeries = trends.Collect(snap)
+			if health.Efficiency != nil {
+				scores := make(map[string]int, len(health.NamespaceEfficiency))
+				for ns, e := range health.NamespaceEfficiency {
+					scores[ns] = e.Score
+				}
+				for key, value := range trends.EfficiencySeries(health.Efficiency.Score, scores) {
+					trendSeries[key] = value
+				}
+			}
+		}
+
+		// Run the detailed health check for Jira tickets, gRPC clients, plugins, the archive,
+		// the health score trends, namespace score regressions, external alerts and the fleet
+		archiveTime := time.Now()
+		archiveDue := archiver != nil && archiver.Due(archiveTime)
+		if !degraded && (jiraTracker != nil || grpcServer != nil || pluginManager != nil || archiveDue || config.Trends || regressionTracker != nil ||
+			externalAlerts != nil || fleetPusher != nil) {
+			extraIssues := append(append([]clusterhealth.HealthIssue(nil), health.PluginIssues...), health.ConfigDrift...)
+			report, err := detailedHealth(clientset, metricsClient, snap, maintenanceSchedule, config.ClusterName, extraIssues)
+			if err != nil {
+				log.Printf("Detailed health check failed: %v", err)
+			} else {
+				// Open, update and close Jira tickets for persistent issues
+				if jiraTracker != nil {
+					if err := jiraTracker.Sync(context.Background(), report.Issues, snap, time.Now()); err != nil {
+						log.Printf("Jira sync failed: %v", err)
+					}
+				}
+				if grpcServer != nil {
+					grpcServer.PublishHealth(report)
+				}
+				if pluginManager != nil {
+					if features.Enabled(features.Remediation) {
+						pluginManager.Remediate(context.Background(), report.Issues)
+					}
+					pluginManager.Publish(context.Background(), report)
+				}
+				if archiveDue {
+					archiveReport(archiver, archive.KindHealth, report, archiveTime)
+				}
+				if fleetPusher != nil {
+					pushReport(fleetPusher, archive.KindHealth, report, archiveTime)
+				}
+				if config.Trends {
+					for key, value := range trends.ScoreSeries(report.HealthScore) {
+						trendSeries[key] = value
+					}
+					for key, value := range trends.NamespaceScoreSeries(clusterhealth.NamespaceScores(report)) {
+						trendSeries[key] = value
+					}
+				}
+				if regressionTracker != nil {
+					for _, r := range regressionTracker.Check(context.Background(), report, time.Now()) {
+						log.Printf("Score regression: %s", strings.ReplaceAll(r.Summary(), "\n", " "))
+					}
+				}
+				if externalAlerts != nil {
+					health.ExternalAlerts = externalAlerts.Correlate(report.Issues, time.Now())
+				}
+			}
+		}
+
+		// Track workload availability against SLOs
+		var sloStatuses []slo.Status
+		if sloTracker != nil {
+			sloStatuses, err = sloTracker.Record(context.Background(), snap)
+			if err != nil {
+				log.Printf("SLO tracking failed: %v", err)
+			}
+		}
+
+		// Compare usage, load and health series with their baselines before recording them
+		var loadSeries map[string]float64
+		if loadSignals != nil && !degraded {
+			loadSeries, err = loadSignals.Collect(context.Background(), snap)
+			if err != nil {
+				log.Printf("Load signals incomplete: %v", err)
+			}
+		}
+		var series map[string]float64
+		if anomalyDetector != nil {
+			series = anomaly.Collect(snap)
+			for key, value := range loadSeries {
+				series[key] = value
+			}
+			health.Anomalies, err = anomalyDetector.Check(context.Background(), series, time.Now())
+			if err != nil {
+				log.Printf("Anomaly detection failed: %v", err)
+			}
+		}
+		if costDetector != nil {
+			costSeries := anomaly.CollectCost(snap, resourcePricing)
+			namespaceCosts := cost.GetNamespaceCosts(cost.PodCostsFromSnapshot(snap, resourcePricing))
+			costAnomalies, err := costDetector.Check(context.Background(), namespaceCosts, costSeries, time.Now())
+			if err != nil {
+				log.Printf("Cost anomaly detection failed: %v", err)
+			}
+			health.Anomalies = append(health.Anomalies, costAnomalies...)
+			if series == nil {
+				series = make(map[string]float64)
+			}
+			for key, value := range costSeries {
+				series[key] = value
+			}
+		}
+		var nodeStates map[string]string
+		if nodeTracker != nil {
+			nodeStates = nodestate.Collect(snap)
+			health.NodeStates, _, err = nodeTracker.Check(context.Background(), nodeStates, time.Now())
+			if err != nil {
+				log.Printf("Node state tracking failed: %v", err)
+			}
+		}
+		if len(trendSeries) > 0 {
+			if series == nil {
+				series = make(map[string]float64)
+			}
+			for key, value := range trendSeries {
+				series[key] = value
+			}
+		}
+		if offHours != nil {
+			if series == nil {
+				series = make(map[string]float64)
+			}
+			for key, value := range optimizer.OffHoursSeries(snap) {
+				series[key] = value
+			}
+		}
+		if commitmentAnalyzer != nil {
+			if series == nil {
+				series = make(map[string]float64)
+			}
+			for key, value := range commitments.Series(snap, resourcePricing) {
+				series[key] = value
+			}
+		}
+		if admissionWebhook != nil {
+			if series == nil {
+				series = make(map[string]float64)
+			}
+			for key, value := range admission.Series(snap) {
+				series[key] = value
+			}
+		}
+		if len(loadSeries) > 0 {
+			if series == nil {
+				series = make(map[string]float64)
+			}
+			for key, value := range loadSeries {
+				series[key] = value
+			}
+		}
+		whatIfHandler.Update(snap)
+		if showbackHandler != nil {
+			showbackHandler.Update(showback.Collect(snap, resourcePricing, config.ShowbackLabel), time.Now())
+		}
+		if config.EnableCostReport || anomalyDetector != nil || costDetector != nil || nodeTracker != nil || config.Trends || showbackHandler != nil || offHours != nil || commitmentAnalyzer != nil ||
+			admissionWebhook != nil || loadSignals != nil {
+			recordAllocationHistory(snap, resourcePricing, store, config.ClusterName, labelKeys, series, nodeStates)
+		}
+		if admissionWebhook != nil {
+			if err := admissionWebhook.Refresh(context.Background(), time.Now()); err != nil {
+				log.Printf("Admission peaks not refreshed: %v", err)
+			}
+		}
+
+		// Generate cost report if enabled
+		var costReport *CostReport
+		if config.EnableCostReport {
+			costReport = generateCostReport(snap, pricingData)
+			if commitmentAnalyzer != nil {
+				report, err := commitmentAnalyzer.Analyze(context.Background(), time.Now())
+				if err != nil {
+					log.Printf("Commitment coverage analysis incomplete: %v", err)
+				}
+				costReport.Commitments = &report
+			}
+			if !degraded {
+				transfer, err := cost.CrossZoneTransfer(context.Background(), clientset, snap, config.PrometheusURL)
+				if err != nil {
+					log.Printf("Cross-zone transfer estimate incomplete: %v", err)
+				}
+				costReport.Transfer = transfer
+			}
+			if armResolver != nil && !degraded {
+				options := multiarch.DefaultOptions
+				options.Discount = config.ARMDiscount
+				costReport.ARM = multiarch.Analyze(context.Background(), armResolver, snap, resourcePricing, options)
+			}
+
+			// Check budgets against allocation history
+			if budgetMonitor != nil {
+				if _, err := budgetMonitor.Check(context.Background(), time.Now()); err != nil {
+					log.Printf("Budget check failed: %v", err)
+				}
+			}
+		}
+
+		// Record optimizer recommendations and measure realized savings; lean snapshots have
+		// no metrics or workloads to recommend from
+		var optimizationReport *optimizer.OptimizationReport
+		if !degraded && features.Enabled(features.Optimizer) {
+			var loads map[string][]optimizer.WorkloadLoad
+			if loadSignals != nil {
+				if loads, err = loadSignals.Loads(context.Background(), loadSeries, time.Now()); err != nil {
+					log.Printf("Load signal peaks not read: %v", err)
+				}
+			}
+			optimizationReport = trackSavings(snap, ledger, store, config.ClusterName, offHours, loads)
+		}
+		if optimizationReport != nil {
+			if config.KEDA {
+				suggestEventScaling(snap, optimizationReport, offHours, config.KEDAManifestDir)
+			}
+			if config.PrometheusURL != "" {
+				suggestStorage(clientset, optimizationReport, config)
+			}
+			if costReport != nil && costReport.ARM != nil {
+				optimizationReport.Add(costReport.ARM.Recommendations()...)
+			}
+			if config.Owners {
+				optimizationReport.AttachOwners()
+			}
+			if grpcServer != nil {
+				grpcServer.PublishReport(optimizationReport)
+			}
+			if archiveDue {
+				archiveReport(archiver, archive.KindOptimization, optimizationReport, archiveTime)
+			}
+			if fleetPusher != nil {
+				pushReport(fleetPusher, archive.KindOptimization, optimizationReport, archiveTime)
+			}
+		}
+
+		// Delete, or preview deleting, what the cleanup rules select; never from a lean
+		// snapshot, which would make every workload look unused
+		if cleanupPolicy != nil && !degraded && features.Enabled(features.Cleanup) {
+			runCleanup(clientset, snap, cleanupPolicy, config.CleanupApply, approvals, cleanupBackups, cleanupBatch, config)
+		}
+
+		// Agents push the cost report too, so the aggregator can serve it
+		if config.Agent && costReport != nil {
+			pushReport(fleetPusher, fleet.KindCost, costReport, archiveTime)
+		}
+
+		// Alert on agents that stopped reporting
+		if aggregator != nil {
+			updateFleetMetrics(aggregator.Check(context.Background(), time.Now()))
+		}
+
+		// Update Prometheus metrics
+		updateMetrics(snap)
+
+		// Output results
+		if config.OutputFile != "" {
+			outputResults(config.OutputFile, health, costReport, sloStatuses)
+		}
+
+		// Print summary to stdout; agents leave reading reports to the aggregator
+		if !config.Agent {
+			printSummary(health, costReport, sloStatuses)
+		}
+
+		// Wait for next interval
+		wait()
+	}
+}
+
+func parseFlags() *Config {
+	config := &Config{}
+
+	// Default to $HOME/.kube/config for kubeconfig path
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		log.Fatalf("Failed to get user home directory: %v", err)
+	}
+	defaultKubeConfig := filepath.Join(homeDir, ".kube", "config")
+
+	flag.StringVar(&config.KubeConfigPath, "kubeconfig", defaultKubeConfig, "Path to kubeconfig file")
+	flag.BoolVar(&config.Protobuf, "protobuf", true, "Request built-in resources from the API server as protobuf, falling back to JSON where it is not served")
+	flag.DurationVar(&config.Interval, "interval", 60*time.Second, "Check interval in seconds")
+	flag.IntVar(&config.MetricsPort, "metrics-port", 8080, "Prometheus metrics port")
+	flag.StringVar(&config.FeatureGates, "feature-gates", "", "Comma-separated name=bool pairs switching subsystems off or on, e.g. optimizer=false,cleanup=false; see /status for the gates")
+	flag.StringVar(&config.OutputFile, "output", "", "Output file for health and cost reports")
+	flag.BoolVar(&config.EnableCostReport, "cost", true, "Enable cost reporting")
+	flag.StringVar(&config.PricingDataFile, "pricing", "pricing.json", "Pricing data file")
+	flag.StringVar(&config.ClusterName, "cluster-name", "cluster-one", "Cluster name reported in cost allocations")
+	flag.StringVar(&config.HistoryDir, "history-dir", "", "Directory for allocation history (in-memory if empty)")
+	flag.DurationVar(&config.HistoryRetention.Raw, "history-raw-retention", history.DefaultRetention.Raw, "Age at which history snapshots are merged into hourly rollups (0 keeps all history)")
+	flag.DurationVar(&config.HistoryRetention.Hourly, "history-hourly-retention", history.DefaultRetention.Hourly, "Age at which hourly history rollups are merged into daily rollups")
+	flag.DurationVar(&config.HistoryRetention.Daily, "history-daily-retention", history.DefaultRetention.Daily, "Age at which daily history rollups are removed")
+	flag.BoolVar(&config.Trends, "trends", false, "Record cluster and namespace usage, cost and health score in the history each cycle for /trends")
+	flag.BoolVar(&config.Showback, "showback", false, "Serve each team's burn rate, trend, top workloads and waste at /showback and /showback/dashboard")
+	flag.StringVar(&config.ShowbackLabel, "showback-label", showback.DefaultLabelKey, "Pod label naming a workload's team for --showback")
+	flag.StringVar(&config.HistoryPostgres, "history-postgres", "", "PostgreSQL URL for allocation history shared by replicas, e.g. postgres://user@host/db (password from PGPASSWORD); overrides --history-dir")
+	flag.StringVar(&config.BudgetConfigFile, "budgets", "", "Budget definitions file")
+	flag.StringVar(&config.WebhookURL, "webhook-url", "", "Webhook URL for alerts")
+	flag.StringVar(&config.SlackWebhookURL, "slack-webhook-url", "", "Slack incoming webhook URL for alerts")
+	flag.StringVar(&config.TeamsWebhookURL, "teams-webhook-url", "", "Microsoft Teams webhook URL for alerts")
+	flag.StringVar(&config.DiscordWebhookURL, "discord-webhook-url", "", "Discord webhook URL for alerts")
+	flag.StringVar(&config.MattermostWebhookURL, "mattermost-webhook-url", "", "Mattermost incoming webhook URL for alerts")
+	flag.BoolVar(&config.Opsgenie, "opsgenie", false, "Send alerts to Opsgenie using the OPSGENIE_API_KEY environment variable")
+	flag.StringVar(&config.OpsgenieAPIURL, "opsgenie-api-url", notify.DefaultOpsgenieAPIURL, "Opsgenie API URL (https://api.eu.opsgenie.com for EU accounts)")
+	flag.StringVar(&config.SplunkOnCallRouting, "splunk-oncall-routing-key", "", "Splunk On-Call routing key; sends alerts using the SPLUNK_ONCALL_API_KEY environment variable")
+	flag.StringVar(&config.SMTPAddr, "smtp-addr", "", "SMTP server host:port for email alerts; authenticates with SMTP_USERNAME and SMTP_PASSWORD if set")
+	flag.StringVar(&config.EmailFrom, "email-from", "", "Sender address for email alerts")
+	flag.StringVar(&config.EmailTo, "email-to", "", "Comma-separated recipients for email alerts")
+	flag.StringVar(&config.AlertTemplates, "alert-templates", "", "JSON file with title and text templates for chat alerts")
+	flag.StringVar(&config.AlertmanagerURL, "alertmanager-url", "", "Comma-separated Alertmanager URLs to push firing and resolved alerts to through its v2 API, e.g. http://alertmanager:9093")
+	flag.StringVar(&config.CheckTTLs, "check-ttl", "", "Comma-separated section=duration pairs for detailed health sections that reuse their results until they are older, e.g. helm=15m,backups=1h")
+	flag.BoolVar(&config.ExternalAlerts, "external-alerts", false, "Accept alerts from Alertmanager webhooks and other systems at /alerts/external and correlate them with detected issues")
+	flag.DurationVar(&config.ExternalAlertWindow, "external-alert-window", external.DefaultWindow, "How long before or after a check an external alert must be active to be correlated with its issues")
+	flag.StringVar(&config.AlertRoutes, "alert-routes", "", "JSON file routing alerts to receivers by namespace, severity, source, team and labels, with per-route rate limits")
+	flag.StringVar(&config.LedgerFile, "savings-ledger", "", "File for the recommendation savings ledger (in-memory if empty)")
+	flag.StringVar(&config.ExportConfigFile, "export-config", "", "Export right-sizing recommendations using this path mapping file and exit")
+	flag.StringVar(&config.ExportDir, "export-dir", ".", "Local repository directory to write exported recommendations to")
+	flag.StringVar(&config.ExportPRProvider, "export-pr-provider", "", "Open a pull request with exported recommendations (github, gitlab); token is read from EXPORT_GIT_TOKEN")
+	flag.StringVar(&config.ExportPRRepo, "export-pr-repo", "", "Repository for export pull requests (owner/name or GitLab project)")
+	flag.StringVar(&config.ExportPRBase, "export-pr-base", "main", "Base branch for export pull requests")
+	flag.StringVar(&config.MaintenanceFile, "maintenance", "", "Maintenance window schedule file; alerts are silenced during windows")
+	flag.StringVar(&config.SLOConfigFile, "slos", "", "SLO definitions file")
+	flag.StringVar(&config.SLOStateFile, "slo-state", "", "File for SLO availability samples (in-memory if empty)")
+	flag.StringVar(&config.RunbookFile, "runbooks", "", "Runbook mapping file keyed by issue type; unmapped types use the built-in runbooks")
+	flag.StringVar(&config.VersionDenylistFile, "version-denylist", "", "File of kubelet, runtime, OS image and kernel versions with known critical bugs")
+	flag.BoolVar(&config.GitOps, "gitops", false, "Report out-of-sync, failed, degraded and suspended Argo CD Applications and Flux Kustomizations")
+	flag.BoolVar(&config.Helm, "helm", false, "Report failed, stuck and orphaned Helm releases from their release Secrets; needs deployment/helm-clusterrole.yaml")
+	flag.BoolVar(&config.Pipelines, "pipelines", false, "Report failing, stuck and pending Argo Workflows and Tekton PipelineRuns, and completed runs to clean up")
+	flag.BoolVar(&config.DataServices, "data-services", false, "Report the health, failovers, replication lag and backup freshness of CloudNativePG, Percona XtraDB and Redis clusters")
+	flag.StringVar(&config.BackupTargetsFile, "backup-targets", "", "File of namespaces that must have recent Velero backups, with their recovery-point objectives")
+	flag.StringVar(&config.PrometheusURL, "prometheus-url", "", "Prometheus base URL for reading CPU throttling from cAdvisor metrics and volume usage")
+	flag.StringVar(&config.StorageIOPSQuery, "storage-iops-query", "", "PromQL giving each claim's peak IOPS by namespace and persistentvolumeclaim over the window %s; with --storage-throughput-query, recommends cheaper storage classes")
+	flag.StringVar(&config.StorageThroughput, "storage-throughput-query", "", "PromQL giving each claim's peak bytes per second by namespace and persistentvolumeclaim over the window %s")
+	flag.StringVar(&config.ProductionNamespaces, "production-namespaces", strings.Join(clusterhealth.ProductionNamespaces, ","), "Comma-separated patterns of production namespaces, where BestEffort workloads are reported")
+	flag.Float64Var(&config.LimitRatio, "limit-ratio", clusterhealth.LimitRequestRatioWarn, "Limit-to-request ratio at which containers are reported as bursting risks")
+	flag.BoolVar(&config.OffHours, "off-hours", false, "Record workload CPU usage and recommend scaling workloads busy only in business hours to zero outside them")
+	flag.StringVar(&config.BusinessHours, "business-hours", "Mon-Fri 08:00-19:00", "Business days and hours for --off-hours")
+	flag.StringVar(&config.BusinessTimezone, "business-timezone", "UTC", "IANA time zone of --business-hours")
+	flag.BoolVar(&config.KEDA, "keda", false, "Suggest KEDA ScaledObjects for queue consumers and, with --off-hours, cron schedules")
+	flag.StringVar(&config.KEDAManifestDir, "keda-manifests", "", "Write suggested KEDA ScaledObject manifests below this directory")
+	flag.BoolVar(&config.ARM, "arm", false, "Check workload images for linux/arm64 builds and estimate the savings of moving them to ARM nodes")
+	flag.Float64Var(&config.ARMDiscount, "arm-discount", multiarch.DefaultOptions.Discount, "How much cheaper ARM nodes are than the x86 nodes they replace, 0-1")
+	flag.BoolVar(&config.Cleanup, "cleanup", false, "Preview deleting finished pods older than 7 days and unreferenced ConfigMaps each run")
+	flag.StringVar(&config.CleanupPolicyFile, "cleanup-policy", "", "Cleanup rules file with per-kind, per-namespace TTLs; implies --cleanup")
+	flag.BoolVar(&config.CleanupApply, "cleanup-apply", false, "Delete what the cleanup rules select instead of only previewing it")
+	flag.BoolVar(&config.CleanupApproval, "cleanup-approval", false, "Queue what the cleanup rules select for approval and delete only approved resources")
+	flag.StringVar(&config.CleanupApprovalState, "cleanup-approval-state", "", "File for the cleanup approval queue and audit trail (in-memory if empty)")
+	flag.IntVar(&config.CleanupConcurrency, "cleanup-concurrency", optimizer.DefaultCleanupBatchOptions.Concurrency, "Cleanup deletions run in parallel")
+	flag.Float64Var(&config.CleanupQPS, "cleanup-qps", optimizer.DefaultCleanupBatchOptions.QPS, "Cleanup deletions started per second (0 for no limit)")
+	flag.StringVar(&config.CleanupBatchState, "cleanup-batch-state", "", "File cleanup checkpoints deletion progress to, so an interrupted batch resumes after a restart (in-memory if empty)")
+	flag.BoolVar(&config.Owners, "owners", true, "Resolve the top-level controller and owning team of each issue and recommendation, and label alerts with them")
+	flag.StringVar(&config.SidecarNames, "sidecar-containers", strings.Join(snapshot.SidecarNames, ","), "Comma-separated names of containers meshes and agents inject, reported as sidecars when they run next to others")
+	flag.StringVar(&config.OwnerTeamKeys, "owner-team-keys", strings.Join(owners.DefaultKeys.Team, ","), "Comma-separated annotation and label keys naming a workload's team, in order of precedence")
+	flag.StringVar(&config.OwnerSlackKeys, "owner-slack-keys", strings.Join(owners.DefaultKeys.Slack, ","), "Comma-separated annotation and label keys naming a workload's Slack channel")
+	flag.StringVar(&config.OwnerEmailKeys, "owner-email-keys", strings.Join(owners.DefaultKeys.Email, ","), "Comma-separated annotation and label keys naming a workload's owner email")
+	flag.StringVar(&config.ProtectedNamespaces, "protected-namespaces", "", "Comma-separated patterns of namespaces cleanup, remediation and export never change, in addition to kube-system and namespaces labeled hc-monitor/protected=true")
+	flag.StringVar(&config.CleanupBackupDir, "cleanup-backup-dir", "cleanup-backups", "Directory cleanup saves the manifest of each resource to before deleting it; also archived with --archive")
+	flag.DurationVar(&config.IdleNamespaceAfter, "idle-namespace-after", clusterhealth.IdleNamespaceAfter, "How long a namespace must go without running pods, rollouts and endpoint changes to be reported as idle (0 disables)")
+	flag.StringVar(&config.RulesFile, "rules", "", "File of custom health and compliance rules written in CEL")
+	flag.StringVar(&config.DaemonSetsFile, "critical-daemonsets", "", "File of DaemonSets that must run on every eligible node; replaces the built-in CNI, kube-proxy and agent list")
+	flag.StringVar(&config.JiraConfigFile, "jira", "", "Jira config file; opens tickets for persistent issues using JIRA_EMAIL and JIRA_API_TOKEN")
+	flag.StringVar(&config.JiraStateFile, "jira-state", "", "File for tracked Jira tickets (in-memory if empty)")
+	flag.BoolVar(&config.Drift, "drift", false, "Report changes to node pools, admission webhooks, RBAC, storage classes and CRDs between checks")
+	flag.StringVar(&config.DriftStateFile, "drift-state", "", "File for the configuration inventory compared between checks (in-memory if empty)")
+	flag.StringVar(&config.ArchiveConfigFile, "archive", "", "Object storage config for archiving compressed health and optimization reports to S3, GCS or Azure Blob")
+	flag.StringVar(&config.FleetConfigFile, "fleet", "", "Fleet config for pushing health and optimization reports to a central monitor, buffered on disk while it is unreachable")
+	flag.BoolVar(&config.FleetReceive, "fleet-receive", false, "Aggregate reports agents push to /fleet/register and /fleet/ingest behind the API's tokens, and serve them at /fleet/clusters")
+	flag.BoolVar(&config.Agent, "agent", false, "Run as a lightweight agent: push reports, including the cost report, to the aggregator of --fleet instead of printing summaries")
+	flag.IntVar(&config.AggregatorPort, "aggregator-port", 0, "HTTPS port on which agents register and push reports with client certificates; disabled if 0")
+	flag.StringVar(&config.AggregatorCert, "aggregator-tls-cert", "/etc/ochestra/aggregator/tls.crt", "TLS certificate the fleet aggregator serves")
+	flag.StringVar(&config.AggregatorKey, "aggregator-tls-key", "/etc/ochestra/aggregator/tls.key", "TLS private key of the fleet aggregator")
+	flag.StringVar(&config.AggregatorClientCA, "aggregator-client-ca", "/etc/ochestra/aggregator/ca.crt", "CA bundle agent client certificates must be signed by; an agent may only report as the cluster its certificate names")
+	flag.DurationVar(&config.FleetStaleAfter, "fleet-stale-after", 0, "How long an agent may go unheard before its cluster is reported stale (0 allows three of its check intervals)")
+	flag.BoolVar(&config.Anomaly, "anomaly", false, "Detect restart spikes, usage cliffs, readiness drops and API latency jumps against baselines in the history store")
+	flag.Float64Var(&config.AnomalyThreshold, "anomaly-threshold", anomaly.DefaultConfig.Threshold, "Z-score at which a deviation from baseline is reported")
+	flag.BoolVar(&config.CostAnomaly, "cost-anomaly", false, "Detect namespace cost spikes and node count jumps against daily baselines in the history store, naming likely culprits")
+	flag.IntVar(&config.APILatencyProbes, "api-latency-probes", latency.DefaultConfig.ProbesPerCycle, "API server get, list and watch probes per cycle for latency percentiles (0 disables)")
+	flag.IntVar(&clusterhealth.APIProbes, "apiserver-probes", clusterhealth.DefaultAPIProbes, "Rounds of GET /readyz and /version that time the API server in the control plane check")
+	flag.StringVar(&config.PluginsFile, "plugins", "", "JSON file of external check, sink and remediation plugins")
+	flag.IntVar(&config.GRPCPort, "grpc-port", 0, "Port for the gRPC API (api/proto/health.proto); disabled if 0")
+	flag.StringVar(&config.GRPCCert, "grpc-tls-cert", "", "TLS certificate the gRPC API serves; plaintext HTTP/2 if empty")
+	flag.StringVar(&config.GRPCKey, "grpc-tls-key", "", "TLS private key of the gRPC API")
+	flag.IntVar(&config.AdmissionPort, "admission-port", 0, "HTTPS port for the admission webhook that warns on or denies wasteful workloads; disabled if 0")
+	flag.StringVar(&config.AdmissionCert, "admission-tls-cert", "/etc/ochestra/webhook/tls.crt", "TLS certificate the admission webhook serves")
+	flag.StringVar(&config.AdmissionKey, "admission-tls-key", "/etc/ochestra/webhook/tls.key", "TLS private key of the admission webhook")
+	flag.StringVar(&config.AdmissionPolicy, "admission-policy", "", "JSON file of admission rules, their actions and oversize thresholds (warn on every rule if empty)")
+	flag.StringVar(&config.LoadSignals, "load-signals", "", "JSON file of per-workload load signals (Prometheus queries, custom or external metrics) that right-sizing and anomaly detection take into account")
+	flag.StringVar(&config.AuthConfigFile, "auth-config", "", "Auth config file with API tokens, admin groups and team namespaces; the API is open if empty")
+	flag.StringVar(&config.CloudOrphans, "cloud-orphans", "", "Find cloud resources tagged for the cluster that nothing references: \"aws\" to list them with the AWS CLI, or a JSON inventory file")
+	flag.DurationVar(&config.CloudOrphanInterval, "cloud-orphans-interval", 6*time.Hour, "Minimum time between cloud orphan scans")
+	flag.StringVar(&config.Commitments, "commitments", "", "Compare steady node spend with reservations and savings plans: \"aws\" to list them with the AWS CLI, or a JSON file of commitments")
+	flag.DurationVar(&config.CommitmentInterval, "commitments-interval", 24*time.Hour, "Minimum time between listings of reservations and savings plans")
+	flag.DurationVar(&config.FinalizerScan, "finalizer-scan-interval", 6*time.Hour, "Minimum time between scans for finalizers whose controller is gone (0 disables)")
+	flag.BoolVar(&config.RemoveFinalizers, "remove-stale-finalizers", false, "Remove finalizers of API groups no longer served from objects whose deletion they have blocked for an hour")
+	flag.IntVar(&config.NodeFlapTransitions, "node-flap-transitions", nodestate.DefaultConfig.FlapTransitions, "Readiness changes per hour at which a node is reported as flapping (0 disables node state tracking)")
+	flag.IntVar(&config.ScoreRegression, "score-regression", 0, "Alert when a namespace's health score falls this many points within --score-regression-window, naming the new issues behind it (0 disables)")
+	flag.DurationVar(&config.ScoreRegressionAfter, "score-regression-window", regression.DefaultConfig.Window, "Window in which a namespace's best health score is compared with its current one")
+	flag.StringVar(&config.ScoreRegressionState, "score-regression-state", "", "File for recent namespace health scores (in-memory if empty)")
+	flag.BoolVar(&config.Watch, "watch", false, "Watch pods, nodes and events to detect issues between check intervals")
+	flag.BoolVar(&config.Compliance, "compliance", false, "Audit the cluster against the API-checkable subset of the CIS Kubernetes Benchmark, print the per-control report and exit")
+	flag.IntVar(&snapshot.LargeCluster.NodeThreshold, "large-cluster-nodes", snapshot.LargeCluster.NodeThreshold, "Node count above which large cluster mode is used")
+	flag.IntVar(&snapshot.LargeCluster.PodThreshold, "large-cluster-pods", snapshot.LargeCluster.PodThreshold, "Pod count above which large cluster mode is used")
+	flag.Int64Var(&snapshot.LargeCluster.PageSize, "large-cluster-page-size", snapshot.LargeCluster.PageSize, "List page size in large cluster mode")
+	flag.IntVar(&snapshot.LargeCluster.Parallelism, "large-cluster-parallelism", snapshot.LargeCluster.Parallelism, "Concurrent list calls in large cluster mode")
+	flag.BoolVar(&config.PProf, "pprof", false, "Serve runtime profiles at /debug/pprof/ to admins")
+	flag.StringVar(&config.MemoryLimit, "memory-limit", "", "Memory the monitor may use, e.g. 512Mi; near it only nodes and pods are read, over it checks are skipped")
+	flag.Float64Var(&config.CPULimit, "cpu-limit", 0, "CPU cores the monitor may use on average between checks; near it only nodes and pods are read, over it checks are skipped")
+	flag.Float64Var(&config.SelfDegradeAt, "self-degrade-at", selfguard.DefaultDegradeAt, "Fraction of --memory-limit or --cpu-limit at which the monitor switches to lean scans")
+	flag.StringVar(&config.HeapProfileDir, "heap-profile-dir", "", "Directory to write a heap profile to whenever the monitor nears or exceeds its limits")
+	flag.IntVar(&clusterhealth.NamespaceWorkers, "namespace-workers", clusterhealth.DefaultNamespaceWorkers, "Namespaces evaluated concurrently by the detailed health check")
+
+	flag.Parse()
+	return config
+}
+
+// updateSelfMetrics exports the monitor's own resource usage
+func updateSelfMetrics(usage selfguard.Usage) {
+	selfUsageGauge.WithLabelValues("memory_bytes").Set(float64(usage.MemoryBytes))
+	selfUsageGauge.WithLabelValues("heap_bytes").Set(float64(usage.HeapBytes))
+	selfUsageGauge.WithLabelValues("cpu_cores").Set(usage.CPUCores)
+	selfUsageGauge.WithLabelValues("goroutines").Set(float64(usage.Goroutines))
+	mode := 0.0
+	switch usage.Mode {
+	case selfguard.ModeDegraded:
+		mode = 1
+	case selfguard.ModeAbort:
+		mode = 2
+	}
+	selfUsageGauge.WithLabelValues("mode").Set(mode)
+}
+
+func updateFleetMetrics(agents []fleet.Agent) {
+	for _, agent := range agents {
+		fleetAgentGauge.WithLabelValues(agent.Cluster, "last_seen_seconds").Set(time.Since(agent.LastSeen).Seconds())
+		stale := 0.0
+		if agent.Stale {
+			stale = 1
+		}
+		fleetAgentGauge.WithLabelValues(agent.Cluster, "stale").Set(stale)
+	}
+}
+
+func startMetricsServer(port int, guard *auth.Guard) {
+	// Metrics cover every namespace, so only admins may scrape them
+	http.Handle("/metrics", guard.Protect(promhttp.Handler(), true))
+	go func() {
+		log.Printf("Starting metrics server on port %d", port)
+		if err := http.ListenAndServe(fmt.Sprintf(":%d", port), nil); err != nil {
+			log.Fatalf("Failed to start metrics server: %v", err)
+		}
+	}()
+}
+
+func loadPricingData(filename string) *PricingData {
+	if _, err := os.Stat(filename); os.IsNotExist(err) {
+		// Create default pricing data if file doesn't exist
+		pricingData := &PricingData{
+			Nodes: map[string]NodePricing{
+				"default": {
+					CPUCostPerHour:     0.03,
+					MemoryCostPerGBHr:  0.004,
+					StorageCostPerGBHr: 0.00012,
+					RegionMultiplier:   1.0,
+				},
+				"highcpu": {
+					CPUCostPerHour:     0.05,
+					MemoryCostPerGBHr:  0.003,
+					StorageCostPerGBHr: 0.00015,
+					RegionMultiplier:   1.0,
+				},
+				"highmem": {
+					CPUCostPerHour:     0.02,
+					MemoryCostPerGBHr:  0.006,
+					StorageCostPerGBHr: 0.0001,
+					RegionMultiplier:   1.0,
+				},
+				cost.VirtualPricing: { // per pod on virtual nodes, at Fargate's rates
+					CPUCostPerHour:    0.04048,
+					MemoryCostPerGBHr: 0.004445,
+					RegionMultiplier:  1.0,
+				},
+			},
+		}
+
+		// Write default pricing data to file
+		data, err := json.MarshalIndent(pricingData, "", "  ")
+		if err != nil {
+			log.Fatalf("Failed to marshal pricing data: %v", err)
+		}
+
+		if err := os.WriteFile(filename, data, 0644); err != nil {
+			log.Fatalf("Failed to write pricing data: %v", err)
+		}
+
+		log.Printf("Created default pricing data file: %s", filename)
+		return pricingData
+	}
+
+	// Load pricing data from file
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		log.Fatalf("Failed to read pricing data: %v", err)
+	}
+
+	var pricingData PricingData
+	if err := json.Unmarshal(data, &pricingData); err != nil {
+		log.Fatalf("Failed to parse pricing data: %v", err)
+	}
+
+	return &pricingData
+}
+
+// toResourcePricing converts node pricing into the format expected by the cost package
+func toResourcePricing(pricingData *PricingData) map[string]cost.ResourcePricing {
+	pricing := make(map[string]cost.ResourcePricing, len(pricingData.Nodes))
+	for nodeType, p := range pricingData.Nodes {
+		multiplier := p.RegionMultiplier
+		if multiplier == 0 {
+			multiplier = 1.0
+		}
+		pricing[nodeType] = cost.ResourcePricing{
+			CPU:     p.CPUCostPerHour * multiplier,
+			Memory:  p.MemoryCostPerGBHr * multiplier,
+			Storage: p.StorageCostPerGBHr * multiplier,
+		}
+	}
+	return pricing
+}
+
+// detailedHealth runs the detailed health check on the snapshot, adds the issues of
+// plugin checks and configuration drift and marks issues raised inside maintenance
+// windows as suppressed
+func detailedHealth(clientset *kubernetes.Clientset, metricsClient *versioned.Clientset, snap *snapshot.ClusterSnapshot,
+	schedule *maintenance.Schedule, cluster string, extraIssues []clusterhealth.HealthIssue) (*clusterhealth.ClusterHealth, error) {
+	report, err := clusterhealth.GetClusterHealthFromSnapshot(context.Background(), clientset, metricsClient, snap)
+	if err != nil {
+		return nil, err
+	}
+	report.Issues = append(report.Issues, extraIssues...)
+	clusterhealth.AttachOwners(report.Issues)
+
+	evaluation := report.NamespaceEvaluation
+	namespaceEvaluationGauge.WithLabelValues("total").Set(evaluation.Duration.Seconds())
+	if len(evaluation.Slowest) > 0 {
+		namespaceEvaluationGauge.WithLabelValues("slowest").Set(evaluation.Slowest[0].Duration.Seconds())
+	}
+	namespaceWorkersGauge.WithLabelValues("namespaces").Set(float64(evaluation.Namespaces))
+	namespaceWorkersGauge.WithLabelValues("workers").Set(float64(evaluation.Workers))
+	if schedule != nil {
+		now := time.Now()
+		report.ApplyMaintenance(maintenance.Names(schedule.Active(now, cluster)), func(namespace string) bool {
+			return schedule.InWindow(now, cluster, namespace)
+		})
+	}
+	return report, nil
+}
+
+// splitList splits a comma-separated flag value, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// buildFormatter loads the alert templates and gives them the cluster's name and version
+func buildFormatter(clientset *kubernetes.Clientset, config *Config) *notify.Formatter {
+	formatter := notify.DefaultFormatter
+	if config.AlertTemplates != "" {
+		var err error
+		if formatter, err = notify.LoadFormatter(config.AlertTemplates); err != nil {
+			log.Fatalf("Failed to load alert templates: %v", err)
+		}
+	}
+
+	info := notify.ClusterInfo{Name: config.ClusterName}
+	if version, err := clientset.Discovery().ServerVersion(); err != nil {
+		log.Printf("Failed to get server version: %v", err)
+	} else {
+		info.Version = version.GitVersion
+	}
+	formatter.SetCluster(info)
+	return formatter
+}
+
+// buildNotifier creates the alert notifiers enabled by configuration
+func buildNotifier(config *Config, formatter *notify.Formatter) notify.Notifier {
+	notifiers := notify.MultiNotifier{notify.LogNotifier{}}
+	if config.WebhookURL != "" {
+		notifiers = append(notifiers, notify.NewWebhookNotifier(config.WebhookURL, formatter))
+	}
+	if config.SlackWebhookURL != "" {
+		notifiers = append(notifiers, notify.NewSlackNotifier(config.SlackWebhookURL, formatter))
+	}
+	if config.TeamsWebhookURL != "" {
+		notifiers = append(notifiers, notify.NewTeamsNotifier(config.TeamsWebhookURL, formatter))
+	}
+	if config.DiscordWebhookURL != "" {
+		notifiers = append(notifiers, notify.NewDiscordNotifier(config.DiscordWebhookURL, formatter))
+	}
+	if config.MattermostWebhookURL != "" {
+		notifiers = append(notifiers, notify.NewMattermostNotifier(config.MattermostWebhookURL, formatter))
+	}
+	var email *notify.EmailNotifier
+	if config.SMTPAddr != "" {
+		// Routes may send email to their own recipients, so --email-to is optional with them
+		if config.EmailFrom == "" || (config.EmailTo == "" && config.AlertRoutes == "") {
+			log.Fatalf("--email-from and --email-to must be set to send email alerts")
+		}
+		email = notify.NewEmailNotifier(config.SMTPAddr, config.EmailFrom, splitList(config.EmailTo),
+			os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"), formatter)
+		if len(email.To) > 0 {
+			notifiers = append(notifiers, email)
+		}
+	}
+	if config.Opsgenie {
+		apiKey := os.Getenv("OPSGENIE_API_KEY")
+		if apiKey == "" {
+			log.Fatalf("OPSGENIE_API_KEY must be set to send alerts to Opsgenie")
+		}
+		notifiers = append(notifiers, notify.NewOpsgenieNotifier(config.OpsgenieAPIURL, apiKey))
+	}
+	if config.SplunkOnCallRouting != "" {
+		apiKey := os.Getenv("SPLUNK_ONCALL_API_KEY")
+		if apiKey == "" {
+			log.Fatalf("SPLUNK_ONCALL_API_KEY must be set to send alerts to Splunk On-Call")
+		}
+		notifiers = append(notifiers, notify.NewSplunkOnCallNotifier(apiKey, config.SplunkOnCallRouting))
+	}
+
+	// Route alerts to team receivers, with the channels above as the default receiver.
+	// Every alert is still logged.
+	if config.AlertRoutes != "" {
+		routes, err := notify.LoadRoutes(config.AlertRoutes)
+		if err != nil {
+			log.Fatalf("Failed to load alert routes: %v", err)
+		}
+		router, err := notify.NewRouter(routes, notifiers[1:], formatter, email)
+		if err != nil {
+			log.Fatalf("Failed to load alert routes: %v", err)
+		}
+		notifiers = notify.MultiNotifier{notify.LogNotifier{}, router}
+	}
+
+	// Alertmanager gets every alert and applies its own routes and silences
+	if config.AlertmanagerURL != "" {
+		alertmanager := notify.NewAlertmanagerNotifier(splitList(config.AlertmanagerURL))
+		go alertmanager.Run(context.Background(), notify.DefaultAlertmanagerResend)
+		notifiers = append(notifiers, alertmanager)
+	}
+	return notifiers
+}
+
+// openHistoryStore opens a PostgreSQL or file-backed history store, or an in-memory one if
+// neither is set
+func openHistoryStore(dir, postgresURL string) history.RetentionStore {
+	if postgresURL != "" {
+		store, err := history.NewPostgresStore(context.Background(), postgresURL)
+		if err != nil {
+			log.Fatalf("Failed to open history store: %v", err)
+		}
+		return store
+	}
+	if dir == "" {
+		return history.NewMemoryStore()
+	}
+	store, err := history.NewFileStore(dir)
+	if err != nil {
+		log.Fatalf("Failed to open history store: %v", err)
+	}
+	return store
+}
+
+// compactHistory rolls up and expires history every CompactionInterval
+func compactHistory(store history.RetentionStore, policy history.RetentionPolicy) {
+	for {
+		result, err := history.Compact(context.Background(), store, policy, time.Now())
+		if err != nil {
+			log.Printf("History compaction failed: %v", err)
+		} else if result.RolledUp > 0 || result.Deleted > 0 {
+			log.Printf("Compacted history: %d rollups written, %d snapshots removed", result.RolledUp, result.Deleted)
+		}
+		time.Sleep(history.CompactionInterval)
+	}
+}
+
+// recordAllocationHistory saves the current namespace and label cost rates, the anomaly
+// and trend series and node states if any, to the history store
+func recordAllocationHistory(snap *snapshot.ClusterSnapshot, pricing map[string]cost.ResourcePricing, store history.Store, cluster string,
+	labelKeys []string, series map[string]float64, nodeStates map[string]string) {
+	ctx := context.Background()
+
+	podCosts := cost.PodCostsFromSnapshot(snap, pricing)
+
+	now := time.Now()
+	historySnapshot := &history.Snapshot{
+		ID:             history.NewSnapshotID(cluster, now),
+		Cluster:        cluster,
+		Timestamp:      now,
+		NamespaceCosts: cost.GetNamespaceCosts(podCosts),
+		LabelCosts:     cost.GetLabelCosts(podCosts, labelKeys),
+		Series:         series,
+		NodeStates:     nodeStates,
+	}
+	if err := store.Save(ctx, historySnapshot); err != nil {
+		log.Printf("Failed to save history snapshot: %v", err)
+	}
+}
+
+// archiveReport uploads a report to object storage and logs where it went
+func archiveReport(archiver *archive.Archiver, kind string, report interface{}, t time.Time) {
+	key, err := archiver.Archive(context.Background(), kind, report, t)
+	if err != nil {
+		log.Printf("Failed to archive %s report: %v", kind, err)
+		return
+	}
+	log.Printf("Archived %s report to %s", kind, key)
+}
+
+// pushReport sends a report to the central monitor; one that cannot be sent now is kept for
+// the next cycle
+func pushReport(pusher *fleet.Pusher, kind string, report interface{}, t time.Time) {
+	if err := pusher.Push(context.Background(), kind, report, t); err != nil {
+		log.Printf("Failed to push %s report: %v", kind, err)
+	}
+}
+
+// trackSavings records new recommendations in the ledger and checks whether earlier ones
+// were applied, returning the cycle's optimization report. With off-hours options, the
+// report also recommends business-hours schedules from the workloads' usage history.
+// Workloads with load signals are sized for the signals' peaks.
+func trackSavings(snap *snapshot.ClusterSnapshot, ledger *optimizer.Ledger, store history.Store, cluster string,
+	offHours *optimizer.OffHoursOptions, loads map[string][]optimizer.WorkloadLoad) *optimizer.OptimizationReport {
+	usages, err := optimizer.CollectContainerUsageFromSnapshot(snap)
+	if err != nil {
+		log.Printf("Failed to collect container usage: %v", err)
+		return nil
+	}
+
+	now := time.Now()
+	ledger.Observe(usages, now)
+	report := optimizer.RecommendRightSizing(optimizer.ApplyLoadToUsage(usages, loads))
+	if deployments, err := optimizer.CollectDeploymentLoad(snap); err != nil {
+		log.Printf("Failed to collect deployment load: %v", err)
+	} else {
+		deployments = optimizer.ApplyLoadToReplicas(deployments, loads)
+		report.Add(optimizer.RecommendReplicas(deployments, optimizer.DefaultReplicaOptions)...)
+	}
+	ledger.Record(report.Recommendations, now)
+	if err := ledger.Save(); err != nil {
+		log.Printf("Failed to save savings ledger: %v", err)
+	}
+
+	// Schedules are not tracked in the ledger, which measures requests and replicas at a
+	// single point in time
+	if offHours != nil {
+		recs, err := optimizer.RecommendOffHours(context.Background(), store, cluster, usages, *offHours, now)
+		if err != nil {
+			log.Printf("Failed to analyze off-hours usage: %v", err)
+		}
+		report.Add(recs...)
+	}
+
+	summary := ledger.Summary()
+	log.Printf("Savings ledger: %d open, %d applied, projected $%.2f/month, realized $%.2f/month",
+		summary.Open, summary.Applied, summary.ProjectedSavings, summary.RealizedSavings)
+	return report
+}
+
+// suggestEventScaling adds KEDA recommendations for queue consumers to the report and
+// writes ScaledObject manifests for them and for off-hours schedules to manifestDir if set
+func suggestEventScaling(snap *snapshot.ClusterSnapshot, report *optimizer.OptimizationReport, offHours *optimizer.OffHoursOptions, manifestDir string) {
+	objects, err := optimizer.DetectQueueConsumers(snap)
+	if err != nil {
+		log.Printf("Failed to detect queue consumers: %v", err)
+	}
+	for _, o := range objects {
+		report.Add(o.Recommendation())
+	}
+	if offHours != nil {
+		objects = append(objects, optimizer.ScheduleScaledObjects(report.Recommendations, offHours.Hours)...)
+	}
+	if manifestDir == "" || len(objects) == 0 {
+		return
+	}
+
+	files, err := optimizer.RenderScaledObjects(objects, "")
+	if err == nil {
+		err = optimizer.WriteExportedFiles(manifestDir, files)
+	}
+	if err != nil {
+		log.Printf("Failed to write KEDA manifests: %v", err)
+	}
+}
+
+// suggestStorage adds recommendations for volumes that stayed mostly empty, or whose I/O a
+// cheaper storage class would serve, to the report
+func suggestStorage(clientset *kubernetes.Clientset, report *optimizer.OptimizationReport, config *Config) {
+	options := optimizer.DefaultStorageOptions
+	options.PrometheusURL = config.PrometheusURL
+	options.IOPSQuery = config.StorageIOPSQuery
+	options.ThroughputQuery = config.StorageThroughput
+	recs, err := optimizer.RecommendStorage(context.Background(), clientset, options, time.Now())
+	if err != nil {
+		log.Printf("Failed to analyze volume usage: %v", err)
+	}
+	report.Add(recs...)
+}
+
+// runCleanup evaluates the cleanup policy and deletes what it selects, or logs it as a
+// dry-run preview. In approval mode it queues what it selects and deletes only the
+// resources approved since an earlier run.
+func runCleanup(clientset *kubernetes.Clientset, snap *snapshot.ClusterSnapshot, policy *optimizer.CleanupPolicy, apply bool,
+	approvals *approval.Queue, backups *optimizer.CleanupBackups, batch *optimizer.CleanupBatch, config *Config) {
+	ctx := context.Background()
+	now := time.Now()
+	recs, err := policy.Evaluate(ctx, clientset, snap, now)
+	if err != nil {
+		log.Printf("Failed to evaluate cleanup policy: %v", err)
+		return
+	}
+
+	options := optimizer.CleanupBatchOptions{Concurrency: config.CleanupConcurrency, QPS: config.CleanupQPS}
+	if approvals != nil {
+		approved, proposed := approvals.Sync(recs, now)
+		approvedRecs := make([]optimizer.CleanupRecommendation, len(approved))
+		for i, c := range approved {
+			approvedRecs[i] = c.Recommendation()
+		}
+		options.OnDone = func(item optimizer.CleanupBatchItem) {
+			var err error
+			if item.Status == optimizer.BatchFailed || item.Status == optimizer.BatchSkipped {
+				err = errors.New(item.Error)
+			}
+			approvals.Deleted(approval.CandidateID(item.Recommendation()), item.BackupID, err, time.Now())
+		}
+		startCleanupBatch(clientset, batch, approvedRecs, backups, options, func() {
+			if err := approvals.Save(); err != nil {
+				log.Printf("Failed to save cleanup approvals: %v", err)
+			}
+		})
+		if err := approvals.Save(); err != nil {
+			log.Printf("Failed to save cleanup approvals: %v", err)
+		}
+		if config.SlackWebhookURL != "" {
+			if err := approval.NotifySlack(ctx, config.SlackWebhookURL, config.ClusterName, proposed); err != nil {
+				log.Printf("Failed to post cleanup candidates to Slack: %v", err)
+			}
+		}
+		log.Printf("Cleanup: %d candidates awaiting approval, %d newly proposed, %d approved for deletion",
+			len(approvals.Candidates(nil)), len(proposed), len(approved))
+		return
+	}
+
+	if apply {
+		startCleanupBatch(clientset, batch, recs, backups, options, nil)
+		return
+	}
+
+	for _, rec := range recs {
+		if rec.Protected != "" {
+			log.Printf("Cleanup (dry run): would skip %s %s/%s (rule %s): protected, %s",
+				rec.ResourceType, rec.Namespace, rec.Name, rec.Rule, rec.Protected)
+			continue
+		}
+		log.Printf("Cleanup (dry run): would delete %s %s/%s (rule %s, %s): %s",
+			rec.ResourceType, rec.Namespace, rec.Name, rec.Rule, rec.Age.Round(time.Minute), rec.Reason)
+	}
+	log.Printf("Cleanup (dry run): %d resources selected; set --cleanup-apply to delete them", len(recs))
+}
+
+// startCleanupBatch deletes the recommendations in the background, along with the pending
+// deletions of an interrupted batch, unless the previous batch is still running. done is
+// called once the batch finishes.
+func startCleanupBatch(clientset *kubernetes.Clientset, batch *optimizer.CleanupBatch, recs []optimizer.CleanupRecommendation, backups *optimizer.CleanupBackups,
+	options optimizer.CleanupBatchOptions, done func()) {
+	if !batch.Start(recs, time.Now()) {
+		progress := batch.Progress(nil)
+		log.Printf("Cleanup: previous batch still running (%d of %d deleted); not starting another", progress.Deleted, progress.Total)
+		return
+	}
+	total := batch.Progress(nil).Total
+	if total == 0 {
+		batch.Run(context.Background(), clientset, backups, options)
+		return
+	}
+	log.Printf("Cleanup: deleting %d resources, %d at a time at up to %g per second", total, options.Concurrency, options.QPS)
+
+	go func() {
+		deleted := batch.Run(context.Background(), clientset, backups, options)
+		backups.Prune(time.Now())
+		if done != nil {
+			done()
+		}
+		log.Printf("Cleanup: deleted %d of %d selected resources, backed up to %s", deleted, total, backups.Dir)
+	}()
+}
+
+// exportRecommendations renders right-sizing recommendations as repository files and
+// either writes them locally or opens a pull request with them
+func exportRecommendations(clientset *kubernetes.Clientset, resourceOptimizer *optimizer.ResourceOptimizer, config *Config) error {
+	ctx := context.Background()
+
+	exportConfig, err := optimizer.LoadExportConfig(config.ExportConfigFile)
+	if err != nil {
+		return err
+	}
+	if err := protection.Refresh(ctx, clientset); err != nil {
+		return err
+	}
+
+	report, err := resourceOptimizer.GenerateOptimizationReport(ctx)
+	if err != nil {
+		return err
+	}
+
+	files, err := optimizer.ExportRecommendations(ctx, clientset, report.Recommendations, exportConfig)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		log.Printf("No recommendations matched the export path mappings")
+		return nil
+	}
+
+	if config.ExportPRProvider == "" {
+		if err := optimizer.WriteExportedFiles(config.ExportDir, files); err != nil {
+			return err
+		}
+		log.Printf("Exported %d files to %s", len(files), config.ExportDir)
+		return nil
+	}
+
+	prURL, err := optimizer.OpenPullRequest(ctx, optimizer.PullRequestOptions{
+		Provider:   config.ExportPRProvider,
+		Repository: config.ExportPRRepo,
+		Token:      os.Getenv("EXPORT_GIT_TOKEN"),
+		BaseBranch: config.ExportPRBase,
+		Body: fmt.Sprintf("Right-sizing recommendations for %d workloads with estimated savings of $%.2f/month.",
+			len(files), report.PotentialSavings),
+	}, files)
+	if err != nil {
+		return err
+	}
+	log.Printf("Opened pull request: %s", prURL)
+	return nil
+}
+
+// runCompliance prints the CIS benchmark report, also writing it to outputFile as JSON
+// if set
+func runCompliance(clientset *kubernetes.Clientset, metricsClient *versioned.Clientset, outputFile string) error {
+	ctx := context.Background()
+	snap, err := snapshot.Take(ctx, clientset, metricsClient)
+	if err != nil {
+		return err
+	}
+	report, err := compliance.Evaluate(ctx, clientset, snap)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("=== %s ===\n", report.Benchmark)
+	fmt.Printf("Score: %d%% (%d passed, %d failed, %d skipped)\n", report.Score, report.Passed, report.Failed, report.Skipped)
+	section := ""
+	for _, c := range report.Controls {
+		if c.Section != section {
+			section = c.Section
+			fmt.Printf("\n--- %s ---\n", section)
+		}
+		fmt.Printf("[%s] %s %s\n", strings.ToUpper(c.Status), c.ID, c.Title)
+		if c.Reason != "" {
+			fmt.Printf("  %s\n", c.Reason)
+		}
+		for _, f := range c.Findings {
+			fmt.Printf("  %s\n", f)
+		}
+	}
+
+	if outputFile != "" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal compliance report: %w", err)
+		}
+		if err := os.WriteFile(outputFile, data, 0644); err != nil {
+			return fmt.Errorf("failed to write compliance report: %w", err)
+		}
+	}
+	return nil
+}
+
+// runDiff compares two snapshots saved with --output or --archive, or fetched as detailed
+// health reports, and returns the exit status
+func runDiff(args []string) int {
+	flags := flag.NewFlagSet("diff", flag.ExitOnError)
+	asJSON := flags.Bool("json", false, "Print the comparison as JSON")
+	failOnRegression := flags.Bool("fail-on-regression", false, "Exit with status 1 if the health score or ready nodes dropped, or new critical issues appeared")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s diff [flags] <before> <after>\n\nCompares two health reports or --output files, gzipped or not.\n\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return 2
+	}
+
+	before, err := compare.Load(flags.Arg(0))
+	if err != nil {
+		log.Printf("Failed to load snapshot: %v", err)
+		return 2
+	}
+	after, err := compare.Load(flags.Arg(1))
+	if err != nil {
+		log.Printf("Failed to load snapshot: %v", err)
+		return 2
+	}
+	result := compare.Diff(before, after)
 
-		// Generate cost report if enabled
-		var costReport *CostReport
-		if config.EnableCostReport {
-			costReport = generateCostReport(clientset, metricsClient, pricingData)
+	if *asJSON {
+		data, err := json.MarshalIndent(struct {
+			*compare.Result
+			Regressed bool `json:"regressed"`
+		}{result, result.Regressed()}, "", "  ")
+		if err != nil {
+			log.Printf("Failed to marshal comparison: %v", err)
+			return 2
 		}
+		fmt.Println(string(data))
+	} else {
+		printDiff(result)
+	}
 
-		// Update Prometheus metrics
-		updateMetrics(clientset, metricsClient)
+	if *failOnRegression && result.Regressed() {
+		return 1
+	}
+	return 0
+}
 
-		// Output results
-		if config.OutputFile != "" {
-			outputResults(config.OutputFile, health, costReport)
+// printDiff prints a snapshot comparison
+func printDiff(r *compare.Result) {
+	fmt.Println("=== Snapshot Comparison ===")
+	for _, s := range []*compare.Snapshot{r.Before, r.After} {
+		label := "Before"
+		if s == r.After {
+			label = "After "
+		}
+		if s.Timestamp.IsZero() {
+			fmt.Printf("%s: %s\n", label, s.Source)
+		} else {
+			fmt.Printf("%s: %s (%s)\n", label, s.Source, s.Timestamp.Format(time.RFC3339))
+		}
+	}
+	fmt.Println()
+
+	printChange := func(name string, c *compare.Change, format string) {
+		if c == nil {
+			return
+		}
+		fmt.Printf("%s: "+format+" -> "+format+" (%+.2f)\n", name, c.Before, c.After, c.Delta)
+	}
+	printChange("Health Score", r.HealthScore, "%.0f")
+	printChange("Nodes", r.TotalNodes, "%.0f")
+	printChange("Ready Nodes", r.ReadyNodes, "%.0f")
+	printChange("CPU Usage", r.CPUUsage, "%.1f%%")
+	printChange("Memory Usage", r.MemoryUsage, "%.1f%%")
+	printChange("Pod Density", r.PodDensity, "%.1f%%")
+	printChange("Hourly Cost", r.HourlyCost, "$%.2f")
+	if len(r.NamespaceCosts) > 0 {
+		fmt.Println("Namespace Costs:")
+		for _, ns := range r.NamespaceCosts {
+			fmt.Printf("  %s: $%.2f -> $%.2f (%+.2f)\n", ns.Namespace, ns.Before, ns.After, ns.Delta)
 		}
+	}
 
-		// Print summary to stdout
-		printSummary(health, costReport)
+	if r.Severities != nil {
+		fmt.Printf("\nIssues: %d new, %d resolved, %d persisting\n", len(r.NewIssues), len(r.Resolved), r.Persisting)
+		for _, severity := range []string{"critical", "warning", "info"} {
+			if c, ok := r.Severities[severity]; ok {
+				fmt.Printf("  %s: %.0f -> %.0f (%+.0f)\n", severity, c.Before, c.After, c.Delta)
+			}
+		}
+		printIssues("New Issues", r.NewIssues)
+		printIssues("Resolved Issues", r.Resolved)
+	}
 
-		// Wait for next interval
-		<-ticker.C
+	if r.Regressed() {
+		fmt.Println("\nResult: regressed")
+	} else {
+		fmt.Println("\nResult: no regression")
 	}
 }
 
-func parseFlags() *Config {
-	config := &Config{}
-
-	// Default to $HOME/.kube/config for kubeconfig path
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		log.Fatalf("Failed to get user home directory: %v", err)
+// runCleanupCommand lists, approves or rejects cleanup candidates through a running
+// monitor's approval API and returns the exit status
+func runCleanupCommand(args []string) int {
+	flags := flag.NewFlagSet("cleanup", flag.ExitOnError)
+	server := flags.String("server", "http://localhost:8080", "Base URL of the monitor's metrics server")
+	note := flags.String("note", "", "Note recorded with approvals and rejections")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s cleanup [flags] list | audit | approve <id>... | reject <id>...\n\n"+
+			"Candidate IDs are Kind/namespace/name/uid, as list prints them. The API token is read from OCHESTRA_API_TOKEN.\n\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
 	}
-	defaultKubeConfig := filepath.Join(homeDir, ".kube", "config")
 
-	flag.StringVar(&config.KubeConfigPath, "kubeconfig", defaultKubeConfig, "Path to kubeconfig file")
-	flag.DurationVar(&config.Interval, "interval", 60*time.Second, "Check interval in seconds")
-	flag.IntVar(&config.MetricsPort, "metrics-port", 8080, "Prometheus metrics port")
-	flag.StringVar(&config.OutputFile, "output", "", "Output file for health and cost reports")
-	flag.BoolVar(&config.EnableCostReport, "cost", true, "Enable cost reporting")
-	flag.StringVar(&config.PricingDataFile, "pricing", "pricing.json", "Pricing data file")
+	action, ids := flags.Arg(0), flags.Args()[1:]
+	method, path := http.MethodGet, "/cleanup/candidates"
+	var body []byte
+	switch action {
+	case "list":
+	case "audit":
+		path = "/cleanup/audit"
+	case "approve", "reject":
+		if len(ids) == 0 {
+			flags.Usage()
+			return 2
+		}
+		method, path = http.MethodPost, "/cleanup/"+action
+		body, _ = json.Marshal(map[string]interface{}{"ids": ids, "note": *note})
+	default:
+		flags.Usage()
+		return 2
+	}
 
-	flag.Parse()
-	return config
-}
+	req, err := http.NewRequest(method, strings.TrimSuffix(*server, "/")+path, strings.NewReader(string(body)))
+	if err != nil {
+		log.Printf("Failed to create request: %v", err)
+		return 1
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token := os.Getenv("OCHESTRA_API_TOKEN"); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		log.Printf("Failed to reach the approval API: %v", err)
+		return 1
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		log.Printf("Approval API returned %s", resp.Status)
+		return 1
+	}
 
-func startMetricsServer(port int) {
-	http.Handle("/metrics", promhttp.Handler())
-	go func() {
-		log.Printf("Starting metrics server on port %d", port)
-		if err := http.ListenAndServe(fmt.Sprintf(":%d", port), nil); err != nil {
-			log.Fatalf("Failed to start metrics server: %v", err)
+	switch action {
+	case "list":
+		var candidates []approval.Candidate
+		if err := json.NewDecoder(resp.Body).Decode(&candidates); err != nil {
+			log.Printf("Failed to parse candidates: %v", err)
+			return 1
 		}
-	}()
+		for _, c := range candidates {
+			decided := ""
+			if c.DecidedBy != "" {
+				decided = " by " + c.DecidedBy
+			}
+			fmt.Printf("%-9s %s  (rule %s, %s) %s%s\n", c.Status, c.ID, c.Rule, c.Age.Round(time.Hour), c.Reason, decided)
+		}
+	case "audit":
+		var entries []approval.AuditEntry
+		if err := json.NewDecoder(resp.Body).Decode(&entries); err != nil {
+			log.Printf("Failed to parse audit trail: %v", err)
+			return 1
+		}
+		for _, e := range entries {
+			fmt.Printf("%s  %-13s %s  %s %s\n", e.Time.Format(time.RFC3339), e.Action, e.ID, e.Actor, e.Detail)
+		}
+	default:
+		var result struct {
+			Decided int      `json:"decided"`
+			Unknown []string `json:"unknown"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+			log.Printf("Failed to parse response: %v", err)
+			return 1
+		}
+		fmt.Printf("%s: %d candidates\n", action, result.Decided)
+		if len(result.Unknown) > 0 {
+			fmt.Printf("Not found, already decided or not permitted: %s\n", strings.Join(result.Unknown, ", "))
+			return 1
+		}
+	}
+	return 0
 }
 
-func loadPricingData(filename string) *PricingData {
-	if _, err := os.Stat(filename); os.IsNotExist(err) {
-		// Create default pricing data if file doesn't exist
-		pricingData := &PricingData{
-			Nodes: map[string]NodePricing{
-				"default": {
-					CPUCostPerHour:     0.03,
-					MemoryCostPerGBHr:  0.004,
-					StorageCostPerGBHr: 0.00012,
-					RegionMultiplier:   1.0,
-				},
-				"highcpu": {
-					CPUCostPerHour:     0.05,
-					MemoryCostPerGBHr:  0.003,
-					StorageCostPerGBHr: 0.00015,
-					RegionMultiplier:   1.0,
-				},
-				"highmem": {
-					CPUCostPerHour:     0.02,
-					MemoryCostPerGBHr:  0.006,
-					StorageCostPerGBHr: 0.0001,
-					RegionMultiplier:   1.0,
-				},
-			},
-		}
+// runRestore re-creates a resource cleanup deleted from its backup, read from the local
+// backup directory or, with an archive config, from object storage, and returns the exit
+// status
+func runRestore(args []string) int {
+	homeDir, _ := os.UserHomeDir()
+	flags := flag.NewFlagSet("restore", flag.ExitOnError)
+	kubeConfigPath := flags.String("kubeconfig", filepath.Join(homeDir, ".kube", "config"), "Path to kubeconfig file")
+	backupDir := flags.String("backup-dir", "cleanup-backups", "Directory of cleanup backups")
+	archiveConfigFile := flags.String("archive", "", "Object storage config to fetch backups from when they are not in -backup-dir")
+	clusterName := flags.String("cluster-name", "cluster-one", "Cluster name the backups were archived under")
+	list := flags.Bool("list", false, "List the local backups instead of restoring one")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s restore [flags] <backup-id>\n       %s restore -list\n\n"+
+			"Re-creates a resource cleanup deleted from the manifest saved before deletion.\n\n", os.Args[0], os.Args[0])
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
 
-		// Write default pricing data to file
-		data, err := json.MarshalIndent(pricingData, "", "  ")
+	if *list {
+		backups, err := optimizer.ListCleanupBackups(*backupDir)
 		if err != nil {
-			log.Fatalf("Failed to marshal pricing data: %v", err)
+			log.Printf("Failed to list backups: %v", err)
+			return 1
 		}
+		for _, b := range backups {
+			fmt.Printf("%s  %s %s/%s (rule %s)\n", b.ID, b.Kind, b.Namespace, b.Name, b.Rule)
+		}
+		return 0
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
 
-		if err := os.WriteFile(filename, data, 0644); err != nil {
-			log.Fatalf("Failed to write pricing data: %v", err)
+	ctx := context.Background()
+	id := flags.Arg(0)
+	backup, err := optimizer.LoadCleanupBackup(*backupDir, id)
+	if err != nil && *archiveConfigFile != "" {
+		archiveConfig, configErr := archive.LoadConfig(*archiveConfigFile)
+		if configErr != nil {
+			log.Printf("Failed to load archive config: %v", configErr)
+			return 2
 		}
+		backup = &optimizer.CleanupBackup{}
+		err = archive.CLIUploader{Config: archiveConfig}.Fetch(ctx, archiveConfig.ObjectKey(*clusterName, archive.KindCleanupBackup, id), backup)
+	}
+	if err != nil {
+		log.Printf("Failed to load backup: %v", err)
+		return 1
+	}
 
-		log.Printf("Created default pricing data file: %s", filename)
-		return pricingData
+	clientset, _ := initKubernetesClient(*kubeConfigPath, false)
+	if err := optimizer.RestoreCleanupBackup(ctx, clientset, backup); err != nil {
+		log.Printf("Failed to restore backup: %v", err)
+		return 1
 	}
+	fmt.Printf("Restored %s %s/%s from %s\n", backup.Kind, backup.Namespace, backup.Name, backup.ID)
+	return 0
+}
 
-	// Load pricing data from file
-	data, err := os.ReadFile(filename)
+// runWhatIf estimates the monthly cost of deploying manifests onto the cluster and returns
+// the exit status, 1 when the estimate exceeds -max-monthly-cost or pods would not fit
+func runWhatIf(args []string) int {
+	homeDir, _ := os.UserHomeDir()
+	flags := flag.NewFlagSet("whatif", flag.ExitOnError)
+	kubeConfigPath := flags.String("kubeconfig", filepath.Join(homeDir, ".kube", "config"), "Path to kubeconfig file")
+	pricingFile := flags.String("pricing", "pricing.json", "Pricing data file")
+	namespace := flags.String("namespace", whatif.DefaultNamespace, "Namespace of manifests that do not set one")
+	asJSON := flags.Bool("json", false, "Print the estimate as JSON")
+	maxMonthlyCost := flags.Float64("max-monthly-cost", 0, "Exit with status 1 if the estimate exceeds this monthly cost (0 to disable)")
+	failOnUnschedulable := flags.Bool("fail-on-unschedulable", false, "Exit with status 1 if any pod would fit on no node")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s whatif [flags] <manifest>...\n       helm template <chart> | %s whatif [flags] -\n\n"+
+			"Estimates the monthly cost of deploying manifests onto the current cluster.\n\n", os.Args[0], os.Args[0])
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	var workloads []whatif.Workload
+	var skipped []string
+	for _, path := range flags.Args() {
+		var data []byte
+		var err error
+		if path == "-" {
+			data, err = io.ReadAll(os.Stdin)
+		} else {
+			data, err = os.ReadFile(path)
+		}
+		if err != nil {
+			log.Printf("Failed to read manifests: %v", err)
+			return 2
+		}
+		w, s, err := whatif.Parse(data, *namespace)
+		if err != nil {
+			log.Printf("Failed to parse %s: %v", path, err)
+			return 2
+		}
+		workloads, skipped = append(workloads, w...), append(skipped, s...)
+	}
+
+	clientset, metricsClient := initKubernetesClient(*kubeConfigPath, false)
+	snap, err := snapshot.Take(context.Background(), clientset, metricsClient)
 	if err != nil {
-		log.Fatalf("Failed to read pricing data: %v", err)
+		log.Printf("Failed to read the cluster: %v", err)
+		return 2
 	}
+	report := whatif.Estimate(snap, toResourcePricing(loadPricingData(*pricingFile)), workloads)
+	report.Skipped = skipped
 
-	var pricingData PricingData
-	if err := json.Unmarshal(data, &pricingData); err != nil {
-		log.Fatalf("Failed to parse pricing data: %v", err)
+	if *asJSON {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			log.Printf("Failed to marshal estimate: %v", err)
+			return 2
+		}
+		fmt.Println(string(data))
+	} else {
+		printWhatIf(report)
 	}
 
-	return &pricingData
+	if *maxMonthlyCost > 0 && report.CostPerMonth > *maxMonthlyCost {
+		log.Printf("Estimated $%.2f/month exceeds the limit of $%.2f/month", report.CostPerMonth, *maxMonthlyCost)
+		return 1
+	}
+	if *failOnUnschedulable && report.Unschedulable() > 0 {
+		log.Printf("%d pods would fit on no node", report.Unschedulable())
+		return 1
+	}
+	return 0
+}
+
+// printWhatIf prints a what-if estimate
+func printWhatIf(r *whatif.Report) {
+	fmt.Printf("Estimated cost: $%.2f/hour, $%.2f/month\n", r.CostPerHour, r.CostPerMonth)
+	if len(r.Workloads) > 0 {
+		fmt.Printf("\n%-50s %6s %10s %12s\n", "WORKLOAD", "PODS", "NEW NODES", "PER MONTH")
+		for _, w := range r.Workloads {
+			fmt.Printf("%-50s %6d %10d %12s\n", fmt.Sprintf("%s %s/%s", w.Kind, w.Namespace, w.Name), w.Pods, w.NewNodePods,
+				fmt.Sprintf("$%.2f", w.CostPerMonth))
+			if w.Unschedulable > 0 {
+				fmt.Printf("  %d pods fit on no node\n", w.Unschedulable)
+			}
+		}
+	}
+	if len(r.NewNodes) > 0 {
+		fmt.Printf("\nNodes to add: %d\n", len(r.NewNodes))
+		for _, n := range r.NewNodes {
+			shape := n.InstanceType
+			if shape == "" {
+				shape = "like " + n.Template
+			}
+			fmt.Printf("  %s: %d pods, $%.2f/month\n", shape, n.Pods, n.CostPerHour*24*30)
+		}
+	}
+	for _, s := range r.Skipped {
+		fmt.Printf("Skipped %s\n", s)
+	}
 }
 
-func initKubernetesClient(kubeConfigPath string) (*kubernetes.Clientset, *versioned.Clientset) {
+func initKubernetesClient(kubeConfigPath string, protobuf bool) (*kubernetes.Clientset, *versioned.Clientset) {
 	var config *rest.Config
 	var err error
 
@@ -270,8 +2449,17 @@ func initKubernetesClient(kubeConfigPath string) (*kubernetes.Clientset, *versio
 		}
 	}
 
-	// Create clientset for Kubernetes API
-	clientset, err := kubernetes.NewForConfig(config)
+	// Count the waits of our own client-side rate limiter for the flow control check
+	clusterhealth.TrackClientThrottling()
+
+	// Create clientset for Kubernetes API. Protobuf cuts API server CPU and transfer time
+	// on large lists; JSON stays acceptable for APIs that do not serve protobuf.
+	kubeConfig := rest.CopyConfig(config)
+	if protobuf {
+		kubeConfig.ContentType = runtime.ContentTypeProtobuf
+		kubeConfig.AcceptContentTypes = runtime.ContentTypeProtobuf + "," + runtime.ContentTypeJSON
+	}
+	clientset, err := kubernetes.NewForConfig(kubeConfig)
 	if err != nil {
 		log.Fatalf("Failed to create Kubernetes client: %v", err)
 	}
@@ -282,23 +2470,23 @@ func initKubernetesClient(kubeConfigPath string) (*kubernetes.Clientset, *versio
 		log.Fatalf("Failed to create Metrics client: %v", err)
 	}
 
+	// Read kinds without typed clients, such as custom resources, through discovery
+	resourceClient, err := resources.NewClient(config)
+	if err != nil {
+		log.Fatalf("Failed to create dynamic client: %v", err)
+	}
+	resources.Configure(resourceClient)
+
 	return clientset, metricsClient
 }
 
-func checkClusterHealth(clientset *kubernetes.Clientset) *ClusterHealth {
-	ctx := context.Background()
+func checkClusterHealth(snap *snapshot.ClusterSnapshot) *ClusterHealth {
 	health := &ClusterHealth{}
 
 	// Check nodes status
-	nodes, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
-	if err != nil {
-		log.Printf("Failed to list nodes: %v", err)
-		return health
-	}
+	health.TotalNodes = len(snap.Nodes)
 
-	health.TotalNodes = len(nodes.Items)
-
-	for _, node := range nodes.Items {
+	for _, node := range snap.Nodes {
 		for _, condition := range node.Status.Conditions {
 			if condition.Type == v1.NodeReady && condition.Status == v1.ConditionTrue {
 				health.ReadyNodes++
@@ -318,14 +2506,16 @@ func checkClusterHealth(clientset *kubernetes.Clientset) *ClusterHealth {
 		}
 	}
 
-	// Check pod status
-	pods, err := clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
-	if err != nil {
-		log.Printf("Failed to list pods: %v", err)
-		return health
+	// Hybrid clusters run Linux and Windows nodes as separate pools
+	health.NodesByOS = clusterhealth.NodePoolsByOS(snap.Nodes)
+	for i := range snap.Nodes {
+		if snapshot.IsVirtualNode(&snap.Nodes[i]) {
+			health.VirtualNodes++
+		}
 	}
 
-	for _, pod := range pods.Items {
+	// Check pod status
+	for _, pod := range snap.Pods {
 		switch pod.Status.Phase {
 		case v1.PodPending:
 			health.PendingPods++
@@ -339,14 +2529,7 @@ func checkClusterHealth(clientset *kubernetes.Clientset) *ClusterHealth {
 	health.CriticalComponentsOK = true
 
 	for _, namespace := range criticalNamespaces {
-		namespacePods, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
-		if err != nil {
-			log.Printf("Failed to list pods in %s: %v", namespace, err)
-			health.CriticalComponentsOK = false
-			continue
-		}
-
-		for _, pod := range namespacePods.Items {
+		for _, pod := range snap.Select(namespace, nil) {
 			if pod.Status.Phase != v1.PodRunning {
 				health.CriticalComponentsOK = false
 				log.Printf("Critical component not running: %s/%s", namespace, pod.Name)
@@ -356,29 +2539,48 @@ func checkClusterHealth(clientset *kubernetes.Clientset) *ClusterHealth {
 
 	// Calculate resource utilization (simplified - would be more detailed with metrics-server data)
 	if health.TotalNodes > 0 {
-		health.ResourceUtilization = float64(len(pods.Items)) / float64(health.TotalNodes*110) * 100 // Assuming ~100 pods per node is "full"
+		health.ResourceUtilization = float64(len(snap.Pods)) / float64(health.TotalNodes*110) * 100 // Assuming ~100 pods per node is "full"
+	}
+
+	// Score how much of the requested and allocatable resources pods use
+	var err error
+	health.Efficiency, health.NamespaceEfficiency, err = clusterhealth.ResourceEfficiency(snap)
+	if err != nil {
+		log.Printf("Efficiency scoring failed: %v", err)
+	}
+
+	// Inventory kubelet, runtime, OS and kernel versions
+	health.Inventory = clusterhealth.NodeInventory(snap.Nodes)
+
+	// Audit cordons and custom taints, which silently shrink capacity
+	health.Taints = clusterhealth.TaintAudit(snap, time.Now())
+
+	// Check CNI, kube-proxy and agent DaemonSets cover every eligible node
+	if coverage, err := clusterhealth.CheckDaemonSets(snap); err != nil {
+		log.Printf("DaemonSet coverage check failed: %v", err)
+	} else {
+		health.DaemonSets = coverage
 	}
 
 	return health
 }
 
-func generateCostReport(clientset *kubernetes.Clientset, metricsClient *versioned.Clientset, pricingData *PricingData) *CostReport {
-	ctx := context.Background()
+func generateCostReport(snap *snapshot.ClusterSnapshot, pricingData *PricingData) *CostReport {
 	costReport := &CostReport{
 		CostByNamespace: make(map[string]float64),
 		CostByNodeType:  make(map[string]float64),
+		CostByOS:        make(map[string]float64),
 		Recommendations: make([]CostOptimizationRec, 0),
 	}
-
-	// Get nodes info
-	nodes, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
-	if err != nil {
-		log.Printf("Failed to list nodes: %v", err)
-		return costReport
-	}
+	resourcePricing := toResourcePricing(pricingData)
 
 	// Calculate cost by node type
-	for _, node := range nodes.Items {
+	virtualNodes := make(map[string]string) // virtual node -> OS
+	for _, node := range snap.Nodes {
+		if snapshot.IsVirtualNode(&node) {
+			virtualNodes[node.Name] = snapshot.NodeOS(&node)
+			continue
+		}
 		nodeType := "default"
 		if t, ok := node.Labels["node.kubernetes.io/instance-type"]; ok {
 			nodeType = t
@@ -405,24 +2607,37 @@ func generateCostReport(clientset *kubernetes.Clientset, metricsClient *versione
 			costReport.CostByNodeType[nodeType] = 0
 		}
 		costReport.CostByNodeType[nodeType] += nodeCost
+		costReport.CostByOS[snapshot.NodeOS(&node)] += nodeCost
 		costReport.TotalCostPerHour += nodeCost
 	}
-
-	// Get pods info
-	pods, err := clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
-	if err != nil {
-		log.Printf("Failed to list pods: %v", err)
-		return costReport
+	machineCost := costReport.TotalCostPerHour
+
+	// Pods on virtual nodes are billed by their requests rather than sharing node capacity
+	virtualCost := make(map[string]float64) // namespace -> cost per hour
+	if len(virtualNodes) > 0 {
+		for _, p := range cost.PodCostsFromSnapshot(snap, resourcePricing) {
+			system, ok := virtualNodes[p.NodeName]
+			if !ok {
+				continue
+			}
+			podCost := p.CPUCost + p.MemoryCost
+			virtualCost[p.Namespace] += podCost
+			costReport.CostByNodeType[cost.VirtualPricing] += podCost
+			costReport.CostByOS[system] += podCost
+			costReport.TotalCostPerHour += podCost
+		}
 	}
 
 	// Create map to store namespace usage
 	namespaceCPURequests := make(map[string]float64)
 	namespaceMemRequests := make(map[string]float64)
+	machineCPURequests := make(map[string]float64) // requests on machine nodes, which share their cost
+	machineMemRequests := make(map[string]float64)
 	namespaceCPULimits := make(map[string]float64)
 	namespaceMemLimits := make(map[string]float64)
 
 	// Calculate usage by namespace
-	for _, pod := range pods.Items {
+	for _, pod := range snap.Pods {
 		namespace := pod.Namespace
 
 		if _, ok := namespaceCPURequests[namespace]; !ok {
@@ -435,8 +2650,14 @@ func generateCostReport(clientset *kubernetes.Clientset, metricsClient *versione
 		// Sum up resource requests and limits
 		for _, container := range pod.Spec.Containers {
 			if container.Resources.Requests != nil {
-				namespaceCPURequests[namespace] += float64(container.Resources.Requests.Cpu().MilliValue()) / 1000
-				namespaceMemRequests[namespace] += float64(container.Resources.Requests.Memory().Value()) / (1024 * 1024 * 1024)
+				cpu := float64(container.Resources.Requests.Cpu().MilliValue()) / 1000
+				mem := float64(container.Resources.Requests.Memory().Value()) / (1024 * 1024 * 1024)
+				namespaceCPURequests[namespace] += cpu
+				namespaceMemRequests[namespace] += mem
+				if _, virtual := virtualNodes[pod.Spec.NodeName]; !virtual {
+					machineCPURequests[namespace] += cpu
+					machineMemRequests[namespace] += mem
+				}
 			}
 
 			if container.Resources.Limits != nil {
@@ -450,7 +2671,10 @@ func generateCostReport(clientset *kubernetes.Clientset, metricsClient *versione
 	totalClusterCPU := 0.0
 	totalClusterMem := 0.0
 
-	for _, node := range nodes.Items {
+	for _, node := range snap.Nodes {
+		if _, virtual := virtualNodes[node.Name]; virtual {
+			continue
+		}
 		totalClusterCPU += float64(node.Status.Capacity.Cpu().Value())
 		totalClusterMem += float64(node.Status.Capacity.Memory().Value()) / (1024 * 1024 * 1024)
 	}
@@ -461,14 +2685,14 @@ func generateCostReport(clientset *kubernetes.Clientset, metricsClient *versione
 		memCostShare := 0.0
 
 		if totalClusterCPU > 0 {
-			cpuCostShare = cpuRequests / totalClusterCPU * costReport.TotalCostPerHour * 0.7 // Assuming CPU is 70% of cost
+			cpuCostShare = machineCPURequests[namespace] / totalClusterCPU * machineCost * 0.7 // Assuming CPU is 70% of cost
 		}
 
 		if totalClusterMem > 0 {
-			memCostShare = namespaceMemRequests[namespace] / totalClusterMem * costReport.TotalCostPerHour * 0.3 // Assuming memory is 30% of cost
+			memCostShare = machineMemRequests[namespace] / totalClusterMem * machineCost * 0.3 // Assuming memory is 30% of cost
 		}
 
-		costReport.CostByNamespace[namespace] = cpuCostShare + memCostShare
+		costReport.CostByNamespace[namespace] = cpuCostShare + memCostShare + virtualCost[namespace]
 
 		// Generate optimization recommendations
 		generateOptimizationRecs(namespace, cpuRequests, namespaceMemRequests[namespace],
@@ -478,6 +2702,12 @@ func generateCostReport(clientset *kubernetes.Clientset, metricsClient *versione
 	// Calculate monthly cost projection
 	costReport.TotalCostPerMonth = costReport.TotalCostPerHour * 24 * 30
 
+	// Break node cost down by purchase option and recommend a mix
+	costReport.Lifecycle = cost.LifecycleFromSnapshot(snap, resourcePricing, time.Now())
+
+	// Show how much of each namespace's requests its mesh proxies and agents take
+	costReport.Sidecars = cost.SidecarCostsFromSnapshot(snap, resourcePricing)
+
 	return costReport
 }
 
@@ -524,17 +2754,9 @@ func generateOptimizationRecs(namespace string, cpuReq, memReq, cpuLimit, memLim
 	}
 }
 
-func updateMetrics(clientset *kubernetes.Clientset, metricsClient *versioned.Clientset) {
-	ctx := context.Background()
-
+func updateMetrics(snap *snapshot.ClusterSnapshot) {
 	// Update node status metrics
-	nodes, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
-	if err != nil {
-		log.Printf("Failed to list nodes for metrics: %v", err)
-		return
-	}
-
-	for _, node := range nodes.Items {
+	for _, node := range snap.Nodes {
 		isReady := 0.0
 		for _, condition := range node.Status.Conditions {
 			if condition.Type == v1.NodeReady && condition.Status == v1.ConditionTrue {
@@ -545,14 +2767,14 @@ func updateMetrics(clientset *kubernetes.Clientset, metricsClient *versioned.Cli
 		nodeStatusGauge.WithLabelValues(node.Name).Set(isReady)
 	}
 
-	// Update pod status metrics
-	pods, err := clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
-	if err != nil {
-		log.Printf("Failed to list pods for metrics: %v", err)
-		return
+	// Update pod status metrics; per-pod series are dropped for large clusters
+	podsWithSeries := snap.Pods
+	if snap.Large {
+		podStatusGauge.Reset()
+		podsWithSeries = nil
 	}
 
-	for _, pod := range pods.Items {
+	for _, pod := range podsWithSeries {
 		var statusValue float64
 
 		switch pod.Status.Phase {
@@ -577,7 +2799,7 @@ func updateMetrics(clientset *kubernetes.Clientset, metricsClient *versioned.Cli
 	namespaceCPURequests := make(map[string]float64)
 	namespaceMemRequests := make(map[string]float64)
 
-	for _, pod := range pods.Items {
+	for _, pod := range snap.Pods {
 		namespace := pod.Namespace
 
 		if _, ok := namespaceCPUUsage[namespace]; !ok {
@@ -588,7 +2810,7 @@ func updateMetrics(clientset *kubernetes.Clientset, metricsClient *versioned.Cli
 		}
 
 		// Sum resource requests
-		for _, container := range pod.Spec.Containers {
+		for _, container := range snapshot.Containers(&pod) {
 			if container.Resources.Requests != nil {
 				cpuReq := float64(container.Resources.Requests.Cpu().MilliValue()) / 1000
 				memReq := float64(container.Resources.Requests.Memory().Value()) / (1024 * 1024 * 1024)
@@ -615,17 +2837,38 @@ func updateMetrics(clientset *kubernetes.Clientset, metricsClient *versioned.Cli
 			resourceEfficiencyGauge.WithLabelValues(namespace, "memory").Set(memEfficiency)
 		}
 	}
+
+	// Per-container series, so sidecars are seen apart from their applications; they are
+	// dropped for large clusters like the per-pod series
+	containerResourceUsageGauge.Reset()
+	containerResourceRequestGauge.Reset()
+	if usages, err := optimizer.CollectContainerUsageFromSnapshot(snap); err == nil && !snap.Large {
+		for _, u := range usages {
+			labels := []string{u.Namespace, u.WorkloadKind + "/" + u.WorkloadName, u.ContainerName, strconv.FormatBool(u.Sidecar)}
+			containerResourceUsageGauge.WithLabelValues(append(labels, "cpu")...).Set(float64(u.CPUUsage) / 1000)
+			containerResourceUsageGauge.WithLabelValues(append(labels, "memory")...).Set(float64(u.MemoryUsage))
+			containerResourceRequestGauge.WithLabelValues(append(labels, "cpu")...).Set(float64(u.CPURequest) / 1000)
+			containerResourceRequestGauge.WithLabelValues(append(labels, "memory")...).Set(float64(u.MemoryRequest))
+		}
+	}
+	namespaceSidecarShareGauge.Reset()
+	for _, o := range cost.SidecarCostsFromSnapshot(snap, nil) {
+		namespaceSidecarShareGauge.WithLabelValues(o.Namespace, "cpu").Set(o.CPUShare)
+		namespaceSidecarShareGauge.WithLabelValues(o.Namespace, "memory").Set(o.MemoryShare)
+	}
 }
 
-func outputResults(filename string, health *ClusterHealth, costReport *CostReport) {
+func outputResults(filename string, health *ClusterHealth, costReport *CostReport, sloStatuses []slo.Status) {
 	output := struct {
 		Timestamp  string         `json:"timestamp"`
 		Health     *ClusterHealth `json:"health"`
 		CostReport *CostReport    `json:"costReport,omitempty"`
+		SLOs       []slo.Status   `json:"slos,omitempty"`
 	}{
 		Timestamp:  time.Now().Format(time.RFC3339),
 		Health:     health,
 		CostReport: costReport,
+		SLOs:       sloStatuses,
 	}
 
 	data, err := json.MarshalIndent(output, "", "  ")
@@ -639,21 +2882,144 @@ func outputResults(filename string, health *ClusterHealth, costReport *CostRepor
 	}
 }
 
-func printSummary(health *ClusterHealth, costReport *CostReport) {
+func printSummary(health *ClusterHealth, costReport *CostReport, sloStatuses []slo.Status) {
 	fmt.Println("=== Kubernetes Health and Cost Management Summary ===")
 	fmt.Printf("Time: %s\n\n", time.Now().Format(time.RFC3339))
 
 	fmt.Println("--- Cluster Health ---")
 	fmt.Printf("Nodes: %d total, %d ready\n", health.TotalNodes, health.ReadyNodes)
+	if health.VirtualNodes > 0 {
+		fmt.Printf("  %d virtual nodes, billed per pod and skipped by node checks\n", health.VirtualNodes)
+	}
+	if len(health.NodesByOS) > 1 {
+		for _, p := range health.NodesByOS {
+			fmt.Printf("  %s: %d nodes, %d ready, %d under pressure, %.1f cores, %.1f GiB allocatable\n",
+				p.OS, p.Nodes, p.ReadyNodes, p.PressureNodes, p.CPU, p.MemoryGB)
+		}
+	}
 	fmt.Printf("Resource Utilization: %.1f%%\n", health.ResourceUtilization)
+	if e := health.Efficiency; e != nil {
+		fmt.Printf("Efficiency Score: %d/100 (CPU %.0f%% of requests used, %.0f%% of allocatable requested; memory %.0f%%, %.0f%%)\n",
+			e.Score, e.CPUUsage*100, e.CPUAllocation*100, e.MemoryUsage*100, e.MemoryAllocation*100)
+		namespaces := make([]string, 0, len(health.NamespaceEfficiency))
+		for ns := range health.NamespaceEfficiency {
+			namespaces = append(namespaces, ns)
+		}
+		sort.Slice(namespaces, func(i, j int) bool {
+			return health.NamespaceEfficiency[namespaces[i]].Score < health.NamespaceEfficiency[namespaces[j]].Score
+		})
+		for i, ns := range namespaces {
+			if i >= 3 {
+				break
+			}
+			fmt.Printf("  Least efficient: %s %d/100\n", ns, health.NamespaceEfficiency[ns].Score)
+		}
+	}
 	fmt.Printf("Pod Issues: %d pending, %d failed\n", health.PendingPods, health.FailedPods)
 	fmt.Printf("Critical Components: %v\n", health.CriticalComponentsOK)
 	fmt.Printf("Pressure Conditions: %d memory, %d disk, %d PID, %d network\n",
 		health.MemoryPressureNodes, health.DiskPressureNodes, health.PIDPressureNodes, health.NetworkUnavailableNodes)
+	if len(health.MaintenanceWindows) > 0 {
+		fmt.Printf("Maintenance Windows Active: %s (alerts silenced)\n", strings.Join(health.MaintenanceWindows, ", "))
+	}
+	for _, state := range health.NodeStates {
+		switch {
+		case state.Flapping && !state.Down:
+			fmt.Printf("  [flapping] %s: %d readiness changes in the last hour, now %s\n", state.Node, state.Transitions, state.State)
+		case state.State != nodestate.Ready:
+			label := "not ready"
+			if state.Down {
+				label = "down"
+			}
+			fmt.Printf("  [%s] %s: %s for %s\n", label, state.Node, state.State, state.Duration.Round(time.Second))
+		}
+	}
+	if len(health.Taints.CordonedNodes) > 0 {
+		fmt.Printf("Cordoned Nodes: %d (%.1f cores, %.1f GiB unavailable)\n",
+			len(health.Taints.CordonedNodes), health.Taints.CordonedCPU, health.Taints.CordonedMemory)
+		for _, c := range health.Taints.CordonedNodes {
+			if c.Duration > 0 {
+				fmt.Printf("  %s: cordoned for %s\n", c.Node, c.Duration.Round(time.Minute))
+			} else {
+				fmt.Printf("  %s: cordoned\n", c.Node)
+			}
+		}
+	}
+	for _, t := range health.Taints.TaintedNodes {
+		fmt.Printf("Tainted Node %s: %s\n", t.Node, strings.Join(t.Taints, ", "))
+	}
+	if len(health.Taints.BlockedPods) > 0 {
+		fmt.Printf("Pods Blocked by Taints: %d\n", len(health.Taints.BlockedPods))
+	}
+	for _, c := range health.DaemonSets {
+		if len(c.MissingNodes) > 0 {
+			fmt.Printf("DaemonSet %s/%s: %d/%d nodes, missing on %s\n", c.Namespace, c.Name,
+				c.CoveredNodes, c.EligibleNodes, strings.Join(c.MissingNodes, ", "))
+		}
+	}
+	fmt.Printf("Kubelet Versions: %s\n", versionCounts(health.Inventory.Versions[clusterhealth.ComponentKubelet]))
+	fmt.Printf("Container Runtimes: %s\n", versionCounts(health.Inventory.Versions[clusterhealth.ComponentRuntime]))
+	for _, f := range health.Inventory.Denied {
+		fmt.Printf("  [denylisted] %s: %s %s (%s)\n", f.Node, f.Component, f.Version, f.Reason)
+	}
+	if len(health.Inventory.Outliers) > 0 {
+		fmt.Printf("Version Outliers: %d nodes differ from the fleet\n", len(health.Inventory.Outliers))
+	}
+	for _, status := range health.APIServerLatency {
+		fmt.Printf("API Server %s Latency: p50 %.0fms, p95 %.0fms, p99 %.0fms", status.Verb, status.P50, status.P95, status.P99)
+		if status.Severity != "" {
+			fmt.Printf(" [%s: %s]", status.Severity, status.Reason)
+		}
+		fmt.Println()
+	}
+	if len(health.CloudOrphans) > 0 {
+		fmt.Printf("Orphaned Cloud Resources: %d (~$%.2f/month)\n", len(health.CloudOrphans), orphans.MonthlyCost(health.CloudOrphans))
+		for _, o := range health.CloudOrphans {
+			fmt.Printf("  %s %s: %s (%s)\n", o.Kind, o.ID, o.Reason, o.Owner)
+		}
+	}
+	if len(health.StaleFinalizers) > 0 {
+		fmt.Printf("Stale Finalizers: %d\n", len(health.StaleFinalizers))
+		for _, f := range health.StaleFinalizers {
+			state := ""
+			switch {
+			case f.Removed:
+				state = " [removed]"
+			case f.Blocking():
+				state = " [blocking deletion]"
+			}
+			name := f.Name
+			if f.Namespace != "" {
+				name = f.Namespace + "/" + name
+			}
+			fmt.Printf("  %s %s: %s, %s%s\n", f.Kind, name, f.Finalizer, f.Reason, state)
+		}
+	}
+	for _, c := range health.ExternalAlerts {
+		title := fmt.Sprintf("External Alert [%s] %s: %s", c.Alert.Source, c.Alert.Name, c.Alert.Summary)
+		if len(c.Issues) == 0 {
+			fmt.Println(title)
+			continue
+		}
+		printIssues(fmt.Sprintf("%s, with issues on the %s", title, strings.TrimPrefix(c.Reason, "same ")), c.Issues)
+	}
+	printIssues("Anomalies", health.Anomalies)
+	printIssues("Plugin Issues", health.PluginIssues)
+	printIssues("Configuration Drift", health.ConfigDrift)
 
 	if costReport != nil {
 		fmt.Println("\n--- Cost Report ---")
 		fmt.Printf("Total Cost: $%.2f/hour, $%.2f/month\n", costReport.TotalCostPerHour, costReport.TotalCostPerMonth)
+		if len(costReport.CostByOS) > 1 {
+			systems := make([]string, 0, len(costReport.CostByOS))
+			for system := range costReport.CostByOS {
+				systems = append(systems, system)
+			}
+			sort.Strings(systems)
+			for _, system := range systems {
+				fmt.Printf("  %s nodes: $%.2f/hour\n", system, costReport.CostByOS[system])
+			}
+		}
 
 		fmt.Println("\nTop 5 Namespace Costs:")
 		count := 0
@@ -673,7 +3039,122 @@ func printSummary(health *ClusterHealth, costReport *CostReport) {
 			fmt.Printf("  [%s/%s] %s - Potential savings: $%.2f/month\n",
 				rec.Namespace, rec.Resource, rec.Description, rec.Savings)
 		}
+
+		if lifecycle := costReport.Lifecycle; lifecycle != nil && len(lifecycle.Options) > 0 {
+			fmt.Println("\nNode Purchase Options:")
+			for _, option := range lifecycle.Options {
+				fmt.Printf("  %s: %d nodes, $%.2f/hour (%.0f%%), %.1f%% utilized\n",
+					option.Option, option.Nodes, option.CostPerHour, option.Share*100, option.Utilization)
+			}
+			for _, rec := range lifecycle.Recommendations {
+				fmt.Printf("  %s - Potential savings: $%.2f/month\n", rec.Description, rec.SavingsPerMonth)
+			}
+		}
+
+		if len(costReport.Sidecars) > 0 {
+			fmt.Println("\nSidecar Overhead:")
+			for i, o := range costReport.Sidecars {
+				if i >= 5 {
+					break
+				}
+				fmt.Printf("  [%s] %s: %.0f%% of CPU and %.0f%% of memory requests, $%.2f/month\n",
+					o.Namespace, strings.Join(o.Sidecars, ", "), o.CPUShare*100, o.MemoryShare*100, o.CostPerHour*24*30)
+			}
+		}
+
+		if arm := costReport.ARM; arm != nil {
+			fmt.Println("\nARM Migration:")
+			fmt.Printf("  %d workloads could move to ARM nodes (%d ARM nodes already in the cluster) - Potential savings: $%.2f/month\n",
+				len(arm.Candidates), arm.ARMNodes, arm.SavingsPerMonth)
+			for i, b := range arm.Blocking {
+				if i >= 5 {
+					break
+				}
+				reason := "no " + multiarch.TargetPlatform + " build"
+				if b.Error != "" {
+					reason = b.Error
+				}
+				fmt.Printf("  Blocked by %s (%s): %d workloads, $%.2f/month\n", b.Image, reason, len(b.Workloads), b.SavingsPerMonth)
+			}
+		}
+
+		if c := costReport.Commitments; c != nil {
+			fmt.Println("\nCommitment Coverage:")
+			fmt.Printf("  Steady spend $%.2f/hour, covered $%.2f/hour by %d commitments\n", c.Baseline, c.Covered, len(c.Commitments))
+			if rec := c.Recommendation; rec != nil {
+				fmt.Printf("  Uncovered $%.2f/hour: commit $%.2f/hour, used %.0f%% of hours (break-even %.0f%%) - Potential savings: $%.2f/month\n",
+					c.Uncovered, rec.HourlyCommitment, rec.Utilization*100, rec.BreakEven*100, rec.SavingsPerMonth)
+			}
+			if c.OverCovered > 0 {
+				fmt.Printf("  Over-committed by $%.2f/hour above the steady spend\n", c.OverCovered)
+			}
+			for _, e := range c.Expiring {
+				fmt.Printf("  %s %s expires %s\n", e.Kind, e.ID, e.End.Format("2006-01-02"))
+			}
+		}
+
+		if t := costReport.Transfer; t != nil && len(t.Services) > 0 {
+			fmt.Println("\nCross-Zone Traffic:")
+			if t.TrafficKnown {
+				fmt.Printf("  Estimated $%.2f/month across %d zones\n", t.CostPerMonth, len(t.Zones))
+			}
+			for i, svc := range t.Services {
+				if i >= 5 {
+					break
+				}
+				fmt.Printf("  [%s/%s] %.0f%% of requests cross zones, $%.2f/month - %s\n",
+					svc.Namespace, svc.Name, svc.CrossZoneShare*100, svc.CostPerMonth, svc.Recommendation)
+			}
+		}
+	}
+
+	if len(sloStatuses) > 0 {
+		fmt.Println("\n--- SLOs ---")
+		for _, status := range sloStatuses {
+			state := "met"
+			if !status.Met {
+				state = "MISSED"
+			}
+			fmt.Printf("  %s: %.3f%% of %.3f%% (%s), %.0f%% budget left, 1h burn %.1fx\n",
+				status.Objective.Name, status.Availability*100, status.Objective.Target*100, state,
+				status.ErrorBudgetRemaining*100, status.BurnRate1h)
+		}
 	}
 
 	fmt.Println("\n=====================================================")
 }
+
+// printIssues prints a titled list of issues, if there are any
+func printIssues(title string, issues []clusterhealth.HealthIssue) {
+	if len(issues) == 0 {
+		return
+	}
+	fmt.Printf("%s:\n", title)
+	for _, issue := range issues {
+		subject := issue.Name
+		if issue.Namespace != "" {
+			subject = fmt.Sprintf("%s %s/%s", issue.Resource, issue.Namespace, issue.Name)
+		}
+		fmt.Printf("  [%s] %s %s: %s\n", issue.Severity, issue.Type, subject, issue.Message)
+	}
+}
+
+// versionCounts formats versions and their node counts, most common first
+func versionCounts(versions map[string][]string) string {
+	names := make([]string, 0, len(versions))
+	for version := range versions {
+		names = append(names, version)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if len(versions[names[i]]) != len(versions[names[j]]) {
+			return len(versions[names[i]]) > len(versions[names[j]])
+		}
+		return names[i] < names[j]
+	})
+
+	parts := make([]string, 0, len(names))
+	for _, version := range names {
+		parts = append(parts, fmt.Sprintf("%s (%d)", version, len(versions[version])))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -16,6 +16,7 @@ import (
 
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
@@ -81,7 +82,6 @@ func main() {
 	ticker := time.NewTicker(config.CheckInterval)
 	defer ticker.Stop()
 
-
 	// Run first check immediately
 	if err := generateReport(ctx, clientset, metricsClient, pricing, config); err != nil {
 		log.Printf("Failed to generate initial report: %v", err)
@@ -95,31 +95,31 @@ func main() {
 	}
 
 	// Run resource optimization analysis
-    optimizer := NewResourceOptimizer(clientset, metricsClient)
-    report, err := optimizer.GenerateOptimizationReport(context.Background())
-    if err != nil {
-        log.Fatal(err)
-    }
-    
-    fmt.Printf("Potential Monthly Savings: $%.2f\n", report.PotentialSavings)
-    fmt.Printf("Optimization Recommendations: %d\n", len(report.Recommendations))
-    
-    for _, rec := range report.Recommendations {
-        fmt.Printf("- %s: %s (Save $%.2f/month)\n", 
-            rec.Type, rec.Description, rec.PotentialSaving)
-    }
-    
-    // Run cleanup with dry-run
-    cleanupRecs, err := CleanupUnusedResources(context.Background(), clientset, true)
-    if err != nil {
-        log.Fatal(err)
-    }
-    
-    fmt.Printf("\nCleanup Recommendations: %d\n", len(cleanupRecs))
-    for _, rec := range cleanupRecs {
-        fmt.Printf("- Delete %s %s/%s: %s\n", 
-            rec.ResourceType, rec.Namespace, rec.Name, rec.Reason)
-    }
+	optimizer := NewResourceOptimizer(clientset, metricsClient)
+	report, err := optimizer.GenerateOptimizationReport(context.Background())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("Potential Monthly Savings: $%.2f\n", report.PotentialSavings)
+	fmt.Printf("Optimization Recommendations: %d\n", len(report.Recommendations))
+
+	for _, rec := range report.Recommendations {
+		fmt.Printf("- %s: %s (Save $%.2f/month)\n",
+			rec.Type, rec.Description, rec.PotentialSaving)
+	}
+
+	// Run cleanup with dry-run
+	cleanupRecs, err := CleanupUnusedResources(context.Background(), clientset, true)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("\nCleanup Recommendations: %d\n", len(cleanupRecs))
+	for _, rec := range cleanupRecs {
+		fmt.Printf("- Delete %s %s/%s: %s\n",
+			rec.ResourceType, rec.Namespace, rec.Name, rec.Reason)
+	}
 }
 
 // parseFlags parses command line flags and returns configuration
@@ -301,16 +301,6 @@ func RunHealthCheck(ctx context.Context, clientset *kubernetes.Clientset, metric
 		return nil, err
 	}
 
-	summary := map[string]interface{}{
-		"healthScore":    healthData.HealthScore,
-		"readyNodes":     healthData.NodeStatus.ReadyNodes,
-		"totalNodes":     healthData.NodeStatus.TotalNodes,
-		"runningPods":    healthData.PodStatus.RunningPods,
-		"totalPods":      healthData.PodStatus.TotalPods,
-		"criticalIssues": countIssuesBySeverity(healthData.Issues, "critical"),
-		"warningIssues":  countIssuesBySeverity(healthData.Issues, "warning"),
-		"cpuUsage":       healthData.ResourceUsage.ClusterCPUUsage,
-		"memoryUsage":    healthData.ResourceUsage.ClusterMemoryUsage,
 	summary := map[string]interface{}{
 		"healthScore":    healthData.HealthScore,
 		"readyNodes":     healthData.NodeStatus.ReadyNodes,
@@ -322,13 +312,12 @@ func RunHealthCheck(ctx context.Context, clientset *kubernetes.Clientset, metric
 		"cpuUsage":       healthData.ResourceUsage.ClusterCPUUsage,
 		"memoryUsage":    healthData.ResourceUsage.ClusterMemoryUsage,
 	}
-	}
 	return summary, nil
 }
 
 // filterIssuesBySeverity filters issues by their severity level
-func filterIssuesBySeverity(issues []health.Issue, severity string) []health.Issue {
-	filtered := make([]health.Issue, 0)
+func filterIssuesBySeverity(issues []health.HealthIssue, severity string) []health.HealthIssue {
+	filtered := make([]health.HealthIssue, 0)
 	for _, issue := range issues {
 		if issue.Severity == severity {
 			filtered = append(filtered, issue)
@@ -336,6 +325,14 @@ func filterIssuesBySeverity(issues []health.Issue, severity string) []health.Iss
 	}
 	return filtered
 }
+
+// ForecastCosts projects the monthly cost over the next months from current node costs
+func ForecastCosts(ctx context.Context, clientset *kubernetes.Clientset, metricsClient *metricsv.Clientset, pricing map[string]cost.ResourcePricing, months int) (*CostForecast, error) {
+	nodeCosts, err := cost.GetNodeCosts(ctx, clientset, metricsClient, pricing)
+	if err != nil {
+		return nil, err
+	}
+
 	currentMonthlyTotal := 0.0
 	for _, node := range nodeCosts {
 		currentMonthlyTotal += node.TotalCost * 24 * 30
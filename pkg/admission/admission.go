@@ -0,0 +1,378 @@
+package admission
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/anomaly"
+	"github.com/ochestra-tech/ochestra-ai/pkg/history"
+	"github.com/ochestra-tech/ochestra-ai/pkg/snapshot"
+)
+
+// Series recorded per container for the webhook's peaks
+const (
+	MetricContainerCPU    = "containerCPU"    // millicores of a workload's container, the most any of its pods uses
+	MetricContainerMemory = "containerMemory" // bytes
+	MetricImageCPU        = "imageCPU"        // millicores, the most any container running an image uses
+	MetricImageMemory     = "imageMemory"     // bytes
+)
+
+// FindingsAnnotation is set by the mutating webhook on workloads with findings
+const FindingsAnnotation = "ochestra.io/admission-findings"
+
+// Series returns the usage of each workload container and each image, from the snapshot's
+// pod metrics, keyed like anomaly series: "containerCPU|payments/Deployment/api/server" or
+// "imageMemory|ghcr.io/acme/api"
+func Series(snap *snapshot.ClusterSnapshot) map[string]float64 {
+	series := make(map[string]float64)
+	if snap.Errors["podMetrics"] != nil {
+		return series
+	}
+	pods := make(map[string]*v1.Pod, len(snap.Pods))
+	for i := range snap.Pods {
+		pods[snap.Pods[i].Namespace+"/"+snap.Pods[i].Name] = &snap.Pods[i]
+	}
+	for _, pm := range snap.PodMetrics {
+		pod, ok := pods[pm.Namespace+"/"+pm.Name]
+		if !ok {
+			continue
+		}
+		images := make(map[string]string, len(pod.Spec.Containers))
+		for _, c := range pod.Spec.Containers {
+			images[c.Name] = imageRepository(c.Image)
+		}
+		kind, name := snapshot.WorkloadOwner(pod)
+		for _, c := range pm.Containers {
+			cpu, mem := float64(c.Usage.Cpu().MilliValue()), float64(c.Usage.Memory().Value())
+			subject := fmt.Sprintf("%s/%s/%s/%s", pod.Namespace, kind, name, c.Name)
+			keepMax(series, anomaly.SeriesKey(MetricContainerCPU, subject), cpu)
+			keepMax(series, anomaly.SeriesKey(MetricContainerMemory, subject), mem)
+			if image := images[c.Name]; image != "" {
+				keepMax(series, anomaly.SeriesKey(MetricImageCPU, image), cpu)
+				keepMax(series, anomaly.SeriesKey(MetricImageMemory, image), mem)
+			}
+		}
+	}
+	return series
+}
+
+// keepMax records value under key unless a larger one is there
+func keepMax(series map[string]float64, key string, value float64) {
+	if current, ok := series[key]; !ok || value > current {
+		series[key] = value
+	}
+}
+
+// imageRepository strips the tag and digest from an image reference
+func imageRepository(image string) string {
+	image, _, _ = strings.Cut(image, "@")
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		image = image[:i]
+	}
+	return image
+}
+
+// peak is the highest recorded value of a series over the lookback
+type peak struct {
+	value   float64
+	samples int
+}
+
+// Finding is a rule a workload breaks
+type Finding struct {
+	Rule    string `json:"rule"`
+	Action  string `json:"action"`
+	Message string `json:"message"`
+}
+
+// Webhook reviews workloads as they are created or their pod templates change, against
+// the peak usage recorded in the history
+type Webhook struct {
+	policy  Policy
+	store   history.Store
+	cluster string
+
+	mu    sync.RWMutex
+	peaks map[string]peak // series key -> peak over the lookback
+}
+
+// NewWebhook creates a webhook reading peaks from store
+func NewWebhook(policy Policy, store history.Store, cluster string) *Webhook {
+	return &Webhook{policy: policy, store: store, cluster: cluster, peaks: make(map[string]peak)}
+}
+
+// Refresh reloads the peaks from the history, once per monitoring cycle, so admission
+// requests are answered without reading the store
+func (w *Webhook) Refresh(ctx context.Context, now time.Time) error {
+	snapshots, err := w.store.List(ctx, history.Query{Cluster: w.cluster, Since: now.Add(-w.policy.lookback)})
+	if err != nil {
+		return fmt.Errorf("failed to read history for admission peaks: %w", err)
+	}
+	peaks := make(map[string]peak)
+	for _, s := range snapshots {
+		for key, value := range s.Series {
+			metric, _, _ := strings.Cut(key, "|")
+			if metric != MetricContainerCPU && metric != MetricContainerMemory && metric != MetricImageCPU && metric != MetricImageMemory {
+				continue
+			}
+			p := peaks[key]
+			p.value = max(p.value, value)
+			p.samples++
+			peaks[key] = p
+		}
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.peaks = peaks
+	return nil
+}
+
+// Review returns the findings for an admission request: an empty slice for a workload
+// without any, and nil when the request does not create a workload or change its pod
+// template, so there was nothing to review
+func (w *Webhook) Review(req *admissionv1.AdmissionRequest) ([]Finding, error) {
+	if req.Operation != admissionv1.Create && req.Operation != admissionv1.Update {
+		return nil, nil
+	}
+	if w.policy.exempt(req.Namespace) {
+		return nil, nil
+	}
+	meta, spec, err := podTemplate(req.Kind.Kind, req.Object.Raw)
+	if err != nil || spec == nil {
+		return nil, err
+	}
+	// Pods created by controllers were reviewed with their controller
+	if req.Kind.Kind == "Pod" && metav1.GetControllerOf(meta) != nil {
+		return nil, nil
+	}
+	if req.Operation == admissionv1.Update {
+		_, oldSpec, err := podTemplate(req.Kind.Kind, req.OldObject.Raw)
+		if err == nil && oldSpec != nil && equality.Semantic.DeepEqual(spec, oldSpec) {
+			return nil, nil
+		}
+	}
+
+	name := meta.Name
+	if name == "" {
+		name = meta.GenerateName
+	}
+	workload := fmt.Sprintf("%s/%s/%s", req.Namespace, req.Kind.Kind, name)
+	longRunning := req.Kind.Kind != "Job" && req.Kind.Kind != "CronJob"
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	findings := []Finding{}
+	add := func(rule, message string) {
+		if action := w.policy.action(rule); action != ActionIgnore {
+			findings = append(findings, Finding{Rule: rule, Action: action, Message: message})
+		}
+	}
+	for _, c := range spec.Containers {
+		if message := w.oversized(workload, &c); message != "" {
+			add(RuleOversized, message)
+		}
+		if longRunning && (c.ReadinessProbe == nil || c.LivenessProbe == nil) {
+			var missing []string
+			if c.ReadinessProbe == nil {
+				missing = append(missing, "readiness")
+			}
+			if c.LivenessProbe == nil {
+				missing = append(missing, "liveness")
+			}
+			add(RuleMissingProbes, fmt.Sprintf("container %q has no %s probe", c.Name, strings.Join(missing, " or ")))
+		}
+		if _, ok := c.Resources.Limits[v1.ResourceMemory]; !ok {
+			add(RuleMissingLimits, fmt.Sprintf("container %q has no memory limit", c.Name))
+		}
+	}
+	return findings, nil
+}
+
+// oversized describes how far a container's requests exceed the peak usage of the same
+// container in earlier versions of the workload, or else of containers running its image.
+// It returns an empty string when the requests are in line or nothing similar has run.
+func (w *Webhook) oversized(workload string, c *v1.Container) string {
+	container := workload + "/" + c.Name
+	image := imageRepository(c.Image)
+	var excess []string
+	var source string
+	for _, r := range []struct {
+		resource         v1.ResourceName
+		containerMetric  string
+		imageMetric      string
+		minExcess, scale float64
+		format           func(float64) string
+	}{
+		{v1.ResourceCPU, MetricContainerCPU, MetricImageCPU, float64(w.policy.MinCPUMillis), 1000, formatMillis},
+		{v1.ResourceMemory, MetricContainerMemory, MetricImageMemory, float64(w.policy.MinMemoryMB << 20), 1, formatBytes},
+	} {
+		quantity, ok := c.Resources.Requests[r.resource]
+		if !ok {
+			continue
+		}
+		request := quantity.AsApproximateFloat64() * r.scale
+		p, from := w.peaks[anomaly.SeriesKey(r.containerMetric, container)], "this workload"
+		if p.samples < w.policy.MinSamples {
+			p, from = w.peaks[anomaly.SeriesKey(r.imageMetric, image)], "containers running "+image
+		}
+		if p.samples < w.policy.MinSamples || request < w.policy.Factor*p.value || request-p.value < r.minExcess {
+			continue
+		}
+		excess = append(excess, fmt.Sprintf("%s %s (peak %s)", r.resource, r.format(request), r.format(p.value)))
+		source = from
+	}
+	if len(excess) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("container %q requests %s, over %gx what %s used in the last %s",
+		c.Name, strings.Join(excess, " and "), w.policy.Factor, source, w.policy.lookback)
+}
+
+// formatMillis formats millicores like a CPU quantity
+func formatMillis(millis float64) string {
+	return fmt.Sprintf("%.0fm", millis)
+}
+
+// formatBytes formats bytes in MiB
+func formatBytes(bytes float64) string {
+	return fmt.Sprintf("%.0fMi", bytes/(1<<20))
+}
+
+// podTemplate decodes an object of a kind that runs pods and returns its metadata and pod
+// spec, or a nil spec for other kinds
+func podTemplate(kind string, raw []byte) (*metav1.ObjectMeta, *v1.PodSpec, error) {
+	var err error
+	switch kind {
+	case "Deployment":
+		var obj appsv1.Deployment
+		if err = json.Unmarshal(raw, &obj); err == nil {
+			return &obj.ObjectMeta, &obj.Spec.Template.Spec, nil
+		}
+	case "StatefulSet":
+		var obj appsv1.StatefulSet
+		if err = json.Unmarshal(raw, &obj); err == nil {
+			return &obj.ObjectMeta, &obj.Spec.Template.Spec, nil
+		}
+	case "DaemonSet":
+		var obj appsv1.DaemonSet
+		if err = json.Unmarshal(raw, &obj); err == nil {
+			return &obj.ObjectMeta, &obj.Spec.Template.Spec, nil
+		}
+	case "Job":
+		var obj batchv1.Job
+		if err = json.Unmarshal(raw, &obj); err == nil {
+			return &obj.ObjectMeta, &obj.Spec.Template.Spec, nil
+		}
+	case "CronJob":
+		var obj batchv1.CronJob
+		if err = json.Unmarshal(raw, &obj); err == nil {
+			return &obj.ObjectMeta, &obj.Spec.JobTemplate.Spec.Template.Spec, nil
+		}
+	case "Pod":
+		var obj v1.Pod
+		if err = json.Unmarshal(raw, &obj); err == nil {
+			return &obj.ObjectMeta, &obj.Spec, nil
+		}
+	default:
+		return nil, nil, nil
+	}
+	return nil, nil, fmt.Errorf("failed to decode %s: %w", kind, err)
+}
+
+// maxReviewSize bounds the admission reviews the webhook reads
+const maxReviewSize = 8 << 20
+
+// ServeHTTP answers AdmissionReviews at /validate, admitting with warnings or denying per
+// the policy, and at /mutate, annotating workloads with their findings. It never fails a
+// request over its own errors; the webhook configurations should also ignore failures.
+func (w *Webhook) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	var review admissionv1.AdmissionReview
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxReviewSize)).Decode(&review); err != nil || review.Request == nil {
+		http.Error(rw, "expected an AdmissionReview", http.StatusBadRequest)
+		return
+	}
+	req := review.Request
+	response := &admissionv1.AdmissionResponse{UID: req.UID, Allowed: true}
+
+	findings, err := w.Review(req)
+	if err != nil {
+		log.Printf("Failed to review %s %s/%s: %v", req.Kind.Kind, req.Namespace, req.Name, err)
+	}
+	if strings.HasSuffix(r.URL.Path, "/mutate") {
+		if patch := annotationPatch(req.Object.Raw, findings); findings != nil && patch != nil {
+			patchType := admissionv1.PatchTypeJSONPatch
+			response.Patch, response.PatchType = patch, &patchType
+		}
+	} else {
+		var denied []string
+		for _, f := range findings {
+			response.Warnings = append(response.Warnings, "ochestra: "+f.Message)
+			if f.Action == ActionDeny {
+				denied = append(denied, f.Message)
+			}
+		}
+		if len(denied) > 0 {
+			response.Allowed = false
+			response.Result = &metav1.Status{
+				Status:  metav1.StatusFailure,
+				Code:    http.StatusForbidden,
+				Reason:  metav1.StatusReasonForbidden,
+				Message: "denied by the ochestra admission policy: " + strings.Join(denied, "; "),
+			}
+		}
+	}
+
+	review.Request, review.Response = nil, response
+	rw.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(rw).Encode(review)
+}
+
+// annotationPatch returns a JSON patch setting FindingsAnnotation to the findings. With
+// none, it removes the annotation left by an earlier version of the workload, and returns
+// nil if there is no such annotation.
+func annotationPatch(raw []byte, findings []Finding) []byte {
+	var obj struct {
+		Metadata struct {
+			Annotations map[string]string `json:"annotations"`
+		} `json:"metadata"`
+	}
+	json.Unmarshal(raw, &obj)
+	_, annotated := obj.Metadata.Annotations[FindingsAnnotation]
+	path := "/metadata/annotations/" + strings.ReplaceAll(strings.ReplaceAll(FindingsAnnotation, "~", "~0"), "/", "~1")
+
+	var op map[string]interface{}
+	switch {
+	case len(findings) == 0 && !annotated:
+		return nil
+	case len(findings) == 0:
+		op = map[string]interface{}{"op": "remove", "path": path}
+	default:
+		messages := make([]string, len(findings))
+		for i, f := range findings {
+			messages[i] = f.Message
+		}
+		value := strings.Join(messages, "; ")
+		if obj.Metadata.Annotations == nil {
+			op = map[string]interface{}{"op": "add", "path": "/metadata/annotations", "value": map[string]string{FindingsAnnotation: value}}
+		} else {
+			op = map[string]interface{}{"op": "add", "path": path, "value": value}
+		}
+	}
+	patch, _ := json.Marshal([]interface{}{op})
+	return patch
+}
@@ -0,0 +1,108 @@
+package admission
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/history"
+)
+
+// deployment returns a Deployment running one container, which has probes and a memory
+// limit unless bare is set, carrying the given annotations
+func deployment(bare bool, annotations map[string]string) runtime.RawExtension {
+	c := v1.Container{Name: "app", Image: "ghcr.io/acme/api:1.0"}
+	if !bare {
+		probe := &v1.Probe{ProbeHandler: v1.ProbeHandler{HTTPGet: &v1.HTTPGetAction{Path: "/healthz"}}}
+		c.ReadinessProbe, c.LivenessProbe = probe, probe
+		c.Resources.Limits = v1.ResourceList{v1.ResourceMemory: resource.MustParse("512Mi")}
+	}
+	obj := appsv1.Deployment{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: "payments", Name: "api", Annotations: annotations},
+		Spec:       appsv1.DeploymentSpec{Template: v1.PodTemplateSpec{Spec: v1.PodSpec{Containers: []v1.Container{c}}}},
+	}
+	raw, _ := json.Marshal(obj)
+	return runtime.RawExtension{Raw: raw}
+}
+
+func TestMutateAnnotation(t *testing.T) {
+	annotated := map[string]string{FindingsAnnotation: `container "app" has no memory limit`, "team": "payments"}
+
+	tests := []struct {
+		name      string
+		operation admissionv1.Operation
+		object    runtime.RawExtension
+		oldObject runtime.RawExtension
+		want      string // JSON patch, "" for none
+	}{
+		{
+			name:      "create with findings",
+			operation: admissionv1.Create,
+			object:    deployment(true, nil),
+			want:      `[{"op":"add","path":"/metadata/annotations","value":{"ochestra.io/admission-findings":"container \"app\" has no readiness or liveness probe; container \"app\" has no memory limit"}}]`,
+		},
+		{
+			name:      "create without findings",
+			operation: admissionv1.Create,
+			object:    deployment(false, nil),
+		},
+		{
+			name:      "update with findings keeps other annotations",
+			operation: admissionv1.Update,
+			object:    deployment(true, map[string]string{"team": "payments"}),
+			oldObject: deployment(false, nil),
+			want:      `[{"op":"add","path":"/metadata/annotations/ochestra.io~1admission-findings","value":"container \"app\" has no readiness or liveness probe; container \"app\" has no memory limit"}]`,
+		},
+		{
+			name:      "update fixing the findings removes the annotation",
+			operation: admissionv1.Update,
+			object:    deployment(false, annotated),
+			oldObject: deployment(true, annotated),
+			want:      `[{"op":"remove","path":"/metadata/annotations/ochestra.io~1admission-findings"}]`,
+		},
+		{
+			name:      "update leaving the pod template alone keeps the annotation",
+			operation: admissionv1.Update,
+			object:    deployment(true, annotated),
+			oldObject: deployment(true, annotated),
+		},
+	}
+	webhook := NewWebhook(DefaultPolicy, history.NewMemoryStore(), "test")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			review := admissionv1.AdmissionReview{Request: &admissionv1.AdmissionRequest{
+				UID:       "1",
+				Kind:      metav1.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"},
+				Namespace: "payments",
+				Name:      "api",
+				Operation: tt.operation,
+				Object:    tt.object,
+				OldObject: tt.oldObject,
+			}}
+			body, _ := json.Marshal(review)
+			rec := httptest.NewRecorder()
+			webhook.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/mutate", bytes.NewReader(body)))
+
+			var got admissionv1.AdmissionReview
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil || got.Response == nil {
+				t.Fatalf("invalid response %s: %v", rec.Body.String(), err)
+			}
+			if !got.Response.Allowed {
+				t.Errorf("request denied: %v", got.Response.Result)
+			}
+			if string(got.Response.Patch) != tt.want {
+				t.Errorf("patch = %s, want %s", got.Response.Patch, tt.want)
+			}
+		})
+	}
+}
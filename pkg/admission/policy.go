@@ -0,0 +1,98 @@
+package admission
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+)
+
+// Actions taken on a finding
+const (
+	ActionIgnore = "ignore"
+	ActionWarn   = "warn" // admit with a warning shown by kubectl
+	ActionDeny   = "deny" // reject at the validating webhook
+)
+
+// Rules a workload is reviewed against
+const (
+	RuleOversized     = "oversized"     // requests far above what similar workloads use
+	RuleMissingProbes = "missingProbes" // long-running containers without readiness or liveness probes
+	RuleMissingLimits = "missingLimits" // containers without a memory limit
+)
+
+// Policy sets what the webhook does about each rule and when requests count as oversized
+type Policy struct {
+	Actions          map[string]string `json:"actions"`          // rule -> action, warn if unset
+	Factor           float64           `json:"factor"`           // request at this multiple of the peak usage is oversized
+	MinCPUMillis     int64             `json:"minCPUMillis"`     // smallest CPU excess worth reporting
+	MinMemoryMB      int64             `json:"minMemoryMB"`      // smallest memory excess worth reporting
+	MinSamples       int               `json:"minSamples"`       // recorded samples a peak needs before it is trusted
+	Lookback         string            `json:"lookback"`         // history the peaks are taken over
+	ExemptNamespaces []string          `json:"exemptNamespaces"` // never reviewed
+	lookback         time.Duration
+}
+
+// DefaultPolicy warns on every rule, flagging requests at four times a week's peak usage
+var DefaultPolicy = Policy{
+	Factor:           4,
+	MinCPUMillis:     250,
+	MinMemoryMB:      512,
+	MinSamples:       12,
+	Lookback:         "168h",
+	ExemptNamespaces: []string{"kube-system"},
+	lookback:         7 * 24 * time.Hour,
+}
+
+// LoadPolicy reads a policy from a JSON file, taking unset fields from DefaultPolicy
+func LoadPolicy(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read admission policy: %w", err)
+	}
+
+	policy := DefaultPolicy
+	policy.Actions = nil
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("failed to parse admission policy: %w", err)
+	}
+	if err := policy.init(); err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// init validates the policy and parses its lookback
+func (p *Policy) init() error {
+	for rule, action := range p.Actions {
+		if rule != RuleOversized && rule != RuleMissingProbes && rule != RuleMissingLimits {
+			return fmt.Errorf("unknown admission rule %q", rule)
+		}
+		if action != ActionIgnore && action != ActionWarn && action != ActionDeny {
+			return fmt.Errorf("%s: unknown action %q", rule, action)
+		}
+	}
+	if p.Factor <= 1 {
+		return fmt.Errorf("factor must be above 1")
+	}
+	lookback, err := time.ParseDuration(p.Lookback)
+	if err != nil || lookback <= 0 {
+		return fmt.Errorf("invalid lookback %q", p.Lookback)
+	}
+	p.lookback = lookback
+	return nil
+}
+
+// action returns what to do about a rule
+func (p *Policy) action(rule string) string {
+	if action, ok := p.Actions[rule]; ok {
+		return action
+	}
+	return ActionWarn
+}
+
+// exempt reports whether a namespace is never reviewed
+func (p *Policy) exempt(namespace string) bool {
+	return slices.Contains(p.ExemptNamespaces, namespace)
+}
@@ -0,0 +1,362 @@
+package anomaly
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/health"
+	"github.com/ochestra-tech/ochestra-ai/pkg/history"
+	"github.com/ochestra-tech/ochestra-ai/pkg/notify"
+	"github.com/ochestra-tech/ochestra-ai/pkg/snapshot"
+)
+
+// Metrics recorded per workload, plus the cluster's API latency
+const (
+	MetricRestarts   = "restarts"   // cumulative container restarts, compared per interval
+	MetricCPU        = "cpu"        // millicores
+	MetricMemory     = "memory"     // bytes
+	MetricReady      = "ready"      // fraction of pods ready
+	MetricAPILatency = "apiLatency" // milliseconds to list nodes
+	MetricLoad       = "load"       // a workload's load signal, recorded per signal as "load:<signal>"
+)
+
+// metricSpec describes how a metric is compared with its baseline
+type metricSpec struct {
+	counter   bool    // compare the change since the previous sample instead of the value
+	direction int     // 1 to flag only rises, -1 only drops, 0 both
+	minDelta  float64 // smallest absolute deviation worth reporting
+	issueType string
+	label     string
+}
+
+var metrics = map[string]metricSpec{
+	MetricRestarts:   {counter: true, direction: 1, minDelta: 3, issueType: health.IssueRestartSpike, label: "restarts per interval"},
+	MetricCPU:        {direction: 0, minDelta: 50, issueType: health.IssueUsageAnomaly, label: "CPU usage (millicores)"},
+	MetricMemory:     {direction: 0, minDelta: 64 << 20, issueType: health.IssueUsageAnomaly, label: "memory usage (bytes)"},
+	MetricReady:      {direction: -1, minDelta: 0.2, issueType: health.IssueReadinessDrop, label: "ready pod fraction"},
+	MetricAPILatency: {direction: 1, minDelta: 100, issueType: health.IssueLatencyJump, label: "API latency (ms)"},
+}
+
+// Config controls anomaly detection
+type Config struct {
+	Alpha      float64       // EWMA smoothing factor; higher adapts to recent samples faster
+	Threshold  float64       // z-score at which a deviation is anomalous
+	MinSamples int           // samples a baseline needs before it is trusted
+	Lookback   time.Duration // history used to build baselines
+}
+
+// DefaultConfig is a conservative configuration suited to minute-level check intervals
+var DefaultConfig = Config{
+	Alpha:      0.2,
+	Threshold:  3,
+	MinSamples: 10,
+	Lookback:   24 * time.Hour,
+}
+
+// LoadMetric names the metric of a load signal, e.g. "load:rps"
+func LoadMetric(signal string) string {
+	return MetricLoad + ":" + signal
+}
+
+// specFor returns how a metric is compared, giving each load signal the spec of a
+// two-sided usage anomaly labeled with its name
+func specFor(metric string) (metricSpec, bool) {
+	if signal, ok := strings.CutPrefix(metric, MetricLoad+":"); ok {
+		return metricSpec{direction: 0, issueType: health.IssueUsageAnomaly, label: signal + " load signal"}, true
+	}
+	spec, ok := metrics[metric]
+	return spec, ok
+}
+
+// SeriesKey names a metric of a subject, e.g. "cpu|team-a/Deployment/api" or
+// "apiLatency|cluster"
+func SeriesKey(metric, subject string) string {
+	return metric + "|" + subject
+}
+
+// Collect computes the per-workload restart, usage and readiness series and the cluster
+// API latency from a snapshot
+func Collect(snap *snapshot.ClusterSnapshot) map[string]float64 {
+	series := make(map[string]float64)
+	pods := make(map[string]string) // namespace/pod -> workload subject
+	ready := make(map[string]int)
+	total := make(map[string]int)
+
+	for i := range snap.Pods {
+		pod := &snap.Pods[i]
+		if pod.Status.Phase == v1.PodSucceeded {
+			continue
+		}
+		kind, name := snapshot.WorkloadOwner(pod)
+		if kind == "Pod" {
+			continue
+		}
+		subject := fmt.Sprintf("%s/%s/%s", pod.Namespace, kind, name)
+		pods[pod.Namespace+"/"+pod.Name] = subject
+
+		restarts := 0.0
+		for _, cs := range pod.Status.ContainerStatuses {
+			restarts += float64(cs.RestartCount)
+		}
+		series[SeriesKey(MetricRestarts, subject)] += restarts
+
+		total[subject]++
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type == v1.PodReady && condition.Status == v1.ConditionTrue {
+				ready[subject]++
+			}
+		}
+	}
+	for subject, n := range total {
+		series[SeriesKey(MetricReady, subject)] = float64(ready[subject]) / float64(n)
+	}
+
+	if snap.Errors["podMetrics"] == nil {
+		for _, pm := range snap.PodMetrics {
+			subject, ok := pods[pm.Namespace+"/"+pm.Name]
+			if !ok {
+				continue
+			}
+			for _, c := range pm.Containers {
+				series[SeriesKey(MetricCPU, subject)] += float64(c.Usage.Cpu().MilliValue())
+				series[SeriesKey(MetricMemory, subject)] += float64(c.Usage.Memory().Value())
+			}
+		}
+	}
+
+	if snap.APILatency > 0 {
+		series[SeriesKey(MetricAPILatency, "cluster")] = float64(snap.APILatency.Milliseconds())
+	}
+	return series
+}
+
+// Baseline is the exponentially weighted mean and standard deviation of a series
+type Baseline struct {
+	Mean    float64
+	StdDev  float64
+	Samples int
+}
+
+// EWMA computes the exponentially weighted baseline of values ordered oldest first
+func EWMA(values []float64, alpha float64) Baseline {
+	if len(values) == 0 {
+		return Baseline{}
+	}
+	mean, variance := values[0], 0.0
+	for _, x := range values[1:] {
+		diff := x - mean
+		mean += alpha * diff
+		variance = (1 - alpha) * (variance + alpha*diff*diff)
+	}
+	return Baseline{Mean: mean, StdDev: math.Sqrt(variance), Samples: len(values)}
+}
+
+// ZScore returns how many standard deviations x is from the baseline. A flat baseline
+// gives an infinite score for any change.
+func (b Baseline) ZScore(x float64) float64 {
+	diff := x - b.Mean
+	if b.StdDev == 0 {
+		if diff == 0 {
+			return 0
+		}
+		return math.Copysign(math.Inf(1), diff)
+	}
+	return diff / b.StdDev
+}
+
+// Detect compares current series values with baselines built from past snapshots, ordered
+// oldest first, and returns an anomaly issue for each significant deviation
+func Detect(config Config, past []*history.Snapshot, current map[string]float64, now time.Time) []health.HealthIssue {
+	found := detect(config, past, current, now)
+	issues := make([]health.HealthIssue, 0, len(found))
+	for _, key := range sortedKeys(found) {
+		issues = append(issues, found[key])
+	}
+	return issues
+}
+
+// detect returns the anomalies keyed by series
+func detect(config Config, past []*history.Snapshot, current map[string]float64, now time.Time) map[string]health.HealthIssue {
+	found := make(map[string]health.HealthIssue)
+	for key, value := range current {
+		metric, subject, _ := strings.Cut(key, "|")
+		spec, ok := specFor(metric)
+		if !ok {
+			continue
+		}
+
+		values := make([]float64, 0, len(past))
+		for _, s := range past {
+			if v, ok := s.Series[key]; ok {
+				values = append(values, v)
+			}
+		}
+		if spec.counter {
+			if len(values) == 0 || value < values[len(values)-1] {
+				continue // no previous sample, or the counter reset
+			}
+			value -= values[len(values)-1]
+			values = deltas(values)
+		}
+		if len(values) < config.MinSamples {
+			continue
+		}
+
+		baseline := EWMA(values, config.Alpha)
+		z := baseline.ZScore(value)
+		if math.Abs(z) < config.Threshold || math.Abs(value-baseline.Mean) < spec.minDelta ||
+			(spec.direction > 0 && z < 0) || (spec.direction < 0 && z > 0) {
+			continue
+		}
+
+		found[key] = anomalyIssue(metric, subject, spec, value, baseline, z, config, now)
+	}
+	return found
+}
+
+// sortedKeys returns the series keys of anomalies in order
+func sortedKeys(found map[string]health.HealthIssue) []string {
+	keys := make([]string, 0, len(found))
+	for key := range found {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// deltas returns the non-negative differences between consecutive counter samples
+func deltas(values []float64) []float64 {
+	result := make([]float64, 0, len(values))
+	for i := 1; i < len(values); i++ {
+		if d := values[i] - values[i-1]; d >= 0 {
+			result = append(result, d)
+		}
+	}
+	return result
+}
+
+// anomalyIssue builds the issue reported for a deviation
+func anomalyIssue(metric, subject string, spec metricSpec, value float64, baseline Baseline, z float64, config Config, now time.Time) health.HealthIssue {
+	direction := "above"
+	if z < 0 {
+		direction = "below"
+	}
+	severity := "warning"
+	if math.Abs(z) >= 2*config.Threshold {
+		severity = "critical"
+	}
+
+	issue := health.HealthIssue{
+		Type:      spec.issueType,
+		Severity:  severity,
+		Resource:  "Cluster",
+		Name:      subject,
+		Detector:  "anomaly",
+		Timestamp: now,
+		Message: fmt.Sprintf("%s is %s its baseline: %.4g vs %.4g ± %.4g (z=%.1f over %d samples)",
+			spec.label, direction, value, baseline.Mean, baseline.StdDev, z, baseline.Samples),
+	}
+	if subject != "cluster" {
+		parts := strings.SplitN(subject, "/", 3)
+		issue.Namespace, issue.Resource, issue.Name = parts[0], parts[1], parts[2]
+	}
+	if metric == MetricMemory || metric == MetricCPU {
+		issue.Suggestion = "A sudden rise can mean a leak or a traffic surge; a sudden drop can mean the workload stopped serving"
+	}
+	if strings.HasPrefix(metric, MetricLoad+":") {
+		issue.Suggestion = "A sudden rise in load can outrun the workload's replicas and requests; a sudden drop can mean upstream traffic or producers stopped"
+	}
+	health.Suggest(&issue)
+	return issue
+}
+
+// Detector checks each cycle's series against history and alerts when anomalies open
+// and clear
+type Detector struct {
+	config   Config
+	store    history.Store
+	notifier notify.Notifier
+	cluster  string
+
+	mu   sync.Mutex
+	open map[string]health.HealthIssue // series key -> open anomaly
+}
+
+// NewDetector creates a detector reading baselines from store
+func NewDetector(config Config, store history.Store, notifier notify.Notifier, cluster string) *Detector {
+	return &Detector{
+		config:   config,
+		store:    store,
+		notifier: notifier,
+		cluster:  cluster,
+		open:     make(map[string]health.HealthIssue),
+	}
+}
+
+// Check compares series collected at now with their baselines and returns the anomalies.
+// Call it before recording series to history so a sample is not part of its own baseline.
+func (d *Detector) Check(ctx context.Context, series map[string]float64, now time.Time) ([]health.HealthIssue, error) {
+	past, err := d.store.List(ctx, history.Query{Cluster: d.cluster, Since: now.Add(-d.config.Lookback)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history for anomaly baselines: %w", err)
+	}
+	current := detect(d.config, past, series, now)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	issues := make([]health.HealthIssue, 0, len(current))
+	for _, key := range sortedKeys(current) {
+		issue := current[key]
+		if _, ok := d.open[key]; !ok {
+			notifyAnomaly(ctx, d.notifier, d.cluster, key, issue, false)
+		}
+		issues = append(issues, issue)
+	}
+	for key, issue := range d.open {
+		if _, ok := current[key]; !ok {
+			notifyAnomaly(ctx, d.notifier, d.cluster, key, issue, true)
+		}
+	}
+	d.open = current
+
+	return issues, nil
+}
+
+// notifyAnomaly sends an alert for an anomaly that opened or cleared
+func notifyAnomaly(ctx context.Context, notifier notify.Notifier, cluster, key string, issue health.HealthIssue, resolved bool) {
+	subject := issue.Name
+	if issue.Namespace != "" {
+		subject = fmt.Sprintf("%s %s/%s", issue.Resource, issue.Namespace, issue.Name)
+	}
+	alert := notify.Alert{
+		Title:       fmt.Sprintf("Anomaly: %s %s", subject, issue.Type),
+		Message:     issue.Message,
+		Severity:    issue.Severity,
+		Source:      "anomaly",
+		Labels:      map[string]string{"cluster": cluster},
+		Timestamp:   issue.Timestamp,
+		Fingerprint: "anomaly/" + key,
+		Issue:       &issue,
+	}
+	if issue.Namespace != "" {
+		alert.Labels["namespace"] = issue.Namespace
+	}
+	if resolved {
+		alert.Title = "Resolved: " + alert.Title
+		alert.Severity = "info"
+		alert.Resolved = true
+	}
+
+	if err := notifier.Notify(ctx, alert); err != nil {
+		log.Printf("Failed to send anomaly alert %q: %v", alert.Title, err)
+	}
+}
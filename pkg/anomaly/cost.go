@@ -0,0 +1,349 @@
+package anomaly
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/cost"
+	"github.com/ochestra-tech/ochestra-ai/pkg/health"
+	"github.com/ochestra-tech/ochestra-ai/pkg/history"
+	"github.com/ochestra-tech/ochestra-ai/pkg/notify"
+	"github.com/ochestra-tech/ochestra-ai/pkg/snapshot"
+)
+
+// Cost series recorded for finding the culprits of a cost anomaly
+const (
+	MetricWorkloadCost = "workloadCost" // hourly cost rate of a workload
+	MetricNodes        = "nodes"        // nodes of an instance type
+)
+
+// CostConfig controls cost anomaly detection
+type CostConfig struct {
+	Window       time.Duration // recent history averaged into the current daily spend
+	BaselineDays int           // days before the window the spend is compared with
+	MinDays      int           // days of history a baseline needs before it is trusted
+	Threshold    float64       // z-score at which a rise in daily spend is anomalous
+	MinIncrease  float64       // smallest rise worth reporting, as a fraction of the baseline
+	MinDelta     float64       // smallest rise worth reporting, in dollars per day
+	MinNodeJump  int           // smallest rise in node count worth reporting
+	NodeJumpRate float64       // smallest rise in node count, as a fraction of the baseline
+	Culprits     int           // workloads and instance types named in an alert
+}
+
+// DefaultCostConfig compares the last three hours with the week before, so a runaway
+// workload is reported within hours
+var DefaultCostConfig = CostConfig{
+	Window:       3 * time.Hour,
+	BaselineDays: 7,
+	MinDays:      3,
+	Threshold:    3,
+	MinIncrease:  0.3,
+	MinDelta:     10,
+	MinNodeJump:  3,
+	NodeJumpRate: 0.25,
+	Culprits:     3,
+}
+
+// CollectCost computes the hourly cost of each workload and the node count of each
+// instance type, virtual nodes aside, from a snapshot
+func CollectCost(snap *snapshot.ClusterSnapshot, pricing map[string]cost.ResourcePricing) map[string]float64 {
+	series := make(map[string]float64)
+	subjects := make(map[string]string, len(snap.Pods)) // namespace/pod -> workload subject
+	for i := range snap.Pods {
+		pod := &snap.Pods[i]
+		kind, name := snapshot.WorkloadOwner(pod)
+		subjects[pod.Namespace+"/"+pod.Name] = fmt.Sprintf("%s/%s/%s", pod.Namespace, kind, name)
+	}
+	for _, pc := range cost.PodCostsFromSnapshot(snap, pricing) {
+		if subject, ok := subjects[pc.Namespace+"/"+pc.Name]; ok {
+			series[SeriesKey(MetricWorkloadCost, subject)] += pc.TotalCost
+		}
+	}
+	for _, node := range snap.Nodes {
+		if snapshot.IsVirtualNode(&node) {
+			continue // serverless providers add a node per pod
+		}
+		instanceType := node.Labels["node.kubernetes.io/instance-type"]
+		if instanceType == "" {
+			instanceType = "unknown"
+		}
+		series[SeriesKey(MetricNodes, instanceType)]++
+	}
+	return series
+}
+
+// spend is the daily spend, or node count, of each subject over a period
+type spend map[string]float64
+
+// costHistory splits history into the recent window and the daily buckets before it
+type costHistory struct {
+	recent []*history.Snapshot
+	days   [][]*history.Snapshot // oldest first, only days with snapshots
+}
+
+// splitCostHistory buckets snapshots, ordered oldest first, by day back from the window
+func splitCostHistory(config CostConfig, past []*history.Snapshot, now time.Time) costHistory {
+	var h costHistory
+	windowStart := now.Add(-config.Window)
+	buckets := make([][]*history.Snapshot, config.BaselineDays)
+	for _, s := range past {
+		if !s.Timestamp.Before(windowStart) {
+			h.recent = append(h.recent, s)
+			continue
+		}
+		day := int(windowStart.Sub(s.Timestamp) / (24 * time.Hour))
+		if day < config.BaselineDays {
+			buckets[config.BaselineDays-1-day] = append(buckets[config.BaselineDays-1-day], s)
+		}
+	}
+	for _, bucket := range buckets {
+		if len(bucket) > 0 {
+			h.days = append(h.days, bucket)
+		}
+	}
+	return h
+}
+
+// dailySpend averages the namespace cost rates of snapshots into dollars per day. A
+// namespace missing from a snapshot cost nothing then.
+func dailySpend(snapshots []*history.Snapshot) spend {
+	result := make(spend)
+	for _, s := range snapshots {
+		for _, ns := range s.NamespaceCosts {
+			result[ns.Name] += ns.TotalCost * 24 / float64(len(snapshots))
+		}
+	}
+	return result
+}
+
+// averageSeries averages the series of a metric over snapshots, keyed by subject
+func averageSeries(snapshots []*history.Snapshot, metric string) spend {
+	result := make(spend)
+	for _, s := range snapshots {
+		for key, value := range s.Series {
+			if m, subject, _ := strings.Cut(key, "|"); m == metric {
+				result[subject] += value / float64(len(snapshots))
+			}
+		}
+	}
+	return result
+}
+
+// DetectCost compares the current daily spend of each namespace, and the node count, with
+// their daily baselines over the days before the window. Current values are the average of
+// the window's snapshots and this cycle's namespace costs and series.
+func DetectCost(config CostConfig, past []*history.Snapshot, namespaces []cost.NamespaceCostData, series map[string]float64, now time.Time) []health.HealthIssue {
+	found := detectCost(config, past, namespaces, series, now)
+	issues := make([]health.HealthIssue, 0, len(found))
+	for _, key := range sortedKeys(found) {
+		issues = append(issues, found[key])
+	}
+	return issues
+}
+
+// detectCost returns the cost anomalies keyed by subject
+func detectCost(config CostConfig, past []*history.Snapshot, namespaces []cost.NamespaceCostData, series map[string]float64, now time.Time) map[string]health.HealthIssue {
+	found := make(map[string]health.HealthIssue)
+	h := splitCostHistory(config, past, now)
+	if len(h.days) < config.MinDays {
+		return found
+	}
+	current := append(h.recent, &history.Snapshot{Timestamp: now, NamespaceCosts: namespaces, Series: series})
+	var baseline []*history.Snapshot
+	for _, day := range h.days {
+		baseline = append(baseline, day...)
+	}
+
+	// Namespace spend
+	daily := make([]spend, len(h.days))
+	for i, day := range h.days {
+		daily[i] = dailySpend(day)
+	}
+	nowSpend := dailySpend(current)
+	workloadsNow, workloadsBefore := averageSeries(current, MetricWorkloadCost), averageSeries(baseline, MetricWorkloadCost)
+	for namespace, value := range nowSpend {
+		values := make([]float64, len(daily))
+		for i := range daily {
+			values[i] = daily[i][namespace]
+		}
+		mean, stddev := meanStdDev(values)
+		rise := value - mean
+		if rise < config.MinDelta || value < mean*(1+config.MinIncrease) || (stddev > 0 && rise/stddev < config.Threshold) {
+			continue
+		}
+		severity := "warning"
+		if value >= 2*mean {
+			severity = "critical"
+		}
+		message := fmt.Sprintf("Namespace %s is spending $%.2f/day against $%.2f ± %.2f/day over the previous %d days",
+			namespace, value, mean, stddev, len(values))
+		if culprits := risers(workloadsNow, workloadsBefore, namespace+"/", config.Culprits, 24); len(culprits) > 0 {
+			message += "; likely culprits: " + strings.Join(culprits, ", ")
+		}
+		issue := health.HealthIssue{
+			Type:       health.IssueCostSpike,
+			Severity:   severity,
+			Resource:   "Namespace",
+			Name:       namespace,
+			Namespace:  namespace,
+			Detector:   "anomaly",
+			Timestamp:  now,
+			Message:    message,
+			Suggestion: "Check the culprits for new deployments, raised replicas or requests, and stuck scale-ups",
+		}
+		health.Suggest(&issue)
+		found[SeriesKey("namespaceCost", namespace)] = issue
+	}
+
+	// Node count
+	nodesNow, nodesBefore := averageSeries(current, MetricNodes), averageSeries(baseline, MetricNodes)
+	counts := make([]float64, len(h.days))
+	for i, day := range h.days {
+		for _, n := range averageSeries(day, MetricNodes) {
+			counts[i] += n
+		}
+	}
+	var total float64
+	for _, n := range nodesNow {
+		total += n
+	}
+	mean, _ := meanStdDev(counts)
+	if rise := total - mean; mean > 0 && rise >= float64(config.MinNodeJump) && rise >= mean*config.NodeJumpRate {
+		severity := "warning"
+		if total >= 2*mean {
+			severity = "critical"
+		}
+		message := fmt.Sprintf("The cluster is running %.0f nodes against %.1f a day over the previous %d days", total, mean, len(counts))
+		if culprits := risers(nodesNow, nodesBefore, "", config.Culprits, 1); len(culprits) > 0 {
+			message += "; instance types: " + strings.Join(culprits, ", ")
+		}
+		workloads := risers(workloadsNow, workloadsBefore, "", config.Culprits, 24)
+		if len(workloads) > 0 {
+			message += "; growing workloads: " + strings.Join(workloads, ", ")
+		}
+		issue := health.HealthIssue{
+			Type:       health.IssueNodeCountJump,
+			Severity:   severity,
+			Resource:   "Cluster",
+			Name:       "cluster",
+			Detector:   "anomaly",
+			Timestamp:  now,
+			Message:    message,
+			Suggestion: "Check the autoscaler's scale-up events and the growing workloads' replicas and requests",
+		}
+		health.Suggest(&issue)
+		found[SeriesKey(MetricNodes, "cluster")] = issue
+	}
+	return found
+}
+
+// risers returns the subjects under prefix that grew the most from before to now, with the
+// rise scaled by unit, e.g. 24 for dollars per day from hourly rates. Subjects missing
+// before are marked new.
+func risers(now, before spend, prefix string, limit int, unit float64) []string {
+	type riser struct {
+		subject string
+		rise    float64
+	}
+	var rising []riser
+	for subject, value := range now {
+		if strings.HasPrefix(subject, prefix) {
+			if rise := value - before[subject]; rise > 0 {
+				rising = append(rising, riser{subject, rise * unit})
+			}
+		}
+	}
+	sort.Slice(rising, func(i, j int) bool { return rising[i].rise > rising[j].rise })
+
+	var result []string
+	for i, r := range rising {
+		if i >= limit {
+			break
+		}
+		name := strings.TrimPrefix(r.subject, prefix)
+		rise := fmt.Sprintf("+%.0f", r.rise)
+		if unit > 1 {
+			rise = fmt.Sprintf("+$%.2f/day", r.rise)
+		}
+		if _, ok := before[r.subject]; !ok {
+			rise += " (new)"
+		}
+		result = append(result, name+" "+rise)
+	}
+	return result
+}
+
+// meanStdDev returns the mean and population standard deviation of values
+func meanStdDev(values []float64) (float64, float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+	var sum, squares float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+	for _, v := range values {
+		squares += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(squares / float64(len(values)))
+}
+
+// CostDetector checks each cycle's namespace costs and node counts against their daily
+// baselines and alerts when cost anomalies open and clear
+type CostDetector struct {
+	config   CostConfig
+	store    history.Store
+	notifier notify.Notifier
+	cluster  string
+
+	mu   sync.Mutex
+	open map[string]health.HealthIssue // subject key -> open anomaly
+}
+
+// NewCostDetector creates a cost detector reading baselines from store
+func NewCostDetector(config CostConfig, store history.Store, notifier notify.Notifier, cluster string) *CostDetector {
+	return &CostDetector{
+		config:   config,
+		store:    store,
+		notifier: notifier,
+		cluster:  cluster,
+		open:     make(map[string]health.HealthIssue),
+	}
+}
+
+// Check compares the namespace costs and series collected at now with their baselines and
+// returns the anomalies. Call it before recording to history, like Detector.Check.
+func (d *CostDetector) Check(ctx context.Context, namespaces []cost.NamespaceCostData, series map[string]float64, now time.Time) ([]health.HealthIssue, error) {
+	since := now.Add(-d.config.Window - time.Duration(d.config.BaselineDays)*24*time.Hour)
+	past, err := d.store.List(ctx, history.Query{Cluster: d.cluster, Since: since})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history for cost baselines: %w", err)
+	}
+	current := detectCost(d.config, past, namespaces, series, now)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	issues := make([]health.HealthIssue, 0, len(current))
+	for _, key := range sortedKeys(current) {
+		issue := current[key]
+		if _, ok := d.open[key]; !ok {
+			notifyAnomaly(ctx, d.notifier, d.cluster, "cost/"+key, issue, false)
+		}
+		issues = append(issues, issue)
+	}
+	for key, issue := range d.open {
+		if _, ok := current[key]; !ok {
+			notifyAnomaly(ctx, d.notifier, d.cluster, "cost/"+key, issue, true)
+		}
+	}
+	d.open = current
+
+	return issues, nil
+}
@@ -0,0 +1,280 @@
+package approval
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/optimizer"
+)
+
+// Status is where a cleanup candidate is in the approval workflow
+type Status string
+
+const (
+	StatusPending  Status = "pending"
+	StatusApproved Status = "approved" // deleted on the next cycle that still selects it
+	StatusRejected Status = "rejected" // kept, and not proposed again while it stays selected
+)
+
+// AuditRetention is how long audit entries are kept
+var AuditRetention = 90 * 24 * time.Hour
+
+// Candidate is a resource the cleanup policy selected, awaiting or past a decision
+type Candidate struct {
+	ID           string        `json:"id"` // Kind/namespace/name/uid
+	Rule         string        `json:"rule"`
+	Kind         string        `json:"kind"`
+	Namespace    string        `json:"namespace"`
+	Name         string        `json:"name"`
+	UID          types.UID     `json:"uid"`
+	Reason       string        `json:"reason"`
+	Age          time.Duration `json:"age"`
+	Status       Status        `json:"status"`
+	ProposedAt   time.Time     `json:"proposedAt"`
+	DecidedBy    string        `json:"decidedBy,omitempty"`
+	DecidedAt    *time.Time    `json:"decidedAt,omitempty"`
+	Note         string        `json:"note,omitempty"`
+	DeleteErrors int           `json:"deleteErrors,omitempty"`
+}
+
+// AuditEntry records one step of a candidate's workflow
+type AuditEntry struct {
+	Time   time.Time `json:"time"`
+	ID     string    `json:"id"`
+	Action string    `json:"action"` // proposed, approved, rejected, deleted, delete-failed or withdrawn
+	Actor  string    `json:"actor"`  // "system" for the monitor's own steps
+	Detail string    `json:"detail,omitempty"`
+}
+
+// CandidateID identifies the resource a cleanup recommendation selects. The UID is part of
+// it, so an approval never carries over to a resource recreated under the same name.
+func CandidateID(rec optimizer.CleanupRecommendation) string {
+	return rec.ResourceType + "/" + rec.Namespace + "/" + rec.Name + "/" + string(rec.UID)
+}
+
+// Recommendation returns the recommendation that deletes the candidate's resource
+func (c Candidate) Recommendation() optimizer.CleanupRecommendation {
+	return optimizer.CleanupRecommendation{Rule: c.Rule, ResourceType: c.Kind, Namespace: c.Namespace, Name: c.Name, UID: c.UID}
+}
+
+// Queue holds the cleanup candidates and their audit trail, persisting both if it has a
+// state file
+type Queue struct {
+	path string
+
+	mu    sync.Mutex
+	state struct {
+		Candidates map[string]*Candidate `json:"candidates"`
+		Audit      []AuditEntry          `json:"audit,omitempty"`
+	}
+}
+
+// LoadQueue creates a queue, restoring the state persisted at path if it is not empty
+func LoadQueue(path string) (*Queue, error) {
+	q := &Queue{path: path}
+	q.state.Candidates = make(map[string]*Candidate)
+	if path == "" {
+		return q, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return q, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cleanup approvals: %w", err)
+	}
+	if err := json.Unmarshal(data, &q.state); err != nil {
+		return nil, fmt.Errorf("failed to parse cleanup approvals: %w", err)
+	}
+	if q.state.Candidates == nil {
+		q.state.Candidates = make(map[string]*Candidate)
+	}
+	return q, nil
+}
+
+// Sync reconciles the queue with what the cleanup policy selects now. New selections are
+// proposed, and candidates no longer selected, or now protected, are withdrawn. It returns
+// the approved candidates to delete and the newly proposed ones.
+func (q *Queue) Sync(recs []optimizer.CleanupRecommendation, now time.Time) (approved, proposed []Candidate) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	selected := make(map[string]bool, len(recs))
+	protected := make(map[string]string)
+	for _, rec := range recs {
+		id := CandidateID(rec)
+		if rec.Protected != "" {
+			protected[id] = rec.Protected
+			continue
+		}
+		selected[id] = true
+		if c, ok := q.state.Candidates[id]; ok {
+			c.Rule, c.Reason, c.Age = rec.Rule, rec.Reason, rec.Age
+			if c.Status == StatusApproved {
+				approved = append(approved, *c)
+			}
+			continue
+		}
+
+		c := &Candidate{
+			ID:         id,
+			Rule:       rec.Rule,
+			Kind:       rec.ResourceType,
+			Namespace:  rec.Namespace,
+			Name:       rec.Name,
+			UID:        rec.UID,
+			Reason:     rec.Reason,
+			Age:        rec.Age,
+			Status:     StatusPending,
+			ProposedAt: now,
+		}
+		q.state.Candidates[id] = c
+		q.audit(now, id, "proposed", "system", fmt.Sprintf("rule %s: %s", rec.Rule, rec.Reason))
+		proposed = append(proposed, *c)
+	}
+
+	for id, c := range q.state.Candidates {
+		if selected[id] {
+			continue
+		}
+		delete(q.state.Candidates, id)
+		if reason, ok := protected[id]; ok {
+			q.audit(now, id, "withdrawn", "system", "protected: "+reason)
+		} else if c.Status != StatusRejected {
+			q.audit(now, id, "withdrawn", "system", "no longer selected by the cleanup policy")
+		}
+	}
+
+	sortCandidates(approved)
+	sortCandidates(proposed)
+	return approved, proposed
+}
+
+// Decide approves or rejects pending candidates on behalf of actor. allowed limits the
+// namespaces the actor may decide for; nil allows all. Decisions are final, so a stale
+// Slack button cannot turn a rejection into a deletion. It returns the IDs it could not
+// decide: unknown, already decided or not allowed.
+func (q *Queue) Decide(ids []string, status Status, actor, note string, allowed func(namespace string) bool, now time.Time) []string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var failed []string
+	for _, id := range ids {
+		c, ok := q.state.Candidates[id]
+		if !ok || c.Status != StatusPending || (allowed != nil && !allowed(c.Namespace)) {
+			failed = append(failed, id)
+			continue
+		}
+		decidedAt := now
+		c.Status, c.DecidedBy, c.DecidedAt, c.Note = status, actor, &decidedAt, note
+		q.audit(now, id, string(status), actor, note)
+	}
+	return failed
+}
+
+// Deleted records the outcome of deleting an approved candidate, with the backup of its
+// manifest if one was taken. Deleted candidates leave the queue; failed ones stay approved
+// and are retried on the next cycle.
+func (q *Queue) Deleted(id, backupID string, err error, now time.Time) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if err != nil {
+		if c, ok := q.state.Candidates[id]; ok {
+			c.DeleteErrors++
+		}
+		q.audit(now, id, "delete-failed", "system", err.Error())
+		return
+	}
+	delete(q.state.Candidates, id)
+	detail := ""
+	if backupID != "" {
+		detail = "backup " + backupID
+	}
+	q.audit(now, id, "deleted", "system", detail)
+}
+
+// Candidates returns the candidates in a namespace-allowed set, ordered by ID; nil allows all
+func (q *Queue) Candidates(allowed func(namespace string) bool) []Candidate {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	result := make([]Candidate, 0, len(q.state.Candidates))
+	for _, c := range q.state.Candidates {
+		if allowed == nil || allowed(c.Namespace) {
+			result = append(result, *c)
+		}
+	}
+	sortCandidates(result)
+	return result
+}
+
+// Audit returns the audit trail, oldest first, for the IDs in allowed namespaces; nil allows all
+func (q *Queue) Audit(allowed func(namespace string) bool) []AuditEntry {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	result := make([]AuditEntry, 0, len(q.state.Audit))
+	for _, e := range q.state.Audit {
+		if allowed == nil || allowed(namespaceOf(e.ID)) {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+// Save persists the queue if it has a state file
+func (q *Queue) Save() error {
+	if q.path == "" {
+		return nil
+	}
+
+	q.mu.Lock()
+	data, err := json.MarshalIndent(q.state, "", "  ")
+	q.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to marshal cleanup approvals: %w", err)
+	}
+
+	if err := os.WriteFile(q.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write cleanup approvals: %w", err)
+	}
+	return nil
+}
+
+// audit appends an entry, logs it and drops entries older than AuditRetention. The caller
+// holds the lock.
+func (q *Queue) audit(now time.Time, id, action, actor, detail string) {
+	log.Printf("Cleanup approval: %s %s by %s %s", id, action, actor, detail)
+	q.state.Audit = append(q.state.Audit, AuditEntry{Time: now, ID: id, Action: action, Actor: actor, Detail: detail})
+
+	cutoff := now.Add(-AuditRetention)
+	i := 0
+	for i < len(q.state.Audit) && q.state.Audit[i].Time.Before(cutoff) {
+		i++
+	}
+	q.state.Audit = q.state.Audit[i:]
+}
+
+// namespaceOf returns the namespace part of a candidate ID
+func namespaceOf(id string) string {
+	parts := strings.SplitN(id, "/", 3)
+	if len(parts) < 3 {
+		return ""
+	}
+	return parts[1]
+}
+
+// sortCandidates orders candidates by ID
+func sortCandidates(candidates []Candidate) {
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].ID < candidates[j].ID })
+}
@@ -0,0 +1,156 @@
+package approval
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/optimizer"
+)
+
+var approvalNow = time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+
+// cleanupRec returns a recommendation of the cleanup rule "ci-jobs" to delete a Job
+func cleanupRec(namespace, name, uid string) optimizer.CleanupRecommendation {
+	return optimizer.CleanupRecommendation{
+		Rule:         "ci-jobs",
+		ResourceType: "Job",
+		Namespace:    namespace,
+		Name:         name,
+		UID:          types.UID(uid),
+		Reason:       "completed 2d ago",
+		Age:          48 * time.Hour,
+	}
+}
+
+// ids returns the IDs of candidates
+func ids(candidates []Candidate) []string {
+	result := make([]string, len(candidates))
+	for i, c := range candidates {
+		result[i] = c.ID
+	}
+	return result
+}
+
+func TestQueueSyncReturnsOnlyApproved(t *testing.T) {
+	q, err := LoadQueue("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	approvedRec := cleanupRec("ci-web", "build-1", "u1")
+	rejectedRec := cleanupRec("ci-web", "build-2", "u2")
+	pendingRec := cleanupRec("ci-api", "test-1", "u3")
+	protectedRec := cleanupRec("ci-api", "test-2", "u4")
+	protectedRec.Protected = "labeled hc-monitor/protected=true"
+
+	approved, proposed := q.Sync([]optimizer.CleanupRecommendation{approvedRec, rejectedRec, pendingRec, protectedRec}, approvalNow)
+	if len(approved) != 0 {
+		t.Fatalf("first Sync() approved %v, want none", ids(approved))
+	}
+	want := []string{CandidateID(pendingRec), CandidateID(approvedRec), CandidateID(rejectedRec)}
+	if got := ids(proposed); !reflect.DeepEqual(got, want) {
+		t.Fatalf("first Sync() proposed %v, want %v", got, want)
+	}
+
+	q.Decide([]string{CandidateID(approvedRec)}, StatusApproved, "ana", "", nil, approvalNow)
+	q.Decide([]string{CandidateID(rejectedRec)}, StatusRejected, "ana", "", nil, approvalNow)
+
+	// build-1 recreated under the same name is a new candidate, not approved
+	recreated := cleanupRec("ci-web", "build-1", "u5")
+	approved, proposed = q.Sync([]optimizer.CleanupRecommendation{approvedRec, rejectedRec, pendingRec, recreated}, approvalNow.Add(time.Hour))
+	if got, want := ids(approved), []string{CandidateID(approvedRec)}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Sync() approved %v, want %v", got, want)
+	}
+	if got, want := ids(proposed), []string{CandidateID(recreated)}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Sync() proposed %v, want %v", got, want)
+	}
+
+	// Only the approved candidate becomes a recommendation for the deletion batch
+	got := approved[0].Recommendation()
+	wantRec := optimizer.CleanupRecommendation{Rule: "ci-jobs", ResourceType: "Job", Namespace: "ci-web", Name: "build-1", UID: "u1"}
+	if got != wantRec {
+		t.Errorf("Recommendation() = %+v, want %+v", got, wantRec)
+	}
+
+	// An approved candidate the policy no longer selects is withdrawn, not deleted
+	approved, _ = q.Sync([]optimizer.CleanupRecommendation{rejectedRec, pendingRec, recreated}, approvalNow.Add(2*time.Hour))
+	if len(approved) != 0 {
+		t.Errorf("Sync() after the policy dropped build-1 approved %v, want none", ids(approved))
+	}
+}
+
+func TestQueueDecide(t *testing.T) {
+	pending := cleanupRec("ci-web", "build-1", "u1")
+	decided := cleanupRec("ci-web", "build-2", "u2")
+	other := cleanupRec("ci-api", "test-1", "u3")
+	webOnly := func(namespace string) bool { return namespace == "ci-web" }
+
+	tests := []struct {
+		name       string
+		id         string
+		status     Status
+		allowed    func(string) bool
+		wantFailed bool
+		wantStatus Status
+	}{
+		{name: "approve pending", id: CandidateID(pending), status: StatusApproved, wantStatus: StatusApproved},
+		{name: "reject pending", id: CandidateID(pending), status: StatusRejected, wantStatus: StatusRejected},
+		{name: "approve unknown", id: "Job/ci-web/build-9/u9", status: StatusApproved, wantFailed: true},
+		{name: "approve already rejected", id: CandidateID(decided), status: StatusApproved, wantFailed: true, wantStatus: StatusRejected},
+		{name: "approve outside allowed namespaces", id: CandidateID(other), status: StatusApproved, allowed: webOnly, wantFailed: true, wantStatus: StatusPending},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, _ := LoadQueue("")
+			q.Sync([]optimizer.CleanupRecommendation{pending, decided, other}, approvalNow)
+			q.Decide([]string{CandidateID(decided)}, StatusRejected, "bob", "still needed", nil, approvalNow)
+
+			failed := q.Decide([]string{tt.id}, tt.status, "ana", "", tt.allowed, approvalNow)
+			if got := len(failed) > 0; got != tt.wantFailed {
+				t.Fatalf("Decide() failed = %v, want failed %v", failed, tt.wantFailed)
+			}
+			for _, c := range q.Candidates(nil) {
+				if c.ID == tt.id && c.Status != tt.wantStatus {
+					t.Errorf("status = %s, want %s", c.Status, tt.wantStatus)
+				}
+			}
+		})
+	}
+}
+
+func TestQueueDeletedAndSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "approvals.json")
+	q, err := LoadQueue(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	failing, deleted := cleanupRec("ci-web", "build-1", "u1"), cleanupRec("ci-web", "build-2", "u2")
+	q.Sync([]optimizer.CleanupRecommendation{failing, deleted}, approvalNow)
+	q.Decide([]string{CandidateID(failing), CandidateID(deleted)}, StatusApproved, "ana", "", nil, approvalNow)
+	q.Deleted(CandidateID(failing), "", errors.New("forbidden"), approvalNow)
+	q.Deleted(CandidateID(deleted), "backup-1", nil, approvalNow)
+	if err := q.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	restored, err := LoadQueue(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	candidates := restored.Candidates(nil)
+	if len(candidates) != 1 || candidates[0].ID != CandidateID(failing) || candidates[0].Status != StatusApproved || candidates[0].DeleteErrors != 1 {
+		t.Fatalf("restored candidates = %+v, want build-1 still approved with one delete error", candidates)
+	}
+	var actions []string
+	for _, e := range restored.Audit(nil) {
+		actions = append(actions, e.Action)
+	}
+	want := []string{"proposed", "proposed", "approved", "approved", "delete-failed", "deleted"}
+	if !reflect.DeepEqual(actions, want) {
+		t.Errorf("audit actions = %v, want %v", actions, want)
+	}
+}
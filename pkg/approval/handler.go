@@ -0,0 +1,249 @@
+package approval
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/auth"
+)
+
+// Handler serves the cleanup approval API:
+//
+//	GET  /cleanup/candidates           candidates and their status
+//	GET  /cleanup/audit                the audit trail
+//	POST /cleanup/approve, /cleanup/reject  {"ids": [...], "note": "..."}
+//
+// Callers only see and decide candidates in the namespaces their scope allows, and
+// decisions are refused without one, since the audit trail needs to know who made them.
+type Handler struct {
+	Queue *Queue
+}
+
+// decision is the body of approve and reject requests
+type decision struct {
+	IDs  []string `json:"ids"`
+	Note string   `json:"note,omitempty"`
+}
+
+// ServeHTTP routes approval API requests
+func (h Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	scope, authenticated := auth.ScopeFrom(r.Context())
+	allowed := func(string) bool { return true }
+	if authenticated {
+		allowed = scope.Allows
+	}
+
+	switch action := strings.TrimPrefix(r.URL.Path, "/cleanup/"); action {
+	case "candidates", "audit":
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		var body interface{} = h.Queue.Candidates(allowed)
+		if action == "audit" {
+			body = h.Queue.Audit(allowed)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(body)
+
+	case "approve", "reject":
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "POST {\"ids\": [...]}", http.StatusMethodNotAllowed)
+			return
+		}
+		if !authenticated {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		var d decision
+		if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&d); err != nil || len(d.IDs) == 0 {
+			http.Error(w, "invalid request: want {\"ids\": [...]}", http.StatusBadRequest)
+			return
+		}
+		status := StatusApproved
+		if action == "reject" {
+			status = StatusRejected
+		}
+		unknown := h.Queue.Decide(d.IDs, status, scope.Identity.Subject, d.Note, allowed, time.Now())
+		if err := h.Queue.Save(); err != nil {
+			log.Printf("Failed to save cleanup approvals: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"decided": len(d.IDs) - len(unknown),
+			"unknown": unknown, // not in the queue, already decided, or outside the caller's namespaces
+		})
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// SlackHandler serves POST /cleanup/slack, the interactivity endpoint of a Slack app whose
+// Approve and Reject buttons are on the messages Notify posts. Requests are verified with
+// the app's signing secret.
+type SlackHandler struct {
+	Queue         *Queue
+	SigningSecret string
+}
+
+// ServeHTTP applies the decision of a button click and answers with the updated message
+func (h SlackHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	if err := verifySlackSignature(h.SigningSecret, r.Header, body, time.Now()); err != nil {
+		log.Printf("Rejected Slack cleanup request: %v", err)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	var payload struct {
+		User struct {
+			Username string `json:"username"`
+			ID       string `json:"id"`
+		} `json:"user"`
+		Actions []struct {
+			ActionID string `json:"action_id"`
+			Value    string `json:"value"`
+		} `json:"actions"`
+	}
+	if err := json.Unmarshal([]byte(r.PostFormValue("payload")), &payload); err != nil {
+		http.Error(w, "invalid payload", http.StatusBadRequest)
+		return
+	}
+
+	actor := "slack:" + payload.User.Username
+	var lines []string
+	for _, a := range payload.Actions {
+		status := StatusApproved
+		if a.ActionID == "reject" {
+			status = StatusRejected
+		}
+		if unknown := h.Queue.Decide([]string{a.Value}, status, actor, "", nil, time.Now()); len(unknown) > 0 {
+			lines = append(lines, fmt.Sprintf("%s is no longer awaiting a decision", a.Value))
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%s %s by <@%s>", a.Value, status, payload.User.ID))
+	}
+	if err := h.Queue.Save(); err != nil {
+		log.Printf("Failed to save cleanup approvals: %v", err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"response_type":    "in_channel",
+		"replace_original": false,
+		"text":             strings.Join(lines, "\n"),
+	})
+}
+
+// verifySlackSignature checks Slack's v0 request signature and rejects requests older
+// than five minutes
+func verifySlackSignature(secret string, header http.Header, body []byte, now time.Time) error {
+	timestamp, err := strconv.ParseInt(header.Get("X-Slack-Request-Timestamp"), 10, 64)
+	if err != nil {
+		return fmt.Errorf("missing request timestamp")
+	}
+	if age := now.Sub(time.Unix(timestamp, 0)); age > 5*time.Minute || age < -5*time.Minute {
+		return fmt.Errorf("stale request timestamp")
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "v0:%d:%s", timestamp, body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(header.Get("X-Slack-Signature"))) {
+		return fmt.Errorf("invalid signature")
+	}
+	return nil
+}
+
+// maxSlackCandidates bounds the buttons in one message; the rest are listed by the API
+const maxSlackCandidates = 20
+
+// NotifySlack posts newly proposed candidates to a Slack incoming webhook, each with
+// Approve and Reject buttons
+func NotifySlack(ctx context.Context, webhookURL, cluster string, proposed []Candidate) error {
+	if len(proposed) == 0 {
+		return nil
+	}
+
+	blocks := []map[string]interface{}{{
+		"type": "section",
+		"text": map[string]string{
+			"type": "mrkdwn",
+			"text": fmt.Sprintf("*%d resources in %s are waiting for cleanup approval*", len(proposed), cluster),
+		},
+	}}
+	for i, c := range proposed {
+		if i == maxSlackCandidates {
+			blocks = append(blocks, map[string]interface{}{
+				"type": "context",
+				"elements": []map[string]string{{
+					"type": "mrkdwn",
+					"text": fmt.Sprintf("%d more; see /cleanup/candidates or `ochestra-ai cleanup list`", len(proposed)-i),
+				}},
+			})
+			break
+		}
+		blocks = append(blocks,
+			map[string]interface{}{
+				"type": "section",
+				"text": map[string]string{
+					"type": "mrkdwn",
+					"text": fmt.Sprintf("`%s`\n%s (rule %s, %s)", c.ID, c.Reason, c.Rule, c.Age.Round(time.Hour)),
+				},
+			},
+			map[string]interface{}{
+				"type": "actions",
+				"elements": []map[string]interface{}{
+					{"type": "button", "action_id": "approve", "value": c.ID, "style": "danger",
+						"text": map[string]string{"type": "plain_text", "text": "Approve delete"}},
+					{"type": "button", "action_id": "reject", "value": c.ID,
+						"text": map[string]string{"type": "plain_text", "text": "Keep"}},
+				},
+			})
+	}
+
+	data, err := json.Marshal(map[string]interface{}{
+		"text":   fmt.Sprintf("%d resources are waiting for cleanup approval", len(proposed)),
+		"blocks": blocks,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal Slack message: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookURL, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := (&http.Client{Timeout: 10 * time.Second}).Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected response status: %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,261 @@
+package approval
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/auth"
+	"github.com/ochestra-tech/ochestra-ai/pkg/optimizer"
+)
+
+const slackSecret = "8f742231b10e8888abcd99yyyzzz85a5"
+
+// slackRequest returns a signed Slack interactivity request clicking action on id,
+// signed at signedAt with secret
+func slackRequest(secret, action, id string, signedAt time.Time) *http.Request {
+	payload, _ := json.Marshal(map[string]interface{}{
+		"user":    map[string]string{"username": "ana", "id": "U1"},
+		"actions": []map[string]string{{"action_id": action, "value": id}},
+	})
+	body := url.Values{"payload": {string(payload)}}.Encode()
+	timestamp := strconv.FormatInt(signedAt.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "v0:%s:%s", timestamp, body)
+
+	r := httptest.NewRequest(http.MethodPost, "/cleanup/slack", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.Header.Set("X-Slack-Request-Timestamp", timestamp)
+	r.Header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+	return r
+}
+
+// candidateStatus returns the status of a queued candidate, or "" if it is not queued
+func candidateStatus(q *Queue, id string) Status {
+	for _, c := range q.Candidates(nil) {
+		if c.ID == id {
+			return c.Status
+		}
+	}
+	return ""
+}
+
+func TestSlackHandler(t *testing.T) {
+	pending := cleanupRec("ci-web", "build-1", "u1")
+	decided := cleanupRec("ci-web", "build-2", "u2")
+
+	tests := []struct {
+		name       string
+		request    func() *http.Request
+		id         string
+		wantCode   int
+		wantStatus Status
+		wantText   string
+	}{
+		{
+			name:       "valid signature",
+			request:    func() *http.Request { return slackRequest(slackSecret, "approve", CandidateID(pending), time.Now()) },
+			id:         CandidateID(pending),
+			wantCode:   http.StatusOK,
+			wantStatus: StatusApproved,
+			wantText:   `approved by \u003c@U1\u003e`,
+		},
+		{
+			name:       "reject button",
+			request:    func() *http.Request { return slackRequest(slackSecret, "reject", CandidateID(pending), time.Now()) },
+			id:         CandidateID(pending),
+			wantCode:   http.StatusOK,
+			wantStatus: StatusRejected,
+		},
+		{
+			name:       "bad signature",
+			request:    func() *http.Request { return slackRequest("other-secret", "approve", CandidateID(pending), time.Now()) },
+			id:         CandidateID(pending),
+			wantCode:   http.StatusUnauthorized,
+			wantStatus: StatusPending,
+		},
+		{
+			name: "stale timestamp",
+			request: func() *http.Request {
+				return slackRequest(slackSecret, "approve", CandidateID(pending), time.Now().Add(-10*time.Minute))
+			},
+			id:         CandidateID(pending),
+			wantCode:   http.StatusUnauthorized,
+			wantStatus: StatusPending,
+		},
+		{
+			name: "missing timestamp",
+			request: func() *http.Request {
+				r := slackRequest(slackSecret, "approve", CandidateID(pending), time.Now())
+				r.Header.Del("X-Slack-Request-Timestamp")
+				return r
+			},
+			id:         CandidateID(pending),
+			wantCode:   http.StatusUnauthorized,
+			wantStatus: StatusPending,
+		},
+		{
+			name:       "already decided",
+			request:    func() *http.Request { return slackRequest(slackSecret, "approve", CandidateID(decided), time.Now()) },
+			id:         CandidateID(decided),
+			wantCode:   http.StatusOK,
+			wantStatus: StatusRejected,
+			wantText:   "no longer awaiting a decision",
+		},
+		{
+			name:     "unknown candidate",
+			request:  func() *http.Request { return slackRequest(slackSecret, "approve", "Job/ci-web/build-9/u9", time.Now()) },
+			id:       "Job/ci-web/build-9/u9",
+			wantCode: http.StatusOK,
+			wantText: "no longer awaiting a decision",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, _ := LoadQueue("")
+			q.Sync([]optimizer.CleanupRecommendation{pending, decided}, approvalNow)
+			q.Decide([]string{CandidateID(decided)}, StatusRejected, "bob", "", nil, approvalNow)
+
+			rec := httptest.NewRecorder()
+			SlackHandler{Queue: q, SigningSecret: slackSecret}.ServeHTTP(rec, tt.request())
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status code = %d, want %d", rec.Code, tt.wantCode)
+			}
+			if got := candidateStatus(q, tt.id); got != tt.wantStatus {
+				t.Errorf("candidate status = %q, want %q", got, tt.wantStatus)
+			}
+			if !strings.Contains(rec.Body.String(), tt.wantText) {
+				t.Errorf("response = %s, want it to contain %q", rec.Body.String(), tt.wantText)
+			}
+		})
+	}
+}
+
+// tokenHash returns the hex SHA-256 digest of a token, as the auth config stores it
+func tokenHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestHandler(t *testing.T) {
+	web, api := cleanupRec("ci-web", "build-1", "u1"), cleanupRec("ci-api", "test-1", "u2")
+	decided := cleanupRec("ci-web", "build-2", "u3")
+	config := &auth.Config{
+		Tokens: []auth.Token{
+			{Subject: "ana", SHA256: tokenHash("ana-token")},
+			{Subject: "bob", SHA256: tokenHash("bob-token")},
+		},
+		AdminSubjects: []string{"ana"},
+		Teams:         []auth.Team{{Name: "web", Subjects: []string{"bob"}, Namespaces: []string{"ci-web"}}},
+	}
+	guard := auth.NewGuard(config, auth.NewTokenAuthenticator(config))
+
+	tests := []struct {
+		name        string
+		token       string
+		path        string
+		ids         []string
+		unguarded   bool
+		wantCode    int
+		wantDecided int
+		wantStatus  map[string]Status
+	}{
+		{
+			name: "admin approves", token: "ana-token", path: "/cleanup/approve", ids: []string{CandidateID(web), CandidateID(api)},
+			wantCode: http.StatusOK, wantDecided: 2,
+			wantStatus: map[string]Status{CandidateID(web): StatusApproved, CandidateID(api): StatusApproved},
+		},
+		{
+			name: "team member outside its namespaces", token: "bob-token", path: "/cleanup/approve", ids: []string{CandidateID(web), CandidateID(api)},
+			wantCode: http.StatusOK, wantDecided: 1,
+			wantStatus: map[string]Status{CandidateID(web): StatusApproved, CandidateID(api): StatusPending},
+		},
+		{
+			name: "unknown and already decided", token: "ana-token", path: "/cleanup/approve", ids: []string{"Job/ci-web/build-9/u9", CandidateID(decided)},
+			wantCode: http.StatusOK, wantDecided: 0,
+			wantStatus: map[string]Status{CandidateID(decided): StatusRejected},
+		},
+		{
+			name: "reject", token: "ana-token", path: "/cleanup/reject", ids: []string{CandidateID(api)},
+			wantCode: http.StatusOK, wantDecided: 1,
+			wantStatus: map[string]Status{CandidateID(api): StatusRejected},
+		},
+		{
+			name: "invalid token", token: "eve-token", path: "/cleanup/approve", ids: []string{CandidateID(web)},
+			wantCode:   http.StatusUnauthorized,
+			wantStatus: map[string]Status{CandidateID(web): StatusPending},
+		},
+		{
+			name: "no caller to record", path: "/cleanup/approve", ids: []string{CandidateID(web)}, unguarded: true,
+			wantCode:   http.StatusUnauthorized,
+			wantStatus: map[string]Status{CandidateID(web): StatusPending},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, _ := LoadQueue("")
+			q.Sync([]optimizer.CleanupRecommendation{web, api, decided}, approvalNow)
+			q.Decide([]string{CandidateID(decided)}, StatusRejected, "bob", "", nil, approvalNow)
+			var handler http.Handler = Handler{Queue: q}
+			if !tt.unguarded {
+				handler = guard.Protect(handler, false)
+			}
+
+			body, _ := json.Marshal(decision{IDs: tt.ids})
+			r := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(string(body)))
+			if tt.token != "" {
+				r.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, r)
+			if rec.Code != tt.wantCode {
+				t.Fatalf("status code = %d, want %d: %s", rec.Code, tt.wantCode, rec.Body.String())
+			}
+			if rec.Code == http.StatusOK {
+				var result struct {
+					Decided int `json:"decided"`
+				}
+				if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil || result.Decided != tt.wantDecided {
+					t.Errorf("response = %s, want %d decided", rec.Body.String(), tt.wantDecided)
+				}
+			}
+			for id, want := range tt.wantStatus {
+				if got := candidateStatus(q, id); got != want {
+					t.Errorf("%s status = %q, want %q", id, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestHandlerListsOnlyAllowedNamespaces(t *testing.T) {
+	q, _ := LoadQueue("")
+	q.Sync([]optimizer.CleanupRecommendation{cleanupRec("ci-web", "build-1", "u1"), cleanupRec("ci-api", "test-1", "u2")}, approvalNow)
+	config := &auth.Config{
+		Tokens: []auth.Token{{Subject: "bob", SHA256: tokenHash("bob-token")}},
+		Teams:  []auth.Team{{Name: "web", Subjects: []string{"bob"}, Namespaces: []string{"ci-web"}}},
+	}
+	handler := auth.NewGuard(config, auth.NewTokenAuthenticator(config)).Protect(Handler{Queue: q}, false)
+
+	r := httptest.NewRequest(http.MethodGet, "/cleanup/candidates", nil)
+	r.Header.Set("Authorization", "Bearer bob-token")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, r)
+
+	var candidates []Candidate
+	if err := json.Unmarshal(rec.Body.Bytes(), &candidates); err != nil {
+		t.Fatalf("failed to parse candidates %s: %v", rec.Body.String(), err)
+	}
+	if got := ids(candidates); len(got) != 1 || got[0] != "Job/ci-web/build-1/u1" {
+		t.Errorf("candidates = %v, want only the ci-web one", got)
+	}
+}
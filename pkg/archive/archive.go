@@ -0,0 +1,282 @@
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// Object storage providers
+const (
+	ProviderS3    = "s3"
+	ProviderGCS   = "gcs"
+	ProviderAzure = "azure"
+)
+
+// Kinds of archived documents, available to key templates as {{.Kind}}
+const (
+	KindHealth        = "health"
+	KindOptimization  = "optimization"
+	KindCleanupBackup = "cleanup-backup" // manifests of deleted resources, stored by name
+)
+
+// DefaultKeyTemplate puts each kind under its own prefix and partitions by date, so
+// lifecycle rules can expire or tier each kind separately
+const DefaultKeyTemplate = `{{.Kind}}/cluster={{.Cluster}}/{{.Time.Format "2006/01/02"}}/{{.Time.Format "150405"}}.json.gz`
+
+// Config selects the bucket snapshots are archived to
+type Config struct {
+	Provider    string `json:"provider"`              // "s3", "gcs" or "azure"
+	Bucket      string `json:"bucket"`                // bucket, or container for Azure
+	Account     string `json:"account,omitempty"`     // Azure storage account
+	Endpoint    string `json:"endpoint,omitempty"`    // S3-compatible endpoint, e.g. MinIO
+	Prefix      string `json:"prefix,omitempty"`      // prepended to every key
+	KeyTemplate string `json:"keyTemplate,omitempty"` // text/template over Cluster, Kind and Time (UTC)
+	Interval    string `json:"interval,omitempty"`    // how often to archive, default "1h"
+
+	interval time.Duration
+	keys     *template.Template
+}
+
+// LoadConfig reads archive settings from a JSON file
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive config: %w", err)
+	}
+
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse archive config: %w", err)
+	}
+	if err := config.init(); err != nil {
+		return nil, fmt.Errorf("archive config: %w", err)
+	}
+	return &config, nil
+}
+
+// init validates the config and parses its interval and key template
+func (c *Config) init() error {
+	switch c.Provider {
+	case ProviderS3, ProviderGCS:
+	case ProviderAzure:
+		if c.Account == "" {
+			return fmt.Errorf("an account is required for Azure")
+		}
+	default:
+		return fmt.Errorf("unsupported provider %q", c.Provider)
+	}
+	if c.Bucket == "" {
+		return fmt.Errorf("a bucket is required")
+	}
+	if c.Interval == "" {
+		c.Interval = "1h"
+	}
+	interval, err := time.ParseDuration(c.Interval)
+	if err != nil || interval <= 0 {
+		return fmt.Errorf("invalid interval %q", c.Interval)
+	}
+	c.interval = interval
+	if c.KeyTemplate == "" {
+		c.KeyTemplate = DefaultKeyTemplate
+	}
+	if c.keys, err = template.New("key").Option("missingkey=error").Parse(c.KeyTemplate); err != nil {
+		return fmt.Errorf("invalid key template: %w", err)
+	}
+	if _, err := c.Key("cluster", KindHealth, time.Now()); err != nil {
+		return fmt.Errorf("invalid key template: %w", err)
+	}
+	return nil
+}
+
+// keyData is what key templates are executed with
+type keyData struct {
+	Cluster string
+	Kind    string
+	Time    time.Time
+}
+
+// Key renders the object key for a document of kind archived at t
+func (c *Config) Key(cluster, kind string, t time.Time) (string, error) {
+	var buf bytes.Buffer
+	if err := c.keys.Execute(&buf, keyData{Cluster: cluster, Kind: kind, Time: t.UTC()}); err != nil {
+		return "", fmt.Errorf("failed to render key: %w", err)
+	}
+	return c.Prefix + strings.TrimPrefix(buf.String(), "/"), nil
+}
+
+// ObjectKey returns the key of a document stored by name rather than by time, such as a
+// cleanup backup
+func (c *Config) ObjectKey(cluster, kind, name string) string {
+	return c.Prefix + kind + "/cluster=" + cluster + "/" + name + ".json.gz"
+}
+
+// Uploader copies a local file to an object key
+type Uploader interface {
+	Upload(ctx context.Context, file, key string) error
+}
+
+// CLIUploader uploads with the provider's CLI, using its usual credentials: the AWS CLI's
+// credential chain, gcloud's active account or workload identity, or az's login
+type CLIUploader struct {
+	Config *Config
+}
+
+// Upload copies file to key with aws, gcloud or az
+func (u CLIUploader) Upload(ctx context.Context, file, key string) error {
+	c := u.Config
+	var name string
+	var args []string
+	switch c.Provider {
+	case ProviderS3:
+		name, args = "aws", []string{"s3", "cp", file, "s3://" + c.Bucket + "/" + key, "--only-show-errors"}
+		if c.Endpoint != "" {
+			args = append(args, "--endpoint-url", c.Endpoint)
+		}
+	case ProviderGCS:
+		name, args = "gcloud", []string{"storage", "cp", file, "gs://" + c.Bucket + "/" + key}
+	case ProviderAzure:
+		name, args = "az", []string{"storage", "blob", "upload", "--auth-mode", "login", "--only-show-errors", "--overwrite",
+			"--account-name", c.Account, "--container-name", c.Bucket, "--name", key, "--file", file}
+	}
+	return runCLI(ctx, name, args)
+}
+
+// Download copies key to a local file with aws, gcloud or az
+func (u CLIUploader) Download(ctx context.Context, key, file string) error {
+	c := u.Config
+	var name string
+	var args []string
+	switch c.Provider {
+	case ProviderS3:
+		name, args = "aws", []string{"s3", "cp", "s3://" + c.Bucket + "/" + key, file, "--only-show-errors"}
+		if c.Endpoint != "" {
+			args = append(args, "--endpoint-url", c.Endpoint)
+		}
+	case ProviderGCS:
+		name, args = "gcloud", []string{"storage", "cp", "gs://" + c.Bucket + "/" + key, file}
+	case ProviderAzure:
+		name, args = "az", []string{"storage", "blob", "download", "--auth-mode", "login", "--only-show-errors", "--overwrite",
+			"--account-name", c.Account, "--container-name", c.Bucket, "--name", key, "--file", file}
+	}
+	return runCLI(ctx, name, args)
+}
+
+// runCLI runs a provider CLI, returning its output with any error
+func runCLI(ctx context.Context, name string, args []string) error {
+	out, err := exec.CommandContext(ctx, name, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s failed: %w: %s", name, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// Fetch downloads a gzipped JSON document and decodes it into v
+func (u CLIUploader) Fetch(ctx context.Context, key string, v interface{}) error {
+	file, err := os.CreateTemp("", "archive-*.json.gz")
+	if err != nil {
+		return fmt.Errorf("failed to create download file: %w", err)
+	}
+	file.Close()
+	defer os.Remove(file.Name())
+
+	if err := u.Download(ctx, key, file.Name()); err != nil {
+		return fmt.Errorf("failed to download %s: %w", key, err)
+	}
+	data, err := os.ReadFile(file.Name())
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", key, err)
+	}
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to decompress %s: %w", key, err)
+	}
+	if err := json.NewDecoder(gz).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode %s: %w", key, err)
+	}
+	return nil
+}
+
+// Archiver writes gzipped JSON documents to object storage at most once per interval
+type Archiver struct {
+	config   *Config
+	uploader Uploader
+	cluster  string
+
+	mu   sync.Mutex
+	last time.Time // start of the last archived cycle
+}
+
+// NewArchiver creates an archiver for the cluster's documents
+func NewArchiver(config *Config, uploader Uploader, cluster string) *Archiver {
+	return &Archiver{config: config, uploader: uploader, cluster: cluster}
+}
+
+// Due reports whether a cycle starting at now should be archived, and if so marks it
+// archived so documents of one cycle share a time
+func (a *Archiver) Due(now time.Time) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if !a.last.IsZero() && now.Sub(a.last) < a.config.interval {
+		return false
+	}
+	a.last = now
+	return true
+}
+
+// Archive gzips v as JSON and uploads it under the key for kind at t, returning the key
+func (a *Archiver) Archive(ctx context.Context, kind string, v interface{}, t time.Time) (string, error) {
+	key, err := a.config.Key(a.cluster, kind, t)
+	if err != nil {
+		return "", err
+	}
+	if err := a.upload(ctx, key, kind, v); err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+// ArchiveObject gzips v as JSON and uploads it under the key for kind and name,
+// returning the key
+func (a *Archiver) ArchiveObject(ctx context.Context, kind, name string, v interface{}) (string, error) {
+	key := a.config.ObjectKey(a.cluster, kind, name)
+	if err := a.upload(ctx, key, kind, v); err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+// upload gzips v as JSON into a temporary file and uploads it to key
+func (a *Archiver) upload(ctx context.Context, key, kind string, v interface{}) error {
+	file, err := os.CreateTemp("", "archive-*.json.gz")
+	if err != nil {
+		return fmt.Errorf("failed to create archive file: %w", err)
+	}
+	defer os.Remove(file.Name())
+
+	gz := gzip.NewWriter(file)
+	if err := json.NewEncoder(gz).Encode(v); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to encode %s document: %w", kind, err)
+	}
+	if err := gz.Close(); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to compress %s document: %w", kind, err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write archive file: %w", err)
+	}
+
+	if err := a.uploader.Upload(ctx, file.Name(), key); err != nil {
+		return fmt.Errorf("failed to upload %s: %w", key, err)
+	}
+	return nil
+}
@@ -0,0 +1,243 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+)
+
+// Identity is an authenticated caller
+type Identity struct {
+	Subject string   `json:"subject"`
+	Groups  []string `json:"groups,omitempty"`
+}
+
+// Authenticator identifies the caller of a request. It returns a nil identity and nil
+// error when the request carries no credentials it understands, so the next
+// authenticator can try.
+type Authenticator interface {
+	Authenticate(r *http.Request) (*Identity, error)
+}
+
+// ErrInvalidCredentials is returned for credentials that are present but not valid
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// Token is a static API token. Only its SHA-256 hash is kept in the config file.
+type Token struct {
+	Subject string   `json:"subject"`
+	SHA256  string   `json:"sha256"` // hex digest of the token
+	Groups  []string `json:"groups,omitempty"`
+}
+
+// Team maps callers, by group or subject, to the namespaces they may see
+type Team struct {
+	Name       string   `json:"name"`
+	Groups     []string `json:"groups,omitempty"`
+	Subjects   []string `json:"subjects,omitempty"`
+	Namespaces []string `json:"namespaces"`
+}
+
+// Config holds API tokens and the mapping of callers to roles
+type Config struct {
+	Tokens        []Token  `json:"tokens,omitempty"`
+	AdminGroups   []string `json:"adminGroups,omitempty"`
+	AdminSubjects []string `json:"adminSubjects,omitempty"`
+	Teams         []Team   `json:"teams,omitempty"`
+
+	// OIDC, when set, also accepts ID tokens from an OpenID Connect provider, whose
+	// groups claim is matched against AdminGroups and Teams like token groups
+	OIDC *OIDCConfig `json:"oidc,omitempty"`
+}
+
+// LoadConfig reads an auth config from a JSON file
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read auth config: %w", err)
+	}
+
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse auth config: %w", err)
+	}
+	for i, t := range config.Tokens {
+		if t.Subject == "" || len(t.SHA256) != sha256.Size*2 {
+			return nil, fmt.Errorf("token %d must have a subject and a hex SHA-256 digest", i)
+		}
+	}
+	for i, team := range config.Teams {
+		if team.Name == "" || len(team.Namespaces) == 0 || len(team.Groups)+len(team.Subjects) == 0 {
+			return nil, fmt.Errorf("team %d must have a name, namespaces and groups or subjects", i)
+		}
+	}
+	if oidc := config.OIDC; oidc != nil && (oidc.Issuer == "" || oidc.ClientID == "" || oidc.RedirectURL == "") {
+		return nil, fmt.Errorf("oidc must have an issuer, clientID and redirectURL")
+	}
+	return &config, nil
+}
+
+// TokenAuthenticator checks bearer tokens against the configured hashes
+type TokenAuthenticator struct {
+	tokens []Token
+}
+
+// NewTokenAuthenticator creates an authenticator for the config's static tokens
+func NewTokenAuthenticator(config *Config) *TokenAuthenticator {
+	return &TokenAuthenticator{tokens: config.Tokens}
+}
+
+// Authenticate matches the request's bearer token by its hash
+func (a *TokenAuthenticator) Authenticate(r *http.Request) (*Identity, error) {
+	token := BearerToken(r)
+	if token == "" {
+		return nil, nil
+	}
+	sum := sha256.Sum256([]byte(token))
+	digest := hex.EncodeToString(sum[:])
+	for _, t := range a.tokens {
+		if subtle.ConstantTimeCompare([]byte(digest), []byte(strings.ToLower(t.SHA256))) == 1 {
+			return &Identity{Subject: t.Subject, Groups: t.Groups}, nil
+		}
+	}
+	// Not one of ours; another authenticator may accept it
+	return nil, nil
+}
+
+// BearerToken returns the token from a request's Authorization header
+func BearerToken(r *http.Request) string {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// Scope is what an identity may see: everything for admins, otherwise its teams'
+// namespaces
+type Scope struct {
+	Identity   *Identity
+	Admin      bool
+	Namespaces map[string]bool
+}
+
+// Allows reports whether the scope covers a namespace
+func (s Scope) Allows(namespace string) bool {
+	return s.Admin || s.Namespaces[namespace]
+}
+
+// ScopeFor resolves an identity's role and namespaces from the config
+func (c *Config) ScopeFor(id *Identity) Scope {
+	scope := Scope{Identity: id, Namespaces: make(map[string]bool)}
+	if slices.Contains(c.AdminSubjects, id.Subject) || overlaps(c.AdminGroups, id.Groups) {
+		scope.Admin = true
+		return scope
+	}
+	for _, team := range c.Teams {
+		if slices.Contains(team.Subjects, id.Subject) || overlaps(team.Groups, id.Groups) {
+			for _, namespace := range team.Namespaces {
+				scope.Namespaces[namespace] = true
+			}
+		}
+	}
+	return scope
+}
+
+type scopeKey struct{}
+
+// ScopeFrom returns the scope a Guard attached to a request's context. Requests served
+// without a guard have no scope and are unrestricted.
+func ScopeFrom(ctx context.Context) (Scope, bool) {
+	scope, ok := ctx.Value(scopeKey{}).(Scope)
+	return scope, ok
+}
+
+// Guard authenticates API requests and attaches the caller's scope to them
+type Guard struct {
+	config         *Config
+	authenticators []Authenticator
+	login          bool // browsers without a session are sent to LoginPath
+}
+
+// NewGuard creates a guard trying each authenticator in turn
+func NewGuard(config *Config, authenticators ...Authenticator) *Guard {
+	g := &Guard{config: config, authenticators: authenticators}
+	for _, a := range authenticators {
+		if _, ok := a.(*OIDCAuthenticator); ok {
+			g.login = true
+		}
+	}
+	return g
+}
+
+// Protect wraps a handler so only authenticated callers reach it, and only admins when
+// adminOnly is set. A nil guard leaves the handler open.
+func (g *Guard) Protect(next http.Handler, adminOnly bool) http.Handler {
+	if g == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := g.authenticate(r)
+		if err != nil || id == nil {
+			if err != nil {
+				log.Printf("Rejected API request to %s: %v", r.URL.Path, err)
+			}
+			if g.login && isBrowser(r) {
+				http.Redirect(w, r, LoginPath+"?return_to="+url.QueryEscape(r.URL.RequestURI()), http.StatusFound)
+				return
+			}
+			w.Header().Set("WWW-Authenticate", `Bearer realm="k8s-health-manager"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		scope := g.config.ScopeFor(id)
+		if !scope.Admin && (adminOnly || len(scope.Namespaces) == 0) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), scopeKey{}, scope)))
+	})
+}
+
+// authenticate returns the first identity an authenticator accepts
+func (g *Guard) authenticate(r *http.Request) (*Identity, error) {
+	for _, a := range g.authenticators {
+		id, err := a.Authenticate(r)
+		if err != nil {
+			return nil, err
+		}
+		if id != nil {
+			return id, nil
+		}
+	}
+	if BearerToken(r) != "" {
+		return nil, ErrInvalidCredentials
+	}
+	return nil, nil
+}
+
+// isBrowser reports whether a request is a browser navigation rather than an API call,
+// so it can be sent through the login flow
+func isBrowser(r *http.Request) bool {
+	return r.Method == http.MethodGet && BearerToken(r) == "" && strings.Contains(r.Header.Get("Accept"), "text/html")
+}
+
+// overlaps reports whether a and b share a value
+func overlaps(a, b []string) bool {
+	for _, v := range b {
+		if slices.Contains(a, v) {
+			return true
+		}
+	}
+	return false
+}
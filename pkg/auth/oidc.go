@@ -0,0 +1,296 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+// OIDCConfig configures login and ID token validation against an OpenID Connect provider
+type OIDCConfig struct {
+	Issuer        string   `json:"issuer"`
+	ClientID      string   `json:"clientID"`
+	RedirectURL   string   `json:"redirectURL"`             // this server's /callback URL
+	Scopes        []string `json:"scopes,omitempty"`        // defaults to openid, email, profile and groups
+	UsernameClaim string   `json:"usernameClaim,omitempty"` // defaults to "email", which must be verified
+	GroupsClaim   string   `json:"groupsClaim,omitempty"`   // defaults to "groups"
+
+	// ClientSecret is read from OIDC_CLIENT_SECRET rather than the config file
+	ClientSecret string `json:"-"`
+}
+
+// Paths the login flow's handlers are served on
+const (
+	LoginPath    = "/login"
+	CallbackPath = "/callback"
+	LogoutPath   = "/logout"
+)
+
+// Cookies used by the login flow
+const (
+	sessionCookie = "k8s_health_session"
+	stateCookie   = "k8s_health_oidc_state"
+)
+
+// OIDCAuthenticator validates ID tokens from the provider, sent as bearer tokens by API
+// clients or kept in a session cookie after a browser login
+type OIDCAuthenticator struct {
+	config   OIDCConfig
+	client   *http.Client
+	verifier *oidc.IDTokenVerifier
+	oauth2   oauth2.Config
+
+	// secure marks cookies Secure unless the redirect URL is plain HTTP, since TLS is
+	// often terminated in front of the monitor where r.TLS is nil
+	secure bool
+}
+
+// NewOIDCAuthenticator reads the provider's discovery document. Its signing keys are
+// fetched when first needed and refetched when a token names an unknown key.
+func NewOIDCAuthenticator(ctx context.Context, config OIDCConfig) (*OIDCAuthenticator, error) {
+	if config.UsernameClaim == "" {
+		config.UsernameClaim = "email"
+	}
+	if config.GroupsClaim == "" {
+		config.GroupsClaim = "groups"
+	}
+	if len(config.Scopes) == 0 {
+		config.Scopes = []string{"openid", "email", "profile", "groups"}
+	}
+	if config.ClientSecret == "" {
+		config.ClientSecret = os.Getenv("OIDC_CLIENT_SECRET")
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	provider, err := oidc.NewProvider(oidc.ClientContext(ctx, client), config.Issuer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OIDC discovery document: %w", err)
+	}
+	return &OIDCAuthenticator{
+		config: config,
+		client: client,
+		verifier: provider.Verifier(&oidc.Config{
+			ClientID:             config.ClientID,
+			SupportedSigningAlgs: []string{oidc.RS256, oidc.ES256},
+		}),
+		oauth2: oauth2.Config{
+			ClientID:     config.ClientID,
+			ClientSecret: config.ClientSecret,
+			Endpoint:     provider.Endpoint(),
+			RedirectURL:  config.RedirectURL,
+			Scopes:       config.Scopes,
+		},
+		secure: secureRedirect(config.RedirectURL),
+	}, nil
+}
+
+// Authenticate validates a bearer ID token, or the session cookie's
+func (a *OIDCAuthenticator) Authenticate(r *http.Request) (*Identity, error) {
+	token := BearerToken(r)
+	if token == "" {
+		cookie, err := r.Cookie(sessionCookie)
+		if err != nil {
+			return nil, nil
+		}
+		token = cookie.Value
+	}
+	if strings.Count(token, ".") != 2 {
+		// Not a JWT; leave it to the token authenticator
+		return nil, nil
+	}
+
+	claims, _, err := a.verify(r.Context(), token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+	id, err := a.identity(claims)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+	return id, nil
+}
+
+// identity maps token claims to an identity using the configured claims. An email is only
+// taken as the subject when the provider verified it, since anyone can otherwise sign up
+// with an admin's address.
+func (a *OIDCAuthenticator) identity(claims map[string]interface{}) (*Identity, error) {
+	id := &Identity{}
+	id.Subject, _ = claims[a.config.UsernameClaim].(string)
+	if id.Subject != "" && a.config.UsernameClaim == "email" && !emailVerified(claims) {
+		return nil, fmt.Errorf("email %s is not verified by the provider", id.Subject)
+	}
+	if id.Subject == "" {
+		id.Subject, _ = claims["sub"].(string)
+	}
+	switch groups := claims[a.config.GroupsClaim].(type) {
+	case []interface{}:
+		for _, g := range groups {
+			if s, ok := g.(string); ok {
+				id.Groups = append(id.Groups, s)
+			}
+		}
+	case string:
+		id.Groups = []string{groups}
+	}
+	return id, nil
+}
+
+// emailVerified reports whether the email_verified claim is true. Some providers send it
+// as a string.
+func emailVerified(claims map[string]interface{}) bool {
+	switch v := claims["email_verified"].(type) {
+	case bool:
+		return v
+	case string:
+		return v == "true"
+	}
+	return false
+}
+
+// safeReturnTo reports whether a login may return to a path: it must be local to this
+// server. "//host" and "/\host" are both taken by browsers as another host.
+func safeReturnTo(path string) bool {
+	return strings.HasPrefix(path, "/") && !strings.HasPrefix(path, "//") && !strings.HasPrefix(path, "/\\")
+}
+
+// secureRedirect reports whether cookies for a redirect URL should be Secure. Only an
+// explicit http:// URL turns it off.
+func secureRedirect(redirectURL string) bool {
+	u, err := url.Parse(redirectURL)
+	return err != nil || !strings.EqualFold(u.Scheme, "http")
+}
+
+// LoginHandler starts the authorization code flow, remembering where to return to
+func (a *OIDCAuthenticator) LoginHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		state, err := randomString()
+		if err != nil {
+			http.Error(w, "failed to start login", http.StatusInternalServerError)
+			return
+		}
+		returnTo := r.URL.Query().Get("return_to")
+		if !safeReturnTo(returnTo) {
+			returnTo = "/"
+		}
+		http.SetCookie(w, &http.Cookie{
+			Name:     stateCookie,
+			Value:    state + "|" + returnTo,
+			Path:     "/",
+			MaxAge:   600,
+			HttpOnly: true,
+			Secure:   a.secure,
+			SameSite: http.SameSiteLaxMode,
+		})
+		http.Redirect(w, r, a.oauth2.AuthCodeURL(state, oidc.Nonce(nonceFor(state))), http.StatusFound)
+	})
+}
+
+// CallbackHandler exchanges the authorization code for an ID token and stores it in the
+// session cookie until the token expires
+func (a *OIDCAuthenticator) CallbackHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie(stateCookie)
+		state, returnTo, _ := strings.Cut(valueOf(cookie, err), "|")
+		if state == "" || r.URL.Query().Get("state") != state {
+			http.Error(w, "invalid login state", http.StatusBadRequest)
+			return
+		}
+		http.SetCookie(w, &http.Cookie{Name: stateCookie, Path: "/", MaxAge: -1})
+
+		rawToken, err := a.exchange(r.Context(), r.URL.Query().Get("code"))
+		if err != nil {
+			http.Error(w, "login failed", http.StatusUnauthorized)
+			return
+		}
+		claims, idToken, err := a.verify(r.Context(), rawToken)
+		if err != nil || idToken.Nonce != nonceFor(state) {
+			http.Error(w, "login failed", http.StatusUnauthorized)
+			return
+		}
+		if _, err := a.identity(claims); err != nil {
+			log.Printf("Rejected login: %v", err)
+			http.Error(w, "login failed", http.StatusUnauthorized)
+			return
+		}
+
+		http.SetCookie(w, &http.Cookie{
+			Name:     sessionCookie,
+			Value:    rawToken,
+			Path:     "/",
+			Expires:  idToken.Expiry,
+			HttpOnly: true,
+			Secure:   a.secure,
+			SameSite: http.SameSiteLaxMode,
+		})
+		http.Redirect(w, r, returnTo, http.StatusFound)
+	})
+}
+
+// LogoutHandler clears the session cookie
+func (a *OIDCAuthenticator) LogoutHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: sessionCookie, Path: "/", MaxAge: -1})
+		fmt.Fprintln(w, "logged out")
+	})
+}
+
+// exchange redeems an authorization code at the token endpoint and returns the ID token
+func (a *OIDCAuthenticator) exchange(ctx context.Context, code string) (string, error) {
+	token, err := a.oauth2.Exchange(oidc.ClientContext(ctx, a.client), code)
+	if err != nil {
+		return "", fmt.Errorf("failed to exchange authorization code: %w", err)
+	}
+	idToken, ok := token.Extra("id_token").(string)
+	if !ok || idToken == "" {
+		return "", fmt.Errorf("token response has no id_token")
+	}
+	return idToken, nil
+}
+
+// verify checks an ID token's signature, issuer, audience and expiry, and returns its
+// claims
+func (a *OIDCAuthenticator) verify(ctx context.Context, token string) (map[string]interface{}, *oidc.IDToken, error) {
+	idToken, err := a.verifier.Verify(oidc.ClientContext(ctx, a.client), token)
+	if err != nil {
+		return nil, nil, err
+	}
+	var claims map[string]interface{}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, nil, fmt.Errorf("malformed token claims: %w", err)
+	}
+	return claims, idToken, nil
+}
+
+// randomString returns 32 random bytes, base64url encoded
+func randomString() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// nonceFor derives the login nonce from its state, so neither needs server-side storage
+func nonceFor(state string) string {
+	sum := sha256.Sum256([]byte("nonce:" + state))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// valueOf returns a cookie's value, or "" if it was not sent
+func valueOf(cookie *http.Cookie, err error) string {
+	if err != nil {
+		return ""
+	}
+	return cookie.Value
+}
@@ -0,0 +1,307 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+)
+
+func TestOIDCIdentity(t *testing.T) {
+	tests := []struct {
+		name          string
+		usernameClaim string
+		claims        map[string]interface{}
+		want          *Identity
+		wantErr       bool
+	}{
+		{
+			name:   "verified email",
+			claims: map[string]interface{}{"sub": "u1", "email": "ana@example.com", "email_verified": true, "groups": []interface{}{"sre", 7, "dev"}},
+			want:   &Identity{Subject: "ana@example.com", Groups: []string{"sre", "dev"}},
+		},
+		{
+			name:   "verified email as a string",
+			claims: map[string]interface{}{"sub": "u1", "email": "ana@example.com", "email_verified": "true"},
+			want:   &Identity{Subject: "ana@example.com"},
+		},
+		{
+			name:    "unverified email",
+			claims:  map[string]interface{}{"sub": "u1", "email": "admin@example.com", "email_verified": false},
+			wantErr: true,
+		},
+		{
+			name:    "email without verification claim",
+			claims:  map[string]interface{}{"sub": "u1", "email": "admin@example.com"},
+			wantErr: true,
+		},
+		{
+			name:   "no email falls back to sub",
+			claims: map[string]interface{}{"sub": "u1", "groups": "sre"},
+			want:   &Identity{Subject: "u1", Groups: []string{"sre"}},
+		},
+		{
+			name:          "other username claim needs no verification",
+			usernameClaim: "preferred_username",
+			claims:        map[string]interface{}{"sub": "u1", "preferred_username": "ana"},
+			want:          &Identity{Subject: "ana"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := OIDCConfig{UsernameClaim: tt.usernameClaim, GroupsClaim: "groups"}
+			if config.UsernameClaim == "" {
+				config.UsernameClaim = "email"
+			}
+			a := &OIDCAuthenticator{config: config}
+			got, err := a.identity(tt.claims)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("identity() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("identity() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSafeReturnTo(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/", true},
+		{"/allocation?window=7d", true},
+		{"", false},
+		{"https://evil.com", false},
+		{"//evil.com", false},
+		{`/\evil.com`, false},
+	}
+	for _, tt := range tests {
+		if got := safeReturnTo(tt.path); got != tt.want {
+			t.Errorf("safeReturnTo(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestLoginCookieSecure(t *testing.T) {
+	tests := []struct {
+		redirectURL string
+		want        bool
+	}{
+		{"https://health.example.com/callback", true},
+		{"HTTPS://health.example.com/callback", true},
+		{"http://localhost:8080/callback", false},
+		{"", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.redirectURL, func(t *testing.T) {
+			a := &OIDCAuthenticator{config: OIDCConfig{RedirectURL: tt.redirectURL}, secure: secureRedirect(tt.redirectURL)}
+			a.oauth2.Endpoint.AuthURL = "https://idp.example.com/authorize"
+
+			// A plain HTTP request, as seen behind a TLS-terminating proxy
+			rec := httptest.NewRecorder()
+			a.LoginHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/login", nil))
+			cookies := rec.Result().Cookies()
+			if len(cookies) != 1 || cookies[0].Name != stateCookie {
+				t.Fatalf("cookies = %v, want the state cookie", cookies)
+			}
+			if cookies[0].Secure != tt.want {
+				t.Errorf("Secure = %v, want %v", cookies[0].Secure, tt.want)
+			}
+		})
+	}
+}
+
+// testProvider is an OpenID Connect provider serving discovery, its signing key and a
+// token endpoint that returns idToken
+type testProvider struct {
+	*httptest.Server
+	key     *rsa.PrivateKey
+	idToken string
+}
+
+func newTestProvider(t *testing.T) *testProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &testProvider{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"issuer":                 p.URL,
+			"authorization_endpoint": p.URL + "/authorize",
+			"token_endpoint":         p.URL + "/token",
+			"jwks_uri":               p.URL + "/keys",
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{Key: &key.PublicKey, KeyID: "k1", Algorithm: "RS256", Use: "sig"}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"access_token": "opaque", "token_type": "Bearer", "id_token": p.idToken})
+	})
+	p.Server = httptest.NewServer(mux)
+	t.Cleanup(p.Close)
+	return p
+}
+
+// sign returns an ID token for claims, signed with key under key ID kid
+func (p *testProvider) sign(t *testing.T, key *rsa.PrivateKey, kid string, claims map[string]interface{}) string {
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: key}, (&jose.SignerOptions{}).WithType("JWT").WithHeader("kid", kid))
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, _ := json.Marshal(claims)
+	object, err := signer.Sign(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := object.CompactSerialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+// claims returns valid claims for the client, with changes applied
+func (p *testProvider) claims(changes map[string]interface{}) map[string]interface{} {
+	claims := map[string]interface{}{
+		"iss":            p.URL,
+		"aud":            "k8s-health",
+		"sub":            "u1",
+		"email":          "ana@example.com",
+		"email_verified": true,
+		"groups":         []string{"sre"},
+		"iat":            time.Now().Unix(),
+		"exp":            time.Now().Add(time.Hour).Unix(),
+	}
+	for k, v := range changes {
+		claims[k] = v
+	}
+	return claims
+}
+
+func newTestAuthenticator(t *testing.T, p *testProvider) *OIDCAuthenticator {
+	a, err := NewOIDCAuthenticator(context.Background(), OIDCConfig{
+		Issuer:       p.URL,
+		ClientID:     "k8s-health",
+		RedirectURL:  "https://health.example.com/callback",
+		ClientSecret: "secret",
+	})
+	if err != nil {
+		t.Fatalf("NewOIDCAuthenticator() error = %v", err)
+	}
+	return a
+}
+
+func TestOIDCAuthenticate(t *testing.T) {
+	p := newTestProvider(t)
+	a := newTestAuthenticator(t, p)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		want    *Identity
+		wantErr bool
+	}{
+		{name: "valid", token: p.sign(t, p.key, "k1", p.claims(nil)), want: &Identity{Subject: "ana@example.com", Groups: []string{"sre"}}},
+		{name: "not a JWT", token: "static-token"},
+		{name: "other audience", token: p.sign(t, p.key, "k1", p.claims(map[string]interface{}{"aud": "other"})), wantErr: true},
+		{name: "other issuer", token: p.sign(t, p.key, "k1", p.claims(map[string]interface{}{"iss": "https://evil.example.com"})), wantErr: true},
+		{name: "expired", token: p.sign(t, p.key, "k1", p.claims(map[string]interface{}{"exp": time.Now().Add(-time.Hour).Unix()})), wantErr: true},
+		{name: "unknown key", token: p.sign(t, otherKey, "k2", p.claims(nil)), wantErr: true},
+		{name: "wrong key for its ID", token: p.sign(t, otherKey, "k1", p.claims(nil)), wantErr: true},
+		{name: "unverified email", token: p.sign(t, p.key, "k1", p.claims(map[string]interface{}{"email_verified": false})), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/health", nil)
+			r.Header.Set("Authorization", "Bearer "+tt.token)
+			got, err := a.Authenticate(r)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidCredentials) {
+					t.Fatalf("Authenticate() error = %v, want ErrInvalidCredentials", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Authenticate() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Authenticate() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOIDCLogin(t *testing.T) {
+	p := newTestProvider(t)
+	a := newTestAuthenticator(t, p)
+
+	rec := httptest.NewRecorder()
+	a.LoginHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/login?return_to=/allocation", nil))
+	location, err := url.Parse(rec.Header().Get("Location"))
+	if err != nil || !strings.HasPrefix(location.String(), p.URL+"/authorize") {
+		t.Fatalf("login redirected to %q, want the provider's authorization endpoint", rec.Header().Get("Location"))
+	}
+	state := location.Query().Get("state")
+	if location.Query().Get("nonce") != nonceFor(state) || location.Query().Get("client_id") != "k8s-health" {
+		t.Errorf("authorization request = %v, want the client ID and the state's nonce", location.Query())
+	}
+	stateCookie := rec.Result().Cookies()[0]
+
+	tests := []struct {
+		name   string
+		nonce  string
+		state  string
+		status int
+	}{
+		{name: "valid", nonce: nonceFor(state), state: state, status: http.StatusFound},
+		{name: "wrong nonce", nonce: nonceFor("other"), state: state, status: http.StatusUnauthorized},
+		{name: "wrong state", nonce: nonceFor(state), state: "other", status: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p.idToken = p.sign(t, p.key, "k1", p.claims(map[string]interface{}{"nonce": tt.nonce}))
+			r := httptest.NewRequest(http.MethodGet, "/callback?code=c1&state="+url.QueryEscape(tt.state), nil)
+			r.AddCookie(stateCookie)
+			rec := httptest.NewRecorder()
+			a.CallbackHandler().ServeHTTP(rec, r)
+			if rec.Code != tt.status {
+				t.Fatalf("callback status = %d, want %d", rec.Code, tt.status)
+			}
+			if tt.status != http.StatusFound {
+				return
+			}
+			if got := rec.Header().Get("Location"); got != "/allocation" {
+				t.Errorf("callback redirected to %q, want /allocation", got)
+			}
+			var session *http.Cookie
+			for _, c := range rec.Result().Cookies() {
+				if c.Name == sessionCookie {
+					session = c
+				}
+			}
+			if session == nil || session.Value != p.idToken || !session.Secure || !session.HttpOnly {
+				t.Errorf("session cookie = %+v, want the ID token, Secure and HttpOnly", session)
+			}
+		})
+	}
+}
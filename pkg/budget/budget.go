@@ -0,0 +1,233 @@
+package budget
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/history"
+	"github.com/ochestra-tech/ochestra-ai/pkg/notify"
+)
+
+// Budget defines a monthly spending limit for a namespace or a label value
+type Budget struct {
+	Name         string    `json:"name"`
+	Namespace    string    `json:"namespace,omitempty"`
+	Label        string    `json:"label,omitempty"` // "key=value"
+	MonthlyLimit float64   `json:"monthlyLimit"`
+	Thresholds   []float64 `json:"thresholds,omitempty"` // forecast/limit ratios that raise alerts
+}
+
+// Config holds the budgets loaded from a budget file
+type Config struct {
+	Budgets []Budget `json:"budgets"`
+}
+
+// Status is the evaluated state of a budget for the current month
+type Status struct {
+	Budget           Budget  `json:"budget"`
+	SpendToDate      float64 `json:"spendToDate"`
+	CurrentRate      float64 `json:"currentRate"` // per hour
+	ForecastMonthEnd float64 `json:"forecastMonthEnd"`
+	BurnRate         float64 `json:"burnRate"` // forecast / limit
+	ExceededAt       float64 `json:"exceededAt,omitempty"`
+}
+
+// defaultThresholds are used when a budget does not define its own
+var defaultThresholds = []float64{0.8, 1.0}
+
+// LoadConfig reads budgets from a JSON file
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read budget config: %w", err)
+	}
+
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse budget config: %w", err)
+	}
+
+	for i, b := range config.Budgets {
+		if b.Name == "" || (b.Namespace == "") == (b.Label == "") {
+			return nil, fmt.Errorf("budget %d must have a name and exactly one of namespace or label", i)
+		}
+		if b.MonthlyLimit <= 0 {
+			return nil, fmt.Errorf("budget %s must have a positive monthlyLimit", b.Name)
+		}
+		if len(b.Thresholds) == 0 {
+			config.Budgets[i].Thresholds = defaultThresholds
+		}
+		sort.Float64s(config.Budgets[i].Thresholds)
+	}
+
+	return &config, nil
+}
+
+// LabelKeys returns the label keys referenced by label-scoped budgets
+func (c *Config) LabelKeys() []string {
+	seen := make(map[string]bool)
+	keys := make([]string, 0)
+	for _, b := range c.Budgets {
+		if b.Label == "" {
+			continue
+		}
+		key, _, _ := strings.Cut(b.Label, "=")
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// Evaluate computes month-to-date spend and a month-end forecast for a budget
+// from the allocation history. Snapshots must be ordered oldest first.
+func Evaluate(b Budget, snapshots []*history.Snapshot, now time.Time) Status {
+	status := Status{Budget: b}
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	monthEnd := monthStart.AddDate(0, 1, 0)
+
+	// Integrate hourly rates between snapshots, assuming each rate holds until the next sample
+	var recentTotal, recentHours float64
+	for i, s := range snapshots {
+		if s.Timestamp.Before(monthStart) {
+			continue
+		}
+		until := now
+		if i+1 < len(snapshots) {
+			until = snapshots[i+1].Timestamp
+		}
+		hours := until.Sub(s.Timestamp).Hours()
+		rate := rateFor(b, s)
+		status.SpendToDate += rate * hours
+		status.CurrentRate = rate
+
+		// Average the last 24 hours for the forecast to smooth out short spikes
+		if now.Sub(s.Timestamp) <= 24*time.Hour {
+			recentTotal += rate * hours
+			recentHours += hours
+		}
+	}
+
+	forecastRate := status.CurrentRate
+	if recentHours > 0 {
+		forecastRate = recentTotal / recentHours
+	}
+
+	status.ForecastMonthEnd = status.SpendToDate + forecastRate*monthEnd.Sub(now).Hours()
+	status.BurnRate = status.ForecastMonthEnd / b.MonthlyLimit
+
+	for _, threshold := range b.Thresholds {
+		if status.BurnRate >= threshold {
+			status.ExceededAt = threshold
+		}
+	}
+
+	return status
+}
+
+// rateFor returns the hourly spend attributed to a budget in a snapshot
+func rateFor(b Budget, s *history.Snapshot) float64 {
+	if b.Label != "" {
+		return s.LabelCosts[b.Label]
+	}
+	for _, ns := range s.NamespaceCosts {
+		if ns.Name == b.Namespace {
+			return ns.TotalCost
+		}
+	}
+	return 0
+}
+
+// Monitor evaluates budgets each cycle and alerts when forecasts cross thresholds
+type Monitor struct {
+	config   *Config
+	store    history.Store
+	notifier notify.Notifier
+	cluster  string
+}
+
+// NewMonitor creates a budget monitor reading allocation history from store
+func NewMonitor(config *Config, store history.Store, notifier notify.Notifier, cluster string) *Monitor {
+	return &Monitor{
+		config:   config,
+		store:    store,
+		notifier: notifier,
+		cluster:  cluster,
+	}
+}
+
+// Check evaluates all budgets and sends an alert for each newly crossed threshold. An
+// alert that fails to send is tried again on the next check, and does not keep the
+// other budgets' alerts from being sent. Sent alerts are recorded on the month's latest
+// allocation snapshot, so restarts do not send them again.
+func (m *Monitor) Check(ctx context.Context, now time.Time) ([]Status, error) {
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	snapshots, err := m.store.List(ctx, history.Query{Cluster: m.cluster, Since: monthStart})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load allocation history: %w", err)
+	}
+	alerted := alertedThresholds(snapshots)
+
+	statuses := make([]Status, 0, len(m.config.Budgets))
+	var errs []error
+	var sent bool
+	for _, b := range m.config.Budgets {
+		status := Evaluate(b, snapshots, now)
+		statuses = append(statuses, status)
+
+		if status.ExceededAt == 0 || status.ExceededAt <= alerted[b.Name] {
+			continue
+		}
+
+		severity := "warning"
+		if status.ExceededAt >= 1.0 {
+			severity = "critical"
+		}
+		alert := notify.Alert{
+			Title:    fmt.Sprintf("Budget %s forecast at %.0f%% of limit", b.Name, status.BurnRate*100),
+			Severity: severity,
+			Source:   "budget",
+			Message: fmt.Sprintf("Month-end forecast $%.2f against a $%.2f budget (spent $%.2f so far, currently $%.2f/hour)",
+				status.ForecastMonthEnd, b.MonthlyLimit, status.SpendToDate, status.CurrentRate),
+			Labels:      map[string]string{"budget": b.Name},
+			Timestamp:   now,
+			Fingerprint: "budget/" + b.Name,
+		}
+		if b.Namespace != "" {
+			alert.Labels["namespace"] = b.Namespace
+		}
+		if err := m.notifier.Notify(ctx, alert); err != nil {
+			errs = append(errs, fmt.Errorf("failed to send alert for budget %s: %w", b.Name, err))
+			continue
+		}
+		alerted[b.Name] = status.ExceededAt
+		sent = true
+	}
+
+	if sent {
+		latest := snapshots[len(snapshots)-1]
+		latest.BudgetAlerts = alerted
+		if err := m.store.Save(ctx, latest); err != nil {
+			errs = append(errs, fmt.Errorf("failed to record budget alerts: %w", err))
+		}
+	}
+	return statuses, errors.Join(errs...)
+}
+
+// alertedThresholds returns the highest threshold alerted per budget in the snapshots
+func alertedThresholds(snapshots []*history.Snapshot) map[string]float64 {
+	alerted := make(map[string]float64)
+	for _, s := range snapshots {
+		for name, threshold := range s.BudgetAlerts {
+			alerted[name] = max(alerted[name], threshold)
+		}
+	}
+	return alerted
+}
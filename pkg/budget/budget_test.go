@@ -0,0 +1,97 @@
+package budget
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/cost"
+	"github.com/ochestra-tech/ochestra-ai/pkg/history"
+	"github.com/ochestra-tech/ochestra-ai/pkg/notify"
+)
+
+// recorder is a notifier that keeps the alerts it is sent
+type recorder struct {
+	alerts []notify.Alert
+}
+
+func (r *recorder) Notify(ctx context.Context, alert notify.Alert) error {
+	r.alerts = append(r.alerts, alert)
+	return nil
+}
+
+func TestCheckRemembersAlertsAcrossRestarts(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 5, 15, 12, 0, 0, 0, time.UTC)
+	store := history.NewMemoryStore()
+	save := func(at time.Time, rate float64) {
+		s := &history.Snapshot{
+			ID:             history.NewSnapshotID("test", at),
+			Cluster:        "test",
+			Timestamp:      at,
+			NamespaceCosts: []cost.NamespaceCostData{{Name: "payments", TotalCost: rate}},
+		}
+		if err := store.Save(ctx, s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// $2/hour since the 1st forecasts about $1488 for May
+	save(time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), 2)
+
+	config := &Config{Budgets: []Budget{{Name: "payments", Namespace: "payments", MonthlyLimit: 1000, Thresholds: []float64{0.8, 1.0}}}}
+	notifier := &recorder{}
+	if _, err := NewMonitor(config, store, notifier, "test").Check(ctx, now); err != nil {
+		t.Fatal(err)
+	}
+	if len(notifier.alerts) != 1 || notifier.alerts[0].Severity != "critical" {
+		t.Fatalf("got alerts %+v, want one critical alert", notifier.alerts)
+	}
+
+	// A restarted monitor reads the alert from history and does not send it again
+	save(now.Add(time.Minute), 2)
+	if _, err := NewMonitor(config, store, notifier, "test").Check(ctx, now.Add(2*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if len(notifier.alerts) != 1 {
+		t.Fatalf("got %d alerts after a restart, want 1", len(notifier.alerts))
+	}
+
+	// Next month starts over
+	june := time.Date(2024, 6, 20, 0, 0, 0, 0, time.UTC)
+	save(time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), 2)
+	if _, err := NewMonitor(config, store, notifier, "test").Check(ctx, june); err != nil {
+		t.Fatal(err)
+	}
+	if len(notifier.alerts) != 2 {
+		t.Fatalf("got %d alerts in a new month, want 2", len(notifier.alerts))
+	}
+}
+
+func TestMergeKeepsBudgetAlerts(t *testing.T) {
+	ctx := context.Background()
+	store := history.NewMemoryStore()
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	for i, alerts := range []map[string]float64{{"payments": 0.8}, nil, {"payments": 1.0, "search": 0.8}} {
+		at := day.Add(time.Duration(i) * 10 * time.Minute)
+		s := &history.Snapshot{ID: history.NewSnapshotID("test", at), Cluster: "test", Timestamp: at, BudgetAlerts: alerts}
+		if err := store.Save(ctx, s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	policy := history.RetentionPolicy{Raw: time.Hour, Hourly: 30 * 24 * time.Hour, Daily: 365 * 24 * time.Hour}
+	if _, err := history.Compact(ctx, store, policy, day.Add(48*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	snapshots, err := store.List(ctx, history.Query{Cluster: "test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshots) != 1 || snapshots[0].Resolution == "" {
+		t.Fatalf("got %d snapshots after compaction, want one rollup", len(snapshots))
+	}
+	alerted := alertedThresholds(snapshots)
+	if alerted["payments"] != 1.0 || alerted["search"] != 0.8 {
+		t.Fatalf("alerted after compaction = %v, want payments 1.0 and search 0.8", alerted)
+	}
+}
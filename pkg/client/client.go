@@ -0,0 +1,243 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/grpcapi"
+	"github.com/ochestra-tech/ochestra-ai/pkg/grpcapi/healthpb"
+	"github.com/ochestra-tech/ochestra-ai/pkg/health"
+	"github.com/ochestra-tech/ochestra-ai/pkg/optimizer"
+	"github.com/ochestra-tech/ochestra-ai/pkg/retry"
+)
+
+// maxResponseSize bounds a single response message
+const maxResponseSize = 64 << 20
+
+// pagingRestarts is how often GetHealth starts over when a newer check replaced the
+// results it was paging through
+const pagingRestarts = 3
+
+// Client calls the monitor's gRPC API, served with --grpc-port
+type Client struct {
+	Backoff  retry.Backoff
+	PageSize int // issues fetched per request by GetHealth
+
+	conn *grpc.ClientConn
+	api  healthpb.HealthMonitorClient
+}
+
+// NewClient creates a client for the monitor at address, e.g. https://k8s-health:9090, or
+// http://k8s-health:9090 for a monitor serving plaintext. The bearer token, needed when
+// the monitor runs with --auth-config, is only sent over TLS.
+func NewClient(address, token string) (*Client, error) {
+	u, err := url.Parse(address)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid monitor address %q", address)
+	}
+	var transport credentials.TransportCredentials
+	switch u.Scheme {
+	case "https":
+		transport = credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12})
+	case "http":
+		if token != "" {
+			return nil, fmt.Errorf("refusing to send a token to %s without TLS: use an https address", address)
+		}
+		transport = insecure.NewCredentials()
+	default:
+		return nil, fmt.Errorf("invalid monitor address %q: want an http or https URL", address)
+	}
+
+	options := []grpc.DialOption{
+		grpc.WithTransportCredentials(transport),
+		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(maxResponseSize)),
+	}
+	if token != "" {
+		options = append(options, grpc.WithPerRPCCredentials(bearerToken(token)))
+	}
+	conn, err := grpc.NewClient(u.Host, options...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gRPC client: %w", err)
+	}
+	return &Client{
+		Backoff:  retry.DefaultBackoff,
+		PageSize: 500,
+		conn:     conn,
+		api:      healthpb.NewHealthMonitorClient(conn),
+	}, nil
+}
+
+// Close closes the connection to the monitor
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// bearerToken sends a token in the authorization metadata of every call, refusing to
+// send it over a connection without TLS
+type bearerToken string
+
+func (t bearerToken) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + string(t)}, nil
+}
+
+func (t bearerToken) RequireTransportSecurity() bool {
+	return true
+}
+
+// IssueFilter selects issues by namespace and minimum severity; empty fields match all
+type IssueFilter struct {
+	Namespace   string
+	MinSeverity string // "critical", "warning" or "info"
+}
+
+// StatusError is a failed call's gRPC status
+type StatusError struct {
+	Code    codes.Code
+	Message string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("gRPC status %s: %s", e.Code, e.Message)
+}
+
+// Retryable reports whether the call may succeed when repeated, which is the case while
+// the monitor is unavailable or has not completed a check yet
+func (e *StatusError) Retryable() bool {
+	return e.Code == codes.Unavailable
+}
+
+// statusError converts an error returned by a call to a StatusError
+func statusError(err error) error {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	st := status.Convert(err)
+	return &StatusError{Code: st.Code(), Message: st.Message()}
+}
+
+// GetHealth returns the latest detailed cluster health with every issue the filter
+// matches, fetching issues page by page
+func (c *Client) GetHealth(ctx context.Context, filter IssueFilter) (*health.ClusterHealth, error) {
+	for restart := 0; ; restart++ {
+		result, err := c.getHealthPages(ctx, filter)
+		var status *StatusError
+		if errors.As(err, &status) && status.Code == codes.Aborted && restart < pagingRestarts {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get cluster health: %w", err)
+		}
+		return result, nil
+	}
+}
+
+// getHealthPages fetches the pages of one check's issues
+func (c *Client) getHealthPages(ctx context.Context, filter IssueFilter) (*health.ClusterHealth, error) {
+	req := &healthpb.ClusterHealthRequest{Namespace: filter.Namespace, MinSeverity: filter.MinSeverity, PageSize: int32(c.PageSize)}
+	var result *health.ClusterHealth
+	for {
+		message, err := retry.Value(ctx, c.Backoff, func() (*healthpb.ClusterHealth, error) {
+			m, err := c.api.GetClusterHealth(ctx, req)
+			return m, statusError(err)
+		})
+		if err != nil {
+			return nil, err
+		}
+		page, next := grpcapi.ClusterHealthFromProto(message)
+		if result == nil {
+			result = page
+		} else {
+			result.Issues = append(result.Issues, page.Issues...)
+		}
+		if next == "" {
+			return result, nil
+		}
+		req.PageToken = next
+	}
+}
+
+// StreamIssues calls fn with each issue the filter matches as it appears, starting with
+// those open now. An issue that clears and comes back is sent again. The stream
+// reconnects after connection failures and runs until ctx is done, reconnecting fails or
+// fn returns an error.
+func (c *Client) StreamIssues(ctx context.Context, filter IssueFilter, fn func(health.HealthIssue) error) error {
+	req := &healthpb.ClusterHealthRequest{Namespace: filter.Namespace, MinSeverity: filter.MinSeverity}
+	open := make(map[string]bool)
+
+	for {
+		received := false
+		err := retry.Do(ctx, c.Backoff, func() error {
+			stream, err := c.api.WatchClusterHealth(ctx, req)
+			if err != nil {
+				return statusError(err)
+			}
+			for {
+				message, err := stream.Recv()
+				if err == io.EOF {
+					// Watches only end when the monitor shuts down
+					return &StatusError{Code: codes.Unavailable, Message: "watch ended"}
+				}
+				if err != nil {
+					return statusError(err)
+				}
+				received = true
+				h, _ := grpcapi.ClusterHealthFromProto(message)
+				current := make(map[string]bool, len(h.Issues))
+				for _, issue := range h.Issues {
+					fingerprint := issue.Fingerprint()
+					current[fingerprint] = true
+					if !open[fingerprint] {
+						if err := fn(issue); err != nil {
+							return err
+						}
+					}
+				}
+				open = current
+			}
+		})
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		// A stream that delivered results before failing gets a fresh set of attempts
+		if !received || !retry.IsRetryable(err) {
+			return fmt.Errorf("failed to stream issues: %w", err)
+		}
+	}
+}
+
+// GetOptimizationReport returns the latest right-sizing recommendations, for one namespace
+// or all of them if namespace is empty
+func (c *Client) GetOptimizationReport(ctx context.Context, namespace string) (*optimizer.OptimizationReport, error) {
+	req := &healthpb.OptimizationReportRequest{Namespace: namespace}
+	message, err := retry.Value(ctx, c.Backoff, func() (*healthpb.OptimizationReport, error) {
+		m, err := c.api.GetOptimizationReport(ctx, req)
+		return m, statusError(err)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get optimization report: %w", err)
+	}
+	return grpcapi.OptimizationReportFromProto(message), nil
+}
+
+// TriggerCheck asks the monitor to run a check cycle now instead of at its next interval.
+// Results arrive through the watch streams once the cycle completes.
+func (c *Client) TriggerCheck(ctx context.Context) error {
+	err := retry.Do(ctx, c.Backoff, func() error {
+		_, err := c.api.TriggerCheck(ctx, &healthpb.TriggerCheckRequest{})
+		return statusError(err)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to trigger check: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/grpcapi"
+	"github.com/ochestra-tech/ochestra-ai/pkg/health"
+	"github.com/ochestra-tech/ochestra-ai/pkg/optimizer"
+	"github.com/ochestra-tech/ochestra-ai/pkg/retry"
+)
+
+// startMonitor serves the gRPC API over plaintext HTTP/2
+func startMonitor(t *testing.T, server *grpcapi.Server) string {
+	ts := httptest.NewUnstartedServer(server)
+	var protocols http.Protocols
+	protocols.SetUnencryptedHTTP2(true)
+	ts.Config.Protocols = &protocols
+	ts.Start()
+	t.Cleanup(ts.Close)
+	return ts.URL
+}
+
+// newTestClient creates a client for address that does not retry
+func newTestClient(t *testing.T, address, token string) *Client {
+	c, err := NewClient(address, token)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+	c.Backoff = retry.Backoff{Initial: time.Millisecond, Max: time.Millisecond, Factor: 1, Attempts: 1}
+	return c
+}
+
+func TestClientCalls(t *testing.T) {
+	server := grpcapi.NewServer()
+	address := startMonitor(t, server)
+	c := newTestClient(t, address, "")
+	c.PageSize = 2
+	ctx := context.Background()
+
+	_, err := c.GetHealth(ctx, IssueFilter{})
+	var status *StatusError
+	if !errors.As(err, &status) || status.Code != codes.Unavailable {
+		t.Fatalf("GetHealth() before the first check error = %v, want Unavailable", err)
+	}
+
+	server.PublishHealth(&health.ClusterHealth{
+		Timestamp:   time.Now(),
+		HealthScore: 90,
+		Issues: []health.HealthIssue{
+			{Type: "A", Severity: "critical", Namespace: "payments", Name: "a"},
+			{Type: "B", Severity: "info", Namespace: "payments", Name: "b"},
+			{Type: "C", Severity: "warning", Namespace: "search", Name: "c"},
+			{Type: "D", Severity: "warning", Namespace: "payments", Name: "d"},
+		},
+	})
+	server.PublishReport(&optimizer.OptimizationReport{
+		PotentialSavings: 10,
+		Recommendations: []optimizer.Recommendation{
+			{Type: "Right-size", Namespace: "payments"},
+			{Type: "Right-size", Namespace: "search"},
+		},
+	})
+
+	h, err := c.GetHealth(ctx, IssueFilter{MinSeverity: "warning"})
+	if err != nil {
+		t.Fatalf("GetHealth() error = %v", err)
+	}
+	var names []string
+	for _, issue := range h.Issues {
+		names = append(names, issue.Name)
+	}
+	if h.HealthScore != 90 || strings.Join(names, ",") != "a,c,d" {
+		t.Errorf("GetHealth() = score %d, issues %v; want 90 and a,c,d", h.HealthScore, names)
+	}
+
+	report, err := c.GetOptimizationReport(ctx, "search")
+	if err != nil {
+		t.Fatalf("GetOptimizationReport() error = %v", err)
+	}
+	if len(report.Recommendations) != 1 || report.Recommendations[0].Namespace != "search" {
+		t.Errorf("GetOptimizationReport() = %+v, want the search recommendation", report.Recommendations)
+	}
+
+	if err := c.TriggerCheck(ctx); err != nil {
+		t.Fatalf("TriggerCheck() error = %v", err)
+	}
+	select {
+	case <-server.Triggered():
+	default:
+		t.Error("TriggerCheck() did not trigger a check")
+	}
+}
+
+func TestStreamIssues(t *testing.T) {
+	server := grpcapi.NewServer()
+	address := startMonitor(t, server)
+	c := newTestClient(t, address, "")
+	server.PublishHealth(&health.ClusterHealth{Issues: []health.HealthIssue{{Type: "A", Namespace: "payments", Name: "a"}}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var received []string
+	err := c.StreamIssues(ctx, IssueFilter{Namespace: "payments"}, func(issue health.HealthIssue) error {
+		received = append(received, issue.Name)
+		if len(received) == 1 {
+			// The open issue is not sent again, the new one is
+			server.PublishHealth(&health.ClusterHealth{Issues: []health.HealthIssue{
+				{Type: "A", Namespace: "payments", Name: "a"},
+				{Type: "B", Namespace: "search", Name: "b"},
+				{Type: "C", Namespace: "payments", Name: "c"},
+			}})
+			return nil
+		}
+		cancel()
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("StreamIssues() error = %v, want context.Canceled", err)
+	}
+	if strings.Join(received, ",") != "a,c" {
+		t.Errorf("StreamIssues() sent %v, want a,c", received)
+	}
+}
+
+func TestNewClientRefusesTokenWithoutTLS(t *testing.T) {
+	if _, err := NewClient("http://k8s-health:9090", "secret"); err == nil {
+		t.Error("NewClient() with a token and a plaintext address succeeded, want an error")
+	}
+	c, err := NewClient("https://k8s-health:9090", "secret")
+	if err != nil {
+		t.Fatalf("NewClient() with a token and a TLS address error = %v", err)
+	}
+	c.Close()
+}
+
+func TestNewClientRejectsInvalidAddress(t *testing.T) {
+	for _, address := range []string{"k8s-health:9090", "ftp://k8s-health:9090", "http://"} {
+		if _, err := NewClient(address, ""); err == nil {
+			t.Errorf("NewClient(%q) succeeded, want an error", address)
+		}
+	}
+}
@@ -0,0 +1,337 @@
+package commitments
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/cost"
+	"github.com/ochestra-tech/ochestra-ai/pkg/history"
+	"github.com/ochestra-tech/ochestra-ai/pkg/snapshot"
+)
+
+// Kinds of commitment
+const (
+	KindSavingsPlan      = "SavingsPlan"
+	KindReservedInstance = "ReservedInstance"
+)
+
+// SeriesKey is the history series holding the on-demand price of the cluster's nodes that
+// a commitment could cover, per hour: every node except spot ones
+const SeriesKey = "nodecost|committable"
+
+// Commitment is a reservation or savings plan in effect
+type Commitment struct {
+	ID           string    `json:"id"`
+	Kind         string    `json:"kind"`
+	InstanceType string    `json:"instanceType,omitempty"` // reserved instances only
+	HourlyCost   float64   `json:"hourlyCost"`             // what the commitment costs per hour, upfront payments spread over its term
+	Coverage     float64   `json:"coverage,omitempty"`     // on-demand spend per hour it covers, 0 derives it from cost.PurchaseDiscounts
+	End          time.Time `json:"end,omitempty"`
+}
+
+// covered returns the on-demand spend per hour the commitment covers
+func (c Commitment) covered() float64 {
+	if c.Coverage > 0 {
+		return c.Coverage
+	}
+	return c.HourlyCost / (1 - cost.PurchaseDiscounts[cost.Reserved])
+}
+
+// Source lists the commitments in effect
+type Source interface {
+	Commitments(ctx context.Context) ([]Commitment, error)
+}
+
+// FileSource reads commitments from a JSON file of the form {"commitments": [...]}, for
+// clouds without a built-in source or billing exports from other tools
+type FileSource struct {
+	Path string
+}
+
+// Commitments reads the commitments file
+func (s FileSource) Commitments(ctx context.Context) ([]Commitment, error) {
+	data, err := os.ReadFile(s.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read commitments: %w", err)
+	}
+	var file struct {
+		Commitments []Commitment `json:"commitments"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse commitments: %w", err)
+	}
+	return file.Commitments, nil
+}
+
+// AWSSource lists active savings plans and EC2 reserved instances with the AWS CLI, using
+// its usual credentials
+type AWSSource struct {
+	Region string // empty uses the CLI's configured region
+}
+
+// Commitments runs describe-savings-plans and describe-reserved-instances
+func (s AWSSource) Commitments(ctx context.Context) ([]Commitment, error) {
+	plans, err := s.savingsPlans(ctx)
+	if err != nil {
+		return nil, err
+	}
+	reserved, err := s.reservedInstances(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return append(plans, reserved...), nil
+}
+
+// aws runs an AWS CLI command and decodes its JSON output into out
+func (s AWSSource) aws(ctx context.Context, out interface{}, args ...string) error {
+	args = append(args, "--output", "json")
+	if s.Region != "" {
+		args = append(args, "--region", s.Region)
+	}
+	data, err := exec.CommandContext(ctx, "aws", args...).Output()
+	if err != nil {
+		return fmt.Errorf("failed to run aws %s %s: %w", args[0], args[1], err)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to parse aws %s %s: %w", args[0], args[1], err)
+	}
+	return nil
+}
+
+// savingsPlans lists active savings plans; their commitment is already an hourly amount
+func (s AWSSource) savingsPlans(ctx context.Context) ([]Commitment, error) {
+	var response struct {
+		SavingsPlans []struct {
+			SavingsPlanID string `json:"savingsPlanId"`
+			Commitment    string `json:"commitment"`
+			End           string `json:"end"`
+		} `json:"savingsPlans"`
+	}
+	if err := s.aws(ctx, &response, "savingsplans", "describe-savings-plans", "--states", "active"); err != nil {
+		return nil, err
+	}
+	commitments := make([]Commitment, 0, len(response.SavingsPlans))
+	for _, plan := range response.SavingsPlans {
+		hourly, err := strconv.ParseFloat(plan.Commitment, 64)
+		if err != nil {
+			continue
+		}
+		end, _ := time.Parse(time.RFC3339, plan.End)
+		commitments = append(commitments, Commitment{ID: plan.SavingsPlanID, Kind: KindSavingsPlan, HourlyCost: hourly, End: end})
+	}
+	return commitments, nil
+}
+
+// reservedInstances lists active EC2 reservations, spreading any upfront payment over the
+// reservation's term
+func (s AWSSource) reservedInstances(ctx context.Context) ([]Commitment, error) {
+	var response struct {
+		ReservedInstances []struct {
+			ReservedInstancesID string    `json:"ReservedInstancesId"`
+			InstanceType        string    `json:"InstanceType"`
+			InstanceCount       int       `json:"InstanceCount"`
+			Duration            int64     `json:"Duration"` // seconds
+			FixedPrice          float64   `json:"FixedPrice"`
+			UsagePrice          float64   `json:"UsagePrice"`
+			End                 time.Time `json:"End"`
+			RecurringCharges    []struct {
+				Amount    float64 `json:"Amount"`
+				Frequency string  `json:"Frequency"`
+			} `json:"RecurringCharges"`
+		} `json:"ReservedInstances"`
+	}
+	if err := s.aws(ctx, &response, "ec2", "describe-reserved-instances", "--filters", "Name=state,Values=active"); err != nil {
+		return nil, err
+	}
+	commitments := make([]Commitment, 0, len(response.ReservedInstances))
+	for _, ri := range response.ReservedInstances {
+		hourly := ri.UsagePrice
+		if ri.Duration > 0 {
+			hourly += ri.FixedPrice / (float64(ri.Duration) / 3600)
+		}
+		for _, charge := range ri.RecurringCharges {
+			if charge.Frequency == "Hourly" {
+				hourly += charge.Amount
+			}
+		}
+		commitments = append(commitments, Commitment{
+			ID:           ri.ReservedInstancesID,
+			Kind:         KindReservedInstance,
+			InstanceType: ri.InstanceType,
+			HourlyCost:   hourly * float64(ri.InstanceCount),
+			End:          ri.End,
+		})
+	}
+	return commitments, nil
+}
+
+// Series returns the history series the analysis reads: the on-demand price of every
+// node that is not spot
+func Series(snap *snapshot.ClusterSnapshot, pricing map[string]cost.ResourcePricing) map[string]float64 {
+	var committable float64
+	nodeCosts := cost.NodeCostsFromSnapshot(snap, pricing)
+	for i := range snap.Nodes {
+		if cost.PurchaseOption(&snap.Nodes[i]) != cost.Spot {
+			committable += nodeCosts[i].TotalCost
+		}
+	}
+	return map[string]float64{SeriesKey: committable}
+}
+
+// Options tune the coverage analysis
+type Options struct {
+	Lookback   time.Duration // history read for the baseline
+	MinHistory time.Duration // history needed before recommending
+	Percentile float64       // of committable spend taken as the steady baseline
+	Expiring   time.Duration // commitments ending within this are reported as expiring
+}
+
+// DefaultOptions takes the 10th percentile of a month of history as the baseline, so the
+// recommended commitment is used at least 90% of hours
+var DefaultOptions = Options{
+	Lookback:   30 * 24 * time.Hour,
+	MinHistory: 7 * 24 * time.Hour,
+	Percentile: 0.1,
+	Expiring:   30 * 24 * time.Hour,
+}
+
+// Report compares the steady baseline of committable spend with the commitments in effect
+type Report struct {
+	Samples     int           `json:"samples"`
+	History     time.Duration `json:"history"`     // span of the history read
+	Baseline    float64       `json:"baseline"`    // steady on-demand spend per hour
+	Covered     float64       `json:"covered"`     // on-demand spend per hour the commitments cover
+	Uncovered   float64       `json:"uncovered"`   // baseline spend not covered, 0 if over-committed
+	OverCovered float64       `json:"overCovered"` // coverage beyond the baseline, likely paid for unused
+
+	Commitments []Commitment `json:"commitments,omitempty"`
+	Expiring    []Commitment `json:"expiring,omitempty"` // ending within Options.Expiring
+
+	Recommendation *Recommendation `json:"recommendation,omitempty"`
+}
+
+// Recommendation is a new commitment covering the uncovered baseline, with the usage it
+// needs to pay off
+type Recommendation struct {
+	HourlyCommitment float64 `json:"hourlyCommitment"` // to buy, at the reserved discount
+	Coverage         float64 `json:"coverage"`         // on-demand spend per hour it covers
+	BreakEven        float64 `json:"breakEven"`        // fraction of hours it must be used to cost no more than on-demand
+	Utilization      float64 `json:"utilization"`      // fraction of it the history would have used
+	SavingsPerMonth  float64 `json:"savingsPerMonth"`  // at that utilization
+}
+
+// Analyze reads the committable spend from history and compares its baseline with the
+// commitments in effect
+func Analyze(snapshots []*history.Snapshot, commitments []Commitment, options Options, now time.Time) Report {
+	var report Report
+	for _, c := range commitments {
+		if !c.End.IsZero() && c.End.Before(now) {
+			continue
+		}
+		report.Commitments = append(report.Commitments, c)
+		report.Covered += c.covered()
+		if !c.End.IsZero() && c.End.Sub(now) <= options.Expiring {
+			report.Expiring = append(report.Expiring, c)
+		}
+	}
+	sort.Slice(report.Expiring, func(i, j int) bool { return report.Expiring[i].End.Before(report.Expiring[j].End) })
+
+	var values []float64
+	var first time.Time
+	for _, s := range snapshots {
+		value, ok := s.Series[SeriesKey]
+		if !ok || s.Timestamp.Before(now.Add(-options.Lookback)) {
+			continue
+		}
+		if first.IsZero() || s.Timestamp.Before(first) {
+			first = s.Timestamp
+		}
+		values = append(values, value)
+	}
+	report.Samples = len(values)
+	if len(values) == 0 {
+		return report
+	}
+	report.History = now.Sub(first)
+	if report.History < options.MinHistory {
+		return report
+	}
+
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	report.Baseline = sorted[int(math.Floor(options.Percentile*float64(len(sorted)-1)))]
+	report.Uncovered = math.Max(report.Baseline-report.Covered, 0)
+	report.OverCovered = math.Max(report.Covered-report.Baseline, 0)
+	if report.Uncovered > 0 {
+		report.Recommendation = recommend(values, report.Covered, report.Uncovered)
+	}
+	return report
+}
+
+// recommend prices a commitment covering the uncovered baseline. It pays off when used
+// more than one minus the discount of hours; the saving is what the history would have
+// saved with it in place.
+func recommend(values []float64, covered, uncovered float64) *Recommendation {
+	discount := cost.PurchaseDiscounts[cost.Reserved]
+	rec := &Recommendation{
+		HourlyCommitment: uncovered * (1 - discount),
+		Coverage:         uncovered,
+		BreakEven:        1 - discount,
+	}
+	var used float64
+	for _, value := range values {
+		used += math.Min(math.Max(value-covered, 0), uncovered)
+	}
+	rec.Utilization = used / (uncovered * float64(len(values)))
+	rec.SavingsPerMonth = (rec.Utilization - rec.BreakEven) * uncovered * 24 * 30
+	return rec
+}
+
+// Analyzer lists commitments at most once per interval, since billing APIs are slow and
+// change rarely, and analyzes them against the cluster's history each cycle
+type Analyzer struct {
+	source   Source
+	store    history.Store
+	cluster  string
+	interval time.Duration
+	options  Options
+
+	mu          sync.Mutex
+	last        time.Time
+	commitments []Commitment
+}
+
+// NewAnalyzer creates an analyzer reading commitments from source and history from store
+func NewAnalyzer(source Source, store history.Store, cluster string, interval time.Duration, options Options) *Analyzer {
+	return &Analyzer{source: source, store: store, cluster: cluster, interval: interval, options: options}
+}
+
+// Analyze returns the coverage report, listing commitments again when the interval passed.
+// A failed listing keeps the previous commitments and is returned with the report.
+func (a *Analyzer) Analyze(ctx context.Context, now time.Time) (Report, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var listErr error
+	if a.last.IsZero() || now.Sub(a.last) >= a.interval {
+		commitments, err := a.source.Commitments(ctx)
+		if err != nil {
+			listErr = err
+		} else {
+			a.commitments, a.last = commitments, now
+		}
+	}
+	snapshots, err := a.store.List(ctx, history.Query{Cluster: a.cluster, Since: now.Add(-a.options.Lookback)})
+	if err != nil {
+		return Report{}, fmt.Errorf("failed to read history: %w", err)
+	}
+	return Analyze(snapshots, a.commitments, a.options, now), listErr
+}
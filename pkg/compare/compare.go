@@ -0,0 +1,308 @@
+package compare
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/health"
+)
+
+// Snapshot is the comparable part of a health report or --output file. Values a document
+// does not carry are nil and left out of comparisons.
+type Snapshot struct {
+	Source         string               `json:"source,omitempty"`
+	Timestamp      time.Time            `json:"timestamp"`
+	HealthScore    *float64             `json:"healthScore,omitempty"`
+	TotalNodes     *float64             `json:"totalNodes,omitempty"`
+	ReadyNodes     *float64             `json:"readyNodes,omitempty"`
+	CPUUsage       *float64             `json:"cpuUsage,omitempty"`    // percent of allocatable
+	MemoryUsage    *float64             `json:"memoryUsage,omitempty"` // percent of allocatable
+	PodDensity     *float64             `json:"podDensity,omitempty"`  // percent of pod slots, from --output files
+	HourlyCost     *float64             `json:"hourlyCost,omitempty"`
+	NamespaceCosts map[string]float64   `json:"namespaceCosts,omitempty"` // hourly
+	Issues         []health.HealthIssue `json:"issues,omitempty"`
+	HasIssues      bool                 `json:"hasIssues"`
+}
+
+// document holds the fields of both a detailed health report and an --output file
+type document struct {
+	Timestamp string `json:"timestamp"`
+
+	// Detailed health report, as archived or sent to gRPC clients and plugins
+	HealthScore   *int                        `json:"healthScore"`
+	NodeStatus    *health.NodeHealthStatus    `json:"nodeStatus"`
+	ResourceUsage *health.ResourceUsageStatus `json:"resourceUsage"`
+	Issues        *[]health.HealthIssue       `json:"issues"`
+
+	// --output file
+	Health *struct {
+		TotalNodes          int     `json:"totalNodes"`
+		ReadyNodes          int     `json:"readyNodes"`
+		ResourceUtilization float64 `json:"resourceUtilization"`
+	} `json:"health"`
+	CostReport *struct {
+		TotalCostPerHour float64            `json:"totalCostPerHour"`
+		CostByNamespace  map[string]float64 `json:"costByNamespace"`
+	} `json:"costReport"`
+}
+
+// Load reads a snapshot from a detailed health report or an --output file, gzipped or not
+func Load(path string) (*Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	snapshot, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	snapshot.Source = path
+	return snapshot, nil
+}
+
+// Parse reads a snapshot from the contents of a detailed health report or an --output file
+func Parse(data []byte) (*Snapshot, error) {
+	if bytes.HasPrefix(data, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress snapshot: %w", err)
+		}
+		if data, err = io.ReadAll(gz); err != nil {
+			return nil, fmt.Errorf("failed to decompress snapshot: %w", err)
+		}
+	}
+
+	var doc document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot: %w", err)
+	}
+	if doc.HealthScore == nil && doc.Health == nil && doc.CostReport == nil {
+		return nil, fmt.Errorf("not a health report or --output file")
+	}
+
+	s := &Snapshot{}
+	s.Timestamp, _ = time.Parse(time.RFC3339Nano, doc.Timestamp)
+	if doc.HealthScore != nil {
+		s.HealthScore = value(float64(*doc.HealthScore))
+	}
+	if doc.NodeStatus != nil {
+		s.TotalNodes, s.ReadyNodes = value(float64(doc.NodeStatus.TotalNodes)), value(float64(doc.NodeStatus.ReadyNodes))
+	}
+	if doc.ResourceUsage != nil {
+		s.CPUUsage, s.MemoryUsage = value(doc.ResourceUsage.ClusterCPUUsage), value(doc.ResourceUsage.ClusterMemoryUsage)
+	}
+	if doc.Issues != nil {
+		s.Issues, s.HasIssues = *doc.Issues, true
+	}
+	if doc.Health != nil {
+		s.TotalNodes, s.ReadyNodes = value(float64(doc.Health.TotalNodes)), value(float64(doc.Health.ReadyNodes))
+		s.PodDensity = value(doc.Health.ResourceUtilization)
+	}
+	if doc.CostReport != nil {
+		s.HourlyCost = value(doc.CostReport.TotalCostPerHour)
+		s.NamespaceCosts = doc.CostReport.CostByNamespace
+	}
+	return s, nil
+}
+
+func value(v float64) *float64 {
+	return &v
+}
+
+// Change is a value in both snapshots
+type Change struct {
+	Before float64 `json:"before"`
+	After  float64 `json:"after"`
+	Delta  float64 `json:"delta"`
+}
+
+// NamespaceCost is the change in a namespace's hourly cost
+type NamespaceCost struct {
+	Namespace string `json:"namespace"`
+	Change
+}
+
+// Result is the difference between two snapshots. Comparisons of values missing from
+// either snapshot are nil.
+type Result struct {
+	Before         *Snapshot            `json:"-"`
+	After          *Snapshot            `json:"-"`
+	BeforeTime     time.Time            `json:"beforeTime"`
+	AfterTime      time.Time            `json:"afterTime"`
+	HealthScore    *Change              `json:"healthScore,omitempty"`
+	TotalNodes     *Change              `json:"totalNodes,omitempty"`
+	ReadyNodes     *Change              `json:"readyNodes,omitempty"`
+	CPUUsage       *Change              `json:"cpuUsage,omitempty"`
+	MemoryUsage    *Change              `json:"memoryUsage,omitempty"`
+	PodDensity     *Change              `json:"podDensity,omitempty"`
+	HourlyCost     *Change              `json:"hourlyCost,omitempty"`
+	NamespaceCosts []NamespaceCost      `json:"namespaceCosts,omitempty"` // changed namespaces, largest change first
+	Severities     map[string]Change    `json:"severities,omitempty"`     // issue counts by severity
+	NewIssues      []health.HealthIssue `json:"newIssues,omitempty"`
+	Resolved       []health.HealthIssue `json:"resolvedIssues,omitempty"`
+	Persisting     int                  `json:"persistingIssues"`
+}
+
+// Diff compares two snapshots, e.g. of one cluster before and after an upgrade, or of two
+// clusters. Issues are matched by fingerprint, so they match across clusters too.
+func Diff(before, after *Snapshot) *Result {
+	r := &Result{
+		Before:      before,
+		After:       after,
+		BeforeTime:  before.Timestamp,
+		AfterTime:   after.Timestamp,
+		HealthScore: change(before.HealthScore, after.HealthScore),
+		TotalNodes:  change(before.TotalNodes, after.TotalNodes),
+		ReadyNodes:  change(before.ReadyNodes, after.ReadyNodes),
+		CPUUsage:    change(before.CPUUsage, after.CPUUsage),
+		MemoryUsage: change(before.MemoryUsage, after.MemoryUsage),
+		PodDensity:  change(before.PodDensity, after.PodDensity),
+		HourlyCost:  change(before.HourlyCost, after.HourlyCost),
+	}
+
+	if before.NamespaceCosts != nil && after.NamespaceCosts != nil {
+		for _, ns := range namespaces(before.NamespaceCosts, after.NamespaceCosts) {
+			c := Change{Before: before.NamespaceCosts[ns], After: after.NamespaceCosts[ns]}
+			c.Delta = c.After - c.Before
+			if math.Abs(c.Delta) >= 0.0001 {
+				r.NamespaceCosts = append(r.NamespaceCosts, NamespaceCost{Namespace: ns, Change: c})
+			}
+		}
+		sort.SliceStable(r.NamespaceCosts, func(i, j int) bool {
+			return math.Abs(r.NamespaceCosts[i].Delta) > math.Abs(r.NamespaceCosts[j].Delta)
+		})
+	}
+
+	if before.HasIssues && after.HasIssues {
+		r.Severities = make(map[string]Change)
+		count := func(issues []health.HealthIssue, after bool) {
+			for _, issue := range issues {
+				c := r.Severities[issue.Severity]
+				if after {
+					c.After++
+				} else {
+					c.Before++
+				}
+				c.Delta = c.After - c.Before
+				r.Severities[issue.Severity] = c
+			}
+		}
+		count(before.Issues, false)
+		count(after.Issues, true)
+
+		previous := fingerprints(before.Issues)
+		current := fingerprints(after.Issues)
+		for _, issue := range after.Issues {
+			if previous[issue.Fingerprint()] {
+				r.Persisting++
+			} else {
+				r.NewIssues = append(r.NewIssues, issue)
+			}
+		}
+		for _, issue := range before.Issues {
+			if !current[issue.Fingerprint()] {
+				r.Resolved = append(r.Resolved, issue)
+			}
+		}
+	}
+	return r
+}
+
+// Regressed reports whether the later snapshot is worse: a lower health score, fewer
+// ready nodes, or critical issues the earlier one did not have
+func (r *Result) Regressed() bool {
+	if r.HealthScore != nil && r.HealthScore.Delta < 0 {
+		return true
+	}
+	if r.ReadyNodes != nil && r.ReadyNodes.Delta < 0 {
+		return true
+	}
+	for _, issue := range r.NewIssues {
+		if issue.Severity == "critical" {
+			return true
+		}
+	}
+	return false
+}
+
+func change(before, after *float64) *Change {
+	if before == nil || after == nil {
+		return nil
+	}
+	return &Change{Before: *before, After: *after, Delta: *after - *before}
+}
+
+// namespaces returns the namespaces of either cost map, sorted
+func namespaces(a, b map[string]float64) []string {
+	names := make([]string, 0, len(a)+len(b))
+	for ns := range a {
+		names = append(names, ns)
+	}
+	for ns := range b {
+		if _, ok := a[ns]; !ok {
+			names = append(names, ns)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// fingerprints returns the set of issue fingerprints
+func fingerprints(issues []health.HealthIssue) map[string]bool {
+	set := make(map[string]bool, len(issues))
+	for _, issue := range issues {
+		set[issue.Fingerprint()] = true
+	}
+	return set
+}
+
+// maxDocumentSize bounds each snapshot posted to the handler
+const maxDocumentSize = 64 << 20
+
+// Handler serves POST /compare with a body of {"before": <report>, "after": <report>},
+// where each report is a detailed health report or an --output file, and answers with
+// the Result
+type Handler struct{}
+
+// ServeHTTP compares the posted snapshots
+func (Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "POST {\"before\": ..., \"after\": ...}", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var body struct {
+		Before json.RawMessage `json:"before"`
+		After  json.RawMessage `json:"after"`
+	}
+	if err := json.NewDecoder(io.LimitReader(r.Body, 2*maxDocumentSize)).Decode(&body); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+	before, err := Parse(body.Before)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("before: %v", err), http.StatusBadRequest)
+		return
+	}
+	after, err := Parse(body.After)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("after: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	result := Diff(before, after)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(struct {
+		*Result
+		Regressed bool `json:"regressed"`
+	}{result, result.Regressed()})
+}
@@ -0,0 +1,261 @@
+package compliance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/retry"
+	"github.com/ochestra-tech/ochestra-ai/pkg/snapshot"
+)
+
+// Benchmark names the benchmark the controls are taken from
+const Benchmark = "CIS Kubernetes Benchmark v1.8 (API-checkable subset)"
+
+// Control results
+const (
+	StatusPass = "pass"
+	StatusFail = "fail"
+	StatusSkip = "skip" // the control could not be checked, e.g. on a managed control plane
+)
+
+// ExemptNamespaces are left out of the workload controls, since system components need
+// the privileges those controls flag
+var ExemptNamespaces = []string{"kube-system", "kube-public", "kube-node-lease"}
+
+// MaxKubeletNodes bounds how many nodes' kubelet configurations are read in large clusters
+var MaxKubeletNodes = 50
+
+// maxFindings bounds the offending objects listed per control
+const maxFindings = 20
+
+// Result is the outcome of one control
+type Result struct {
+	ID       string   `json:"id"`
+	Section  string   `json:"section"`
+	Title    string   `json:"title"`
+	Status   string   `json:"status"`
+	Reason   string   `json:"reason,omitempty"`   // why a control was skipped
+	Findings []string `json:"findings,omitempty"` // objects that fail the control
+}
+
+// Report is the per-control outcome of a benchmark run
+type Report struct {
+	Timestamp time.Time `json:"timestamp"`
+	Benchmark string    `json:"benchmark"`
+	Controls  []Result  `json:"controls"`
+	Passed    int       `json:"passed"`
+	Failed    int       `json:"failed"`
+	Skipped   int       `json:"skipped"`
+	Score     int       `json:"score"` // 0-100, share of checked controls that pass
+}
+
+// inputs holds the cluster state the controls are evaluated on
+type inputs struct {
+	snap                *snapshot.ClusterSnapshot
+	apiServers          []apiServer
+	kubelets            map[string]kubeletConfig // node name -> kubelet configuration
+	kubeletErr          error
+	namespaces          []v1.Namespace
+	serviceAccounts     []v1.ServiceAccount
+	clusterRoles        []rbacv1.ClusterRole
+	roles               []rbacv1.Role
+	clusterRoleBindings []rbacv1.ClusterRoleBinding
+	roleBindings        []rbacv1.RoleBinding
+	errors              map[string]error // resource name -> list error
+}
+
+// Evaluate runs every control against the cluster. Controls whose inputs cannot be read
+// are skipped rather than failed, so missing permissions do not lower the score.
+func Evaluate(ctx context.Context, clientset kubernetes.Interface, snap *snapshot.ClusterSnapshot) (*Report, error) {
+	in := &inputs{snap: snap, errors: make(map[string]error)}
+	in.apiServers = apiServers(snap.Pods)
+	in.kubelets, in.kubeletErr = readKubeletConfigs(ctx, clientset, snap)
+
+	list := func(name string, fn func() error) {
+		if err := retry.Do(ctx, retry.DefaultBackoff, fn); err != nil {
+			in.errors[name] = fmt.Errorf("failed to list %s: %w", name, err)
+		}
+	}
+	list("namespaces", func() error {
+		l, err := clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+		if err == nil {
+			in.namespaces = l.Items
+		}
+		return err
+	})
+	list("serviceaccounts", func() error {
+		l, err := clientset.CoreV1().ServiceAccounts("").List(ctx, metav1.ListOptions{})
+		if err == nil {
+			in.serviceAccounts = l.Items
+		}
+		return err
+	})
+	list("clusterroles", func() error {
+		l, err := clientset.RbacV1().ClusterRoles().List(ctx, metav1.ListOptions{})
+		if err == nil {
+			in.clusterRoles = l.Items
+		}
+		return err
+	})
+	list("roles", func() error {
+		l, err := clientset.RbacV1().Roles("").List(ctx, metav1.ListOptions{})
+		if err == nil {
+			in.roles = l.Items
+		}
+		return err
+	})
+	list("clusterrolebindings", func() error {
+		l, err := clientset.RbacV1().ClusterRoleBindings().List(ctx, metav1.ListOptions{})
+		if err == nil {
+			in.clusterRoleBindings = l.Items
+		}
+		return err
+	})
+	list("rolebindings", func() error {
+		l, err := clientset.RbacV1().RoleBindings("").List(ctx, metav1.ListOptions{})
+		if err == nil {
+			in.roleBindings = l.Items
+		}
+		return err
+	})
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	report := &Report{Timestamp: time.Now(), Benchmark: Benchmark, Controls: make([]Result, 0, len(controls))}
+	for _, c := range controls {
+		result := Result{ID: c.id, Section: c.section, Title: c.title}
+		findings, reason := c.check(in)
+		switch {
+		case reason != "":
+			result.Status, result.Reason = StatusSkip, reason
+			report.Skipped++
+		case len(findings) > 0:
+			result.Status, result.Findings = StatusFail, limit(findings)
+			report.Failed++
+		default:
+			result.Status = StatusPass
+			report.Passed++
+		}
+		report.Controls = append(report.Controls, result)
+	}
+	if checked := report.Passed + report.Failed; checked > 0 {
+		report.Score = int(math.Round(float64(report.Passed) / float64(checked) * 100))
+	}
+	return report, nil
+}
+
+// limit truncates a long findings list, noting how many were left out
+func limit(findings []string) []string {
+	if len(findings) <= maxFindings {
+		return findings
+	}
+	more := len(findings) - maxFindings
+	return append(findings[:maxFindings:maxFindings], fmt.Sprintf("... and %d more", more))
+}
+
+// apiServer is a kube-apiserver static pod and its flags
+type apiServer struct {
+	pod  string
+	args map[string]string
+}
+
+// apiServers returns the kube-apiserver static pods with their flags. Managed control
+// planes run the apiserver outside the cluster, so there are none.
+func apiServers(pods []v1.Pod) []apiServer {
+	var servers []apiServer
+	for _, pod := range pods {
+		if pod.Namespace != "kube-system" || pod.Labels["component"] != "kube-apiserver" {
+			continue
+		}
+		for _, c := range pod.Spec.Containers {
+			if c.Name != "kube-apiserver" {
+				continue
+			}
+			args := make(map[string]string)
+			for _, arg := range append(append([]string{}, c.Command...), c.Args...) {
+				if !strings.HasPrefix(arg, "--") {
+					continue
+				}
+				name, value, ok := strings.Cut(arg[2:], "=")
+				if !ok {
+					value = "true"
+				}
+				args[name] = value
+			}
+			servers = append(servers, apiServer{pod: pod.Name, args: args})
+		}
+	}
+	return servers
+}
+
+// kubeletConfig holds the kubelet configuration fields the controls check
+type kubeletConfig struct {
+	Authentication struct {
+		Anonymous struct {
+			Enabled *bool `json:"enabled"`
+		} `json:"anonymous"`
+		X509 struct {
+			ClientCAFile string `json:"clientCAFile"`
+		} `json:"x509"`
+	} `json:"authentication"`
+	Authorization struct {
+		Mode string `json:"mode"`
+	} `json:"authorization"`
+	ReadOnlyPort                   int    `json:"readOnlyPort"`
+	StreamingConnectionIdleTimeout string `json:"streamingConnectionIdleTimeout"`
+	MakeIPTablesUtilChains         *bool  `json:"makeIPTablesUtilChains"`
+	RotateCertificates             bool   `json:"rotateCertificates"`
+}
+
+// readKubeletConfigs reads the running configuration of each ready node's kubelet through
+// the apiserver's node proxy, sampling nodes in large clusters
+func readKubeletConfigs(ctx context.Context, clientset kubernetes.Interface, snap *snapshot.ClusterSnapshot) (map[string]kubeletConfig, error) {
+	configs := make(map[string]kubeletConfig)
+	var errs []string
+	for _, node := range snap.Nodes {
+		if snap.Large && len(configs)+len(errs) >= MaxKubeletNodes {
+			break
+		}
+		if !nodeReady(node) {
+			continue
+		}
+		data, err := clientset.CoreV1().RESTClient().Get().
+			AbsPath("/api/v1/nodes", node.Name, "proxy", "configz").DoRaw(ctx)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("node %s: %v", node.Name, err))
+			continue
+		}
+		var configz struct {
+			KubeletConfig kubeletConfig `json:"kubeletconfig"`
+		}
+		if err := json.Unmarshal(data, &configz); err != nil {
+			errs = append(errs, fmt.Sprintf("node %s: %v", node.Name, err))
+			continue
+		}
+		configs[node.Name] = configz.KubeletConfig
+	}
+	if len(configs) == 0 && len(errs) > 0 {
+		return nil, fmt.Errorf("failed to read kubelet configuration: %s", errs[0])
+	}
+	return configs, nil
+}
+
+// nodeReady reports whether a node's Ready condition is true
+func nodeReady(node v1.Node) bool {
+	for _, c := range node.Status.Conditions {
+		if c.Type == v1.NodeReady {
+			return c.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
@@ -0,0 +1,438 @@
+package compliance
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Benchmark sections
+const (
+	SectionAPIServer = "1.2 API Server"
+	SectionKubelet   = "4.2 Kubelet"
+	SectionRBAC      = "5.1 RBAC and Service Accounts"
+	SectionPods      = "5.2 Pod Security Standards"
+	SectionSecrets   = "5.4 Secrets Management"
+	SectionGeneral   = "5.7 General Policies"
+)
+
+// control is a benchmark recommendation. check returns the objects failing it, or the
+// reason it could not be checked.
+type control struct {
+	id, section, title string
+	check              func(in *inputs) (findings []string, skipReason string)
+}
+
+// controls lists the recommendations checked, in benchmark order
+var controls = []control{
+	{"1.2.1", SectionAPIServer, "Ensure that the --anonymous-auth argument is set to false", apiServerFlag(func(args map[string]string) bool {
+		return args["anonymous-auth"] == "false"
+	})},
+	{"1.2.2", SectionAPIServer, "Ensure that the --token-auth-file parameter is not set", apiServerFlag(func(args map[string]string) bool {
+		return args["token-auth-file"] == ""
+	})},
+	{"1.2.6", SectionAPIServer, "Ensure that the --authorization-mode argument is not set to AlwaysAllow", apiServerFlag(func(args map[string]string) bool {
+		return !listFlag(args, "authorization-mode", "AlwaysAllow") && args["authorization-mode"] != ""
+	})},
+	{"1.2.7", SectionAPIServer, "Ensure that the --authorization-mode argument includes Node", apiServerFlag(func(args map[string]string) bool {
+		return listFlag(args, "authorization-mode", "Node")
+	})},
+	{"1.2.8", SectionAPIServer, "Ensure that the --authorization-mode argument includes RBAC", apiServerFlag(func(args map[string]string) bool {
+		return listFlag(args, "authorization-mode", "RBAC")
+	})},
+	{"1.2.10", SectionAPIServer, "Ensure that the admission control plugin AlwaysAdmit is not set", apiServerFlag(func(args map[string]string) bool {
+		return !listFlag(args, "enable-admission-plugins", "AlwaysAdmit")
+	})},
+	{"1.2.15", SectionAPIServer, "Ensure that the admission control plugin NodeRestriction is set", apiServerFlag(func(args map[string]string) bool {
+		return listFlag(args, "enable-admission-plugins", "NodeRestriction")
+	})},
+	{"1.2.16", SectionAPIServer, "Ensure that the --profiling argument is set to false", apiServerFlag(func(args map[string]string) bool {
+		return args["profiling"] == "false"
+	})},
+	{"1.2.17", SectionAPIServer, "Ensure that the --audit-log-path argument is set", apiServerFlag(func(args map[string]string) bool {
+		return args["audit-log-path"] != ""
+	})},
+
+	{"4.2.1", SectionKubelet, "Ensure that the --anonymous-auth argument is set to false", kubeletSetting(func(c kubeletConfig) bool {
+		return c.Authentication.Anonymous.Enabled != nil && !*c.Authentication.Anonymous.Enabled
+	})},
+	{"4.2.2", SectionKubelet, "Ensure that the --authorization-mode argument is not set to AlwaysAllow", kubeletSetting(func(c kubeletConfig) bool {
+		return c.Authorization.Mode != "" && c.Authorization.Mode != "AlwaysAllow"
+	})},
+	{"4.2.3", SectionKubelet, "Ensure that the --client-ca-file argument is set as appropriate", kubeletSetting(func(c kubeletConfig) bool {
+		return c.Authentication.X509.ClientCAFile != ""
+	})},
+	{"4.2.4", SectionKubelet, "Verify that the --read-only-port argument is set to 0", kubeletSetting(func(c kubeletConfig) bool {
+		return c.ReadOnlyPort == 0
+	})},
+	{"4.2.5", SectionKubelet, "Ensure that the --streaming-connection-idle-timeout argument is not set to 0", kubeletSetting(func(c kubeletConfig) bool {
+		return c.StreamingConnectionIdleTimeout != "0s" && c.StreamingConnectionIdleTimeout != "0"
+	})},
+	{"4.2.7", SectionKubelet, "Ensure that the --make-iptables-util-chains argument is set to true", kubeletSetting(func(c kubeletConfig) bool {
+		return c.MakeIPTablesUtilChains == nil || *c.MakeIPTablesUtilChains
+	})},
+	{"4.2.10", SectionKubelet, "Ensure that the --rotate-certificates argument is not set to false", kubeletSetting(func(c kubeletConfig) bool {
+		return c.RotateCertificates
+	})},
+
+	{"5.1.1", SectionRBAC, "Ensure that the cluster-admin role is only used where required", checkClusterAdminBindings},
+	{"5.1.2", SectionRBAC, "Minimize access to secrets", roleRules(func(r rbacv1.PolicyRule) bool {
+		return matches(r.APIGroups, "") && matches(r.Resources, "secrets") &&
+			(matches(r.Verbs, "get") || matches(r.Verbs, "list") || matches(r.Verbs, "watch"))
+	})},
+	{"5.1.3", SectionRBAC, "Minimize wildcard use in Roles and ClusterRoles", roleRules(func(r rbacv1.PolicyRule) bool {
+		return slices.Contains(r.APIGroups, "*") || slices.Contains(r.Resources, "*") || slices.Contains(r.Verbs, "*")
+	})},
+	{"5.1.4", SectionRBAC, "Minimize access to create pods", roleRules(func(r rbacv1.PolicyRule) bool {
+		return matches(r.APIGroups, "") && matches(r.Resources, "pods") && matches(r.Verbs, "create")
+	})},
+	{"5.1.5", SectionRBAC, "Ensure that default service accounts are not actively used", checkDefaultServiceAccounts},
+	{"5.1.7", SectionRBAC, "Avoid use of system:masters group", checkSystemMasters},
+	{"5.1.8", SectionRBAC, "Limit use of the Bind, Impersonate and Escalate permissions", roleRules(func(r rbacv1.PolicyRule) bool {
+		return slices.Contains(r.Verbs, "bind") || slices.Contains(r.Verbs, "impersonate") || slices.Contains(r.Verbs, "escalate")
+	})},
+
+	{"5.2.1", SectionPods, "Ensure that the cluster has at least one active policy control mechanism in place", checkPodSecurityAdmission},
+	{"5.2.2", SectionPods, "Minimize the admission of privileged containers", containerCheck(func(_ *v1.Pod, c *v1.Container) bool {
+		return c.SecurityContext != nil && c.SecurityContext.Privileged != nil && *c.SecurityContext.Privileged
+	})},
+	{"5.2.3", SectionPods, "Minimize the admission of containers wishing to share the host process ID namespace", podCheck(func(pod *v1.Pod) bool {
+		return pod.Spec.HostPID
+	})},
+	{"5.2.4", SectionPods, "Minimize the admission of containers wishing to share the host IPC namespace", podCheck(func(pod *v1.Pod) bool {
+		return pod.Spec.HostIPC
+	})},
+	{"5.2.5", SectionPods, "Minimize the admission of containers wishing to share the host network namespace", podCheck(func(pod *v1.Pod) bool {
+		return pod.Spec.HostNetwork
+	})},
+	{"5.2.6", SectionPods, "Minimize the admission of containers with allowPrivilegeEscalation", containerCheck(func(_ *v1.Pod, c *v1.Container) bool {
+		return c.SecurityContext == nil || c.SecurityContext.AllowPrivilegeEscalation == nil || *c.SecurityContext.AllowPrivilegeEscalation
+	})},
+	{"5.2.7", SectionPods, "Minimize the admission of root containers", containerCheck(func(pod *v1.Pod, c *v1.Container) bool {
+		return !runsAsNonRoot(pod, c)
+	})},
+	{"5.2.8", SectionPods, "Minimize the admission of containers with the NET_RAW capability", containerCheck(func(_ *v1.Pod, c *v1.Container) bool {
+		if c.SecurityContext == nil || c.SecurityContext.Capabilities == nil {
+			return true
+		}
+		caps := c.SecurityContext.Capabilities
+		return (!hasCapability(caps.Drop, "NET_RAW") && !hasCapability(caps.Drop, "ALL")) || hasCapability(caps.Add, "NET_RAW")
+	})},
+	{"5.2.9", SectionPods, "Minimize the admission of containers with added capabilities", containerCheck(func(_ *v1.Pod, c *v1.Container) bool {
+		return c.SecurityContext != nil && c.SecurityContext.Capabilities != nil && len(c.SecurityContext.Capabilities.Add) > 0
+	})},
+	{"5.2.12", SectionPods, "Minimize the admission of HostPath volumes", podCheck(func(pod *v1.Pod) bool {
+		for _, volume := range pod.Spec.Volumes {
+			if volume.HostPath != nil {
+				return true
+			}
+		}
+		return false
+	})},
+	{"5.2.13", SectionPods, "Minimize the admission of containers which use HostPorts", containerCheck(func(_ *v1.Pod, c *v1.Container) bool {
+		for _, port := range c.Ports {
+			if port.HostPort != 0 {
+				return true
+			}
+		}
+		return false
+	})},
+
+	{"5.4.1", SectionSecrets, "Prefer using secrets as files over secrets as environment variables", containerCheck(func(_ *v1.Pod, c *v1.Container) bool {
+		for _, env := range c.Env {
+			if env.ValueFrom != nil && env.ValueFrom.SecretKeyRef != nil {
+				return true
+			}
+		}
+		for _, from := range c.EnvFrom {
+			if from.SecretRef != nil {
+				return true
+			}
+		}
+		return false
+	})},
+
+	{"5.7.4", SectionGeneral, "The default namespace should not be used", func(in *inputs) ([]string, string) {
+		var findings []string
+		for i := range in.snap.Pods {
+			if pod := &in.snap.Pods[i]; pod.Namespace == "default" {
+				findings = appendUnique(findings, workload(pod))
+			}
+		}
+		return findings, ""
+	}},
+}
+
+// apiServerFlag checks the flags of every kube-apiserver pod
+func apiServerFlag(ok func(args map[string]string) bool) func(in *inputs) ([]string, string) {
+	return func(in *inputs) ([]string, string) {
+		if len(in.apiServers) == 0 {
+			return nil, "kube-apiserver does not run as a pod; managed control planes are audited by the provider"
+		}
+		var findings []string
+		for _, server := range in.apiServers {
+			if !ok(server.args) {
+				findings = append(findings, "Pod kube-system/"+server.pod)
+			}
+		}
+		return findings, ""
+	}
+}
+
+// listFlag reports whether a comma-separated flag includes value
+func listFlag(args map[string]string, name, value string) bool {
+	return slices.Contains(strings.Split(args[name], ","), value)
+}
+
+// kubeletSetting checks the configuration of every kubelet read
+func kubeletSetting(ok func(kubeletConfig) bool) func(in *inputs) ([]string, string) {
+	return func(in *inputs) ([]string, string) {
+		if in.kubeletErr != nil {
+			return nil, in.kubeletErr.Error()
+		}
+		if len(in.kubelets) == 0 {
+			return nil, "no ready nodes"
+		}
+		var findings []string
+		for node, config := range in.kubelets {
+			if !ok(config) {
+				findings = append(findings, "Node "+node)
+			}
+		}
+		sort.Strings(findings)
+		return findings, ""
+	}
+}
+
+// isDefaultRBAC reports whether an RBAC object is one of the defaults Kubernetes creates
+// and reconciles, which the RBAC controls leave alone
+func isDefaultRBAC(meta metav1.ObjectMeta) bool {
+	return meta.Labels["kubernetes.io/bootstrapping"] == "rbac-defaults" || strings.HasPrefix(meta.Name, "system:")
+}
+
+// roleRules finds the Roles and ClusterRoles, other than the defaults, with a rule that
+// fails the control
+func roleRules(fails func(rbacv1.PolicyRule) bool) func(in *inputs) ([]string, string) {
+	return func(in *inputs) ([]string, string) {
+		for _, name := range []string{"clusterroles", "roles"} {
+			if err := in.errors[name]; err != nil {
+				return nil, err.Error()
+			}
+		}
+		var findings []string
+		for _, role := range in.clusterRoles {
+			if !isDefaultRBAC(role.ObjectMeta) && anyRule(role.Rules, fails) {
+				findings = append(findings, "ClusterRole "+role.Name)
+			}
+		}
+		for _, role := range in.roles {
+			if !isDefaultRBAC(role.ObjectMeta) && anyRule(role.Rules, fails) {
+				findings = append(findings, "Role "+role.Namespace+"/"+role.Name)
+			}
+		}
+		return findings, ""
+	}
+}
+
+func anyRule(rules []rbacv1.PolicyRule, fails func(rbacv1.PolicyRule) bool) bool {
+	for _, rule := range rules {
+		if fails(rule) {
+			return true
+		}
+	}
+	return false
+}
+
+// matches reports whether an RBAC rule list includes v, directly or by wildcard
+func matches(values []string, v string) bool {
+	return slices.Contains(values, v) || slices.Contains(values, "*")
+}
+
+// checkClusterAdminBindings finds bindings to cluster-admin other than the default one
+func checkClusterAdminBindings(in *inputs) ([]string, string) {
+	for _, name := range []string{"clusterrolebindings", "rolebindings"} {
+		if err := in.errors[name]; err != nil {
+			return nil, err.Error()
+		}
+	}
+	var findings []string
+	for _, b := range in.clusterRoleBindings {
+		if b.RoleRef.Kind == "ClusterRole" && b.RoleRef.Name == "cluster-admin" && !isDefaultRBAC(b.ObjectMeta) {
+			findings = append(findings, fmt.Sprintf("ClusterRoleBinding %s (%s)", b.Name, subjects(b.Subjects)))
+		}
+	}
+	for _, b := range in.roleBindings {
+		if b.RoleRef.Kind == "ClusterRole" && b.RoleRef.Name == "cluster-admin" && !isDefaultRBAC(b.ObjectMeta) {
+			findings = append(findings, fmt.Sprintf("RoleBinding %s/%s (%s)", b.Namespace, b.Name, subjects(b.Subjects)))
+		}
+	}
+	return findings, ""
+}
+
+// checkSystemMasters finds bindings, other than the defaults, that name the
+// system:masters group, whose members bypass RBAC
+func checkSystemMasters(in *inputs) ([]string, string) {
+	for _, name := range []string{"clusterrolebindings", "rolebindings"} {
+		if err := in.errors[name]; err != nil {
+			return nil, err.Error()
+		}
+	}
+	var findings []string
+	for _, b := range in.clusterRoleBindings {
+		if !isDefaultRBAC(b.ObjectMeta) && hasGroup(b.Subjects, "system:masters") {
+			findings = append(findings, "ClusterRoleBinding "+b.Name)
+		}
+	}
+	for _, b := range in.roleBindings {
+		if !isDefaultRBAC(b.ObjectMeta) && hasGroup(b.Subjects, "system:masters") {
+			findings = append(findings, "RoleBinding "+b.Namespace+"/"+b.Name)
+		}
+	}
+	return findings, ""
+}
+
+func hasGroup(subjects []rbacv1.Subject, group string) bool {
+	for _, s := range subjects {
+		if s.Kind == rbacv1.GroupKind && s.Name == group {
+			return true
+		}
+	}
+	return false
+}
+
+// subjects lists binding subjects as kind:name
+func subjects(list []rbacv1.Subject) string {
+	names := make([]string, 0, len(list))
+	for _, s := range list {
+		name := s.Name
+		if s.Namespace != "" {
+			name = s.Namespace + "/" + name
+		}
+		names = append(names, s.Kind+":"+name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// checkDefaultServiceAccounts finds default service accounts that still mount their token
+// into pods, outside the exempt namespaces
+func checkDefaultServiceAccounts(in *inputs) ([]string, string) {
+	if err := in.errors["serviceaccounts"]; err != nil {
+		return nil, err.Error()
+	}
+	var findings []string
+	for _, sa := range in.serviceAccounts {
+		if sa.Name != "default" || slices.Contains(ExemptNamespaces, sa.Namespace) {
+			continue
+		}
+		if sa.AutomountServiceAccountToken == nil || *sa.AutomountServiceAccountToken {
+			findings = append(findings, "ServiceAccount "+sa.Namespace+"/default")
+		}
+	}
+	return findings, ""
+}
+
+// checkPodSecurityAdmission finds namespaces outside the exempt ones that Pod Security
+// Admission does not enforce a standard in
+func checkPodSecurityAdmission(in *inputs) ([]string, string) {
+	if err := in.errors["namespaces"]; err != nil {
+		return nil, err.Error()
+	}
+	var findings []string
+	for _, ns := range in.namespaces {
+		if slices.Contains(ExemptNamespaces, ns.Name) {
+			continue
+		}
+		if ns.Labels["pod-security.kubernetes.io/enforce"] == "" {
+			findings = append(findings, "Namespace "+ns.Name)
+		}
+	}
+	return findings, ""
+}
+
+// podCheck finds workloads, outside the exempt namespaces, with a pod failing the control
+func podCheck(fails func(*v1.Pod) bool) func(in *inputs) ([]string, string) {
+	return func(in *inputs) ([]string, string) {
+		var findings []string
+		for i := range in.snap.Pods {
+			pod := &in.snap.Pods[i]
+			if !slices.Contains(ExemptNamespaces, pod.Namespace) && fails(pod) {
+				findings = appendUnique(findings, workload(pod))
+			}
+		}
+		return findings, ""
+	}
+}
+
+// containerCheck finds workloads, outside the exempt namespaces, with a container or init
+// container failing the control
+func containerCheck(fails func(*v1.Pod, *v1.Container) bool) func(in *inputs) ([]string, string) {
+	return podCheck(func(pod *v1.Pod) bool {
+		for _, containers := range [][]v1.Container{pod.Spec.InitContainers, pod.Spec.Containers} {
+			for i := range containers {
+				if fails(pod, &containers[i]) {
+					return true
+				}
+			}
+		}
+		return false
+	})
+}
+
+// runsAsNonRoot reports whether a container is kept from running as root by its own or
+// its pod's security context
+func runsAsNonRoot(pod *v1.Pod, c *v1.Container) bool {
+	if sc := c.SecurityContext; sc != nil {
+		if sc.RunAsUser != nil {
+			return *sc.RunAsUser != 0
+		}
+		if sc.RunAsNonRoot != nil {
+			return *sc.RunAsNonRoot
+		}
+	}
+	if sc := pod.Spec.SecurityContext; sc != nil {
+		if sc.RunAsUser != nil {
+			return *sc.RunAsUser != 0
+		}
+		if sc.RunAsNonRoot != nil {
+			return *sc.RunAsNonRoot
+		}
+	}
+	return false
+}
+
+func hasCapability(caps []v1.Capability, name v1.Capability) bool {
+	for _, c := range caps {
+		if c == name {
+			return true
+		}
+	}
+	return false
+}
+
+// workload names the controller that owns a pod, so replicas are reported once; pods of
+// a Deployment's ReplicaSets are reported as the Deployment
+func workload(pod *v1.Pod) string {
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil {
+		return "Pod " + pod.Namespace + "/" + pod.Name
+	}
+	if hash := pod.Labels["pod-template-hash"]; owner.Kind == "ReplicaSet" && hash != "" {
+		if name, ok := strings.CutSuffix(owner.Name, "-"+hash); ok {
+			return "Deployment " + pod.Namespace + "/" + name
+		}
+	}
+	return owner.Kind + " " + pod.Namespace + "/" + owner.Name
+}
+
+// appendUnique appends v unless values already includes it
+func appendUnique(values []string, v string) []string {
+	if slices.Contains(values, v) {
+		return values
+	}
+	return append(values, v)
+}
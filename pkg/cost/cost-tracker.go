@@ -4,18 +4,26 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"math"
 	"sort"
 
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	metricsv "k8s.io/metrics/pkg/client/clientset/versioned"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/retry"
+	"github.com/ochestra-tech/ochestra-ai/pkg/snapshot"
 )
 
 // PodCostData represents cost information for a pod
 type PodCostData struct {
 	Namespace   string
 	Name        string
+	NodeName    string
+	CPURequest  float64 // cores
+	MemRequest  float64 // GB
+	StorageSize float64 // GB
 	CPUCost     float64
 	MemoryCost  float64
 	StorageCost float64
@@ -38,6 +46,7 @@ type NodeCostData struct {
 	Utilization  float64
 	PodCount     int
 	Labels       map[string]string
+	Virtual      bool // a virtual node, costing what its pods are billed
 }
 
 // NamespaceCostData represents cost information for a namespace
@@ -59,21 +68,87 @@ type ResourcePricing struct {
 	GPUPricing map[string]float64
 }
 
+// VirtualPricing is the pricing key of pods on virtual nodes, which serverless providers
+// bill per pod by its requests. Without it they use the default prices.
+const VirtualPricing = "virtual"
+
+// The smallest pod serverless providers bill for, Fargate's; pods on virtual nodes
+// requesting less are billed for this
+var (
+	VirtualMinCPU      = 0.25 // cores
+	VirtualMinMemoryGB = 0.5
+)
+
+// PricingFor returns the prices of a node: the virtual prices for a virtual node, else its
+// instance type's, falling back to the default prices
+func PricingFor(node *v1.Node, pricing map[string]ResourcePricing) ResourcePricing {
+	key := node.Labels["node.kubernetes.io/instance-type"]
+	if snapshot.IsVirtualNode(node) {
+		key = VirtualPricing
+	}
+	if p, ok := pricing[key]; ok {
+		return p
+	}
+	return pricing["default"]
+}
+
+// virtualPodCost is the hourly CPU and memory cost of a pod on a virtual node: its
+// requests, raised to the smallest pod the provider bills
+func virtualPodCost(pod *v1.Pod, pricing ResourcePricing) (float64, float64) {
+	var cpu, mem float64
+	for _, c := range snapshot.Containers(pod) {
+		cpu += c.Resources.Requests.Cpu().AsApproximateFloat64()
+		mem += float64(c.Resources.Requests.Memory().Value()) / (1024 * 1024 * 1024)
+	}
+	return math.Max(cpu, VirtualMinCPU) * pricing.CPU, math.Max(mem, VirtualMinMemoryGB) * pricing.Memory
+}
+
 // GetNodeCosts calculates costs for all nodes in the cluster
 func GetNodeCosts(
 	ctx context.Context,
-	clientset *kubernetes.Clientset,
-	metricsClient *metricsv.Clientset,
+	clientset kubernetes.Interface,
+	metricsClient metricsv.Interface,
 	pricing map[string]ResourcePricing,
 ) ([]NodeCostData, error) {
-	nodes, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	nodes, err := retry.Value(ctx, retry.DefaultBackoff, func() (*v1.NodeList, error) {
+		return clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to list nodes: %w", err)
 	}
 
-	results := make([]NodeCostData, 0, len(nodes.Items))
+	// Only pods holding node resources count toward utilization, so finished and unscheduled
+	// pods are filtered by the apiserver. Utilization is left at zero if pods cannot be listed.
+	pods, err := snapshot.ListPods(ctx, clientset, "", metav1.ListOptions{
+		FieldSelector: snapshot.ActivePodsSelector,
+	})
+	if err != nil {
+		log.Printf("Failed to list pods for node utilization: %v", err)
+	}
+
+	return computeNodeCosts(nodes.Items, pods, pricing), nil
+}
 
-	for _, node := range nodes.Items {
+// NodeCostsFromSnapshot calculates node costs from a cluster snapshot
+func NodeCostsFromSnapshot(snap *snapshot.ClusterSnapshot, pricing map[string]ResourcePricing) []NodeCostData {
+	return computeNodeCosts(snap.Nodes, snap.Pods, pricing)
+}
+
+// computeNodeCosts calculates node costs and request-based utilization
+func computeNodeCosts(nodes []v1.Node, allPods []v1.Pod, pricing map[string]ResourcePricing) []NodeCostData {
+	// Group pods by node once instead of listing per node
+	podsByNode := make(map[string][]*v1.Pod)
+	for i := range allPods {
+		// Finished pods from a snapshot no longer hold node resources
+		if allPods[i].Status.Phase == v1.PodSucceeded || allPods[i].Status.Phase == v1.PodFailed {
+			continue
+		}
+		podsByNode[allPods[i].Spec.NodeName] = append(podsByNode[allPods[i].Spec.NodeName], &allPods[i])
+	}
+
+	results := make([]NodeCostData, 0, len(nodes))
+
+	for _, node := range nodes {
 		// Get node metadata
 		nodeData := NodeCostData{
 			Name:         node.Name,
@@ -83,9 +158,23 @@ func GetNodeCosts(
 		}
 
 		// Determine which pricing to use based on instance type
-		resourcePricing := pricing["default"]
-		if p, ok := pricing[nodeData.InstanceType]; ok {
-			resourcePricing = p
+		resourcePricing := PricingFor(&node, pricing)
+
+		// A virtual node's capacity is nominal; it costs what its pods are billed and
+		// they use all of it
+		if snapshot.IsVirtualNode(&node) {
+			nodeData.Virtual = true
+			for _, pod := range podsByNode[node.Name] {
+				cpuCost, memCost := virtualPodCost(pod, resourcePricing)
+				nodeData.CPUCost += cpuCost
+				nodeData.MemoryCost += memCost
+			}
+			nodeData.TotalCost = nodeData.CPUCost + nodeData.MemoryCost
+			if nodeData.PodCount = len(podsByNode[node.Name]); nodeData.PodCount > 0 {
+				nodeData.Utilization = 100
+			}
+			results = append(results, nodeData)
+			continue
 		}
 
 		// Calculate CPU cost
@@ -98,7 +187,7 @@ func GetNodeCosts(
 
 		// Calculate storage cost
 		var storageCapacity float64
-		for _, fs := range node.Status.VolumesAttached {
+		for range node.Status.VolumesAttached {
 			// In a real implementation, we would get actual PV sizes
 			// This is a simplified version
 			storageCapacity += 100 // Assume 100GB per attached volume
@@ -109,20 +198,15 @@ func GetNodeCosts(
 		nodeData.TotalCost = nodeData.CPUCost + nodeData.MemoryCost + nodeData.StorageCost
 
 		// Calculate utilization and pod count (simplified)
-		pods, err := clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{
-			FieldSelector: fmt.Sprintf("spec.nodeName=%s", node.Name),
-		})
-		if err != nil {
-			log.Printf("Failed to list pods for node %s: %v", node.Name, err)
-		} else {
-			nodeData.PodCount = len(pods.Items)
+		if pods := podsByNode[node.Name]; len(pods) > 0 {
+			nodeData.PodCount = len(pods)
 
 			// Calculate utilization based on total requests vs capacity
 			totalCPURequests := 0.0
 			totalMemRequests := 0.0
 
-			for _, pod := range pods.Items {
-				for _, container := range pod.Spec.Containers {
+			for _, pod := range pods {
+				for _, container := range snapshot.Containers(pod) {
 					if container.Resources.Requests != nil {
 						cpuReq := float64(container.Resources.Requests.Cpu().MilliValue()) / 1000
 						memReq := float64(container.Resources.Requests.Memory().Value()) / (1024 * 1024 * 1024)
@@ -143,24 +227,50 @@ func GetNodeCosts(
 		results = append(results, nodeData)
 	}
 
-	return results, nil
+	return results
 }
 
 // GetPodCosts calculates costs for all pods in the cluster
 func GetPodCosts(
 	ctx context.Context,
-	clientset *kubernetes.Clientset,
-	metricsClient *metricsv.Clientset,
+	clientset kubernetes.Interface,
+	metricsClient metricsv.Interface,
 	pricing map[string]ResourcePricing,
 ) ([]PodCostData, error) {
-	pods, err := clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
+	// Only running pods are costed, so let the apiserver filter the rest
+	pods, err := snapshot.ListPods(ctx, clientset, "", metav1.ListOptions{
+		FieldSelector: snapshot.RunningPodsSelector,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to list pods: %w", err)
+		return nil, err
+	}
+
+	// Instance types are resolved from one node listing rather than a Get per pod
+	nodes, err := retry.Value(ctx, retry.DefaultBackoff, func() (*v1.NodeList, error) {
+		return clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+
+	return computePodCosts(nodes.Items, pods, pricing), nil
+}
+
+// PodCostsFromSnapshot calculates pod costs from a cluster snapshot
+func PodCostsFromSnapshot(snap *snapshot.ClusterSnapshot, pricing map[string]ResourcePricing) []PodCostData {
+	return computePodCosts(snap.Nodes, snap.Pods, pricing)
+}
+
+// computePodCosts calculates costs for running pods based on their node's pricing
+func computePodCosts(nodes []v1.Node, pods []v1.Pod, pricing map[string]ResourcePricing) []PodCostData {
+	nodesByName := make(map[string]*v1.Node, len(nodes))
+	for i := range nodes {
+		nodesByName[nodes[i].Name] = &nodes[i]
 	}
 
-	results := make([]PodCostData, 0, len(pods.Items))
+	results := make([]PodCostData, 0, len(pods))
 
-	for _, pod := range pods.Items {
+	for _, pod := range pods {
 		// Skip pods that are not running
 		if pod.Status.Phase != v1.PodRunning {
 			continue
@@ -169,23 +279,20 @@ func GetPodCosts(
 		podData := PodCostData{
 			Namespace: pod.Namespace,
 			Name:      pod.Name,
+			NodeName:  pod.Spec.NodeName,
 			Labels:    pod.Labels,
 			QoSClass:  string(pod.Status.QOSClass),
 		}
 
 		// Get node that pod is running on
-		node, err := clientset.CoreV1().Nodes().Get(ctx, pod.Spec.NodeName, metav1.GetOptions{})
-		if err != nil {
-			log.Printf("Failed to get node %s for pod %s/%s: %v", pod.Spec.NodeName, pod.Namespace, pod.Name, err)
+		node, ok := nodesByName[pod.Spec.NodeName]
+		if !ok {
+			log.Printf("Failed to get node %s for pod %s/%s: node not found", pod.Spec.NodeName, pod.Namespace, pod.Name)
 			continue
 		}
 
 		// Determine which pricing to use based on node's instance type
-		instanceType := node.Labels["node.kubernetes.io/instance-type"]
-		resourcePricing := pricing["default"]
-		if p, ok := pricing[instanceType]; ok {
-			resourcePricing = p
-		}
+		resourcePricing := PricingFor(node, pricing)
 
 		// Calculate pod resource costs
 		totalCPURequests := 0.0
@@ -194,7 +301,7 @@ func GetPodCosts(
 		totalMemLimits := 0.0
 		totalStorage := 0.0
 
-		for _, container := range pod.Spec.Containers {
+		for _, container := range snapshot.Containers(&pod) {
 			// CPU requests and limits
 			if container.Resources.Requests != nil {
 				if cpu, ok := container.Resources.Requests.Cpu().AsInt64(); ok {
@@ -233,10 +340,17 @@ func GetPodCosts(
 			}
 		}
 
+		podData.CPURequest = totalCPURequests
+		podData.MemRequest = totalMemRequests
+		podData.StorageSize = totalStorage
+
 		// Calculate costs
 		podData.CPUCost = totalCPURequests * resourcePricing.CPU
 		podData.MemoryCost = totalMemRequests * resourcePricing.Memory
 		podData.StorageCost = totalStorage * resourcePricing.Storage
+		if snapshot.IsVirtualNode(node) {
+			podData.CPUCost, podData.MemoryCost = virtualPodCost(&pod, resourcePricing)
+		}
 		// Network cost would require metrics data
 		podData.NetworkCost = 0
 
@@ -249,7 +363,7 @@ func GetPodCosts(
 		results = append(results, podData)
 	}
 
-	return results, nil
+	return results
 }
 
 // GetNamespaceCosts calculates costs aggregated by namespace
@@ -286,3 +400,18 @@ func GetNamespaceCosts(podCosts []PodCostData) []NamespaceCostData {
 
 	return result
 }
+
+// GetLabelCosts calculates hourly costs aggregated by "key=value" for the given label keys
+func GetLabelCosts(podCosts []PodCostData, labelKeys []string) map[string]float64 {
+	result := make(map[string]float64)
+
+	for _, pod := range podCosts {
+		for _, key := range labelKeys {
+			if value, ok := pod.Labels[key]; ok {
+				result[fmt.Sprintf("%s=%s", key, value)] += pod.TotalCost
+			}
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,52 @@
+package cost
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/snapshot"
+)
+
+// benchPricing is a flat price list used by the cost benchmarks
+var benchPricing = map[string]ResourcePricing{
+	"default": {CPU: 0.04, Memory: 0.005, Storage: 0.0001},
+}
+
+// benchSizes are the synthetic cluster sizes the benchmarks run against
+var benchSizes = []struct{ nodes, pods int }{{20, 200}, {500, 10000}}
+
+func BenchmarkNodeCostsFromSnapshot(b *testing.B) {
+	for _, size := range benchSizes {
+		b.Run(fmt.Sprintf("%dnodes-%dpods", size.nodes, size.pods), func(b *testing.B) {
+			snap := snapshot.Synthetic(size.nodes, size.pods)
+			b.ReportAllocs()
+			for b.Loop() {
+				NodeCostsFromSnapshot(snap, benchPricing)
+			}
+		})
+	}
+}
+
+func BenchmarkPodCostsFromSnapshot(b *testing.B) {
+	for _, size := range benchSizes {
+		b.Run(fmt.Sprintf("%dnodes-%dpods", size.nodes, size.pods), func(b *testing.B) {
+			snap := snapshot.Synthetic(size.nodes, size.pods)
+			b.ReportAllocs()
+			for b.Loop() {
+				PodCostsFromSnapshot(snap, benchPricing)
+			}
+		})
+	}
+}
+
+func BenchmarkGetNamespaceCosts(b *testing.B) {
+	for _, size := range benchSizes {
+		b.Run(fmt.Sprintf("%dnodes-%dpods", size.nodes, size.pods), func(b *testing.B) {
+			snap := snapshot.Synthetic(size.nodes, size.pods)
+			b.ReportAllocs()
+			for b.Loop() {
+				GetNamespaceCosts(PodCostsFromSnapshot(snap, benchPricing))
+			}
+		})
+	}
+}
@@ -0,0 +1,227 @@
+package cost
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/snapshot"
+)
+
+// Purchase options of a node
+const (
+	OnDemand = "on-demand"
+	Spot     = "spot"
+	Reserved = "reserved" // reserved instances, savings plans or committed use
+)
+
+// PurchaseOptionLabel lets nodes bought under a commitment, which no cloud labels, be
+// marked with e.g. ochestra.io/purchase-option=reserved
+const PurchaseOptionLabel = "ochestra.io/purchase-option"
+
+// spotLabels are the node labels the common provisioners set on spot and preemptible nodes
+var spotLabels = map[string]string{
+	"karpenter.sh/capacity-type":            "spot",
+	"eks.amazonaws.com/capacityType":        "SPOT",
+	"cloud.google.com/gke-spot":             "true",
+	"cloud.google.com/gke-preemptible":      "true",
+	"kubernetes.azure.com/scalesetpriority": "spot",
+	"node.kubernetes.io/lifecycle":          "spot",
+}
+
+// PurchaseDiscounts is the discount off the on-demand price of each purchase option, which
+// the pricing data's prices are taken to be
+var PurchaseDiscounts = map[string]float64{
+	Spot:     0.65,
+	Reserved: 0.35,
+}
+
+// SteadyAfter is how long a pod must have run to count toward the steady load worth
+// committing to
+var SteadyAfter = 7 * 24 * time.Hour
+
+// CommitFraction is the share of the steady stateless load on on-demand nodes recommended
+// for reserved capacity, leaving headroom for the load to shrink
+var CommitFraction = 0.8
+
+// SpotMinReplicas is how many replicas a stateless workload needs before its remaining
+// on-demand load is recommended for spot, so losing a node does not take it down
+var SpotMinReplicas = 3
+
+// LifecycleReport breaks the cluster's nodes down by purchase option and recommends a mix
+type LifecycleReport struct {
+	Options         []PurchaseOptionUsage     `json:"options"`
+	CostPerHour     float64                   `json:"costPerHour"`
+	Recommendations []LifecycleRecommendation `json:"recommendations,omitempty"`
+}
+
+// PurchaseOptionUsage is the nodes of one purchase option, their cost after discount and
+// how much of them pods request
+type PurchaseOptionUsage struct {
+	Option      string  `json:"option"`
+	Nodes       int     `json:"nodes"`
+	CPUCores    float64 `json:"cpuCores"`
+	MemoryGB    float64 `json:"memoryGB"`
+	CostPerHour float64 `json:"costPerHour"`
+	Share       float64 `json:"share"`       // fraction of the cluster's cost
+	Utilization float64 `json:"utilization"` // average request-based utilization, percent
+}
+
+// LifecycleRecommendation moves part of the on-demand load to another purchase option
+type LifecycleRecommendation struct {
+	Option          string  `json:"option"`      // the option to move to
+	CostPerHour     float64 `json:"costPerHour"` // on-demand cost of the load moved
+	Share           float64 `json:"share"`       // fraction of the on-demand cost moved
+	SavingsPerMonth float64 `json:"savingsPerMonth"`
+	Workloads       int     `json:"workloads"` // Deployments whose load is moved
+	Description     string  `json:"description"`
+}
+
+// PurchaseOption returns a node's purchase option from its labels
+func PurchaseOption(node *v1.Node) string {
+	switch node.Labels[PurchaseOptionLabel] {
+	case Spot, Reserved, OnDemand:
+		return node.Labels[PurchaseOptionLabel]
+	}
+	if node.Labels["karpenter.sh/capacity-type"] == Reserved {
+		return Reserved
+	}
+	for label, value := range spotLabels {
+		if node.Labels[label] == value {
+			return Spot
+		}
+	}
+	return OnDemand
+}
+
+// LifecycleFromSnapshot prices each node at its option's discount and recommends moving
+// steady stateless load on on-demand nodes to reserved capacity, and the rest of
+// replicated stateless load to spot. Pricing gives on-demand prices. Virtual nodes are
+// left out, since their pods are billed by the provider rather than run on nodes bought
+// under a purchase option.
+func LifecycleFromSnapshot(snap *snapshot.ClusterSnapshot, pricing map[string]ResourcePricing, now time.Time) *LifecycleReport {
+	report := &LifecycleReport{}
+	nodeCosts := computeNodeCosts(snap.Nodes, snap.Pods, pricing)
+
+	options := make(map[string]*PurchaseOptionUsage)
+	nodeOption := make(map[string]string, len(snap.Nodes))
+	onDemandCost := make(map[string]float64) // node -> on-demand cost per hour
+	var onDemandTotal float64
+	for i := range snap.Nodes {
+		node := &snap.Nodes[i]
+		if nodeCosts[i].Virtual {
+			continue
+		}
+		option := PurchaseOption(node)
+		nodeOption[node.Name] = option
+		usage, ok := options[option]
+		if !ok {
+			usage = &PurchaseOptionUsage{Option: option}
+			options[option] = usage
+		}
+		usage.Nodes++
+		usage.CPUCores += float64(node.Status.Capacity.Cpu().Value())
+		usage.MemoryGB += float64(node.Status.Capacity.Memory().Value()) / (1024 * 1024 * 1024)
+		usage.CostPerHour += nodeCosts[i].TotalCost * (1 - PurchaseDiscounts[option])
+		usage.Utilization += nodeCosts[i].Utilization
+		if option == OnDemand {
+			onDemandCost[node.Name] = nodeCosts[i].TotalCost
+			onDemandTotal += nodeCosts[i].TotalCost
+		}
+	}
+	for _, usage := range options {
+		usage.Utilization /= float64(usage.Nodes)
+		report.CostPerHour += usage.CostPerHour
+	}
+	for _, usage := range options {
+		if report.CostPerHour > 0 {
+			usage.Share = usage.CostPerHour / report.CostPerHour
+		}
+		report.Options = append(report.Options, *usage)
+	}
+	sort.Slice(report.Options, func(i, j int) bool { return report.Options[i].CostPerHour > report.Options[j].CostPerHour })
+
+	if onDemandTotal > 0 {
+		report.Recommendations = lifecycleRecommendations(snap, nodeOption, onDemandCost, onDemandTotal, now)
+	}
+	return report
+}
+
+// lifecycleRecommendations splits the on-demand cost of Deployment pods into the steady
+// load worth committing to and the replicated load that can run on spot
+func lifecycleRecommendations(snap *snapshot.ClusterSnapshot, nodeOption map[string]string, onDemandCost map[string]float64, onDemandTotal float64, now time.Time) []LifecycleRecommendation {
+	replicas := make(map[string]int)
+	for i := range snap.Pods {
+		pod := &snap.Pods[i]
+		if kind, name := snapshot.WorkloadOwner(pod); kind == "Deployment" && pod.Status.Phase == v1.PodRunning {
+			replicas[pod.Namespace+"/"+name]++
+		}
+	}
+
+	var steady, replicated float64
+	steadyWorkloads, replicatedWorkloads := make(map[string]bool), make(map[string]bool)
+	for i := range snap.Pods {
+		pod := &snap.Pods[i]
+		kind, name := snapshot.WorkloadOwner(pod)
+		if kind != "Deployment" || pod.Status.Phase != v1.PodRunning || nodeOption[pod.Spec.NodeName] != OnDemand {
+			continue
+		}
+		node := snap.Node(pod.Spec.NodeName)
+		if node == nil {
+			continue
+		}
+		share := podNodeShare(pod, node) * onDemandCost[node.Name]
+		key := pod.Namespace + "/" + name
+		if pod.Status.StartTime != nil && now.Sub(pod.Status.StartTime.Time) >= SteadyAfter {
+			steady += share * CommitFraction
+			steadyWorkloads[key] = true
+			share *= 1 - CommitFraction
+		}
+		if replicas[key] >= SpotMinReplicas {
+			replicated += share
+			replicatedWorkloads[key] = true
+		}
+	}
+
+	var recommendations []LifecycleRecommendation
+	if steady > 0 {
+		recommendations = append(recommendations, LifecycleRecommendation{
+			Option:          Reserved,
+			CostPerHour:     steady,
+			Share:           steady / onDemandTotal,
+			SavingsPerMonth: steady * PurchaseDiscounts[Reserved] * 24 * 30,
+			Description: fmt.Sprintf("Cover %.0f%% of on-demand spend, %.0f%% of the stateless load running for %s or more, with reserved instances or a savings plan",
+				steady/onDemandTotal*100, CommitFraction*100, SteadyAfter),
+			Workloads: len(steadyWorkloads),
+		})
+	}
+	if replicated > 0 {
+		recommendations = append(recommendations, LifecycleRecommendation{
+			Option:          Spot,
+			CostPerHour:     replicated,
+			Share:           replicated / onDemandTotal,
+			SavingsPerMonth: replicated * PurchaseDiscounts[Spot] * 24 * 30,
+			Description: fmt.Sprintf("Move %.0f%% of on-demand spend, from stateless workloads with %d or more replicas, to spot nodes",
+				replicated/onDemandTotal*100, SpotMinReplicas),
+			Workloads: len(replicatedWorkloads),
+		})
+	}
+	return recommendations
+}
+
+// podNodeShare is the fraction of a node a pod requests, averaging CPU and memory
+func podNodeShare(pod *v1.Pod, node *v1.Node) float64 {
+	cpuCapacity := float64(node.Status.Capacity.Cpu().MilliValue())
+	memCapacity := float64(node.Status.Capacity.Memory().Value())
+	if cpuCapacity == 0 || memCapacity == 0 {
+		return 0
+	}
+	var cpu, mem float64
+	for _, container := range pod.Spec.Containers {
+		cpu += float64(container.Resources.Requests.Cpu().MilliValue())
+		mem += float64(container.Resources.Requests.Memory().Value())
+	}
+	return (cpu/cpuCapacity + mem/memCapacity) / 2
+}
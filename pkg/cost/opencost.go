@@ -0,0 +1,350 @@
+package cost
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/client-go/kubernetes"
+	metricsv "k8s.io/metrics/pkg/client/clientset/versioned"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/auth"
+)
+
+// Allocation mirrors the allocation object returned by the OpenCost /allocation API
+type Allocation struct {
+	Name                  string               `json:"name"`
+	Properties            AllocationProperties `json:"properties"`
+	Window                AllocationWindow     `json:"window"`
+	Start                 time.Time            `json:"start"`
+	End                   time.Time            `json:"end"`
+	Minutes               float64              `json:"minutes"`
+	CPUCores              float64              `json:"cpuCores"`
+	CPUCoreRequestAverage float64              `json:"cpuCoreRequestAverage"`
+	CPUCoreUsageAverage   float64              `json:"cpuCoreUsageAverage"`
+	CPUCoreHours          float64              `json:"cpuCoreHours"`
+	CPUCost               float64              `json:"cpuCost"`
+	CPUEfficiency         float64              `json:"cpuEfficiency"`
+	GPUCount              float64              `json:"gpuCount"`
+	GPUHours              float64              `json:"gpuHours"`
+	GPUCost               float64              `json:"gpuCost"`
+	NetworkCost           float64              `json:"networkCost"`
+	LoadBalancerCost      float64              `json:"loadBalancerCost"`
+	PVBytes               float64              `json:"pvBytes"`
+	PVByteHours           float64              `json:"pvByteHours"`
+	PVCost                float64              `json:"pvCost"`
+	RAMBytes              float64              `json:"ramBytes"`
+	RAMByteRequestAverage float64              `json:"ramByteRequestAverage"`
+	RAMByteUsageAverage   float64              `json:"ramByteUsageAverage"`
+	RAMByteHours          float64              `json:"ramByteHours"`
+	RAMCost               float64              `json:"ramCost"`
+	RAMEfficiency         float64              `json:"ramEfficiency"`
+	SharedCost            float64              `json:"sharedCost"`
+	ExternalCost          float64              `json:"externalCost"`
+	TotalCost             float64              `json:"totalCost"`
+	TotalEfficiency       float64              `json:"totalEfficiency"`
+}
+
+// AllocationProperties identifies the workload an allocation belongs to
+type AllocationProperties struct {
+	Cluster   string            `json:"cluster,omitempty"`
+	Node      string            `json:"node,omitempty"`
+	Namespace string            `json:"namespace,omitempty"`
+	Pod       string            `json:"pod,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty"`
+}
+
+// AllocationWindow is the time range an allocation covers
+type AllocationWindow struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// AllocationResponse is the envelope used by the OpenCost API. Estimated marks costs
+// projected from the pods' current rates rather than read from recorded spend, which is
+// what every window, including past ones such as "yesterday", is computed from.
+type AllocationResponse struct {
+	Code      int                      `json:"code"`
+	Status    string                   `json:"status"`
+	Message   string                   `json:"message,omitempty"`
+	Estimated bool                     `json:"estimated"`
+	Data      []map[string]*Allocation `json:"data"`
+}
+
+// maxAllocationSteps bounds the sets one allocation query returns, e.g. 60 days by the hour
+const maxAllocationSteps = 1440
+
+// AllocationHandler serves pod cost data in the OpenCost /allocation shape
+type AllocationHandler struct {
+	clientset     kubernetes.Interface
+	metricsClient metricsv.Interface
+	pricing       map[string]ResourcePricing
+	cluster       string
+}
+
+// NewAllocationHandler creates a handler for the /allocation and /allocation/compute endpoints
+func NewAllocationHandler(
+	clientset kubernetes.Interface,
+	metricsClient metricsv.Interface,
+	pricing map[string]ResourcePricing,
+	cluster string,
+) *AllocationHandler {
+	if cluster == "" {
+		cluster = "cluster-one"
+	}
+	return &AllocationHandler{
+		clientset:     clientset,
+		metricsClient: metricsClient,
+		pricing:       pricing,
+		cluster:       cluster,
+	}
+}
+
+// ServeHTTP handles OpenCost-style allocation queries (window, aggregate, accumulate, step)
+func (h *AllocationHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	now := time.Now().UTC()
+
+	start, end, err := ParseAllocationWindow(query.Get("window"), now)
+	if err != nil {
+		writeAllocationError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	step := end.Sub(start)
+	if accumulate, _ := strconv.ParseBool(query.Get("accumulate")); !accumulate {
+		step = 24 * time.Hour
+		if s := query.Get("step"); s != "" {
+			if step, err = parseWindowDuration(s); err != nil {
+				writeAllocationError(w, http.StatusBadRequest, fmt.Errorf("invalid step: %w", err))
+				return
+			}
+		}
+	}
+	steps := end.Sub(start) / step
+	if end.Sub(start)%step != 0 {
+		steps++
+	}
+	if steps > maxAllocationSteps {
+		writeAllocationError(w, http.StatusBadRequest,
+			fmt.Errorf("window has %d steps of %s, at most %d are allowed; use a larger step", steps, step, maxAllocationSteps))
+		return
+	}
+
+	podCosts, err := GetPodCosts(r.Context(), h.clientset, h.metricsClient, h.pricing)
+	if err != nil {
+		writeAllocationError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	// Team callers only see their own namespaces, in every aggregation
+	if scope, ok := auth.ScopeFrom(r.Context()); ok && !scope.Admin {
+		scoped := make([]PodCostData, 0, len(podCosts))
+		for _, pod := range podCosts {
+			if scope.Allows(pod.Namespace) {
+				scoped = append(scoped, pod)
+			}
+		}
+		podCosts = scoped
+	}
+
+	resp := AllocationResponse{
+		Code:      http.StatusOK,
+		Status:    "success",
+		Message:   "costs are current rates projected over the window, not recorded spend",
+		Estimated: true,
+		Data:      make([]map[string]*Allocation, 0),
+	}
+	for s := start; s.Before(end); s = s.Add(step) {
+		e := s.Add(step)
+		if e.After(end) {
+			e = end
+		}
+		set, err := ComputeAllocations(podCosts, h.cluster, query.Get("aggregate"), s, e)
+		if err != nil {
+			writeAllocationError(w, http.StatusBadRequest, err)
+			return
+		}
+		resp.Data = append(resp.Data, set)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// ComputeAllocations projects hourly pod costs over a window and aggregates them
+// by the given OpenCost aggregation key (namespace, pod, node, cluster or label:<name>)
+func ComputeAllocations(podCosts []PodCostData, cluster, aggregate string, start, end time.Time) (map[string]*Allocation, error) {
+	hours := end.Sub(start).Hours()
+	result := make(map[string]*Allocation)
+
+	for _, pod := range podCosts {
+		key, err := allocationKey(pod, cluster, aggregate)
+		if err != nil {
+			return nil, err
+		}
+
+		alloc, exists := result[key]
+		if !exists {
+			alloc = &Allocation{
+				Name:       key,
+				Properties: allocationProperties(pod, cluster, aggregate),
+				Window:     AllocationWindow{Start: start, End: end},
+				Start:      start,
+				End:        end,
+				Minutes:    end.Sub(start).Minutes(),
+			}
+			result[key] = alloc
+		}
+
+		ramBytes := pod.MemRequest * 1024 * 1024 * 1024
+		pvBytes := pod.StorageSize * 1024 * 1024 * 1024
+
+		alloc.CPUCores += pod.CPURequest
+		alloc.CPUCoreRequestAverage += pod.CPURequest
+		alloc.CPUCoreUsageAverage += pod.CPURequest * pod.Efficiency
+		alloc.CPUCoreHours += pod.CPURequest * hours
+		alloc.CPUCost += pod.CPUCost * hours
+		alloc.RAMBytes += ramBytes
+		alloc.RAMByteRequestAverage += ramBytes
+		alloc.RAMByteUsageAverage += ramBytes * pod.Efficiency
+		alloc.RAMByteHours += ramBytes * hours
+		alloc.RAMCost += pod.MemoryCost * hours
+		alloc.PVBytes += pvBytes
+		alloc.PVByteHours += pvBytes * hours
+		alloc.PVCost += pod.StorageCost * hours
+		alloc.NetworkCost += pod.NetworkCost * hours
+		alloc.TotalCost += pod.TotalCost * hours
+	}
+
+	for _, alloc := range result {
+		if alloc.CPUCoreRequestAverage > 0 {
+			alloc.CPUEfficiency = alloc.CPUCoreUsageAverage / alloc.CPUCoreRequestAverage
+		}
+		if alloc.RAMByteRequestAverage > 0 {
+			alloc.RAMEfficiency = alloc.RAMByteUsageAverage / alloc.RAMByteRequestAverage
+		}
+		if alloc.CPUCost+alloc.RAMCost > 0 {
+			alloc.TotalEfficiency = (alloc.CPUEfficiency*alloc.CPUCost + alloc.RAMEfficiency*alloc.RAMCost) /
+				(alloc.CPUCost + alloc.RAMCost)
+		}
+	}
+
+	return result, nil
+}
+
+// allocationKey returns the aggregation key for a pod
+func allocationKey(pod PodCostData, cluster, aggregate string) (string, error) {
+	switch {
+	case aggregate == "":
+		return strings.Join([]string{cluster, pod.NodeName, pod.Namespace, pod.Name}, "/"), nil
+	case aggregate == "cluster":
+		return cluster, nil
+	case aggregate == "node":
+		return pod.NodeName, nil
+	case aggregate == "namespace":
+		return pod.Namespace, nil
+	case aggregate == "pod":
+		return pod.Name, nil
+	case strings.HasPrefix(aggregate, "label:"):
+		if value, ok := pod.Labels[strings.TrimPrefix(aggregate, "label:")]; ok {
+			return value, nil
+		}
+		return "__unallocated__", nil
+	default:
+		return "", fmt.Errorf("unsupported aggregate: %s", aggregate)
+	}
+}
+
+// allocationProperties returns the properties shared by every pod in an aggregate
+func allocationProperties(pod PodCostData, cluster, aggregate string) AllocationProperties {
+	props := AllocationProperties{Cluster: cluster}
+	switch aggregate {
+	case "", "pod":
+		props.Node = pod.NodeName
+		props.Namespace = pod.Namespace
+		props.Pod = pod.Name
+		props.Labels = pod.Labels
+	case "node":
+		props.Node = pod.NodeName
+	case "namespace":
+		props.Namespace = pod.Namespace
+	}
+	return props
+}
+
+// ParseAllocationWindow parses an OpenCost window expression such as "7d", "24h",
+// "today", "week", "month" or "2024-01-01T00:00:00Z,2024-01-02T00:00:00Z"
+func ParseAllocationWindow(window string, now time.Time) (time.Time, time.Time, error) {
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	switch window {
+	case "", "24h", "1d":
+		return now.Add(-24 * time.Hour), now, nil
+	case "today":
+		return midnight, now, nil
+	case "yesterday":
+		return midnight.AddDate(0, 0, -1), midnight, nil
+	case "week":
+		return midnight.AddDate(0, 0, -int(midnight.Weekday())), now, nil
+	case "month":
+		return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()), now, nil
+	}
+
+	if parts := strings.Split(window, ","); len(parts) == 2 {
+		start, err := time.Parse(time.RFC3339, parts[0])
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid window start: %w", err)
+		}
+		end, err := time.Parse(time.RFC3339, parts[1])
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid window end: %w", err)
+		}
+		if !end.After(start) {
+			return time.Time{}, time.Time{}, fmt.Errorf("window end must be after start")
+		}
+		return start, end, nil
+	}
+
+	d, err := parseWindowDuration(window)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return now.Add(-d), now, nil
+}
+
+// parseWindowDuration parses durations with an optional day suffix ("7d")
+func parseWindowDuration(s string) (time.Duration, error) {
+	var d time.Duration
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.ParseInt(days, 10, 64)
+		if err != nil || n > math.MaxInt64/int64(24*time.Hour) {
+			return 0, fmt.Errorf("invalid window: %s", s)
+		}
+		d = time.Duration(n) * 24 * time.Hour
+	} else {
+		var err error
+		if d, err = time.ParseDuration(s); err != nil {
+			return 0, fmt.Errorf("invalid window: %s", s)
+		}
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("invalid window: %s", s)
+	}
+	return d, nil
+}
+
+// writeAllocationError writes an OpenCost-style error envelope
+func writeAllocationError(w http.ResponseWriter, code int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(AllocationResponse{
+		Code:    code,
+		Status:  "error",
+		Message: err.Error(),
+	})
+}
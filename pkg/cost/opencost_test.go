@@ -0,0 +1,35 @@
+package cost
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseWindowDuration(t *testing.T) {
+	tests := []struct {
+		window  string
+		want    time.Duration
+		wantErr bool
+	}{
+		{window: "7d", want: 7 * 24 * time.Hour},
+		{window: "90m", want: 90 * time.Minute},
+		{window: "106751d", want: 106751 * 24 * time.Hour},
+		{window: "106752d", wantErr: true}, // past the largest Duration
+		{window: "200000d", wantErr: true},
+		{window: "0d", wantErr: true},
+		{window: "-1d", wantErr: true},
+		{window: "-5m", wantErr: true},
+		{window: "d", wantErr: true},
+		{window: "week", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseWindowDuration(tt.window)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseWindowDuration(%q) error = %v, wantErr %v", tt.window, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseWindowDuration(%q) = %v, want %v", tt.window, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package cost
+
+import (
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/snapshot"
+)
+
+// SidecarCost is how much of a namespace's requests go to sidecars rather than to the
+// applications they run next to
+type SidecarCost struct {
+	Namespace     string   `json:"namespace"`
+	Sidecars      []string `json:"sidecars"`      // container names
+	CPURequest    float64  `json:"cpuRequest"`    // cores
+	MemoryRequest float64  `json:"memoryRequest"` // GB
+	CPUShare      float64  `json:"cpuShare"`      // of the namespace's CPU requests, 0-1
+	MemoryShare   float64  `json:"memoryShare"`   // of the namespace's memory requests, 0-1
+	CostPerHour   float64  `json:"costPerHour"`
+	CostShare     float64  `json:"costShare"` // of the namespace's request cost, 0-1
+}
+
+// SidecarCostsFromSnapshot prices the requests of the sidecars in running pods at their
+// node's rates and compares them with each namespace's total. Namespaces without sidecars
+// are left out; the rest are ordered by sidecar cost.
+func SidecarCostsFromSnapshot(snap *snapshot.ClusterSnapshot, pricing map[string]ResourcePricing) []SidecarCost {
+	type totals struct {
+		overhead         SidecarCost
+		names            map[string]bool
+		cpu, mem, dollar float64 // every container's requests and their cost
+	}
+	nodes := make(map[string]*v1.Node, len(snap.Nodes))
+	for i := range snap.Nodes {
+		nodes[snap.Nodes[i].Name] = &snap.Nodes[i]
+	}
+	namespaces := make(map[string]*totals)
+	for i := range snap.Pods {
+		pod := &snap.Pods[i]
+		node, ok := nodes[pod.Spec.NodeName]
+		if pod.Status.Phase != v1.PodRunning || !ok {
+			continue
+		}
+		resourcePricing := PricingFor(node, pricing)
+
+		t, ok := namespaces[pod.Namespace]
+		if !ok {
+			t = &totals{overhead: SidecarCost{Namespace: pod.Namespace}, names: make(map[string]bool)}
+			namespaces[pod.Namespace] = t
+		}
+		for _, c := range snapshot.Containers(pod) {
+			cpu := c.Resources.Requests.Cpu().AsApproximateFloat64()
+			mem := float64(c.Resources.Requests.Memory().Value()) / (1024 * 1024 * 1024)
+			dollar := cpu*resourcePricing.CPU + mem*resourcePricing.Memory
+			t.cpu, t.mem, t.dollar = t.cpu+cpu, t.mem+mem, t.dollar+dollar
+			if snapshot.Sidecar(pod, c.Name) {
+				t.names[c.Name] = true
+				t.overhead.CPURequest += cpu
+				t.overhead.MemoryRequest += mem
+				t.overhead.CostPerHour += dollar
+			}
+		}
+	}
+
+	var result []SidecarCost
+	for _, t := range namespaces {
+		if len(t.names) == 0 {
+			continue
+		}
+		o := t.overhead
+		for name := range t.names {
+			o.Sidecars = append(o.Sidecars, name)
+		}
+		sort.Strings(o.Sidecars)
+		if t.cpu > 0 {
+			o.CPUShare = o.CPURequest / t.cpu
+		}
+		if t.mem > 0 {
+			o.MemoryShare = o.MemoryRequest / t.mem
+		}
+		if t.dollar > 0 {
+			o.CostShare = o.CostPerHour / t.dollar
+		}
+		result = append(result, o)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].CostPerHour != result[j].CostPerHour {
+			return result[i].CostPerHour > result[j].CostPerHour
+		}
+		return result[i].Namespace < result[j].Namespace
+	})
+	return result
+}
@@ -0,0 +1,234 @@
+package cost
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/promql"
+	"github.com/ochestra-tech/ochestra-ai/pkg/retry"
+	"github.com/ochestra-tech/ochestra-ai/pkg/snapshot"
+)
+
+// CrossZoneCostPerGB is the price of a GB crossing zones. AWS charges $0.01/GB on each
+// side; the pricing data's crossZonePerGB overrides it.
+var CrossZoneCostPerGB = 0.02
+
+// CrossZoneMinShare is the estimated share of a service's traffic crossing zones below
+// which it is not reported
+var CrossZoneMinShare = 0.1
+
+// Topology-aware routing settings of a service
+const (
+	topologyModeAnnotation  = "service.kubernetes.io/topology-mode"
+	topologyHintsAnnotation = "service.kubernetes.io/topology-aware-hints"
+)
+
+// backendTrafficQuery is each pod's received and transmitted bytes per second
+const backendTrafficQuery = `sum by (namespace, pod) (rate(container_network_receive_bytes_total[1h])) + sum by (namespace, pod) (rate(container_network_transmit_bytes_total[1h]))`
+
+// TransferReport estimates what traffic between zones costs. Clients of a service are taken
+// to be spread across zones like the cluster's pods, and spread their requests evenly
+// across the service's ready endpoints.
+type TransferReport struct {
+	Zones        []string          `json:"zones"`
+	CostPerGB    float64           `json:"costPerGB"`
+	TrafficKnown bool              `json:"trafficKnown"` // backend traffic was read from Prometheus
+	CostPerMonth float64           `json:"costPerMonth"`
+	Services     []ServiceTransfer `json:"services,omitempty"`
+}
+
+// ServiceTransfer is a service whose clients and backends straddle zones
+type ServiceTransfer struct {
+	Namespace      string         `json:"namespace"`
+	Name           string         `json:"name"`
+	BackendZones   map[string]int `json:"backendZones"`   // ready endpoints per zone
+	CrossZoneShare float64        `json:"crossZoneShare"` // estimated fraction of requests crossing zones
+	GBPerMonth     float64        `json:"gbPerMonth,omitempty"`
+	CostPerMonth   float64        `json:"costPerMonth,omitempty"`
+	Routing        string         `json:"routing,omitempty"` // topology-aware routing setting, if any
+	Recommendation string         `json:"recommendation"`
+}
+
+// CrossZoneTransfer finds services whose endpoints, read from their EndpointSlices, leave
+// clients in some zones to call backends in others. With a Prometheus URL, each service's
+// monthly cost is estimated from its backend pods' network traffic.
+func CrossZoneTransfer(ctx context.Context, clientset kubernetes.Interface, snap *snapshot.ClusterSnapshot, prometheusURL string) (*TransferReport, error) {
+	report := &TransferReport{CostPerGB: CrossZoneCostPerGB}
+	nodeZones := make(map[string]string, len(snap.Nodes))
+	for _, node := range snap.Nodes {
+		if zone := node.Labels[v1.LabelTopologyZone]; zone != "" {
+			nodeZones[node.Name] = zone
+		}
+	}
+	// Client share of each zone
+	clients := make(map[string]float64)
+	var total float64
+	for i := range snap.Pods {
+		if zone, ok := nodeZones[snap.Pods[i].Spec.NodeName]; ok && snap.Pods[i].Status.Phase == v1.PodRunning {
+			clients[zone]++
+			total++
+		}
+	}
+	for zone := range clients {
+		clients[zone] /= total
+		report.Zones = append(report.Zones, zone)
+	}
+	sort.Strings(report.Zones)
+	if len(report.Zones) < 2 {
+		return report, nil
+	}
+
+	slices, err := retry.Value(ctx, retry.DefaultBackoff, func() (*discoveryv1.EndpointSliceList, error) {
+		return clientset.DiscoveryV1().EndpointSlices("").List(ctx, metav1.ListOptions{})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list endpoint slices: %w", err)
+	}
+	byService := make(map[string][]*discoveryv1.EndpointSlice)
+	for i := range slices.Items {
+		slice := &slices.Items[i]
+		if name := slice.Labels[discoveryv1.LabelServiceName]; name != "" {
+			key := slice.Namespace + "/" + name
+			byService[key] = append(byService[key], slice)
+		}
+	}
+
+	backends := make(map[string][]string) // service -> "<namespace>/<pod>" of its ready endpoints
+	services := make(map[string]int)      // pod -> services it backs
+	for _, svc := range snap.Services {
+		if svc.Spec.Type == v1.ServiceTypeExternalName || svc.Spec.ClusterIP == v1.ClusterIPNone {
+			continue // headless and external names are resolved by DNS, not routed by kube-proxy
+		}
+		key := svc.Namespace + "/" + svc.Name
+		zones := make(map[string]int)
+		hinted := false
+		for _, slice := range byService[key] {
+			for _, endpoint := range slice.Endpoints {
+				if endpoint.Conditions.Ready != nil && !*endpoint.Conditions.Ready {
+					continue
+				}
+				zone := nodeZones[stringValue(endpoint.NodeName)]
+				if endpoint.Zone != nil {
+					zone = *endpoint.Zone
+				}
+				if zone == "" {
+					continue
+				}
+				zones[zone]++
+				if endpoint.Hints != nil && len(endpoint.Hints.ForZones) > 0 {
+					hinted = true
+				}
+				if ref := endpoint.TargetRef; ref != nil && ref.Kind == "Pod" {
+					pod := svc.Namespace + "/" + ref.Name
+					backends[key] = append(backends[key], pod)
+					services[pod]++
+				}
+			}
+		}
+		routing := topologyRouting(&svc)
+		if len(zones) == 0 || (routing != "" && hinted) {
+			continue
+		}
+
+		var endpoints int
+		for _, n := range zones {
+			endpoints += n
+		}
+		share := 1.0
+		for zone, n := range zones {
+			share -= clients[zone] * float64(n) / float64(endpoints)
+		}
+		if share < CrossZoneMinShare {
+			continue
+		}
+		report.Services = append(report.Services, ServiceTransfer{
+			Namespace:      svc.Namespace,
+			Name:           svc.Name,
+			BackendZones:   zones,
+			CrossZoneShare: share,
+			Routing:        routing,
+			Recommendation: transferRecommendation(report.Zones, zones, routing),
+		})
+	}
+
+	if prometheusURL != "" {
+		samples, err := promql.Query(ctx, prometheusURL, backendTrafficQuery)
+		if err != nil {
+			sortTransfers(report.Services)
+			return report, fmt.Errorf("failed to read backend traffic: %w", err)
+		}
+		traffic := make(map[string]float64, len(samples))
+		for _, s := range samples {
+			traffic[s.Labels["namespace"]+"/"+s.Labels["pod"]] = s.Value
+		}
+		report.TrafficKnown = true
+		for i := range report.Services {
+			st := &report.Services[i]
+			var bytesPerSecond float64
+			for _, pod := range backends[st.Namespace+"/"+st.Name] {
+				bytesPerSecond += traffic[pod] / float64(services[pod])
+			}
+			st.GBPerMonth = bytesPerSecond * 30 * 24 * 3600 / (1024 * 1024 * 1024)
+			st.CostPerMonth = st.GBPerMonth * st.CrossZoneShare * CrossZoneCostPerGB
+			report.CostPerMonth += st.CostPerMonth
+		}
+	}
+	sortTransfers(report.Services)
+	return report, nil
+}
+
+// topologyRouting returns how a service asks for topology-aware routing, or "" if it does not
+func topologyRouting(svc *v1.Service) string {
+	if svc.Spec.TrafficDistribution != nil {
+		return "trafficDistribution: " + *svc.Spec.TrafficDistribution
+	}
+	if mode := svc.Annotations[topologyModeAnnotation]; mode != "" && mode != "Disabled" {
+		return topologyModeAnnotation + ": " + mode
+	}
+	if hints := svc.Annotations[topologyHintsAnnotation]; hints != "" && hints != "disabled" && hints != "Disabled" {
+		return topologyHintsAnnotation + ": " + hints
+	}
+	return ""
+}
+
+// transferRecommendation suggests topology-aware routing when every zone has a backend, and
+// spreading the backends first when some do not
+func transferRecommendation(clusterZones []string, backendZones map[string]int, routing string) string {
+	var missing []string
+	for _, zone := range clusterZones {
+		if backendZones[zone] == 0 {
+			missing = append(missing, zone)
+		}
+	}
+	switch {
+	case len(missing) > 0:
+		return fmt.Sprintf("Spread the backends across zones with a topologySpreadConstraint; zones %v have none, so topology-aware routing would not apply", missing)
+	case routing != "":
+		return "Topology-aware routing is requested but the EndpointSlices carry no hints; balance the backends across zones in proportion to their nodes' CPU"
+	}
+	return fmt.Sprintf("Set trafficDistribution: PreferClose, or the %s: Auto annotation before Kubernetes 1.31, to keep traffic in the client's zone", topologyModeAnnotation)
+}
+
+// sortTransfers orders services by estimated cost, then by cross-zone share
+func sortTransfers(services []ServiceTransfer) {
+	sort.Slice(services, func(i, j int) bool {
+		if services[i].CostPerMonth != services[j].CostPerMonth {
+			return services[i].CostPerMonth > services[j].CostPerMonth
+		}
+		return services[i].CrossZoneShare > services[j].CrossZoneShare
+	})
+}
+
+// stringValue dereferences an optional string
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
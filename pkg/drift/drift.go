@@ -0,0 +1,468 @@
+package drift
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/health"
+	"github.com/ochestra-tech/ochestra-ai/pkg/retry"
+	"github.com/ochestra-tech/ochestra-ai/pkg/snapshot"
+)
+
+// Resources inventoried, used as the first part of inventory keys
+const (
+	NodePool                       = "NodePool"
+	MutatingWebhookConfiguration   = "MutatingWebhookConfiguration"
+	ValidatingWebhookConfiguration = "ValidatingWebhookConfiguration"
+	ClusterRole                    = "ClusterRole"
+	ClusterRoleBinding             = "ClusterRoleBinding"
+	Role                           = "Role"
+	RoleBinding                    = "RoleBinding"
+	StorageClass                   = "StorageClass"
+	CustomResourceDefinition       = "CustomResourceDefinition"
+)
+
+// Kinds of change
+const (
+	Added   = "added"
+	Removed = "removed"
+	Changed = "changed"
+)
+
+// Retention is how long a change stays in the report after it was detected, so changes
+// between two checks are not missed by whoever reads only some reports
+var Retention = 24 * time.Hour
+
+// PoolLabels name a node's pool, in order of preference. Nodes without any are pooled by
+// instance type.
+var PoolLabels = []string{
+	"eks.amazonaws.com/nodegroup",
+	"cloud.google.com/gke-nodepool",
+	"kubernetes.azure.com/agentpool",
+	"karpenter.sh/nodepool",
+	"node.kubernetes.io/instance-type",
+}
+
+// Inventory maps "<resource>/[<namespace>/]<name>" to a description of the object's
+// configuration. Large configurations are described by a hash.
+type Inventory map[string]string
+
+// Change is a difference between two inventories
+type Change struct {
+	Resource   string    `json:"resource"`
+	Namespace  string    `json:"namespace,omitempty"`
+	Name       string    `json:"name"`
+	Change     string    `json:"change"`
+	Before     string    `json:"before,omitempty"`
+	After      string    `json:"after,omitempty"`
+	DetectedAt time.Time `json:"detectedAt"`
+}
+
+// Collect inventories the configuration drift is detected on. Resources that cannot be
+// listed are left out, so they are not reported as removed.
+func Collect(ctx context.Context, clientset kubernetes.Interface, snap *snapshot.ClusterSnapshot) (Inventory, []string, error) {
+	inv := make(Inventory)
+	var skipped []string
+	var errs []string
+	list := func(resource string, fn func() error) {
+		if err := retry.Do(ctx, retry.DefaultBackoff, fn); err != nil {
+			skipped = append(skipped, resource)
+			errs = append(errs, fmt.Sprintf("%s: %v", resource, err))
+		}
+	}
+
+	if snap.Errors["nodes"] == nil {
+		collectNodePools(snap.Nodes, inv)
+	} else {
+		skipped = append(skipped, NodePool)
+	}
+
+	list(MutatingWebhookConfiguration, func() error {
+		l, err := clientset.AdmissionregistrationV1().MutatingWebhookConfigurations().List(ctx, metav1.ListOptions{})
+		if err == nil {
+			for _, c := range l.Items {
+				for i := range c.Webhooks {
+					c.Webhooks[i].ClientConfig.CABundle = nil // rotated by cert managers
+				}
+				inv[MutatingWebhookConfiguration+"/"+c.Name] = hash(c.Webhooks)
+			}
+		}
+		return err
+	})
+	list(ValidatingWebhookConfiguration, func() error {
+		l, err := clientset.AdmissionregistrationV1().ValidatingWebhookConfigurations().List(ctx, metav1.ListOptions{})
+		if err == nil {
+			for _, c := range l.Items {
+				for i := range c.Webhooks {
+					c.Webhooks[i].ClientConfig.CABundle = nil
+				}
+				inv[ValidatingWebhookConfiguration+"/"+c.Name] = hash(c.Webhooks)
+			}
+		}
+		return err
+	})
+	list(ClusterRole, func() error {
+		l, err := clientset.RbacV1().ClusterRoles().List(ctx, metav1.ListOptions{})
+		if err == nil {
+			for _, r := range l.Items {
+				if !bootstrapped(r.ObjectMeta) {
+					inv[ClusterRole+"/"+r.Name] = hash(r.Rules)
+				}
+			}
+		}
+		return err
+	})
+	list(ClusterRoleBinding, func() error {
+		l, err := clientset.RbacV1().ClusterRoleBindings().List(ctx, metav1.ListOptions{})
+		if err == nil {
+			for _, b := range l.Items {
+				if !bootstrapped(b.ObjectMeta) {
+					inv[ClusterRoleBinding+"/"+b.Name] = hash([]interface{}{b.RoleRef, b.Subjects})
+				}
+			}
+		}
+		return err
+	})
+	list(Role, func() error {
+		l, err := clientset.RbacV1().Roles("").List(ctx, metav1.ListOptions{})
+		if err == nil {
+			for _, r := range l.Items {
+				if !bootstrapped(r.ObjectMeta) {
+					inv[Role+"/"+r.Namespace+"/"+r.Name] = hash(r.Rules)
+				}
+			}
+		}
+		return err
+	})
+	list(RoleBinding, func() error {
+		l, err := clientset.RbacV1().RoleBindings("").List(ctx, metav1.ListOptions{})
+		if err == nil {
+			for _, b := range l.Items {
+				if !bootstrapped(b.ObjectMeta) {
+					inv[RoleBinding+"/"+b.Namespace+"/"+b.Name] = hash([]interface{}{b.RoleRef, b.Subjects})
+				}
+			}
+		}
+		return err
+	})
+	list(StorageClass, func() error {
+		l, err := clientset.StorageV1().StorageClasses().List(ctx, metav1.ListOptions{})
+		if err == nil {
+			for _, sc := range l.Items {
+				desc := "provisioner=" + sc.Provisioner
+				if sc.ReclaimPolicy != nil {
+					desc += " reclaim=" + string(*sc.ReclaimPolicy)
+				}
+				if sc.VolumeBindingMode != nil {
+					desc += " binding=" + string(*sc.VolumeBindingMode)
+				}
+				if sc.AllowVolumeExpansion != nil && *sc.AllowVolumeExpansion {
+					desc += " expansion=true"
+				}
+				if sc.Annotations["storageclass.kubernetes.io/is-default-class"] == "true" {
+					desc += " default=true"
+				}
+				if len(sc.Parameters) > 0 {
+					desc += " parameters=" + hash(sc.Parameters)
+				}
+				inv[StorageClass+"/"+sc.Name] = desc
+			}
+		}
+		return err
+	})
+	list(CustomResourceDefinition, func() error {
+		// Ask for JSON, since the clientset may prefer protobuf, which CRDs are also served as
+		data, err := clientset.CoreV1().RESTClient().Get().AbsPath("/apis/apiextensions.k8s.io/v1/customresourcedefinitions").
+			SetHeader("Accept", "application/json").DoRaw(ctx)
+		if err != nil {
+			return err
+		}
+		var crds struct {
+			Items []struct {
+				Metadata struct {
+					Name string `json:"name"`
+				} `json:"metadata"`
+				Spec struct {
+					Scope    string `json:"scope"`
+					Versions []struct {
+						Name    string          `json:"name"`
+						Served  bool            `json:"served"`
+						Storage bool            `json:"storage"`
+						Schema  json.RawMessage `json:"schema"`
+					} `json:"versions"`
+				} `json:"spec"`
+			} `json:"items"`
+		}
+		if err := json.Unmarshal(data, &crds); err != nil {
+			return err
+		}
+		for _, crd := range crds.Items {
+			var versions, schemas []string
+			for _, v := range crd.Spec.Versions {
+				if !v.Served {
+					continue
+				}
+				name := v.Name
+				if v.Storage {
+					name += "*" // the storage version
+				}
+				versions = append(versions, name)
+				schemas = append(schemas, string(v.Schema))
+			}
+			inv[CustomResourceDefinition+"/"+crd.Metadata.Name] = fmt.Sprintf("scope=%s versions=%s schema=%s",
+				crd.Spec.Scope, strings.Join(versions, ","), hash(schemas))
+		}
+		return nil
+	})
+
+	if len(errs) > 0 {
+		return inv, skipped, fmt.Errorf("failed to inventory %s", strings.Join(errs, "; "))
+	}
+	return inv, skipped, nil
+}
+
+// collectNodePools describes each node pool by its instance types, kubelet versions, OS
+// images and taints. Node counts are left out since autoscaling changes them.
+func collectNodePools(nodes []v1.Node, inv Inventory) {
+	type pool struct{ instanceTypes, versions, images, taints map[string]bool }
+	pools := make(map[string]*pool)
+	for _, node := range nodes {
+		name := ""
+		for _, label := range PoolLabels {
+			if name = node.Labels[label]; name != "" {
+				break
+			}
+		}
+		if name == "" {
+			name = "unlabeled"
+		}
+		p, ok := pools[name]
+		if !ok {
+			p = &pool{make(map[string]bool), make(map[string]bool), make(map[string]bool), make(map[string]bool)}
+			pools[name] = p
+		}
+		p.instanceTypes[node.Labels["node.kubernetes.io/instance-type"]] = true
+		p.versions[node.Status.NodeInfo.KubeletVersion] = true
+		p.images[node.Status.NodeInfo.OSImage] = true
+		for _, t := range node.Spec.Taints {
+			if t.Key != v1.TaintNodeUnschedulable && !strings.HasPrefix(t.Key, "node.kubernetes.io/") {
+				p.taints[t.Key+"="+t.Value+":"+string(t.Effect)] = true
+			}
+		}
+	}
+	for name, p := range pools {
+		inv[NodePool+"/"+name] = fmt.Sprintf("instanceTypes=%s kubelet=%s os=%s taints=%s",
+			joinSet(p.instanceTypes), joinSet(p.versions), joinSet(p.images), joinSet(p.taints))
+	}
+}
+
+// bootstrapped reports whether an RBAC object is one of the apiserver's defaults, which
+// change only with Kubernetes upgrades
+func bootstrapped(meta metav1.ObjectMeta) bool {
+	return meta.Labels["kubernetes.io/bootstrapping"] == "rbac-defaults"
+}
+
+// hash returns a short hash of a value's JSON encoding
+func hash(v interface{}) string {
+	data, _ := json.Marshal(v)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:6])
+}
+
+// joinSet renders a set in order
+func joinSet(set map[string]bool) string {
+	values := make([]string, 0, len(set))
+	for v := range set {
+		if v != "" {
+			values = append(values, v)
+		}
+	}
+	sort.Strings(values)
+	return strings.Join(values, ",")
+}
+
+// Diff compares two inventories. Resources in skipped could not be listed now and are not
+// compared.
+func Diff(previous, current Inventory, skipped []string, now time.Time) []Change {
+	var changes []Change
+	for key, after := range current {
+		before, ok := previous[key]
+		switch {
+		case !ok:
+			changes = append(changes, newChange(key, Added, "", after, now))
+		case before != after:
+			changes = append(changes, newChange(key, Changed, before, after, now))
+		}
+	}
+	for key, before := range previous {
+		if _, ok := current[key]; ok {
+			continue
+		}
+		resource, _, _ := strings.Cut(key, "/")
+		if !slices.Contains(skipped, resource) {
+			changes = append(changes, newChange(key, Removed, before, "", now))
+		}
+	}
+	sortChanges(changes)
+	return changes
+}
+
+// newChange splits an inventory key into the changed object's resource, namespace and name
+func newChange(key, change, before, after string, now time.Time) Change {
+	c := Change{Change: change, Before: before, After: after, DetectedAt: now}
+	parts := strings.SplitN(key, "/", 3)
+	c.Resource, c.Name = parts[0], parts[len(parts)-1]
+	if len(parts) == 3 {
+		c.Namespace = parts[1]
+	}
+	return c
+}
+
+// sortChanges orders changes newest first, then by object
+func sortChanges(changes []Change) {
+	sort.Slice(changes, func(i, j int) bool {
+		a, b := changes[i], changes[j]
+		if !a.DetectedAt.Equal(b.DetectedAt) {
+			return a.DetectedAt.After(b.DetectedAt)
+		}
+		if a.Resource != b.Resource {
+			return a.Resource < b.Resource
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+}
+
+// Issues turns changes into health issues. Admission webhook and RBAC changes can alter
+// what workloads and users may do, so they are warnings; the rest are informational.
+func Issues(changes []Change) []health.HealthIssue {
+	issues := make([]health.HealthIssue, 0, len(changes))
+	for _, c := range changes {
+		severity := "info"
+		switch c.Resource {
+		case MutatingWebhookConfiguration, ValidatingWebhookConfiguration, ClusterRole, ClusterRoleBinding, Role, RoleBinding:
+			severity = "warning"
+		}
+		message := fmt.Sprintf("%s was %s", c.Resource, c.Change)
+		if c.Change == Changed && !isHash(c.Before) {
+			message += fmt.Sprintf(": %s -> %s", c.Before, c.After)
+		}
+		issue := health.HealthIssue{
+			Type:       health.IssueConfigDrift,
+			Severity:   severity,
+			Resource:   c.Resource,
+			Namespace:  c.Namespace,
+			Name:       c.Name,
+			Message:    message,
+			Timestamp:  c.DetectedAt,
+			Suggestion: "Confirm the change was intended; if it was made by hand, apply it to the cluster's source of truth or revert it",
+		}
+		health.Suggest(&issue)
+		issues = append(issues, issue)
+	}
+	return issues
+}
+
+// isHash reports whether an inventory value is a bare hash rather than a description
+func isHash(value string) bool {
+	return len(value) == 12 && !strings.ContainsAny(value, "= ")
+}
+
+// Tracker keeps the inventory of the previous check and the changes still within
+// Retention, persisting both if it has a state file
+type Tracker struct {
+	path string
+
+	mu    sync.Mutex
+	state struct {
+		Inventory Inventory `json:"inventory"`
+		Changes   []Change  `json:"changes,omitempty"`
+	}
+}
+
+// LoadTracker creates a tracker, restoring the state persisted at path if it is not empty
+func LoadTracker(path string) (*Tracker, error) {
+	t := &Tracker{path: path}
+	if path == "" {
+		return t, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return t, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read drift state: %w", err)
+	}
+	if err := json.Unmarshal(data, &t.state); err != nil {
+		return nil, fmt.Errorf("failed to parse drift state: %w", err)
+	}
+	return t, nil
+}
+
+// Check inventories the cluster, records the changes since the previous check and returns
+// every change still within Retention. The first check only records a baseline.
+func (t *Tracker) Check(ctx context.Context, clientset kubernetes.Interface, snap *snapshot.ClusterSnapshot, now time.Time) ([]Change, error) {
+	current, skipped, collectErr := Collect(ctx, clientset, snap)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.state.Inventory != nil {
+		t.state.Changes = append(t.state.Changes, Diff(t.state.Inventory, current, skipped, now)...)
+	}
+	// Keep the previous description of resources that could not be listed
+	for key, value := range t.state.Inventory {
+		resource, _, _ := strings.Cut(key, "/")
+		if _, ok := current[key]; !ok && slices.Contains(skipped, resource) {
+			current[key] = value
+		}
+	}
+	t.state.Inventory = current
+
+	kept := t.state.Changes[:0]
+	for _, c := range t.state.Changes {
+		if now.Sub(c.DetectedAt) <= Retention {
+			kept = append(kept, c)
+		}
+	}
+	t.state.Changes = kept
+	sortChanges(t.state.Changes)
+
+	changes := append([]Change(nil), t.state.Changes...)
+	if err := t.save(); err != nil {
+		return changes, err
+	}
+	return changes, collectErr
+}
+
+// save persists the state to disk if the tracker has a state file
+func (t *Tracker) save() error {
+	if t.path == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(t.state)
+	if err != nil {
+		return fmt.Errorf("failed to marshal drift state: %w", err)
+	}
+	if err := os.WriteFile(t.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write drift state: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,269 @@
+package external
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/health"
+)
+
+// DefaultWindow is how long before and after a check an external alert must have fired
+// to be correlated with the issues found by it
+const DefaultWindow = 15 * time.Minute
+
+// maxBody bounds a posted batch of alerts
+const maxBody = 4 << 20
+
+// expireAfter drops firing alerts that have not been posted again for this long, in case
+// their sender never resolves them
+const expireAfter = 24 * time.Hour
+
+// Label names read from Alertmanager alerts to place them in the cluster
+var (
+	namespaceLabels = []string{"namespace", "kubernetes_namespace", "exported_namespace"}
+	nodeLabels      = []string{"node", "kubernetes_node", "nodename", "instance"}
+)
+
+// Alert is an alert raised by a system outside the monitor, such as Alertmanager or a
+// cloud provider's monitoring
+type Alert struct {
+	Source      string            `json:"source"` // e.g. "alertmanager", "cloudwatch"
+	Name        string            `json:"name"`
+	Severity    string            `json:"severity,omitempty"`
+	Summary     string            `json:"summary,omitempty"`
+	Namespace   string            `json:"namespace,omitempty"`
+	Node        string            `json:"node,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	StartsAt    time.Time         `json:"startsAt"`
+	EndsAt      time.Time         `json:"endsAt"` // zero while firing
+	Fingerprint string            `json:"fingerprint,omitempty"`
+
+	receivedAt time.Time
+}
+
+// key identifies an alert across repeated posts
+func (a Alert) key() string {
+	if a.Fingerprint != "" {
+		return a.Source + "/" + a.Fingerprint
+	}
+	labels := make([]string, 0, len(a.Labels))
+	for k, v := range a.Labels {
+		labels = append(labels, k+"="+v)
+	}
+	sort.Strings(labels)
+	return fmt.Sprintf("%s/%s/%s/%s/%s", a.Source, a.Name, a.Namespace, a.Node, strings.Join(labels, ","))
+}
+
+// Correlation is an external alert with the Kubernetes issues found in the same place
+// around the same time
+type Correlation struct {
+	Alert  Alert                `json:"alert"`
+	Issues []health.HealthIssue `json:"issues,omitempty"`
+	Reason string               `json:"reason,omitempty"` // e.g. "same node", "same namespace"
+}
+
+// Receiver accepts external alerts and correlates them with the monitor's issues:
+//
+//	POST /alerts/external                  Alertmanager webhook payloads, or {"alerts": [<Alert>...]}
+//	GET  /alerts/external                  alerts active in the window, with their correlated issues
+type Receiver struct {
+	window time.Duration
+
+	mu     sync.Mutex
+	alerts map[string]Alert
+	latest []Correlation
+}
+
+// NewReceiver creates a receiver correlating alerts active within window of a check
+func NewReceiver(window time.Duration) *Receiver {
+	return &Receiver{window: window, alerts: make(map[string]Alert)}
+}
+
+// alertmanagerPayload is the body Alertmanager posts to webhook receivers
+type alertmanagerPayload struct {
+	Version string `json:"version"`
+	Alerts  []struct {
+		Status      string            `json:"status"`
+		Labels      map[string]string `json:"labels"`
+		Annotations map[string]string `json:"annotations"`
+		StartsAt    time.Time         `json:"startsAt"`
+		EndsAt      time.Time         `json:"endsAt"`
+		Fingerprint string            `json:"fingerprint"`
+	} `json:"alerts"`
+}
+
+// ServeHTTP receives posted alerts and lists the latest correlations
+func (r *Receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	switch req.Method {
+	case http.MethodGet:
+		r.mu.Lock()
+		body := r.latest
+		r.mu.Unlock()
+		if body == nil {
+			body = []Correlation{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(body)
+
+	case http.MethodPost:
+		data, err := io.ReadAll(io.LimitReader(req.Body, maxBody))
+		if err != nil {
+			http.Error(w, "failed to read body", http.StatusBadRequest)
+			return
+		}
+		alerts, err := parse(data)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		r.Receive(alerts, time.Now())
+		w.WriteHeader(http.StatusAccepted)
+
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// parse reads an Alertmanager webhook payload, or a list of alerts in this package's format
+func parse(data []byte) ([]Alert, error) {
+	var am alertmanagerPayload
+	if err := json.Unmarshal(data, &am); err != nil {
+		return nil, fmt.Errorf("invalid alerts: %w", err)
+	}
+	if am.Version != "" {
+		alerts := make([]Alert, 0, len(am.Alerts))
+		for _, a := range am.Alerts {
+			alert := Alert{
+				Source:      "alertmanager",
+				Name:        a.Labels["alertname"],
+				Severity:    a.Labels["severity"],
+				Summary:     a.Annotations["summary"],
+				Namespace:   firstLabel(a.Labels, namespaceLabels),
+				Node:        firstLabel(a.Labels, nodeLabels),
+				Labels:      a.Labels,
+				StartsAt:    a.StartsAt,
+				Fingerprint: a.Fingerprint,
+			}
+			if alert.Summary == "" {
+				alert.Summary = a.Annotations["description"]
+			}
+			if a.Status == "resolved" {
+				alert.EndsAt = a.EndsAt
+			}
+			alerts = append(alerts, alert)
+		}
+		return alerts, nil
+	}
+
+	var generic struct {
+		Alerts []Alert `json:"alerts"`
+	}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return nil, fmt.Errorf("invalid alerts: %w", err)
+	}
+	for i, a := range generic.Alerts {
+		if a.Source == "" || a.Name == "" {
+			return nil, fmt.Errorf("alert %d needs a source and a name", i)
+		}
+	}
+	return generic.Alerts, nil
+}
+
+// firstLabel returns the first of the labels that is set. Host and port values, as in
+// Prometheus "instance" labels, are reduced to the host.
+func firstLabel(labels map[string]string, names []string) string {
+	for _, name := range names {
+		if value := labels[name]; value != "" {
+			if host, _, err := net.SplitHostPort(value); err == nil {
+				return host
+			}
+			return value
+		}
+	}
+	return ""
+}
+
+// Receive records alerts, replacing earlier posts of the same alerts
+func (r *Receiver) Receive(alerts []Alert, now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, a := range alerts {
+		if a.StartsAt.IsZero() {
+			a.StartsAt = now
+		}
+		a.receivedAt = now
+		r.alerts[a.key()] = a
+	}
+}
+
+// Correlate matches the alerts active within the window of now with the issues on the
+// same node, or else in the same namespace, and keeps the result for the API. Alerts that
+// ended before the window are forgotten.
+func (r *Receiver) Correlate(issues []health.HealthIssue, now time.Time) []Correlation {
+	var flat []health.HealthIssue
+	var flatten func([]health.HealthIssue)
+	flatten = func(issues []health.HealthIssue) {
+		for _, issue := range issues {
+			flatten(issue.Children)
+			issue.Children = nil
+			flat = append(flat, issue)
+		}
+	}
+	flatten(issues)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	from, to := now.Add(-r.window), now.Add(r.window)
+	result := make([]Correlation, 0)
+	for key, a := range r.alerts {
+		ended := !a.EndsAt.IsZero() && a.EndsAt.Before(from)
+		if ended || (a.EndsAt.IsZero() && now.Sub(a.receivedAt) > expireAfter) {
+			delete(r.alerts, key)
+			continue
+		}
+		if a.StartsAt.After(to) {
+			continue
+		}
+
+		c := Correlation{Alert: a}
+		if a.Node != "" {
+			for _, issue := range flat {
+				if issue.Node == a.Node || (issue.Resource == "Node" && issue.Name == a.Node) {
+					c.Issues = append(c.Issues, issue)
+				}
+			}
+			if len(c.Issues) > 0 {
+				c.Reason = "same node"
+			}
+		}
+		if len(c.Issues) == 0 && a.Namespace != "" {
+			for _, issue := range flat {
+				if issue.Namespace == a.Namespace {
+					c.Issues = append(c.Issues, issue)
+				}
+			}
+			if len(c.Issues) > 0 {
+				c.Reason = "same namespace"
+			}
+		}
+		result = append(result, c)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if len(result[i].Issues) != len(result[j].Issues) {
+			return len(result[i].Issues) > len(result[j].Issues)
+		}
+		return result[i].Alert.StartsAt.Before(result[j].Alert.StartsAt)
+	})
+
+	r.latest = result
+	return result
+}
@@ -0,0 +1,132 @@
+package features
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Feature gates, set with --feature-gates, e.g. "optimizer=false,cleanup=false". They switch
+// whole subsystems off however the rest of the configuration enables them, so a deployment
+// can disable one from a single value.
+const (
+	Optimizer   = "optimizer"
+	Cleanup     = "cleanup"
+	Remediation = "remediation"
+	Dashboard   = "dashboard"
+)
+
+// Gate is a feature gate and its state
+type Gate struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Default     bool   `json:"default"`
+	Enabled     bool   `json:"enabled"`
+}
+
+// defaults registers every gate with its default state
+var defaults = []Gate{
+	{Name: Optimizer, Description: "Recommendations, the savings ledger and recommendation export", Default: true},
+	{Name: Cleanup, Description: "Deleting, or previewing deleting, what --cleanup-policy selects", Default: true},
+	{Name: Remediation, Description: "Plugin remediations and stale finalizer removal", Default: true},
+	{Name: Dashboard, Description: "The allocation, what-if, trends, compare, showback and fleet endpoints", Default: true},
+}
+
+var (
+	mu    sync.RWMutex
+	gates = reset()
+)
+
+// reset returns every gate in its default state
+func reset() map[string]bool {
+	result := make(map[string]bool, len(defaults))
+	for _, g := range defaults {
+		result[g.Name] = g.Default
+	}
+	return result
+}
+
+// Configure sets gates from comma-separated name=bool pairs, leaving the others at their
+// defaults
+func Configure(spec string) error {
+	result := reset()
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return fmt.Errorf("invalid feature gate %q, want name=true or name=false", pair)
+		}
+		name = strings.TrimSpace(name)
+		if _, known := result[name]; !known {
+			return fmt.Errorf("unknown feature gate %q", name)
+		}
+		enabled, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("invalid value for feature gate %s: %w", name, err)
+		}
+		result[name] = enabled
+	}
+
+	mu.Lock()
+	gates = result
+	mu.Unlock()
+	return nil
+}
+
+// Enabled reports whether a gate is on; unknown gates are off
+func Enabled(name string) bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return gates[name]
+}
+
+// All returns every gate with its state, ordered by name
+func All() []Gate {
+	mu.RLock()
+	defer mu.RUnlock()
+	result := make([]Gate, 0, len(defaults))
+	for _, g := range defaults {
+		g.Enabled = gates[g.Name]
+		result = append(result, g)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// Handler serves next while a gate is on, and 404 Not Found while it is off
+func Handler(name string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !Enabled(name) {
+			http.Error(w, fmt.Sprintf("feature %s is disabled", name), http.StatusNotFound)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Status is the monitor's answer at /status
+type Status struct {
+	Cluster   string    `json:"cluster"`
+	StartedAt time.Time `json:"startedAt"`
+	Features  []Gate    `json:"features"`
+}
+
+// StatusHandler reports the monitor's cluster and which features are active
+type StatusHandler struct {
+	Cluster   string
+	StartedAt time.Time
+}
+
+// ServeHTTP handles /status
+func (h StatusHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(Status{Cluster: h.Cluster, StartedAt: h.StartedAt, Features: All()})
+}
@@ -0,0 +1,263 @@
+package fleet
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/notify"
+)
+
+// DefaultStaleAfter is how long an agent that registered no check interval may stay silent
+const DefaultStaleAfter = 15 * time.Minute
+
+// maxRegistrationBytes bounds a registration body
+const maxRegistrationBytes = 64 << 10
+
+// Agent is a cluster registered with the aggregator
+type Agent struct {
+	Registration
+	Identity     string         `json:"identity,omitempty"` // client certificate it registered with
+	RegisteredAt time.Time      `json:"registeredAt"`
+	LastSeen     time.Time      `json:"lastSeen"` // latest registration or batch
+	Stale        bool           `json:"stale"`
+	Streams      []StreamStatus `json:"streams"`
+}
+
+// Aggregator is the central service per-cluster agents push their reports to. Agents
+// register before pushing; behind mutual TLS, an agent may only register and push as the
+// cluster its client certificate names. A cluster that has not been heard from for three of
+// its check intervals, or the configured staleAfter, turns stale.
+type Aggregator struct {
+	receiver   *Receiver
+	staleAfter time.Duration
+	notifier   notify.Notifier
+
+	mu     sync.Mutex
+	agents map[string]*Agent
+}
+
+// NewAggregator creates an aggregator; staleAfter 0 derives each agent's limit from its interval
+func NewAggregator(staleAfter time.Duration, notifier notify.Notifier) *Aggregator {
+	return &Aggregator{
+		receiver:   NewReceiver(),
+		staleAfter: staleAfter,
+		notifier:   notifier,
+		agents:     make(map[string]*Agent),
+	}
+}
+
+// ServeHTTP handles POST /fleet/register and POST /fleet/ingest
+func (a *Aggregator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/register"):
+		a.serveRegister(w, r)
+	case strings.HasSuffix(r.URL.Path, "/ingest"):
+		a.serveIngest(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// serveRegister records an agent's registration
+func (a *Aggregator) serveRegister(w http.ResponseWriter, r *http.Request) {
+	identity, verified := clientIdentity(r)
+	if verified && identity == "" {
+		http.Error(w, "client certificate names no cluster", http.StatusForbidden)
+		return
+	}
+	var registration Registration
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRegistrationBytes)).Decode(&registration); err != nil {
+		http.Error(w, fmt.Sprintf("invalid registration: %v", err), http.StatusBadRequest)
+		return
+	}
+	if registration.Cluster == "" {
+		http.Error(w, "a cluster name is required", http.StatusBadRequest)
+		return
+	}
+	if verified && identity != registration.Cluster {
+		http.Error(w, fmt.Sprintf("certificate of %q cannot register cluster %q", identity, registration.Cluster), http.StatusForbidden)
+		return
+	}
+
+	now := time.Now()
+	a.mu.Lock()
+	agent, ok := a.agents[registration.Cluster]
+	if !ok {
+		agent = &Agent{RegisteredAt: now}
+		a.agents[registration.Cluster] = agent
+	}
+	agent.Registration, agent.Identity, agent.LastSeen = registration, identity, now
+	a.mu.Unlock()
+	if !ok {
+		log.Printf("Fleet agent %s registered", registration.Cluster)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(registration)
+}
+
+// serveIngest applies a batch from a registered agent. Batches from clusters the aggregator
+// does not know, e.g. after it restarted, are answered with 428 Precondition Required so
+// the agent registers again.
+func (a *Aggregator) serveIngest(w http.ResponseWriter, r *http.Request) {
+	identity, verified := clientIdentity(r)
+	if verified && identity == "" {
+		http.Error(w, "client certificate names no cluster", http.StatusForbidden)
+		return
+	}
+	batch, err := decodeBatch(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	now := time.Now()
+	a.mu.Lock()
+	for _, env := range batch {
+		if verified && env.Cluster != identity {
+			a.mu.Unlock()
+			http.Error(w, fmt.Sprintf("certificate of %q cannot push reports of cluster %q", identity, env.Cluster), http.StatusForbidden)
+			return
+		}
+		if _, ok := a.agents[env.Cluster]; !ok {
+			a.mu.Unlock()
+			http.Error(w, fmt.Sprintf("cluster %q is not registered", env.Cluster), http.StatusPreconditionRequired)
+			return
+		}
+	}
+	for _, env := range batch {
+		a.agents[env.Cluster].LastSeen = now
+	}
+	a.mu.Unlock()
+
+	resp, status := a.receiver.ingest(batch, now)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// Check marks agents not heard from within their limit as stale and alerts when an agent
+// turns stale or reports again. It returns every agent.
+func (a *Aggregator) Check(ctx context.Context, now time.Time) []Agent {
+	var alerts []notify.Alert
+	a.mu.Lock()
+	for _, agent := range a.agents {
+		limit := a.limit(agent)
+		stale := now.Sub(agent.LastSeen) > limit
+		if stale == agent.Stale {
+			continue
+		}
+		agent.Stale = stale
+		alert := notify.Alert{
+			Title:       fmt.Sprintf("Cluster %s stopped reporting", agent.Cluster),
+			Message:     fmt.Sprintf("No reports or registration from the agent for %s, since %s", now.Sub(agent.LastSeen).Round(time.Second), agent.LastSeen.Format(time.RFC3339)),
+			Severity:    "warning",
+			Source:      "fleet",
+			Labels:      map[string]string{"cluster": agent.Cluster},
+			Timestamp:   now,
+			Fingerprint: "fleet-stale/" + agent.Cluster,
+		}
+		if !stale {
+			alert.Title = fmt.Sprintf("Cluster %s reports again", agent.Cluster)
+			alert.Message = fmt.Sprintf("Heard from the agent at %s", agent.LastSeen.Format(time.RFC3339))
+			alert.Severity = "info"
+			alert.Resolved = true
+		}
+		alerts = append(alerts, alert)
+	}
+	a.mu.Unlock()
+
+	if a.notifier != nil {
+		for _, alert := range alerts {
+			if err := a.notifier.Notify(ctx, alert); err != nil {
+				log.Printf("Failed to send fleet alert: %v", err)
+			}
+		}
+	}
+	return a.Agents()
+}
+
+// limit is how long an agent may stay silent
+func (a *Aggregator) limit(agent *Agent) time.Duration {
+	if a.staleAfter > 0 {
+		return a.staleAfter
+	}
+	if agent.Interval > 0 {
+		return 3 * agent.Interval
+	}
+	return DefaultStaleAfter
+}
+
+// Agents returns the registered agents with their reports, ordered by cluster
+func (a *Aggregator) Agents() []Agent {
+	streams := a.receiver.Streams()
+	a.mu.Lock()
+	result := make([]Agent, 0, len(a.agents))
+	for _, agent := range a.agents {
+		result = append(result, *agent)
+	}
+	a.mu.Unlock()
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Cluster < result[j].Cluster })
+	for i := range result {
+		result[i].Streams = make([]StreamStatus, 0)
+		for _, s := range streams {
+			if s.Cluster == result[i].Cluster {
+				result[i].Streams = append(result[i].Streams, s)
+			}
+		}
+	}
+	return result
+}
+
+// clientIdentity names the cluster a verified client certificate was issued to, from its
+// common name or else its first DNS name. verified is false without mutual TLS; with it, an
+// empty identity is a certificate that names no cluster, which may not register or push.
+func clientIdentity(r *http.Request) (identity string, verified bool) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return "", false
+	}
+	cert := r.TLS.VerifiedChains[0][0]
+	if cert.Subject.CommonName != "" {
+		return cert.Subject.CommonName, true
+	}
+	if len(cert.DNSNames) > 0 {
+		return cert.DNSNames[0], true
+	}
+	return "", true
+}
+
+// ServerTLS configures the aggregator's listener to require client certificates signed by
+// the client CA. The serving certificate is read on every handshake so rotations apply
+// without a restart.
+func ServerTLS(certFile, keyFile, clientCAFile string) (*tls.Config, error) {
+	if certFile == "" || keyFile == "" || clientCAFile == "" {
+		return nil, fmt.Errorf("mutual TLS needs a certificate, a key and a client CA")
+	}
+	if _, err := tls.LoadX509KeyPair(certFile, keyFile); err != nil {
+		return nil, fmt.Errorf("failed to load aggregator certificate: %w", err)
+	}
+	pool, err := loadCAs(clientCAFile)
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  pool,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+			return &cert, err
+		},
+	}, nil
+}
@@ -0,0 +1,147 @@
+package fleet
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Config points an edge cluster's monitor at the central sink it reports to
+type Config struct {
+	Endpoint       string `json:"endpoint"`                 // URL reports are POSTed to, e.g. https://central:8080/fleet/ingest
+	Token          string `json:"token,omitempty"`          // sent as a bearer token
+	CertFile       string `json:"certFile,omitempty"`       // client certificate for aggregators requiring mutual TLS
+	KeyFile        string `json:"keyFile,omitempty"`        // its private key
+	CAFile         string `json:"caFile,omitempty"`         // CA the aggregator's certificate is checked against, instead of the system roots
+	BufferDir      string `json:"bufferDir"`                // where reports wait while the sink is unreachable
+	MaxBufferBytes int64  `json:"maxBufferBytes,omitempty"` // per kind of report; the oldest are dropped beyond it, default 256MiB
+	FullEvery      int    `json:"fullEvery,omitempty"`      // deltas between full reports, default 24
+	BatchBytes     int64  `json:"batchBytes,omitempty"`     // compressed reports sent per request, default 1MiB
+	Timeout        string `json:"timeout,omitempty"`        // per request, default "30s"
+
+	timeout time.Duration
+	tls     *tls.Config
+}
+
+// LoadConfig reads edge settings from a JSON file
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fleet config: %w", err)
+	}
+
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse fleet config: %w", err)
+	}
+	if err := config.init(); err != nil {
+		return nil, fmt.Errorf("fleet config: %w", err)
+	}
+	return &config, nil
+}
+
+// init validates the config and fills in defaults
+func (c *Config) init() error {
+	if c.Endpoint == "" {
+		return fmt.Errorf("an endpoint is required")
+	}
+	if c.BufferDir == "" {
+		return fmt.Errorf("a buffer directory is required")
+	}
+	if c.MaxBufferBytes <= 0 {
+		c.MaxBufferBytes = 256 << 20
+	}
+	if c.FullEvery <= 0 {
+		c.FullEvery = 24
+	}
+	if c.BatchBytes <= 0 {
+		c.BatchBytes = 1 << 20
+	}
+	if c.Timeout == "" {
+		c.Timeout = "30s"
+	}
+	timeout, err := time.ParseDuration(c.Timeout)
+	if err != nil || timeout <= 0 {
+		return fmt.Errorf("invalid timeout %q", c.Timeout)
+	}
+	c.timeout = timeout
+
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		return fmt.Errorf("a client certificate needs both certFile and keyFile")
+	}
+	if c.CertFile == "" && c.CAFile == "" {
+		return nil
+	}
+	c.tls = &tls.Config{MinVersion: tls.VersionTLS12}
+	if c.CertFile != "" {
+		if _, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile); err != nil {
+			return fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		// Read the certificate on every handshake so rotated certificates are picked up
+		certFile, keyFile := c.CertFile, c.KeyFile
+		c.tls.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+			return &cert, err
+		}
+	}
+	if c.CAFile != "" {
+		pool, err := loadCAs(c.CAFile)
+		if err != nil {
+			return err
+		}
+		c.tls.RootCAs = pool
+	}
+	return nil
+}
+
+// loadCAs reads a PEM bundle of CA certificates
+func loadCAs(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificates in CA bundle %s", path)
+	}
+	return pool, nil
+}
+
+// Registration introduces a cluster's agent to the aggregator before it pushes reports
+type Registration struct {
+	Cluster  string        `json:"cluster"`
+	Version  string        `json:"version,omitempty"` // Kubernetes version of the cluster
+	Interval time.Duration `json:"interval"`          // between the agent's checks, which sets when it turns stale
+}
+
+// KindCost is the kind of the cost reports agents push; health and optimization reports
+// use the archive's kinds
+const KindCost = "cost"
+
+// Envelope carries one report of a cluster, either in full or as a JSON merge patch
+// (RFC 7386) against the report before it. Reports of a kind are numbered from one, so a
+// patch applies only where the sink holds report Base.
+type Envelope struct {
+	Cluster  string          `json:"cluster"`
+	Kind     string          `json:"kind"` // e.g. "health" or "optimization"
+	Seq      uint64          `json:"seq"`
+	Base     uint64          `json:"base,omitempty"` // report the patch applies to
+	Time     time.Time       `json:"time"`
+	Document json.RawMessage `json:"document,omitempty"`
+	Patch    json.RawMessage `json:"patch,omitempty"`
+}
+
+// Full reports whether the envelope holds a whole report
+func (e *Envelope) Full() bool {
+	return e.Patch == nil
+}
+
+// IngestResponse is the sink's answer to a batch: how many envelopes it applied in order,
+// and its latest report of the kind when it stopped at a patch whose base it lacks
+type IngestResponse struct {
+	Applied int    `json:"applied"`
+	Seq     uint64 `json:"seq,omitempty"`
+}
@@ -0,0 +1,483 @@
+package fleet
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	jsonpatch "gopkg.in/evanphx/json-patch.v4"
+)
+
+// baseFile holds the last report of a kind the sink acknowledged, the document the oldest
+// buffered delta applies to
+const baseFile = "base.json.gz"
+
+// Pusher sends an edge cluster's reports to the central sink. Every report is written to a
+// buffer directory first and removed once the sink acknowledges it, so reports taken while
+// the link is down survive restarts and are replayed in order on reconnect. Between full
+// reports only what changed since the previous report is sent. The pusher registers the
+// cluster before its first batch, and again whenever the sink no longer knows it.
+type Pusher struct {
+	config       *Config
+	registration Registration
+	client       *http.Client
+
+	mu         sync.Mutex
+	streams    map[string]*stream
+	registered bool
+}
+
+// stream is the buffer of one kind of report
+type stream struct {
+	dir     string
+	base    []byte // document of report baseSeq; nil before the first acknowledgement
+	baseSeq uint64
+	tail    []byte // document of the latest report
+	seq     uint64
+	deltas  int // since the latest full report
+	pending []entry
+	size    int64 // of the pending files
+}
+
+// entry is a buffered report
+type entry struct {
+	path string
+	size int64
+}
+
+// NewPusher creates a pusher for the registered cluster, picking up reports buffered before
+// a restart
+func NewPusher(config *Config, registration Registration) (*Pusher, error) {
+	p := &Pusher{
+		config:       config,
+		registration: registration,
+		client:       &http.Client{Timeout: config.timeout},
+		streams:      make(map[string]*stream),
+	}
+	if config.tls != nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = config.tls
+		p.client.Transport = transport
+	}
+	entries, err := os.ReadDir(config.BufferDir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read fleet buffer: %w", err)
+	}
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		s, err := loadStream(filepath.Join(config.BufferDir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		p.streams[e.Name()] = s
+	}
+	return p, nil
+}
+
+// Buffered returns how many reports wait for the sink
+func (p *Pusher) Buffered() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	n := 0
+	for _, s := range p.streams {
+		n += len(s.pending)
+	}
+	return n
+}
+
+// Push buffers a report of a kind and sends everything buffered to the sink. When the sink
+// cannot be reached the report stays buffered for the next push, and the error says so.
+func (p *Pusher) Push(ctx context.Context, kind string, report interface{}, t time.Time) error {
+	doc, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s report: %w", kind, err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	s, ok := p.streams[kind]
+	if !ok {
+		s = &stream{dir: filepath.Join(p.config.BufferDir, kind)}
+		if err := os.MkdirAll(s.dir, 0o755); err != nil {
+			return fmt.Errorf("failed to create fleet buffer: %w", err)
+		}
+		p.streams[kind] = s
+	}
+
+	env := Envelope{Cluster: p.registration.Cluster, Kind: kind, Seq: s.seq + 1, Time: t.UTC()}
+	if s.tail != nil && s.deltas < p.config.FullEvery {
+		// A delta larger than the report it describes saves nothing
+		if patch, err := jsonpatch.CreateMergePatch(s.tail, doc); err == nil && len(patch) < len(doc) {
+			env.Base, env.Patch = s.seq, patch
+		}
+	}
+	if env.Patch == nil {
+		env.Document = doc
+	}
+	path := filepath.Join(s.dir, fmt.Sprintf("%020d.json.gz", env.Seq))
+	size, err := writeEnvelope(path, &env)
+	if err != nil {
+		return err
+	}
+	s.seq, s.tail = env.Seq, doc
+	if env.Full() {
+		s.deltas = 0
+	} else {
+		s.deltas++
+	}
+	s.pending = append(s.pending, entry{path: path, size: size})
+	s.size += size
+	if err := s.trim(p.config.MaxBufferBytes); err != nil {
+		return err
+	}
+
+	if err := p.flush(ctx, s); err != nil {
+		return fmt.Errorf("%w; %d %s reports buffered", err, len(s.pending), kind)
+	}
+	return nil
+}
+
+// flush sends a stream's buffered reports in batches until the buffer is empty
+func (p *Pusher) flush(ctx context.Context, s *stream) error {
+	reregistered := false
+	for len(s.pending) > 0 {
+		if !p.registered {
+			if err := p.register(ctx); err != nil {
+				return err
+			}
+		}
+		batch := make([]Envelope, 0)
+		var size int64
+		for _, e := range s.pending {
+			if len(batch) > 0 && size+e.size > p.config.BatchBytes {
+				break
+			}
+			env, err := readEnvelope(e.path)
+			if err != nil {
+				return err
+			}
+			batch = append(batch, env)
+			size += e.size
+		}
+
+		resp, status, err := p.send(ctx, batch)
+		if err != nil {
+			return err
+		}
+		if status == http.StatusPreconditionRequired {
+			// The sink lost the registration, e.g. in a restart
+			if reregistered {
+				return fmt.Errorf("fleet sink does not accept the registration of cluster %s", p.registration.Cluster)
+			}
+			p.registered, reregistered = false, true
+			continue
+		}
+		if resp.Applied < 0 || resp.Applied > len(batch) {
+			return fmt.Errorf("fleet sink acknowledged %d of %d reports", resp.Applied, len(batch))
+		}
+		if err := s.acknowledge(batch[:resp.Applied]); err != nil {
+			return err
+		}
+		if status == http.StatusOK {
+			if resp.Applied == 0 {
+				return fmt.Errorf("fleet sink accepted none of %d reports", len(batch))
+			}
+			continue
+		}
+
+		// The sink lacks the report the next delta applies to, e.g. after losing its state,
+		// so send that report in full
+		if resp.Applied == len(batch) || batch[resp.Applied].Full() {
+			return fmt.Errorf("fleet sink rejected a full report")
+		}
+		if err := s.expandHead(batch[resp.Applied]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// send posts a batch of reports as a gzip-compressed JSON array. Besides 200 OK, the sink
+// answers 409 Conflict when it stopped at a delta it could not apply, and 428 Precondition
+// Required when it does not know the cluster.
+func (p *Pusher) send(ctx context.Context, batch []Envelope) (IngestResponse, int, error) {
+	var resp IngestResponse
+	var body bytes.Buffer
+	gz := gzip.NewWriter(&body)
+	if err := json.NewEncoder(gz).Encode(batch); err != nil {
+		return resp, 0, fmt.Errorf("failed to encode reports: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return resp, 0, fmt.Errorf("failed to compress reports: %w", err)
+	}
+
+	httpResp, err := p.post(ctx, p.config.Endpoint, &body, "gzip")
+	if err != nil {
+		return resp, 0, err
+	}
+	defer httpResp.Body.Close()
+	switch httpResp.StatusCode {
+	case http.StatusOK, http.StatusConflict:
+	case http.StatusPreconditionRequired:
+		return resp, httpResp.StatusCode, nil
+	default:
+		return resp, 0, responseError(httpResp)
+	}
+	if err := json.NewDecoder(httpResp.Body).Decode(&resp); err != nil {
+		return resp, 0, fmt.Errorf("failed to parse fleet sink response: %w", err)
+	}
+	return resp, httpResp.StatusCode, nil
+}
+
+// register introduces the cluster at the register endpoint next to the ingest endpoint
+func (p *Pusher) register(ctx context.Context) error {
+	endpoint, err := url.Parse(p.config.Endpoint)
+	if err != nil {
+		return fmt.Errorf("invalid fleet endpoint: %w", err)
+	}
+	endpoint.Path = path.Join(path.Dir(endpoint.Path), "register")
+	data, err := json.Marshal(p.registration)
+	if err != nil {
+		return fmt.Errorf("failed to encode registration: %w", err)
+	}
+
+	resp, err := p.post(ctx, endpoint.String(), bytes.NewReader(data), "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("fleet registration failed: %w", responseError(resp))
+	}
+	p.registered = true
+	return nil
+}
+
+// post sends a JSON body to the sink with the configured credentials
+func (p *Pusher) post(ctx context.Context, endpoint string, body io.Reader, encoding string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create fleet request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if encoding != "" {
+		req.Header.Set("Content-Encoding", encoding)
+	}
+	if p.config.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+p.config.Token)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fleet sink unreachable: %w", err)
+	}
+	return resp, nil
+}
+
+// responseError describes an unexpected answer from the sink
+func responseError(resp *http.Response) error {
+	message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("fleet sink returned HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+}
+
+// acknowledge moves the base past reports the sink applied and removes them from the buffer.
+// The base is saved first, so a restart in between finds them already covered by it.
+func (s *stream) acknowledge(applied []Envelope) error {
+	if len(applied) == 0 {
+		return nil
+	}
+	for i := range applied {
+		if err := s.advance(&applied[i]); err != nil {
+			return err
+		}
+	}
+	if err := s.saveBase(); err != nil {
+		return err
+	}
+	for range applied {
+		s.drop()
+	}
+	return nil
+}
+
+// trim drops the oldest buffered reports while the buffer is over its limit, always keeping
+// the latest. A delta left at the head is expanded into a full report, since the sink never
+// sees the reports it applies to.
+func (s *stream) trim(limit int64) error {
+	if s.size <= limit || len(s.pending) < 2 {
+		return nil
+	}
+	for s.size > limit && len(s.pending) > 1 {
+		env, err := readEnvelope(s.pending[0].path)
+		if err != nil {
+			return err
+		}
+		if err := s.advance(&env); err != nil {
+			return err
+		}
+		s.drop()
+	}
+	if err := s.saveBase(); err != nil {
+		return err
+	}
+	head, err := readEnvelope(s.pending[0].path)
+	if err != nil {
+		return err
+	}
+	if head.Full() {
+		return nil
+	}
+	return s.expandHead(head)
+}
+
+// advance makes a report the stream's base
+func (s *stream) advance(env *Envelope) error {
+	doc := []byte(env.Document)
+	if !env.Full() {
+		if s.base == nil || env.Base != s.baseSeq {
+			return fmt.Errorf("fleet buffer for %s is missing report %d", env.Kind, env.Base)
+		}
+		var err error
+		if doc, err = jsonpatch.MergePatch(s.base, env.Patch); err != nil {
+			return fmt.Errorf("failed to apply buffered %s report %d: %w", env.Kind, env.Seq, err)
+		}
+	}
+	s.base, s.baseSeq = doc, env.Seq
+	return nil
+}
+
+// expandHead rewrites the oldest buffered report, a delta against the base, as a full report
+func (s *stream) expandHead(head Envelope) error {
+	if s.base == nil || head.Base != s.baseSeq {
+		return fmt.Errorf("fleet buffer for %s is missing report %d", head.Kind, head.Base)
+	}
+	doc, err := jsonpatch.MergePatch(s.base, head.Patch)
+	if err != nil {
+		return fmt.Errorf("failed to apply buffered %s report %d: %w", head.Kind, head.Seq, err)
+	}
+	head.Base, head.Patch, head.Document = 0, nil, doc
+	size, err := writeEnvelope(s.pending[0].path, &head)
+	if err != nil {
+		return err
+	}
+	s.size += size - s.pending[0].size
+	s.pending[0].size = size
+	return nil
+}
+
+// drop removes the oldest buffered report
+func (s *stream) drop() {
+	e := s.pending[0]
+	os.Remove(e.path)
+	s.size -= e.size
+	s.pending = s.pending[1:]
+}
+
+// saveBase persists the base as a full report
+func (s *stream) saveBase() error {
+	_, err := writeEnvelope(filepath.Join(s.dir, baseFile), &Envelope{Seq: s.baseSeq, Document: s.base})
+	return err
+}
+
+// loadStream reads a kind's buffer back: the base, then the buffered reports in order,
+// replaying them to recover the latest document deltas continue from
+func loadStream(dir string) (*stream, error) {
+	s := &stream{dir: dir}
+	if base, err := readEnvelope(filepath.Join(dir, baseFile)); err == nil {
+		s.base, s.baseSeq, s.seq = base.Document, base.Seq, base.Seq
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json.gz"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list fleet buffer: %w", err)
+	}
+	sort.Strings(files) // zero-padded sequence numbers sort in order
+	s.tail = s.base
+	for _, path := range files {
+		if filepath.Base(path) == baseFile {
+			continue
+		}
+		env, err := readEnvelope(path)
+		if err != nil {
+			return nil, err
+		}
+		if s.base != nil && env.Seq <= s.baseSeq {
+			os.Remove(path) // acknowledged before a restart
+			continue
+		}
+		doc := []byte(env.Document)
+		if !env.Full() {
+			if s.tail == nil || env.Base != s.seq {
+				return nil, fmt.Errorf("fleet buffer %s is missing report %d", dir, env.Base)
+			}
+			if doc, err = jsonpatch.MergePatch(s.tail, env.Patch); err != nil {
+				return nil, fmt.Errorf("failed to replay buffered report %s: %w", path, err)
+			}
+			s.deltas++
+		} else {
+			s.deltas = 0
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read fleet buffer: %w", err)
+		}
+		s.tail, s.seq = doc, env.Seq
+		s.pending = append(s.pending, entry{path: path, size: info.Size()})
+		s.size += info.Size()
+	}
+	return s, nil
+}
+
+// writeEnvelope saves a report gzip-compressed, through a rename so a crash never leaves
+// half of one, and returns its size on disk
+func writeEnvelope(path string, env *Envelope) (int64, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(gz).Encode(env); err != nil {
+		return 0, fmt.Errorf("failed to encode buffered report: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return 0, fmt.Errorf("failed to compress buffered report: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o644); err != nil {
+		return 0, fmt.Errorf("failed to buffer report: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return 0, fmt.Errorf("failed to buffer report: %w", err)
+	}
+	return int64(buf.Len()), nil
+}
+
+// readEnvelope loads a buffered report
+func readEnvelope(path string) (Envelope, error) {
+	var env Envelope
+	f, err := os.Open(path)
+	if err != nil {
+		return env, err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return env, fmt.Errorf("failed to read buffered report %s: %w", path, err)
+	}
+	if err := json.NewDecoder(gz).Decode(&env); err != nil {
+		return env, fmt.Errorf("failed to read buffered report %s: %w", path, err)
+	}
+	return env, nil
+}
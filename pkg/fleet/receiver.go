@@ -0,0 +1,162 @@
+package fleet
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	jsonpatch "gopkg.in/evanphx/json-patch.v4"
+)
+
+// maxIngestBytes bounds a decompressed batch
+const maxIngestBytes = 256 << 20
+
+// Receiver keeps the latest report of each kind per cluster pushed to the aggregator,
+// rebuilt from deltas as they arrive
+type Receiver struct {
+	mu      sync.Mutex
+	streams map[string]*received // by cluster/kind
+}
+
+// received is the latest report of a cluster and kind
+type received struct {
+	status   StreamStatus
+	document []byte
+}
+
+// StreamStatus describes the latest report of a cluster and kind
+type StreamStatus struct {
+	Cluster  string    `json:"cluster"`
+	Kind     string    `json:"kind"`
+	Seq      uint64    `json:"seq"`
+	Time     time.Time `json:"time"`     // when the edge took the report
+	Received time.Time `json:"received"` // when it arrived, later than Time for replayed reports
+	Deltas   int       `json:"deltas"`   // received since the latest full report
+}
+
+// NewReceiver creates an empty sink
+func NewReceiver() *Receiver {
+	return &Receiver{streams: make(map[string]*received)}
+}
+
+// decodeBatch reads a batch of reports, optionally gzip-compressed
+func decodeBatch(r *http.Request) ([]Envelope, error) {
+	var body io.Reader = r.Body
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzip body: %w", err)
+		}
+		defer gz.Close()
+		body = gz
+	}
+	var batch []Envelope
+	if err := json.NewDecoder(io.LimitReader(body, maxIngestBytes)).Decode(&batch); err != nil {
+		return nil, fmt.Errorf("invalid reports: %w", err)
+	}
+	return batch, nil
+}
+
+// ingest applies a batch in order and returns the answer and its status code. A full report
+// always applies; a delta only onto the report it was taken against, and a delta already
+// applied is skipped so replays after a lost answer are harmless. The answer counts the
+// reports applied, with 409 Conflict when the sink stopped at a delta whose base it lacks.
+func (r *Receiver) ingest(batch []Envelope, now time.Time) (IngestResponse, int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var resp IngestResponse
+	for _, env := range batch {
+		if env.Cluster == "" || env.Kind == "" {
+			return resp, http.StatusBadRequest
+		}
+		key := env.Cluster + "/" + env.Kind
+		cur := r.streams[key]
+
+		if env.Full() {
+			r.streams[key] = &received{
+				status:   StreamStatus{Cluster: env.Cluster, Kind: env.Kind, Seq: env.Seq, Time: env.Time, Received: now},
+				document: env.Document,
+			}
+			resp.Applied++
+			continue
+		}
+		switch {
+		case cur != nil && env.Base == cur.status.Seq:
+			doc, err := jsonpatch.MergePatch(cur.document, env.Patch)
+			if err != nil {
+				return resp, http.StatusBadRequest
+			}
+			cur.document = doc
+			cur.status.Seq, cur.status.Time, cur.status.Received = env.Seq, env.Time, now
+			cur.status.Deltas++
+		case cur != nil && env.Seq <= cur.status.Seq:
+			// applied before the edge lost our answer
+		default:
+			if cur != nil {
+				resp.Seq = cur.status.Seq
+			}
+			return resp, http.StatusConflict
+		}
+		resp.Applied++
+	}
+	return resp, http.StatusOK
+}
+
+// Latest returns the latest report of a kind from a cluster
+func (r *Receiver) Latest(cluster, kind string) (json.RawMessage, StreamStatus, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cur, ok := r.streams[cluster+"/"+kind]
+	if !ok {
+		return nil, StreamStatus{}, false
+	}
+	return cur.document, cur.status, true
+}
+
+// Streams returns the status of every cluster and kind reported, ordered by cluster and kind
+func (r *Receiver) Streams() []StreamStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	result := make([]StreamStatus, 0, len(r.streams))
+	for _, cur := range r.streams {
+		result = append(result, cur.status)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Cluster != result[j].Cluster {
+			return result[i].Cluster < result[j].Cluster
+		}
+		return result[i].Kind < result[j].Kind
+	})
+	return result
+}
+
+// ClustersHandler serves what edge clusters pushed to an aggregator
+type ClustersHandler struct {
+	Aggregator *Aggregator
+}
+
+// ServeHTTP handles /fleet/clusters, listing every registered cluster with its staleness and
+// reports, and /fleet/clusters?cluster=store-42&kind=health with the latest report of one;
+// kind defaults to health
+func (h ClustersHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	cluster, kind := r.URL.Query().Get("cluster"), r.URL.Query().Get("kind")
+	w.Header().Set("Content-Type", "application/json")
+	if cluster == "" {
+		json.NewEncoder(w).Encode(h.Aggregator.Agents())
+		return
+	}
+	if kind == "" {
+		kind = "health"
+	}
+	doc, _, ok := h.Aggregator.receiver.Latest(cluster, kind)
+	if !ok {
+		http.Error(w, fmt.Sprintf("no %s report from cluster %q", kind, cluster), http.StatusNotFound)
+		return
+	}
+	w.Write(doc)
+}
@@ -0,0 +1,1164 @@
+// Report types and the streaming API served with --grpc-port. The Go code in
+// pkg/grpcapi/healthpb is generated from this file with `go generate ./pkg/grpcapi`.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: health.proto
+
+package healthpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type ClusterHealthRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Only return issues in this namespace; empty returns all issues
+	Namespace string `protobuf:"bytes,1,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	// Only return issues at least this severe: "critical", "warning" or "info"
+	MinSeverity string `protobuf:"bytes,2,opt,name=min_severity,json=minSeverity,proto3" json:"min_severity,omitempty"`
+	// Return at most this many issues, with next_page_token set when more remain; 0 returns
+	// all issues. Watch calls are not paged.
+	PageSize int32 `protobuf:"varint,3,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// next_page_token of the previous page; fails with ABORTED once a newer check replaced
+	// the result being paged through
+	PageToken     string `protobuf:"bytes,4,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ClusterHealthRequest) Reset() {
+	*x = ClusterHealthRequest{}
+	mi := &file_health_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClusterHealthRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClusterHealthRequest) ProtoMessage() {}
+
+func (x *ClusterHealthRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_health_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClusterHealthRequest.ProtoReflect.Descriptor instead.
+func (*ClusterHealthRequest) Descriptor() ([]byte, []int) {
+	return file_health_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ClusterHealthRequest) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+func (x *ClusterHealthRequest) GetMinSeverity() string {
+	if x != nil {
+		return x.MinSeverity
+	}
+	return ""
+}
+
+func (x *ClusterHealthRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ClusterHealthRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+type TriggerCheckRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TriggerCheckRequest) Reset() {
+	*x = TriggerCheckRequest{}
+	mi := &file_health_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TriggerCheckRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TriggerCheckRequest) ProtoMessage() {}
+
+func (x *TriggerCheckRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_health_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TriggerCheckRequest.ProtoReflect.Descriptor instead.
+func (*TriggerCheckRequest) Descriptor() ([]byte, []int) {
+	return file_health_proto_rawDescGZIP(), []int{1}
+}
+
+type TriggerCheckResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TriggerCheckResponse) Reset() {
+	*x = TriggerCheckResponse{}
+	mi := &file_health_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TriggerCheckResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TriggerCheckResponse) ProtoMessage() {}
+
+func (x *TriggerCheckResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_health_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TriggerCheckResponse.ProtoReflect.Descriptor instead.
+func (*TriggerCheckResponse) Descriptor() ([]byte, []int) {
+	return file_health_proto_rawDescGZIP(), []int{2}
+}
+
+type OptimizationReportRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Only return recommendations for this namespace; empty returns all
+	Namespace     string `protobuf:"bytes,1,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OptimizationReportRequest) Reset() {
+	*x = OptimizationReportRequest{}
+	mi := &file_health_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OptimizationReportRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OptimizationReportRequest) ProtoMessage() {}
+
+func (x *OptimizationReportRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_health_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OptimizationReportRequest.ProtoReflect.Descriptor instead.
+func (*OptimizationReportRequest) Descriptor() ([]byte, []int) {
+	return file_health_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *OptimizationReportRequest) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+type ClusterHealth struct {
+	state              protoimpl.MessageState    `protogen:"open.v1"`
+	Timestamp          *timestamppb.Timestamp    `protobuf:"bytes,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	HealthScore        int32                     `protobuf:"varint,2,opt,name=health_score,json=healthScore,proto3" json:"health_score,omitempty"`
+	Nodes              *NodeSummary              `protobuf:"bytes,3,opt,name=nodes,proto3" json:"nodes,omitempty"`
+	Pods               *PodSummary               `protobuf:"bytes,4,opt,name=pods,proto3" json:"pods,omitempty"`
+	Issues             []*HealthIssue            `protobuf:"bytes,5,rep,name=issues,proto3" json:"issues,omitempty"`
+	Sections           map[string]*SectionStatus `protobuf:"bytes,6,rep,name=sections,proto3" json:"sections,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	MaintenanceWindows []string                  `protobuf:"bytes,7,rep,name=maintenance_windows,json=maintenanceWindows,proto3" json:"maintenance_windows,omitempty"`
+	NextPageToken      string                    `protobuf:"bytes,8,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *ClusterHealth) Reset() {
+	*x = ClusterHealth{}
+	mi := &file_health_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClusterHealth) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClusterHealth) ProtoMessage() {}
+
+func (x *ClusterHealth) ProtoReflect() protoreflect.Message {
+	mi := &file_health_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClusterHealth.ProtoReflect.Descriptor instead.
+func (*ClusterHealth) Descriptor() ([]byte, []int) {
+	return file_health_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ClusterHealth) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *ClusterHealth) GetHealthScore() int32 {
+	if x != nil {
+		return x.HealthScore
+	}
+	return 0
+}
+
+func (x *ClusterHealth) GetNodes() *NodeSummary {
+	if x != nil {
+		return x.Nodes
+	}
+	return nil
+}
+
+func (x *ClusterHealth) GetPods() *PodSummary {
+	if x != nil {
+		return x.Pods
+	}
+	return nil
+}
+
+func (x *ClusterHealth) GetIssues() []*HealthIssue {
+	if x != nil {
+		return x.Issues
+	}
+	return nil
+}
+
+func (x *ClusterHealth) GetSections() map[string]*SectionStatus {
+	if x != nil {
+		return x.Sections
+	}
+	return nil
+}
+
+func (x *ClusterHealth) GetMaintenanceWindows() []string {
+	if x != nil {
+		return x.MaintenanceWindows
+	}
+	return nil
+}
+
+func (x *ClusterHealth) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+type NodeSummary struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	Total              int32                  `protobuf:"varint,1,opt,name=total,proto3" json:"total,omitempty"`
+	Ready              int32                  `protobuf:"varint,2,opt,name=ready,proto3" json:"ready,omitempty"`
+	MemoryPressure     int32                  `protobuf:"varint,3,opt,name=memory_pressure,json=memoryPressure,proto3" json:"memory_pressure,omitempty"`
+	DiskPressure       int32                  `protobuf:"varint,4,opt,name=disk_pressure,json=diskPressure,proto3" json:"disk_pressure,omitempty"`
+	PidPressure        int32                  `protobuf:"varint,5,opt,name=pid_pressure,json=pidPressure,proto3" json:"pid_pressure,omitempty"`
+	NetworkUnavailable int32                  `protobuf:"varint,6,opt,name=network_unavailable,json=networkUnavailable,proto3" json:"network_unavailable,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *NodeSummary) Reset() {
+	*x = NodeSummary{}
+	mi := &file_health_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NodeSummary) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NodeSummary) ProtoMessage() {}
+
+func (x *NodeSummary) ProtoReflect() protoreflect.Message {
+	mi := &file_health_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NodeSummary.ProtoReflect.Descriptor instead.
+func (*NodeSummary) Descriptor() ([]byte, []int) {
+	return file_health_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *NodeSummary) GetTotal() int32 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *NodeSummary) GetReady() int32 {
+	if x != nil {
+		return x.Ready
+	}
+	return 0
+}
+
+func (x *NodeSummary) GetMemoryPressure() int32 {
+	if x != nil {
+		return x.MemoryPressure
+	}
+	return 0
+}
+
+func (x *NodeSummary) GetDiskPressure() int32 {
+	if x != nil {
+		return x.DiskPressure
+	}
+	return 0
+}
+
+func (x *NodeSummary) GetPidPressure() int32 {
+	if x != nil {
+		return x.PidPressure
+	}
+	return 0
+}
+
+func (x *NodeSummary) GetNetworkUnavailable() int32 {
+	if x != nil {
+		return x.NetworkUnavailable
+	}
+	return 0
+}
+
+type PodSummary struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Total         int32                  `protobuf:"varint,1,opt,name=total,proto3" json:"total,omitempty"`
+	Running       int32                  `protobuf:"varint,2,opt,name=running,proto3" json:"running,omitempty"`
+	Pending       int32                  `protobuf:"varint,3,opt,name=pending,proto3" json:"pending,omitempty"`
+	Succeeded     int32                  `protobuf:"varint,4,opt,name=succeeded,proto3" json:"succeeded,omitempty"`
+	Failed        int32                  `protobuf:"varint,5,opt,name=failed,proto3" json:"failed,omitempty"`
+	Unknown       int32                  `protobuf:"varint,6,opt,name=unknown,proto3" json:"unknown,omitempty"`
+	Restarting    int32                  `protobuf:"varint,7,opt,name=restarting,proto3" json:"restarting,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PodSummary) Reset() {
+	*x = PodSummary{}
+	mi := &file_health_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PodSummary) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PodSummary) ProtoMessage() {}
+
+func (x *PodSummary) ProtoReflect() protoreflect.Message {
+	mi := &file_health_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PodSummary.ProtoReflect.Descriptor instead.
+func (*PodSummary) Descriptor() ([]byte, []int) {
+	return file_health_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *PodSummary) GetTotal() int32 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *PodSummary) GetRunning() int32 {
+	if x != nil {
+		return x.Running
+	}
+	return 0
+}
+
+func (x *PodSummary) GetPending() int32 {
+	if x != nil {
+		return x.Pending
+	}
+	return 0
+}
+
+func (x *PodSummary) GetSucceeded() int32 {
+	if x != nil {
+		return x.Succeeded
+	}
+	return 0
+}
+
+func (x *PodSummary) GetFailed() int32 {
+	if x != nil {
+		return x.Failed
+	}
+	return 0
+}
+
+func (x *PodSummary) GetUnknown() int32 {
+	if x != nil {
+		return x.Unknown
+	}
+	return 0
+}
+
+func (x *PodSummary) GetRestarting() int32 {
+	if x != nil {
+		return x.Restarting
+	}
+	return 0
+}
+
+type SectionStatus struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	State         string                 `protobuf:"bytes,1,opt,name=state,proto3" json:"state,omitempty"` // "complete", "partial" or "failed"
+	Error         string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SectionStatus) Reset() {
+	*x = SectionStatus{}
+	mi := &file_health_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SectionStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SectionStatus) ProtoMessage() {}
+
+func (x *SectionStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_health_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SectionStatus.ProtoReflect.Descriptor instead.
+func (*SectionStatus) Descriptor() ([]byte, []int) {
+	return file_health_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *SectionStatus) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+func (x *SectionStatus) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type HealthIssue struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	Severity      string                 `protobuf:"bytes,2,opt,name=severity,proto3" json:"severity,omitempty"`
+	Resource      string                 `protobuf:"bytes,3,opt,name=resource,proto3" json:"resource,omitempty"`
+	Namespace     string                 `protobuf:"bytes,4,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	Name          string                 `protobuf:"bytes,5,opt,name=name,proto3" json:"name,omitempty"`
+	Message       string                 `protobuf:"bytes,6,opt,name=message,proto3" json:"message,omitempty"`
+	Timestamp     *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Suggestion    string                 `protobuf:"bytes,8,opt,name=suggestion,proto3" json:"suggestion,omitempty"`
+	RunbookUrl    string                 `protobuf:"bytes,9,opt,name=runbook_url,json=runbookUrl,proto3" json:"runbook_url,omitempty"`
+	Remediation   []string               `protobuf:"bytes,10,rep,name=remediation,proto3" json:"remediation,omitempty"`
+	Suppressed    bool                   `protobuf:"varint,11,opt,name=suppressed,proto3" json:"suppressed,omitempty"`
+	Node          string                 `protobuf:"bytes,12,opt,name=node,proto3" json:"node,omitempty"`
+	Detector      string                 `protobuf:"bytes,13,opt,name=detector,proto3" json:"detector,omitempty"`
+	Children      []*HealthIssue         `protobuf:"bytes,14,rep,name=children,proto3" json:"children,omitempty"`
+	Owner         *Owner                 `protobuf:"bytes,15,opt,name=owner,proto3" json:"owner,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HealthIssue) Reset() {
+	*x = HealthIssue{}
+	mi := &file_health_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HealthIssue) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HealthIssue) ProtoMessage() {}
+
+func (x *HealthIssue) ProtoReflect() protoreflect.Message {
+	mi := &file_health_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HealthIssue.ProtoReflect.Descriptor instead.
+func (*HealthIssue) Descriptor() ([]byte, []int) {
+	return file_health_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *HealthIssue) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *HealthIssue) GetSeverity() string {
+	if x != nil {
+		return x.Severity
+	}
+	return ""
+}
+
+func (x *HealthIssue) GetResource() string {
+	if x != nil {
+		return x.Resource
+	}
+	return ""
+}
+
+func (x *HealthIssue) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+func (x *HealthIssue) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *HealthIssue) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *HealthIssue) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *HealthIssue) GetSuggestion() string {
+	if x != nil {
+		return x.Suggestion
+	}
+	return ""
+}
+
+func (x *HealthIssue) GetRunbookUrl() string {
+	if x != nil {
+		return x.RunbookUrl
+	}
+	return ""
+}
+
+func (x *HealthIssue) GetRemediation() []string {
+	if x != nil {
+		return x.Remediation
+	}
+	return nil
+}
+
+func (x *HealthIssue) GetSuppressed() bool {
+	if x != nil {
+		return x.Suppressed
+	}
+	return false
+}
+
+func (x *HealthIssue) GetNode() string {
+	if x != nil {
+		return x.Node
+	}
+	return ""
+}
+
+func (x *HealthIssue) GetDetector() string {
+	if x != nil {
+		return x.Detector
+	}
+	return ""
+}
+
+func (x *HealthIssue) GetChildren() []*HealthIssue {
+	if x != nil {
+		return x.Children
+	}
+	return nil
+}
+
+func (x *HealthIssue) GetOwner() *Owner {
+	if x != nil {
+		return x.Owner
+	}
+	return nil
+}
+
+// Owner is the top-level controller of an issue's or recommendation's resource and the
+// team responsible for it
+type Owner struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Kind          string                 `protobuf:"bytes,1,opt,name=kind,proto3" json:"kind,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Team          string                 `protobuf:"bytes,3,opt,name=team,proto3" json:"team,omitempty"`
+	Slack         string                 `protobuf:"bytes,4,opt,name=slack,proto3" json:"slack,omitempty"`
+	Email         string                 `protobuf:"bytes,5,opt,name=email,proto3" json:"email,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Owner) Reset() {
+	*x = Owner{}
+	mi := &file_health_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Owner) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Owner) ProtoMessage() {}
+
+func (x *Owner) ProtoReflect() protoreflect.Message {
+	mi := &file_health_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Owner.ProtoReflect.Descriptor instead.
+func (*Owner) Descriptor() ([]byte, []int) {
+	return file_health_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *Owner) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *Owner) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Owner) GetTeam() string {
+	if x != nil {
+		return x.Team
+	}
+	return ""
+}
+
+func (x *Owner) GetSlack() string {
+	if x != nil {
+		return x.Slack
+	}
+	return ""
+}
+
+func (x *Owner) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+type OptimizationReport struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	GeneratedAt      *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=generated_at,json=generatedAt,proto3" json:"generated_at,omitempty"`
+	PotentialSavings float64                `protobuf:"fixed64,2,opt,name=potential_savings,json=potentialSavings,proto3" json:"potential_savings,omitempty"`
+	Recommendations  []*Recommendation      `protobuf:"bytes,3,rep,name=recommendations,proto3" json:"recommendations,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *OptimizationReport) Reset() {
+	*x = OptimizationReport{}
+	mi := &file_health_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OptimizationReport) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OptimizationReport) ProtoMessage() {}
+
+func (x *OptimizationReport) ProtoReflect() protoreflect.Message {
+	mi := &file_health_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OptimizationReport.ProtoReflect.Descriptor instead.
+func (*OptimizationReport) Descriptor() ([]byte, []int) {
+	return file_health_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *OptimizationReport) GetGeneratedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.GeneratedAt
+	}
+	return nil
+}
+
+func (x *OptimizationReport) GetPotentialSavings() float64 {
+	if x != nil {
+		return x.PotentialSavings
+	}
+	return 0
+}
+
+func (x *OptimizationReport) GetRecommendations() []*Recommendation {
+	if x != nil {
+		return x.Recommendations
+	}
+	return nil
+}
+
+type Recommendation struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	Type               string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	Description        string                 `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	PotentialSaving    float64                `protobuf:"fixed64,3,opt,name=potential_saving,json=potentialSaving,proto3" json:"potential_saving,omitempty"`
+	Namespace          string                 `protobuf:"bytes,4,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	WorkloadKind       string                 `protobuf:"bytes,5,opt,name=workload_kind,json=workloadKind,proto3" json:"workload_kind,omitempty"`
+	WorkloadName       string                 `protobuf:"bytes,6,opt,name=workload_name,json=workloadName,proto3" json:"workload_name,omitempty"`
+	ContainerName      string                 `protobuf:"bytes,7,opt,name=container_name,json=containerName,proto3" json:"container_name,omitempty"`
+	ResourceType       string                 `protobuf:"bytes,8,opt,name=resource_type,json=resourceType,proto3" json:"resource_type,omitempty"`        // "cpu" or "memory"
+	CurrentRequest     int64                  `protobuf:"varint,9,opt,name=current_request,json=currentRequest,proto3" json:"current_request,omitempty"` // millicores or bytes
+	RecommendedRequest int64                  `protobuf:"varint,10,opt,name=recommended_request,json=recommendedRequest,proto3" json:"recommended_request,omitempty"`
+	Usage              int64                  `protobuf:"varint,11,opt,name=usage,proto3" json:"usage,omitempty"`
+	Replicas           int32                  `protobuf:"varint,12,opt,name=replicas,proto3" json:"replicas,omitempty"`
+	Owner              *Owner                 `protobuf:"bytes,13,opt,name=owner,proto3" json:"owner,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *Recommendation) Reset() {
+	*x = Recommendation{}
+	mi := &file_health_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Recommendation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Recommendation) ProtoMessage() {}
+
+func (x *Recommendation) ProtoReflect() protoreflect.Message {
+	mi := &file_health_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Recommendation.ProtoReflect.Descriptor instead.
+func (*Recommendation) Descriptor() ([]byte, []int) {
+	return file_health_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *Recommendation) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Recommendation) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Recommendation) GetPotentialSaving() float64 {
+	if x != nil {
+		return x.PotentialSaving
+	}
+	return 0
+}
+
+func (x *Recommendation) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+func (x *Recommendation) GetWorkloadKind() string {
+	if x != nil {
+		return x.WorkloadKind
+	}
+	return ""
+}
+
+func (x *Recommendation) GetWorkloadName() string {
+	if x != nil {
+		return x.WorkloadName
+	}
+	return ""
+}
+
+func (x *Recommendation) GetContainerName() string {
+	if x != nil {
+		return x.ContainerName
+	}
+	return ""
+}
+
+func (x *Recommendation) GetResourceType() string {
+	if x != nil {
+		return x.ResourceType
+	}
+	return ""
+}
+
+func (x *Recommendation) GetCurrentRequest() int64 {
+	if x != nil {
+		return x.CurrentRequest
+	}
+	return 0
+}
+
+func (x *Recommendation) GetRecommendedRequest() int64 {
+	if x != nil {
+		return x.RecommendedRequest
+	}
+	return 0
+}
+
+func (x *Recommendation) GetUsage() int64 {
+	if x != nil {
+		return x.Usage
+	}
+	return 0
+}
+
+func (x *Recommendation) GetReplicas() int32 {
+	if x != nil {
+		return x.Replicas
+	}
+	return 0
+}
+
+func (x *Recommendation) GetOwner() *Owner {
+	if x != nil {
+		return x.Owner
+	}
+	return nil
+}
+
+var File_health_proto protoreflect.FileDescriptor
+
+const file_health_proto_rawDesc = "" +
+	"\n" +
+	"\fhealth.proto\x12\x12ochestra.health.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\x93\x01\n" +
+	"\x14ClusterHealthRequest\x12\x1c\n" +
+	"\tnamespace\x18\x01 \x01(\tR\tnamespace\x12!\n" +
+	"\fmin_severity\x18\x02 \x01(\tR\vminSeverity\x12\x1b\n" +
+	"\tpage_size\x18\x03 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x04 \x01(\tR\tpageToken\"\x15\n" +
+	"\x13TriggerCheckRequest\"\x16\n" +
+	"\x14TriggerCheckResponse\"9\n" +
+	"\x19OptimizationReportRequest\x12\x1c\n" +
+	"\tnamespace\x18\x01 \x01(\tR\tnamespace\"\x96\x04\n" +
+	"\rClusterHealth\x128\n" +
+	"\ttimestamp\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12!\n" +
+	"\fhealth_score\x18\x02 \x01(\x05R\vhealthScore\x125\n" +
+	"\x05nodes\x18\x03 \x01(\v2\x1f.ochestra.health.v1.NodeSummaryR\x05nodes\x122\n" +
+	"\x04pods\x18\x04 \x01(\v2\x1e.ochestra.health.v1.PodSummaryR\x04pods\x127\n" +
+	"\x06issues\x18\x05 \x03(\v2\x1f.ochestra.health.v1.HealthIssueR\x06issues\x12K\n" +
+	"\bsections\x18\x06 \x03(\v2/.ochestra.health.v1.ClusterHealth.SectionsEntryR\bsections\x12/\n" +
+	"\x13maintenance_windows\x18\a \x03(\tR\x12maintenanceWindows\x12&\n" +
+	"\x0fnext_page_token\x18\b \x01(\tR\rnextPageToken\x1a^\n" +
+	"\rSectionsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x127\n" +
+	"\x05value\x18\x02 \x01(\v2!.ochestra.health.v1.SectionStatusR\x05value:\x028\x01\"\xdb\x01\n" +
+	"\vNodeSummary\x12\x14\n" +
+	"\x05total\x18\x01 \x01(\x05R\x05total\x12\x14\n" +
+	"\x05ready\x18\x02 \x01(\x05R\x05ready\x12'\n" +
+	"\x0fmemory_pressure\x18\x03 \x01(\x05R\x0ememoryPressure\x12#\n" +
+	"\rdisk_pressure\x18\x04 \x01(\x05R\fdiskPressure\x12!\n" +
+	"\fpid_pressure\x18\x05 \x01(\x05R\vpidPressure\x12/\n" +
+	"\x13network_unavailable\x18\x06 \x01(\x05R\x12networkUnavailable\"\xc6\x01\n" +
+	"\n" +
+	"PodSummary\x12\x14\n" +
+	"\x05total\x18\x01 \x01(\x05R\x05total\x12\x18\n" +
+	"\arunning\x18\x02 \x01(\x05R\arunning\x12\x18\n" +
+	"\apending\x18\x03 \x01(\x05R\apending\x12\x1c\n" +
+	"\tsucceeded\x18\x04 \x01(\x05R\tsucceeded\x12\x16\n" +
+	"\x06failed\x18\x05 \x01(\x05R\x06failed\x12\x18\n" +
+	"\aunknown\x18\x06 \x01(\x05R\aunknown\x12\x1e\n" +
+	"\n" +
+	"restarting\x18\a \x01(\x05R\n" +
+	"restarting\";\n" +
+	"\rSectionStatus\x12\x14\n" +
+	"\x05state\x18\x01 \x01(\tR\x05state\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\"\x80\x04\n" +
+	"\vHealthIssue\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12\x1a\n" +
+	"\bseverity\x18\x02 \x01(\tR\bseverity\x12\x1a\n" +
+	"\bresource\x18\x03 \x01(\tR\bresource\x12\x1c\n" +
+	"\tnamespace\x18\x04 \x01(\tR\tnamespace\x12\x12\n" +
+	"\x04name\x18\x05 \x01(\tR\x04name\x12\x18\n" +
+	"\amessage\x18\x06 \x01(\tR\amessage\x128\n" +
+	"\ttimestamp\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12\x1e\n" +
+	"\n" +
+	"suggestion\x18\b \x01(\tR\n" +
+	"suggestion\x12\x1f\n" +
+	"\vrunbook_url\x18\t \x01(\tR\n" +
+	"runbookUrl\x12 \n" +
+	"\vremediation\x18\n" +
+	" \x03(\tR\vremediation\x12\x1e\n" +
+	"\n" +
+	"suppressed\x18\v \x01(\bR\n" +
+	"suppressed\x12\x12\n" +
+	"\x04node\x18\f \x01(\tR\x04node\x12\x1a\n" +
+	"\bdetector\x18\r \x01(\tR\bdetector\x12;\n" +
+	"\bchildren\x18\x0e \x03(\v2\x1f.ochestra.health.v1.HealthIssueR\bchildren\x12/\n" +
+	"\x05owner\x18\x0f \x01(\v2\x19.ochestra.health.v1.OwnerR\x05owner\"o\n" +
+	"\x05Owner\x12\x12\n" +
+	"\x04kind\x18\x01 \x01(\tR\x04kind\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x12\n" +
+	"\x04team\x18\x03 \x01(\tR\x04team\x12\x14\n" +
+	"\x05slack\x18\x04 \x01(\tR\x05slack\x12\x14\n" +
+	"\x05email\x18\x05 \x01(\tR\x05email\"\xce\x01\n" +
+	"\x12OptimizationReport\x12=\n" +
+	"\fgenerated_at\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\vgeneratedAt\x12+\n" +
+	"\x11potential_savings\x18\x02 \x01(\x01R\x10potentialSavings\x12L\n" +
+	"\x0frecommendations\x18\x03 \x03(\v2\".ochestra.health.v1.RecommendationR\x0frecommendations\"\xe2\x03\n" +
+	"\x0eRecommendation\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12 \n" +
+	"\vdescription\x18\x02 \x01(\tR\vdescription\x12)\n" +
+	"\x10potential_saving\x18\x03 \x01(\x01R\x0fpotentialSaving\x12\x1c\n" +
+	"\tnamespace\x18\x04 \x01(\tR\tnamespace\x12#\n" +
+	"\rworkload_kind\x18\x05 \x01(\tR\fworkloadKind\x12#\n" +
+	"\rworkload_name\x18\x06 \x01(\tR\fworkloadName\x12%\n" +
+	"\x0econtainer_name\x18\a \x01(\tR\rcontainerName\x12#\n" +
+	"\rresource_type\x18\b \x01(\tR\fresourceType\x12'\n" +
+	"\x0fcurrent_request\x18\t \x01(\x03R\x0ecurrentRequest\x12/\n" +
+	"\x13recommended_request\x18\n" +
+	" \x01(\x03R\x12recommendedRequest\x12\x14\n" +
+	"\x05usage\x18\v \x01(\x03R\x05usage\x12\x1a\n" +
+	"\breplicas\x18\f \x01(\x05R\breplicas\x12/\n" +
+	"\x05owner\x18\r \x01(\v2\x19.ochestra.health.v1.OwnerR\x05owner2\x9c\x04\n" +
+	"\rHealthMonitor\x12_\n" +
+	"\x10GetClusterHealth\x12(.ochestra.health.v1.ClusterHealthRequest\x1a!.ochestra.health.v1.ClusterHealth\x12c\n" +
+	"\x12WatchClusterHealth\x12(.ochestra.health.v1.ClusterHealthRequest\x1a!.ochestra.health.v1.ClusterHealth0\x01\x12n\n" +
+	"\x15GetOptimizationReport\x12-.ochestra.health.v1.OptimizationReportRequest\x1a&.ochestra.health.v1.OptimizationReport\x12r\n" +
+	"\x17WatchOptimizationReport\x12-.ochestra.health.v1.OptimizationReportRequest\x1a&.ochestra.health.v1.OptimizationReport0\x01\x12a\n" +
+	"\fTriggerCheck\x12'.ochestra.health.v1.TriggerCheckRequest\x1a(.ochestra.health.v1.TriggerCheckResponseB;Z9github.com/ochestra-tech/ochestra-ai/pkg/grpcapi/healthpbb\x06proto3"
+
+var (
+	file_health_proto_rawDescOnce sync.Once
+	file_health_proto_rawDescData []byte
+)
+
+func file_health_proto_rawDescGZIP() []byte {
+	file_health_proto_rawDescOnce.Do(func() {
+		file_health_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_health_proto_rawDesc), len(file_health_proto_rawDesc)))
+	})
+	return file_health_proto_rawDescData
+}
+
+var file_health_proto_msgTypes = make([]protoimpl.MessageInfo, 13)
+var file_health_proto_goTypes = []any{
+	(*ClusterHealthRequest)(nil),      // 0: ochestra.health.v1.ClusterHealthRequest
+	(*TriggerCheckRequest)(nil),       // 1: ochestra.health.v1.TriggerCheckRequest
+	(*TriggerCheckResponse)(nil),      // 2: ochestra.health.v1.TriggerCheckResponse
+	(*OptimizationReportRequest)(nil), // 3: ochestra.health.v1.OptimizationReportRequest
+	(*ClusterHealth)(nil),             // 4: ochestra.health.v1.ClusterHealth
+	(*NodeSummary)(nil),               // 5: ochestra.health.v1.NodeSummary
+	(*PodSummary)(nil),                // 6: ochestra.health.v1.PodSummary
+	(*SectionStatus)(nil),             // 7: ochestra.health.v1.SectionStatus
+	(*HealthIssue)(nil),               // 8: ochestra.health.v1.HealthIssue
+	(*Owner)(nil),                     // 9: ochestra.health.v1.Owner
+	(*OptimizationReport)(nil),        // 10: ochestra.health.v1.OptimizationReport
+	(*Recommendation)(nil),            // 11: ochestra.health.v1.Recommendation
+	nil,                               // 12: ochestra.health.v1.ClusterHealth.SectionsEntry
+	(*timestamppb.Timestamp)(nil),     // 13: google.protobuf.Timestamp
+}
+var file_health_proto_depIdxs = []int32{
+	13, // 0: ochestra.health.v1.ClusterHealth.timestamp:type_name -> google.protobuf.Timestamp
+	5,  // 1: ochestra.health.v1.ClusterHealth.nodes:type_name -> ochestra.health.v1.NodeSummary
+	6,  // 2: ochestra.health.v1.ClusterHealth.pods:type_name -> ochestra.health.v1.PodSummary
+	8,  // 3: ochestra.health.v1.ClusterHealth.issues:type_name -> ochestra.health.v1.HealthIssue
+	12, // 4: ochestra.health.v1.ClusterHealth.sections:type_name -> ochestra.health.v1.ClusterHealth.SectionsEntry
+	13, // 5: ochestra.health.v1.HealthIssue.timestamp:type_name -> google.protobuf.Timestamp
+	8,  // 6: ochestra.health.v1.HealthIssue.children:type_name -> ochestra.health.v1.HealthIssue
+	9,  // 7: ochestra.health.v1.HealthIssue.owner:type_name -> ochestra.health.v1.Owner
+	13, // 8: ochestra.health.v1.OptimizationReport.generated_at:type_name -> google.protobuf.Timestamp
+	11, // 9: ochestra.health.v1.OptimizationReport.recommendations:type_name -> ochestra.health.v1.Recommendation
+	9,  // 10: ochestra.health.v1.Recommendation.owner:type_name -> ochestra.health.v1.Owner
+	7,  // 11: ochestra.health.v1.ClusterHealth.SectionsEntry.value:type_name -> ochestra.health.v1.SectionStatus
+	0,  // 12: ochestra.health.v1.HealthMonitor.GetClusterHealth:input_type -> ochestra.health.v1.ClusterHealthRequest
+	0,  // 13: ochestra.health.v1.HealthMonitor.WatchClusterHealth:input_type -> ochestra.health.v1.ClusterHealthRequest
+	3,  // 14: ochestra.health.v1.HealthMonitor.GetOptimizationReport:input_type -> ochestra.health.v1.OptimizationReportRequest
+	3,  // 15: ochestra.health.v1.HealthMonitor.WatchOptimizationReport:input_type -> ochestra.health.v1.OptimizationReportRequest
+	1,  // 16: ochestra.health.v1.HealthMonitor.TriggerCheck:input_type -> ochestra.health.v1.TriggerCheckRequest
+	4,  // 17: ochestra.health.v1.HealthMonitor.GetClusterHealth:output_type -> ochestra.health.v1.ClusterHealth
+	4,  // 18: ochestra.health.v1.HealthMonitor.WatchClusterHealth:output_type -> ochestra.health.v1.ClusterHealth
+	10, // 19: ochestra.health.v1.HealthMonitor.GetOptimizationReport:output_type -> ochestra.health.v1.OptimizationReport
+	10, // 20: ochestra.health.v1.HealthMonitor.WatchOptimizationReport:output_type -> ochestra.health.v1.OptimizationReport
+	2,  // 21: ochestra.health.v1.HealthMonitor.TriggerCheck:output_type -> ochestra.health.v1.TriggerCheckResponse
+	17, // [17:22] is the sub-list for method output_type
+	12, // [12:17] is the sub-list for method input_type
+	12, // [12:12] is the sub-list for extension type_name
+	12, // [12:12] is the sub-list for extension extendee
+	0,  // [0:12] is the sub-list for field type_name
+}
+
+func init() { file_health_proto_init() }
+func file_health_proto_init() {
+	if File_health_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_health_proto_rawDesc), len(file_health_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   13,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_health_proto_goTypes,
+		DependencyIndexes: file_health_proto_depIdxs,
+		MessageInfos:      file_health_proto_msgTypes,
+	}.Build()
+	File_health_proto = out.File
+	file_health_proto_goTypes = nil
+	file_health_proto_depIdxs = nil
+}
@@ -0,0 +1,291 @@
+// Report types and the streaming API served with --grpc-port. The Go code in
+// pkg/grpcapi/healthpb is generated from this file with `go generate ./pkg/grpcapi`.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: health.proto
+
+package healthpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	HealthMonitor_GetClusterHealth_FullMethodName        = "/ochestra.health.v1.HealthMonitor/GetClusterHealth"
+	HealthMonitor_WatchClusterHealth_FullMethodName      = "/ochestra.health.v1.HealthMonitor/WatchClusterHealth"
+	HealthMonitor_GetOptimizationReport_FullMethodName   = "/ochestra.health.v1.HealthMonitor/GetOptimizationReport"
+	HealthMonitor_WatchOptimizationReport_FullMethodName = "/ochestra.health.v1.HealthMonitor/WatchOptimizationReport"
+	HealthMonitor_TriggerCheck_FullMethodName            = "/ochestra.health.v1.HealthMonitor/TriggerCheck"
+)
+
+// HealthMonitorClient is the client API for HealthMonitor service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// HealthMonitor serves the monitor's latest results. Watch calls send the current result
+// straight away and then one message per check cycle.
+type HealthMonitorClient interface {
+	GetClusterHealth(ctx context.Context, in *ClusterHealthRequest, opts ...grpc.CallOption) (*ClusterHealth, error)
+	WatchClusterHealth(ctx context.Context, in *ClusterHealthRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ClusterHealth], error)
+	GetOptimizationReport(ctx context.Context, in *OptimizationReportRequest, opts ...grpc.CallOption) (*OptimizationReport, error)
+	WatchOptimizationReport(ctx context.Context, in *OptimizationReportRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[OptimizationReport], error)
+	// Run a check cycle now instead of waiting for the interval
+	TriggerCheck(ctx context.Context, in *TriggerCheckRequest, opts ...grpc.CallOption) (*TriggerCheckResponse, error)
+}
+
+type healthMonitorClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewHealthMonitorClient(cc grpc.ClientConnInterface) HealthMonitorClient {
+	return &healthMonitorClient{cc}
+}
+
+func (c *healthMonitorClient) GetClusterHealth(ctx context.Context, in *ClusterHealthRequest, opts ...grpc.CallOption) (*ClusterHealth, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ClusterHealth)
+	err := c.cc.Invoke(ctx, HealthMonitor_GetClusterHealth_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *healthMonitorClient) WatchClusterHealth(ctx context.Context, in *ClusterHealthRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ClusterHealth], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &HealthMonitor_ServiceDesc.Streams[0], HealthMonitor_WatchClusterHealth_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ClusterHealthRequest, ClusterHealth]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type HealthMonitor_WatchClusterHealthClient = grpc.ServerStreamingClient[ClusterHealth]
+
+func (c *healthMonitorClient) GetOptimizationReport(ctx context.Context, in *OptimizationReportRequest, opts ...grpc.CallOption) (*OptimizationReport, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(OptimizationReport)
+	err := c.cc.Invoke(ctx, HealthMonitor_GetOptimizationReport_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *healthMonitorClient) WatchOptimizationReport(ctx context.Context, in *OptimizationReportRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[OptimizationReport], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &HealthMonitor_ServiceDesc.Streams[1], HealthMonitor_WatchOptimizationReport_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[OptimizationReportRequest, OptimizationReport]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type HealthMonitor_WatchOptimizationReportClient = grpc.ServerStreamingClient[OptimizationReport]
+
+func (c *healthMonitorClient) TriggerCheck(ctx context.Context, in *TriggerCheckRequest, opts ...grpc.CallOption) (*TriggerCheckResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TriggerCheckResponse)
+	err := c.cc.Invoke(ctx, HealthMonitor_TriggerCheck_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// HealthMonitorServer is the server API for HealthMonitor service.
+// All implementations must embed UnimplementedHealthMonitorServer
+// for forward compatibility.
+//
+// HealthMonitor serves the monitor's latest results. Watch calls send the current result
+// straight away and then one message per check cycle.
+type HealthMonitorServer interface {
+	GetClusterHealth(context.Context, *ClusterHealthRequest) (*ClusterHealth, error)
+	WatchClusterHealth(*ClusterHealthRequest, grpc.ServerStreamingServer[ClusterHealth]) error
+	GetOptimizationReport(context.Context, *OptimizationReportRequest) (*OptimizationReport, error)
+	WatchOptimizationReport(*OptimizationReportRequest, grpc.ServerStreamingServer[OptimizationReport]) error
+	// Run a check cycle now instead of waiting for the interval
+	TriggerCheck(context.Context, *TriggerCheckRequest) (*TriggerCheckResponse, error)
+	mustEmbedUnimplementedHealthMonitorServer()
+}
+
+// UnimplementedHealthMonitorServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedHealthMonitorServer struct{}
+
+func (UnimplementedHealthMonitorServer) GetClusterHealth(context.Context, *ClusterHealthRequest) (*ClusterHealth, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetClusterHealth not implemented")
+}
+func (UnimplementedHealthMonitorServer) WatchClusterHealth(*ClusterHealthRequest, grpc.ServerStreamingServer[ClusterHealth]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchClusterHealth not implemented")
+}
+func (UnimplementedHealthMonitorServer) GetOptimizationReport(context.Context, *OptimizationReportRequest) (*OptimizationReport, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetOptimizationReport not implemented")
+}
+func (UnimplementedHealthMonitorServer) WatchOptimizationReport(*OptimizationReportRequest, grpc.ServerStreamingServer[OptimizationReport]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchOptimizationReport not implemented")
+}
+func (UnimplementedHealthMonitorServer) TriggerCheck(context.Context, *TriggerCheckRequest) (*TriggerCheckResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method TriggerCheck not implemented")
+}
+func (UnimplementedHealthMonitorServer) mustEmbedUnimplementedHealthMonitorServer() {}
+func (UnimplementedHealthMonitorServer) testEmbeddedByValue()                       {}
+
+// UnsafeHealthMonitorServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to HealthMonitorServer will
+// result in compilation errors.
+type UnsafeHealthMonitorServer interface {
+	mustEmbedUnimplementedHealthMonitorServer()
+}
+
+func RegisterHealthMonitorServer(s grpc.ServiceRegistrar, srv HealthMonitorServer) {
+	// If the following call pancis, it indicates UnimplementedHealthMonitorServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&HealthMonitor_ServiceDesc, srv)
+}
+
+func _HealthMonitor_GetClusterHealth_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ClusterHealthRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HealthMonitorServer).GetClusterHealth(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: HealthMonitor_GetClusterHealth_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HealthMonitorServer).GetClusterHealth(ctx, req.(*ClusterHealthRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _HealthMonitor_WatchClusterHealth_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ClusterHealthRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(HealthMonitorServer).WatchClusterHealth(m, &grpc.GenericServerStream[ClusterHealthRequest, ClusterHealth]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type HealthMonitor_WatchClusterHealthServer = grpc.ServerStreamingServer[ClusterHealth]
+
+func _HealthMonitor_GetOptimizationReport_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(OptimizationReportRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HealthMonitorServer).GetOptimizationReport(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: HealthMonitor_GetOptimizationReport_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HealthMonitorServer).GetOptimizationReport(ctx, req.(*OptimizationReportRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _HealthMonitor_WatchOptimizationReport_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(OptimizationReportRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(HealthMonitorServer).WatchOptimizationReport(m, &grpc.GenericServerStream[OptimizationReportRequest, OptimizationReport]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type HealthMonitor_WatchOptimizationReportServer = grpc.ServerStreamingServer[OptimizationReport]
+
+func _HealthMonitor_TriggerCheck_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TriggerCheckRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HealthMonitorServer).TriggerCheck(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: HealthMonitor_TriggerCheck_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HealthMonitorServer).TriggerCheck(ctx, req.(*TriggerCheckRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// HealthMonitor_ServiceDesc is the grpc.ServiceDesc for HealthMonitor service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var HealthMonitor_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "ochestra.health.v1.HealthMonitor",
+	HandlerType: (*HealthMonitorServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetClusterHealth",
+			Handler:    _HealthMonitor_GetClusterHealth_Handler,
+		},
+		{
+			MethodName: "GetOptimizationReport",
+			Handler:    _HealthMonitor_GetOptimizationReport_Handler,
+		},
+		{
+			MethodName: "TriggerCheck",
+			Handler:    _HealthMonitor_TriggerCheck_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchClusterHealth",
+			Handler:       _HealthMonitor_WatchClusterHealth_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "WatchOptimizationReport",
+			Handler:       _HealthMonitor_WatchOptimizationReport_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "health.proto",
+}
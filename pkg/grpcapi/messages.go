@@ -0,0 +1,227 @@
+package grpcapi
+
+//go:generate buf generate --template ../../api/proto/buf.gen.yaml ../../api/proto
+
+import (
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/grpcapi/healthpb"
+	"github.com/ochestra-tech/ochestra-ai/pkg/health"
+	"github.com/ochestra-tech/ochestra-ai/pkg/optimizer"
+	"github.com/ochestra-tech/ochestra-ai/pkg/owners"
+)
+
+// The messages of api/proto/health.proto are generated into healthpb. These functions
+// convert between them and the monitor's own types, in both directions so pkg/client can
+// share them with the server.
+
+// ClusterHealthToProto converts h to a ClusterHealth message carrying the given issues in
+// place of h.Issues, so callers can filter and page them
+func ClusterHealthToProto(h *health.ClusterHealth, issues []health.HealthIssue, nextPageToken string) *healthpb.ClusterHealth {
+	m := &healthpb.ClusterHealth{
+		Timestamp:   timestampToProto(h.Timestamp),
+		HealthScore: int32(h.HealthScore),
+		Nodes: &healthpb.NodeSummary{
+			Total:              int32(h.NodeStatus.TotalNodes),
+			Ready:              int32(h.NodeStatus.ReadyNodes),
+			MemoryPressure:     int32(h.NodeStatus.MemoryPressureNodes),
+			DiskPressure:       int32(h.NodeStatus.DiskPressureNodes),
+			PidPressure:        int32(h.NodeStatus.PIDPressureNodes),
+			NetworkUnavailable: int32(h.NodeStatus.NetworkUnavailableNodes),
+		},
+		Pods: &healthpb.PodSummary{
+			Total:      int32(h.PodStatus.TotalPods),
+			Running:    int32(h.PodStatus.RunningPods),
+			Pending:    int32(h.PodStatus.PendingPods),
+			Succeeded:  int32(h.PodStatus.SucceededPods),
+			Failed:     int32(h.PodStatus.FailedPods),
+			Unknown:    int32(h.PodStatus.UnknownPods),
+			Restarting: int32(h.PodStatus.RestartingPods),
+		},
+		MaintenanceWindows: h.MaintenanceWindows,
+		NextPageToken:      nextPageToken,
+	}
+	for _, issue := range issues {
+		m.Issues = append(m.Issues, healthIssueToProto(issue))
+	}
+	if len(h.Sections) > 0 {
+		m.Sections = make(map[string]*healthpb.SectionStatus, len(h.Sections))
+		for name, status := range h.Sections {
+			m.Sections[name] = &healthpb.SectionStatus{State: status.State, Error: status.Error}
+		}
+	}
+	return m
+}
+
+// ClusterHealthFromProto converts a ClusterHealth message and returns its next page token
+func ClusterHealthFromProto(m *healthpb.ClusterHealth) (*health.ClusterHealth, string) {
+	h := &health.ClusterHealth{
+		Timestamp:          timestampFromProto(m.GetTimestamp()),
+		HealthScore:        int(m.GetHealthScore()),
+		Sections:           make(map[string]health.SectionStatus, len(m.GetSections())),
+		MaintenanceWindows: m.GetMaintenanceWindows(),
+	}
+	nodes := m.GetNodes()
+	h.NodeStatus.TotalNodes = int(nodes.GetTotal())
+	h.NodeStatus.ReadyNodes = int(nodes.GetReady())
+	h.NodeStatus.MemoryPressureNodes = int(nodes.GetMemoryPressure())
+	h.NodeStatus.DiskPressureNodes = int(nodes.GetDiskPressure())
+	h.NodeStatus.PIDPressureNodes = int(nodes.GetPidPressure())
+	h.NodeStatus.NetworkUnavailableNodes = int(nodes.GetNetworkUnavailable())
+
+	pods := m.GetPods()
+	h.PodStatus.TotalPods = int(pods.GetTotal())
+	h.PodStatus.RunningPods = int(pods.GetRunning())
+	h.PodStatus.PendingPods = int(pods.GetPending())
+	h.PodStatus.SucceededPods = int(pods.GetSucceeded())
+	h.PodStatus.FailedPods = int(pods.GetFailed())
+	h.PodStatus.UnknownPods = int(pods.GetUnknown())
+	h.PodStatus.RestartingPods = int(pods.GetRestarting())
+
+	for _, issue := range m.GetIssues() {
+		h.Issues = append(h.Issues, healthIssueFromProto(issue))
+	}
+	for name, status := range m.GetSections() {
+		h.Sections[name] = health.SectionStatus{State: status.GetState(), Error: status.GetError()}
+	}
+	return h, m.GetNextPageToken()
+}
+
+// healthIssueToProto converts an issue and its correlated children
+func healthIssueToProto(issue health.HealthIssue) *healthpb.HealthIssue {
+	m := &healthpb.HealthIssue{
+		Type:        issue.Type,
+		Severity:    issue.Severity,
+		Resource:    issue.Resource,
+		Namespace:   issue.Namespace,
+		Name:        issue.Name,
+		Message:     issue.Message,
+		Timestamp:   timestampToProto(issue.Timestamp),
+		Suggestion:  issue.Suggestion,
+		RunbookUrl:  issue.RunbookURL,
+		Remediation: issue.Remediation,
+		Suppressed:  issue.Suppressed,
+		Node:        issue.Node,
+		Detector:    issue.Detector,
+		Owner:       ownerToProto(issue.Owner),
+	}
+	for _, child := range issue.Children {
+		m.Children = append(m.Children, healthIssueToProto(child))
+	}
+	return m
+}
+
+// healthIssueFromProto converts an issue message and its children
+func healthIssueFromProto(m *healthpb.HealthIssue) health.HealthIssue {
+	issue := health.HealthIssue{
+		Type:        m.GetType(),
+		Severity:    m.GetSeverity(),
+		Resource:    m.GetResource(),
+		Namespace:   m.GetNamespace(),
+		Name:        m.GetName(),
+		Message:     m.GetMessage(),
+		Timestamp:   timestampFromProto(m.GetTimestamp()),
+		Suggestion:  m.GetSuggestion(),
+		RunbookURL:  m.GetRunbookUrl(),
+		Remediation: m.GetRemediation(),
+		Suppressed:  m.GetSuppressed(),
+		Node:        m.GetNode(),
+		Detector:    m.GetDetector(),
+		Owner:       ownerFromProto(m.GetOwner()),
+	}
+	for _, child := range m.GetChildren() {
+		issue.Children = append(issue.Children, healthIssueFromProto(child))
+	}
+	return issue
+}
+
+// ownerToProto converts an owner, which may be nil
+func ownerToProto(o *owners.Owner) *healthpb.Owner {
+	if o == nil {
+		return nil
+	}
+	return &healthpb.Owner{Kind: o.Kind, Name: o.Name, Team: o.Team, Slack: o.Slack, Email: o.Email}
+}
+
+// ownerFromProto converts an owner message, which may be nil
+func ownerFromProto(m *healthpb.Owner) *owners.Owner {
+	if m == nil {
+		return nil
+	}
+	return &owners.Owner{Kind: m.GetKind(), Name: m.GetName(), Team: m.GetTeam(), Slack: m.GetSlack(), Email: m.GetEmail()}
+}
+
+// OptimizationReportToProto converts report to an OptimizationReport message with the
+// recommendations for namespace, or all of them if namespace is empty
+func OptimizationReportToProto(report *optimizer.OptimizationReport, namespace string) *healthpb.OptimizationReport {
+	m := &healthpb.OptimizationReport{
+		GeneratedAt:      timestampToProto(report.GeneratedAt),
+		PotentialSavings: report.PotentialSavings,
+	}
+	for _, r := range report.Recommendations {
+		if namespace != "" && r.Namespace != namespace {
+			continue
+		}
+		m.Recommendations = append(m.Recommendations, &healthpb.Recommendation{
+			Type:               r.Type,
+			Description:        r.Description,
+			PotentialSaving:    r.PotentialSaving,
+			Namespace:          r.Namespace,
+			WorkloadKind:       r.WorkloadKind,
+			WorkloadName:       r.WorkloadName,
+			ContainerName:      r.ContainerName,
+			ResourceType:       r.ResourceType,
+			CurrentRequest:     r.CurrentRequest,
+			RecommendedRequest: r.RecommendedRequest,
+			Usage:              r.Usage,
+			Replicas:           int32(r.Replicas),
+			Owner:              ownerToProto(r.Owner),
+		})
+	}
+	return m
+}
+
+// OptimizationReportFromProto converts an OptimizationReport message
+func OptimizationReportFromProto(m *healthpb.OptimizationReport) *optimizer.OptimizationReport {
+	report := &optimizer.OptimizationReport{
+		GeneratedAt:      timestampFromProto(m.GetGeneratedAt()),
+		PotentialSavings: m.GetPotentialSavings(),
+		Recommendations:  make([]optimizer.Recommendation, 0, len(m.GetRecommendations())),
+	}
+	for _, r := range m.GetRecommendations() {
+		report.Recommendations = append(report.Recommendations, optimizer.Recommendation{
+			Type:               r.GetType(),
+			Description:        r.GetDescription(),
+			PotentialSaving:    r.GetPotentialSaving(),
+			Namespace:          r.GetNamespace(),
+			WorkloadKind:       r.GetWorkloadKind(),
+			WorkloadName:       r.GetWorkloadName(),
+			ContainerName:      r.GetContainerName(),
+			ResourceType:       r.GetResourceType(),
+			CurrentRequest:     r.GetCurrentRequest(),
+			RecommendedRequest: r.GetRecommendedRequest(),
+			Usage:              r.GetUsage(),
+			Replicas:           int(r.GetReplicas()),
+			Owner:              ownerFromProto(r.GetOwner()),
+		})
+	}
+	return report
+}
+
+// timestampToProto converts a time, leaving zero times unset
+func timestampToProto(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+// timestampFromProto converts a timestamp, returning the zero time when it is unset
+func timestampFromProto(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
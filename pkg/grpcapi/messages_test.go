@@ -0,0 +1,105 @@
+package grpcapi
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/grpcapi/healthpb"
+	"github.com/ochestra-tech/ochestra-ai/pkg/health"
+	"github.com/ochestra-tech/ochestra-ai/pkg/optimizer"
+	"github.com/ochestra-tech/ochestra-ai/pkg/owners"
+)
+
+// roundTrip marshals m and unmarshals it into out, as the message crosses the wire
+func roundTrip(t *testing.T, m, out proto.Message) {
+	t.Helper()
+	b, err := proto.Marshal(m)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	if err := proto.Unmarshal(b, out); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+}
+
+func TestClusterHealthRoundTrip(t *testing.T) {
+	now := time.Date(2026, 3, 4, 5, 6, 7, 8, time.UTC)
+	owner := &owners.Owner{Kind: "Deployment", Name: "ledger", Team: "payments", Slack: "#payments", Email: "payments@example.com"}
+	h := &health.ClusterHealth{
+		Timestamp:   now,
+		HealthScore: 87,
+		NodeStatus: health.NodeHealthStatus{TotalNodes: 5, ReadyNodes: 4, MemoryPressureNodes: 1, DiskPressureNodes: 2,
+			PIDPressureNodes: 3, NetworkUnavailableNodes: 1},
+		PodStatus: health.PodHealthStatus{TotalPods: 40, RunningPods: 35, PendingPods: 2, SucceededPods: 1,
+			FailedPods: 1, UnknownPods: 1, RestartingPods: 3},
+		Issues: []health.HealthIssue{{
+			Type: "CrashLoopBackOff", Severity: "critical", Resource: "Pod", Namespace: "payments", Name: "ledger-0",
+			Message: "back-off restarting", Timestamp: now, Suggestion: "check the logs", RunbookURL: "https://runbooks/crash",
+			Remediation: []string{"kubectl logs ledger-0", "kubectl describe pod ledger-0"}, Suppressed: true,
+			Node: "node-1", Detector: "pods", Owner: owner,
+			Children: []health.HealthIssue{{Type: "OOMKilled", Severity: "warning", Namespace: "payments", Name: "ledger-0"}},
+		}},
+		Sections: map[string]health.SectionStatus{
+			"helm":   {State: "complete"},
+			"gitops": {State: "failed", Error: "forbidden"},
+		},
+		MaintenanceWindows: []string{"weekly-patching"},
+	}
+
+	var got healthpb.ClusterHealth
+	roundTrip(t, ClusterHealthToProto(h, h.Issues, "abc.10"), &got)
+	decoded, next := ClusterHealthFromProto(&got)
+	if next != "abc.10" {
+		t.Errorf("next page token = %q, want abc.10", next)
+	}
+	if !reflect.DeepEqual(decoded, h) {
+		t.Errorf("ClusterHealthFromProto() = %+v, want %+v", decoded, h)
+	}
+}
+
+func TestClusterHealthToProtoUsesGivenIssues(t *testing.T) {
+	h := &health.ClusterHealth{Issues: []health.HealthIssue{{Name: "a"}, {Name: "b"}}}
+	m := ClusterHealthToProto(h, h.Issues[1:], "")
+	if len(m.Issues) != 1 || m.Issues[0].Name != "b" {
+		t.Errorf("issues = %v, want only b", m.Issues)
+	}
+	if m.Timestamp != nil {
+		t.Errorf("timestamp = %v, want unset for a zero time", m.Timestamp)
+	}
+}
+
+func TestOptimizationReportRoundTrip(t *testing.T) {
+	report := &optimizer.OptimizationReport{
+		GeneratedAt:      time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC),
+		PotentialSavings: 123.45,
+		Recommendations: []optimizer.Recommendation{
+			{Type: "Right-size", Description: "lower CPU", PotentialSaving: 12.5, Namespace: "payments", WorkloadKind: "Deployment",
+				WorkloadName: "ledger", ContainerName: "app", ResourceType: "cpu", CurrentRequest: 1000, RecommendedRequest: 250,
+				Usage: 120, Replicas: 3, Owner: &owners.Owner{Kind: "Deployment", Name: "ledger", Team: "payments"}},
+			{Type: "Right-size", Namespace: "search", ResourceType: "memory", CurrentRequest: 1 << 33, RecommendedRequest: 1 << 32},
+		},
+	}
+
+	tests := []struct {
+		namespace string
+		want      []optimizer.Recommendation
+	}{
+		{"", report.Recommendations},
+		{"search", report.Recommendations[1:]},
+		{"other", []optimizer.Recommendation{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.namespace, func(t *testing.T) {
+			var got healthpb.OptimizationReport
+			roundTrip(t, OptimizationReportToProto(report, tt.namespace), &got)
+			decoded := OptimizationReportFromProto(&got)
+			want := &optimizer.OptimizationReport{GeneratedAt: report.GeneratedAt, PotentialSavings: report.PotentialSavings, Recommendations: tt.want}
+			if !reflect.DeepEqual(decoded, want) {
+				t.Errorf("OptimizationReportFromProto() = %+v, want %+v", decoded, want)
+			}
+		})
+	}
+}
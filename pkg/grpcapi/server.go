@@ -0,0 +1,228 @@
+package grpcapi
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/grpcapi/healthpb"
+	"github.com/ochestra-tech/ochestra-ai/pkg/health"
+	"github.com/ochestra-tech/ochestra-ai/pkg/optimizer"
+)
+
+// maxRequestSize bounds request messages, which only carry filters
+const maxRequestSize = 64 << 10
+
+// Server serves the latest cluster health and optimization report as the HealthMonitor
+// service of api/proto/health.proto. It is an http.Handler so it runs behind the API's
+// authentication like the other endpoints.
+type Server struct {
+	healthpb.UnimplementedHealthMonitorServer
+	grpc *grpc.Server
+
+	mu      sync.Mutex
+	health  *health.ClusterHealth
+	report  *optimizer.OptimizationReport
+	updated chan struct{} // closed and replaced whenever a result is published
+
+	trigger chan struct{}
+}
+
+// NewServer creates a server with no results yet
+func NewServer() *Server {
+	s := &Server{updated: make(chan struct{}), trigger: make(chan struct{}, 1)}
+	s.grpc = grpc.NewServer(grpc.MaxRecvMsgSize(maxRequestSize))
+	healthpb.RegisterHealthMonitorServer(s.grpc, s)
+	return s
+}
+
+// PublishHealth makes h the latest cluster health and sends it to watchers
+func (s *Server) PublishHealth(h *health.ClusterHealth) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.health = h
+	s.notify()
+}
+
+// PublishReport makes report the latest optimization report and sends it to watchers
+func (s *Server) PublishReport(report *optimizer.OptimizationReport) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.report = report
+	s.notify()
+}
+
+// Triggered receives when a client asked for a check cycle to run now. Requests made
+// while one is pending are merged.
+func (s *Server) Triggered() <-chan struct{} {
+	return s.trigger
+}
+
+// notify wakes watchers; callers hold s.mu
+func (s *Server) notify() {
+	close(s.updated)
+	s.updated = make(chan struct{})
+}
+
+// latest returns the current results and the channel closed on the next update
+func (s *Server) latest() (*health.ClusterHealth, *optimizer.OptimizationReport, <-chan struct{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.health, s.report, s.updated
+}
+
+// ServeHTTP handles a gRPC call over HTTP/2
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.grpc.ServeHTTP(w, r)
+}
+
+// GetClusterHealth returns the latest cluster health with the issues the request selects
+func (s *Server) GetClusterHealth(_ context.Context, req *healthpb.ClusterHealthRequest) (*healthpb.ClusterHealth, error) {
+	h, _, _ := s.latest()
+	if h == nil {
+		return nil, status.Error(codes.Unavailable, "no check has completed yet")
+	}
+	issues, next, err := selectIssues(h, req)
+	if err != nil {
+		return nil, err
+	}
+	return ClusterHealthToProto(h, issues, next), nil
+}
+
+// WatchClusterHealth streams the cluster health after each check. Watches are not paged.
+func (s *Server) WatchClusterHealth(req *healthpb.ClusterHealthRequest, stream healthpb.HealthMonitor_WatchClusterHealthServer) error {
+	filter := &healthpb.ClusterHealthRequest{Namespace: req.GetNamespace(), MinSeverity: req.GetMinSeverity()}
+	return watch(s, stream, func(h *health.ClusterHealth, _ *optimizer.OptimizationReport) *healthpb.ClusterHealth {
+		if h == nil {
+			return nil
+		}
+		issues, _, _ := selectIssues(h, filter)
+		return ClusterHealthToProto(h, issues, "")
+	})
+}
+
+// GetOptimizationReport returns the latest optimization report for the requested namespace
+func (s *Server) GetOptimizationReport(_ context.Context, req *healthpb.OptimizationReportRequest) (*healthpb.OptimizationReport, error) {
+	_, report, _ := s.latest()
+	if report == nil {
+		return nil, status.Error(codes.Unavailable, "no check has completed yet")
+	}
+	return OptimizationReportToProto(report, req.GetNamespace()), nil
+}
+
+// WatchOptimizationReport streams the optimization report after each check
+func (s *Server) WatchOptimizationReport(req *healthpb.OptimizationReportRequest, stream healthpb.HealthMonitor_WatchOptimizationReportServer) error {
+	return watch(s, stream, func(_ *health.ClusterHealth, report *optimizer.OptimizationReport) *healthpb.OptimizationReport {
+		if report == nil {
+			return nil
+		}
+		return OptimizationReportToProto(report, req.GetNamespace())
+	})
+}
+
+// TriggerCheck asks for a check cycle to run now
+func (s *Server) TriggerCheck(context.Context, *healthpb.TriggerCheckRequest) (*healthpb.TriggerCheckResponse, error) {
+	select {
+	case s.trigger <- struct{}{}:
+	default:
+	}
+	return &healthpb.TriggerCheckResponse{}, nil
+}
+
+// watch streams the message built from the latest results, and again whenever a new
+// result changes it, until the client goes away. build returns nil while there is no
+// result yet.
+func watch[M any, P interface {
+	*M
+	proto.Message
+}](s *Server, stream grpc.ServerStreamingServer[M], build func(*health.ClusterHealth, *optimizer.OptimizationReport) P) error {
+	h, report, updated := s.latest()
+
+	// Send the headers now so the client sees the stream open before the first result
+	if err := stream.SendHeader(nil); err != nil {
+		return err
+	}
+	var last P
+	for {
+		// Results are published together each cycle, so skip updates that leave this
+		// stream's message unchanged
+		if message := build(h, report); message != nil && (last == nil || !proto.Equal(message, last)) {
+			if err := stream.Send(message); err != nil {
+				log.Printf("gRPC watch ended: %v", err)
+				return err
+			}
+			last = message
+		}
+		select {
+		case <-updated:
+			h, report, updated = s.latest()
+		case <-stream.Context().Done():
+			return nil
+		}
+	}
+}
+
+// selectIssues filters the issues of h by the request and returns the requested page.
+// Page tokens name the check they page through, so a newer check aborts the paging
+// rather than skipping or repeating issues.
+func selectIssues(h *health.ClusterHealth, req *healthpb.ClusterHealthRequest) ([]health.HealthIssue, string, error) {
+	issues := make([]health.HealthIssue, 0, len(h.Issues))
+	for _, issue := range h.Issues {
+		if req.GetNamespace() != "" && issue.Namespace != req.GetNamespace() {
+			continue
+		}
+		if req.GetMinSeverity() != "" && health.SeverityRank(issue.Severity) > health.SeverityRank(req.GetMinSeverity()) {
+			continue
+		}
+		issues = append(issues, issue)
+	}
+	pageSize := int(req.GetPageSize())
+	if pageSize <= 0 {
+		return issues, "", nil
+	}
+
+	check := strconv.FormatInt(h.Timestamp.UnixNano(), 36)
+	offset := 0
+	if req.GetPageToken() != "" {
+		tokenCheck, tokenOffset, _ := strings.Cut(req.GetPageToken(), ".")
+		if tokenCheck != check {
+			return nil, "", status.Error(codes.Aborted, "a newer check replaced the results being paged; start again from the first page")
+		}
+		var err error
+		if offset, err = strconv.Atoi(tokenOffset); err != nil || offset < 0 || offset > len(issues) {
+			return nil, "", status.Error(codes.InvalidArgument, "invalid page token")
+		}
+	}
+
+	end := offset + pageSize
+	if end >= len(issues) {
+		return issues[offset:], "", nil
+	}
+	return issues[offset:end], check + "." + strconv.Itoa(end), nil
+}
+
+// ListenAndServe serves handler over HTTP/2 on port. With a certificate and key it serves
+// TLS; without, it serves plaintext HTTP/2 for clients dialing with insecure credentials,
+// which is only safe behind a proxy or service mesh that terminates TLS, since bearer
+// tokens would otherwise cross the network in the clear.
+func ListenAndServe(port int, handler http.Handler, certFile, keyFile string) error {
+	var protocols http.Protocols
+	protocols.SetHTTP2(true)
+	if certFile == "" {
+		protocols.SetUnencryptedHTTP2(true)
+	}
+	server := &http.Server{Addr: fmt.Sprintf(":%d", port), Handler: handler, Protocols: &protocols}
+	if certFile == "" {
+		return server.ListenAndServe()
+	}
+	return server.ListenAndServeTLS(certFile, keyFile)
+}
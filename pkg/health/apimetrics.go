@@ -0,0 +1,84 @@
+package health
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/prometheus/common/expfmt"
+	"k8s.io/client-go/kubernetes"
+)
+
+// apiServerMetrics reads and parses the API server's /metrics at most once per check and
+// shares the samples between the checks that read them, since the response can be megabytes
+type apiServerMetrics struct {
+	clientset kubernetes.Interface
+
+	once    sync.Once
+	samples []metricSample
+	err     error
+}
+
+// get returns the API server's metric samples
+func (m *apiServerMetrics) get(ctx context.Context) ([]metricSample, error) {
+	m.once.Do(func() {
+		if m.clientset == nil {
+			m.err = fmt.Errorf("no API server to read metrics from")
+			return
+		}
+		data, err := m.clientset.CoreV1().RESTClient().Get().AbsPath("/metrics").DoRaw(ctx)
+		if err != nil {
+			m.err = fmt.Errorf("failed to read apiserver metrics: %w", err)
+			return
+		}
+		if m.samples, err = parseMetrics(data); err != nil {
+			m.err = fmt.Errorf("failed to read apiserver metrics: %w", err)
+		}
+	})
+	return m.samples, m.err
+}
+
+// metricSample is one sample of a Prometheus metric. Histograms and summaries give a
+// <name>_sum and a <name>_count sample, as in the text format.
+type metricSample struct {
+	name   string
+	labels map[string]string
+	value  float64
+}
+
+// parseMetrics parses metrics in the Prometheus text format
+func parseMetrics(data []byte) ([]metricSample, error) {
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse metrics: %w", err)
+	}
+
+	var samples []metricSample
+	for name, family := range families {
+		for _, m := range family.GetMetric() {
+			labels := make(map[string]string, len(m.GetLabel()))
+			for _, pair := range m.GetLabel() {
+				labels[pair.GetName()] = pair.GetValue()
+			}
+			switch {
+			case m.Histogram != nil:
+				samples = append(samples,
+					metricSample{name: name + "_sum", labels: labels, value: m.GetHistogram().GetSampleSum()},
+					metricSample{name: name + "_count", labels: labels, value: float64(m.GetHistogram().GetSampleCount())})
+			case m.Summary != nil:
+				samples = append(samples,
+					metricSample{name: name + "_sum", labels: labels, value: m.GetSummary().GetSampleSum()},
+					metricSample{name: name + "_count", labels: labels, value: float64(m.GetSummary().GetSampleCount())})
+			case m.Counter != nil:
+				samples = append(samples, metricSample{name: name, labels: labels, value: m.GetCounter().GetValue()})
+			case m.Gauge != nil:
+				samples = append(samples, metricSample{name: name, labels: labels, value: m.GetGauge().GetValue()})
+			case m.Untyped != nil:
+				samples = append(samples, metricSample{name: name, labels: labels, value: m.GetUntyped().GetValue()})
+			}
+		}
+	}
+	return samples, nil
+}
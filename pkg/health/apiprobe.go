@@ -0,0 +1,67 @@
+package health
+
+import (
+	"context"
+	"math/rand"
+	"sort"
+	"time"
+
+	"k8s.io/client-go/kubernetes"
+)
+
+// DefaultAPIProbes is how many probe rounds time the API server per check by default
+const DefaultAPIProbes = 3
+
+// APIProbes is how many rounds of GET /readyz and GET /version time the API server per check
+var APIProbes = DefaultAPIProbes
+
+// apiProbePaths are answered by the API server without reading from storage, so their
+// latency does not grow with the size of the cluster
+var apiProbePaths = []string{"/readyz", "/version"}
+
+const (
+	apiProbeJitter  = 250 * time.Millisecond // bound on the random pause before each round
+	apiProbeTimeout = 5 * time.Second        // bound on a single probe request
+)
+
+// probeAPIServer times APIProbes rounds of the probe requests, each round after a random
+// pause so the rounds do not line up with other periodic clients. Requests are not retried,
+// so a slow or failing API server shows in the result. The reported latency is the median
+// of all requests.
+func probeAPIServer(ctx context.Context, clientset kubernetes.Interface, status *ControlPlaneStatus) {
+	rounds := max(APIProbes, 1)
+	durations := make([]time.Duration, 0, rounds*len(apiProbePaths))
+	failures := 0
+	status.APIServerReady = true
+
+	for i := 0; i < rounds; i++ {
+		select {
+		case <-time.After(time.Duration(rand.Int63n(int64(apiProbeJitter)))):
+		case <-ctx.Done():
+			return
+		}
+		for _, path := range apiProbePaths {
+			probeCtx, cancel := context.WithTimeout(ctx, apiProbeTimeout)
+			start := time.Now()
+			_, err := clientset.CoreV1().RESTClient().Get().AbsPath(path).DoRaw(probeCtx)
+			durations = append(durations, time.Since(start))
+			cancel()
+			if err == nil {
+				continue
+			}
+			failures++
+			if path == "/readyz" {
+				status.APIServerReady = false
+				status.APIServerReadyError = err.Error()
+			}
+		}
+	}
+
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+	median := durations[len(durations)/2]
+	status.APIServerLatency = float64(median.Milliseconds())
+	status.APIServerLatencyMax = float64(durations[len(durations)-1].Milliseconds())
+	status.APIServerProbes = len(durations)
+	status.APIServerProbeFailures = failures
+	status.APIServerHealthy = status.APIServerReady && failures == 0 && median < time.Second
+}
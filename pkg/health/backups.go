@@ -0,0 +1,252 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"time"
+
+	"k8s.io/client-go/kubernetes"
+)
+
+// BackupTarget is a namespace that must be backed up, and its recovery-point objective
+type BackupTarget struct {
+	Namespace string `json:"namespace"`
+	RPO       string `json:"rpo,omitempty"` // e.g. "24h"; defaults to DefaultRPO
+
+	rpo time.Duration
+}
+
+// DefaultRPO is the recovery-point objective of targets that do not set one
+const DefaultRPO = "24h"
+
+// BackupTargets are the namespaces whose Velero backups are checked. The check is off
+// while there are none.
+var BackupTargets []BackupTarget
+
+// LoadBackupTargets reads a file of the form {"namespaces": [{"namespace": "payments", "rpo": "6h"}]}
+func LoadBackupTargets(path string) ([]BackupTarget, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup targets: %w", err)
+	}
+
+	var file struct {
+		Namespaces []BackupTarget `json:"namespaces"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse backup targets: %w", err)
+	}
+	for i := range file.Namespaces {
+		if err := file.Namespaces[i].init(); err != nil {
+			return nil, fmt.Errorf("backup target %d: %w", i, err)
+		}
+	}
+	return file.Namespaces, nil
+}
+
+// init validates a target and parses its RPO
+func (t *BackupTarget) init() error {
+	if t.Namespace == "" {
+		return fmt.Errorf("a namespace is required")
+	}
+	if t.RPO == "" {
+		t.RPO = DefaultRPO
+	}
+	rpo, err := time.ParseDuration(t.RPO)
+	if err != nil || rpo <= 0 {
+		return fmt.Errorf("invalid rpo %q", t.RPO)
+	}
+	t.rpo = rpo
+	return nil
+}
+
+// BackupStatus reports Velero's storage locations and schedules, and whether each backup
+// target meets its recovery-point objective
+type BackupStatus struct {
+	Locations  []BackupLocation  `json:"locations,omitempty"`
+	Schedules  []BackupSchedule  `json:"schedules,omitempty"`
+	Namespaces []NamespaceBackup `json:"namespaces,omitempty"`
+}
+
+// BackupLocation is a Velero BackupStorageLocation
+type BackupLocation struct {
+	Namespace     string    `json:"namespace"`
+	Name          string    `json:"name"`
+	Phase         string    `json:"phase"` // "Available" or "Unavailable"
+	Default       bool      `json:"default"`
+	LastValidated time.Time `json:"lastValidated,omitempty"`
+	Message       string    `json:"message,omitempty"`
+}
+
+// BackupSchedule is a Velero Schedule and the namespaces its backups include
+type BackupSchedule struct {
+	Namespace  string    `json:"namespace"`
+	Name       string    `json:"name"`
+	Schedule   string    `json:"schedule"`
+	Phase      string    `json:"phase,omitempty"` // "Enabled" or "FailedValidation"
+	Paused     bool      `json:"paused"`
+	Includes   []string  `json:"includes,omitempty"` // empty or "*" for all namespaces
+	Excludes   []string  `json:"excludes,omitempty"`
+	LastBackup time.Time `json:"lastBackup,omitempty"`
+}
+
+// NamespaceBackup is the backup state of one backup target
+type NamespaceBackup struct {
+	Namespace      string        `json:"namespace"`
+	RPO            time.Duration `json:"rpo"`
+	LastBackup     time.Time     `json:"lastBackup,omitempty"` // last completed backup including the namespace
+	LastBackupName string        `json:"lastBackupName,omitempty"`
+	Schedules      []string      `json:"schedules,omitempty"` // enabled schedules including the namespace
+	RPOViolated    bool          `json:"rpoViolated"`
+}
+
+// veleroScope is the namespace selection of a backup or a schedule's backup template
+type veleroScope struct {
+	IncludedNamespaces []string `json:"includedNamespaces"`
+	ExcludedNamespaces []string `json:"excludedNamespaces"`
+}
+
+// includes reports whether the scope selects the namespace
+func (s veleroScope) includes(namespace string) bool {
+	if slices.Contains(s.ExcludedNamespaces, namespace) {
+		return false
+	}
+	return len(s.IncludedNamespaces) == 0 || slices.Contains(s.IncludedNamespaces, "*") || slices.Contains(s.IncludedNamespaces, namespace)
+}
+
+// veleroBackup is the part of a Velero Backup the check reads
+type veleroBackup struct {
+	Metadata struct {
+		Name string `json:"name"`
+	} `json:"metadata"`
+	Spec   veleroScope `json:"spec"`
+	Status struct {
+		Phase               string     `json:"phase"`
+		CompletionTimestamp *time.Time `json:"completionTimestamp"`
+	} `json:"status"`
+}
+
+// veleroLocation is the part of a Velero BackupStorageLocation the check reads
+type veleroLocation struct {
+	Metadata struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	} `json:"metadata"`
+	Spec struct {
+		Default bool `json:"default"`
+	} `json:"spec"`
+	Status struct {
+		Phase              string     `json:"phase"`
+		LastValidationTime *time.Time `json:"lastValidationTime"`
+		Message            string     `json:"message"`
+	} `json:"status"`
+}
+
+// veleroSchedule is the part of a Velero Schedule the check reads
+type veleroSchedule struct {
+	Metadata struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	} `json:"metadata"`
+	Spec struct {
+		Schedule string      `json:"schedule"`
+		Paused   bool        `json:"paused"`
+		Template veleroScope `json:"template"`
+	} `json:"spec"`
+	Status struct {
+		Phase      string     `json:"phase"`
+		LastBackup *time.Time `json:"lastBackup"`
+	} `json:"status"`
+}
+
+// checkBackups reads Velero's backups, storage locations and schedules and checks each
+// backup target against its recovery-point objective. Without Velero every target
+// violates its objective.
+func checkBackups(ctx context.Context, clientset kubernetes.Interface, now time.Time, status *BackupStatus) error {
+	if len(BackupTargets) == 0 || clientset == nil {
+		return nil
+	}
+
+	var backups struct {
+		Items []veleroBackup `json:"items"`
+	}
+	found, err := listCustomResources(ctx, clientset, "/apis/velero.io/v1/backups", &backups)
+	if err != nil {
+		return fmt.Errorf("failed to list Velero backups: %w", err)
+	}
+	partial := &PartialError{}
+	if !found {
+		partial.Errors = append(partial.Errors, fmt.Errorf("velero is not installed"))
+	}
+
+	var locations struct {
+		Items []veleroLocation `json:"items"`
+	}
+	if _, err := listCustomResources(ctx, clientset, "/apis/velero.io/v1/backupstoragelocations", &locations); err != nil {
+		partial.Errors = append(partial.Errors, fmt.Errorf("failed to list Velero backup storage locations: %w", err))
+	}
+	for _, l := range locations.Items {
+		location := BackupLocation{
+			Namespace: l.Metadata.Namespace,
+			Name:      l.Metadata.Name,
+			Phase:     l.Status.Phase,
+			Default:   l.Spec.Default,
+			Message:   l.Status.Message,
+		}
+		if l.Status.LastValidationTime != nil {
+			location.LastValidated = *l.Status.LastValidationTime
+		}
+		status.Locations = append(status.Locations, location)
+	}
+
+	var schedules struct {
+		Items []veleroSchedule `json:"items"`
+	}
+	if _, err := listCustomResources(ctx, clientset, "/apis/velero.io/v1/schedules", &schedules); err != nil {
+		partial.Errors = append(partial.Errors, fmt.Errorf("failed to list Velero schedules: %w", err))
+	}
+	for _, s := range schedules.Items {
+		schedule := BackupSchedule{
+			Namespace: s.Metadata.Namespace,
+			Name:      s.Metadata.Name,
+			Schedule:  s.Spec.Schedule,
+			Phase:     s.Status.Phase,
+			Paused:    s.Spec.Paused,
+			Includes:  s.Spec.Template.IncludedNamespaces,
+			Excludes:  s.Spec.Template.ExcludedNamespaces,
+		}
+		if s.Status.LastBackup != nil {
+			schedule.LastBackup = *s.Status.LastBackup
+		}
+		status.Schedules = append(status.Schedules, schedule)
+	}
+
+	for _, target := range BackupTargets {
+		ns := NamespaceBackup{Namespace: target.Namespace, RPO: target.rpo}
+		for _, b := range backups.Items {
+			if b.Status.Phase != "Completed" || b.Status.CompletionTimestamp == nil || !b.Spec.includes(target.Namespace) {
+				continue
+			}
+			if b.Status.CompletionTimestamp.After(ns.LastBackup) {
+				ns.LastBackup, ns.LastBackupName = *b.Status.CompletionTimestamp, b.Metadata.Name
+			}
+		}
+		for _, s := range schedules.Items {
+			if !s.Spec.Paused && s.Status.Phase != "FailedValidation" && s.Spec.Template.includes(target.Namespace) {
+				ns.Schedules = append(ns.Schedules, s.Metadata.Name)
+			}
+		}
+		ns.RPOViolated = ns.LastBackup.IsZero() || now.Sub(ns.LastBackup) > ns.RPO
+		status.Namespaces = append(status.Namespaces, ns)
+	}
+	sort.Slice(status.Namespaces, func(i, j int) bool { return status.Namespaces[i].Namespace < status.Namespaces[j].Namespace })
+
+	if len(partial.Errors) > 0 {
+		return partial
+	}
+	return nil
+}
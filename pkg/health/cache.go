@@ -0,0 +1,78 @@
+package health
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CheckTTLs lets expensive sections run at their own, longer intervals: a section with a
+// TTL reuses its last result until the result is older than the TTL. Sections without
+// one run on every check. Keyed by section name, e.g. "helm" or "backups".
+var CheckTTLs = map[string]time.Duration{}
+
+// CacheableSections are the sections CheckTTLs applies to
+var CacheableSections = []string{
+	"etcd", "events", "podSecurity", "qos", "idleNamespaces", "helm", "gitops", "pipelines", "dataServices", "backups",
+	"reservations",
+}
+
+// sectionCache holds the last result of each section with a TTL
+var sectionCache = struct {
+	sync.Mutex
+	entries map[string]cachedSection
+}{entries: make(map[string]cachedSection)}
+
+// cachedSection is a section's result and when it was collected
+type cachedSection struct {
+	value interface{}
+	err   error
+	at    time.Time
+}
+
+// ParseCheckTTLs parses comma-separated section=duration pairs, e.g. "helm=15m,backups=1h"
+func ParseCheckTTLs(value string) (map[string]time.Duration, error) {
+	ttls := make(map[string]time.Duration)
+	for _, pair := range strings.Split(value, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		name, duration, ok := strings.Cut(pair, "=")
+		if !ok || !slices.Contains(CacheableSections, name) {
+			return nil, fmt.Errorf("invalid check TTL %q: want <section>=<duration> for one of %s", pair, strings.Join(CacheableSections, ", "))
+		}
+		ttl, err := time.ParseDuration(duration)
+		if err != nil || ttl < 0 {
+			return nil, fmt.Errorf("invalid check TTL %q: %v", pair, err)
+		}
+		ttls[name] = ttl
+	}
+	return ttls, nil
+}
+
+// cachedCheck runs a section's check into out, or replays the section's last result and
+// error while it is younger than the section's TTL. The time the result was collected is
+// recorded for the section's status.
+func cachedCheck[T any](health *ClusterHealth, name string, out *T, check func(*T) error) error {
+	ttl := CheckTTLs[name]
+	if ttl <= 0 {
+		return check(out)
+	}
+
+	sectionCache.Lock()
+	entry, ok := sectionCache.entries[name]
+	sectionCache.Unlock()
+	if ok && health.Timestamp.Sub(entry.at) < ttl {
+		*out = entry.value.(T)
+		health.collectedAt[name] = entry.at
+		return entry.err
+	}
+
+	err := check(out)
+	sectionCache.Lock()
+	sectionCache.entries[name] = cachedSection{value: *out, err: err, at: health.Timestamp}
+	sectionCache.Unlock()
+	return err
+}
@@ -0,0 +1,189 @@
+package health
+
+import (
+	"fmt"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/snapshot"
+)
+
+// Correlate groups related issues under a single root cause so an incident is reported
+// once: issues on a NotReady node become children of the node's issue, pods of one
+// workload failing the same way are grouped under the workload, and services whose
+// backing pods are affected join the group of those pods. Without a snapshot only issues
+// that already know their node are grouped.
+func Correlate(issues []HealthIssue, snap *snapshot.ClusterSnapshot) []HealthIssue {
+	pods := make(map[string]*v1.Pod)
+	if snap != nil {
+		for i := range snap.Pods {
+			pod := &snap.Pods[i]
+			pods[pod.Namespace+"/"+pod.Name] = pod
+		}
+	}
+	for i := range issues {
+		if pod := issuePod(issues[i], pods); pod != nil && issues[i].Node == "" {
+			issues[i].Node = pod.Spec.NodeName
+		}
+	}
+
+	// NotReady nodes are root causes for everything running on them
+	roots := make([]HealthIssue, 0, len(issues))
+	nodeRoots := make(map[string]int)
+	for _, issue := range issues {
+		if issue.Type == IssueNodeNotReady {
+			nodeRoots[issue.Name] = len(roots)
+			roots = append(roots, issue)
+		}
+	}
+
+	var services, rest []HealthIssue
+	for _, issue := range issues {
+		if issue.Type == IssueNodeNotReady {
+			continue
+		}
+		node := issue.Node
+		if issue.Resource == "Node" {
+			node = issue.Name
+		}
+		if i, ok := nodeRoots[node]; ok && node != "" {
+			roots[i].Children = append(roots[i].Children, issue)
+			continue
+		}
+		if issue.Resource == "Service" {
+			services = append(services, issue)
+			continue
+		}
+		rest = append(rest, issue)
+	}
+
+	// Pods of one workload failing the same way share a root
+	type group struct {
+		kind, namespace, name string
+		members               []HealthIssue
+	}
+	podRoots := make(map[string]int) // namespace/pod -> index in roots
+	groups := make(map[string]*group)
+	var order []*group
+	for _, issue := range rest {
+		pod := issuePod(issue, pods)
+		if pod == nil {
+			roots = append(roots, issue)
+			continue
+		}
+		kind, name := snapshot.WorkloadOwner(pod)
+		if kind == "Pod" {
+			roots = append(roots, issue)
+			continue
+		}
+		key := fmt.Sprintf("%s/%s/%s/%s", kind, pod.Namespace, name, issue.Type)
+		g, ok := groups[key]
+		if !ok {
+			g = &group{kind: kind, namespace: pod.Namespace, name: name}
+			groups[key] = g
+			order = append(order, g)
+		}
+		g.members = append(g.members, issue)
+	}
+	for _, g := range order {
+		members := g.members
+		if len(members) == 1 {
+			if pod := issuePod(members[0], pods); pod != nil {
+				podRoots[pod.Namespace+"/"+pod.Name] = len(roots)
+			}
+			roots = append(roots, members[0])
+			continue
+		}
+
+		root := workloadRoot(g.kind, g.namespace, g.name, members)
+		for _, member := range members {
+			if pod := issuePod(member, pods); pod != nil {
+				podRoots[pod.Namespace+"/"+pod.Name] = len(roots)
+			}
+		}
+		roots = append(roots, root)
+	}
+
+	// Services are degraded by the pods behind them
+	for _, issue := range services {
+		if i, ok := serviceRoot(issue, snap, nodeRoots, podRoots); ok {
+			roots[i].Children = append(roots[i].Children, issue)
+			continue
+		}
+		roots = append(roots, issue)
+	}
+
+	for i := range roots {
+		sortIssues(roots[i].Children)
+	}
+	sortIssues(roots)
+	return roots
+}
+
+// workloadRoot creates the root issue for pods of one workload with the same issue type
+func workloadRoot(kind, namespace, name string, members []HealthIssue) HealthIssue {
+	root := HealthIssue{
+		Type:       members[0].Type,
+		Severity:   members[0].Severity,
+		Resource:   kind,
+		Namespace:  namespace,
+		Name:       name,
+		Message:    fmt.Sprintf("%d pods report %s", len(members), members[0].Type),
+		Timestamp:  members[0].Timestamp,
+		Suggestion: members[0].Suggestion,
+		Children:   members,
+	}
+	for _, m := range members[1:] {
+		if SeverityRank(m.Severity) < SeverityRank(root.Severity) {
+			root.Severity = m.Severity
+		}
+		if m.Timestamp.Before(root.Timestamp) {
+			root.Timestamp = m.Timestamp
+		}
+	}
+	Suggest(&root)
+	return root
+}
+
+// serviceRoot finds the root whose pods back a service: a NotReady node running one of
+// them, or the group of one of its pods
+func serviceRoot(issue HealthIssue, snap *snapshot.ClusterSnapshot, nodeRoots, podRoots map[string]int) (int, bool) {
+	if snap == nil {
+		return 0, false
+	}
+	for _, svc := range snap.Services {
+		if svc.Namespace != issue.Namespace || svc.Name != issue.Name || len(svc.Spec.Selector) == 0 {
+			continue
+		}
+		for _, pod := range snap.Select(svc.Namespace, labels.SelectorFromSet(svc.Spec.Selector)) {
+			if i, ok := nodeRoots[pod.Spec.NodeName]; ok {
+				return i, true
+			}
+			if i, ok := podRoots[pod.Namespace+"/"+pod.Name]; ok {
+				return i, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// issuePod returns the pod an issue is about. Container issues are named
+// "<pod>/<container>" and event issues "<kind>/<name>/<reason>".
+func issuePod(issue HealthIssue, pods map[string]*v1.Pod) *v1.Pod {
+	var name string
+	switch issue.Resource {
+	case "Pod":
+		name, _, _ = strings.Cut(issue.Name, "/")
+	case "Event":
+		kind, rest, _ := strings.Cut(issue.Name, "/")
+		if kind != "Pod" {
+			return nil
+		}
+		name, _, _ = strings.Cut(rest, "/")
+	default:
+		return nil
+	}
+	return pods[issue.Namespace+"/"+name]
+}
@@ -0,0 +1,262 @@
+package health
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/snapshot"
+)
+
+// CriticalDaemonSet names a DaemonSet that must run on every node it is eligible for. An
+// empty namespace matches any namespace, and a "*" at the end or start of the name matches
+// a prefix or suffix.
+type CriticalDaemonSet struct {
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	Severity  string `json:"severity,omitempty"` // defaults to "warning"
+}
+
+// matches reports whether the entry covers a DaemonSet
+func (c CriticalDaemonSet) matches(ds *appsv1.DaemonSet) bool {
+	if c.Namespace != "" && c.Namespace != ds.Namespace {
+		return false
+	}
+	if prefix, ok := strings.CutSuffix(c.Name, "*"); ok {
+		return strings.HasPrefix(ds.Name, prefix)
+	}
+	if suffix, ok := strings.CutPrefix(c.Name, "*"); ok {
+		return strings.HasSuffix(ds.Name, suffix)
+	}
+	return c.Name == ds.Name
+}
+
+// CriticalDaemonSets are the DaemonSets whose coverage is checked. Entries for DaemonSets
+// that do not exist in the cluster are ignored, so the defaults cover the common CNIs and
+// agents without requiring all of them.
+var CriticalDaemonSets = []CriticalDaemonSet{
+	{Namespace: "kube-system", Name: "kube-proxy", Severity: "critical"},
+	{Namespace: "kube-system", Name: "kube-proxy-windows", Severity: "critical"},
+	{Name: "calico-node", Severity: "critical"},
+	{Name: "calico-node-windows", Severity: "critical"},
+	{Name: "cilium", Severity: "critical"},
+	{Namespace: "kube-system", Name: "aws-node", Severity: "critical"},
+	{Name: "kube-flannel-ds", Severity: "critical"},
+	{Namespace: "kube-system", Name: "weave-net", Severity: "critical"},
+	{Name: "fluent-bit*"},
+	{Name: "fluentd*"},
+	{Name: "node-exporter*"},
+	{Name: "*-node-exporter"},
+}
+
+// LoadCriticalDaemonSets reads a file of the form {"daemonsets": [...]}
+func LoadCriticalDaemonSets(path string) ([]CriticalDaemonSet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read critical DaemonSets: %w", err)
+	}
+
+	var file struct {
+		DaemonSets []CriticalDaemonSet `json:"daemonsets"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse critical DaemonSets: %w", err)
+	}
+	for i, c := range file.DaemonSets {
+		if c.Name == "" {
+			return nil, fmt.Errorf("critical DaemonSet %d must have a name", i)
+		}
+	}
+	return file.DaemonSets, nil
+}
+
+// DaemonSetCoverage is how many eligible nodes run a ready pod of a critical DaemonSet
+type DaemonSetCoverage struct {
+	Namespace     string   `json:"namespace"`
+	Name          string   `json:"name"`
+	Severity      string   `json:"severity"`
+	EligibleNodes int      `json:"eligibleNodes"`
+	CoveredNodes  int      `json:"coveredNodes"`
+	MissingNodes  []string `json:"missingNodes,omitempty"`
+}
+
+// CheckDaemonSets finds the nodes each critical DaemonSet should run on, from its node
+// selector, required node affinity and tolerations, and lists those without a ready pod.
+// A DaemonSet that selects no operating system is taken to run Linux images, so Windows
+// nodes are not counted against it.
+func CheckDaemonSets(snap *snapshot.ClusterSnapshot) ([]DaemonSetCoverage, error) {
+	if err := snap.Errors["daemonsets"]; err != nil {
+		return nil, err
+	}
+
+	coverage := make([]DaemonSetCoverage, 0)
+	for i := range snap.DaemonSets {
+		ds := &snap.DaemonSets[i]
+		entry, critical := criticalDaemonSet(ds)
+		if !critical {
+			continue
+		}
+
+		// Nodes with a ready pod owned by the DaemonSet
+		covered := make(map[string]bool)
+		for _, pod := range snap.Select(ds.Namespace, nil) {
+			if !ownedBy(pod, ds) || pod.Spec.NodeName == "" || !podReady(pod) {
+				continue
+			}
+			covered[pod.Spec.NodeName] = true
+		}
+
+		c := DaemonSetCoverage{Namespace: ds.Namespace, Name: ds.Name, Severity: entry.Severity}
+		if c.Severity == "" {
+			c.Severity = "warning"
+		}
+		for j := range snap.Nodes {
+			node := &snap.Nodes[j]
+			if !NodeEligible(&ds.Spec.Template.Spec, node) || snapshot.IsVirtualNode(node) {
+				continue
+			}
+			if snapshot.PodOS(&ds.Spec.Template.Spec) == "" && snapshot.NodeOS(node) != snapshot.OSLinux {
+				continue
+			}
+			c.EligibleNodes++
+			if covered[node.Name] {
+				c.CoveredNodes++
+			} else {
+				c.MissingNodes = append(c.MissingNodes, node.Name)
+			}
+		}
+		sort.Strings(c.MissingNodes)
+		coverage = append(coverage, c)
+	}
+
+	sort.Slice(coverage, func(i, j int) bool {
+		return coverage[i].Namespace+"/"+coverage[i].Name < coverage[j].Namespace+"/"+coverage[j].Name
+	})
+	return coverage, nil
+}
+
+// criticalDaemonSet returns the CriticalDaemonSets entry covering a DaemonSet
+func criticalDaemonSet(ds *appsv1.DaemonSet) (CriticalDaemonSet, bool) {
+	for _, c := range CriticalDaemonSets {
+		if c.matches(ds) {
+			return c, true
+		}
+	}
+	return CriticalDaemonSet{}, false
+}
+
+// ownedBy reports whether a pod is controlled by the DaemonSet
+func ownedBy(pod *v1.Pod, ds *appsv1.DaemonSet) bool {
+	for _, ref := range pod.OwnerReferences {
+		if ref.Kind == "DaemonSet" && (ref.UID == ds.UID || (ds.UID == "" && ref.Name == ds.Name)) {
+			return true
+		}
+	}
+	return false
+}
+
+// podReady reports whether a pod is running with its Ready condition true
+func podReady(pod *v1.Pod) bool {
+	if pod.Status.Phase != v1.PodRunning {
+		return false
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == v1.PodReady {
+			return condition.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// NodeEligible reports whether a pod template may run on a node, by its node name, node
+// selector, required node affinity and tolerations. Taints under node.kubernetes.io/ are
+// ignored since the DaemonSet controller tolerates them and they mark nodes that are not
+// ready or schedulable, which callers check themselves.
+func NodeEligible(spec *v1.PodSpec, node *v1.Node) bool {
+	if len(spec.NodeSelector) > 0 && !labels.SelectorFromSet(spec.NodeSelector).Matches(labels.Set(node.Labels)) {
+		return false
+	}
+	if spec.NodeName != "" && spec.NodeName != node.Name {
+		return false
+	}
+	if affinity := spec.Affinity; affinity != nil && affinity.NodeAffinity != nil {
+		if required := affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution; required != nil &&
+			!matchesNodeSelectorTerms(required.NodeSelectorTerms, node) {
+			return false
+		}
+	}
+	for _, taint := range node.Spec.Taints {
+		if taint.Effect == v1.TaintEffectPreferNoSchedule || strings.HasPrefix(taint.Key, "node.kubernetes.io/") {
+			continue
+		}
+		if !toleratesTaint(spec.Tolerations, taint) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesNodeSelectorTerms reports whether a node matches any of the terms, each term
+// requiring all of its label expressions and field expressions
+func matchesNodeSelectorTerms(terms []v1.NodeSelectorTerm, node *v1.Node) bool {
+	for _, term := range terms {
+		if len(term.MatchExpressions) == 0 && len(term.MatchFields) == 0 {
+			continue
+		}
+		matched := true
+		for _, req := range term.MatchExpressions {
+			value, exists := node.Labels[req.Key]
+			if !matchesRequirement(req, value, exists) {
+				matched = false
+				break
+			}
+		}
+		for _, req := range term.MatchFields {
+			if req.Key == "metadata.name" && !matchesRequirement(req, node.Name, true) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesRequirement evaluates one node selector requirement against a label value
+func matchesRequirement(req v1.NodeSelectorRequirement, value string, exists bool) bool {
+	switch req.Operator {
+	case v1.NodeSelectorOpIn:
+		return exists && slices.Contains(req.Values, value)
+	case v1.NodeSelectorOpNotIn:
+		return !exists || !slices.Contains(req.Values, value)
+	case v1.NodeSelectorOpExists:
+		return exists
+	case v1.NodeSelectorOpDoesNotExist:
+		return !exists
+	case v1.NodeSelectorOpGt, v1.NodeSelectorOpLt:
+		if !exists || len(req.Values) != 1 {
+			return false
+		}
+		have, err1 := strconv.ParseInt(value, 10, 64)
+		want, err2 := strconv.ParseInt(req.Values[0], 10, 64)
+		if err1 != nil || err2 != nil {
+			return false
+		}
+		if req.Operator == v1.NodeSelectorOpGt {
+			return have > want
+		}
+		return have < want
+	default:
+		return false
+	}
+}
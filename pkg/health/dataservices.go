@@ -0,0 +1,304 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/snapshot"
+)
+
+// DataServicesEnabled turns on reading the clusters of the supported database operators.
+// Operators that are not installed are skipped.
+var DataServicesEnabled bool
+
+// Thresholds of the data services check
+var (
+	ReplicationLagWarn = 30 * time.Second // replicas further behind their primary are reported
+	BackupMaxAge       = 26 * time.Hour   // a daily backup plus slack
+)
+
+// Database operators
+const (
+	OperatorCloudNativePG = "cloudnative-pg"
+	OperatorPerconaXtraDB = "percona-xtradb"
+	OperatorRedis         = "redis"
+)
+
+// cnpgMetricsPort is the port of the CloudNativePG instance metrics exporter
+const cnpgMetricsPort = "9187"
+
+// DataServicesStatus reports the health of operator-managed databases
+type DataServicesStatus struct {
+	Databases []DatabaseCluster `json:"databases,omitempty"`
+}
+
+// DatabaseCluster is one database cluster as its operator reports it
+type DatabaseCluster struct {
+	Operator         string        `json:"operator"`
+	Kind             string        `json:"kind"`
+	Namespace        string        `json:"namespace"`
+	Name             string        `json:"name"`
+	State            string        `json:"state,omitempty"` // the operator's own phase or state
+	Healthy          bool          `json:"healthy"`
+	Instances        int           `json:"instances"`
+	ReadyInstances   int           `json:"readyInstances"`
+	Primary          string        `json:"primary,omitempty"`
+	FailingOver      bool          `json:"failingOver"`
+	ReplicationLag   time.Duration `json:"replicationLag,omitempty"` // the furthest replica behind, where it can be read
+	BackupConfigured bool          `json:"backupConfigured"`
+	LastBackup       time.Time     `json:"lastBackup,omitempty"`
+	Message          string        `json:"message,omitempty"`
+}
+
+// BackupAge returns how old the last backup is at now, or 0 if there never was one
+func (d DatabaseCluster) BackupAge(now time.Time) time.Duration {
+	if d.LastBackup.IsZero() {
+		return 0
+	}
+	return now.Sub(d.LastBackup)
+}
+
+// cnpgCluster is the part of a CloudNativePG Cluster the check reads
+type cnpgCluster struct {
+	Metadata struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	} `json:"metadata"`
+	Spec struct {
+		Instances int              `json:"instances"`
+		Backup    *json.RawMessage `json:"backup"`
+	} `json:"spec"`
+	Status struct {
+		Phase                string    `json:"phase"`
+		PhaseReason          string    `json:"phaseReason"`
+		ReadyInstances       int       `json:"readyInstances"`
+		CurrentPrimary       string    `json:"currentPrimary"`
+		TargetPrimary        string    `json:"targetPrimary"`
+		LastSuccessfulBackup time.Time `json:"lastSuccessfulBackup"`
+	} `json:"status"`
+}
+
+// pxcCluster is the part of a PerconaXtraDBCluster the check reads
+type pxcCluster struct {
+	Metadata struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	} `json:"metadata"`
+	Spec struct {
+		Backup *struct {
+			Schedule []struct {
+				Name string `json:"name"`
+			} `json:"schedule"`
+		} `json:"backup"`
+	} `json:"spec"`
+	Status struct {
+		State    string   `json:"state"`
+		Messages []string `json:"message"`
+		PXC      struct {
+			Size  int `json:"size"`
+			Ready int `json:"ready"`
+		} `json:"pxc"`
+	} `json:"status"`
+}
+
+// pxcBackup is the part of a PerconaXtraDBClusterBackup the check reads
+type pxcBackup struct {
+	Metadata struct {
+		Namespace string `json:"namespace"`
+	} `json:"metadata"`
+	Spec struct {
+		Cluster string `json:"pxcCluster"`
+	} `json:"spec"`
+	Status struct {
+		State     string    `json:"state"`
+		Completed time.Time `json:"completed"`
+	} `json:"status"`
+}
+
+// redisCluster is the part of an OT-container-kit RedisCluster the check reads
+type redisCluster struct {
+	Metadata struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	} `json:"metadata"`
+	Spec struct {
+		ClusterSize int `json:"clusterSize"`
+	} `json:"spec"`
+	Status struct {
+		State                 string `json:"state"`
+		Reason                string `json:"reason"`
+		ReadyLeaderReplicas   int    `json:"readyLeaderReplicas"`
+		ReadyFollowerReplicas int    `json:"readyFollowerReplicas"`
+	} `json:"status"`
+}
+
+// checkDataServices reads CloudNativePG, Percona XtraDB and Redis clusters when
+// DataServicesEnabled is set
+func checkDataServices(ctx context.Context, clientset kubernetes.Interface, snap *snapshot.ClusterSnapshot, status *DataServicesStatus) error {
+	if !DataServicesEnabled || clientset == nil {
+		return nil
+	}
+	partial := &PartialError{}
+
+	var cnpg struct {
+		Items []cnpgCluster `json:"items"`
+	}
+	if found, err := listCustomResources(ctx, clientset, "/apis/postgresql.cnpg.io/v1/clusters", &cnpg); err != nil {
+		partial.Errors = append(partial.Errors, fmt.Errorf("failed to list CloudNativePG clusters: %w", err))
+	} else if found {
+		for _, c := range cnpg.Items {
+			db := cnpgStatus(c)
+			db.ReplicationLag = cnpgReplicationLag(ctx, clientset, snap, c.Metadata.Namespace, c.Metadata.Name)
+			status.Databases = append(status.Databases, db)
+		}
+	}
+
+	var pxc struct {
+		Items []pxcCluster `json:"items"`
+	}
+	if found, err := listCustomResources(ctx, clientset, "/apis/pxc.percona.com/v1/perconaxtradbclusters", &pxc); err != nil {
+		partial.Errors = append(partial.Errors, fmt.Errorf("failed to list Percona XtraDB clusters: %w", err))
+	} else if found {
+		var backups struct {
+			Items []pxcBackup `json:"items"`
+		}
+		if _, err := listCustomResources(ctx, clientset, "/apis/pxc.percona.com/v1/perconaxtradbclusterbackups", &backups); err != nil {
+			partial.Errors = append(partial.Errors, fmt.Errorf("failed to list Percona XtraDB backups: %w", err))
+		}
+		lastBackup := make(map[string]time.Time) // namespace/cluster -> latest successful backup
+		for _, b := range backups.Items {
+			key := b.Metadata.Namespace + "/" + b.Spec.Cluster
+			if b.Status.State == "Succeeded" && b.Status.Completed.After(lastBackup[key]) {
+				lastBackup[key] = b.Status.Completed
+			}
+		}
+		for _, c := range pxc.Items {
+			db := pxcStatus(c)
+			db.LastBackup = lastBackup[c.Metadata.Namespace+"/"+c.Metadata.Name]
+			status.Databases = append(status.Databases, db)
+		}
+	}
+
+	var redis struct {
+		Items []redisCluster `json:"items"`
+	}
+	if found, err := listCustomResources(ctx, clientset, "/apis/redis.redis.opstreelabs.in/v1beta2/redisclusters", &redis); err != nil {
+		partial.Errors = append(partial.Errors, fmt.Errorf("failed to list Redis clusters: %w", err))
+	} else if found {
+		for _, c := range redis.Items {
+			status.Databases = append(status.Databases, redisStatus(c))
+		}
+	}
+
+	sort.Slice(status.Databases, func(i, j int) bool {
+		a, b := status.Databases[i], status.Databases[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+
+	if len(partial.Errors) > 0 {
+		return partial
+	}
+	return nil
+}
+
+// cnpgStatus converts a CloudNativePG Cluster. A switchover or failover is in progress while
+// the target primary differs from the current one.
+func cnpgStatus(c cnpgCluster) DatabaseCluster {
+	return DatabaseCluster{
+		Operator:         OperatorCloudNativePG,
+		Kind:             "Cluster",
+		Namespace:        c.Metadata.Namespace,
+		Name:             c.Metadata.Name,
+		State:            c.Status.Phase,
+		Healthy:          c.Status.Phase == "Cluster in healthy state",
+		Instances:        c.Spec.Instances,
+		ReadyInstances:   c.Status.ReadyInstances,
+		Primary:          c.Status.CurrentPrimary,
+		FailingOver:      c.Status.TargetPrimary != "" && c.Status.TargetPrimary != c.Status.CurrentPrimary,
+		BackupConfigured: c.Spec.Backup != nil,
+		LastBackup:       c.Status.LastSuccessfulBackup,
+		Message:          c.Status.PhaseReason,
+	}
+}
+
+// cnpgReplicationLag scrapes cnpg_pg_replication_lag from the exporter of each running
+// replica of a cluster and returns the largest
+func cnpgReplicationLag(ctx context.Context, clientset kubernetes.Interface, snap *snapshot.ClusterSnapshot, namespace, cluster string) time.Duration {
+	var lag time.Duration
+	for _, pod := range snap.Pods {
+		if pod.Namespace != namespace || pod.Labels["cnpg.io/cluster"] != cluster || pod.Status.Phase != v1.PodRunning {
+			continue
+		}
+		if role := pod.Labels["cnpg.io/instanceRole"]; role != "replica" && pod.Labels["role"] != "replica" {
+			continue
+		}
+		data, err := clientset.CoreV1().Pods(pod.Namespace).ProxyGet("http", pod.Name, cnpgMetricsPort, "/metrics", nil).DoRaw(ctx)
+		if err != nil {
+			continue
+		}
+		seconds, err := parseReplicationLag(data)
+		if err != nil {
+			continue
+		}
+		if time.Duration(seconds*float64(time.Second)) > lag {
+			lag = time.Duration(seconds * float64(time.Second))
+		}
+	}
+	return lag
+}
+
+// parseReplicationLag reads cnpg_pg_replication_lag, in seconds, from exporter output
+func parseReplicationLag(data []byte) (float64, error) {
+	samples, err := parseMetrics(data)
+	if err != nil {
+		return 0, err
+	}
+	for _, sample := range samples {
+		if sample.name == "cnpg_pg_replication_lag" {
+			return sample.value, nil
+		}
+	}
+	return 0, nil
+}
+
+// pxcStatus converts a PerconaXtraDBCluster. Its last backup comes from the backup objects.
+func pxcStatus(c pxcCluster) DatabaseCluster {
+	return DatabaseCluster{
+		Operator:         OperatorPerconaXtraDB,
+		Kind:             "PerconaXtraDBCluster",
+		Namespace:        c.Metadata.Namespace,
+		Name:             c.Metadata.Name,
+		State:            c.Status.State,
+		Healthy:          c.Status.State == "ready",
+		Instances:        c.Status.PXC.Size,
+		ReadyInstances:   c.Status.PXC.Ready,
+		BackupConfigured: c.Spec.Backup != nil && len(c.Spec.Backup.Schedule) > 0,
+		Message:          strings.Join(c.Status.Messages, "; "),
+	}
+}
+
+// redisStatus converts a RedisCluster. Redis clusters are caches as often as stores, so no
+// backup is expected.
+func redisStatus(c redisCluster) DatabaseCluster {
+	return DatabaseCluster{
+		Operator:       OperatorRedis,
+		Kind:           "RedisCluster",
+		Namespace:      c.Metadata.Namespace,
+		Name:           c.Metadata.Name,
+		State:          c.Status.State,
+		Healthy:        c.Status.State == "Ready",
+		Instances:      2 * c.Spec.ClusterSize, // a leader and a follower per shard
+		ReadyInstances: c.Status.ReadyLeaderReplicas + c.Status.ReadyFollowerReplicas,
+		Message:        c.Status.Reason,
+	}
+}
@@ -0,0 +1,104 @@
+package health
+
+import (
+	"sort"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/snapshot"
+)
+
+// PodDensityWarn is the fraction of a node's maxPods in use at which the node is reported
+// as nearly full. A node at its maxPods is critical.
+var PodDensityWarn = 0.9
+
+// PodDensityStatus compares the pods on each node with the kubelet's maxPods, and maxPods
+// with what the CNI can address
+type PodDensityStatus struct {
+	Nodes        []NodePodDensity `json:"nodes,omitempty"`        // nodes nearly full or with maxPods above their IPs
+	Rejected     int              `json:"rejected"`               // pending pods the scheduler rejected for pod count
+	RejectedPods []string         `json:"rejectedPods,omitempty"` // "<namespace>/<name>" of up to ten of them
+}
+
+// NodePodDensity is a node's pod count against its pod limits
+type NodePodDensity struct {
+	Node        string  `json:"node"`
+	Pods        int     `json:"pods"`    // scheduled pods that have not finished
+	MaxPods     int     `json:"maxPods"` // allocatable pods, the kubelet's maxPods
+	Usage       float64 `json:"usage"`   // Pods / MaxPods
+	HostNetwork int     `json:"hostNetwork"`
+	IPCapacity  int     `json:"ipCapacity,omitempty"` // pod IPs the CNI can hand out, 0 if unknown
+	IPLimit     string  `json:"ipLimit,omitempty"`    // podCIDR or eni
+}
+
+// ExceedsIPs reports whether the scheduler may place more pods needing an IP on the node
+// than the CNI can address. Those pods are scheduled but never start.
+func (d NodePodDensity) ExceedsIPs() bool {
+	return d.IPCapacity > 0 && d.MaxPods-d.HostNetwork > d.IPCapacity
+}
+
+// rejectedPodsListed caps the pending pods listed in PodDensityStatus
+const rejectedPodsListed = 10
+
+// PodDensity counts the pods on each node against its maxPods and its IP capacity, and the
+// pending pods the scheduler could not place because every node was at its maxPods
+func PodDensity(snap *snapshot.ClusterSnapshot) PodDensityStatus {
+	var status PodDensityStatus
+
+	pods := make(map[string]int, len(snap.Nodes))
+	hostNetwork := make(map[string]int, len(snap.Nodes))
+	for i := range snap.Pods {
+		pod := &snap.Pods[i]
+		if pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+			continue
+		}
+		if pod.Spec.NodeName == "" {
+			if rejectedForPodCount(pod) {
+				status.Rejected++
+				if len(status.RejectedPods) < rejectedPodsListed {
+					status.RejectedPods = append(status.RejectedPods, pod.Namespace+"/"+pod.Name)
+				}
+			}
+			continue
+		}
+		pods[pod.Spec.NodeName]++
+		if pod.Spec.HostNetwork {
+			hostNetwork[pod.Spec.NodeName]++
+		}
+	}
+
+	for i := range snap.Nodes {
+		node := &snap.Nodes[i]
+		maxPods := int(node.Status.Allocatable.Pods().Value())
+		if maxPods == 0 || snapshot.IsVirtualNode(node) {
+			continue
+		}
+		d := NodePodDensity{
+			Node:        node.Name,
+			Pods:        pods[node.Name],
+			MaxPods:     maxPods,
+			Usage:       float64(pods[node.Name]) / float64(maxPods),
+			HostNetwork: hostNetwork[node.Name],
+		}
+		d.IPCapacity, d.IPLimit = nodeIPCapacity(node)
+		if d.Usage >= PodDensityWarn || d.ExceedsIPs() {
+			status.Nodes = append(status.Nodes, d)
+		}
+	}
+
+	sort.Slice(status.Nodes, func(i, j int) bool { return status.Nodes[i].Usage > status.Nodes[j].Usage })
+	return status
+}
+
+// rejectedForPodCount reports whether the scheduler rejected a pod because nodes were at
+// their maxPods, which it reports as "Too many pods"
+func rejectedForPodCount(pod *v1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == v1.PodScheduled && condition.Status == v1.ConditionFalse &&
+			condition.Reason == v1.PodReasonUnschedulable && strings.Contains(condition.Message, "Too many pods") {
+			return true
+		}
+	}
+	return false
+}
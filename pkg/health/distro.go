@@ -0,0 +1,164 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Lightweight distributions. k3s and k0s run the control plane as one process and
+// MicroK8s as host services; minikube and kind run it as static pods like kubeadm.
+const (
+	DistroK3s      = "k3s"
+	DistroK0s      = "k0s"
+	DistroMicroK8s = "microk8s"
+	DistroMinikube = "minikube"
+	DistroKind     = "kind"
+)
+
+// embeddedServices are the host services of the distributions that run the scheduler,
+// controller manager and etcd without pods in kube-system
+var embeddedServices = map[string]string{
+	DistroK3s:      "k3s",
+	DistroK0s:      "k0scontroller",
+	DistroMicroK8s: "snap.microk8s.daemon-kubelite",
+}
+
+// DetectDistribution names the lightweight distribution the nodes run, from the kubelet
+// version suffix k3s and k0s add, the labels minikube and MicroK8s set, or kind's provider
+// ID. It is empty for other clusters.
+func DetectDistribution(nodes []v1.Node) string {
+	for i := range nodes {
+		node := &nodes[i]
+		version := node.Status.NodeInfo.KubeletVersion
+		switch {
+		case strings.Contains(version, "+k3s"):
+			return DistroK3s
+		case strings.Contains(version, "+k0s"):
+			return DistroK0s
+		case node.Labels["microk8s.io/cluster"] != "":
+			return DistroMicroK8s
+		case node.Labels["minikube.k8s.io/name"] != "":
+			return DistroMinikube
+		case strings.HasPrefix(node.Spec.ProviderID, "kind://"):
+			return DistroKind
+		}
+	}
+	return ""
+}
+
+// ControlPlaneProbe is how a control plane component without a pod was checked
+type ControlPlaneProbe struct {
+	Component string `json:"component"`
+	Method    string `json:"method,omitempty"` // "readyz", "healthz" or "lease"; empty when it could not be checked
+	Healthy   bool   `json:"healthy"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Ports the scheduler and controller manager serve /healthz on
+const (
+	schedulerPort  = 10259
+	controllerPort = 10257
+)
+
+// probeEmbeddedControlPlane checks an embedded control plane through its endpoints: etcd,
+// or the kine datastore k3s puts in its place, through the apiserver's verbose /readyz, and
+// the scheduler and controller manager through their /healthz on the control plane nodes,
+// falling back to how recently they renewed their leader lease. Components that cannot be
+// checked either way are left healthy rather than reported on every cycle.
+func probeEmbeddedControlPlane(ctx context.Context, clientset kubernetes.Interface, nodes []v1.Node, status *ControlPlaneStatus) {
+	etcd := probeEtcdReadyz(ctx, clientset)
+	status.EtcdHealthy = etcd.Healthy
+	status.Probes = append(status.Probes, etcd)
+
+	var controlPlane []string
+	for i := range nodes {
+		node := &nodes[i]
+		if _, ok := node.Labels["node-role.kubernetes.io/control-plane"]; ok {
+			controlPlane = append(controlPlane, node.Name)
+		} else if _, ok := node.Labels["node-role.kubernetes.io/master"]; ok {
+			controlPlane = append(controlPlane, node.Name)
+		}
+	}
+	if len(controlPlane) == 0 && len(nodes) == 1 {
+		controlPlane = []string{nodes[0].Name} // single-node clusters, e.g. MicroK8s
+	}
+
+	scheduler := probeComponent(ctx, clientset, "kube-scheduler", schedulerPort, controlPlane)
+	status.SchedulerHealthy = scheduler.Healthy
+	controller := probeComponent(ctx, clientset, "kube-controller-manager", controllerPort, controlPlane)
+	status.ControllerHealthy = controller.Healthy
+	status.Probes = append(status.Probes, scheduler, controller)
+}
+
+// probeEtcdReadyz reads the etcd check from the apiserver's verbose readiness report, which
+// lists each check as "[+]etcd ok" or "[-]etcd failed"
+func probeEtcdReadyz(ctx context.Context, clientset kubernetes.Interface) ControlPlaneProbe {
+	probe := ControlPlaneProbe{Component: "etcd", Healthy: true}
+	probeCtx, cancel := context.WithTimeout(ctx, apiProbeTimeout)
+	defer cancel()
+	// A failing readiness check answers 500 with the report as its body
+	data, err := clientset.CoreV1().RESTClient().Get().AbsPath("/readyz").Param("verbose", "").DoRaw(probeCtx)
+	for _, line := range strings.Split(string(data), "\n") {
+		switch {
+		case strings.HasPrefix(line, "[+]etcd "):
+			probe.Method = "readyz"
+			return probe
+		case strings.HasPrefix(line, "[-]etcd "):
+			probe.Method, probe.Healthy, probe.Error = "readyz", false, strings.TrimSpace(line)
+			return probe
+		}
+	}
+	if err != nil {
+		probe.Error = fmt.Sprintf("failed to read apiserver readiness: %v", err)
+	} else {
+		probe.Error = "apiserver readiness reports no etcd check"
+	}
+	return probe
+}
+
+// probeComponent asks a component's /healthz on each control plane node through the
+// apiserver's node proxy, and failing that reads its leader lease in kube-system. k3s binds
+// the endpoints to localhost, where only the lease shows the component is alive.
+func probeComponent(ctx context.Context, clientset kubernetes.Interface, component string, port int, nodes []string) ControlPlaneProbe {
+	probe := ControlPlaneProbe{Component: component, Healthy: true}
+	var errs []string
+	for _, node := range nodes {
+		probeCtx, cancel := context.WithTimeout(ctx, apiProbeTimeout)
+		data, err := clientset.CoreV1().RESTClient().Get().
+			AbsPath("/api/v1/nodes", fmt.Sprintf("https:%s:%d", node, port), "proxy", "healthz").DoRaw(probeCtx)
+		cancel()
+		if err == nil && strings.TrimSpace(string(data)) == "ok" {
+			probe.Method = "healthz"
+			return probe
+		}
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", node, err))
+		}
+	}
+
+	lease, err := clientset.CoordinationV1().Leases("kube-system").Get(ctx, component, metav1.GetOptions{})
+	if err != nil {
+		reason := "no control plane node to probe"
+		if len(errs) > 0 {
+			reason = "no healthz answer from " + strings.Join(errs, "; ")
+		}
+		probe.Error = fmt.Sprintf("%s, and no leader lease: %v", reason, err)
+		return probe
+	}
+	probe.Method = "lease"
+	duration := 15 * time.Second // the leader election default
+	if lease.Spec.LeaseDurationSeconds != nil && *lease.Spec.LeaseDurationSeconds > 0 {
+		duration = time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second
+	}
+	if lease.Spec.RenewTime == nil || time.Since(lease.Spec.RenewTime.Time) > duration {
+		probe.Healthy = false
+		probe.Error = fmt.Sprintf("leader lease not renewed within %s", duration)
+	}
+	return probe
+}
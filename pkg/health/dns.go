@@ -0,0 +1,165 @@
+package health
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+// DNSMetrics summarizes CoreDNS metrics across its pods since the previous check, or since
+// the pods started on the first check
+type DNSMetrics struct {
+	MetricsVisible bool          `json:"metricsVisible"`
+	Responses      float64       `json:"responses"`
+	ServfailRate   float64       `json:"servfailRate"`   // fraction of responses
+	CacheHitRatio  float64       `json:"cacheHitRatio"`  // fraction of cache lookups
+	ForwardLatency time.Duration `json:"forwardLatency"` // average upstream request time
+	Panics         int           `json:"panics"`
+}
+
+// Thresholds at which CoreDNS metrics raise issues
+var (
+	DNSServfailWarning       = 0.05
+	DNSServfailCritical      = 0.25
+	DNSForwardLatencyWarning = 500 * time.Millisecond
+	DNSMinResponses          = 100.0 // responses below which SERVFAIL rates are not judged
+)
+
+// corednsMetricsPort is the prometheus plugin's default port
+const corednsMetricsPort = "9153"
+
+// dnsCounters are CoreDNS's cumulative counters for one pod
+type dnsCounters struct {
+	responses, servfails     float64
+	cacheHits, cacheMisses   float64
+	forwardSum, forwardCount float64
+	panics                   float64
+}
+
+// sub returns the counters accumulated since previous, or all of them if the pod restarted
+func (c dnsCounters) sub(previous dnsCounters) dnsCounters {
+	if c.responses < previous.responses || c.panics < previous.panics {
+		return c
+	}
+	return dnsCounters{
+		responses:    c.responses - previous.responses,
+		servfails:    c.servfails - previous.servfails,
+		cacheHits:    c.cacheHits - previous.cacheHits,
+		cacheMisses:  c.cacheMisses - previous.cacheMisses,
+		forwardSum:   c.forwardSum - previous.forwardSum,
+		forwardCount: c.forwardCount - previous.forwardCount,
+		panics:       c.panics - previous.panics,
+	}
+}
+
+// lastDNSCounters holds each CoreDNS pod's counters from the previous check, so rates
+// reflect recent traffic rather than the pod's whole lifetime
+var lastDNSCounters = struct {
+	sync.Mutex
+	pods map[types.UID]dnsCounters
+}{pods: make(map[types.UID]dnsCounters)}
+
+// checkDNSMetrics scrapes each running CoreDNS pod's metrics through the apiserver's pod
+// proxy and combines the change in its counters since the previous check
+func checkDNSMetrics(ctx context.Context, clientset kubernetes.Interface, pods []*v1.Pod, metrics *DNSMetrics) {
+	if clientset == nil {
+		return
+	}
+
+	lastDNSCounters.Lock()
+	defer lastDNSCounters.Unlock()
+
+	var total dnsCounters
+	seen := make(map[types.UID]bool, len(pods))
+	for _, pod := range pods {
+		if pod.Status.Phase != v1.PodRunning {
+			continue
+		}
+		data, err := clientset.CoreV1().Pods(pod.Namespace).ProxyGet("http", pod.Name, corednsPort(pod), "/metrics", nil).DoRaw(ctx)
+		if err != nil {
+			continue
+		}
+		current, err := parseDNSMetrics(data)
+		if err != nil {
+			continue
+		}
+		metrics.MetricsVisible = true
+		seen[pod.UID] = true
+
+		delta := current.sub(lastDNSCounters.pods[pod.UID])
+		lastDNSCounters.pods[pod.UID] = current
+
+		total.responses += delta.responses
+		total.servfails += delta.servfails
+		total.cacheHits += delta.cacheHits
+		total.cacheMisses += delta.cacheMisses
+		total.forwardSum += delta.forwardSum
+		total.forwardCount += delta.forwardCount
+		total.panics += delta.panics
+	}
+	for uid := range lastDNSCounters.pods {
+		if !seen[uid] {
+			delete(lastDNSCounters.pods, uid)
+		}
+	}
+
+	metrics.Responses = total.responses
+	metrics.Panics = int(total.panics)
+	if total.responses > 0 {
+		metrics.ServfailRate = total.servfails / total.responses
+	}
+	if lookups := total.cacheHits + total.cacheMisses; lookups > 0 {
+		metrics.CacheHitRatio = total.cacheHits / lookups
+	}
+	if total.forwardCount > 0 {
+		metrics.ForwardLatency = time.Duration(total.forwardSum / total.forwardCount * float64(time.Second))
+	}
+}
+
+// corednsPort returns the pod's metrics container port, or the prometheus plugin default
+func corednsPort(pod *v1.Pod) string {
+	for _, c := range pod.Spec.Containers {
+		for _, port := range c.Ports {
+			if port.Name == "metrics" {
+				return strconv.Itoa(int(port.ContainerPort))
+			}
+		}
+	}
+	return corednsMetricsPort
+}
+
+// parseDNSMetrics reads CoreDNS's response, cache, forward and panic counters. Response
+// counts come from coredns_dns_responses_total, or the pre-1.7 rcode metric.
+func parseDNSMetrics(data []byte) (dnsCounters, error) {
+	var c dnsCounters
+	samples, err := parseMetrics(data)
+	if err != nil {
+		return c, err
+	}
+	for _, sample := range samples {
+		value := sample.value
+		switch sample.name {
+		case "coredns_dns_responses_total", "coredns_dns_response_rcode_count_total":
+			c.responses += value
+			if sample.labels["rcode"] == "SERVFAIL" {
+				c.servfails += value
+			}
+		case "coredns_cache_hits_total":
+			c.cacheHits += value
+		case "coredns_cache_misses_total":
+			c.cacheMisses += value
+		case "coredns_forward_request_duration_seconds_sum":
+			c.forwardSum += value
+		case "coredns_forward_request_duration_seconds_count":
+			c.forwardCount += value
+		case "coredns_panics_total":
+			c.panics += value
+		}
+	}
+	return c, nil
+}
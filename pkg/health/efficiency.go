@@ -0,0 +1,160 @@
+package health
+
+import (
+	"fmt"
+	"math"
+
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/snapshot"
+)
+
+// EfficiencyScore combines how much of their requests pods use with how much of the
+// nodes' allocatable they request. A cluster whose pods use everything they request and
+// request everything the nodes offer scores 100.
+type EfficiencyScore struct {
+	Score            int     `json:"score"`            // 0-100
+	CPUUsage         float64 `json:"cpuUsage"`         // used / requested, usage above a pod's request counting as its request
+	MemoryUsage      float64 `json:"memoryUsage"`      // used / requested
+	CPUAllocation    float64 `json:"cpuAllocation"`    // requested / allocatable
+	MemoryAllocation float64 `json:"memoryAllocation"` // requested / allocatable
+}
+
+// efficiencyTotals accumulates the requests, usage and allocatable of a scope
+type efficiencyTotals struct {
+	cpuRequested, memRequested float64 // of pods with metrics
+	cpuUsed, memUsed           float64
+	cpuAllocated, memAllocated float64 // requests weighted by their node's allocation, for namespaces
+	cpuAll, memAll             float64 // requests of every scheduled pod
+}
+
+// score computes the efficiency from the totals, with allocation given per resource. Only
+// resources that are requested count toward the score.
+func (t efficiencyTotals) score(cpuAllocation, memAllocation float64) EfficiencyScore {
+	e := EfficiencyScore{CPUAllocation: cpuAllocation, MemoryAllocation: memAllocation}
+	var sum float64
+	var resources int
+	if t.cpuRequested > 0 {
+		e.CPUUsage = t.cpuUsed / t.cpuRequested
+		sum += e.CPUUsage * e.CPUAllocation
+		resources++
+	}
+	if t.memRequested > 0 {
+		e.MemoryUsage = t.memUsed / t.memRequested
+		sum += e.MemoryUsage * e.MemoryAllocation
+		resources++
+	}
+	if resources > 0 {
+		e.Score = int(math.Round(100 * sum / float64(resources)))
+	}
+	return e
+}
+
+// ResourceEfficiency scores the cluster and each namespace with pods requesting resources.
+// A namespace's allocation is that of the nodes its pods run on, weighted by its requests.
+// Virtual nodes are sized to their pods' requests, so they are fully allocated. Usage comes
+// from metrics-server, so without pod metrics nothing is scored.
+func ResourceEfficiency(snap *snapshot.ClusterSnapshot) (*EfficiencyScore, map[string]EfficiencyScore, error) {
+	if err := snap.Errors["podMetrics"]; err != nil {
+		return nil, nil, fmt.Errorf("pod metrics unavailable: %w", err)
+	}
+	var cpuAllocatable, memAllocatable float64
+	ready := make(map[string][2]float64, len(snap.Nodes)) // node -> allocatable CPU and memory
+	virtual := make(map[string]bool)
+	for i := range snap.Nodes {
+		node := &snap.Nodes[i]
+		cpu, mem := float64(node.Status.Allocatable.Cpu().MilliValue()), float64(node.Status.Allocatable.Memory().Value())
+		if !nodeReady(node) || cpu == 0 || mem == 0 {
+			continue
+		}
+		if snapshot.IsVirtualNode(node) {
+			virtual[node.Name] = true
+			ready[node.Name] = [2]float64{} // set from its pods' requests below
+			continue
+		}
+		ready[node.Name] = [2]float64{cpu, mem}
+		cpuAllocatable += cpu
+		memAllocatable += mem
+	}
+
+	usage := make(map[string][2]float64, len(snap.PodMetrics))
+	for _, pm := range snap.PodMetrics {
+		var u [2]float64
+		for _, c := range pm.Containers {
+			u[0] += float64(c.Usage.Cpu().MilliValue())
+			u[1] += float64(c.Usage.Memory().Value())
+		}
+		usage[pm.Namespace+"/"+pm.Name] = u
+	}
+
+	// Requests per node, for each node's allocation
+	type podRequest struct {
+		pod      *v1.Pod
+		cpu, mem float64
+	}
+	var requests []podRequest
+	nodeRequests := make(map[string][2]float64)
+	for i := range snap.Pods {
+		pod := &snap.Pods[i]
+		if _, ok := ready[pod.Spec.NodeName]; !ok || pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+			continue
+		}
+		var cpu, mem float64
+		for _, c := range pod.Spec.Containers {
+			cpu += float64(c.Resources.Requests.Cpu().MilliValue())
+			mem += float64(c.Resources.Requests.Memory().Value())
+		}
+		requests = append(requests, podRequest{pod, cpu, mem})
+		r := nodeRequests[pod.Spec.NodeName]
+		nodeRequests[pod.Spec.NodeName] = [2]float64{r[0] + cpu, r[1] + mem}
+	}
+	for name := range virtual {
+		r := nodeRequests[name]
+		ready[name] = [2]float64{math.Max(r[0], 1), math.Max(r[1], 1)}
+		cpuAllocatable += r[0]
+		memAllocatable += r[1]
+	}
+	if cpuAllocatable == 0 || memAllocatable == 0 {
+		return nil, nil, nil
+	}
+
+	var cluster efficiencyTotals
+	namespaces := make(map[string]*efficiencyTotals)
+	for _, r := range requests {
+		t, ok := namespaces[r.pod.Namespace]
+		if !ok {
+			t = &efficiencyTotals{}
+			namespaces[r.pod.Namespace] = t
+		}
+		node, allocatable := nodeRequests[r.pod.Spec.NodeName], ready[r.pod.Spec.NodeName]
+		for _, totals := range []*efficiencyTotals{&cluster, t} {
+			totals.cpuAll += r.cpu
+			totals.memAll += r.mem
+			totals.cpuAllocated += r.cpu * math.Min(node[0]/allocatable[0], 1)
+			totals.memAllocated += r.mem * math.Min(node[1]/allocatable[1], 1)
+			if u, ok := usage[r.pod.Namespace+"/"+r.pod.Name]; ok {
+				totals.cpuRequested += r.cpu
+				totals.memRequested += r.mem
+				totals.cpuUsed += math.Min(u[0], r.cpu)
+				totals.memUsed += math.Min(u[1], r.mem)
+			}
+		}
+	}
+
+	clusterScore := cluster.score(math.Min(cluster.cpuAll/cpuAllocatable, 1), math.Min(cluster.memAll/memAllocatable, 1))
+	scores := make(map[string]EfficiencyScore, len(namespaces))
+	for ns, t := range namespaces {
+		if t.cpuRequested == 0 && t.memRequested == 0 {
+			continue
+		}
+		var cpuAllocation, memAllocation float64
+		if t.cpuAll > 0 {
+			cpuAllocation = t.cpuAllocated / t.cpuAll
+		}
+		if t.memAll > 0 {
+			memAllocation = t.memAllocated / t.memAll
+		}
+		scores[ns] = t.score(cpuAllocation, memAllocation)
+	}
+	return &clusterScore, scores, nil
+}
@@ -0,0 +1,181 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/snapshot"
+)
+
+// EtcdStatus estimates how close etcd is to its scalability limits
+type EtcdStatus struct {
+	ObjectCounts   map[string]int64   `json:"objectCounts"`          // resource -> stored objects
+	DBSizeBytes    int64              `json:"dbSizeBytes,omitempty"` // from apiserver metrics, 0 if unavailable
+	QuotaBytes     int64              `json:"quotaBytes"`            // backend quota the size is compared with
+	MetricsVisible bool               `json:"metricsVisible"`        // apiserver /metrics could be read
+	Pressure       []string           `json:"pressure,omitempty"`    // resources (or "dbSize") near their limit
+	Usage          map[string]float64 `json:"usage,omitempty"`       // resource or "dbSize" -> fraction of its limit
+}
+
+// EtcdObjectLimits are object counts at which a single cluster is known to degrade, based
+// on the Kubernetes scalability thresholds. Resources without a published threshold use
+// conservative values for a default 2GiB etcd.
+var EtcdObjectLimits = map[string]int64{
+	"pods":       150000,
+	"services":   10000,
+	"endpoints":  10000,
+	"namespaces": 10000,
+	"secrets":    50000,
+	"configmaps": 50000,
+	"events":     100000,
+}
+
+// EtcdQuotaBytes is etcd's default backend quota; clusters running with a larger
+// --quota-backend-bytes can raise it
+var EtcdQuotaBytes int64 = 2 << 30
+
+// Fractions of a limit at which etcd pressure is a warning and critical
+const (
+	EtcdWarningRatio  = 0.8
+	EtcdCriticalRatio = 0.95
+)
+
+// etcdSizeMetrics are the apiserver metrics reporting the etcd database size, newest first
+var etcdSizeMetrics = []string{
+	"apiserver_storage_size_bytes",
+	"apiserver_storage_db_total_size_in_bytes",
+	"etcd_db_total_size_in_bytes",
+}
+
+// checkEtcdPressure counts stored objects and reads the database size from the apiserver's
+// metrics when they are reachable. Counts come from apiserver_storage_objects, falling back
+// to the snapshot and single-item lists that report the remaining item count.
+func checkEtcdPressure(ctx context.Context, clientset kubernetes.Interface, apiMetrics *apiServerMetrics, snap *snapshot.ClusterSnapshot, status *EtcdStatus) error {
+	status.ObjectCounts = make(map[string]int64)
+	status.Usage = make(map[string]float64)
+	status.QuotaBytes = EtcdQuotaBytes
+	partial := &PartialError{}
+
+	if clientset != nil {
+		samples, err := apiMetrics.get(ctx)
+		if err == nil {
+			status.MetricsVisible = true
+			status.DBSizeBytes = parseEtcdMetrics(samples, status.ObjectCounts)
+		}
+	}
+
+	// Fill counts the metrics did not provide
+	if _, ok := status.ObjectCounts["pods"]; !ok {
+		status.ObjectCounts["pods"] = int64(len(snap.Pods))
+	}
+	if _, ok := status.ObjectCounts["services"]; !ok && snap.Errors["services"] == nil {
+		status.ObjectCounts["services"] = int64(len(snap.Services))
+	}
+	if _, ok := status.ObjectCounts["endpoints"]; !ok && snap.Errors["endpoints"] == nil {
+		status.ObjectCounts["endpoints"] = int64(len(snap.Endpoints))
+	}
+	if clientset != nil {
+		counters := map[string]func(metav1.ListOptions) (runtime.Object, error){
+			"events": func(opts metav1.ListOptions) (runtime.Object, error) {
+				return clientset.CoreV1().Events("").List(ctx, opts)
+			},
+			"secrets": func(opts metav1.ListOptions) (runtime.Object, error) {
+				return clientset.CoreV1().Secrets("").List(ctx, opts)
+			},
+			"configmaps": func(opts metav1.ListOptions) (runtime.Object, error) {
+				return clientset.CoreV1().ConfigMaps("").List(ctx, opts)
+			},
+			"namespaces": func(opts metav1.ListOptions) (runtime.Object, error) {
+				return clientset.CoreV1().Namespaces().List(ctx, opts)
+			},
+		}
+		for resource, list := range counters {
+			if _, ok := status.ObjectCounts[resource]; ok {
+				continue
+			}
+			count, err := countObjects(list)
+			if err != nil {
+				partial.Errors = append(partial.Errors, fmt.Errorf("failed to count %s: %w", resource, err))
+				continue
+			}
+			status.ObjectCounts[resource] = count
+		}
+	}
+
+	for resource, limit := range EtcdObjectLimits {
+		if count, ok := status.ObjectCounts[resource]; ok && limit > 0 {
+			status.Usage[resource] = float64(count) / float64(limit)
+		}
+	}
+	if status.DBSizeBytes > 0 && status.QuotaBytes > 0 {
+		status.Usage["dbSize"] = float64(status.DBSizeBytes) / float64(status.QuotaBytes)
+	}
+	for name, usage := range status.Usage {
+		if usage >= EtcdWarningRatio {
+			status.Pressure = append(status.Pressure, name)
+		}
+	}
+	sort.Strings(status.Pressure)
+
+	if len(partial.Errors) > 0 {
+		return partial
+	}
+	return nil
+}
+
+// countObjects lists a single item and adds the remaining item count the apiserver reports
+func countObjects(list func(metav1.ListOptions) (runtime.Object, error)) (int64, error) {
+	result, err := list(metav1.ListOptions{Limit: 1})
+	if err != nil {
+		return 0, err
+	}
+	accessor, err := meta.ListAccessor(result)
+	if err != nil {
+		return 0, err
+	}
+	count := int64(meta.LenList(result))
+	if remaining := accessor.GetRemainingItemCount(); remaining != nil {
+		count += *remaining
+	}
+	return count, nil
+}
+
+// parseEtcdMetrics reads apiserver_storage_objects into counts keyed by resource (group
+// suffixes dropped, so "events.events.k8s.io" and "events" are summed) and returns the
+// largest reported database size
+func parseEtcdMetrics(samples []metricSample, counts map[string]int64) int64 {
+	var size int64
+	sizes := make(map[string]int64)
+	for _, sample := range samples {
+		switch {
+		case sample.name == "apiserver_storage_objects":
+			resource := sample.labels["resource"]
+			if i := strings.Index(resource, "."); i >= 0 {
+				resource = resource[:i]
+			}
+			if resource != "" && sample.value >= 0 {
+				counts[resource] += int64(sample.value)
+			}
+		default:
+			for _, metric := range etcdSizeMetrics {
+				if sample.name == metric && int64(sample.value) > sizes[metric] {
+					sizes[metric] = int64(sample.value)
+				}
+			}
+		}
+	}
+	for _, metric := range etcdSizeMetrics {
+		if sizes[metric] > 0 {
+			size = sizes[metric]
+			break
+		}
+	}
+	return size
+}
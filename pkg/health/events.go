@@ -0,0 +1,155 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/snapshot"
+)
+
+// EventStatus summarizes event volume over the last hour
+type EventStatus struct {
+	TotalEvents   int           `json:"totalEvents"`   // event objects stored
+	EventsPerHour float64       `json:"eventsPerHour"` // occurrences in the last hour, counting repeats
+	Storms        []EventStorm  `json:"storms,omitempty"`
+	NoisySources  []EventSource `json:"noisySources,omitempty"`
+}
+
+// EventStorm is one reason repeated by one source for one workload at a high rate
+type EventStorm struct {
+	Reason    string  `json:"reason"`
+	Source    string  `json:"source"` // reporting controller or component
+	Namespace string  `json:"namespace,omitempty"`
+	Kind      string  `json:"kind"` // workload kind, or the involved object's kind
+	Name      string  `json:"name"`
+	PerHour   float64 `json:"perHour"`
+	Message   string  `json:"message,omitempty"` // latest message
+}
+
+// EventSource is a controller or component emitting events at a high rate
+type EventSource struct {
+	Source  string  `json:"source"`
+	PerHour float64 `json:"perHour"`
+}
+
+// EventStormThreshold is the hourly rate of one reason for one workload at which events
+// are reported as a storm; ten times the rate is critical
+var EventStormThreshold = 1000.0
+
+// maxNoisySources limits the noisy sources reported
+const maxNoisySources = 5
+
+// checkEventFloods estimates how often each event occurred in the last hour from its
+// repeat count and first and last timestamps, and groups events by reason, source and the
+// workload owning the involved pod
+func checkEventFloods(ctx context.Context, clientset kubernetes.Interface, snap *snapshot.ClusterSnapshot, status *EventStatus) error {
+	if clientset == nil {
+		return nil
+	}
+	events, err := snapshot.ListEvents(ctx, clientset, "", metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+
+	pods := make(map[string]*v1.Pod, len(snap.Pods))
+	for i := range snap.Pods {
+		pods[snap.Pods[i].Namespace+"/"+snap.Pods[i].Name] = &snap.Pods[i]
+	}
+
+	now := time.Now()
+	storms := make(map[string]*EventStorm)
+	sources := make(map[string]float64)
+	status.TotalEvents = len(events)
+	for i := range events {
+		event := &events[i]
+		occurrences := eventOccurrences(event, now, time.Hour)
+		if occurrences == 0 {
+			continue
+		}
+		status.EventsPerHour += occurrences
+
+		source := eventSource(event)
+		sources[source] += occurrences
+
+		object := event.InvolvedObject
+		kind, name := object.Kind, object.Name
+		if pod, ok := pods[object.Namespace+"/"+object.Name]; ok && object.Kind == "Pod" {
+			kind, name = snapshot.WorkloadOwner(pod)
+		}
+		key := fmt.Sprintf("%s|%s|%s/%s/%s", event.Reason, source, object.Namespace, kind, name)
+		storm, ok := storms[key]
+		if !ok {
+			storm = &EventStorm{Reason: event.Reason, Source: source, Namespace: object.Namespace, Kind: kind, Name: name}
+			storms[key] = storm
+		}
+		storm.PerHour += occurrences
+		storm.Message = event.Message
+	}
+
+	for _, storm := range storms {
+		if storm.PerHour >= EventStormThreshold {
+			status.Storms = append(status.Storms, *storm)
+		}
+	}
+	sort.Slice(status.Storms, func(i, j int) bool { return status.Storms[i].PerHour > status.Storms[j].PerHour })
+
+	for source, perHour := range sources {
+		if perHour >= EventStormThreshold {
+			status.NoisySources = append(status.NoisySources, EventSource{Source: source, PerHour: perHour})
+		}
+	}
+	sort.Slice(status.NoisySources, func(i, j int) bool { return status.NoisySources[i].PerHour > status.NoisySources[j].PerHour })
+	if len(status.NoisySources) > maxNoisySources {
+		status.NoisySources = status.NoisySources[:maxNoisySources]
+	}
+
+	return nil
+}
+
+// eventOccurrences estimates how many times an event occurred within window before now,
+// assuming repeats were spread evenly between its first and last occurrence
+func eventOccurrences(event *v1.Event, now time.Time, window time.Duration) float64 {
+	count := float64(event.Count)
+	first, last := event.FirstTimestamp.Time, event.LastTimestamp.Time
+	if event.Series != nil {
+		count = float64(event.Series.Count)
+		last = event.Series.LastObservedTime.Time
+	}
+	if first.IsZero() {
+		first = event.EventTime.Time
+	}
+	if last.IsZero() {
+		last = first
+	}
+	if count < 1 {
+		count = 1
+	}
+
+	since := now.Add(-window)
+	if last.Before(since) {
+		return 0
+	}
+	span := last.Sub(first)
+	if span <= 0 || !first.Before(since) {
+		return count
+	}
+	return count * float64(last.Sub(since)) / float64(span)
+}
+
+// eventSource names the controller or component that reported an event
+func eventSource(event *v1.Event) string {
+	switch {
+	case event.ReportingController != "":
+		return event.ReportingController
+	case event.Source.Component != "":
+		return event.Source.Component
+	default:
+		return "unknown"
+	}
+}
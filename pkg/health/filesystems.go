@@ -0,0 +1,146 @@
+package health
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/snapshot"
+)
+
+// NodeFilesystem is a node's root (nodefs) and image (imagefs) filesystem usage and the
+// images taking the most space in its cache
+type NodeFilesystem struct {
+	Node                  string        `json:"node"`
+	NodefsUsage           float64       `json:"nodefsUsage"` // fraction of capacity
+	NodefsAvailableBytes  int64         `json:"nodefsAvailableBytes"`
+	ImagefsUsage          float64       `json:"imagefsUsage"`
+	ImagefsAvailableBytes int64         `json:"imagefsAvailableBytes"`
+	CachedImageBytes      int64         `json:"cachedImageBytes"`
+	LargestImages         []CachedImage `json:"largestImages,omitempty"`
+	DiskPressureEvents    int           `json:"diskPressureEvents"` // times DiskPressure began within DiskPressureWindow
+}
+
+// CachedImage is an image in a node's cache
+type CachedImage struct {
+	Name      string `json:"name"`
+	SizeBytes int64  `json:"sizeBytes"`
+}
+
+// ImageGCHighThreshold mirrors the kubelet's default imageGCHighThresholdPercent; above it
+// the kubelet is deleting images to free space
+var ImageGCHighThreshold = 0.85
+
+// DiskPressureWindow is how far back DiskPressure onsets are counted, and
+// DiskPressureRepeats how many onsets make a node's disk pressure recurring
+var (
+	DiskPressureWindow  = 24 * time.Hour
+	DiskPressureRepeats = 3
+)
+
+// maxLargestImages limits the images listed per node
+const maxLargestImages = 5
+
+// diskPressureOnsets keeps the distinct times each node's DiskPressure condition became
+// true, since the condition only records the latest one
+var diskPressureOnsets = struct {
+	sync.Mutex
+	nodes map[string][]time.Time
+}{nodes: make(map[string][]time.Time)}
+
+// checkNodeFilesystems reads each node's filesystem usage from its kubelet summary, its
+// largest cached images from the node status, and counts its recent DiskPressure onsets
+func checkNodeFilesystems(snap *snapshot.ClusterSnapshot, summaries map[string]*kubeletSummary, now time.Time) []NodeFilesystem {
+	diskPressureOnsets.Lock()
+	defer diskPressureOnsets.Unlock()
+
+	filesystems := make([]NodeFilesystem, 0, len(snap.Nodes))
+	seen := make(map[string]bool, len(snap.Nodes))
+	for i := range snap.Nodes {
+		node := &snap.Nodes[i]
+		if snapshot.IsVirtualNode(node) {
+			continue
+		}
+		seen[node.Name] = true
+		fs := NodeFilesystem{Node: node.Name, DiskPressureEvents: recordDiskPressure(node, now)}
+
+		if summary, ok := summaries[node.Name]; ok {
+			fs.NodefsUsage, fs.NodefsAvailableBytes = fsUsage(summary.Node.Fs)
+			fs.ImagefsUsage, fs.ImagefsAvailableBytes = fs.NodefsUsage, fs.NodefsAvailableBytes
+			if runtime := summary.Node.Runtime; runtime != nil && runtime.ImageFs != nil {
+				fs.ImagefsUsage, fs.ImagefsAvailableBytes = fsUsage(runtime.ImageFs)
+			}
+		}
+
+		for _, image := range node.Status.Images {
+			fs.CachedImageBytes += image.SizeBytes
+			fs.LargestImages = append(fs.LargestImages, CachedImage{Name: imageName(image), SizeBytes: image.SizeBytes})
+		}
+		sort.Slice(fs.LargestImages, func(i, j int) bool { return fs.LargestImages[i].SizeBytes > fs.LargestImages[j].SizeBytes })
+		if len(fs.LargestImages) > maxLargestImages {
+			fs.LargestImages = fs.LargestImages[:maxLargestImages]
+		}
+
+		filesystems = append(filesystems, fs)
+	}
+	for node := range diskPressureOnsets.nodes {
+		if !seen[node] {
+			delete(diskPressureOnsets.nodes, node)
+		}
+	}
+
+	sort.Slice(filesystems, func(i, j int) bool { return filesystems[i].Node < filesystems[j].Node })
+	return filesystems
+}
+
+// recordDiskPressure notes a new DiskPressure onset for a node and returns how many onsets
+// fall within DiskPressureWindow
+func recordDiskPressure(node *v1.Node, now time.Time) int {
+	onsets := diskPressureOnsets.nodes[node.Name]
+	for _, condition := range node.Status.Conditions {
+		if condition.Type != v1.NodeDiskPressure || condition.Status != v1.ConditionTrue {
+			continue
+		}
+		onset := condition.LastTransitionTime.Time
+		known := false
+		for _, t := range onsets {
+			known = known || t.Equal(onset)
+		}
+		if !known {
+			onsets = append(onsets, onset)
+		}
+	}
+
+	recent := onsets[:0]
+	for _, t := range onsets {
+		if now.Sub(t) <= DiskPressureWindow {
+			recent = append(recent, t)
+		}
+	}
+	diskPressureOnsets.nodes[node.Name] = recent
+	return len(recent)
+}
+
+// fsUsage returns a filesystem's used fraction and available bytes
+func fsUsage(fs *kubeletFsStats) (float64, int64) {
+	if fs == nil || statValue(fs.CapacityBytes) == 0 {
+		return 0, 0
+	}
+	return float64(statValue(fs.UsedBytes)) / float64(statValue(fs.CapacityBytes)), statValue(fs.AvailableBytes)
+}
+
+// imageName prefers an image's tagged name over its digest
+func imageName(image v1.ContainerImage) string {
+	for _, name := range image.Names {
+		if !strings.Contains(name, "@") {
+			return name
+		}
+	}
+	if len(image.Names) > 0 {
+		return image.Names[0]
+	}
+	return ""
+}
@@ -0,0 +1,218 @@
+package health
+
+import (
+	"context"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/metrics"
+)
+
+// FlowControlSaturation is the fraction of a priority level's seats in use, with requests
+// queued, at which the level is reported as saturated
+var FlowControlSaturation = 0.9
+
+// ClientThrottleWarn is how long the monitor's own requests may wait on its client-side
+// rate limiter between checks before the monitor reports itself as throttled
+var ClientThrottleWarn = 5 * time.Second
+
+// clientThrottleMin is the smallest rate limiter wait counted as throttling, the same
+// threshold client-go logs throttling at
+const clientThrottleMin = 50 * time.Millisecond
+
+// FlowControlStatus reports API Priority and Fairness load on the API server and the
+// throttling of the monitor's own client
+type FlowControlStatus struct {
+	MetricsVisible bool                 `json:"metricsVisible"` // apiserver /metrics could be read
+	PriorityLevels []PriorityLevelLoad  `json:"priorityLevels,omitempty"`
+	Client         ClientThrottleStatus `json:"client"`
+}
+
+// PriorityLevelLoad is one priority level's load at the check, and its rejections since
+// the previous check. The numbers come from the API server instance that answered.
+type PriorityLevelLoad struct {
+	Name           string             `json:"name"`
+	Rejected       float64            `json:"rejected"`
+	RejectReasons  map[string]float64 `json:"rejectReasons,omitempty"` // "queue-full", "concurrency-limit", "time-out"
+	InQueue        float64            `json:"inQueue"`
+	ExecutingSeats float64            `json:"executingSeats"`
+	LimitSeats     float64            `json:"limitSeats"`
+	Saturation     float64            `json:"saturation"` // executing seats / limit seats
+}
+
+// ClientThrottleStatus is how the monitor's own client-side rate limiter delayed its
+// requests since the previous check
+type ClientThrottleStatus struct {
+	Requests  int64         `json:"requests"`
+	Throttled int64         `json:"throttled"` // requests delayed by clientThrottleMin or more
+	TotalWait time.Duration `json:"totalWait"`
+	MaxWait   time.Duration `json:"maxWait"`
+}
+
+// clientThrottle accumulates the rate limiter waits client-go reports
+var clientThrottle struct {
+	requests, throttled, totalWait, maxWait atomic.Int64
+}
+
+// rateLimiterObserver receives the client-side rate limiter wait of each request
+type rateLimiterObserver struct{}
+
+func (rateLimiterObserver) Observe(_ context.Context, _ string, _ url.URL, latency time.Duration) {
+	clientThrottle.requests.Add(1)
+	if latency < clientThrottleMin {
+		return
+	}
+	clientThrottle.throttled.Add(1)
+	clientThrottle.totalWait.Add(int64(latency))
+	for {
+		current := clientThrottle.maxWait.Load()
+		if int64(latency) <= current || clientThrottle.maxWait.CompareAndSwap(current, int64(latency)) {
+			return
+		}
+	}
+}
+
+// TrackClientThrottling has client-go report every request's rate limiter wait, so checks
+// can tell when the monitor itself is throttled. client-go accepts one registration per
+// process, so it must be called before any client is used.
+func TrackClientThrottling() {
+	metrics.Register(metrics.RegisterOpts{RateLimiterLatency: rateLimiterObserver{}})
+}
+
+// takeClientThrottle returns the throttling since the previous call
+func takeClientThrottle() ClientThrottleStatus {
+	return ClientThrottleStatus{
+		Requests:  clientThrottle.requests.Swap(0),
+		Throttled: clientThrottle.throttled.Swap(0),
+		TotalWait: time.Duration(clientThrottle.totalWait.Swap(0)),
+		MaxWait:   time.Duration(clientThrottle.maxWait.Swap(0)),
+	}
+}
+
+// lastRejected holds the rejection counters from the previous check, keyed by
+// "<priority level>/<reason>", so rejections are counted per check interval
+var lastRejected = struct {
+	sync.Mutex
+	counts map[string]float64
+}{}
+
+// Limit metrics of a priority level, newest first
+var flowControlLimitMetrics = []string{
+	"apiserver_flowcontrol_current_limit_seats",
+	"apiserver_flowcontrol_nominal_limit_seats",
+	"apiserver_flowcontrol_request_concurrency_limit",
+}
+
+// checkFlowControl reads API Priority and Fairness metrics from the API server and the
+// client's throttling since the previous check
+func checkFlowControl(ctx context.Context, clientset kubernetes.Interface, apiMetrics *apiServerMetrics, status *FlowControlStatus) error {
+	status.Client = takeClientThrottle()
+	if clientset == nil {
+		return nil
+	}
+	samples, err := apiMetrics.get(ctx)
+	if err != nil {
+		return err
+	}
+	status.MetricsVisible = true
+
+	rejected := make(map[string]float64)
+	status.PriorityLevels = parseFlowControlMetrics(samples, rejected)
+
+	lastRejected.Lock()
+	previous := lastRejected.counts
+	lastRejected.counts = rejected
+	lastRejected.Unlock()
+
+	// The first check has no interval to count rejections over
+	if previous == nil {
+		return nil
+	}
+	for i := range status.PriorityLevels {
+		level := &status.PriorityLevels[i]
+		for key, count := range rejected {
+			name, reason, _ := strings.Cut(key, "/")
+			if name != level.Name {
+				continue
+			}
+			// A counter that is new or went down, after an API server restart, counts in full
+			delta := count
+			if before, ok := previous[key]; ok && before <= count {
+				delta = count - before
+			}
+			if delta > 0 {
+				if level.RejectReasons == nil {
+					level.RejectReasons = make(map[string]float64)
+				}
+				level.RejectReasons[reason] = delta
+				level.Rejected += delta
+			}
+		}
+	}
+	return nil
+}
+
+// parseFlowControlMetrics sums the flow control metrics per priority level and fills
+// rejected with the cumulative rejections per "<priority level>/<reason>"
+func parseFlowControlMetrics(samples []metricSample, rejected map[string]float64) []PriorityLevelLoad {
+	levels := make(map[string]*PriorityLevelLoad)
+	limits := make(map[string]map[string]float64) // metric -> level -> limit
+	level := func(name string) *PriorityLevelLoad {
+		l, ok := levels[name]
+		if !ok {
+			l = &PriorityLevelLoad{Name: name}
+			levels[name] = l
+		}
+		return l
+	}
+
+	for _, sample := range samples {
+		if !strings.HasPrefix(sample.name, "apiserver_flowcontrol_") {
+			continue
+		}
+		name, value := sample.name, sample.value
+		priority := sample.labels["priority_level"]
+		if priority == "" {
+			continue
+		}
+		switch name {
+		case "apiserver_flowcontrol_rejected_requests_total":
+			rejected[priority+"/"+sample.labels["reason"]] += value
+			level(priority)
+		case "apiserver_flowcontrol_current_inqueue_requests":
+			level(priority).InQueue += value
+		case "apiserver_flowcontrol_current_executing_seats":
+			level(priority).ExecutingSeats += value
+		default:
+			if slices.Contains(flowControlLimitMetrics, name) {
+				if limits[name] == nil {
+					limits[name] = make(map[string]float64)
+				}
+				limits[name][priority] = value
+				level(priority)
+			}
+		}
+	}
+
+	result := make([]PriorityLevelLoad, 0, len(levels))
+	for name, l := range levels {
+		for _, metric := range flowControlLimitMetrics {
+			if limit, ok := limits[metric][name]; ok {
+				l.LimitSeats = limit
+				break
+			}
+		}
+		if l.LimitSeats > 0 {
+			l.Saturation = l.ExecutingSeats / l.LimitSeats
+		}
+		result = append(result, *l)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
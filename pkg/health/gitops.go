@@ -0,0 +1,258 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/retry"
+)
+
+// GitOpsEnabled turns on reading Argo CD Applications and Flux Kustomizations. Either tool
+// may be missing; its CRDs not being installed is not an error.
+var GitOpsEnabled bool
+
+// GitOps tools
+const (
+	GitOpsArgoCD = "argocd"
+	GitOpsFlux   = "flux"
+)
+
+// GitOpsStatus reports the sync state of the applications GitOps tools deploy
+type GitOpsStatus struct {
+	Applications []GitOpsApplication `json:"applications,omitempty"`
+}
+
+// GitOpsApplication is an Argo CD Application or a Flux Kustomization
+type GitOpsApplication struct {
+	Tool         string   `json:"tool"`
+	Namespace    string   `json:"namespace"`
+	Name         string   `json:"name"`
+	Destinations []string `json:"destinations,omitempty"` // namespaces in this cluster it deploys to
+	Revision     string   `json:"revision,omitempty"`
+	Sync         string   `json:"sync,omitempty"`   // Argo CD sync status, e.g. "OutOfSync"
+	Health       string   `json:"health,omitempty"` // Argo CD health status, e.g. "Degraded"
+	SyncFailed   bool     `json:"syncFailed"`       // the last sync or reconciliation failed
+	Suspended    bool     `json:"suspended"`
+	SyncError    string   `json:"syncError,omitempty"`
+	Message      string   `json:"message,omitempty"` // Argo CD health message
+}
+
+// Argo CD states the check reports
+const (
+	argoOutOfSync = "OutOfSync"
+	argoDegraded  = "Degraded"
+	argoMissing   = "Missing"
+	argoSuspended = "Suspended"
+)
+
+// argoApplication is the part of an Argo CD Application the check reads
+type argoApplication struct {
+	Metadata struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	} `json:"metadata"`
+	Spec struct {
+		Destination struct {
+			Server    string `json:"server"`
+			Name      string `json:"name"`
+			Namespace string `json:"namespace"`
+		} `json:"destination"`
+	} `json:"spec"`
+	Status struct {
+		Sync struct {
+			Status   string `json:"status"`
+			Revision string `json:"revision"`
+		} `json:"sync"`
+		Health struct {
+			Status  string `json:"status"`
+			Message string `json:"message"`
+		} `json:"health"`
+		OperationState *struct {
+			Phase   string `json:"phase"`
+			Message string `json:"message"`
+		} `json:"operationState"`
+		Resources []struct {
+			Namespace string `json:"namespace"`
+		} `json:"resources"`
+	} `json:"status"`
+}
+
+// fluxKustomization is the part of a Flux Kustomization the check reads
+type fluxKustomization struct {
+	Metadata struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	} `json:"metadata"`
+	Spec struct {
+		Suspend         bool   `json:"suspend"`
+		TargetNamespace string `json:"targetNamespace"`
+		KubeConfig      *struct {
+			SecretRef struct {
+				Name string `json:"name"`
+			} `json:"secretRef"`
+		} `json:"kubeConfig"`
+	} `json:"spec"`
+	Status struct {
+		LastAppliedRevision string `json:"lastAppliedRevision"`
+		Conditions          []struct {
+			Type    string `json:"type"`
+			Status  string `json:"status"`
+			Reason  string `json:"reason"`
+			Message string `json:"message"`
+		} `json:"conditions"`
+		Inventory *struct {
+			Entries []struct {
+				ID string `json:"id"` // <namespace>_<name>_<group>_<kind>
+			} `json:"entries"`
+		} `json:"inventory"`
+	} `json:"status"`
+}
+
+// checkGitOps reads Argo CD Applications and Flux Kustomizations when GitOpsEnabled is set
+func checkGitOps(ctx context.Context, clientset kubernetes.Interface, status *GitOpsStatus) error {
+	if !GitOpsEnabled || clientset == nil {
+		return nil
+	}
+	partial := &PartialError{}
+
+	var argo struct {
+		Items []argoApplication `json:"items"`
+	}
+	if found, err := listCustomResources(ctx, clientset, "/apis/argoproj.io/v1alpha1/applications", &argo); err != nil {
+		partial.Errors = append(partial.Errors, fmt.Errorf("failed to list Argo CD applications: %w", err))
+	} else if found {
+		for _, app := range argo.Items {
+			status.Applications = append(status.Applications, argoStatus(app))
+		}
+	}
+
+	var flux struct {
+		Items []fluxKustomization `json:"items"`
+	}
+	if found, err := listCustomResources(ctx, clientset, "/apis/kustomize.toolkit.fluxcd.io/v1/kustomizations", &flux); err != nil {
+		partial.Errors = append(partial.Errors, fmt.Errorf("failed to list Flux kustomizations: %w", err))
+	} else if found {
+		for _, k := range flux.Items {
+			status.Applications = append(status.Applications, fluxStatus(k))
+		}
+	}
+
+	sort.Slice(status.Applications, func(i, j int) bool {
+		a, b := status.Applications[i], status.Applications[j]
+		if a.Tool != b.Tool {
+			return a.Tool < b.Tool
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+
+	if len(partial.Errors) > 0 {
+		return partial
+	}
+	return nil
+}
+
+// listCustomResources lists custom resources at path into list, reporting false when their
+// CRD is not installed
+func listCustomResources(ctx context.Context, clientset kubernetes.Interface, path string, list interface{}) (bool, error) {
+	data, err := retry.Value(ctx, retry.DefaultBackoff, func() ([]byte, error) {
+		return clientset.CoreV1().RESTClient().Get().AbsPath(path).DoRaw(ctx)
+	})
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if err := json.Unmarshal(data, list); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// argoStatus converts an Argo CD Application. Applications deploying to another cluster
+// have no destinations here.
+func argoStatus(app argoApplication) GitOpsApplication {
+	result := GitOpsApplication{
+		Tool:      GitOpsArgoCD,
+		Namespace: app.Metadata.Namespace,
+		Name:      app.Metadata.Name,
+		Revision:  app.Status.Sync.Revision,
+		Sync:      app.Status.Sync.Status,
+		Health:    app.Status.Health.Status,
+		Suspended: app.Status.Health.Status == argoSuspended,
+		Message:   app.Status.Health.Message,
+	}
+	if op := app.Status.OperationState; op != nil && (op.Phase == "Failed" || op.Phase == "Error") {
+		result.SyncFailed = true
+		result.SyncError = op.Message
+	}
+
+	dest := app.Spec.Destination
+	if (dest.Server == "" || dest.Server == "https://kubernetes.default.svc") && (dest.Name == "" || dest.Name == "in-cluster") {
+		namespaces := make(map[string]bool)
+		if dest.Namespace != "" {
+			namespaces[dest.Namespace] = true
+		}
+		for _, r := range app.Status.Resources {
+			if r.Namespace != "" {
+				namespaces[r.Namespace] = true
+			}
+		}
+		result.Destinations = sortedKeys(namespaces)
+	}
+	return result
+}
+
+// fluxStatus converts a Flux Kustomization. Kustomizations applied through a kubeConfig
+// deploy to another cluster and have no destinations here.
+func fluxStatus(k fluxKustomization) GitOpsApplication {
+	result := GitOpsApplication{
+		Tool:      GitOpsFlux,
+		Namespace: k.Metadata.Namespace,
+		Name:      k.Metadata.Name,
+		Revision:  k.Status.LastAppliedRevision,
+		Suspended: k.Spec.Suspend,
+	}
+	for _, c := range k.Status.Conditions {
+		if c.Type == "Ready" && c.Status == "False" {
+			result.SyncFailed = true
+			result.SyncError = c.Reason + ": " + c.Message
+		}
+	}
+
+	if k.Spec.KubeConfig == nil {
+		namespaces := make(map[string]bool)
+		if k.Spec.TargetNamespace != "" {
+			namespaces[k.Spec.TargetNamespace] = true
+		}
+		if k.Status.Inventory != nil {
+			for _, e := range k.Status.Inventory.Entries {
+				// Cluster-scoped entries have an empty namespace
+				if i := strings.IndexByte(e.ID, '_'); i > 0 {
+					namespaces[e.ID[:i]] = true
+				}
+			}
+		}
+		result.Destinations = sortedKeys(namespaces)
+	}
+	return result
+}
+
+// sortedKeys returns the keys of a set in order
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -2,15 +2,26 @@ package health
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"math"
+	"regexp"
+	"sort"
 	"strings"
 	"time"
 
+	admissionv1 "k8s.io/api/admissionregistration/v1"
 	v1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	metricsv "k8s.io/metrics/pkg/client/clientset/versioned"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/owners"
+	"github.com/ochestra-tech/ochestra-ai/pkg/retry"
+	"github.com/ochestra-tech/ochestra-ai/pkg/rules"
+	"github.com/ochestra-tech/ochestra-ai/pkg/snapshot"
 )
 
 // ClusterHealth represents overall cluster health status
@@ -20,11 +31,88 @@ type ClusterHealth struct {
 	PodStatus          PodHealthStatus            `json:"podStatus"`
 	ControlPlaneStatus ControlPlaneStatus         `json:"controlPlaneStatus"`
 	NetworkStatus      NetworkStatus              `json:"networkStatus"`
+	EtcdStatus         EtcdStatus                 `json:"etcdStatus"`
+	FlowControl        FlowControlStatus          `json:"flowControl"`
+	Admission          AdmissionStatus            `json:"admission"`
+	EventStatus        EventStatus                `json:"eventStatus"`
+	Inventory          InventoryStatus            `json:"inventory"`
+	TaintStatus        TaintStatus                `json:"taintStatus"`
+	LeaseStatus        LeaseStatus                `json:"leaseStatus"`
+	KubeProxyStatus    KubeProxyStatus            `json:"kubeProxyStatus"`
+	IPStatus           IPStatus                   `json:"ipStatus"`
+	PodDensity         PodDensityStatus           `json:"podDensity"`
+	Reservations       ReservationStatus          `json:"reservations"`
+	Filesystems        []NodeFilesystem           `json:"nodeFilesystems"`
+	DaemonSetCoverage  []DaemonSetCoverage        `json:"daemonSetCoverage"`
 	ResourceUsage      ResourceUsageStatus        `json:"resourceUsage"`
 	ComponentStatuses  []ComponentStatus          `json:"componentStatuses"`
 	NamespaceHealth    map[string]NamespaceHealth `json:"namespaceHealth"`
+	PodSecurity        PodSecurityStatus          `json:"podSecurity"`
+	QoS                QoSStatus                  `json:"qos"`
+	IdleNamespaces     []IdleNamespace            `json:"idleNamespaces,omitempty"`
+	Terminating        TerminatingStatus          `json:"terminating"`
+	Priority           PriorityStatus             `json:"priority"`
+	Helm               HelmStatus                 `json:"helm"`
+	GitOps             GitOpsStatus               `json:"gitOps"`
+	Pipelines          PipelineStatus             `json:"pipelines"`
+	DataServices       DataServicesStatus         `json:"dataServices"`
+	Backups            BackupStatus               `json:"backups"`
+	RuleViolations     []rules.Violation          `json:"ruleViolations,omitempty"`
 	HealthScore        int                        `json:"healthScore"` // 0-100
+	Efficiency         *EfficiencyScore           `json:"efficiency,omitempty"`
 	Issues             []HealthIssue              `json:"issues"`
+	Sections           map[string]SectionStatus   `json:"sections"`                     // section name -> collection state
+	MaintenanceWindows []string                   `json:"maintenanceWindows,omitempty"` // windows active during the check
+
+	// NamespaceEvaluation times the per-namespace checks, see NamespaceWorkers
+	NamespaceEvaluation NamespaceEvaluation `json:"namespaceEvaluation"`
+
+	collectedAt map[string]time.Time // sections replayed from the cache -> when they were collected
+}
+
+// Section collection states
+const (
+	SectionComplete = "complete"
+	SectionPartial  = "partial"
+	SectionFailed   = "failed"
+)
+
+// SectionStatus records whether a section of ClusterHealth was fully collected, and when
+type SectionStatus struct {
+	State       string    `json:"state"`
+	Error       string    `json:"error,omitempty"`
+	CollectedAt time.Time `json:"collectedAt"`
+	Cached      bool      `json:"cached,omitempty"` // reused from an earlier check, see CheckTTLs
+}
+
+// PartialError is returned by checks that completed with some sub-checks failing
+type PartialError struct {
+	Errors []error
+}
+
+func (e *PartialError) Error() string {
+	msgs := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// recordSection stores the collection state of a section based on its check's error
+func recordSection(health *ClusterHealth, name string, err error) {
+	status := SectionStatus{State: SectionComplete, CollectedAt: health.Timestamp}
+	if at, ok := health.collectedAt[name]; ok {
+		status.CollectedAt, status.Cached = at, true
+	}
+	var partial *PartialError
+	switch {
+	case err == nil:
+	case errors.As(err, &partial):
+		status.State, status.Error = SectionPartial, err.Error()
+	default:
+		status.State, status.Error = SectionFailed, err.Error()
+	}
+	health.Sections[name] = status
 }
 
 // NodeHealthStatus contains node health information
@@ -37,6 +125,8 @@ type NodeHealthStatus struct {
 	NetworkUnavailableNodes int                 `json:"networkUnavailableNodes"`
 	NodeConditions          map[string][]string `json:"nodeConditions"` // Node name -> conditions
 	AverageLoad             float64             `json:"averageLoad"`
+	ByOS                    []OSPool            `json:"byOS"`
+	VirtualNodes            int                 `json:"virtualNodes"` // virtual kubelets and serverless nodes, skipped by host checks
 }
 
 // PodHealthStatus contains pod health information
@@ -50,6 +140,7 @@ type PodHealthStatus struct {
 	RestartingPods   int            `json:"restartingPods"`
 	PodsPerNode      map[string]int `json:"podsPerNode"`
 	CrashLoopingPods []string       `json:"crashLoopingPods"`
+	OSMismatchPods   []string       `json:"osMismatchPods,omitempty"` // not ready on a node of another OS
 }
 
 // ControlPlaneStatus contains control plane health information
@@ -60,7 +151,17 @@ type ControlPlaneStatus struct {
 	EtcdHealthy       bool    `json:"etcdHealthy"`
 	CoreDNSHealthy    bool    `json:"coreDNSHealthy"`
 	OverallHealthy    bool    `json:"overallHealthy"`
-	APIServerLatency  float64 `json:"apiServerLatency"` // in milliseconds
+	APIServerLatency  float64 `json:"apiServerLatency"` // median of the /readyz and /version probes, in milliseconds
+
+	APIServerLatencyMax    float64 `json:"apiServerLatencyMax"` // slowest probe, in milliseconds
+	APIServerReady         bool    `json:"apiServerReady"`
+	APIServerReadyError    string  `json:"apiServerReadyError,omitempty"`
+	APIServerProbes        int     `json:"apiServerProbes"`
+	APIServerProbeFailures int     `json:"apiServerProbeFailures"`
+
+	Distribution string              `json:"distribution,omitempty"` // lightweight distribution, e.g. k3s or kind
+	Embedded     bool                `json:"embedded,omitempty"`     // control plane runs without pods in kube-system
+	Probes       []ControlPlaneProbe `json:"probes,omitempty"`       // endpoint checks of an embedded control plane
 }
 
 // NetworkStatus contains network health information
@@ -70,6 +171,15 @@ type NetworkStatus struct {
 	ServiceEndpointsHealthy bool `json:"serviceEndpointsHealthy"`
 	IngressHealthy          bool `json:"ingressHealthy"`
 	NetworkPoliciesCount    int  `json:"networkPoliciesCount"`
+
+	ServicesWithoutEndpoints []string   `json:"servicesWithoutEndpoints,omitempty"` // namespace/name
+	DNS                      DNSMetrics `json:"dns"`
+
+	PendingLoadBalancers []PendingLoadBalancer `json:"pendingLoadBalancers,omitempty"`
+	NodePortsUsed        int                   `json:"nodePortsUsed"`
+	NodePortsTotal       int                   `json:"nodePortsTotal"`
+
+	Policies NetworkPolicyStatus `json:"policies"`
 }
 
 // ResourceUsageStatus contains resource usage information
@@ -81,6 +191,9 @@ type ResourceUsageStatus struct {
 	HighMemoryNodes     []string `json:"highMemoryNodes"`
 	LowResourceNodes    []string `json:"lowResourceNodes"`
 	HighUsageNamespaces []string `json:"highUsageNamespaces"`
+
+	FullVolumes   []VolumeUsage  `json:"fullVolumes,omitempty"`
+	StorageTrends []StorageTrend `json:"storageTrends,omitempty"`
 }
 
 // ComponentStatus represents a cluster component's health
@@ -97,7 +210,9 @@ type NamespaceHealth struct {
 	DeploymentStatus DeploymentStatus    `json:"deploymentStatus"`
 	ServiceStatus    ServiceStatus       `json:"serviceStatus"`
 	ResourceUsage    ResourceUsageStatus `json:"resourceUsage"`
-	HealthScore      int                 `json:"healthScore"` // 0-100
+	HealthScore      int                 `json:"healthScore"`      // 0-100
+	GitOps           []string            `json:"gitOps,omitempty"` // "<tool>:<namespace>/<name>" of applications deploying here
+	Efficiency       *EfficiencyScore    `json:"efficiency,omitempty"`
 }
 
 // DeploymentStatus contains deployment health information
@@ -117,89 +232,395 @@ type ServiceStatus struct {
 
 // HealthIssue represents a detected health issue
 type HealthIssue struct {
-	Severity   string    `json:"severity"` // "critical", "warning", "info"
-	Resource   string    `json:"resource"`
-	Namespace  string    `json:"namespace,omitempty"`
-	Name       string    `json:"name,omitempty"`
-	Message    string    `json:"message"`
-	Timestamp  time.Time `json:"timestamp"`
-	Suggestion string    `json:"suggestion,omitempty"`
+	Type        string    `json:"type,omitempty"` // e.g. "CrashLoopBackOff", see the Issue constants
+	Severity    string    `json:"severity"`       // "critical", "warning", "info"
+	Resource    string    `json:"resource"`
+	Namespace   string    `json:"namespace,omitempty"`
+	Name        string    `json:"name,omitempty"`
+	Message     string    `json:"message"`
+	Timestamp   time.Time `json:"timestamp"`
+	Suggestion  string    `json:"suggestion,omitempty"`
+	RunbookURL  string    `json:"runbookURL,omitempty"`
+	Remediation []string  `json:"remediation,omitempty"`
+	Suppressed  bool      `json:"suppressed,omitempty"` // raised inside a maintenance window
+	Node        string    `json:"node,omitempty"`       // node the affected pod runs on
+	Detector    string    `json:"detector,omitempty"`   // "anomaly" for baseline deviations, "plugin:<name>" for plugin checks, empty for static thresholds
+
+	// Owner is the top-level controller and team responsible, see AttachOwners
+	Owner *owners.Owner `json:"owner,omitempty"`
+
+	// Children are the symptoms correlated with this root cause, see Correlate
+	Children []HealthIssue `json:"children,omitempty"`
+}
+
+// Fingerprint identifies an issue across checks by its resource, namespace, name and
+// message with numbers masked, so a changing count does not look like a new issue
+func (i HealthIssue) Fingerprint() string {
+	return fmt.Sprintf("%s/%s/%s/%s", i.Resource, i.Namespace, i.Name, numberPattern.ReplaceAllString(i.Message, "#"))
+}
+
+// AttachOwners sets the owner of each issue and its correlated symptoms that has none
+func AttachOwners(issues []HealthIssue) {
+	for i := range issues {
+		if issues[i].Owner == nil {
+			issues[i].Owner = owners.Resolve(issues[i].Resource, issues[i].Namespace, issues[i].Name)
+		}
+		AttachOwners(issues[i].Children)
+	}
 }
 
+// numberPattern matches the numbers masked in issue fingerprints
+var numberPattern = regexp.MustCompile(`[0-9]+(\.[0-9]+)?`)
+
 // GetClusterHealth performs a comprehensive health check of the Kubernetes cluster
 func GetClusterHealth(
 	ctx context.Context,
-	clientset *kubernetes.Clientset,
-	metricsClient *metricsv.Clientset,
+	clientset kubernetes.Interface,
+	metricsClient metricsv.Interface,
+) (*ClusterHealth, error) {
+	snap, err := snapshot.Take(ctx, clientset, metricsClient)
+	if err != nil {
+		return nil, fmt.Errorf("failed to take cluster snapshot: %w", err)
+	}
+	return GetClusterHealthFromSnapshot(ctx, clientset, metricsClient, snap)
+}
+
+// GetClusterHealthFromSnapshot performs the health check using objects already read into a
+// cluster snapshot; the clients are only used for checks the snapshot does not cover. With a
+// nil clientset (e.g. evaluating a synthetic snapshot) the API server probe and network
+// policy count are skipped.
+func GetClusterHealthFromSnapshot(
+	ctx context.Context,
+	clientset kubernetes.Interface,
+	metricsClient metricsv.Interface,
+	snap *snapshot.ClusterSnapshot,
 ) (*ClusterHealth, error) {
 	health := &ClusterHealth{
 		Timestamp:       time.Now(),
 		NamespaceHealth: make(map[string]NamespaceHealth),
 		Issues:          make([]HealthIssue, 0),
+		Sections:        make(map[string]SectionStatus),
+		collectedAt:     make(map[string]time.Time),
 	}
+	apiMetrics := &apiServerMetrics{clientset: clientset}
 
 	// Check node health
-	if err := checkNodeHealth(ctx, clientset, &health.NodeStatus); err != nil {
-		return nil, fmt.Errorf("node health check failed: %w", err)
+	checkNodeHealth(snap.Nodes, &health.NodeStatus)
+	recordSection(health, "nodes", nil)
+
+	// Inventory node software versions
+	health.Inventory = NodeInventory(snap.Nodes)
+	recordSection(health, "inventory", nil)
+
+	// Audit cordons and taints
+	health.TaintStatus = TaintAudit(snap, health.Timestamp)
+	recordSection(health, "taints", nil)
+
+	// Check kubelet heartbeats before they show in the Ready condition
+	err := checkNodeLeases(ctx, clientset, snap, health.Timestamp, &health.LeaseStatus)
+	recordSection(health, "leases", err)
+	if err != nil {
+		log.Printf("Node lease check failed: %v", err)
+		// Continue with partial data
 	}
 
+	// Compare pod IPs in use with pod CIDR and ENI limits
+	health.IPStatus = IPUsage(snap)
+	recordSection(health, "ipam", nil)
+
+	// Compare pods per node with maxPods
+	health.PodDensity = PodDensity(snap)
+	recordSection(health, "podDensity", nil)
+
 	// Check pod health
-	if err := checkPodHealth(ctx, clientset, &health.PodStatus); err != nil {
-		return nil, fmt.Errorf("pod health check failed: %w", err)
-	}
+	checkPodHealth(&snap.PodSnapshot, &health.PodStatus)
+	health.PodStatus.OSMismatchPods = osMismatchPods(snap)
+	recordSection(health, "pods", nil)
 
 	// Check control plane health
-	if err := checkControlPlaneHealth(ctx, clientset, &health.ControlPlaneStatus); err != nil {
+	err = checkControlPlaneHealth(ctx, clientset, snap, &health.ControlPlaneStatus)
+	recordSection(health, "controlPlane", err)
+	if err != nil {
 		log.Printf("Control plane health check failed: %v", err)
 		// Continue with partial data
 	}
 
+	// Check for API Priority and Fairness rejections and client-side throttling
+	err = checkFlowControl(ctx, clientset, apiMetrics, &health.FlowControl)
+	recordSection(health, "flowControl", err)
+	if err != nil {
+		log.Printf("Flow control check failed: %v", err)
+		// Continue with partial data
+	}
+
+	// Check admission webhook latency and failures
+	err = checkAdmissionWebhooks(ctx, clientset, apiMetrics, &health.Admission)
+	recordSection(health, "admission", err)
+	if err != nil {
+		log.Printf("Admission webhook check failed: %v", err)
+		// Continue with partial data
+	}
+
 	// Check network health
-	if err := checkNetworkHealth(ctx, clientset, &health.NetworkStatus); err != nil {
+	err = checkNetworkHealth(ctx, clientset, snap, &health.NetworkStatus)
+	recordSection(health, "network", err)
+	if err != nil {
 		log.Printf("Network health check failed: %v", err)
 		// Continue with partial data
 	}
 
+	// Check kube-proxy on each node and the freshness of its rules
+	err = checkKubeProxy(ctx, clientset, snap, &health.KubeProxyStatus)
+	recordSection(health, "kubeProxy", err)
+	if err != nil {
+		log.Printf("kube-proxy health check failed: %v", err)
+		// Continue with partial data
+	}
+
+	// Check etcd object counts and database size
+	err = cachedCheck(health, "etcd", &health.EtcdStatus, func(out *EtcdStatus) error {
+		return checkEtcdPressure(ctx, clientset, apiMetrics, snap, out)
+	})
+	recordSection(health, "etcd", err)
+	if err != nil {
+		log.Printf("Etcd pressure check failed: %v", err)
+		// Continue with partial data
+	}
+
+	// Check critical DaemonSets run on every eligible node
+	health.DaemonSetCoverage, err = CheckDaemonSets(snap)
+	recordSection(health, "daemonsets", err)
+	if err != nil {
+		log.Printf("DaemonSet coverage check failed: %v", err)
+		// Continue with partial data
+	}
+
+	// Check for event storms and the controllers behind them
+	err = cachedCheck(health, "events", &health.EventStatus, func(out *EventStatus) error {
+		return checkEventFloods(ctx, clientset, snap, out)
+	})
+	recordSection(health, "events", err)
+	if err != nil {
+		log.Printf("Event flood check failed: %v", err)
+		// Continue with partial data
+	}
+
 	// Check resource usage
-	if err := checkResourceUsage(ctx, clientset, metricsClient, &health.ResourceUsage); err != nil {
+	err = checkResourceUsage(snap, &health.ResourceUsage)
+	recordSection(health, "resourceUsage", err)
+	if err != nil {
 		log.Printf("Resource usage check failed: %v", err)
 		// Continue with partial data
 	}
 
+	// Read kubelet stats once for the volume and node filesystem checks
+	summaries, err := fetchKubeletSummaries(ctx, clientset, snap)
+	if err != nil {
+		log.Printf("Kubelet stats collection failed: %v", err)
+		// Continue with partial data
+	}
+
+	// Check persistent volume usage
+	checkVolumeUsage(summaries, &health.ResourceUsage)
+	recordSection(health, "volumes", err)
+
+	// Check node and image filesystems and cached images
+	health.Filesystems = checkNodeFilesystems(snap, summaries, health.Timestamp)
+	recordSection(health, "filesystems", err)
+
+	// Audit system and kube reservations and eviction thresholds
+	err = cachedCheck(health, "reservations", &health.Reservations, func(out *ReservationStatus) error {
+		return checkReservations(ctx, clientset, snap, out)
+	})
+	recordSection(health, "reservations", err)
+	if err != nil {
+		log.Printf("Node reservation check failed: %v", err)
+		// Continue with partial data
+	}
+
 	// Check component statuses
-	if err := checkComponentStatuses(ctx, clientset, &health.ComponentStatuses); err != nil {
+	err = checkComponentStatuses(ctx, clientset, &health.ComponentStatuses)
+	recordSection(health, "components", err)
+	if err != nil {
 		log.Printf("Component status check failed: %v", err)
 		// Continue with partial data
 	}
 
 	// Check namespace health
-	if err := checkNamespaceHealth(ctx, clientset, metricsClient, health); err != nil {
+	err = checkNamespaceHealth(ctx, snap, health)
+	recordSection(health, "namespaces", err)
+	if err != nil {
 		log.Printf("Namespace health check failed: %v", err)
 		// Continue with partial data
 	}
 
+	// Score how much of the requested and allocatable resources pods use
+	var namespaceEfficiency map[string]EfficiencyScore
+	health.Efficiency, namespaceEfficiency, err = ResourceEfficiency(snap)
+	recordSection(health, "efficiency", err)
+	if err != nil {
+		log.Printf("Efficiency scoring failed: %v", err)
+		// Continue with partial data
+	}
+	for ns, e := range namespaceEfficiency {
+		if nh, ok := health.NamespaceHealth[ns]; ok {
+			nh.Efficiency = &e
+			health.NamespaceHealth[ns] = nh
+		}
+	}
+
+	// Compare each namespace's enforced Pod Security Standard with what its pods satisfy
+	err = cachedCheck(health, "podSecurity", &health.PodSecurity, func(out *PodSecurityStatus) error {
+		return checkPodSecurity(ctx, clientset, snap, out)
+	})
+	recordSection(health, "podSecurity", err)
+	if err != nil {
+		log.Printf("Pod security audit failed: %v", err)
+		// Continue with partial data
+	}
+
+	// Check for BestEffort production workloads, limits far above requests and CPU throttling
+	err = cachedCheck(health, "qos", &health.QoS, func(out *QoSStatus) error {
+		return checkQoS(ctx, snap, out)
+	})
+	recordSection(health, "qos", err)
+	if err != nil {
+		log.Printf("QoS check failed: %v", err)
+		// Continue with partial data
+	}
+
+	// Find namespaces without running pods, rollouts or endpoint changes
+	err = cachedCheck(health, "idleNamespaces", &health.IdleNamespaces, func(out *[]IdleNamespace) error {
+		return checkIdleNamespaces(ctx, clientset, snap, health.Timestamp, out)
+	})
+	recordSection(health, "idleNamespaces", err)
+	if err != nil {
+		log.Printf("Idle namespace check failed: %v", err)
+		// Continue with partial data
+	}
+
+	// Find pods and namespaces stuck Terminating and what blocks them
+	err = checkTerminating(ctx, clientset, snap, health.Timestamp, &health.Terminating)
+	recordSection(health, "terminating", err)
+	if err != nil {
+		log.Printf("Terminating check failed: %v", err)
+		// Continue with partial data
+	}
+
+	// Audit priority classes and recent preemptions
+	err = checkPriorities(ctx, clientset, snap, health.Timestamp, &health.Priority)
+	recordSection(health, "priority", err)
+	if err != nil {
+		log.Printf("Priority class check failed: %v", err)
+		// Continue with partial data
+	}
+
+	// Check Helm releases for failed or stuck operations and missing dependencies
+	err = cachedCheck(health, "helm", &health.Helm, func(out *HelmStatus) error {
+		return checkHelmReleases(ctx, clientset, snap, out)
+	})
+	if HelmEnabled {
+		recordSection(health, "helm", err)
+	}
+	if err != nil {
+		log.Printf("Helm release check failed: %v", err)
+		// Continue with partial data
+	}
+
+	// Read GitOps sync state and link applications to the namespaces they deploy to
+	err = cachedCheck(health, "gitops", &health.GitOps, func(out *GitOpsStatus) error {
+		return checkGitOps(ctx, clientset, out)
+	})
+	if GitOpsEnabled {
+		recordSection(health, "gitops", err)
+	}
+	if err != nil {
+		log.Printf("GitOps check failed: %v", err)
+		// Continue with partial data
+	}
+	for _, app := range health.GitOps.Applications {
+		for _, ns := range app.Destinations {
+			if nh, ok := health.NamespaceHealth[ns]; ok {
+				nh.GitOps = append(nh.GitOps, app.Tool+":"+app.Namespace+"/"+app.Name)
+				health.NamespaceHealth[ns] = nh
+			}
+		}
+	}
+
+	// Check CI workloads for failing pipelines, stuck runs and pods that cannot start
+	err = cachedCheck(health, "pipelines", &health.Pipelines, func(out *PipelineStatus) error {
+		return checkPipelines(ctx, clientset, snap, health.Timestamp, out)
+	})
+	if PipelinesEnabled {
+		recordSection(health, "pipelines", err)
+	}
+	if err != nil {
+		log.Printf("CI workload check failed: %v", err)
+		// Continue with partial data
+	}
+
+	// Check operator-managed databases for degraded clusters, failovers, lag and stale backups
+	err = cachedCheck(health, "dataServices", &health.DataServices, func(out *DataServicesStatus) error {
+		return checkDataServices(ctx, clientset, snap, out)
+	})
+	if DataServicesEnabled {
+		recordSection(health, "dataServices", err)
+	}
+	if err != nil {
+		log.Printf("Data services check failed: %v", err)
+		// Continue with partial data
+	}
+
+	// Check that backup targets have recent Velero backups and working storage
+	err = cachedCheck(health, "backups", &health.Backups, func(out *BackupStatus) error {
+		return checkBackups(ctx, clientset, health.Timestamp, out)
+	})
+	if len(BackupTargets) > 0 {
+		recordSection(health, "backups", err)
+	}
+	if err != nil {
+		log.Printf("Backup check failed: %v", err)
+		// Continue with partial data
+	}
+
+	// Evaluate user-defined rules
+	health.RuleViolations, err = checkCustomRules(ctx, snap)
+	if CustomRules != nil {
+		recordSection(health, "rules", err)
+	}
+	if err != nil {
+		log.Printf("Custom rule evaluation failed: %v", err)
+		// Continue with partial data
+	}
+
 	// Identify health issues
 	identifyHealthIssues(health)
 
+	// Group symptoms under their root cause
+	health.Issues = Correlate(health.Issues, snap)
+
+	// Name the team responsible for each issue
+	AttachOwners(health.Issues)
+
 	// Calculate overall health score
 	health.HealthScore = calculateHealthScore(health)
+	for ns, score := range NamespaceScores(health) {
+		if nh, ok := health.NamespaceHealth[ns]; ok {
+			nh.HealthScore = score
+			health.NamespaceHealth[ns] = nh
+		}
+	}
 
 	return health, nil
 }
 
 // checkNodeHealth checks the health status of all nodes
-func checkNodeHealth(ctx context.Context, clientset *kubernetes.Clientset, status *NodeHealthStatus) error {
-	nodes, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
-	if err != nil {
-		return fmt.Errorf("failed to list nodes: %w", err)
-	}
-
-	status.TotalNodes = len(nodes.Items)
+func checkNodeHealth(nodes []v1.Node, status *NodeHealthStatus) {
+	status.TotalNodes = len(nodes)
 	status.NodeConditions = make(map[string][]string)
 	totalLoad := 0.0
 
-	for _, node := range nodes.Items {
-		isReady := false
+	for _, node := range nodes {
 		nodeConditions := make([]string, 0)
 
 		for _, condition := range node.Status.Conditions {
@@ -208,7 +629,6 @@ func checkNodeHealth(ctx context.Context, clientset *kubernetes.Clientset, statu
 
 				switch condition.Type {
 				case v1.NodeReady:
-					isReady = true
 					status.ReadyNodes++
 				case v1.NodeMemoryPressure:
 					status.MemoryPressureNodes++
@@ -223,6 +643,10 @@ func checkNodeHealth(ctx context.Context, clientset *kubernetes.Clientset, statu
 		}
 
 		status.NodeConditions[node.Name] = nodeConditions
+		if snapshot.IsVirtualNode(&node) {
+			status.VirtualNodes++
+			continue // nominal capacity
+		}
 
 		// Get node load (simplified)
 		for _, metric := range node.Status.Allocatable {
@@ -230,25 +654,19 @@ func checkNodeHealth(ctx context.Context, clientset *kubernetes.Clientset, statu
 		}
 	}
 
-	if status.TotalNodes > 0 {
-		status.AverageLoad = totalLoad / float64(status.TotalNodes)
+	if machines := status.TotalNodes - status.VirtualNodes; machines > 0 {
+		status.AverageLoad = totalLoad / float64(machines)
 	}
-
-	return nil
+	status.ByOS = NodePoolsByOS(nodes)
 }
 
 // checkPodHealth checks the health status of all pods
-func checkPodHealth(ctx context.Context, clientset *kubernetes.Clientset, status *PodHealthStatus) error {
-	pods, err := clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
-	if err != nil {
-		return fmt.Errorf("failed to list pods: %w", err)
-	}
-
-	status.TotalPods = len(pods.Items)
+func checkPodHealth(pods *snapshot.PodSnapshot, status *PodHealthStatus) {
+	status.TotalPods = len(pods.Pods)
 	status.PodsPerNode = make(map[string]int)
 	status.CrashLoopingPods = make([]string, 0)
 
-	for _, pod := range pods.Items {
+	for _, pod := range pods.Pods {
 		// Update pod count per node
 		nodeName := pod.Spec.NodeName
 		if nodeName != "" {
@@ -286,32 +704,34 @@ func checkPodHealth(ctx context.Context, clientset *kubernetes.Clientset, status
 			}
 		}
 	}
-
-	return nil
 }
 
-// checkControlPlaneHealth checks the health of control plane components
-func checkControlPlaneHealth(ctx context.Context, clientset *kubernetes.Clientset, status *ControlPlaneStatus) error {
+// checkControlPlaneHealth checks the health of control plane components, from their pods in
+// kube-system or, on distributions that embed them, their endpoints
+func checkControlPlaneHealth(
+	ctx context.Context,
+	clientset kubernetes.Interface,
+	snap *snapshot.ClusterSnapshot,
+	status *ControlPlaneStatus,
+) error {
 	// Check API server
-	startTime := time.Now()
-	_, err := clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{Limit: 1})
-	apiCallDuration := time.Since(startTime)
-
-	status.APIServerLatency = float64(apiCallDuration.Milliseconds())
-	status.APIServerHealthy = err == nil && apiCallDuration < 1*time.Second
-
-	// Check kube-system components
-	pods, err := clientset.CoreV1().Pods("kube-system").List(ctx, metav1.ListOptions{})
-	if err != nil {
-		return fmt.Errorf("failed to list kube-system pods: %w", err)
+	if clientset != nil {
+		probeAPIServer(ctx, clientset, status)
 	}
 
+	// Check kube-system components
 	status.ControllerHealthy = true
 	status.SchedulerHealthy = true
 	status.EtcdHealthy = true
 	status.CoreDNSHealthy = true
 
-	for _, pod := range pods.Items {
+	status.Distribution = DetectDistribution(snap.Nodes)
+	status.Embedded = embeddedServices[status.Distribution] != ""
+	if status.Embedded && clientset != nil {
+		probeEmbeddedControlPlane(ctx, clientset, snap.Nodes, status)
+	}
+
+	for _, pod := range snap.Select("kube-system", nil) {
 		if strings.Contains(pod.Name, "kube-controller-manager") && pod.Status.Phase != v1.PodRunning {
 			status.ControllerHealthy = false
 		}
@@ -333,96 +753,937 @@ func checkControlPlaneHealth(ctx context.Context, clientset *kubernetes.Clientse
 }
 
 // checkNetworkHealth checks the health of network components
-func checkNetworkHealth(ctx context.Context, clientset *kubernetes.Clientset, status *NetworkStatus) error {
-	// Check CNI pods (assuming they're in kube-system)
-	cniPods, err := clientset.CoreV1().Pods("kube-system").List(ctx, metav1.ListOptions{
-		LabelSelector: "k8s-app in (calico-node,flannel,weave-net,cilium)",
-	})
+func checkNetworkHealth(
+	ctx context.Context,
+	clientset kubernetes.Interface,
+	snap *snapshot.ClusterSnapshot,
+	status *NetworkStatus,
+) error {
+	partial := &PartialError{}
 
-	if err != nil {
-		log.Printf("Failed to check CNI pods: %v", err)
-		status.CNIHealthy = false
-	} else {
-		status.CNIHealthy = true
-		for _, pod := range cniPods.Items {
-			if pod.Status.Phase != v1.PodRunning {
-				status.CNIHealthy = false
-				break
-			}
+	// Check CNI pods (assuming they're in kube-system), including the Windows DaemonSets of
+	// hybrid clusters
+	status.CNIHealthy = true
+	for _, pod := range snap.SelectString("kube-system", "k8s-app in (calico-node,calico-node-windows,flannel,flannel-windows,weave-net,cilium)") {
+		if pod.Status.Phase != v1.PodRunning {
+			status.CNIHealthy = false
+			break
 		}
 	}
 
 	// Check DNS resolution - CoreDNS
-	coredns, err := clientset.CoreV1().Pods("kube-system").List(ctx, metav1.ListOptions{
-		LabelSelector: "k8s-app=kube-dns",
-	})
-
-	if err != nil {
-		log.Printf("Failed to check CoreDNS pods: %v", err)
-		status.DNSResolutionOK = false
-	} else {
-		status.DNSResolutionOK = true
-		for _, pod := range coredns.Items {
-			if pod.Status.Phase != v1.PodRunning {
-				status.DNSResolutionOK = false
-				break
-			}
+	status.DNSResolutionOK = true
+	dnsPods := snap.SelectString("kube-system", "k8s-app=kube-dns")
+	for _, pod := range dnsPods {
+		if pod.Status.Phase != v1.PodRunning {
+			status.DNSResolutionOK = false
+			break
 		}
 	}
 
+	// Running pods can still fail lookups, so read CoreDNS's own error and latency metrics
+	checkDNSMetrics(ctx, clientset, dnsPods, &status.DNS)
+
 	// Check service endpoints health
-	services, err := clientset.CoreV1().Services("").List(ctx, metav1.ListOptions{})
-	if err != nil {
-		log.Printf("Failed to list services: %v", err)
+	if err := firstError(snap.Errors, "services", "endpoints"); err != nil {
+		log.Printf("Failed to check service endpoints: %v", err)
+		partial.Errors = append(partial.Errors, err)
 		status.ServiceEndpointsHealthy = false
 	} else {
 		status.ServiceEndpointsHealthy = true
 
-		for _, svc := range services.Items {
+		withEndpoints := make(map[string]bool, len(snap.Endpoints))
+		for _, ep := range snap.Endpoints {
+			withEndpoints[ep.Namespace+"/"+ep.Name] = len(ep.Subsets) > 0
+		}
+
+		for _, svc := range snap.Services {
 			if svc.Spec.Selector == nil || len(svc.Spec.Selector) == 0 {
 				// Skip services without selectors (e.g., ExternalName)
 				continue
 			}
 
 			// Check if service has endpoints
-			endpoints, err := clientset.CoreV1().Endpoints(svc.Namespace).Get(ctx, svc.Name, metav1.GetOptions{})
-			if err != nil || len(endpoints.Subsets) == 0 {
+			if !withEndpoints[svc.Namespace+"/"+svc.Name] {
 				status.ServiceEndpointsHealthy = false
-				break
+				status.ServicesWithoutEndpoints = append(status.ServicesWithoutEndpoints, svc.Namespace+"/"+svc.Name)
 			}
 		}
+
+		// Check load balancers were provisioned and node ports remain
+		checkServiceProvisioning(snap.Services, time.Now(), status)
 	}
 
 	// Check Ingress controller
-	ingressControllers, err := clientset.AppsV1().Deployments("").List(ctx, metav1.ListOptions{
-		LabelSelector: "app in (ingress-nginx,traefik,istio-ingressgateway)",
-	})
-
-	if err != nil {
+	if err := snap.Errors["deployments"]; err != nil {
 		log.Printf("Failed to check ingress controllers: %v", err)
+		partial.Errors = append(partial.Errors, err)
 		status.IngressHealthy = false
 	} else {
-		if len(ingressControllers.Items) == 0 {
-			// No ingress controller found - might be normal for some clusters
-			status.IngressHealthy = true
+		// No ingress controller found might be normal for some clusters
+		status.IngressHealthy = true
+		for _, ingress := range snap.Deployments {
+			if !ingressControllerApps[ingress.Labels["app"]] {
+				continue
+			}
+			if ingress.Spec.Replicas != nil && ingress.Status.ReadyReplicas < *ingress.Spec.Replicas {
+				status.IngressHealthy = false
+				break
+			}
+		}
+	}
+
+	// Count network policies and check what they actually restrict
+	if clientset != nil {
+		netpols, err := retry.Value(ctx, retry.DefaultBackoff, func() (*networkingv1.NetworkPolicyList, error) {
+			return clientset.NetworkingV1().NetworkPolicies("").List(ctx, metav1.ListOptions{})
+		})
+		if err != nil {
+			log.Printf("Failed to count network policies: %v", err)
+			partial.Errors = append(partial.Errors, fmt.Errorf("failed to count network policies: %w", err))
 		} else {
-			status.IngressHealthy = true
-			for _, ingress := range ingressControllers.Items {
-				if ingress.Status.ReadyReplicas < *ingress.Spec.Replicas {
-					status.IngressHealthy = false
-					break
+			status.NetworkPoliciesCount = len(netpols.Items)
+			status.Policies = analyzeNetworkPolicies(netpols.Items, snap)
+		}
+	}
+
+	if len(partial.Errors) > 0 {
+		return partial
+	}
+	return nil
+}
+
+// ingressControllerApps are the "app" label values of known ingress controller deployments
+var ingressControllerApps = map[string]bool{
+	"ingress-nginx":        true,
+	"traefik":              true,
+	"istio-ingressgateway": true,
+}
+
+// firstError returns the first snapshot error recorded for the given resources
+func firstError(errs map[string]error, resources ...string) error {
+	for _, resource := range resources {
+		if err := errs[resource]; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// identifyHealthIssues derives health issues from the collected status sections
+func identifyHealthIssues(health *ClusterHealth) {
+	now := health.Timestamp
+	// addOnNode records an issue with a resource on a node, so it correlates with the node's
+	// own failure
+	addOnNode := func(node, issueType, severity, resource, namespace, name, message, suggestion string) {
+		issue := HealthIssue{
+			Type:       issueType,
+			Severity:   severity,
+			Resource:   resource,
+			Namespace:  namespace,
+			Name:       name,
+			Message:    message,
+			Timestamp:  now,
+			Suggestion: suggestion,
+			Node:       node,
+		}
+		Suggest(&issue)
+		health.Issues = append(health.Issues, issue)
+	}
+	add := func(issueType, severity, resource, namespace, name, message, suggestion string) {
+		addOnNode("", issueType, severity, resource, namespace, name, message, suggestion)
+	}
+
+	// Node issues
+	for node, conditions := range health.NodeStatus.NodeConditions {
+		ready := false
+		for _, condition := range conditions {
+			switch v1.NodeConditionType(condition) {
+			case v1.NodeReady:
+				ready = true
+			case v1.NodeMemoryPressure, v1.NodeDiskPressure, v1.NodePIDPressure, v1.NodeNetworkUnavailable:
+				add(condition, "warning", "Node", "", node, fmt.Sprintf("Node has condition %s", condition),
+					"Check node resource usage and kubelet logs")
+			}
+		}
+		if !ready {
+			add(IssueNodeNotReady, "critical", "Node", "", node, "Node is not ready", "Check kubelet status and node connectivity")
+		}
+	}
+
+	// Kubelet heartbeats; a NotReady node is already reported above
+	for _, l := range health.LeaseStatus.StaleLeases {
+		if l.NodeReady {
+			add(IssueNodeHeartbeatStale, "warning", "Node", "", l.Node,
+				fmt.Sprintf("Node lease was last renewed %s ago while the node is still Ready", l.Age.Round(time.Second)),
+				"Check the kubelet on the node before it is marked NotReady")
+		}
+	}
+	for _, node := range health.LeaseStatus.MissingLeases {
+		add(IssueNodeHeartbeatStale, "warning", "Node", "", node, "Node has no lease in kube-node-lease",
+			"Check the kubelet on the node is running and can reach the API server")
+	}
+
+	// API server throttling, of the cluster's clients and of the monitor itself
+	for _, l := range health.FlowControl.PriorityLevels {
+		if l.Rejected > 0 {
+			reasons := make([]string, 0, len(l.RejectReasons))
+			for reason, count := range l.RejectReasons {
+				reasons = append(reasons, fmt.Sprintf("%.0f %s", count, reason))
+			}
+			sort.Strings(reasons)
+			add(IssueAPIThrottling, "warning", "PriorityLevel", "", l.Name,
+				fmt.Sprintf("API server rejected %.0f requests since the last check (%s)", l.Rejected, strings.Join(reasons, ", ")),
+				"Find the flow schemas sending the most requests, or raise the priority level's share")
+		}
+		if l.Saturation >= FlowControlSaturation && l.InQueue > 0 {
+			add(IssueAPIThrottling, "warning", "PriorityLevel", "", l.Name,
+				fmt.Sprintf("Priority level uses %.0f of %.0f seats with %.0f requests queued", l.ExecutingSeats, l.LimitSeats, l.InQueue),
+				"Find the flow schemas sending the most requests, or raise the priority level's share")
+		}
+	}
+	if c := health.FlowControl.Client; c.TotalWait >= ClientThrottleWarn {
+		add(IssueClientThrottled, "warning", "Monitor", "", "client",
+			fmt.Sprintf("%d of the monitor's %d requests waited %s on its client-side rate limiter, up to %s", c.Throttled, c.Requests,
+				c.TotalWait.Round(time.Second), c.MaxWait.Round(time.Millisecond)),
+			"Raise the check interval or the TTLs of expensive sections")
+	}
+
+	// Admission webhooks slowing down or failing requests
+	for _, w := range health.Admission.Webhooks {
+		resource, name := "Webhook", w.Name
+		if w.Configuration != "" {
+			resource, name, _ = strings.Cut(w.Configuration, "/")
+		}
+		if w.Errors > 0 {
+			severity, effect := "warning", "admitted without it"
+			if w.FailurePolicy == string(admissionv1.Fail) {
+				severity, effect = "critical", "rejected"
+			}
+			add(IssueWebhookFailing, severity, resource, "", name,
+				fmt.Sprintf("Webhook %s failed %.0f of %.0f calls since the last check, requests were %s", w.Name, w.Errors, w.Requests, effect),
+				"Check the webhook's service and pods are running and reachable from the API server")
+		}
+		if w.slow() {
+			add(IssueWebhookSlow, "warning", resource, "", name,
+				fmt.Sprintf("Webhook %s takes %s per call on average", w.Name, w.MeanLatency.Round(time.Millisecond)),
+				"Scale the webhook's deployment, or narrow its rules and namespaceSelector to fewer requests")
+		}
+	}
+
+	// Deletions that are not completing
+	for _, p := range health.Terminating.Pods {
+		message := fmt.Sprintf("Pod has been Terminating for %s", p.Duration.Round(time.Minute))
+		suggestion := "Check the kubelet on the pod's node"
+		switch p.Reason {
+		case StuckFinalizers:
+			message += fmt.Sprintf(", blocked by finalizers %s", strings.Join(p.Finalizers, ", "))
+			suggestion = "Check the controllers that own the finalizers, or remove them once their cleanup is done"
+		case StuckNodeGone:
+			message += fmt.Sprintf(", its node %s no longer exists", p.Node)
+			suggestion = "Force delete the pod with kubectl delete pod --grace-period=0 --force"
+		case StuckNodeNotReady:
+			message += fmt.Sprintf(", its node %s is NotReady", p.Node)
+		}
+		addOnNode(p.Node, IssuePodTerminating, "warning", "Pod", p.Namespace, p.Name, message, suggestion)
+	}
+	for _, n := range health.Terminating.Namespaces {
+		add(IssueNamespaceTerminating, "warning", "Namespace", "", n.Namespace,
+			fmt.Sprintf("Namespace has been Terminating for %s, blocked by %s", n.Duration.Round(time.Minute), n.blockers()),
+			"Restore the unavailable APIs or remove the blocking finalizers")
+	}
+
+	// Node software versions
+	for _, f := range health.Inventory.Denied {
+		add(IssueVersionDenied, deniedSeverity(f), "Node", "", f.Node, fmt.Sprintf("%s %s is denylisted: %s", f.Component, f.Version, f.Reason),
+			"Upgrade the node to a fixed version")
+	}
+	for _, f := range health.Inventory.Outliers {
+		add(IssueVersionOutlier, "info", "Node", "", f.Node, fmt.Sprintf("%s %s differs from the fleet: %s", f.Component, f.Version, f.Reason),
+			"Bring the node in line with the rest of its pool")
+	}
+
+	// Cordons and taints
+	for _, c := range health.TaintStatus.CordonedNodes {
+		switch {
+		case c.Duration >= CordonWarnAfter:
+			add(IssueNodeCordoned, "warning", "Node", "", c.Node, fmt.Sprintf("Node has been cordoned since %s", c.Since.Format(time.RFC3339)),
+				"Uncordon the node if its maintenance is finished")
+		case c.Duration > 0:
+			add(IssueNodeCordoned, "info", "Node", "", c.Node, fmt.Sprintf("Node has been cordoned since %s", c.Since.Format(time.RFC3339)), "")
+		default:
+			add(IssueNodeCordoned, "info", "Node", "", c.Node, "Node is cordoned", "")
+		}
+	}
+	for _, t := range health.TaintStatus.TaintedNodes {
+		add(IssueCustomTaint, "info", "Node", "", t.Node, fmt.Sprintf("Node has taints %s", strings.Join(t.Taints, ", ")), "")
+	}
+	for _, p := range health.TaintStatus.BlockedPods {
+		message := "Pod is unschedulable because of node taints"
+		if len(p.Taints) > 0 {
+			message = fmt.Sprintf("Pod does not tolerate %s", strings.Join(p.Taints, ", "))
+		}
+		add(IssueTaintBlocked, "warning", "Pod", p.Namespace, p.Name, message, "Add a toleration to the workload or remove the taint")
+	}
+
+	// Node filesystems, pointing at image pruning or eviction settings
+	for _, fs := range health.Filesystems {
+		if fs.DiskPressureEvents >= DiskPressureRepeats {
+			addOnNode(fs.Node, IssueImageFSPressure, "warning", "Node", "", fs.Node,
+				fmt.Sprintf("Node has hit DiskPressure %d times in the last %s", fs.DiskPressureEvents, DiskPressureWindow),
+				"Raise the kubelet's eviction and image GC thresholds, or give the node a larger disk")
+			continue
+		}
+		usage, name := fs.ImagefsUsage, "imagefs"
+		if fs.NodefsUsage > usage {
+			usage, name = fs.NodefsUsage, "nodefs"
+		}
+		if usage >= ImageGCHighThreshold {
+			addOnNode(fs.Node, IssueImageFSPressure, "warning", "Node", "", fs.Node, fmt.Sprintf("Node %s is %.0f%% full", name, usage*100),
+				"Prune unused images with crictl rmi --prune and check for large images or logs on the node")
+		}
+	}
+
+	// Volume fullness and namespaces running out of storage
+	for _, v := range health.ResourceUsage.FullVolumes {
+		usage, what := v.Usage, "space"
+		if v.InodeUsage > usage {
+			usage, what = v.InodeUsage, "inodes"
+		}
+		severity := "warning"
+		if usage >= VolumeFullCritical {
+			severity = "critical"
+		}
+		add(IssueVolumeFull, severity, "PersistentVolumeClaim", v.Namespace, v.PVC, fmt.Sprintf("Volume has used %.0f%% of its %s", usage*100, what),
+			"Expand the claim or clean up data on the volume")
+	}
+	for _, t := range health.ResourceUsage.StorageTrends {
+		add(IssueStorageTrend, "warning", "Namespace", t.Namespace, t.Namespace,
+			fmt.Sprintf("Volumes grow %d MiB a day and fill within %s", t.GrowthPerDay>>20, StorageTrendHorizon),
+			"Expand the namespace's claims or check what is writing to them")
+	}
+
+	// Pod IP exhaustion, which otherwise shows up only as pods stuck creating sandboxes
+	for _, n := range health.IPStatus.AtRisk {
+		severity := "warning"
+		if n.Usage >= IPCriticalRatio {
+			severity = "critical"
+		}
+		addOnNode(n.Node, IssueIPExhaustion, severity, "Node", "", n.Node,
+			fmt.Sprintf("%d of %d pod IPs in use (%s limit)", n.Used, n.Capacity, n.Limit),
+			"Move pods off the node, or give nodes larger pod CIDRs or enable prefix delegation")
+	}
+	if ip := health.IPStatus; ip.Capacity > 0 && ip.Usage >= IPWarningRatio {
+		severity := "warning"
+		if ip.Usage >= IPCriticalRatio {
+			severity = "critical"
+		}
+		add(IssueIPExhaustion, severity, "Cluster", "", "", fmt.Sprintf("%.0f%% of pod IP capacity across nodes is in use", ip.Usage*100),
+			"Add nodes or expand the pod address space before new pods fail to start")
+	}
+
+	// Nodes at their maxPods, which otherwise shows up only as pods the scheduler cannot place
+	for _, d := range health.PodDensity.Nodes {
+		if d.Usage >= PodDensityWarn {
+			severity := "warning"
+			if d.Pods >= d.MaxPods {
+				severity = "critical"
+			}
+			addOnNode(d.Node, IssuePodCapacity, severity, "Node", "", d.Node,
+				fmt.Sprintf("%d of %d pods (maxPods) running", d.Pods, d.MaxPods),
+				"Spread pods across more nodes, or raise the kubelet's maxPods if the node's IPs and resources allow")
+		}
+		if d.ExceedsIPs() {
+			addOnNode(d.Node, IssueMaxPodsAboveIPs, "warning", "Node", "", d.Node,
+				fmt.Sprintf("maxPods %d allows more pods than the %d pod IPs (%s limit) the node can hand out", d.MaxPods, d.IPCapacity, d.IPLimit),
+				"Lower the kubelet's maxPods to the node's IP capacity so pods are not scheduled onto it and left without an IP")
+		}
+	}
+	if d := health.PodDensity; d.Rejected > 0 {
+		add(IssuePodCapacity, "critical", "Cluster", "", "",
+			fmt.Sprintf("%d pending pods rejected by the scheduler with Too many pods, e.g. %s", d.Rejected, strings.Join(d.RejectedPods, ", ")),
+			"Add nodes or raise maxPods on existing ones")
+	}
+
+	// Nodes without reserved resources, where system daemons compete with pods
+	for _, r := range health.Reservations.Nodes {
+		if r.NoReservation && r.Windows {
+			addOnNode(r.Node, IssueNoSystemReserved, "critical", "Node", "", r.Node,
+				fmt.Sprintf("No system-reserved or kube-reserved CPU or memory on a Windows node (read from %s)", r.Source),
+				"Set systemReserved and kubeReserved in the kubelet configuration; Windows has no out-of-memory killer, so without them an overcommitted node pages and stalls")
+		} else if r.NoReservation {
+			addOnNode(r.Node, IssueNoSystemReserved, "warning", "Node", "", r.Node,
+				fmt.Sprintf("No system-reserved or kube-reserved CPU or memory (read from %s)", r.Source),
+				"Set systemReserved and kubeReserved in the kubelet configuration so the kubelet and system daemons keep resources under load")
+		}
+		if len(r.MissingEviction) > 0 {
+			addOnNode(r.Node, IssueNoEvictionThreshold, "warning", "Node", "", r.Node,
+				fmt.Sprintf("No hard eviction threshold for %s", strings.Join(r.MissingEviction, ", ")),
+				"Set evictionHard in the kubelet configuration so pods are evicted before the node runs out")
+		}
+	}
+
+	// Pod issues
+	for _, key := range health.PodStatus.CrashLoopingPods {
+		namespace, name, _ := strings.Cut(key, "/")
+		add(IssueCrashLoopBackOff, "critical", "Pod", namespace, name, "Pod is in CrashLoopBackOff",
+			"Check the container logs and recent events for the failure cause")
+	}
+	if health.PodStatus.PendingPods > 0 {
+		add(IssuePodsPending, "warning", "Pod", "", "", fmt.Sprintf("%d pods are pending", health.PodStatus.PendingPods),
+			"Check for insufficient resources, unschedulable nodes or unbound volumes")
+	}
+	if health.PodStatus.FailedPods > 0 {
+		add(IssuePodsFailed, "warning", "Pod", "", "", fmt.Sprintf("%d pods have failed", health.PodStatus.FailedPods),
+			"Inspect failed pods and clean up completed workloads")
+	}
+	for _, key := range health.PodStatus.OSMismatchPods {
+		namespace, name, _ := strings.Cut(key, "/")
+		add(IssuePodOSMismatch, "warning", "Pod", namespace, name, "Pod is not ready on a node of another operating system than its images need",
+			"Select the OS with nodeSelector kubernetes.io/os, or spec.os for Windows pods, and taint Windows nodes so Linux pods without one stay off them")
+	}
+	if health.PodStatus.RestartingPods > 0 {
+		add(IssueContainerRestarts, "info", "Pod", "", "", fmt.Sprintf("%d containers restarted more than 5 times", health.PodStatus.RestartingPods), "")
+	}
+
+	// Control plane issues, only when the section was collected
+	if health.Sections["controlPlane"].State == SectionComplete {
+		cp := health.ControlPlaneStatus
+		components := []struct {
+			name    string
+			healthy bool
+		}{
+			{"kube-apiserver", cp.APIServerHealthy},
+			{"kube-controller-manager", cp.ControllerHealthy},
+			{"kube-scheduler", cp.SchedulerHealthy},
+			{"etcd", cp.EtcdHealthy},
+			{"coredns", cp.CoreDNSHealthy},
+		}
+		remediation := "Check the component's pod status and logs"
+		if cp.Embedded {
+			remediation = fmt.Sprintf("The %s control plane runs outside pods; check its logs with journalctl -u %s on the server nodes",
+				cp.Distribution, embeddedServices[cp.Distribution])
+		}
+		for _, c := range components {
+			if !c.healthy {
+				add(IssueControlPlaneUnhealthy, "critical", "ControlPlane", "kube-system", c.name, fmt.Sprintf("%s is unhealthy", c.name), remediation)
+			}
+		}
+	}
+
+	// Network issues
+	if health.Sections["network"].State != SectionFailed {
+		ns := health.NetworkStatus
+		if !ns.CNIHealthy {
+			add(IssueCNIUnhealthy, "critical", "Network", "kube-system", "cni", "CNI pods are not all running", "Check the CNI daemonset")
+		}
+		if !ns.DNSResolutionOK {
+			add(IssueDNSUnhealthy, "critical", "Network", "kube-system", "coredns", "CoreDNS pods are not all running", "Check the CoreDNS deployment")
+		}
+		if dns := ns.DNS; dns.Responses >= DNSMinResponses && dns.ServfailRate >= DNSServfailWarning {
+			severity := "warning"
+			if dns.ServfailRate >= DNSServfailCritical {
+				severity = "critical"
+			}
+			add(IssueDNSErrors, severity, "Network", "kube-system", "coredns",
+				fmt.Sprintf("%.0f%% of CoreDNS responses are SERVFAIL", dns.ServfailRate*100),
+				"Check CoreDNS logs and the upstream resolvers it forwards to")
+		}
+		if ns.DNS.Panics > 0 {
+			add(IssueDNSErrors, "warning", "Network", "kube-system", "coredns", "CoreDNS recovered from panics",
+				"Check CoreDNS logs for the panicking plugin")
+		}
+		if ns.DNS.ForwardLatency >= DNSForwardLatencyWarning {
+			add(IssueDNSLatency, "warning", "Network", "kube-system", "coredns",
+				fmt.Sprintf("CoreDNS upstream requests take %s on average", ns.DNS.ForwardLatency.Round(time.Millisecond)),
+				"Check the upstream resolvers' latency, or raise the cache TTL")
+		}
+		for _, lb := range ns.PendingLoadBalancers {
+			add(IssueLoadBalancerPending, "warning", "Service", lb.Namespace, lb.Name,
+				fmt.Sprintf("LoadBalancer has had no external address since %s", lb.Created.Format(time.RFC3339)),
+				"Check the service's events and the cloud controller manager logs")
+		}
+		if ns.NodePortsTotal > 0 {
+			if usage := float64(ns.NodePortsUsed) / float64(ns.NodePortsTotal); usage >= NodePortWarningRatio {
+				severity := "warning"
+				if ns.NodePortsUsed >= ns.NodePortsTotal {
+					severity = "critical"
 				}
+				add(IssueNodePortExhaustion, severity, "Network", "", "nodeports",
+					fmt.Sprintf("%d of %d node ports are allocated", ns.NodePortsUsed, ns.NodePortsTotal),
+					"Remove unused NodePort services or set allocateLoadBalancerNodePorts: false where the load balancer does not need them")
 			}
 		}
+		for _, namespace := range ns.Policies.OpenNamespaces {
+			add(IssueNamespaceWithoutPolicy, "info", "Namespace", namespace, namespace, "Namespace has no network policies, so all traffic is allowed",
+				"Add a default-deny policy and allow the traffic the workloads need")
+		}
+		for _, key := range ns.Policies.UnusedPolicies {
+			namespace, name, _ := strings.Cut(key, "/")
+			add(IssueUnusedNetworkPolicy, "info", "NetworkPolicy", namespace, name, "Network policy selects no pods",
+				"Fix the pod selector or delete the policy")
+		}
+		for _, c := range ns.Policies.Conflicts {
+			add(IssueNetworkPolicyConflict, "warning", "NetworkPolicy", c.Namespace, c.Deny,
+				fmt.Sprintf("%s deny-all is overridden by allow-all policy %s", c.Direction, c.Allow),
+				"Narrow the allow-all rule to the peers and ports that need access")
+		}
+		for _, key := range ns.ServicesWithoutEndpoints {
+			namespace, name, _ := strings.Cut(key, "/")
+			add(IssueServiceWithoutEndpoints, "warning", "Service", namespace, name, "Service has no endpoints",
+				"Check that service selectors match ready pods")
+		}
+		if !ns.IngressHealthy {
+			add(IssueIngressUnhealthy, "warning", "Network", "", "ingress", "Ingress controller replicas are not all ready",
+				"Check the ingress controller deployment")
+		}
 	}
 
-	// Count network policies
-	netpols, err := clientset.NetworkingV1().NetworkPolicies("").List(ctx, metav1.ListOptions{})
-	if err != nil {
-		log.Printf("Failed to count network policies: %v", err)
-	} else {
-		status.NetworkPoliciesCount = len(netpols.Items)
+	// Etcd pressure
+	for _, name := range health.EtcdStatus.Pressure {
+		usage := health.EtcdStatus.Usage[name]
+		severity := "warning"
+		if usage >= EtcdCriticalRatio {
+			severity = "critical"
+		}
+		if name == "dbSize" {
+			add(IssueEtcdPressure, severity, "Etcd", "", "dbSize", fmt.Sprintf("Etcd database is %.0f%% of its %d MiB quota",
+				usage*100, health.EtcdStatus.QuotaBytes>>20), "Compact and defragment etcd, or raise --quota-backend-bytes")
+			continue
+		}
+		add(IssueEtcdPressure, severity, "Etcd", "", name, fmt.Sprintf("%d %s stored, %.0f%% of the %d known limit",
+			health.EtcdStatus.ObjectCounts[name], name, usage*100, EtcdObjectLimits[name]), "Clean up unused objects of this type")
 	}
 
-	return nil
+	// DaemonSet coverage, one issue per node so node failures correlate with their root
+	for _, c := range health.DaemonSetCoverage {
+		for _, node := range c.MissingNodes {
+			addOnNode(node, IssueDaemonSetMissing, c.Severity, "DaemonSet", c.Namespace, c.Name, fmt.Sprintf("No ready pod on node %s", node),
+				"Check the DaemonSet's pod on the node, its tolerations and the node's capacity")
+		}
+	}
+
+	// Pod Security Standards: namespaces enforcing less than their pods allow, and pods
+	// that would be rejected by the level their namespace enforces if recreated
+	for _, ns := range health.PodSecurity.Namespaces {
+		switch {
+		case ns.Enforced == "" && ns.Achievable != PSSPrivileged:
+			add(IssuePodSecurityNotEnforced, "warning", "Namespace", ns.Namespace, ns.Namespace,
+				"No Pod Security Standard is enforced although every pod meets the "+ns.Achievable+" standard",
+				"Label the namespace "+pssEnforceLabel+"="+ns.Achievable)
+		case ns.Enforced == "":
+			add(IssuePodSecurityNotEnforced, "info", "Namespace", ns.Namespace, ns.Namespace,
+				"No Pod Security Standard is enforced and some pods need privileged",
+				"Label the namespace "+pssEnforceLabel+"=privileged to make the exception explicit, or harden the pods listed in the report")
+		case ns.Weak():
+			add(IssuePodSecurityWeak, "info", "Namespace", ns.Namespace, ns.Namespace,
+				"Namespace enforces the "+ns.Enforced+" Pod Security Standard although every pod meets "+ns.Achievable,
+				"Raise the namespace's "+pssEnforceLabel+" label to "+ns.Achievable)
+		}
+		if len(ns.Violations) > 0 {
+			add(IssuePodSecurityViolation, "warning", "Namespace", ns.Namespace, ns.Namespace,
+				"Pods break the enforced "+ns.Enforced+" Pod Security Standard and would be rejected if recreated",
+				"Fix the pods' security contexts before they restart: "+strings.Join(ns.Violations, "; "))
+		}
+	}
+
+	// QoS classes, limit-to-request ratios and CPU throttling
+	for _, f := range health.QoS.BestEffort {
+		add(IssueBestEffortWorkload, "warning", f.Kind, f.Namespace, f.Name,
+			fmt.Sprintf("%d pods run without requests or limits (BestEffort QoS) in a production namespace", f.Pods),
+			"Set CPU and memory requests and a memory limit; BestEffort pods are the first evicted under node pressure")
+	}
+	for _, f := range health.QoS.Bursting {
+		if ratio := f.MemoryRatio(); ratio >= LimitRequestRatioWarn {
+			add(IssueLimitBursting, "warning", f.Kind, f.Namespace, f.Name,
+				fmt.Sprintf("Container %s has a %dMi memory limit, %.0fx its %dMi request", f.Container, f.MemoryLimit>>20, ratio, f.MemoryRequest>>20),
+				fmt.Sprintf("Raise the memory request to at least %dMi or lower the limit; memory used past the request is OOM-killed or evicted when the node runs short",
+					int64(float64(f.MemoryLimit)/LimitRequestRatioWarn)>>20))
+		}
+		if ratio := f.CPURatio(); ratio >= LimitRequestRatioWarn {
+			add(IssueLimitBursting, "info", f.Kind, f.Namespace, f.Name,
+				fmt.Sprintf("Container %s has a %dm CPU limit, %.0fx its %dm request", f.Container, f.CPULimit, ratio, f.CPURequest),
+				fmt.Sprintf("Raise the CPU request to at least %dm or lower the limit, so the scheduler reserves the CPU the container bursts to",
+					int64(float64(f.CPULimit)/LimitRequestRatioWarn)))
+		}
+	}
+	for _, f := range health.QoS.Throttled {
+		add(IssueCPUThrottling, "warning", f.Kind, f.Namespace, f.Name,
+			fmt.Sprintf("Container %s is CPU throttled in %.0f%% of periods at its %dm limit", f.Container, f.Throttled*100, f.CPULimit),
+			fmt.Sprintf("Raise the CPU limit, e.g. to %dm, or remove it and rely on the request; throttling adds latency even when nodes have idle CPU",
+				2*f.CPULimit))
+	}
+
+	// Priority classes and preemption
+	for _, w := range health.Priority.Unprioritized {
+		add(IssueCriticalNoPriority, "warning", w.Kind, w.Namespace, w.Name,
+			fmt.Sprintf("Critical workload (%s) runs without a priority class and can be preempted by any higher-priority pod", w.Reason),
+			"Set priorityClassName to a class above the cluster's default")
+	}
+	for _, w := range health.Priority.SystemPriority {
+		add(IssueSystemPriorityMisuse, "warning", w.Kind, w.Namespace, w.Name,
+			fmt.Sprintf("User workload runs at %s and can preempt cluster components", w.PriorityClass),
+			"Use a priority class below the system classes; keep those for components the cluster cannot run without")
+	}
+	for _, p := range health.Priority.Preemptions {
+		add(IssuePodsPreempted, "warning", p.Kind, p.Namespace, p.Name,
+			fmt.Sprintf("%d pods preempted in the last %s: %s", p.Count, PreemptionWindow, p.Message),
+			"Give the workload a higher priority class, or add capacity so higher-priority pods fit without preempting")
+	}
+
+	// Idle namespaces, with what deleting them would reclaim
+	for _, n := range health.IdleNamespaces {
+		add(IssueIdleNamespace, "info", "Namespace", n.Namespace, n.Namespace,
+			fmt.Sprintf("No running pods, rollouts or endpoint changes for %d days; holds %s", int(n.IdleFor(now).Hours()/24), idleHoldings(n)),
+			fmt.Sprintf("Confirm with the owners, then archive the namespace's manifests and volume snapshots and delete it to reclaim ~$%.2f/month", n.MonthlyReclaim))
+	}
+
+	// Helm releases
+	for _, r := range health.Helm.Releases {
+		switch r.Status {
+		case HelmFailed:
+			add(IssueHelmReleaseFailed, "warning", "HelmRelease", r.Namespace, r.Name, "Latest Helm release revision failed",
+				fmt.Sprintf("Revision %d of %s failed: %s. Fix the cause, then upgrade again or roll back", r.Revision, r.Chart, r.Description))
+		case HelmPendingInstall, HelmPendingUpgrade, HelmPendingRollback, HelmUninstalling:
+			if now.Sub(r.Updated) > HelmPendingAfter {
+				add(IssueHelmReleasePending, "warning", "HelmRelease", r.Namespace, r.Name, "Helm release is stuck in "+r.Status+", blocking further upgrades",
+					"Check whether a helm or CI process is still running for the release; if not, roll back to the last deployed revision")
+			}
+		}
+		if len(r.MissingImages) > 0 {
+			add(IssueHelmMissingImage, "critical", "HelmRelease", r.Namespace, r.Name,
+				"Helm release deploys images that cannot be pulled: "+strings.Join(r.MissingImages, ", "),
+				"Check the image names and tags in the chart values, and that the registry is reachable with the pods' pull secrets")
+		}
+		if len(r.MissingNamespaces) > 0 {
+			add(IssueHelmMissingNamespace, "warning", "HelmRelease", r.Namespace, r.Name,
+				"Helm release deploys to namespaces that no longer exist: "+strings.Join(r.MissingNamespaces, ", "),
+				"Recreate the namespaces or change the chart values, then upgrade the release")
+		}
+		if r.Orphaned {
+			add(IssueHelmOrphanedRelease, "info", "HelmRelease", r.Namespace, r.Name, "Helm release is deployed but its workloads and services were deleted",
+				"Uninstall the release to remove its secrets, or upgrade it to recreate the objects")
+		}
+	}
+
+	// User-defined rules, with the severity each rule sets
+	for _, v := range health.RuleViolations {
+		add(IssueRuleViolation, v.Severity, v.Kind, v.Namespace, v.Name, v.Rule+": "+v.Message, v.Suggestion)
+	}
+
+	// kube-proxy, whose failures break service routing on its node without any other symptom
+	for _, node := range health.KubeProxyStatus.Nodes {
+		if !node.Ready {
+			addOnNode(node.Node, IssueKubeProxyUnhealthy, "critical", "Pod", "kube-system", node.Pod,
+				fmt.Sprintf("kube-proxy is not ready on node %s", node.Node), "Check the kube-proxy pod's logs and restarts")
+			continue
+		}
+		symptoms := kubeProxySymptoms(node)
+		if len(symptoms) == 0 {
+			continue
+		}
+		severity := "warning"
+		if node.StaleRules {
+			severity = "critical"
+		}
+		addOnNode(node.Node, IssueKubeProxyRulesStale, severity, "Pod", "kube-system", node.Pod,
+			fmt.Sprintf("Service rules on node %s may be stale: %s", node.Node, strings.Join(symptoms, "; ")),
+			"Check the kube-proxy logs for sync errors and restart the pod if rules stay out of date")
+	}
+
+	// Event storms
+	for _, storm := range health.EventStatus.Storms {
+		severity := "warning"
+		if storm.PerHour >= 10*EventStormThreshold {
+			severity = "critical"
+		}
+		add(IssueEventStorm, severity, storm.Kind, storm.Namespace, storm.Name,
+			fmt.Sprintf("%s events from %s at %.0f/hour", storm.Reason, storm.Source, storm.PerHour),
+			"Fix the condition the controller keeps reporting, or the controller's retry loop")
+	}
+
+	// Resource issues
+	for _, node := range health.ResourceUsage.HighCPUNodes {
+		add(IssueHighCPU, "warning", "Node", "", node, "Node CPU usage is high", "Rebalance workloads or add capacity")
+	}
+	for _, node := range health.ResourceUsage.HighMemoryNodes {
+		add(IssueHighMemory, "warning", "Node", "", node, "Node memory usage is high", "Rebalance workloads or add capacity")
+	}
+
+	// Component issues
+	for _, c := range health.ComponentStatuses {
+		// Embedded control planes do not serve the endpoints componentstatuses reads; their
+		// endpoint probes report them instead
+		if health.ControlPlaneStatus.Embedded && c.Name != "kube-apiserver" {
+			continue
+		}
+		if !c.Healthy {
+			add(IssueComponentUnhealthy, "warning", "Component", "", c.Name, fmt.Sprintf("Component is unhealthy: %s", c.Message), "")
+		}
+	}
+
+	// Operator-managed databases
+	for _, db := range health.DataServices.Databases {
+		switch {
+		case !db.Healthy || db.ReadyInstances < db.Instances:
+			severity := "warning"
+			if db.ReadyInstances == 0 {
+				severity = "critical"
+			}
+			message := fmt.Sprintf("%d of %d instances ready", db.ReadyInstances, db.Instances)
+			if db.State != "" {
+				message += ", state " + db.State
+			}
+			add(IssueDatabaseDegraded, severity, db.Kind, db.Namespace, db.Name, message,
+				"Check the operator's logs and the database pods: "+db.Message)
+		}
+		if db.FailingOver {
+			add(IssueDatabaseFailover, "warning", db.Kind, db.Namespace, db.Name, "Failover or switchover away from "+db.Primary+" is in progress",
+				"Watch that the new primary is promoted and that clients reconnect")
+		}
+		if db.ReplicationLag > ReplicationLagWarn {
+			add(IssueDatabaseReplicationLag, "warning", db.Kind, db.Namespace, db.Name,
+				fmt.Sprintf("Replica is %.0fs behind the primary", db.ReplicationLag.Seconds()),
+				"Check the replica's disk and network throughput and long-running queries on the primary")
+		}
+		switch {
+		case db.BackupConfigured && db.LastBackup.IsZero():
+			add(IssueDatabaseBackupStale, "warning", db.Kind, db.Namespace, db.Name, "No successful backup recorded",
+				"Check the backup jobs and the object store credentials")
+		case db.BackupConfigured && db.BackupAge(now) > BackupMaxAge:
+			add(IssueDatabaseBackupStale, "warning", db.Kind, db.Namespace, db.Name,
+				fmt.Sprintf("Last successful backup was %.0f hours ago", db.BackupAge(now).Hours()),
+				"Check the backup jobs and the object store credentials")
+		case !db.BackupConfigured && db.Operator != OperatorRedis:
+			add(IssueDatabaseNoBackup, "info", db.Kind, db.Namespace, db.Name, "No backups are configured",
+				"Configure scheduled backups to an object store")
+		}
+	}
+
+	// Backup and disaster recovery
+	for _, l := range health.Backups.Locations {
+		if l.Phase == "Unavailable" {
+			add(IssueBackupLocationDown, "critical", "BackupStorageLocation", l.Namespace, l.Name, "Backup storage location is unavailable",
+				"Check the bucket, its credentials and network access from the Velero pod: "+l.Message)
+		}
+	}
+	for _, sc := range health.Backups.Schedules {
+		if sc.Phase == "FailedValidation" {
+			add(IssueBackupScheduleInvalid, "warning", "Schedule", sc.Namespace, sc.Name, "Backup schedule failed validation and creates no backups",
+				"velero schedule describe the schedule for the validation errors and fix its spec")
+		}
+	}
+	for _, ns := range health.Backups.Namespaces {
+		rpo := fmt.Sprintf("%g hour recovery-point objective", ns.RPO.Hours())
+		switch {
+		case ns.LastBackup.IsZero():
+			add(IssueBackupRPOViolated, "critical", "Namespace", ns.Namespace, ns.Namespace, "No completed backup includes the namespace, violating its "+rpo,
+				"Run a backup now with velero backup create <name> --include-namespaces "+ns.Namespace+", then fix the schedule")
+		case ns.RPOViolated:
+			add(IssueBackupRPOViolated, "critical", "Namespace", ns.Namespace, ns.Namespace,
+				fmt.Sprintf("Last completed backup is %.0f hours old, violating the %s", now.Sub(ns.LastBackup).Hours(), rpo),
+				"Check why recent backups failed (velero backup describe), starting after "+ns.LastBackupName)
+		}
+		if len(ns.Schedules) == 0 {
+			add(IssueBackupNoSchedule, "warning", "Namespace", ns.Namespace, ns.Namespace, "No enabled backup schedule includes the namespace",
+				"Create one with velero schedule create <name> --schedule <cron> --include-namespaces "+ns.Namespace)
+		}
+	}
+
+	// CI workloads
+	for _, f := range health.Pipelines.Failing {
+		resource := "Pipeline"
+		if f.Tool == PipelineArgo {
+			resource = "Workflow"
+		}
+		severity := "warning"
+		if f.Failed == f.Total && f.Total > 1 {
+			severity = "critical"
+		}
+		add(IssuePipelineFailing, severity, resource, f.Namespace, f.Pipeline,
+			fmt.Sprintf("%d of %d runs failed in the last %.0f hours", f.Failed, f.Total, PipelineFailureWindow.Hours()),
+			fmt.Sprintf("Check the logs of the latest failed run %s: %s", f.LastRun, f.LastMessage))
+	}
+	for _, r := range health.Pipelines.Stuck {
+		resource := "PipelineRun"
+		if r.Tool == PipelineArgo {
+			resource = "Workflow"
+		}
+		add(IssuePipelineStuck, "warning", resource, r.Namespace, r.Name, fmt.Sprintf("Run has been running since %s", r.Started.Format(time.RFC3339)),
+			"Check which step is hanging; set a timeout or activeDeadlineSeconds on the pipeline so runs cannot hang forever")
+	}
+	for _, p := range health.Pipelines.PendingPods {
+		message := fmt.Sprintf("Pod of run %s has been pending for over %.0f minutes", p.Run, PipelinePodPendingAfter.Minutes())
+		if p.Reason != "" {
+			message += ": " + p.Reason
+		}
+		add(IssuePipelinePodPending, "warning", "Pod", p.Namespace, p.Pod, message,
+			"Check the pod's events for scheduling or image pull failures, and whether the namespace's quota or node capacity is exhausted")
+	}
+	for _, b := range health.Pipelines.Cleanup {
+		add(IssuePipelineCleanup, "info", "Namespace", b.Namespace, b.Namespace,
+			fmt.Sprintf("%d completed %s runs older than %.0f days can be cleaned up", b.Completed, b.Tool, PipelineCleanupAfter.Hours()/24),
+			"Configure a TTL or pod GC strategy for workflows, or a pruner for Tekton pipeline runs")
+	}
+
+	// GitOps applications, last so they can point at the issues in the namespaces they deploy to
+	for _, app := range health.GitOps.Applications {
+		resource, tool := "Application", "Argo CD application"
+		if app.Tool == GitOpsFlux {
+			resource, tool = "Kustomization", "Flux kustomization"
+		}
+		impact := gitOpsImpact(health, app)
+		switch {
+		case app.SyncFailed:
+			add(IssueGitOpsSyncFailed, "critical", resource, app.Namespace, app.Name, tool+" failed to sync"+impact,
+				fmt.Sprintf("Fix the manifests at revision %q or roll back the commit: %s", app.Revision, app.SyncError))
+		case app.Sync == argoOutOfSync && !app.Suspended:
+			add(IssueGitOpsOutOfSync, "warning", resource, app.Namespace, app.Name, tool+" is out of sync with Git"+impact,
+				"Sync the application, or check whether the drift comes from manual changes or a mutating webhook")
+		}
+		switch {
+		case app.Health == argoDegraded || app.Health == argoMissing:
+			add(IssueGitOpsDegraded, "critical", resource, app.Namespace, app.Name, tool+" is "+app.Health+impact,
+				"Check the application's resources in the Argo CD UI or with argocd app get: "+app.Message)
+		case app.Suspended:
+			add(IssueGitOpsSuspended, "info", resource, app.Namespace, app.Name, tool+" is suspended and not reconciling changes",
+				"Resume the application once the reason for suspending it is resolved")
+		}
+	}
+
+	// Keep the report stable across runs
+	sortIssues(health.Issues)
+}
+
+// sortIssues orders issues by severity, then resource, namespace and name
+func sortIssues(issues []HealthIssue) {
+	sort.SliceStable(issues, func(i, j int) bool {
+		if SeverityRank(issues[i].Severity) != SeverityRank(issues[j].Severity) {
+			return SeverityRank(issues[i].Severity) < SeverityRank(issues[j].Severity)
+		}
+		a, b := issues[i], issues[j]
+		return a.Resource+"/"+a.Namespace+"/"+a.Name < b.Resource+"/"+b.Namespace+"/"+b.Name
+	})
+}
+
+// Health score penalty per issue severity
+var severityPenalty = map[string]int{
+	"critical": 10,
+	"warning":  3,
+	"info":     1,
+}
+
+// calculateHealthScore returns 100 minus penalties for each issue. Issues raised inside a
+// maintenance window count at a quarter of their usual penalty.
+func calculateHealthScore(health *ClusterHealth) int {
+	penalty := 0.0
+	for _, issue := range health.Issues {
+		penalty += IssuePenalty(issue)
+	}
+	return scoreFromPenalty(penalty)
+}
+
+// NamespaceScores scores each namespace with health data or issues the way the cluster is
+// scored, from the penalties of the issues raised in it
+func NamespaceScores(health *ClusterHealth) map[string]int {
+	penalties := make(map[string]float64, len(health.NamespaceHealth))
+	for ns := range health.NamespaceHealth {
+		penalties[ns] = 0
+	}
+	for _, issue := range health.Issues {
+		if issue.Namespace != "" {
+			penalties[issue.Namespace] += IssuePenalty(issue)
+		}
+	}
+
+	scores := make(map[string]int, len(penalties))
+	for ns, penalty := range penalties {
+		scores[ns] = scoreFromPenalty(penalty)
+	}
+	return scores
+}
+
+// IssuePenalty is the score penalty of an issue and its correlated symptoms
+func IssuePenalty(issue HealthIssue) float64 {
+	return issuePenalty(issue, 1)
+}
+
+// issuePenalty weighs an issue's severity penalty, relaxing it inside maintenance windows
+func issuePenalty(issue HealthIssue, weight float64) float64 {
+	if issue.Suppressed {
+		weight *= 0.25
+	}
+	penalty := float64(severityPenalty[issue.Severity]) * weight
+	// Correlated symptoms count less than their root cause
+	for _, child := range issue.Children {
+		penalty += issuePenalty(child, weight*0.25)
+	}
+	return penalty
+}
+
+// scoreFromPenalty converts a total penalty into a 0-100 score
+func scoreFromPenalty(penalty float64) int {
+	score := 100 - int(math.Round(penalty))
+	if score < 0 {
+		score = 0
+	}
+	return score
+}
+
+// ApplyMaintenance marks the issues covered by active maintenance windows as suppressed,
+// records the window names and relaxes the health score accordingly. inWindow reports
+// whether a namespace ("" for cluster-scoped issues) is under maintenance.
+func (h *ClusterHealth) ApplyMaintenance(windows []string, inWindow func(namespace string) bool) {
+	if len(windows) == 0 {
+		return
+	}
+
+	h.MaintenanceWindows = windows
+	var suppress func(issues []HealthIssue)
+	suppress = func(issues []HealthIssue) {
+		for i := range issues {
+			issues[i].Suppressed = inWindow(issues[i].Namespace)
+			suppress(issues[i].Children)
+		}
+	}
+	suppress(h.Issues)
+	h.HealthScore = calculateHealthScore(h)
+}
+
+// SeverityRank orders severities from most to least severe, so lower ranks sort first
+func SeverityRank(severity string) int {
+	switch severity {
+	case "critical":
+		return 0
+	case "warning":
+		return 1
+	default:
+		return 2
+	}
+}
+
+// gitOpsImpact describes the health of the namespaces a GitOps application deploys to, so an
+// application's failure can be matched with the namespaces it broke
+func gitOpsImpact(health *ClusterHealth, app GitOpsApplication) string {
+	var parts []string
+	for _, ns := range app.Destinations {
+		issues := 0
+		for _, issue := range health.Issues {
+			if issue.Namespace == ns && issue.Resource != "Application" && issue.Resource != "Kustomization" {
+				issues++
+			}
+		}
+		if issues == 0 {
+			continue
+		}
+		part := fmt.Sprintf("%s has %d issues", ns, issues)
+		if nh, ok := health.NamespaceHealth[ns]; ok {
+			part += fmt.Sprintf(", health score %d", nh.HealthScore)
+		}
+		parts = append(parts, part)
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "; namespace " + strings.Join(parts, "; namespace ")
 }
@@ -0,0 +1,153 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/rest"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/snapshot"
+)
+
+// fakeClientset is a fake clientset whose core REST client answers raw requests, such as
+// /readyz, /metrics and kubelet proxies, with 404 Not Found. The fake's own REST client
+// is nil.
+type fakeClientset struct {
+	*fake.Clientset
+	rest rest.Interface
+}
+
+func (c fakeClientset) CoreV1() corev1.CoreV1Interface {
+	return fakeCoreV1{c.Clientset.CoreV1(), c.rest}
+}
+
+type fakeCoreV1 struct {
+	corev1.CoreV1Interface
+	rest rest.Interface
+}
+
+func (c fakeCoreV1) RESTClient() rest.Interface {
+	return c.rest
+}
+
+// roundTripFunc answers HTTP requests with a function
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+// syntheticClientset returns a fake clientset serving the snapshot's nodes and pods
+func syntheticClientset(tb testing.TB, snap *snapshot.ClusterSnapshot) kubernetes.Interface {
+	objects := make([]runtime.Object, 0, len(snap.Nodes)+len(snap.Pods))
+	for i := range snap.Nodes {
+		objects = append(objects, &snap.Nodes[i])
+	}
+	for i := range snap.Pods {
+		objects = append(objects, &snap.Pods[i])
+	}
+
+	notFound := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusNotFound, Header: http.Header{}, Body: io.NopCloser(strings.NewReader("")), Request: r}, nil
+	})
+	client, err := rest.RESTClientFor(&rest.Config{
+		Host:    "http://apiserver.invalid",
+		APIPath: "/api",
+		ContentConfig: rest.ContentConfig{
+			GroupVersion:         &v1.SchemeGroupVersion,
+			NegotiatedSerializer: scheme.Codecs.WithoutConversion(),
+		},
+		Transport: notFound,
+		QPS:       -1,
+	})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return fakeClientset{fake.NewSimpleClientset(objects...), client}
+}
+
+func TestGetClusterHealthFromSnapshotWithoutClientset(t *testing.T) {
+	snap := snapshot.Synthetic(20, 200)
+	health, err := GetClusterHealthFromSnapshot(context.Background(), nil, nil, snap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, section := range health.Sections {
+		if section.State == SectionFailed {
+			t.Errorf("section %s failed without a clientset: %s", name, section.Error)
+		}
+	}
+	if health.NodeStatus.TotalNodes != 20 || health.PodStatus.TotalPods != 200 {
+		t.Errorf("got %d nodes and %d pods, want 20 and 200", health.NodeStatus.TotalNodes, health.PodStatus.TotalPods)
+	}
+}
+
+func BenchmarkGetClusterHealthFromSnapshot(b *testing.B) {
+	log.SetOutput(io.Discard)
+	b.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	for _, size := range []struct{ nodes, pods int }{{20, 200}, {500, 10000}} {
+		b.Run(fmt.Sprintf("%dnodes-%dpods", size.nodes, size.pods), func(b *testing.B) {
+			snap := snapshot.Synthetic(size.nodes, size.pods)
+			clientset := syntheticClientset(b, snap)
+			b.ReportAllocs()
+			for b.Loop() {
+				if _, err := GetClusterHealthFromSnapshot(context.Background(), clientset, nil, snap); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestParseMetricsLabelValues(t *testing.T) {
+	data := []byte(`# TYPE apiserver_admission_webhook_rejection_count counter
+apiserver_admission_webhook_rejection_count{name="policy.example.com",type="validating",error_type="calling_webhook_error",reason="a, \"quoted\" reason"} 3
+# TYPE apiserver_admission_webhook_admission_duration_seconds histogram
+apiserver_admission_webhook_admission_duration_seconds_bucket{name="policy.example.com",type="validating",rejected="true",le="+Inf"} 4
+apiserver_admission_webhook_admission_duration_seconds_sum{name="policy.example.com",type="validating",rejected="true"} 0.5
+apiserver_admission_webhook_admission_duration_seconds_count{name="policy.example.com",type="validating",rejected="true"} 4
+apiserver_storage_objects{resource="events.events.k8s.io"} 12
+`)
+	samples, err := parseMetrics(data)
+	if err != nil {
+		t.Fatalf("parseMetrics() error = %v", err)
+	}
+
+	var reason string
+	for _, sample := range samples {
+		if sample.name == "apiserver_admission_webhook_rejection_count" {
+			reason = sample.labels["reason"]
+		}
+	}
+	if want := `a, "quoted" reason`; reason != want {
+		t.Errorf("reason label = %q, want %q", reason, want)
+	}
+
+	counters := parseWebhookMetrics(samples)["validating/policy.example.com"]
+	if counters.requests != 4 || counters.rejected != 4 || counters.latencySum != 0.5 || counters.errors != 3 {
+		t.Errorf("webhook counters = %+v, want 4 requests, 4 rejected, 0.5s latency and 3 errors", counters)
+	}
+
+	counts := make(map[string]int64)
+	parseEtcdMetrics(samples, counts)
+	if counts["events"] != 12 {
+		t.Errorf("events count = %d, want 12", counts["events"])
+	}
+
+	if _, err := parseMetrics([]byte("not a metric line {")); err == nil {
+		t.Error("parseMetrics() of malformed input succeeded, want an error")
+	}
+}
@@ -0,0 +1,297 @@
+package health
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/retry"
+	"github.com/ochestra-tech/ochestra-ai/pkg/snapshot"
+)
+
+// HelmStatus reports the latest revision of each Helm release found in the cluster
+type HelmStatus struct {
+	Releases []HelmRelease `json:"releases,omitempty"`
+}
+
+// HelmRelease is the latest revision of a release and what is wrong with it
+type HelmRelease struct {
+	Namespace         string    `json:"namespace"`
+	Name              string    `json:"name"`
+	Revision          int       `json:"revision"`
+	Status            string    `json:"status"`
+	Chart             string    `json:"chart,omitempty"` // name-version
+	Updated           time.Time `json:"updated,omitempty"`
+	Description       string    `json:"description,omitempty"`
+	MissingImages     []string  `json:"missingImages,omitempty"`     // images pods of the release fail to pull
+	MissingNamespaces []string  `json:"missingNamespaces,omitempty"` // namespaces the manifest deploys to that do not exist
+	Orphaned          bool      `json:"orphaned"`                    // deployed, but its workloads and services are all gone
+}
+
+// Helm release states
+const (
+	HelmDeployed        = "deployed"
+	HelmFailed          = "failed"
+	HelmPendingInstall  = "pending-install"
+	HelmPendingUpgrade  = "pending-upgrade"
+	HelmPendingRollback = "pending-rollback"
+	HelmUninstalling    = "uninstalling"
+)
+
+// HelmEnabled turns on reading the release Secrets Helm stores. They hold each release's
+// rendered values, credentials included, so the check is opt-in and its RBAC is in a
+// separate ClusterRole.
+var HelmEnabled bool
+
+// HelmPendingAfter is how long a release may stay pending before it is reported as stuck;
+// Helm refuses further upgrades of a release until its pending operation is cleared
+var HelmPendingAfter = 15 * time.Minute
+
+// helmReleaseSecretType is the type of the Secrets Helm 3 stores releases in
+const helmReleaseSecretType = "helm.sh/release.v1"
+
+// helmReleaseRecord is the part of a stored Helm release the check reads
+type helmReleaseRecord struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Version   int    `json:"version"`
+	Info      struct {
+		Status       string    `json:"status"`
+		LastDeployed time.Time `json:"last_deployed"`
+		Description  string    `json:"description"`
+	} `json:"info"`
+	Chart struct {
+		Metadata struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		} `json:"metadata"`
+	} `json:"chart"`
+	Manifest string `json:"manifest"`
+}
+
+// checkHelmReleases reads the release Secrets Helm stores and inspects the latest revision
+// of each release when HelmEnabled is set
+func checkHelmReleases(ctx context.Context, clientset kubernetes.Interface, snap *snapshot.ClusterSnapshot, status *HelmStatus) error {
+	if !HelmEnabled || clientset == nil {
+		return nil
+	}
+	secrets, err := retry.Value(ctx, retry.DefaultBackoff, func() (*v1.SecretList, error) {
+		return clientset.CoreV1().Secrets("").List(ctx, metav1.ListOptions{
+			LabelSelector: "owner=helm",
+			FieldSelector: "type=" + helmReleaseSecretType,
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list Helm release secrets: %w", err)
+	}
+	namespaces, err := retry.Value(ctx, retry.DefaultBackoff, func() (*v1.NamespaceList, error) {
+		return clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list namespaces: %w", err)
+	}
+	existing := make(map[string]bool, len(namespaces.Items))
+	for _, ns := range namespaces.Items {
+		existing[ns.Name] = true
+	}
+
+	// Keep the latest revision of each release
+	latest := make(map[string]*v1.Secret)
+	for i := range secrets.Items {
+		secret := &secrets.Items[i]
+		key := secret.Namespace + "/" + secret.Labels["name"]
+		if current, ok := latest[key]; !ok || helmRevision(secret) > helmRevision(current) {
+			latest[key] = secret
+		}
+	}
+
+	partial := &PartialError{}
+	for _, secret := range latest {
+		record, err := decodeHelmRelease(secret.Data["release"])
+		if err != nil {
+			partial.Errors = append(partial.Errors, fmt.Errorf("failed to decode Helm release secret %s/%s: %w", secret.Namespace, secret.Name, err))
+			continue
+		}
+		status.Releases = append(status.Releases, inspectHelmRelease(record, snap, existing))
+	}
+	sort.Slice(status.Releases, func(i, j int) bool {
+		a, b := status.Releases[i], status.Releases[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+
+	if len(partial.Errors) > 0 {
+		return partial
+	}
+	return nil
+}
+
+// helmRevision returns the revision a release secret stores
+func helmRevision(secret *v1.Secret) int {
+	revision, _ := strconv.Atoi(secret.Labels["version"])
+	return revision
+}
+
+// decodeHelmRelease decodes a release as Helm stores it: base64 of gzipped JSON, inside the
+// Secret's own base64
+func decodeHelmRelease(data []byte) (*helmReleaseRecord, error) {
+	raw, err := base64.StdEncoding.DecodeString(string(data))
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) > 2 && raw[0] == 0x1f && raw[1] == 0x8b {
+		reader, err := gzip.NewReader(bytes.NewReader(raw))
+		if err != nil {
+			return nil, err
+		}
+		defer reader.Close()
+		if raw, err = io.ReadAll(reader); err != nil {
+			return nil, err
+		}
+	}
+	var record helmReleaseRecord
+	if err := json.Unmarshal(raw, &record); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// helmManifestObject is the part of a rendered manifest document the check reads
+type helmManifestObject struct {
+	Kind     string `json:"kind"`
+	Metadata struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	} `json:"metadata"`
+}
+
+// inspectHelmRelease finds the images and namespaces a release's manifest references that
+// are missing, and whether its workloads and services are all gone
+func inspectHelmRelease(record *helmReleaseRecord, snap *snapshot.ClusterSnapshot, namespaces map[string]bool) HelmRelease {
+	release := HelmRelease{
+		Namespace:   record.Namespace,
+		Name:        record.Name,
+		Revision:    record.Version,
+		Status:      record.Info.Status,
+		Updated:     record.Info.LastDeployed,
+		Description: record.Info.Description,
+	}
+	if record.Chart.Metadata.Name != "" {
+		release.Chart = record.Chart.Metadata.Name + "-" + record.Chart.Metadata.Version
+	}
+
+	images := make(map[string]bool)
+	releaseNamespaces := map[string]bool{record.Namespace: true}
+	missingNamespaces := make(map[string]bool)
+	tracked, found := 0, 0
+	for _, doc := range strings.Split(record.Manifest, "\n---") {
+		var obj helmManifestObject
+		if err := yaml.Unmarshal([]byte(doc), &obj); err != nil || obj.Kind == "" {
+			continue
+		}
+		namespace := obj.Metadata.Namespace
+		if namespace == "" {
+			namespace = record.Namespace
+		}
+		releaseNamespaces[namespace] = true
+		if obj.Kind != "Namespace" && !namespaces[namespace] {
+			missingNamespaces[namespace] = true
+		}
+
+		var fields interface{}
+		if err := yaml.Unmarshal([]byte(doc), &fields); err == nil {
+			collectImages(fields, images)
+		}
+
+		switch obj.Kind {
+		case "Deployment", "DaemonSet", "Service":
+			tracked++
+			if snapshotHas(snap, obj.Kind, namespace, obj.Metadata.Name) {
+				found++
+			}
+		}
+	}
+	for namespace := range missingNamespaces {
+		release.MissingNamespaces = append(release.MissingNamespaces, namespace)
+	}
+	sort.Strings(release.MissingNamespaces)
+
+	// An image is missing when pods in the release's namespaces fail to pull it
+	for _, pod := range snap.Pods {
+		if !releaseNamespaces[pod.Namespace] {
+			continue
+		}
+		for _, cs := range append(append([]v1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...) {
+			if w := cs.State.Waiting; w != nil && (w.Reason == "ErrImagePull" || w.Reason == "ImagePullBackOff" || w.Reason == "InvalidImageName") &&
+				images[cs.Image] && !slices.Contains(release.MissingImages, cs.Image) {
+				release.MissingImages = append(release.MissingImages, cs.Image)
+			}
+		}
+	}
+	sort.Strings(release.MissingImages)
+
+	// Without all of the snapshot's workloads and services, gone objects cannot be told apart
+	complete := snap.Errors["deployments"] == nil && snap.Errors["daemonsets"] == nil && snap.Errors["services"] == nil
+	release.Orphaned = release.Status == HelmDeployed && tracked > 0 && found == 0 && complete
+	return release
+}
+
+// collectImages adds the image of every container spec in a decoded manifest document
+func collectImages(value interface{}, images map[string]bool) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if image, ok := v["image"].(string); ok {
+			if _, isContainer := v["name"]; isContainer {
+				images[image] = true
+			}
+		}
+		for _, child := range v {
+			collectImages(child, images)
+		}
+	case []interface{}:
+		for _, child := range v {
+			collectImages(child, images)
+		}
+	}
+}
+
+// snapshotHas reports whether the snapshot holds the object
+func snapshotHas(snap *snapshot.ClusterSnapshot, kind, namespace, name string) bool {
+	switch kind {
+	case "Deployment":
+		for _, d := range snap.Deployments {
+			if d.Namespace == namespace && d.Name == name {
+				return true
+			}
+		}
+	case "DaemonSet":
+		for _, d := range snap.DaemonSets {
+			if d.Namespace == namespace && d.Name == name {
+				return true
+			}
+		}
+	case "Service":
+		for _, s := range snap.Services {
+			if s.Namespace == namespace && s.Name == name {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,193 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/retry"
+	"github.com/ochestra-tech/ochestra-ai/pkg/snapshot"
+)
+
+// IdleNamespaceAfter is how long a namespace must go without running pods, rollouts and
+// endpoint changes to be reported as idle; 0 disables the check
+var IdleNamespaceAfter = 30 * 24 * time.Hour
+
+// IdleExemptNamespaces are never reported as idle
+var IdleExemptNamespaces = []string{"default", "kube-system", "kube-public", "kube-node-lease"}
+
+// Prices used to estimate what deleting an idle namespace reclaims
+var (
+	IdleStorageCostPerGBHour = 0.00014 // about $0.10 per GB-month of block storage
+	IdleLoadBalancerMonthly  = 16.43   // one cloud load balancer
+)
+
+// endpointsChangeAnnotation is set by the endpoints controller when it last updated the object
+const endpointsChangeAnnotation = "endpoints.kubernetes.io/last-change-trigger-time"
+
+// IdleNamespace is a namespace with no running pods, rollouts or endpoint changes for at
+// least IdleNamespaceAfter, and what deleting it would reclaim
+type IdleNamespace struct {
+	Namespace      string    `json:"namespace"`
+	LastActivity   time.Time `json:"lastActivity"`
+	Deployments    int       `json:"deployments"`
+	Services       int       `json:"services"`
+	LoadBalancers  int       `json:"loadBalancers"`
+	Volumes        int       `json:"volumes"`      // PersistentVolumeClaims
+	StorageBytes   int64     `json:"storageBytes"` // capacity of its claims
+	MonthlyReclaim float64   `json:"monthlyReclaim"`
+}
+
+// IdleFor returns how long the namespace has been idle
+func (n IdleNamespace) IdleFor(now time.Time) time.Duration {
+	return now.Sub(n.LastActivity)
+}
+
+// checkIdleNamespaces finds namespaces whose last activity is older than IdleNamespaceAfter
+func checkIdleNamespaces(ctx context.Context, clientset kubernetes.Interface, snap *snapshot.ClusterSnapshot, now time.Time, idle *[]IdleNamespace) error {
+	if clientset == nil || IdleNamespaceAfter <= 0 {
+		return nil
+	}
+	for _, resource := range []string{"deployments", "services", "endpoints"} {
+		if err := snap.Errors[resource]; err != nil {
+			return err
+		}
+	}
+
+	namespaces, err := retry.Value(ctx, retry.DefaultBackoff, func() (*v1.NamespaceList, error) {
+		return clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list namespaces: %w", err)
+	}
+	claims, err := retry.Value(ctx, retry.DefaultBackoff, func() (*v1.PersistentVolumeClaimList, error) {
+		return clientset.CoreV1().PersistentVolumeClaims("").List(ctx, metav1.ListOptions{})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list persistent volume claims: %w", err)
+	}
+
+	*idle = findIdleNamespaces(namespaces.Items, snap, claims.Items, now)
+	return nil
+}
+
+// findIdleNamespaces returns the namespaces with no running pods or ready endpoints whose
+// latest pod, rollout or endpoints change is older than IdleNamespaceAfter, most idle first
+func findIdleNamespaces(namespaces []v1.Namespace, snap *snapshot.ClusterSnapshot, claims []v1.PersistentVolumeClaim, now time.Time) []IdleNamespace {
+	candidates := make(map[string]*IdleNamespace)
+	for _, ns := range namespaces {
+		if slices.Contains(IdleExemptNamespaces, ns.Name) || ns.Status.Phase == v1.NamespaceTerminating {
+			continue
+		}
+		candidates[ns.Name] = &IdleNamespace{Namespace: ns.Name, LastActivity: ns.CreationTimestamp.Time}
+	}
+	active := func(namespace string, t time.Time) {
+		if n, ok := candidates[namespace]; ok && t.After(n.LastActivity) {
+			n.LastActivity = t
+		}
+	}
+
+	// Pods: running ones make the namespace active; others date its last activity
+	for _, pod := range snap.Pods {
+		if pod.Status.Phase == v1.PodRunning {
+			delete(candidates, pod.Namespace)
+			continue
+		}
+		active(pod.Namespace, pod.CreationTimestamp.Time)
+		for _, status := range pod.Status.ContainerStatuses {
+			if t := status.State.Terminated; t != nil {
+				active(pod.Namespace, t.FinishedAt.Time)
+			}
+			if t := status.LastTerminationState.Terminated; t != nil {
+				active(pod.Namespace, t.FinishedAt.Time)
+			}
+		}
+	}
+
+	// Deployments: rollouts and scaling update the Progressing condition
+	for _, d := range snap.Deployments {
+		if n, ok := candidates[d.Namespace]; ok {
+			n.Deployments++
+		}
+		active(d.Namespace, d.CreationTimestamp.Time)
+		for _, c := range d.Status.Conditions {
+			active(d.Namespace, c.LastUpdateTime.Time)
+		}
+	}
+
+	// Services and endpoints: ready addresses can still take traffic
+	for _, svc := range snap.Services {
+		if n, ok := candidates[svc.Namespace]; ok {
+			n.Services++
+			if svc.Spec.Type == v1.ServiceTypeLoadBalancer {
+				n.LoadBalancers++
+			}
+		}
+	}
+	for _, ep := range snap.Endpoints {
+		for _, subset := range ep.Subsets {
+			if len(subset.Addresses) > 0 {
+				delete(candidates, ep.Namespace)
+			}
+		}
+		if t, err := time.Parse(time.RFC3339Nano, ep.Annotations[endpointsChangeAnnotation]); err == nil {
+			active(ep.Namespace, t)
+		}
+	}
+
+	for _, claim := range claims {
+		if n, ok := candidates[claim.Namespace]; ok {
+			n.Volumes++
+			storage := claim.Status.Capacity[v1.ResourceStorage]
+			if storage.IsZero() {
+				storage = claim.Spec.Resources.Requests[v1.ResourceStorage]
+			}
+			n.StorageBytes += storage.Value()
+		}
+	}
+
+	result := make([]IdleNamespace, 0)
+	for _, n := range candidates {
+		if n.IdleFor(now) < IdleNamespaceAfter {
+			continue
+		}
+		n.MonthlyReclaim = float64(n.StorageBytes)/(1<<30)*IdleStorageCostPerGBHour*24*30 +
+			float64(n.LoadBalancers)*IdleLoadBalancerMonthly
+		result = append(result, *n)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].LastActivity.Equal(result[j].LastActivity) {
+			return result[i].LastActivity.Before(result[j].LastActivity)
+		}
+		return result[i].Namespace < result[j].Namespace
+	})
+	return result
+}
+
+// idleHoldings describes what an idle namespace still holds, e.g. "2 volumes (20Gi), 1 load balancer"
+func idleHoldings(n IdleNamespace) string {
+	var parts []string
+	if n.Volumes > 0 {
+		parts = append(parts, fmt.Sprintf("%d volumes (%dGi)", n.Volumes, n.StorageBytes>>30))
+	}
+	if n.LoadBalancers > 0 {
+		parts = append(parts, fmt.Sprintf("%d load balancers", n.LoadBalancers))
+	}
+	if n.Deployments > 0 {
+		parts = append(parts, fmt.Sprintf("%d deployments", n.Deployments))
+	}
+	if n.Services > 0 {
+		parts = append(parts, fmt.Sprintf("%d services", n.Services))
+	}
+	if len(parts) == 0 {
+		return "no workloads or storage"
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,170 @@
+package health
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// Node software components tracked in the inventory
+const (
+	ComponentKubelet = "kubelet"
+	ComponentRuntime = "containerRuntime"
+	ComponentOSImage = "osImage"
+	ComponentKernel  = "kernel"
+)
+
+var inventoryComponents = []string{ComponentKubelet, ComponentRuntime, ComponentOSImage, ComponentKernel}
+
+// InventoryStatus lists the node software versions in the cluster
+type InventoryStatus struct {
+	Versions map[string]map[string][]string `json:"versions"` // component -> version -> nodes
+	Outliers []VersionFinding               `json:"outliers,omitempty"`
+	Denied   []VersionFinding               `json:"denied,omitempty"`
+}
+
+// VersionFinding is a node running a version worth attention
+type VersionFinding struct {
+	Node      string `json:"node"`
+	Component string `json:"component"`
+	Version   string `json:"version"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// DeniedVersion marks a component version with a known critical bug. A version ending in
+// "*" matches any version with that prefix.
+type DeniedVersion struct {
+	Component string `json:"component"`
+	Version   string `json:"version"`
+	Reason    string `json:"reason"`
+	Severity  string `json:"severity,omitempty"` // defaults to "critical"
+}
+
+// matches reports whether the denylist entry covers a component version
+func (d DeniedVersion) matches(component, version string) bool {
+	if d.Component != component {
+		return false
+	}
+	if prefix, ok := strings.CutSuffix(d.Version, "*"); ok {
+		return strings.HasPrefix(version, prefix)
+	}
+	return d.Version == version
+}
+
+// VersionDenylist holds the versions flagged by the inventory check
+var VersionDenylist []DeniedVersion
+
+// LoadVersionDenylist reads a denylist file of the form {"denylist": [...]}
+func LoadVersionDenylist(path string) ([]DeniedVersion, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read version denylist: %w", err)
+	}
+
+	var file struct {
+		Denylist []DeniedVersion `json:"denylist"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse version denylist: %w", err)
+	}
+	for i, d := range file.Denylist {
+		if !slices.Contains(inventoryComponents, d.Component) || d.Version == "" {
+			return nil, fmt.Errorf("denylist entry %d must have a version and a component of %s", i, strings.Join(inventoryComponents, ", "))
+		}
+	}
+	return file.Denylist, nil
+}
+
+// nodeVersions returns the versions of a node's software components
+func nodeVersions(info v1.NodeSystemInfo) map[string]string {
+	return map[string]string{
+		ComponentKubelet: info.KubeletVersion,
+		ComponentRuntime: info.ContainerRuntimeVersion,
+		ComponentOSImage: info.OSImage,
+		ComponentKernel:  info.KernelVersion,
+	}
+}
+
+// NodeInventory groups nodes by component version, flags nodes whose version differs from
+// the one most nodes run, and nodes running a version in VersionDenylist
+func NodeInventory(nodes []v1.Node) InventoryStatus {
+	var inventory InventoryStatus
+	status := &inventory
+	status.Versions = make(map[string]map[string][]string)
+	for _, component := range inventoryComponents {
+		status.Versions[component] = make(map[string][]string)
+	}
+
+	for _, node := range nodes {
+		for component, version := range nodeVersions(node.Status.NodeInfo) {
+			if version == "" {
+				continue
+			}
+			status.Versions[component][version] = append(status.Versions[component][version], node.Name)
+
+			for _, d := range VersionDenylist {
+				if d.matches(component, version) {
+					status.Denied = append(status.Denied, VersionFinding{Node: node.Name, Component: component, Version: version, Reason: d.Reason})
+				}
+			}
+		}
+	}
+
+	for _, component := range inventoryComponents {
+		versions := status.Versions[component]
+		if len(versions) < 2 {
+			continue
+		}
+
+		// The most common version is the fleet's baseline; ties go to the newest-looking one
+		common, total := "", 0
+		for version, nodes := range versions {
+			total += len(nodes)
+			if common == "" || len(nodes) > len(versions[common]) || (len(nodes) == len(versions[common]) && version > common) {
+				common = version
+			}
+		}
+		for version, nodes := range versions {
+			if version == common {
+				continue
+			}
+			for _, node := range nodes {
+				status.Outliers = append(status.Outliers, VersionFinding{
+					Node:      node,
+					Component: component,
+					Version:   version,
+					Reason:    fmt.Sprintf("%d of %d nodes run %s", len(versions[common]), total, common),
+				})
+			}
+		}
+	}
+
+	sortFindings(status.Outliers)
+	sortFindings(status.Denied)
+	return inventory
+}
+
+// sortFindings orders findings by component, then node
+func sortFindings(findings []VersionFinding) {
+	sort.Slice(findings, func(i, j int) bool {
+		if findings[i].Component != findings[j].Component {
+			return findings[i].Component < findings[j].Component
+		}
+		return findings[i].Node < findings[j].Node
+	})
+}
+
+// deniedSeverity returns the severity of the denylist entry behind a finding
+func deniedSeverity(finding VersionFinding) string {
+	for _, d := range VersionDenylist {
+		if d.matches(finding.Component, finding.Version) && d.Severity != "" {
+			return d.Severity
+		}
+	}
+	return "critical"
+}
@@ -0,0 +1,135 @@
+package health
+
+import (
+	"math"
+	"net"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/snapshot"
+)
+
+// IPStatus compares pod IPs in use with the addresses each node can hand out
+type IPStatus struct {
+	Used     int           `json:"used"`     // pod IPs in use across nodes
+	Capacity int           `json:"capacity"` // pod IPs available across nodes
+	Usage    float64       `json:"usage"`    // fraction of capacity in use
+	AtRisk   []NodeIPUsage `json:"atRisk,omitempty"`
+}
+
+// NodeIPUsage is a node's pod IP usage against its tightest limit
+type NodeIPUsage struct {
+	Node     string  `json:"node"`
+	PodCIDR  string  `json:"podCIDR,omitempty"`
+	Used     int     `json:"used"`
+	Capacity int     `json:"capacity"`
+	Limit    string  `json:"limit"` // what sets the capacity: podCIDR or eni
+	Usage    float64 `json:"usage"`
+}
+
+// ENILimit is how many network interfaces an instance type can attach and how many IPv4
+// addresses each can hold, which caps pods on nodes using the AWS VPC CNI
+type ENILimit struct {
+	ENIs      int `json:"enis"`
+	IPsPerENI int `json:"ipsPerENI"`
+}
+
+// ENILimits are the limits of common EC2 instance types. Clusters using prefix delegation
+// are not bound by them and can set this to nil.
+var ENILimits = map[string]ENILimit{
+	"t3.medium":   {3, 6},
+	"t3.large":    {3, 12},
+	"t3.xlarge":   {4, 15},
+	"t3.2xlarge":  {4, 15},
+	"m5.large":    {3, 10},
+	"m5.xlarge":   {4, 15},
+	"m5.2xlarge":  {4, 15},
+	"m5.4xlarge":  {8, 30},
+	"m5.8xlarge":  {8, 30},
+	"m6i.large":   {3, 10},
+	"m6i.xlarge":  {4, 15},
+	"m6i.2xlarge": {4, 15},
+	"c5.large":    {3, 10},
+	"c5.xlarge":   {4, 15},
+	"c5.2xlarge":  {4, 15},
+	"r5.large":    {3, 10},
+	"r5.xlarge":   {4, 15},
+	"r5.2xlarge":  {4, 15},
+}
+
+// Fractions of pod IP capacity at which exhaustion is a warning and critical
+var (
+	IPWarningRatio  = 0.8
+	IPCriticalRatio = 0.95
+)
+
+// IPUsage counts pods that take a pod IP on each node and compares them with the smaller of
+// the node's pod CIDR size and its ENI limit. Nodes with neither, such as those whose CNI
+// allocates from a shared pool, are left out.
+func IPUsage(snap *snapshot.ClusterSnapshot) IPStatus {
+	var status IPStatus
+
+	used := make(map[string]int, len(snap.Nodes))
+	for i := range snap.Pods {
+		pod := &snap.Pods[i]
+		if pod.Spec.NodeName == "" || pod.Spec.HostNetwork ||
+			pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+			continue
+		}
+		used[pod.Spec.NodeName]++
+	}
+
+	for _, node := range snap.Nodes {
+		usage := NodeIPUsage{Node: node.Name, PodCIDR: node.Spec.PodCIDR, Used: used[node.Name]}
+		usage.Capacity, usage.Limit = nodeIPCapacity(&node)
+		if usage.Capacity == 0 || snapshot.IsVirtualNode(&node) {
+			continue
+		}
+
+		usage.Usage = float64(usage.Used) / float64(usage.Capacity)
+		status.Used += usage.Used
+		status.Capacity += usage.Capacity
+		if usage.Usage >= IPWarningRatio {
+			status.AtRisk = append(status.AtRisk, usage)
+		}
+	}
+	if status.Capacity > 0 {
+		status.Usage = float64(status.Used) / float64(status.Capacity)
+	}
+
+	sort.Slice(status.AtRisk, func(i, j int) bool { return status.AtRisk[i].Usage > status.AtRisk[j].Usage })
+	return status
+}
+
+// nodeIPCapacity returns the pod IPs a node can hand out, the smaller of its pod CIDR size
+// and its ENI limit, and which of the two it is. It returns 0 if neither is known.
+func nodeIPCapacity(node *v1.Node) (int, string) {
+	capacity, limit := math.MaxInt, ""
+	if size := cidrAddresses(node.Spec.PodCIDR); size > 0 {
+		capacity, limit = size, "podCIDR"
+	}
+	if eni, ok := ENILimits[node.Labels[v1.LabelInstanceTypeStable]]; ok {
+		if addresses := eni.ENIs * (eni.IPsPerENI - 1); addresses < capacity {
+			capacity, limit = addresses, "eni"
+		}
+	}
+	if capacity == math.MaxInt {
+		return 0, ""
+	}
+	return capacity, limit
+}
+
+// cidrAddresses returns the usable pod addresses in an IPv4 CIDR, leaving out the network
+// and broadcast addresses; IPv6 ranges are too large to exhaust and return 0
+func cidrAddresses(cidr string) int {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return 0
+	}
+	ones, bits := network.Mask.Size()
+	if bits != 32 || bits-ones < 2 {
+		return 0
+	}
+	return 1<<(bits-ones) - 2
+}
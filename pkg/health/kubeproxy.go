@@ -0,0 +1,230 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/snapshot"
+)
+
+// KubeProxyStatus reports kube-proxy health on each node. Clusters that replace kube-proxy,
+// for example with Cilium, have no kube-proxy pods and report Installed false.
+type KubeProxyStatus struct {
+	Installed      bool            `json:"installed"`
+	Mode           string          `json:"mode,omitempty"` // configured mode from the kube-proxy ConfigMap
+	MetricsVisible bool            `json:"metricsVisible"` // metrics could be read from at least one node
+	Nodes          []KubeProxyNode `json:"nodes,omitempty"`
+}
+
+// KubeProxyNode is the kube-proxy pod on one node and what its metrics say about its rules
+type KubeProxyNode struct {
+	Node     string `json:"node"`
+	Pod      string `json:"pod"`
+	Ready    bool   `json:"ready"`
+	Restarts int32  `json:"restarts"`
+	Mode     string `json:"mode,omitempty"` // iptables, ipvs or nftables; kernelspace on Windows
+
+	// From the pod's metrics, when reachable
+	MetricsVisible  bool          `json:"metricsVisible"`
+	LastSync        time.Time     `json:"lastSync,omitempty"`
+	SyncDuration    time.Duration `json:"syncDuration,omitempty"` // average rule sync time
+	PendingChanges  int           `json:"pendingChanges"`         // service and endpoint changes not yet applied
+	RestoreFailures int           `json:"restoreFailures"`        // failed rule syncs since kube-proxy started
+	StaleRules      bool          `json:"staleRules"`             // changes queued longer than KubeProxyStaleAfter
+}
+
+// KubeProxyStaleAfter is how long a queued rule change may wait before the node's service
+// rules, and the conntrack entries kube-proxy clears with them, are considered stale
+var KubeProxyStaleAfter = 5 * time.Minute
+
+// KubeProxySlowSync is the average rule sync time above which kube-proxy is reported as slow
+var KubeProxySlowSync = 5 * time.Second
+
+// kubeProxyMetricsPort is the port kube-proxy serves /metrics and /proxyMode on
+const kubeProxyMetricsPort = "10249"
+
+// windowsProxyMode is the mode kube-proxy runs in on Windows nodes, programming the Host
+// Networking Service instead of iptables
+const windowsProxyMode = "kernelspace"
+
+// kubeProxyProbeLimit is how many pods may fail to serve metrics before the rest are not
+// tried; kube-proxy binds its metrics to localhost by default
+const kubeProxyProbeLimit = 3
+
+// checkKubeProxy finds the kube-proxy pod on each node, its mode, and, when its metrics are
+// reachable through the apiserver's pod proxy, whether its rules are stale or failing to sync
+func checkKubeProxy(ctx context.Context, clientset kubernetes.Interface, snap *snapshot.ClusterSnapshot, status *KubeProxyStatus) error {
+	pods := snap.SelectString("kube-system", "k8s-app in (kube-proxy,kube-proxy-windows)")
+	for _, pod := range snap.SelectString("kube-system", "component=kube-proxy") {
+		if !containsPod(pods, pod) {
+			pods = append(pods, pod)
+		}
+	}
+	if len(pods) == 0 {
+		return nil
+	}
+	status.Installed = true
+
+	if clientset != nil {
+		cm, err := clientset.CoreV1().ConfigMaps("kube-system").Get(ctx, "kube-proxy", metav1.GetOptions{})
+		if err == nil {
+			status.Mode = configuredProxyMode(cm.Data)
+		}
+	}
+
+	windows := make(map[string]bool)
+	for i := range snap.Nodes {
+		windows[snap.Nodes[i].Name] = snapshot.NodeOS(&snap.Nodes[i]) == snapshot.OSWindows
+	}
+
+	now := time.Now()
+	failures := 0
+	for _, pod := range pods {
+		if pod.Spec.NodeName == "" {
+			continue
+		}
+		node := KubeProxyNode{Node: pod.Spec.NodeName, Pod: pod.Name, Ready: podReady(pod), Mode: proxyModeArg(pod)}
+		for _, cs := range pod.Status.ContainerStatuses {
+			node.Restarts += cs.RestartCount
+		}
+
+		if clientset != nil && node.Ready && (status.MetricsVisible || failures < kubeProxyProbeLimit) {
+			proxy := clientset.CoreV1().Pods(pod.Namespace)
+			data, err := proxy.ProxyGet("http", pod.Name, kubeProxyMetricsPort, "/metrics", nil).DoRaw(ctx)
+			if err == nil {
+				err = parseKubeProxyMetrics(data, &node, now)
+			}
+			if err != nil {
+				failures++
+			} else {
+				status.MetricsVisible = true
+				node.MetricsVisible = true
+				if mode, err := proxy.ProxyGet("http", pod.Name, kubeProxyMetricsPort, "/proxyMode", nil).DoRaw(ctx); err == nil {
+					node.Mode = strings.TrimSpace(string(mode))
+				}
+			}
+		}
+		// The ConfigMap configures the Linux kube-proxy; on Windows the only mode is kernelspace
+		if node.Mode == "" && windows[pod.Spec.NodeName] {
+			node.Mode = windowsProxyMode
+		}
+		if node.Mode == "" {
+			node.Mode = status.Mode
+		}
+		status.Nodes = append(status.Nodes, node)
+	}
+
+	sort.Slice(status.Nodes, func(i, j int) bool { return status.Nodes[i].Node < status.Nodes[j].Node })
+	return nil
+}
+
+// parseKubeProxyMetrics reads sync timestamps, durations, pending changes and restore
+// failures from kube-proxy's metrics
+func parseKubeProxyMetrics(data []byte, node *KubeProxyNode, now time.Time) error {
+	samples, err := parseMetrics(data)
+	if err != nil {
+		return err
+	}
+	var lastSync, lastQueued, durationSum, durationCount float64
+	for _, sample := range samples {
+		value := sample.value
+		switch sample.name {
+		case "kubeproxy_sync_proxy_rules_last_timestamp_seconds":
+			lastSync = value
+		case "kubeproxy_sync_proxy_rules_last_queued_timestamp_seconds":
+			lastQueued = value
+		case "kubeproxy_sync_proxy_rules_duration_seconds_sum":
+			durationSum = value
+		case "kubeproxy_sync_proxy_rules_duration_seconds_count":
+			durationCount = value
+		case "kubeproxy_sync_proxy_rules_endpoint_changes_pending", "kubeproxy_sync_proxy_rules_service_changes_pending":
+			node.PendingChanges += int(value)
+		case "kubeproxy_sync_proxy_rules_iptables_restore_failures_total",
+			"kubeproxy_sync_proxy_rules_iptables_partial_restore_failures_total",
+			"kubeproxy_sync_proxy_rules_nftables_sync_failures_total":
+			node.RestoreFailures += int(value)
+		}
+	}
+
+	if lastSync > 0 {
+		node.LastSync = time.Unix(0, int64(lastSync*float64(time.Second)))
+	}
+	if durationCount > 0 {
+		node.SyncDuration = time.Duration(durationSum / durationCount * float64(time.Second))
+	}
+	// A change queued after the last sync that has waited too long means the rules on the
+	// node no longer match the cluster's services and endpoints
+	if lastQueued > lastSync {
+		queued := time.Unix(0, int64(lastQueued*float64(time.Second)))
+		node.StaleRules = now.Sub(queued) > KubeProxyStaleAfter
+	}
+	return nil
+}
+
+// configuredProxyMode reads the mode from a kubeadm-style kube-proxy ConfigMap. An empty
+// mode means the platform default, iptables on Linux.
+func configuredProxyMode(data map[string]string) string {
+	for _, key := range []string{"config.conf", "config"} {
+		config, ok := data[key]
+		if !ok {
+			continue
+		}
+		for _, line := range strings.Split(config, "\n") {
+			if value, ok := strings.CutPrefix(line, "mode:"); ok {
+				if mode := strings.Trim(strings.TrimSpace(value), `"'`); mode != "" {
+					return mode
+				}
+				return "iptables"
+			}
+		}
+	}
+	return ""
+}
+
+// proxyModeArg returns the mode passed to a kube-proxy pod with --proxy-mode, if any
+func proxyModeArg(pod *v1.Pod) string {
+	for _, c := range pod.Spec.Containers {
+		args := append(append([]string{}, c.Command...), c.Args...)
+		for i, arg := range args {
+			if mode, ok := strings.CutPrefix(arg, "--proxy-mode="); ok {
+				return mode
+			}
+			if arg == "--proxy-mode" && i+1 < len(args) {
+				return args[i+1]
+			}
+		}
+	}
+	return ""
+}
+
+// containsPod reports whether pods includes a pod with the same namespace and name
+func containsPod(pods []*v1.Pod, pod *v1.Pod) bool {
+	for _, p := range pods {
+		if p.Namespace == pod.Namespace && p.Name == pod.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// kubeProxySymptoms describes what a node's kube-proxy metrics show is wrong
+func kubeProxySymptoms(node KubeProxyNode) []string {
+	var symptoms []string
+	if node.StaleRules {
+		symptoms = append(symptoms, fmt.Sprintf("rule changes queued for more than %s", KubeProxyStaleAfter))
+	}
+	if node.RestoreFailures > 0 {
+		symptoms = append(symptoms, "rule syncs have failed since kube-proxy started")
+	}
+	if node.SyncDuration > KubeProxySlowSync {
+		symptoms = append(symptoms, fmt.Sprintf("rule syncs take longer than %s", KubeProxySlowSync))
+	}
+	return symptoms
+}
@@ -0,0 +1,96 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/retry"
+	"github.com/ochestra-tech/ochestra-ai/pkg/snapshot"
+)
+
+// LeaseStaleFraction is the fraction of a node lease's duration after which an unrenewed
+// lease is stale. The kubelet renews its lease every quarter of the duration (10s of the
+// default 40s), so at 0.75 three renewals in a row were missed, while the node controller
+// only marks the node NotReady after its grace period, 40s or more.
+var LeaseStaleFraction = 0.75
+
+// defaultLeaseDuration is the kubelet's lease duration when a lease does not set one
+const defaultLeaseDuration = 40 * time.Second
+
+// LeaseStatus compares the kubelet heartbeats in kube-node-lease with the nodes' Ready
+// conditions, so a stopped kubelet shows before its node turns NotReady
+type LeaseStatus struct {
+	Nodes         int          `json:"nodes"`
+	StaleLeases   []StaleLease `json:"staleLeases,omitempty"`
+	MissingLeases []string     `json:"missingLeases,omitempty"` // nodes without a lease
+}
+
+// StaleLease is a node lease that has not been renewed in time
+type StaleLease struct {
+	Node      string        `json:"node"`
+	RenewTime time.Time     `json:"renewTime,omitempty"`
+	Age       time.Duration `json:"age"`       // since the last renewal
+	NodeReady bool          `json:"nodeReady"` // Ready condition not yet changed
+}
+
+// checkNodeLeases reads the node leases and finds the stale ones
+func checkNodeLeases(ctx context.Context, clientset kubernetes.Interface, snap *snapshot.ClusterSnapshot, now time.Time, status *LeaseStatus) error {
+	if clientset == nil {
+		return nil
+	}
+	leases, err := retry.Value(ctx, retry.DefaultBackoff, func() (*coordinationv1.LeaseList, error) {
+		return clientset.CoordinationV1().Leases(v1.NamespaceNodeLease).List(ctx, metav1.ListOptions{})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list node leases: %w", err)
+	}
+	*status = evaluateNodeLeases(snap.Nodes, leases.Items, now)
+	return nil
+}
+
+// evaluateNodeLeases matches each node with its lease, which the kubelet names after the node
+func evaluateNodeLeases(nodes []v1.Node, leases []coordinationv1.Lease, now time.Time) LeaseStatus {
+	byNode := make(map[string]*coordinationv1.Lease, len(leases))
+	for i := range leases {
+		byNode[leases[i].Name] = &leases[i]
+	}
+
+	status := LeaseStatus{Nodes: len(nodes)}
+	for i := range nodes {
+		node := &nodes[i]
+		lease, ok := byNode[node.Name]
+		if !ok {
+			status.MissingLeases = append(status.MissingLeases, node.Name)
+			continue
+		}
+		if lease.Spec.RenewTime == nil {
+			continue // not renewed yet, the kubelet is still registering
+		}
+
+		duration := defaultLeaseDuration
+		if lease.Spec.LeaseDurationSeconds != nil && *lease.Spec.LeaseDurationSeconds > 0 {
+			duration = time.Duration(*lease.Spec.LeaseDurationSeconds) * time.Second
+		}
+		age := now.Sub(lease.Spec.RenewTime.Time)
+		if age < time.Duration(float64(duration)*LeaseStaleFraction) {
+			continue
+		}
+		status.StaleLeases = append(status.StaleLeases, StaleLease{
+			Node:      node.Name,
+			RenewTime: lease.Spec.RenewTime.Time,
+			Age:       age,
+			NodeReady: nodeReady(node),
+		})
+	}
+
+	sort.Strings(status.MissingLeases)
+	sort.Slice(status.StaleLeases, func(i, j int) bool { return status.StaleLeases[i].Node < status.StaleLeases[j].Node })
+	return status
+}
@@ -0,0 +1,222 @@
+package health
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metricsapi "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/snapshot"
+)
+
+// DefaultNamespaceWorkers is how many namespaces are evaluated at once by default
+const DefaultNamespaceWorkers = 8
+
+// NamespaceWorkers bounds the namespaces evaluated at once
+var NamespaceWorkers = DefaultNamespaceWorkers
+
+// slowestNamespaces is how many of the slowest namespaces NamespaceEvaluation keeps
+const slowestNamespaces = 5
+
+// NamespaceEvaluation describes the last per-namespace evaluation, for tuning NamespaceWorkers
+type NamespaceEvaluation struct {
+	Namespaces int               `json:"namespaces"`
+	Workers    int               `json:"workers"`
+	Duration   time.Duration     `json:"duration"`
+	Slowest    []NamespaceTiming `json:"slowest,omitempty"`
+}
+
+// NamespaceTiming is how long one namespace took to evaluate
+type NamespaceTiming struct {
+	Namespace string        `json:"namespace"`
+	Duration  time.Duration `json:"duration"`
+}
+
+// namespaceObjects are the snapshot objects of one namespace
+type namespaceObjects struct {
+	pods        []v1.Pod
+	deployments []*appsv1.Deployment
+	services    []*v1.Service
+	metrics     []*metricsapi.PodMetrics
+}
+
+// checkNamespaceHealth evaluates the pods, Deployments, Services and resource usage of
+// every namespace in the snapshot. The snapshot is grouped by namespace in one pass, then
+// the namespaces are evaluated by at most NamespaceWorkers workers.
+func checkNamespaceHealth(ctx context.Context, snap *snapshot.ClusterSnapshot, health *ClusterHealth) error {
+	start := time.Now()
+
+	groups := make(map[string]*namespaceObjects)
+	group := func(namespace string) *namespaceObjects {
+		g, ok := groups[namespace]
+		if !ok {
+			g = &namespaceObjects{}
+			groups[namespace] = g
+		}
+		return g
+	}
+	for i := range snap.Pods {
+		g := group(snap.Pods[i].Namespace)
+		g.pods = append(g.pods, snap.Pods[i])
+	}
+	for i := range snap.Deployments {
+		g := group(snap.Deployments[i].Namespace)
+		g.deployments = append(g.deployments, &snap.Deployments[i])
+	}
+	for i := range snap.Services {
+		g := group(snap.Services[i].Namespace)
+		g.services = append(g.services, &snap.Services[i])
+	}
+	for i := range snap.PodMetrics {
+		if g, ok := groups[snap.PodMetrics[i].Namespace]; ok {
+			g.metrics = append(g.metrics, &snap.PodMetrics[i])
+		}
+	}
+	endpoints := make(map[string]bool, len(snap.Endpoints))
+	for _, ep := range snap.Endpoints {
+		for _, subset := range ep.Subsets {
+			if len(subset.Addresses) > 0 {
+				endpoints[ep.Namespace+"/"+ep.Name] = true
+				break
+			}
+		}
+	}
+
+	namespaces := make([]string, 0, len(groups))
+	for ns := range groups {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+
+	workers := max(NamespaceWorkers, 1)
+	var mu sync.Mutex
+	timings := make([]NamespaceTiming, 0, len(namespaces))
+	fns := make([]func(), 0, len(namespaces))
+	for _, ns := range namespaces {
+		fns = append(fns, func() {
+			if ctx.Err() != nil {
+				return
+			}
+			began := time.Now()
+			nh := evaluateNamespace(ns, groups[ns], endpoints)
+			took := time.Since(began)
+
+			mu.Lock()
+			health.NamespaceHealth[ns] = nh
+			timings = append(timings, NamespaceTiming{Namespace: ns, Duration: took})
+			mu.Unlock()
+		})
+	}
+	runLimited(fns, workers)
+
+	sort.Slice(timings, func(i, j int) bool { return timings[i].Duration > timings[j].Duration })
+	if len(timings) > slowestNamespaces {
+		timings = timings[:slowestNamespaces]
+	}
+	health.NamespaceEvaluation = NamespaceEvaluation{
+		Namespaces: len(namespaces),
+		Workers:    workers,
+		Duration:   time.Since(start),
+		Slowest:    timings,
+	}
+	return ctx.Err()
+}
+
+// evaluateNamespace builds the health of one namespace from its objects
+func evaluateNamespace(namespace string, objects *namespaceObjects, endpoints map[string]bool) NamespaceHealth {
+	var nh NamespaceHealth
+	checkPodHealth(&snapshot.PodSnapshot{Pods: objects.pods}, &nh.PodStatus)
+
+	for _, d := range objects.deployments {
+		nh.DeploymentStatus.TotalDeployments++
+		switch {
+		case deploymentFailed(d):
+			nh.DeploymentStatus.FailedDeployments++
+		case d.Status.UpdatedReplicas < desiredReplicas(d) || d.Status.AvailableReplicas < desiredReplicas(d):
+			nh.DeploymentStatus.ProgressingDeployments++
+		default:
+			nh.DeploymentStatus.HealthyDeployments++
+		}
+	}
+
+	for _, svc := range objects.services {
+		// ExternalName Services and Services without a selector have no managed endpoints
+		if svc.Spec.Type == v1.ServiceTypeExternalName || len(svc.Spec.Selector) == 0 {
+			continue
+		}
+		nh.ServiceStatus.TotalServices++
+		if endpoints[namespace+"/"+svc.Name] {
+			nh.ServiceStatus.ServicesWithEndpoints++
+		} else {
+			nh.ServiceStatus.ServicesWithoutEndpoints++
+		}
+	}
+
+	// Usage as a percentage of the requests of the namespace's running pods
+	var cpuRequests, memoryRequests, cpuUsage, memoryUsage int64
+	for _, pod := range objects.pods {
+		if pod.Status.Phase != v1.PodRunning {
+			continue
+		}
+		for _, c := range pod.Spec.Containers {
+			cpuRequests += c.Resources.Requests.Cpu().MilliValue()
+			memoryRequests += c.Resources.Requests.Memory().Value()
+		}
+	}
+	for _, m := range objects.metrics {
+		for _, c := range m.Containers {
+			cpuUsage += c.Usage.Cpu().MilliValue()
+			memoryUsage += c.Usage.Memory().Value()
+		}
+	}
+	if cpuRequests > 0 {
+		nh.ResourceUsage.ClusterCPUUsage = float64(cpuUsage) / float64(cpuRequests) * 100
+	}
+	if memoryRequests > 0 {
+		nh.ResourceUsage.ClusterMemoryUsage = float64(memoryUsage) / float64(memoryRequests) * 100
+	}
+
+	return nh
+}
+
+// desiredReplicas is a Deployment's desired replica count, defaulting to one
+func desiredReplicas(d *appsv1.Deployment) int32 {
+	if d.Spec.Replicas == nil {
+		return 1
+	}
+	return *d.Spec.Replicas
+}
+
+// deploymentFailed reports whether a Deployment exceeded its progress deadline or failed
+// to create replicas
+func deploymentFailed(d *appsv1.Deployment) bool {
+	for _, c := range d.Status.Conditions {
+		if c.Type == appsv1.DeploymentProgressing && c.Status == v1.ConditionFalse && c.Reason == "ProgressDeadlineExceeded" {
+			return true
+		}
+		if c.Type == appsv1.DeploymentReplicaFailure && c.Status == v1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+// runLimited runs the functions with at most limit running at once
+func runLimited(fns []func(), limit int) {
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for _, fn := range fns {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(fn func()) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			fn()
+		}(fn)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,168 @@
+package health
+
+import (
+	"slices"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/snapshot"
+)
+
+// NetworkPolicyStatus reports network policy hygiene
+type NetworkPolicyStatus struct {
+	OpenNamespaces []string         `json:"openNamespaces,omitempty"` // namespaces with pods and no policies
+	UnusedPolicies []string         `json:"unusedPolicies,omitempty"` // namespace/name of policies selecting no pods
+	Conflicts      []PolicyConflict `json:"conflicts,omitempty"`
+}
+
+// PolicyConflict is a deny-all policy made ineffective by an allow-all policy selecting
+// some of the same pods, since network policies only add allowed traffic
+type PolicyConflict struct {
+	Namespace string `json:"namespace"`
+	Direction string `json:"direction"` // Ingress or Egress
+	Deny      string `json:"deny"`
+	Allow     string `json:"allow"`
+}
+
+// PolicyExemptNamespaces are not reported as open when they have no network policies
+var PolicyExemptNamespaces = []string{"kube-system", "kube-public", "kube-node-lease"}
+
+// analyzeNetworkPolicies finds namespaces with running pods and no policies, policies whose
+// pod selector matches nothing, and deny-all policies overridden by allow-all ones
+func analyzeNetworkPolicies(policies []networkingv1.NetworkPolicy, snap *snapshot.ClusterSnapshot) NetworkPolicyStatus {
+	var status NetworkPolicyStatus
+
+	byNamespace := make(map[string][]*networkingv1.NetworkPolicy)
+	for i := range policies {
+		byNamespace[policies[i].Namespace] = append(byNamespace[policies[i].Namespace], &policies[i])
+	}
+
+	withPods := make(map[string]bool)
+	for i := range snap.Pods {
+		if snap.Pods[i].Status.Phase == v1.PodRunning {
+			withPods[snap.Pods[i].Namespace] = true
+		}
+	}
+	for namespace := range withPods {
+		if len(byNamespace[namespace]) == 0 && !slices.Contains(PolicyExemptNamespaces, namespace) {
+			status.OpenNamespaces = append(status.OpenNamespaces, namespace)
+		}
+	}
+
+	for namespace, nsPolicies := range byNamespace {
+		selected := make(map[string]map[string]bool, len(nsPolicies)) // policy -> pod names
+		for _, policy := range nsPolicies {
+			pods, err := selectedPods(snap, policy)
+			if err != nil {
+				continue
+			}
+			selected[policy.Name] = pods
+			if len(pods) == 0 && len(policy.Spec.PodSelector.MatchLabels)+len(policy.Spec.PodSelector.MatchExpressions) > 0 {
+				status.UnusedPolicies = append(status.UnusedPolicies, namespace+"/"+policy.Name)
+			}
+		}
+
+		for _, direction := range []networkingv1.PolicyType{networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress} {
+			for _, deny := range nsPolicies {
+				if !deniesAll(deny, direction) {
+					continue
+				}
+				for _, allow := range nsPolicies {
+					if allow == deny || !allowsAll(allow, direction) || !overlaps(selected[deny.Name], selected[allow.Name]) {
+						continue
+					}
+					status.Conflicts = append(status.Conflicts, PolicyConflict{
+						Namespace: namespace,
+						Direction: string(direction),
+						Deny:      deny.Name,
+						Allow:     allow.Name,
+					})
+				}
+			}
+		}
+	}
+
+	sort.Strings(status.OpenNamespaces)
+	sort.Strings(status.UnusedPolicies)
+	sort.Slice(status.Conflicts, func(i, j int) bool {
+		a, b := status.Conflicts[i], status.Conflicts[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Deny+"/"+a.Allow+"/"+a.Direction < b.Deny+"/"+b.Allow+"/"+b.Direction
+	})
+	return status
+}
+
+// selectedPods returns the names of the pods a policy applies to
+func selectedPods(snap *snapshot.ClusterSnapshot, policy *networkingv1.NetworkPolicy) (map[string]bool, error) {
+	selector, err := metav1.LabelSelectorAsSelector(&policy.Spec.PodSelector)
+	if err != nil {
+		return nil, err
+	}
+	pods := make(map[string]bool)
+	for _, pod := range snap.Select(policy.Namespace, selector) {
+		pods[pod.Name] = true
+	}
+	return pods, nil
+}
+
+// policyTypes returns the directions a policy restricts, applying the API defaults when
+// policyTypes is unset
+func policyTypes(policy *networkingv1.NetworkPolicy) []networkingv1.PolicyType {
+	if len(policy.Spec.PolicyTypes) > 0 {
+		return policy.Spec.PolicyTypes
+	}
+	types := []networkingv1.PolicyType{networkingv1.PolicyTypeIngress}
+	if len(policy.Spec.Egress) > 0 {
+		types = append(types, networkingv1.PolicyTypeEgress)
+	}
+	return types
+}
+
+// deniesAll reports whether a policy restricts a direction without allowing anything
+func deniesAll(policy *networkingv1.NetworkPolicy, direction networkingv1.PolicyType) bool {
+	restricts := false
+	for _, t := range policyTypes(policy) {
+		restricts = restricts || t == direction
+	}
+	if !restricts {
+		return false
+	}
+	if direction == networkingv1.PolicyTypeIngress {
+		return len(policy.Spec.Ingress) == 0
+	}
+	return len(policy.Spec.Egress) == 0
+}
+
+// allowsAll reports whether a policy has a rule allowing all traffic in a direction, from
+// or to any peer on any port
+func allowsAll(policy *networkingv1.NetworkPolicy, direction networkingv1.PolicyType) bool {
+	if direction == networkingv1.PolicyTypeIngress {
+		for _, rule := range policy.Spec.Ingress {
+			if len(rule.From) == 0 && len(rule.Ports) == 0 {
+				return true
+			}
+		}
+		return false
+	}
+	for _, rule := range policy.Spec.Egress {
+		if len(rule.To) == 0 && len(rule.Ports) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// overlaps reports whether two pod sets share a pod
+func overlaps(a, b map[string]bool) bool {
+	for pod := range a {
+		if b[pod] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,89 @@
+package health
+
+import (
+	"fmt"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/snapshot"
+)
+
+// OSPool is a cluster's nodes of one operating system. Hybrid clusters report them apart
+// since Windows and Linux nodes run different agents, networking and workloads.
+type OSPool struct {
+	OS            string  `json:"os"`
+	Nodes         int     `json:"nodes"`
+	ReadyNodes    int     `json:"readyNodes"`
+	PressureNodes int     `json:"pressureNodes"` // under memory, disk or PID pressure
+	CPU           float64 `json:"cpu"`           // allocatable cores
+	MemoryGB      float64 `json:"memoryGB"`      // allocatable
+}
+
+// NodePoolsByOS groups nodes by operating system, ordered by OS
+func NodePoolsByOS(nodes []v1.Node) []OSPool {
+	pools := make(map[string]*OSPool)
+	for i := range nodes {
+		node := &nodes[i]
+		os := snapshot.NodeOS(node)
+		p, ok := pools[os]
+		if !ok {
+			p = &OSPool{OS: os}
+			pools[os] = p
+		}
+		p.Nodes++
+		p.CPU += node.Status.Allocatable.Cpu().AsApproximateFloat64()
+		p.MemoryGB += float64(node.Status.Allocatable.Memory().Value()) / (1024 * 1024 * 1024)
+		pressure := false
+		for _, condition := range node.Status.Conditions {
+			if condition.Status != v1.ConditionTrue {
+				continue
+			}
+			switch condition.Type {
+			case v1.NodeReady:
+				p.ReadyNodes++
+			case v1.NodeMemoryPressure, v1.NodeDiskPressure, v1.NodePIDPressure:
+				pressure = true
+			}
+		}
+		if pressure {
+			p.PressureNodes++
+		}
+	}
+
+	result := make([]OSPool, 0, len(pools))
+	for _, p := range pools {
+		result = append(result, *p)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].OS < result[j].OS })
+	return result
+}
+
+// osMismatchPods lists the pods, as namespace/name, that are not ready on a node of another
+// operating system than the one they ask for, or on a Windows node without asking for any.
+// In hybrid clusters Linux workloads without an OS selector land on untainted Windows nodes
+// and never start.
+func osMismatchPods(snap *snapshot.ClusterSnapshot) []string {
+	nodes := make(map[string]string, len(snap.Nodes))
+	for i := range snap.Nodes {
+		nodes[snap.Nodes[i].Name] = snapshot.NodeOS(&snap.Nodes[i])
+	}
+
+	result := make([]string, 0)
+	for i := range snap.Pods {
+		pod := &snap.Pods[i]
+		nodeOS, ok := nodes[pod.Spec.NodeName]
+		if !ok || pod.Status.Phase == v1.PodSucceeded || podReady(pod) {
+			continue
+		}
+		podOS := snapshot.PodOS(&pod.Spec)
+		if podOS == "" {
+			podOS = snapshot.OSLinux
+		}
+		if podOS != nodeOS {
+			result = append(result, fmt.Sprintf("%s/%s", pod.Namespace, pod.Name))
+		}
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,325 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/snapshot"
+)
+
+// PipelinesEnabled turns on reading Argo Workflows and Tekton PipelineRuns. Either tool may
+// be missing; its CRDs not being installed is not an error.
+var PipelinesEnabled bool
+
+// Thresholds of the CI workload check
+var (
+	PipelineStuckAfter      = 6 * time.Hour    // runs still running after this are stuck
+	PipelinePodPendingAfter = 15 * time.Minute // workflow pods pending this long are reported
+	PipelineFailureWindow   = 24 * time.Hour   // failures finished within this window count
+	PipelineCleanupAfter    = 7 * 24 * time.Hour
+	PipelineCleanupMinimum  = 100 // completed runs older than PipelineCleanupAfter per namespace before cleanup is suggested
+)
+
+// CI tools
+const (
+	PipelineArgo   = "argo-workflows"
+	PipelineTekton = "tekton"
+)
+
+// Pod labels naming the run a pod belongs to
+const (
+	argoWorkflowLabel   = "workflows.argoproj.io/workflow"
+	tektonPipelineLabel = "tekton.dev/pipelineRun"
+)
+
+// PipelineStatus reports the health of CI workloads
+type PipelineStatus struct {
+	Failing     []PipelineFailures `json:"failing,omitempty"`
+	Stuck       []PipelineRun      `json:"stuck,omitempty"`
+	PendingPods []PipelinePod      `json:"pendingPods,omitempty"`
+	Cleanup     []PipelineBacklog  `json:"cleanup,omitempty"`
+}
+
+// PipelineFailures counts the recent failed runs of one pipeline or workflow template
+type PipelineFailures struct {
+	Tool        string `json:"tool"`
+	Namespace   string `json:"namespace"`
+	Pipeline    string `json:"pipeline"`
+	Failed      int    `json:"failed"`
+	Total       int    `json:"total"` // runs finished within the failure window
+	LastRun     string `json:"lastRun"`
+	LastMessage string `json:"lastMessage,omitempty"`
+}
+
+// PipelineRun is a workflow or pipeline run that has been running too long
+type PipelineRun struct {
+	Tool      string        `json:"tool"`
+	Namespace string        `json:"namespace"`
+	Name      string        `json:"name"`
+	Pipeline  string        `json:"pipeline,omitempty"`
+	Started   time.Time     `json:"started"`
+	Duration  time.Duration `json:"duration"`
+}
+
+// PipelinePod is a workflow pod that has not been scheduled or whose containers have not started
+type PipelinePod struct {
+	Tool      string        `json:"tool"`
+	Namespace string        `json:"namespace"`
+	Pod       string        `json:"pod"`
+	Run       string        `json:"run"`
+	Pending   time.Duration `json:"pending"`
+	Reason    string        `json:"reason,omitempty"`
+}
+
+// PipelineBacklog counts completed runs in a namespace that can be cleaned up
+type PipelineBacklog struct {
+	Tool      string `json:"tool"`
+	Namespace string `json:"namespace"`
+	Completed int    `json:"completed"` // completed before PipelineCleanupAfter
+}
+
+// pipelineRun is a run of either tool reduced to what the check compares
+type pipelineRun struct {
+	namespace, name, pipeline string
+	running, failed, finished bool
+	started, completed        time.Time
+	message                   string
+}
+
+// argoWorkflow is the part of an Argo Workflow the check reads
+type argoWorkflow struct {
+	Metadata struct {
+		Name      string            `json:"name"`
+		Namespace string            `json:"namespace"`
+		Labels    map[string]string `json:"labels"`
+	} `json:"metadata"`
+	Spec struct {
+		WorkflowTemplateRef *struct {
+			Name string `json:"name"`
+		} `json:"workflowTemplateRef"`
+	} `json:"spec"`
+	Status struct {
+		Phase      string    `json:"phase"`
+		StartedAt  time.Time `json:"startedAt"`
+		FinishedAt time.Time `json:"finishedAt"`
+		Message    string    `json:"message"`
+	} `json:"status"`
+}
+
+// tektonPipelineRun is the part of a Tekton PipelineRun the check reads
+type tektonPipelineRun struct {
+	Metadata struct {
+		Name      string            `json:"name"`
+		Namespace string            `json:"namespace"`
+		Labels    map[string]string `json:"labels"`
+	} `json:"metadata"`
+	Status struct {
+		StartTime      time.Time `json:"startTime"`
+		CompletionTime time.Time `json:"completionTime"`
+		Conditions     []struct {
+			Type    string `json:"type"`
+			Status  string `json:"status"`
+			Reason  string `json:"reason"`
+			Message string `json:"message"`
+		} `json:"conditions"`
+	} `json:"status"`
+}
+
+// checkPipelines reads Argo Workflows and Tekton PipelineRuns when PipelinesEnabled is set,
+// and finds their pods that have been pending too long
+func checkPipelines(ctx context.Context, clientset kubernetes.Interface, snap *snapshot.ClusterSnapshot, now time.Time, status *PipelineStatus) error {
+	if !PipelinesEnabled || clientset == nil {
+		return nil
+	}
+	partial := &PartialError{}
+
+	var workflows struct {
+		Items []argoWorkflow `json:"items"`
+	}
+	if found, err := listCustomResources(ctx, clientset, "/apis/argoproj.io/v1alpha1/workflows", &workflows); err != nil {
+		partial.Errors = append(partial.Errors, fmt.Errorf("failed to list Argo workflows: %w", err))
+	} else if found {
+		runs := make([]pipelineRun, 0, len(workflows.Items))
+		for _, wf := range workflows.Items {
+			runs = append(runs, argoRun(wf))
+		}
+		auditPipelineRuns(PipelineArgo, runs, now, status)
+	}
+
+	var pipelineRuns struct {
+		Items []tektonPipelineRun `json:"items"`
+	}
+	if found, err := listCustomResources(ctx, clientset, "/apis/tekton.dev/v1/pipelineruns", &pipelineRuns); err != nil {
+		partial.Errors = append(partial.Errors, fmt.Errorf("failed to list Tekton pipeline runs: %w", err))
+	} else if found {
+		runs := make([]pipelineRun, 0, len(pipelineRuns.Items))
+		for _, pr := range pipelineRuns.Items {
+			runs = append(runs, tektonRun(pr))
+		}
+		auditPipelineRuns(PipelineTekton, runs, now, status)
+	}
+
+	status.PendingPods = pendingPipelinePods(snap.Pods, now)
+
+	if len(partial.Errors) > 0 {
+		return partial
+	}
+	return nil
+}
+
+// argoRun converts an Argo Workflow. Workflows are grouped by their template or cron
+// workflow, falling back to their own name.
+func argoRun(wf argoWorkflow) pipelineRun {
+	run := pipelineRun{
+		namespace: wf.Metadata.Namespace,
+		name:      wf.Metadata.Name,
+		pipeline:  wf.Metadata.Name,
+		started:   wf.Status.StartedAt,
+		completed: wf.Status.FinishedAt,
+		message:   wf.Status.Message,
+	}
+	switch {
+	case wf.Spec.WorkflowTemplateRef != nil:
+		run.pipeline = wf.Spec.WorkflowTemplateRef.Name
+	case wf.Metadata.Labels["workflows.argoproj.io/cron-workflow"] != "":
+		run.pipeline = wf.Metadata.Labels["workflows.argoproj.io/cron-workflow"]
+	case wf.Metadata.Labels["workflows.argoproj.io/workflow-template"] != "":
+		run.pipeline = wf.Metadata.Labels["workflows.argoproj.io/workflow-template"]
+	}
+	switch wf.Status.Phase {
+	case "Running":
+		run.running = true
+	case "Failed", "Error":
+		run.failed, run.finished = true, true
+	case "Succeeded":
+		run.finished = true
+	}
+	return run
+}
+
+// tektonRun converts a Tekton PipelineRun from its Succeeded condition
+func tektonRun(pr tektonPipelineRun) pipelineRun {
+	run := pipelineRun{
+		namespace: pr.Metadata.Namespace,
+		name:      pr.Metadata.Name,
+		pipeline:  pr.Metadata.Labels["tekton.dev/pipeline"],
+		started:   pr.Status.StartTime,
+		completed: pr.Status.CompletionTime,
+	}
+	if run.pipeline == "" {
+		run.pipeline = pr.Metadata.Name
+	}
+	for _, c := range pr.Status.Conditions {
+		if c.Type != "Succeeded" {
+			continue
+		}
+		run.message = c.Message
+		switch c.Status {
+		case "True":
+			run.finished = true
+		case "False":
+			run.failed, run.finished = true, true
+		default:
+			run.running = c.Reason == "Running" || c.Reason == "Started"
+		}
+	}
+	return run
+}
+
+// auditPipelineRuns finds the failing pipelines, stuck runs and cleanup backlog of one tool
+func auditPipelineRuns(tool string, runs []pipelineRun, now time.Time, status *PipelineStatus) {
+	failures := make(map[string]*PipelineFailures)
+	latest := make(map[string]time.Time)
+	backlog := make(map[string]int)
+	for _, run := range runs {
+		if run.running && !run.started.IsZero() && now.Sub(run.started) > PipelineStuckAfter {
+			status.Stuck = append(status.Stuck, PipelineRun{
+				Tool: tool, Namespace: run.namespace, Name: run.name, Pipeline: run.pipeline,
+				Started: run.started, Duration: now.Sub(run.started),
+			})
+		}
+		if !run.finished || run.completed.IsZero() {
+			continue
+		}
+		if now.Sub(run.completed) > PipelineCleanupAfter {
+			backlog[run.namespace]++
+		}
+		if now.Sub(run.completed) > PipelineFailureWindow {
+			continue
+		}
+		key := run.namespace + "/" + run.pipeline
+		f, ok := failures[key]
+		if !ok {
+			f = &PipelineFailures{Tool: tool, Namespace: run.namespace, Pipeline: run.pipeline}
+			failures[key] = f
+		}
+		f.Total++
+		if run.failed {
+			f.Failed++
+			if run.completed.After(latest[key]) {
+				latest[key] = run.completed
+				f.LastRun, f.LastMessage = run.name, run.message
+			}
+		}
+	}
+
+	for _, f := range failures {
+		if f.Failed > 0 {
+			status.Failing = append(status.Failing, *f)
+		}
+	}
+	for namespace, completed := range backlog {
+		if completed >= PipelineCleanupMinimum {
+			status.Cleanup = append(status.Cleanup, PipelineBacklog{Tool: tool, Namespace: namespace, Completed: completed})
+		}
+	}
+	sort.Slice(status.Failing, func(i, j int) bool {
+		a, b := status.Failing[i], status.Failing[j]
+		if a.Tool != b.Tool {
+			return a.Tool < b.Tool
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Pipeline < b.Pipeline
+	})
+	sort.Slice(status.Stuck, func(i, j int) bool { return status.Stuck[i].Duration > status.Stuck[j].Duration })
+	sort.Slice(status.Cleanup, func(i, j int) bool { return status.Cleanup[i].Completed > status.Cleanup[j].Completed })
+}
+
+// pendingPipelinePods finds workflow and pipeline pods pending longer than
+// PipelinePodPendingAfter, with the scheduler's reason when it gave one
+func pendingPipelinePods(pods []v1.Pod, now time.Time) []PipelinePod {
+	var pending []PipelinePod
+	for _, pod := range pods {
+		if pod.Status.Phase != v1.PodPending {
+			continue
+		}
+		tool, run := PipelineArgo, pod.Labels[argoWorkflowLabel]
+		if run == "" {
+			tool, run = PipelineTekton, pod.Labels[tektonPipelineLabel]
+		}
+		if run == "" || now.Sub(pod.CreationTimestamp.Time) < PipelinePodPendingAfter {
+			continue
+		}
+		p := PipelinePod{Tool: tool, Namespace: pod.Namespace, Pod: pod.Name, Run: run, Pending: now.Sub(pod.CreationTimestamp.Time)}
+		for _, c := range pod.Status.Conditions {
+			if c.Type == v1.PodScheduled && c.Status == v1.ConditionFalse {
+				p.Reason = c.Reason
+			}
+		}
+		for _, cs := range append(append([]v1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...) {
+			if p.Reason == "" && cs.State.Waiting != nil && cs.State.Waiting.Reason != "PodInitializing" {
+				p.Reason = cs.State.Waiting.Reason
+			}
+		}
+		pending = append(pending, p)
+	}
+	sort.Slice(pending, func(i, j int) bool { return pending[i].Pending > pending[j].Pending })
+	return pending
+}
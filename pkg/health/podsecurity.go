@@ -0,0 +1,360 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/retry"
+	"github.com/ochestra-tech/ochestra-ai/pkg/snapshot"
+)
+
+// Pod Security Standards levels, from least to most restrictive
+const (
+	PSSPrivileged = "privileged"
+	PSSBaseline   = "baseline"
+	PSSRestricted = "restricted"
+)
+
+// pssEnforceLabel is the namespace label Pod Security Admission enforces
+const pssEnforceLabel = "pod-security.kubernetes.io/enforce"
+
+// PodSecurityExemptNamespaces are not audited, since system components need privileges
+var PodSecurityExemptNamespaces = []string{"kube-system", "kube-public", "kube-node-lease"}
+
+// PodSecurityStatus compares the Pod Security Standard each namespace enforces with the
+// strictest one its workloads satisfy
+type PodSecurityStatus struct {
+	Namespaces []NamespacePodSecurity `json:"namespaces,omitempty"`
+}
+
+// NamespacePodSecurity is the audit of one namespace with pods
+type NamespacePodSecurity struct {
+	Namespace  string   `json:"namespace"`
+	Enforced   string   `json:"enforced,omitempty"` // enforce label, empty if unset
+	Achievable string   `json:"achievable"`         // strictest level every pod satisfies
+	Pods       int      `json:"pods"`
+	Blockers   []string `json:"blockers,omitempty"`   // why pods miss the next stricter level
+	Violations []string `json:"violations,omitempty"` // pods breaking the enforced level
+}
+
+// Weak reports whether the namespace enforces no level, or a weaker one than its pods allow
+func (n NamespacePodSecurity) Weak() bool {
+	return n.Enforced == "" || pssRank(n.Enforced) < pssRank(n.Achievable)
+}
+
+// pssRank orders levels; unknown values rank as privileged, as admission treats them
+func pssRank(level string) int {
+	switch level {
+	case PSSRestricted:
+		return 2
+	case PSSBaseline:
+		return 1
+	}
+	return 0
+}
+
+// checkPodSecurity evaluates every audited namespace's pods against the Baseline and
+// Restricted standards
+func checkPodSecurity(ctx context.Context, clientset kubernetes.Interface, snap *snapshot.ClusterSnapshot, status *PodSecurityStatus) error {
+	if clientset == nil {
+		return nil
+	}
+	namespaces, err := retry.Value(ctx, retry.DefaultBackoff, func() (*v1.NamespaceList, error) {
+		return clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list namespaces: %w", err)
+	}
+	status.Namespaces = auditPodSecurity(namespaces.Items, snap.Pods)
+	return nil
+}
+
+// auditPodSecurity finds each namespace's achievable level and the pods that hold it back
+// or break its enforced level. Finished pods are left out.
+func auditPodSecurity(namespaces []v1.Namespace, pods []v1.Pod) []NamespacePodSecurity {
+	audits := make(map[string]*NamespacePodSecurity)
+	for _, ns := range namespaces {
+		if slices.Contains(PodSecurityExemptNamespaces, ns.Name) {
+			continue
+		}
+		audits[ns.Name] = &NamespacePodSecurity{Namespace: ns.Name, Enforced: ns.Labels[pssEnforceLabel], Achievable: PSSRestricted}
+	}
+
+	blockers := make(map[string][]string) // namespace -> pods holding it at its achievable level
+	for i := range pods {
+		pod := &pods[i]
+		audit, ok := audits[pod.Namespace]
+		if !ok || pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+			continue
+		}
+		audit.Pods++
+		level, reason := podSecurityLevel(pod)
+		if pssRank(level) < pssRank(audit.Achievable) {
+			audit.Achievable = level
+			blockers[pod.Namespace] = nil
+		}
+		if level == audit.Achievable && level != PSSRestricted {
+			blockers[pod.Namespace] = appendLimited(blockers[pod.Namespace], pod.Name+": "+reason)
+		}
+		if audit.Enforced != "" && pssRank(level) < pssRank(audit.Enforced) {
+			audit.Violations = appendLimited(audit.Violations, pod.Name+": "+reason)
+		}
+	}
+
+	result := make([]NamespacePodSecurity, 0, len(audits))
+	for name, audit := range audits {
+		if audit.Pods == 0 {
+			continue
+		}
+		audit.Blockers = blockers[name]
+		result = append(result, *audit)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Namespace < result[j].Namespace })
+	return result
+}
+
+// maxPodSecurityExamples bounds the pods listed per namespace
+const maxPodSecurityExamples = 5
+
+func appendLimited(values []string, v string) []string {
+	if len(values) >= maxPodSecurityExamples {
+		return values
+	}
+	return append(values, v)
+}
+
+// Capabilities the Baseline standard allows containers to add
+var baselineCapabilities = []string{"AUDIT_WRITE", "CHOWN", "DAC_OVERRIDE", "FOWNER", "FSETID", "KILL", "MKNOD",
+	"NET_BIND_SERVICE", "SETFCAP", "SETGID", "SETPCAP", "SETUID", "SYS_CHROOT"}
+
+// Sysctls the Baseline standard allows
+var safeSysctls = []string{"kernel.shm_rmid_forced", "net.ipv4.ip_local_port_range", "net.ipv4.ip_unprivileged_port_start",
+	"net.ipv4.tcp_syncookies", "net.ipv4.ping_group_range", "net.ipv4.ip_local_reserved_ports", "net.ipv4.tcp_keepalive_time",
+	"net.ipv4.tcp_fin_timeout", "net.ipv4.tcp_keepalive_intvl", "net.ipv4.tcp_keepalive_probes"}
+
+// SELinux types the Baseline standard allows
+var baselineSELinuxTypes = []string{"", "container_t", "container_init_t", "container_kvm_t", "container_engine_t"}
+
+// Volume types the Restricted standard allows
+var restrictedVolumes = []string{"configMap", "csi", "downwardAPI", "emptyDir", "ephemeral", "persistentVolumeClaim", "projected", "secret"}
+
+// podSecurityLevel returns the strictest level a pod satisfies and why it misses the next
+// stricter one, which also explains why it breaks any stricter level
+func podSecurityLevel(pod *v1.Pod) (string, string) {
+	if reason := baselineViolation(pod); reason != "" {
+		return PSSPrivileged, reason
+	}
+	if reason := restrictedViolation(pod); reason != "" {
+		return PSSBaseline, reason
+	}
+	return PSSRestricted, ""
+}
+
+// allContainers returns a pod's init, regular and ephemeral containers with their names
+func allContainers(pod *v1.Pod) []podContainer {
+	var containers []podContainer
+	for i := range pod.Spec.InitContainers {
+		c := &pod.Spec.InitContainers[i]
+		containers = append(containers, podContainer{c.Name, c.SecurityContext, c.Ports})
+	}
+	for i := range pod.Spec.Containers {
+		c := &pod.Spec.Containers[i]
+		containers = append(containers, podContainer{c.Name, c.SecurityContext, c.Ports})
+	}
+	for i := range pod.Spec.EphemeralContainers {
+		c := &pod.Spec.EphemeralContainers[i]
+		containers = append(containers, podContainer{c.Name, c.SecurityContext, c.Ports})
+	}
+	return containers
+}
+
+// podContainer is the part of any kind of container the standards check
+type podContainer struct {
+	name            string
+	securityContext *v1.SecurityContext
+	ports           []v1.ContainerPort
+}
+
+// baselineViolation returns the first Baseline control the pod breaks, or ""
+func baselineViolation(pod *v1.Pod) string {
+	spec := &pod.Spec
+	switch {
+	case spec.HostNetwork:
+		return "uses the host network"
+	case spec.HostPID:
+		return "uses the host PID namespace"
+	case spec.HostIPC:
+		return "uses the host IPC namespace"
+	}
+	for _, volume := range spec.Volumes {
+		if volume.HostPath != nil {
+			return "mounts hostPath volume " + volume.Name
+		}
+	}
+	if psc := spec.SecurityContext; psc != nil {
+		if psc.WindowsOptions != nil && psc.WindowsOptions.HostProcess != nil && *psc.WindowsOptions.HostProcess {
+			return "runs as a Windows host process"
+		}
+		if psc.SeccompProfile != nil && psc.SeccompProfile.Type == v1.SeccompProfileTypeUnconfined {
+			return "sets an Unconfined seccomp profile"
+		}
+		if psc.AppArmorProfile != nil && psc.AppArmorProfile.Type == v1.AppArmorProfileTypeUnconfined {
+			return "sets an Unconfined AppArmor profile"
+		}
+		if reason := seLinuxViolation(psc.SELinuxOptions); reason != "" {
+			return reason
+		}
+		for _, sysctl := range psc.Sysctls {
+			if !slices.Contains(safeSysctls, sysctl.Name) {
+				return "sets unsafe sysctl " + sysctl.Name
+			}
+		}
+	}
+	for key, value := range pod.Annotations {
+		if strings.HasPrefix(key, "container.apparmor.security.beta.kubernetes.io/") &&
+			value != "runtime/default" && !strings.HasPrefix(value, "localhost/") {
+			return "sets AppArmor profile " + value
+		}
+	}
+
+	for _, c := range allContainers(pod) {
+		for _, port := range c.ports {
+			if port.HostPort != 0 {
+				return fmt.Sprintf("container %s uses host port %d", c.name, port.HostPort)
+			}
+		}
+		sc := c.securityContext
+		if sc == nil {
+			continue
+		}
+		switch {
+		case sc.Privileged != nil && *sc.Privileged:
+			return "container " + c.name + " is privileged"
+		case sc.WindowsOptions != nil && sc.WindowsOptions.HostProcess != nil && *sc.WindowsOptions.HostProcess:
+			return "container " + c.name + " runs as a Windows host process"
+		case sc.ProcMount != nil && *sc.ProcMount != v1.DefaultProcMount:
+			return "container " + c.name + " sets an unmasked /proc mount"
+		case sc.SeccompProfile != nil && sc.SeccompProfile.Type == v1.SeccompProfileTypeUnconfined:
+			return "container " + c.name + " sets an Unconfined seccomp profile"
+		case sc.AppArmorProfile != nil && sc.AppArmorProfile.Type == v1.AppArmorProfileTypeUnconfined:
+			return "container " + c.name + " sets an Unconfined AppArmor profile"
+		}
+		if reason := seLinuxViolation(sc.SELinuxOptions); reason != "" {
+			return "container " + c.name + " " + reason
+		}
+		if sc.Capabilities != nil {
+			for _, capability := range sc.Capabilities.Add {
+				if !slices.Contains(baselineCapabilities, string(capability)) {
+					return fmt.Sprintf("container %s adds capability %s", c.name, capability)
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// seLinuxViolation returns why SELinux options break the Baseline standard, or ""
+func seLinuxViolation(options *v1.SELinuxOptions) string {
+	switch {
+	case options == nil:
+		return ""
+	case !slices.Contains(baselineSELinuxTypes, options.Type):
+		return "sets SELinux type " + options.Type
+	case options.User != "" || options.Role != "":
+		return "sets a custom SELinux user or role"
+	}
+	return ""
+}
+
+// restrictedViolation returns the first Restricted control a Baseline pod breaks, or ""
+func restrictedViolation(pod *v1.Pod) string {
+	for _, volume := range pod.Spec.Volumes {
+		if kind := volumeType(volume.VolumeSource); !slices.Contains(restrictedVolumes, kind) {
+			return fmt.Sprintf("mounts %s volume %s", kind, volume.Name)
+		}
+	}
+
+	psc := pod.Spec.SecurityContext
+	if psc == nil {
+		psc = &v1.PodSecurityContext{}
+	}
+	if psc.RunAsUser != nil && *psc.RunAsUser == 0 {
+		return "runs as user 0"
+	}
+	podSeccomp := psc.SeccompProfile != nil && psc.SeccompProfile.Type != v1.SeccompProfileTypeUnconfined
+	podNonRoot := psc.RunAsNonRoot != nil && *psc.RunAsNonRoot
+
+	for _, c := range allContainers(pod) {
+		sc := c.securityContext
+		if sc == nil {
+			sc = &v1.SecurityContext{}
+		}
+		switch {
+		case sc.AllowPrivilegeEscalation == nil || *sc.AllowPrivilegeEscalation:
+			return "container " + c.name + " does not set allowPrivilegeEscalation to false"
+		case sc.RunAsUser != nil && *sc.RunAsUser == 0:
+			return "container " + c.name + " runs as user 0"
+		case sc.RunAsNonRoot != nil && !*sc.RunAsNonRoot, sc.RunAsNonRoot == nil && !podNonRoot:
+			return "container " + c.name + " does not set runAsNonRoot"
+		case sc.SeccompProfile == nil && !podSeccomp:
+			return "container " + c.name + " does not set a RuntimeDefault or Localhost seccomp profile"
+		case sc.Capabilities == nil || !containsCapability(sc.Capabilities.Drop, "ALL"):
+			return "container " + c.name + " does not drop ALL capabilities"
+		}
+		for _, capability := range sc.Capabilities.Add {
+			if capability != "NET_BIND_SERVICE" {
+				return fmt.Sprintf("container %s adds capability %s", c.name, capability)
+			}
+		}
+	}
+	return ""
+}
+
+func containsCapability(capabilities []v1.Capability, name v1.Capability) bool {
+	for _, c := range capabilities {
+		if c == name {
+			return true
+		}
+	}
+	return false
+}
+
+// volumeType names the source type of a volume as in the pod spec
+func volumeType(source v1.VolumeSource) string {
+	switch {
+	case source.ConfigMap != nil:
+		return "configMap"
+	case source.CSI != nil:
+		return "csi"
+	case source.DownwardAPI != nil:
+		return "downwardAPI"
+	case source.EmptyDir != nil:
+		return "emptyDir"
+	case source.Ephemeral != nil:
+		return "ephemeral"
+	case source.PersistentVolumeClaim != nil:
+		return "persistentVolumeClaim"
+	case source.Projected != nil:
+		return "projected"
+	case source.Secret != nil:
+		return "secret"
+	case source.HostPath != nil:
+		return "hostPath"
+	case source.NFS != nil:
+		return "nfs"
+	case source.ISCSI != nil:
+		return "iscsi"
+	case source.GitRepo != nil:
+		return "gitRepo"
+	case source.Image != nil:
+		return "image"
+	}
+	return "inline"
+}
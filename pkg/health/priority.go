@@ -0,0 +1,276 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	schedulingv1 "k8s.io/api/scheduling/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/retry"
+	"github.com/ochestra-tech/ochestra-ai/pkg/snapshot"
+)
+
+// SystemPriorityNamespaces may run pods at system-cluster-critical and system-node-critical.
+// Anywhere else those classes let a user workload preempt cluster components.
+var SystemPriorityNamespaces = []string{"kube-system"}
+
+// systemPriorityClasses are the built-in classes reserved for cluster components
+var systemPriorityClasses = []string{"system-cluster-critical", "system-node-critical"}
+
+// PreemptionWindow is how far back preemption events are counted
+var PreemptionWindow = time.Hour
+
+// PriorityStatus reports how workloads use priority classes and which were preempted
+type PriorityStatus struct {
+	Classes         []PriorityClassUsage `json:"classes,omitempty"`
+	GlobalDefault   string               `json:"globalDefault,omitempty"`
+	Unprioritized   []PriorityWorkload   `json:"unprioritized,omitempty"`   // critical workloads without a priority class
+	SystemPriority  []PriorityWorkload   `json:"systemPriority,omitempty"`  // user workloads at a system class
+	Preemptions     []Preemption         `json:"preemptions,omitempty"`     // within PreemptionWindow
+	Recommendations []string             `json:"recommendations,omitempty"` // toward a priority scheme
+}
+
+// PriorityClassUsage is a priority class and the pods using it
+type PriorityClassUsage struct {
+	Name             string `json:"name"`
+	Value            int32  `json:"value"`
+	GlobalDefault    bool   `json:"globalDefault,omitempty"`
+	PreemptionPolicy string `json:"preemptionPolicy,omitempty"`
+	Pods             int    `json:"pods"`
+}
+
+// PriorityWorkload is a workload whose priority class needs attention
+type PriorityWorkload struct {
+	Namespace     string `json:"namespace"`
+	Kind          string `json:"kind"`
+	Name          string `json:"name"`
+	PriorityClass string `json:"priorityClass,omitempty"`
+	Reason        string `json:"reason,omitempty"` // why the workload counts as critical
+}
+
+// Preemption is a workload whose pods the scheduler preempted
+type Preemption struct {
+	Namespace string    `json:"namespace"`
+	Kind      string    `json:"kind"`
+	Name      string    `json:"name"`
+	Count     int       `json:"count"`
+	LastSeen  time.Time `json:"lastSeen"`
+	Message   string    `json:"message,omitempty"` // latest message, naming the preemptor and node
+}
+
+// checkPriorities lists priority classes, disruption budgets and preemption events and
+// matches them with the pods in the snapshot
+func checkPriorities(ctx context.Context, clientset kubernetes.Interface, snap *snapshot.ClusterSnapshot, now time.Time, status *PriorityStatus) error {
+	if clientset == nil {
+		return nil
+	}
+	classes, err := retry.Value(ctx, retry.DefaultBackoff, func() (*schedulingv1.PriorityClassList, error) {
+		return clientset.SchedulingV1().PriorityClasses().List(ctx, metav1.ListOptions{})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list priority classes: %w", err)
+	}
+	pdbs, err := retry.Value(ctx, retry.DefaultBackoff, func() (*policyv1.PodDisruptionBudgetList, error) {
+		return clientset.PolicyV1().PodDisruptionBudgets("").List(ctx, metav1.ListOptions{})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list pod disruption budgets: %w", err)
+	}
+	*status = evaluatePriorities(snap, classes.Items, pdbs.Items)
+
+	events, err := snapshot.ListEvents(ctx, clientset, "", metav1.ListOptions{FieldSelector: "reason=Preempted"})
+	if err != nil {
+		return &PartialError{Errors: []error{fmt.Errorf("failed to list preemption events: %w", err)}}
+	}
+	status.Preemptions = preemptions(snap, events, now)
+	return nil
+}
+
+// evaluatePriorities counts pods per class and finds critical workloads without a class and
+// user workloads running at a system class. A workload is critical when a disruption budget
+// covers it or it is one of the CriticalDaemonSets.
+func evaluatePriorities(snap *snapshot.ClusterSnapshot, classes []schedulingv1.PriorityClass, pdbs []policyv1.PodDisruptionBudget) PriorityStatus {
+	var status PriorityStatus
+	usage := make(map[string]int)
+	for i := range snap.Pods {
+		if name := snap.Pods[i].Spec.PriorityClassName; name != "" {
+			usage[name]++
+		}
+	}
+	for _, pc := range classes {
+		c := PriorityClassUsage{Name: pc.Name, Value: pc.Value, GlobalDefault: pc.GlobalDefault, Pods: usage[pc.Name]}
+		if pc.PreemptionPolicy != nil {
+			c.PreemptionPolicy = string(*pc.PreemptionPolicy)
+		}
+		if pc.GlobalDefault {
+			status.GlobalDefault = pc.Name
+		}
+		status.Classes = append(status.Classes, c)
+	}
+	sort.Slice(status.Classes, func(i, j int) bool { return status.Classes[i].Value > status.Classes[j].Value })
+
+	// Selectors of the disruption budgets in each namespace
+	budgets := make(map[string][]labels.Selector)
+	for _, pdb := range pdbs {
+		if selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector); err == nil && !selector.Empty() {
+			budgets[pdb.Namespace] = append(budgets[pdb.Namespace], selector)
+		}
+	}
+	critical := make(map[string]string) // "<namespace>/<kind>/<name>" -> reason
+	for i := range snap.DaemonSets {
+		if _, ok := criticalDaemonSet(&snap.DaemonSets[i]); ok {
+			critical[snap.DaemonSets[i].Namespace+"/DaemonSet/"+snap.DaemonSets[i].Name] = "critical DaemonSet"
+		}
+	}
+
+	seen := make(map[string]bool)
+	for i := range snap.Pods {
+		pod := &snap.Pods[i]
+		if pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+			continue
+		}
+		kind, name := snapshot.WorkloadOwner(pod)
+		key := pod.Namespace + "/" + kind + "/" + name
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		workload := PriorityWorkload{Namespace: pod.Namespace, Kind: kind, Name: name, PriorityClass: pod.Spec.PriorityClassName}
+
+		if slices.Contains(systemPriorityClasses, pod.Spec.PriorityClassName) && !slices.Contains(SystemPriorityNamespaces, pod.Namespace) {
+			status.SystemPriority = append(status.SystemPriority, workload)
+			continue
+		}
+		if pod.Spec.PriorityClassName != "" {
+			continue
+		}
+		reason, ok := critical[key]
+		if !ok {
+			for _, selector := range budgets[pod.Namespace] {
+				if selector.Matches(labels.Set(pod.Labels)) {
+					reason, ok = "covered by a PodDisruptionBudget", true
+					break
+				}
+			}
+		}
+		if ok {
+			workload.Reason = reason
+			status.Unprioritized = append(status.Unprioritized, workload)
+		}
+	}
+	sortPriorityWorkloads(status.Unprioritized)
+	sortPriorityWorkloads(status.SystemPriority)
+
+	status.Recommendations = priorityRecommendations(status)
+	return status
+}
+
+// preemptions groups the preemption events within PreemptionWindow by the preempted
+// pod's workload
+func preemptions(snap *snapshot.ClusterSnapshot, events []v1.Event, now time.Time) []Preemption {
+	pods := make(map[string]*v1.Pod, len(snap.Pods))
+	for i := range snap.Pods {
+		pods[snap.Pods[i].Namespace+"/"+snap.Pods[i].Name] = &snap.Pods[i]
+	}
+	byWorkload := make(map[string]*Preemption)
+	for i := range events {
+		event := &events[i]
+		occurrences := eventOccurrences(event, now, PreemptionWindow)
+		if occurrences == 0 || event.InvolvedObject.Kind != "Pod" {
+			continue
+		}
+		object := event.InvolvedObject
+		kind, name := "Pod", object.Name
+		if pod, ok := pods[object.Namespace+"/"+object.Name]; ok {
+			kind, name = snapshot.WorkloadOwner(pod)
+		}
+		key := object.Namespace + "/" + kind + "/" + name
+		p, ok := byWorkload[key]
+		if !ok {
+			p = &Preemption{Namespace: object.Namespace, Kind: kind, Name: name}
+			byWorkload[key] = p
+		}
+		p.Count += int(occurrences + 0.5)
+		if last := eventLastSeen(event); last.After(p.LastSeen) {
+			p.LastSeen, p.Message = last, event.Message
+		}
+	}
+
+	result := make([]Preemption, 0, len(byWorkload))
+	for _, p := range byWorkload {
+		result = append(result, *p)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Count > result[j].Count })
+	return result
+}
+
+// eventLastSeen returns when an event last occurred
+func eventLastSeen(event *v1.Event) time.Time {
+	switch {
+	case event.Series != nil:
+		return event.Series.LastObservedTime.Time
+	case !event.LastTimestamp.IsZero():
+		return event.LastTimestamp.Time
+	}
+	return event.EventTime.Time
+}
+
+// priorityRecommendations suggests steps toward a small priority scheme: a default class
+// for ordinary workloads, a higher one for critical services and a non-preempting low one
+// for batch work, with the system classes left to cluster components
+func priorityRecommendations(status PriorityStatus) []string {
+	var custom, low int
+	for _, c := range status.Classes {
+		if slices.Contains(systemPriorityClasses, c.Name) {
+			continue
+		}
+		custom++
+		if c.PreemptionPolicy == string(v1.PreemptNever) {
+			low++
+		}
+	}
+
+	var recommendations []string
+	if custom == 0 {
+		recommendations = append(recommendations,
+			"Define a few priority classes: a globalDefault for ordinary workloads, a higher class for critical services and a low class for batch work")
+	} else if status.GlobalDefault == "" {
+		recommendations = append(recommendations,
+			"Mark one priority class globalDefault so pods without a class do not all run at priority 0")
+	}
+	if custom > 0 && low == 0 {
+		recommendations = append(recommendations,
+			"Give batch and best-effort work a low class with preemptionPolicy Never so it queues instead of preempting")
+	}
+	if len(status.Unprioritized) > 0 {
+		recommendations = append(recommendations,
+			fmt.Sprintf("Assign a high priority class to the %d critical workloads without one so other pods cannot preempt them", len(status.Unprioritized)))
+	}
+	if len(status.SystemPriority) > 0 {
+		recommendations = append(recommendations,
+			"Move user workloads off system-cluster-critical and system-node-critical to a class below them, so they cannot preempt cluster components")
+	}
+	return recommendations
+}
+
+// sortPriorityWorkloads orders workloads by namespace, kind and name
+func sortPriorityWorkloads(workloads []PriorityWorkload) {
+	sort.Slice(workloads, func(i, j int) bool {
+		a, b := workloads[i], workloads[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.Name < b.Name
+	})
+}
@@ -0,0 +1,199 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/promql"
+	"github.com/ochestra-tech/ochestra-ai/pkg/snapshot"
+)
+
+// ProductionNamespaces are path.Match patterns of the namespaces where BestEffort
+// workloads are reported
+var ProductionNamespaces = []string{"prod", "prod-*", "*-prod", "production", "production-*", "*-production"}
+
+// QoSExemptNamespaces are not audited, since system components set their own resources
+var QoSExemptNamespaces = []string{"kube-system", "kube-public", "kube-node-lease"}
+
+// Thresholds of the QoS check
+var (
+	LimitRequestRatioWarn = 4.0  // limits this many times their request risk bursting past what nodes can give
+	CPUThrottlingWarn     = 0.25 // fraction of CFS periods throttled above which a container is reported
+)
+
+// PrometheusURL is the base URL of a Prometheus scraping cAdvisor. CPU throttling is only
+// checked when it is set.
+var PrometheusURL string
+
+// throttlingQuery is the fraction of CFS periods each container was throttled in over the
+// last 15 minutes
+const throttlingQuery = `sum by (namespace, pod, container) (increase(container_cpu_cfs_throttled_periods_total{container!=""}[15m]))
+	/ sum by (namespace, pod, container) (increase(container_cpu_cfs_periods_total{container!=""}[15m]))`
+
+// QoSStatus reports workloads whose QoS class, limits or CPU throttling put them at risk
+type QoSStatus struct {
+	BestEffort []QoSFinding `json:"bestEffort,omitempty"` // workloads without requests or limits in production namespaces
+	Bursting   []QoSFinding `json:"bursting,omitempty"`   // containers with limits far above their requests
+	Throttled  []QoSFinding `json:"throttled,omitempty"`  // containers throttled by their CPU limit
+}
+
+// QoSFinding is a workload, or one of its containers, with the resources it sets
+type QoSFinding struct {
+	Namespace     string  `json:"namespace"`
+	Kind          string  `json:"kind"`
+	Name          string  `json:"name"`
+	Container     string  `json:"container,omitempty"`
+	Pods          int     `json:"pods"`
+	CPURequest    int64   `json:"cpuRequest,omitempty"`    // millicores
+	CPULimit      int64   `json:"cpuLimit,omitempty"`      // millicores
+	MemoryRequest int64   `json:"memoryRequest,omitempty"` // bytes
+	MemoryLimit   int64   `json:"memoryLimit,omitempty"`   // bytes
+	Throttled     float64 `json:"throttled,omitempty"`     // fraction of CFS periods throttled, the worst pod's
+}
+
+// CPURatio returns the CPU limit over the request, or 0 if either is unset
+func (f QoSFinding) CPURatio() float64 {
+	if f.CPURequest == 0 || f.CPULimit == 0 {
+		return 0
+	}
+	return float64(f.CPULimit) / float64(f.CPURequest)
+}
+
+// MemoryRatio returns the memory limit over the request, or 0 if either is unset
+func (f QoSFinding) MemoryRatio() float64 {
+	if f.MemoryRequest == 0 || f.MemoryLimit == 0 {
+		return 0
+	}
+	return float64(f.MemoryLimit) / float64(f.MemoryRequest)
+}
+
+// IsProductionNamespace reports whether a namespace matches ProductionNamespaces
+func IsProductionNamespace(namespace string) bool {
+	for _, pattern := range ProductionNamespaces {
+		if ok, _ := path.Match(pattern, namespace); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// checkQoS audits the pods' QoS classes and limits, and reads CPU throttling from
+// Prometheus when it is configured
+func checkQoS(ctx context.Context, snap *snapshot.ClusterSnapshot, status *QoSStatus) error {
+	status.BestEffort, status.Bursting = auditQoS(snap.Pods)
+	if PrometheusURL == "" {
+		return nil
+	}
+
+	samples, err := promql.Query(ctx, PrometheusURL, throttlingQuery)
+	if err != nil {
+		return &PartialError{Errors: []error{fmt.Errorf("failed to read CPU throttling: %w", err)}}
+	}
+	status.Throttled = throttledContainers(snap.Pods, samples)
+	return nil
+}
+
+// auditQoS finds BestEffort workloads in production namespaces and containers whose
+// limits are far above their requests. Finished pods are left out.
+func auditQoS(pods []v1.Pod) ([]QoSFinding, []QoSFinding) {
+	bestEffort := make(map[string]*QoSFinding)
+	bursting := make(map[string]*QoSFinding)
+	for i := range pods {
+		pod := &pods[i]
+		if slices.Contains(QoSExemptNamespaces, pod.Namespace) || pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+			continue
+		}
+		kind, name := snapshot.WorkloadOwner(pod)
+
+		if pod.Status.QOSClass == v1.PodQOSBestEffort && IsProductionNamespace(pod.Namespace) {
+			key := pod.Namespace + "/" + kind + "/" + name
+			if f, ok := bestEffort[key]; ok {
+				f.Pods++
+			} else {
+				bestEffort[key] = &QoSFinding{Namespace: pod.Namespace, Kind: kind, Name: name, Pods: 1}
+			}
+		}
+
+		for _, c := range pod.Spec.Containers {
+			f := containerResources(pod.Namespace, kind, name, c)
+			if f.CPURatio() < LimitRequestRatioWarn && f.MemoryRatio() < LimitRequestRatioWarn {
+				continue
+			}
+			key := pod.Namespace + "/" + kind + "/" + name + "/" + c.Name
+			if existing, ok := bursting[key]; ok {
+				existing.Pods++
+			} else {
+				bursting[key] = &f
+			}
+		}
+	}
+	return sortedFindings(bestEffort), sortedFindings(bursting)
+}
+
+// throttledContainers joins throttling samples with the pods' containers, keeping the
+// worst pod of each workload container above CPUThrottlingWarn
+func throttledContainers(pods []v1.Pod, samples []promql.Sample) []QoSFinding {
+	podsByKey := make(map[string]*v1.Pod, len(pods))
+	for i := range pods {
+		podsByKey[pods[i].Namespace+"/"+pods[i].Name] = &pods[i]
+	}
+
+	throttled := make(map[string]*QoSFinding)
+	for _, s := range samples {
+		pod, ok := podsByKey[s.Labels["namespace"]+"/"+s.Labels["pod"]]
+		if !ok || slices.Contains(QoSExemptNamespaces, pod.Namespace) || s.Value < CPUThrottlingWarn {
+			continue
+		}
+		kind, name := snapshot.WorkloadOwner(pod)
+		for _, c := range pod.Spec.Containers {
+			if c.Name != s.Labels["container"] {
+				continue
+			}
+			key := pod.Namespace + "/" + kind + "/" + name + "/" + c.Name
+			if existing, ok := throttled[key]; ok {
+				existing.Pods++
+				existing.Throttled = max(existing.Throttled, s.Value)
+				continue
+			}
+			f := containerResources(pod.Namespace, kind, name, c)
+			f.Throttled = s.Value
+			throttled[key] = &f
+		}
+	}
+	return sortedFindings(throttled)
+}
+
+// containerResources returns a finding for one pod's container with its requests and limits
+func containerResources(namespace, kind, name string, c v1.Container) QoSFinding {
+	return QoSFinding{
+		Namespace:     namespace,
+		Kind:          kind,
+		Name:          name,
+		Container:     c.Name,
+		Pods:          1,
+		CPURequest:    c.Resources.Requests.Cpu().MilliValue(),
+		CPULimit:      c.Resources.Limits.Cpu().MilliValue(),
+		MemoryRequest: c.Resources.Requests.Memory().Value(),
+		MemoryLimit:   c.Resources.Limits.Memory().Value(),
+	}
+}
+
+// sortedFindings returns the findings ordered by namespace, workload and container
+func sortedFindings(findings map[string]*QoSFinding) []QoSFinding {
+	keys := make([]string, 0, len(findings))
+	for key := range findings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	result := make([]QoSFinding, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, *findings[key])
+	}
+	return result
+}
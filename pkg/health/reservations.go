@@ -0,0 +1,154 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/snapshot"
+)
+
+// Where a node's reservations were read from
+const (
+	ReservationFromConfig      = "configz"     // the kubelet's running configuration
+	ReservationFromAllocatable = "allocatable" // the gap between capacity and allocatable
+)
+
+// defaultEvictionMemory is the kubelet's default hard eviction threshold for memory, which
+// the allocatable gap includes even with nothing reserved
+var defaultEvictionMemory = resource.MustParse("100Mi")
+
+// ReservationStatus audits the resources each kubelet holds back from pods for the system
+// and itself, and its eviction thresholds. Without them system daemons compete with pods
+// for CPU and memory, and a node under memory pressure can fail before evicting anything.
+type ReservationStatus struct {
+	Audited int               `json:"audited"` // nodes whose reservations could be read
+	Nodes   []NodeReservation `json:"nodes,omitempty"`
+}
+
+// NodeReservation is a node without a reservation or eviction threshold
+type NodeReservation struct {
+	Node            string            `json:"node"`
+	Source          string            `json:"source"`                    // configz or allocatable
+	ReservedCPU     int64             `json:"reservedCPU"`               // millicores
+	ReservedMemory  int64             `json:"reservedMemory"`            // bytes, including the hard eviction threshold when read from allocatable
+	EvictionHard    map[string]string `json:"evictionHard,omitempty"`    // only read from configz
+	NoReservation   bool              `json:"noReservation"`             // neither system-reserved nor kube-reserved CPU or memory
+	MissingEviction []string          `json:"missingEviction,omitempty"` // signals without a hard eviction threshold
+	Windows         bool              `json:"windows,omitempty"`
+}
+
+// kubeletConfigz is the part of the kubelet's /configz response the audit reads
+type kubeletConfigz struct {
+	KubeletConfig struct {
+		SystemReserved map[string]string `json:"systemReserved"`
+		KubeReserved   map[string]string `json:"kubeReserved"`
+		EvictionHard   map[string]string `json:"evictionHard"`
+	} `json:"kubeletconfig"`
+}
+
+// evictionSignals are the signals a node should have a hard eviction threshold for
+var evictionSignals = []string{"memory.available", "nodefs.available"}
+
+// checkReservations reads each ready node's kubelet configuration, falling back to the gap
+// between capacity and allocatable when configz cannot be read. Large clusters only use
+// the allocatable gap, since configz costs one request per node.
+func checkReservations(ctx context.Context, clientset kubernetes.Interface, snap *snapshot.ClusterSnapshot, status *ReservationStatus) error {
+	partial := &PartialError{}
+	for i := range snap.Nodes {
+		node := &snap.Nodes[i]
+		if snapshot.IsVirtualNode(node) {
+			continue // no host whose daemons need protecting
+		}
+		var r NodeReservation
+		if clientset != nil && !snap.Large && nodeReady(node) {
+			config, err := fetchKubeletConfigz(ctx, clientset, node.Name)
+			if err != nil {
+				partial.Errors = append(partial.Errors, err)
+			} else {
+				r = configReservation(node.Name, config)
+			}
+		}
+		if r.Source == "" {
+			r = allocatableReservation(node)
+		}
+		if r.Source == "" {
+			continue
+		}
+		// Windows kubelets do not evict on memory or disk pressure, so only their
+		// reservations protect the node
+		if snapshot.NodeOS(node) == snapshot.OSWindows {
+			r.Windows, r.MissingEviction = true, nil
+		}
+		status.Audited++
+		if r.NoReservation || len(r.MissingEviction) > 0 {
+			status.Nodes = append(status.Nodes, r)
+		}
+	}
+	sort.Slice(status.Nodes, func(i, j int) bool { return status.Nodes[i].Node < status.Nodes[j].Node })
+	if len(partial.Errors) > 0 {
+		return partial
+	}
+	return nil
+}
+
+// fetchKubeletConfigz reads a node's running kubelet configuration through the apiserver's
+// node proxy
+func fetchKubeletConfigz(ctx context.Context, clientset kubernetes.Interface, node string) (*kubeletConfigz, error) {
+	data, err := clientset.CoreV1().RESTClient().Get().
+		AbsPath("/api/v1/nodes", node, "proxy", "configz").DoRaw(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read kubelet configuration from node %s: %w", node, err)
+	}
+	var config kubeletConfigz
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse kubelet configuration from node %s: %w", node, err)
+	}
+	return &config, nil
+}
+
+// configReservation reads the reservations and eviction thresholds from a kubelet's
+// configuration
+func configReservation(node string, config *kubeletConfigz) NodeReservation {
+	kc := config.KubeletConfig
+	r := NodeReservation{Node: node, Source: ReservationFromConfig, EvictionHard: kc.EvictionHard}
+	for _, reserved := range []map[string]string{kc.SystemReserved, kc.KubeReserved} {
+		if q, err := resource.ParseQuantity(reserved[string(v1.ResourceCPU)]); err == nil {
+			r.ReservedCPU += q.MilliValue()
+		}
+		if q, err := resource.ParseQuantity(reserved[string(v1.ResourceMemory)]); err == nil {
+			r.ReservedMemory += q.Value()
+		}
+	}
+	r.NoReservation = r.ReservedCPU == 0 && r.ReservedMemory == 0
+	for _, signal := range evictionSignals {
+		if threshold := kc.EvictionHard[signal]; threshold == "" || threshold == "0" || threshold == "0%" {
+			r.MissingEviction = append(r.MissingEviction, signal)
+		}
+	}
+	return r
+}
+
+// allocatableReservation infers the reservation from the gap between a node's capacity and
+// allocatable. The memory gap includes the hard eviction threshold, so a gap no larger
+// than the default threshold means nothing is reserved. Eviction thresholds cannot be
+// told apart from reservations this way and are not audited.
+func allocatableReservation(node *v1.Node) NodeReservation {
+	capacity, allocatable := node.Status.Capacity, node.Status.Allocatable
+	if capacity.Cpu().IsZero() || allocatable.Cpu().IsZero() {
+		return NodeReservation{}
+	}
+	r := NodeReservation{
+		Node:           node.Name,
+		Source:         ReservationFromAllocatable,
+		ReservedCPU:    capacity.Cpu().MilliValue() - allocatable.Cpu().MilliValue(),
+		ReservedMemory: capacity.Memory().Value() - allocatable.Memory().Value(),
+	}
+	r.NoReservation = r.ReservedCPU == 0 && r.ReservedMemory <= defaultEvictionMemory.Value()
+	return r
+}
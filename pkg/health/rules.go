@@ -0,0 +1,23 @@
+package health
+
+import (
+	"context"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/rules"
+	"github.com/ochestra-tech/ochestra-ai/pkg/snapshot"
+)
+
+// CustomRules holds the user-defined rules evaluated by each health check, if any
+var CustomRules *rules.RuleSet
+
+// checkCustomRules evaluates CustomRules against the snapshot
+func checkCustomRules(ctx context.Context, snap *snapshot.ClusterSnapshot) ([]rules.Violation, error) {
+	if CustomRules == nil {
+		return nil, nil
+	}
+	violations, errs := CustomRules.Evaluate(ctx, snap)
+	if len(errs) > 0 {
+		return violations, &PartialError{Errors: errs}
+	}
+	return violations, nil
+}
@@ -0,0 +1,884 @@
+package health
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Suggestion is the remediation guidance attached to an issue type
+type Suggestion struct {
+	Summary    string   `json:"summary,omitempty"`
+	RunbookURL string   `json:"runbookURL,omitempty"`
+	Steps      []string `json:"steps,omitempty"`
+}
+
+// SuggestionProvider maps an issue to remediation guidance
+type SuggestionProvider interface {
+	Suggest(issue HealthIssue) (Suggestion, bool)
+}
+
+// SuggestionMap is a SuggestionProvider keyed by issue type
+type SuggestionMap map[string]Suggestion
+
+// Suggest returns the suggestion for the issue's type
+func (m SuggestionMap) Suggest(issue HealthIssue) (Suggestion, bool) {
+	s, ok := m[issue.Type]
+	return s, ok
+}
+
+// SuggestionChain tries providers in order, returning the first suggestion found
+type SuggestionChain []SuggestionProvider
+
+// Suggest returns the first provider's suggestion for the issue
+func (c SuggestionChain) Suggest(issue HealthIssue) (Suggestion, bool) {
+	for _, p := range c {
+		if s, ok := p.Suggest(issue); ok {
+			return s, true
+		}
+	}
+	return Suggestion{}, false
+}
+
+// Suggestions provides the guidance attached to every issue the checks raise
+var Suggestions SuggestionProvider = DefaultSuggestions
+
+// LoadSuggestions reads a runbook mapping file keyed by issue type, e.g. to point at an
+// internal wiki. Types it does not mention fall back to DefaultSuggestions.
+func LoadSuggestions(path string) (SuggestionProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read runbook mapping: %w", err)
+	}
+
+	var mapping struct {
+		Runbooks SuggestionMap `json:"runbooks"`
+	}
+	if err := json.Unmarshal(data, &mapping); err != nil {
+		return nil, fmt.Errorf("failed to parse runbook mapping: %w", err)
+	}
+	return SuggestionChain{mapping.Runbooks, DefaultSuggestions}, nil
+}
+
+// Suggest fills an issue's suggestion, runbook URL and remediation steps from Suggestions,
+// keeping the issue's own suggestion when the mapping has no summary
+func Suggest(issue *HealthIssue) {
+	s, ok := Suggestions.Suggest(*issue)
+	if !ok {
+		return
+	}
+	if s.Summary != "" {
+		issue.Suggestion = s.Summary
+	}
+	issue.RunbookURL = s.RunbookURL
+	issue.Remediation = s.Steps
+}
+
+// Issue types raised by the health checks and the watcher
+const (
+	IssueNodeNotReady            = "NodeNotReady"
+	IssueMemoryPressure          = "MemoryPressure"
+	IssueDiskPressure            = "DiskPressure"
+	IssuePIDPressure             = "PIDPressure"
+	IssueNetworkUnavailable      = "NetworkUnavailable"
+	IssueHighCPU                 = "HighCPU"
+	IssueHighMemory              = "HighMemory"
+	IssueCrashLoopBackOff        = "CrashLoopBackOff"
+	IssueImagePullBackOff        = "ImagePullBackOff"
+	IssueErrImagePull            = "ErrImagePull"
+	IssueCreateContainerConfig   = "CreateContainerConfigError"
+	IssuePodsPending             = "PodsPending"
+	IssuePodsFailed              = "PodsFailed"
+	IssueContainerRestarts       = "ContainerRestarts"
+	IssueControlPlaneUnhealthy   = "ControlPlaneUnhealthy"
+	IssueCNIUnhealthy            = "CNIUnhealthy"
+	IssueDNSUnhealthy            = "DNSUnhealthy"
+	IssueServiceWithoutEndpoints = "ServiceWithoutEndpoints"
+	IssueIngressUnhealthy        = "IngressUnhealthy"
+	IssueComponentUnhealthy      = "ComponentUnhealthy"
+	IssueFailedScheduling        = "FailedScheduling"
+	IssueOOMKilling              = "OOMKilling"
+	IssueEvicted                 = "Evicted"
+	IssueFailedMount             = "FailedMount"
+	IssueRestartSpike            = "RestartSpike"
+	IssueUsageAnomaly            = "UsageAnomaly"
+	IssueReadinessDrop           = "ReadinessDrop"
+	IssueLatencyJump             = "LatencyJump"
+	IssueCostSpike               = "CostSpike"
+	IssueNodeCountJump           = "NodeCountJump"
+	IssueEtcdPressure            = "EtcdPressure"
+	IssueEventStorm              = "EventStorm"
+	IssueVersionDenied           = "VersionDenied"
+	IssueVersionOutlier          = "VersionOutlier"
+	IssueNodeFlapping            = "NodeFlapping"
+	IssueNodeHeartbeatStale      = "NodeHeartbeatStale"
+	IssueNodeCordoned            = "NodeCordoned"
+	IssueCustomTaint             = "CustomTaint"
+	IssueTaintBlocked            = "TaintBlocked"
+	IssueDaemonSetMissing        = "DaemonSetMissing"
+	IssueKubeProxyUnhealthy      = "KubeProxyUnhealthy"
+	IssueKubeProxyRulesStale     = "KubeProxyRulesStale"
+	IssueDNSErrors               = "DNSErrors"
+	IssueDNSLatency              = "DNSLatency"
+	IssueNamespaceWithoutPolicy  = "NamespaceWithoutPolicy"
+	IssueUnusedNetworkPolicy     = "UnusedNetworkPolicy"
+	IssueNetworkPolicyConflict   = "NetworkPolicyConflict"
+	IssueIPExhaustion            = "IPExhaustion"
+	IssuePodCapacity             = "PodCapacity"
+	IssueMaxPodsAboveIPs         = "MaxPodsAboveIPs"
+	IssueNoSystemReserved        = "NoSystemReserved"
+	IssueNoEvictionThreshold     = "NoEvictionThreshold"
+	IssueLoadBalancerPending     = "LoadBalancerPending"
+	IssueNodePortExhaustion      = "NodePortExhaustion"
+	IssueVolumeFull              = "VolumeFull"
+	IssueStorageTrend            = "StorageTrend"
+	IssueImageFSPressure         = "ImageFSPressure"
+	IssueRuleViolation           = "RuleViolation"
+	IssuePodSecurityNotEnforced  = "PodSecurityNotEnforced"
+	IssuePodSecurityWeak         = "PodSecurityWeak"
+	IssuePodSecurityViolation    = "PodSecurityViolation"
+	IssueHelmReleaseFailed       = "HelmReleaseFailed"
+	IssueHelmReleasePending      = "HelmReleasePending"
+	IssueHelmMissingImage        = "HelmMissingImage"
+	IssueHelmMissingNamespace    = "HelmMissingNamespace"
+	IssueHelmOrphanedRelease     = "HelmOrphanedRelease"
+	IssueGitOpsSyncFailed        = "GitOpsSyncFailed"
+	IssueGitOpsOutOfSync         = "GitOpsOutOfSync"
+	IssueGitOpsDegraded          = "GitOpsDegraded"
+	IssueGitOpsSuspended         = "GitOpsSuspended"
+	IssuePipelineFailing         = "PipelineFailing"
+	IssuePipelineStuck           = "PipelineStuck"
+	IssuePipelinePodPending      = "PipelinePodPending"
+	IssuePipelineCleanup         = "PipelineCleanup"
+	IssueDatabaseDegraded        = "DatabaseDegraded"
+	IssueDatabaseFailover        = "DatabaseFailover"
+	IssueDatabaseReplicationLag  = "DatabaseReplicationLag"
+	IssueDatabaseBackupStale     = "DatabaseBackupStale"
+	IssueDatabaseNoBackup        = "DatabaseNoBackup"
+	IssueBackupRPOViolated       = "BackupRPOViolated"
+	IssueBackupNoSchedule        = "BackupNoSchedule"
+	IssueBackupLocationDown      = "BackupLocationDown"
+	IssueBackupScheduleInvalid   = "BackupScheduleInvalid"
+	IssueConfigDrift             = "ConfigDrift"
+	IssueBestEffortWorkload      = "BestEffortWorkload"
+	IssueLimitBursting           = "LimitBursting"
+	IssueCPUThrottling           = "CPUThrottling"
+	IssueIdleNamespace           = "IdleNamespace"
+	IssueCriticalNoPriority      = "CriticalWithoutPriority"
+	IssueSystemPriorityMisuse    = "SystemPriorityMisuse"
+	IssuePodsPreempted           = "PodsPreempted"
+	IssuePodTerminating          = "PodStuckTerminating"
+	IssueNamespaceTerminating    = "NamespaceStuckTerminating"
+	IssueAPIThrottling           = "APIThrottling"
+	IssueClientThrottled         = "ClientThrottled"
+	IssueWebhookFailing          = "WebhookFailing"
+	IssueWebhookSlow             = "WebhookSlow"
+	IssuePodOSMismatch           = "PodOSMismatch"
+)
+
+// DefaultSuggestions points each issue type at the upstream Kubernetes documentation
+var DefaultSuggestions = SuggestionMap{
+	IssueNodeNotReady: {
+		RunbookURL: "https://kubernetes.io/docs/tasks/debug/debug-cluster/",
+		Steps: []string{
+			"kubectl describe node <node> and check the Ready condition message",
+			"Check kubelet and container runtime status on the node",
+			"Check network connectivity between the node and the API server",
+		},
+	},
+	IssueMemoryPressure: {
+		RunbookURL: "https://kubernetes.io/docs/concepts/scheduling-eviction/node-pressure-eviction/",
+		Steps: []string{
+			"kubectl top pods --all-namespaces --sort-by=memory to find the largest consumers",
+			"Set memory requests and limits on workloads without them",
+		},
+	},
+	IssueDiskPressure: {
+		RunbookURL: "https://kubernetes.io/docs/concepts/scheduling-eviction/node-pressure-eviction/",
+		Steps: []string{
+			"Check image and container log usage on the node",
+			"Prune unused images or increase the node's disk size",
+		},
+	},
+	IssuePIDPressure: {
+		RunbookURL: "https://kubernetes.io/docs/concepts/policy/pid-limiting/",
+		Steps: []string{
+			"Find pods with runaway process counts on the node",
+			"Set a pod PID limit on the kubelet",
+		},
+	},
+	IssueNetworkUnavailable: {
+		RunbookURL: "https://kubernetes.io/docs/concepts/cluster-administration/networking/",
+		Steps: []string{
+			"Check the CNI pod on the node",
+			"Check the node's routes and network interfaces",
+		},
+	},
+	IssueHighCPU: {
+		RunbookURL: "https://kubernetes.io/docs/concepts/configuration/manage-resources-containers/",
+		Steps: []string{
+			"kubectl top pods --all-namespaces --sort-by=cpu to find the largest consumers",
+			"Rebalance workloads or add capacity",
+		},
+	},
+	IssueHighMemory: {
+		RunbookURL: "https://kubernetes.io/docs/concepts/configuration/manage-resources-containers/",
+		Steps: []string{
+			"kubectl top pods --all-namespaces --sort-by=memory to find the largest consumers",
+			"Rebalance workloads or add capacity",
+		},
+	},
+	IssueCrashLoopBackOff: {
+		RunbookURL: "https://kubernetes.io/docs/tasks/debug/debug-application/debug-pods/",
+		Steps: []string{
+			"kubectl logs <pod> --previous to see why the container exited",
+			"kubectl describe pod <pod> and check the last state and events",
+			"Check liveness probes and resource limits",
+		},
+	},
+	IssueImagePullBackOff: {
+		RunbookURL: "https://kubernetes.io/docs/concepts/containers/images/",
+		Steps: []string{
+			"Check the image name and tag exist in the registry",
+			"Check imagePullSecrets for private registries",
+		},
+	},
+	IssueErrImagePull: {
+		RunbookURL: "https://kubernetes.io/docs/concepts/containers/images/",
+		Steps: []string{
+			"Check the image name and tag exist in the registry",
+			"Check imagePullSecrets for private registries",
+		},
+	},
+	IssueCreateContainerConfig: {
+		RunbookURL: "https://kubernetes.io/docs/tasks/debug/debug-application/debug-pods/",
+		Steps: []string{
+			"kubectl describe pod <pod> for the missing ConfigMap, Secret or key",
+		},
+	},
+	IssuePodsPending: {
+		RunbookURL: "https://kubernetes.io/docs/tasks/debug/debug-application/debug-pods/",
+		Steps: []string{
+			"kubectl get pods --all-namespaces --field-selector=status.phase=Pending",
+			"kubectl describe pod <pod> and check scheduling events",
+		},
+	},
+	IssuePodsFailed: {
+		RunbookURL: "https://kubernetes.io/docs/tasks/debug/debug-application/debug-pods/",
+		Steps: []string{
+			"kubectl get pods --all-namespaces --field-selector=status.phase=Failed",
+			"Delete completed failed pods once investigated",
+		},
+	},
+	IssueContainerRestarts: {
+		RunbookURL: "https://kubernetes.io/docs/tasks/debug/debug-application/debug-running-pod/",
+	},
+	IssueControlPlaneUnhealthy: {
+		RunbookURL: "https://kubernetes.io/docs/tasks/debug/debug-cluster/",
+		Steps: []string{
+			"kubectl get pods -n kube-system and check the component's pods",
+			"Check the component's logs",
+		},
+	},
+	IssueCNIUnhealthy: {
+		RunbookURL: "https://kubernetes.io/docs/concepts/cluster-administration/networking/",
+		Steps: []string{
+			"kubectl get daemonsets -n kube-system and check the CNI daemonset",
+		},
+	},
+	IssueDNSUnhealthy: {
+		RunbookURL: "https://kubernetes.io/docs/tasks/administer-cluster/dns-debugging-resolution/",
+		Steps: []string{
+			"kubectl get pods -n kube-system -l k8s-app=kube-dns",
+			"Check the CoreDNS logs",
+		},
+	},
+	IssueServiceWithoutEndpoints: {
+		RunbookURL: "https://kubernetes.io/docs/tasks/debug/debug-application/debug-service/",
+		Steps: []string{
+			"Check that the service selector matches the pod labels",
+			"Check that matching pods pass their readiness probes",
+		},
+	},
+	IssueIngressUnhealthy: {
+		RunbookURL: "https://kubernetes.io/docs/concepts/services-networking/ingress-controllers/",
+	},
+	IssueComponentUnhealthy: {
+		RunbookURL: "https://kubernetes.io/docs/tasks/debug/debug-cluster/",
+	},
+	IssueFailedScheduling: {
+		RunbookURL: "https://kubernetes.io/docs/concepts/scheduling-eviction/",
+		Steps: []string{
+			"Check the event message for insufficient resources, taints or affinity rules",
+			"Add capacity or adjust the pod's requests and constraints",
+		},
+	},
+	IssueOOMKilling: {
+		RunbookURL: "https://kubernetes.io/docs/tasks/configure-pod-container/assign-memory-resource/",
+		Steps: []string{
+			"Raise the container's memory limit or fix the leak",
+		},
+	},
+	IssueEvicted: {
+		RunbookURL: "https://kubernetes.io/docs/concepts/scheduling-eviction/node-pressure-eviction/",
+	},
+	IssueRestartSpike: {
+		RunbookURL: "https://kubernetes.io/docs/tasks/debug/debug-application/debug-running-pod/",
+		Steps: []string{
+			"kubectl get pods -n <namespace> and check which containers are restarting",
+			"Compare with recent deploys and config changes to the workload",
+		},
+	},
+	IssueUsageAnomaly: {
+		RunbookURL: "https://kubernetes.io/docs/concepts/configuration/manage-resources-containers/",
+		Steps: []string{
+			"Compare the workload's traffic and recent deploys with its usual pattern",
+			"A sudden drop can mean the workload stopped serving; check its logs and readiness",
+		},
+	},
+	IssueReadinessDrop: {
+		RunbookURL: "https://kubernetes.io/docs/tasks/debug/debug-application/debug-pods/",
+		Steps: []string{
+			"kubectl describe pod <pod> and check readiness probe failures",
+		},
+	},
+	IssueLatencyJump: {
+		RunbookURL: "https://kubernetes.io/docs/tasks/debug/debug-cluster/",
+		Steps: []string{
+			"Check apiserver and etcd load and request latency metrics",
+			"Look for clients listing large collections without pagination",
+		},
+	},
+	IssueCostSpike: {
+		RunbookURL: "https://kubernetes.io/docs/concepts/policy/resource-quotas/",
+		Steps: []string{
+			"Check the culprit workloads' recent rollouts, replica counts and requests",
+			"Look for HPAs pinned at maxReplicas and Jobs that keep retrying",
+			"Cap the namespace with a ResourceQuota if the growth was not intended",
+		},
+	},
+	IssueNodeCountJump: {
+		RunbookURL: "https://kubernetes.io/docs/concepts/cluster-administration/cluster-autoscaling/",
+		Steps: []string{
+			"Check the autoscaler's scale-up events and the pending pods that caused them",
+			"Check whether the growing workloads' replicas or requests changed",
+			"Check that scale-down is not blocked by pods without controllers or PDBs",
+		},
+	},
+	IssueEtcdPressure: {
+		RunbookURL: "https://kubernetes.io/docs/tasks/administer-cluster/configure-upgrade-etcd/",
+		Steps: []string{
+			"kubectl get <resource> --all-namespaces --no-headers | wc -l to find where objects accumulate",
+			"Lower the apiserver --event-ttl if events dominate",
+			"Compact and defragment etcd to reclaim space after deleting objects",
+		},
+	},
+	IssueEventStorm: {
+		RunbookURL: "https://kubernetes.io/docs/reference/kubernetes-api/cluster-resources/event-v1/",
+		Steps: []string{
+			"kubectl get events -n <namespace> --field-selector reason=<reason> to see the repeated message",
+			"Check the reporting controller's logs for a hot retry loop",
+			"Lower the apiserver --event-ttl while the flood is fixed to relieve etcd",
+		},
+	},
+	IssueVersionDenied: {
+		RunbookURL: "https://kubernetes.io/docs/tasks/administer-cluster/cluster-upgrade/",
+		Steps: []string{
+			"Cordon and drain the node",
+			"Upgrade the component, or replace the node from an image with a fixed version",
+		},
+	},
+	IssueVersionOutlier: {
+		RunbookURL: "https://kubernetes.io/releases/version-skew-policy/",
+		Steps: []string{
+			"kubectl get nodes -o wide to compare versions across nodes",
+			"Finish the rollout the node missed, or replace it from the pool's current image",
+		},
+	},
+	IssueNodeFlapping: {
+		RunbookURL: "https://kubernetes.io/docs/concepts/architecture/nodes/#node-status",
+		Steps: []string{
+			"kubectl describe node <node> and check the Ready condition's recent transitions",
+			"Check the kubelet and container runtime for restarts on the node",
+			"Check for intermittent network loss between the node and the API server",
+		},
+	},
+	IssueNodeHeartbeatStale: {
+		RunbookURL: "https://kubernetes.io/docs/concepts/architecture/leases/#node-heart-beats",
+		Steps: []string{
+			"kubectl get lease <node> -n kube-node-lease -o yaml and check spec.renewTime",
+			"Check the kubelet's logs and status on the node, e.g. journalctl -u kubelet",
+			"Check network connectivity between the node and the API server",
+		},
+	},
+	IssueNodeCordoned: {
+		RunbookURL: "https://kubernetes.io/docs/tasks/administer-cluster/safely-drain-node/",
+		Steps: []string{
+			"Check with the node's owner whether maintenance is still in progress",
+			"kubectl uncordon <node> once it is finished",
+		},
+	},
+	IssueCustomTaint: {
+		RunbookURL: "https://kubernetes.io/docs/concepts/scheduling-eviction/taint-and-toleration/",
+	},
+	IssueTaintBlocked: {
+		RunbookURL: "https://kubernetes.io/docs/concepts/scheduling-eviction/taint-and-toleration/",
+		Steps: []string{
+			"kubectl describe pod <pod> and check the FailedScheduling message",
+			"Add a matching toleration to the workload, or remove the taint with kubectl taint nodes <node> <key>-",
+		},
+	},
+	IssueDaemonSetMissing: {
+		RunbookURL: "https://kubernetes.io/docs/concepts/workloads/controllers/daemonset/",
+		Steps: []string{
+			"kubectl get pods -n <namespace> -o wide --field-selector spec.nodeName=<node> to find the DaemonSet's pod",
+			"kubectl describe daemonset <name> -n <namespace> and check scheduling events",
+			"Check the node has room for the pod's requests and that the pod tolerates the node's taints",
+		},
+	},
+	IssueKubeProxyUnhealthy: {
+		RunbookURL: "https://kubernetes.io/docs/reference/command-line-tools-reference/kube-proxy/",
+		Steps: []string{
+			"kubectl logs -n kube-system <pod> --previous to see why kube-proxy restarted",
+			"Check the node's conntrack and iptables kernel modules are loaded",
+			"Delete the pod so the DaemonSet recreates it",
+		},
+	},
+	IssueKubeProxyRulesStale: {
+		RunbookURL: "https://kubernetes.io/docs/tasks/debug/debug-application/debug-service/#is-the-kube-proxy-working",
+		Steps: []string{
+			"kubectl logs -n kube-system <pod> and look for iptables-restore, ipvs or nftables errors",
+			"On the node, compare iptables-save -t nat or ipvsadm -Ln with the service's endpoints",
+			"Check conntrack -S for insert failures and the table size against nf_conntrack_max",
+			"Restart the kube-proxy pod to force a full resync",
+		},
+	},
+	IssueDNSErrors: {
+		RunbookURL: "https://kubernetes.io/docs/tasks/administer-cluster/dns-debugging-resolution/",
+		Steps: []string{
+			"kubectl logs -n kube-system -l k8s-app=kube-dns for errors and panics",
+			"kubectl get configmap coredns -n kube-system -o yaml and check the forward targets",
+			"Resolve a failing name from a debug pod with nslookup to find the affected zone",
+		},
+	},
+	IssueDNSLatency: {
+		RunbookURL: "https://coredns.io/plugins/forward/",
+		Steps: []string{
+			"Check the latency of the resolvers in the CoreDNS forward block from a node",
+			"Raise the cache plugin's TTL or scale CoreDNS if its pods are CPU throttled",
+			"Consider NodeLocal DNSCache to cut upstream round trips",
+		},
+	},
+	IssueNamespaceWithoutPolicy: {
+		RunbookURL: "https://kubernetes.io/docs/concepts/services-networking/network-policies/#default-policies",
+		Steps: []string{
+			"Add a default-deny ingress policy selecting all pods in the namespace",
+			"Add policies allowing the traffic each workload needs",
+			"Check the CNI enforces network policies",
+		},
+	},
+	IssueUnusedNetworkPolicy: {
+		RunbookURL: "https://kubernetes.io/docs/concepts/services-networking/network-policies/",
+		Steps: []string{
+			"kubectl get networkpolicy <name> -n <namespace> -o yaml and compare its podSelector with the pods' labels",
+			"Fix the selector, or delete the policy if its workload is gone",
+		},
+	},
+	IssueNetworkPolicyConflict: {
+		RunbookURL: "https://kubernetes.io/docs/concepts/services-networking/network-policies/",
+		Steps: []string{
+			"Network policies are additive; an allow-all rule cancels a deny-all policy for the pods both select",
+			"Narrow the allow-all rule's peers and ports, or its pod selector",
+		},
+	},
+	IssueIPExhaustion: {
+		RunbookURL: "https://kubernetes.io/docs/concepts/cluster-administration/networking/",
+		Steps: []string{
+			"kubectl get pods -A -o wide --field-selector spec.nodeName=<node> to see what holds the node's IPs",
+			"Look for FailedCreatePodSandBox events mentioning IP assignment",
+			"On AWS, enable prefix delegation or use instance types with more ENIs; elsewhere, use a larger node CIDR mask",
+		},
+	},
+	IssuePodCapacity: {
+		RunbookURL: "https://kubernetes.io/docs/reference/config-api/kubelet-config.v1beta1/",
+		Steps: []string{
+			"kubectl describe node <node> and compare the Non-terminated Pods count with Allocatable pods",
+			"Look for FailedScheduling events reading Too many pods",
+			"Add nodes, or raise maxPods in the kubelet configuration where the node's pod CIDR and ENIs allow it",
+		},
+	},
+	IssueMaxPodsAboveIPs: {
+		RunbookURL: "https://kubernetes.io/docs/reference/config-api/kubelet-config.v1beta1/",
+		Steps: []string{
+			"Compare the node's Allocatable pods with its pod CIDR size or, on AWS, its instance type's ENI limit",
+			"Set maxPods to the IP capacity plus the host-network pods the node runs",
+			"On AWS, enable prefix delegation instead if the node should run more pods",
+		},
+	},
+	IssueNoSystemReserved: {
+		RunbookURL: "https://kubernetes.io/docs/tasks/administer-cluster/reserve-compute-resources/",
+		Steps: []string{
+			"kubectl get --raw /api/v1/nodes/<node>/proxy/configz to see the kubelet's systemReserved and kubeReserved",
+			"Compare the node's Capacity and Allocatable in kubectl describe node <node>",
+			"Reserve CPU and memory for the kubelet, container runtime and system daemons, sized for the node",
+		},
+	},
+	IssueNoEvictionThreshold: {
+		RunbookURL: "https://kubernetes.io/docs/concepts/scheduling-eviction/node-pressure-eviction/",
+		Steps: []string{
+			"kubectl get --raw /api/v1/nodes/<node>/proxy/configz to see the kubelet's evictionHard",
+			"Setting evictionHard replaces all defaults, so list every signal it should keep, such as memory.available and nodefs.available",
+		},
+	},
+	IssueLoadBalancerPending: {
+		RunbookURL: "https://kubernetes.io/docs/concepts/services-networking/service/#loadbalancer",
+		Steps: []string{
+			"kubectl describe service <name> -n <namespace> and look for SyncLoadBalancerFailed events",
+			"Check the cloud controller manager logs and the cloud account's load balancer and IP quotas",
+			"Check the service's annotations match what the cloud provider expects",
+		},
+	},
+	IssueNodePortExhaustion: {
+		RunbookURL: "https://kubernetes.io/docs/concepts/services-networking/service/#type-nodeport",
+		Steps: []string{
+			"kubectl get services -A --field-selector spec.type=NodePort to find services holding node ports",
+			"Set allocateLoadBalancerNodePorts: false on LoadBalancer services whose load balancer routes to pods directly",
+			"Widen --service-node-port-range on the apiserver",
+		},
+	},
+	IssueVolumeFull: {
+		RunbookURL: "https://kubernetes.io/docs/concepts/storage/persistent-volumes/#expanding-persistent-volumes-claims",
+		Steps: []string{
+			"kubectl exec into a pod mounting the claim and run df -h and df -i on the mount",
+			"Delete logs, temporary files or old data the application no longer needs",
+			"Raise spec.resources.requests.storage if the storage class allows volume expansion",
+		},
+	},
+	IssueStorageTrend: {
+		RunbookURL: "https://kubernetes.io/docs/concepts/storage/persistent-volumes/#expanding-persistent-volumes-claims",
+		Steps: []string{
+			"Find the claims in the namespace that grew the most",
+			"Check retention settings of databases, queues and log collectors writing to them",
+			"Expand the claims before they fill",
+		},
+	},
+	IssueImageFSPressure: {
+		RunbookURL: "https://kubernetes.io/docs/concepts/scheduling-eviction/node-pressure-eviction/",
+		Steps: []string{
+			"crictl images on the node and remove unused images with crictl rmi --prune",
+			"Check container logs and emptyDir volumes under /var/lib/kubelet for large files",
+			"For recurring pressure, lower imageGCHighThresholdPercent and raise evictionSoft thresholds so cleanup starts earlier",
+			"Give the node pool a larger root or image disk",
+		},
+	},
+	IssueRuleViolation: {
+		RunbookURL: "https://kubernetes.io/docs/concepts/policy/",
+		Steps: []string{
+			"kubectl get <kind> <name> -o yaml and compare it with the rule's expression in the rules file",
+			"Fix the object's manifest at its source so the next deploy does not reintroduce the violation",
+		},
+	},
+	IssuePodSecurityNotEnforced: {
+		RunbookURL: "https://kubernetes.io/docs/concepts/security/pod-security-admission/",
+		Steps: []string{
+			"kubectl label --dry-run=server --overwrite ns <namespace> pod-security.kubernetes.io/enforce=<level> lists pods the level would reject",
+			"Label the namespace with the level, or add pod-security.kubernetes.io/warn first to surface violations without rejecting pods",
+		},
+	},
+	IssuePodSecurityWeak: {
+		RunbookURL: "https://kubernetes.io/docs/concepts/security/pod-security-standards/",
+		Steps: []string{
+			"kubectl label --dry-run=server --overwrite ns <namespace> pod-security.kubernetes.io/enforce=<level> to confirm no pod would be rejected",
+			"Raise the enforce label so new workloads cannot weaken the namespace",
+		},
+	},
+	IssuePodSecurityViolation: {
+		RunbookURL: "https://kubernetes.io/docs/concepts/security/pod-security-standards/",
+		Steps: []string{
+			"The pods were admitted before the namespace was labeled, or by an exemption",
+			"Fix the security context of their controller's pod template, then roll them out",
+		},
+	},
+	IssueHelmReleaseFailed: {
+		RunbookURL: "https://helm.sh/docs/helm/helm_history/",
+		Steps: []string{
+			"helm history <release> -n <namespace> to see the failed revision and its description",
+			"helm rollback <release> <last deployed revision> -n <namespace>, or fix the values and upgrade again",
+		},
+	},
+	IssueHelmReleasePending: {
+		RunbookURL: "https://helm.sh/docs/helm/helm_rollback/",
+		Steps: []string{
+			"Make sure no helm process or CI job is still operating on the release",
+			"helm rollback <release> <last deployed revision> -n <namespace> clears the pending state",
+			"If the release never deployed, helm uninstall <release> -n <namespace> and install it again",
+		},
+	},
+	IssueHelmMissingImage: {
+		RunbookURL: "https://kubernetes.io/docs/concepts/containers/images/",
+		Steps: []string{
+			"kubectl describe pod for the pull error",
+			"Fix the image repository or tag in the chart values and upgrade the release",
+		},
+	},
+	IssueHelmMissingNamespace: {
+		RunbookURL: "https://helm.sh/docs/helm/helm_upgrade/",
+		Steps: []string{
+			"helm get manifest <release> -n <namespace> to find the objects targeting the missing namespaces",
+			"Recreate the namespaces, or change the values so the chart no longer targets them",
+		},
+	},
+	IssueHelmOrphanedRelease: {
+		RunbookURL: "https://helm.sh/docs/helm/helm_uninstall/",
+		Steps: []string{
+			"helm get manifest <release> -n <namespace> to confirm its objects were deleted outside Helm",
+			"helm uninstall <release> -n <namespace> removes the release secrets",
+		},
+	},
+	IssueGitOpsSyncFailed: {
+		RunbookURL: "https://argo-cd.readthedocs.io/en/stable/user-guide/sync-options/",
+		Steps: []string{
+			"argocd app get <app> or flux get kustomizations -n <namespace> for the sync error",
+			"Fix the manifests in Git or revert the commit, then sync or reconcile again",
+			"Issues in the namespaces the application deploys to may clear once it syncs",
+		},
+	},
+	IssueGitOpsOutOfSync: {
+		RunbookURL: "https://argo-cd.readthedocs.io/en/stable/user-guide/diffing/",
+		Steps: []string{
+			"argocd app diff <app> to see which resources drifted",
+			"Sync the application, or add ignoreDifferences for fields a controller manages",
+		},
+	},
+	IssueGitOpsDegraded: {
+		RunbookURL: "https://argo-cd.readthedocs.io/en/stable/operator-manual/health/",
+		Steps: []string{
+			"argocd app get <app> to find the degraded or missing resources",
+			"Check the pods and events in the namespaces the application deploys to",
+		},
+	},
+	IssueGitOpsSuspended: {
+		RunbookURL: "https://fluxcd.io/flux/cmd/flux_resume_kustomization/",
+		Steps: []string{
+			"Find out why reconciliation was suspended before resuming it",
+			"flux resume kustomization <name> -n <namespace>, or resume the Argo CD rollout",
+		},
+	},
+	IssuePipelineFailing: {
+		RunbookURL: "https://tekton.dev/docs/pipelines/pipelineruns/#monitoring-execution-status",
+		Steps: []string{
+			"argo logs <workflow> -n <namespace> or tkn pipelinerun logs <run> -n <namespace>",
+			"Compare the failing step with the last successful run for changed inputs or images",
+		},
+	},
+	IssuePipelineStuck: {
+		RunbookURL: "https://argo-workflows.readthedocs.io/en/latest/walk-through/timeouts/",
+		Steps: []string{
+			"Find the step still running and check its pod",
+			"Stop the run (argo stop or tkn pipelinerun cancel) if it will not finish",
+			"Set activeDeadlineSeconds on workflows or timeouts on pipeline runs",
+		},
+	},
+	IssuePipelinePodPending: {
+		RunbookURL: "https://kubernetes.io/docs/tasks/debug/debug-application/debug-pods/",
+		Steps: []string{
+			"kubectl describe pod for scheduling and image pull events",
+			"Check the namespace's ResourceQuota and whether node pools for CI workloads can scale",
+		},
+	},
+	IssuePipelineCleanup: {
+		RunbookURL: "https://argo-workflows.readthedocs.io/en/latest/cost-optimisation/#limit-the-total-number-of-workflows-and-pods",
+		Steps: []string{
+			"Set ttlStrategy and podGC on workflows, or run the Tekton pruner",
+			"Delete old completed runs: argo delete --completed --older 7d or tkn pipelinerun delete --keep 50",
+		},
+	},
+	IssueDatabaseDegraded: {
+		RunbookURL: "https://cloudnative-pg.io/documentation/current/troubleshooting/",
+		Steps: []string{
+			"kubectl describe the cluster object for the operator's status and events",
+			"Check the logs of the database pods that are not ready",
+			"Check the operator's own logs for reconciliation errors",
+		},
+	},
+	IssueDatabaseFailover: {
+		RunbookURL: "https://cloudnative-pg.io/documentation/current/failover/",
+		Steps: []string{
+			"Find why the old primary failed: node, storage or the database process",
+			"Confirm the new primary accepts writes and replicas follow it",
+		},
+	},
+	IssueDatabaseReplicationLag: {
+		RunbookURL: "https://cloudnative-pg.io/documentation/current/replication/",
+		Steps: []string{
+			"Compare disk IO and network throughput of the lagging replica with the primary",
+			"Look for long-running transactions or bulk writes on the primary",
+		},
+	},
+	IssueDatabaseBackupStale: {
+		RunbookURL: "https://cloudnative-pg.io/documentation/current/backup/",
+		Steps: []string{
+			"List the backup objects of the cluster and check the latest one's error",
+			"Verify the object store credentials and bucket permissions",
+			"Run an on-demand backup once the cause is fixed",
+		},
+	},
+	IssueDatabaseNoBackup: {
+		RunbookURL: "https://docs.percona.com/percona-operator-for-mysql/pxc/backups.html",
+		Steps: []string{
+			"Add a backup section with a schedule and an object store to the cluster spec",
+		},
+	},
+	IssueBackupRPOViolated: {
+		RunbookURL: "https://velero.io/docs/main/troubleshooting/",
+		Steps: []string{
+			"velero backup get to find recent failed or partially failed backups",
+			"velero backup logs <backup> for the errors",
+			"Take a manual backup of the namespace once the cause is fixed",
+		},
+	},
+	IssueBackupNoSchedule: {
+		RunbookURL: "https://velero.io/docs/main/backup-reference/#schedule-a-backup",
+		Steps: []string{
+			"Create a schedule including the namespace, at an interval shorter than its recovery-point objective",
+		},
+	},
+	IssueBackupLocationDown: {
+		RunbookURL: "https://velero.io/docs/main/locations/",
+		Steps: []string{
+			"velero backup-location get for the location's phase and error",
+			"Check the bucket exists and the credentials secret is valid",
+			"Check egress from the Velero pod to the object store",
+		},
+	},
+	IssueBackupScheduleInvalid: {
+		RunbookURL: "https://velero.io/docs/main/backup-reference/#schedule-a-backup",
+		Steps: []string{
+			"velero schedule describe <schedule> for the validation errors",
+			"Fix the cron expression or the referenced storage location",
+		},
+	},
+	IssueConfigDrift: {
+		RunbookURL: "https://kubernetes.io/docs/reference/access-authn-authz/audit/",
+		Steps: []string{
+			"Find who made the change in the apiserver audit log, or from the object's managedFields",
+			"If the change was intended, commit it to the cluster's source of truth",
+			"Otherwise revert it; webhook and RBAC changes may need a security review",
+		},
+	},
+	IssueBestEffortWorkload: {
+		RunbookURL: "https://kubernetes.io/docs/concepts/workloads/pods/pod-qos/",
+		Steps: []string{
+			"kubectl top pods -n <namespace> to size requests from actual usage",
+			"Set requests in the workload's pod template, or a LimitRange default for the namespace",
+		},
+	},
+	IssueLimitBursting: {
+		RunbookURL: "https://kubernetes.io/docs/concepts/configuration/manage-resources-containers/",
+		Steps: []string{
+			"Compare the container's usage over a busy period with its request",
+			"Set the request near peak usage and keep the limit within a small multiple of it",
+		},
+	},
+	IssueCPUThrottling: {
+		RunbookURL: "https://kubernetes.io/docs/concepts/configuration/manage-resources-containers/#how-pods-with-resource-limits-are-run",
+		Steps: []string{
+			"Check container_cpu_cfs_throttled_periods_total for the container in Prometheus",
+			"Raise or remove the CPU limit, keeping the request at the container's steady usage",
+		},
+	},
+	IssueCriticalNoPriority: {
+		RunbookURL: "https://kubernetes.io/docs/concepts/scheduling-eviction/pod-priority-preemption/",
+		Steps: []string{
+			"kubectl get priorityclasses to see the classes and which one is globalDefault",
+			"Set spec.priorityClassName in the workload's pod template to a class above the default",
+		},
+	},
+	IssueSystemPriorityMisuse: {
+		RunbookURL: "https://kubernetes.io/docs/concepts/scheduling-eviction/pod-priority-preemption/",
+		Steps: []string{
+			"system-cluster-critical and system-node-critical rank above every user class and preempt anything below them",
+			"Create a class for the workload below 1000000000 and switch its priorityClassName",
+			"Limit the system classes to kube-system with a ResourceQuota scoped by PriorityClass",
+		},
+	},
+	IssuePodsPreempted: {
+		RunbookURL: "https://kubernetes.io/docs/concepts/scheduling-eviction/pod-priority-preemption/",
+		Steps: []string{
+			"kubectl get events -A --field-selector reason=Preempted to see which pods preempted the workload",
+			"Compare the workload's priority with the preemptor's, and check whether the cluster is short of capacity",
+			"Give low-priority batch work preemptionPolicy Never so it waits instead of preempting",
+		},
+	},
+	IssueIdleNamespace: {
+		RunbookURL: "https://kubernetes.io/docs/concepts/overview/working-with-objects/namespaces/",
+		Steps: []string{
+			"kubectl get all,pvc,configmaps,secrets -n <namespace> to see what is left",
+			"Export the manifests and snapshot the volumes worth keeping",
+			"kubectl delete namespace <namespace>",
+		},
+	},
+	IssuePodTerminating: {
+		RunbookURL: "https://kubernetes.io/docs/concepts/workloads/pods/pod-lifecycle/#pod-termination",
+		Steps: []string{
+			"kubectl get pod <pod> -n <namespace> -o jsonpath='{.metadata.finalizers}' to list its finalizers",
+			"kubectl get node <node> to check the node still exists and is Ready",
+			"If the node is gone, kubectl delete pod <pod> -n <namespace> --grace-period=0 --force",
+		},
+	},
+	IssueNamespaceTerminating: {
+		RunbookURL: "https://kubernetes.io/docs/concepts/overview/working-with-objects/finalizers/",
+		Steps: []string{
+			"kubectl get namespace <namespace> -o yaml and read status.conditions",
+			"kubectl get apiservice and fix or delete the unavailable ones",
+			"kubectl api-resources --verbs=list --namespaced -o name | xargs -n 1 kubectl get -n <namespace> to find what is left",
+			"Remove finalizers only once the controller that owns them is gone for good",
+		},
+	},
+	IssueAPIThrottling: {
+		RunbookURL: "https://kubernetes.io/docs/concepts/cluster-administration/flow-control/",
+		Steps: []string{
+			"kubectl get prioritylevelconfigurations and kubectl get flowschemas to see how requests are classified",
+			"kubectl get --raw /debug/api_priority_and_fairness/dump_priority_levels to see the level's seats and queues",
+			"Check apiserver_flowcontrol_dispatched_requests_total by flow_schema for the heaviest clients",
+		},
+	},
+	IssueClientThrottled: {
+		RunbookURL: "https://kubernetes.io/docs/concepts/cluster-administration/flow-control/",
+		Steps: []string{
+			"Raise --interval, or set --check-ttl for the expensive sections",
+			"Disable optional scans that list many objects, such as --finalizer-scan-interval",
+		},
+	},
+	IssueWebhookFailing: {
+		RunbookURL: "https://kubernetes.io/docs/reference/access-authn-authz/extensible-admission-controllers/",
+		Steps: []string{
+			"kubectl get <configuration kind> <name> -o yaml to find the webhook's service",
+			"Check the service's endpoints and the webhook pods' logs",
+			"If the webhook cannot be fixed quickly, set its failurePolicy to Ignore or scope its namespaceSelector",
+		},
+	},
+	IssueWebhookSlow: {
+		RunbookURL: "https://kubernetes.io/docs/reference/access-authn-authz/extensible-admission-controllers/#timeouts",
+		Steps: []string{
+			"Check the webhook pods' CPU usage and scale them out",
+			"Narrow the webhook's rules, namespaceSelector or objectSelector so it sees fewer requests",
+			"Lower timeoutSeconds so a hanging webhook fails fast",
+		},
+	},
+	IssuePodOSMismatch: {
+		RunbookURL: "https://kubernetes.io/docs/concepts/windows/user-guide/#ensuring-os-specific-workloads-land-on-the-appropriate-container-host",
+		Steps: []string{
+			"kubectl describe pod <pod> and check for image pull or container creation errors naming the platform",
+			"Add nodeSelector kubernetes.io/os: linux to Linux workloads, or set spec.os.name: windows on Windows ones",
+			"Taint Windows nodes os=windows:NoSchedule and give Windows workloads a matching toleration",
+		},
+	},
+	IssueFailedMount: {
+		RunbookURL: "https://kubernetes.io/docs/concepts/storage/persistent-volumes/",
+		Steps: []string{
+			"kubectl describe pod <pod> for the volume that failed to mount",
+			"Check the PersistentVolumeClaim is bound and the volume is attachable to the node",
+		},
+	},
+}
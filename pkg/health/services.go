@@ -0,0 +1,64 @@
+package health
+
+import (
+	"sort"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// PendingLoadBalancer is a LoadBalancer service the cloud provider has not given an address
+type PendingLoadBalancer struct {
+	Namespace string    `json:"namespace"`
+	Name      string    `json:"name"`
+	Created   time.Time `json:"created"`
+}
+
+// LoadBalancerPendingAfter is how long a LoadBalancer service may wait for an external IP or
+// hostname before it is reported as stuck
+var LoadBalancerPendingAfter = 10 * time.Minute
+
+// NodePortRange is the apiserver's --service-node-port-range, inclusive
+var NodePortRange = [2]int32{30000, 32767}
+
+// NodePortWarningRatio is the fraction of the NodePort range in use at which exhaustion is
+// reported; the rest of the range is critical
+const NodePortWarningRatio = 0.8
+
+// checkServiceProvisioning finds LoadBalancer services without an ingress address after
+// LoadBalancerPendingAfter and counts the node ports allocated from NodePortRange
+func checkServiceProvisioning(services []v1.Service, now time.Time, status *NetworkStatus) {
+	ports := make(map[int32]bool)
+	for _, svc := range services {
+		for _, port := range svc.Spec.Ports {
+			if port.NodePort >= NodePortRange[0] && port.NodePort <= NodePortRange[1] {
+				ports[port.NodePort] = true
+			}
+		}
+
+		if svc.Spec.Type != v1.ServiceTypeLoadBalancer || hasIngressAddress(svc.Status.LoadBalancer) {
+			continue
+		}
+		if created := svc.CreationTimestamp.Time; now.Sub(created) >= LoadBalancerPendingAfter {
+			status.PendingLoadBalancers = append(status.PendingLoadBalancers,
+				PendingLoadBalancer{Namespace: svc.Namespace, Name: svc.Name, Created: created})
+		}
+	}
+	status.NodePortsUsed = len(ports)
+	status.NodePortsTotal = int(NodePortRange[1]-NodePortRange[0]) + 1
+
+	sort.Slice(status.PendingLoadBalancers, func(i, j int) bool {
+		a, b := status.PendingLoadBalancers[i], status.PendingLoadBalancers[j]
+		return a.Namespace+"/"+a.Name < b.Namespace+"/"+b.Name
+	})
+}
+
+// hasIngressAddress reports whether a load balancer status has an IP or hostname
+func hasIngressAddress(lb v1.LoadBalancerStatus) bool {
+	for _, ingress := range lb.Ingress {
+		if ingress.IP != "" || ingress.Hostname != "" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/snapshot"
+)
+
+// kubeletSummary is the part of the kubelet's /stats/summary response the checks read
+type kubeletSummary struct {
+	Node struct {
+		Fs      *kubeletFsStats `json:"fs"`
+		Runtime *struct {
+			ImageFs *kubeletFsStats `json:"imageFs"`
+		} `json:"runtime"`
+	} `json:"node"`
+	Pods []struct {
+		PodRef struct {
+			Name      string `json:"name"`
+			Namespace string `json:"namespace"`
+		} `json:"podRef"`
+		Volumes []struct {
+			Name          string  `json:"name"`
+			CapacityBytes *uint64 `json:"capacityBytes"`
+			UsedBytes     *uint64 `json:"usedBytes"`
+			Inodes        *uint64 `json:"inodes"`
+			InodesUsed    *uint64 `json:"inodesUsed"`
+			PVCRef        *struct {
+				Name      string `json:"name"`
+				Namespace string `json:"namespace"`
+			} `json:"pvcRef"`
+		} `json:"volume"`
+	} `json:"pods"`
+}
+
+// kubeletFsStats is a filesystem's usage in a stats summary
+type kubeletFsStats struct {
+	AvailableBytes *uint64 `json:"availableBytes"`
+	CapacityBytes  *uint64 `json:"capacityBytes"`
+	UsedBytes      *uint64 `json:"usedBytes"`
+}
+
+// fetchKubeletSummaries reads the stats summary of every ready node, keyed by node name.
+// Large clusters are skipped since it costs one request per node.
+func fetchKubeletSummaries(ctx context.Context, clientset kubernetes.Interface, snap *snapshot.ClusterSnapshot) (map[string]*kubeletSummary, error) {
+	summaries := make(map[string]*kubeletSummary)
+	if clientset == nil || snap.Large {
+		return summaries, nil
+	}
+	partial := &PartialError{}
+	for i := range snap.Nodes {
+		node := &snap.Nodes[i]
+		if !nodeReady(node) || snapshot.IsVirtualNode(node) {
+			continue
+		}
+		summary, err := fetchKubeletSummary(ctx, clientset, node.Name)
+		if err != nil {
+			partial.Errors = append(partial.Errors, err)
+			continue
+		}
+		summaries[node.Name] = summary
+	}
+	if len(partial.Errors) > 0 {
+		return summaries, partial
+	}
+	return summaries, nil
+}
+
+// fetchKubeletSummary reads a node's stats summary through the apiserver's node proxy
+func fetchKubeletSummary(ctx context.Context, clientset kubernetes.Interface, node string) (*kubeletSummary, error) {
+	data, err := clientset.CoreV1().RESTClient().Get().
+		AbsPath("/api/v1/nodes", node, "proxy", "stats", "summary").DoRaw(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stats summary from node %s: %w", node, err)
+	}
+	var summary kubeletSummary
+	if err := json.Unmarshal(data, &summary); err != nil {
+		return nil, fmt.Errorf("failed to parse stats summary from node %s: %w", node, err)
+	}
+	return &summary, nil
+}
+
+// statValue dereferences an optional kubelet stat, treating missing values as zero
+func statValue(v *uint64) int64 {
+	if v == nil {
+		return 0
+	}
+	return int64(*v)
+}
+
+// nodeReady reports whether a node's Ready condition is true, so its kubelet can be queried
+func nodeReady(node *v1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == v1.NodeReady {
+			return condition.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
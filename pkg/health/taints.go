@@ -0,0 +1,152 @@
+package health
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/snapshot"
+)
+
+// TaintStatus audits cordoned nodes, custom taints and the pods they keep from scheduling
+type TaintStatus struct {
+	CordonedNodes []CordonedNode `json:"cordonedNodes,omitempty"`
+	TaintedNodes  []TaintedNode  `json:"taintedNodes,omitempty"`
+	BlockedPods   []BlockedPod   `json:"blockedPods,omitempty"`
+
+	// Allocatable capacity of cordoned nodes
+	CordonedCPU    float64 `json:"cordonedCPU"`    // cores
+	CordonedMemory float64 `json:"cordonedMemory"` // GiB
+}
+
+// CordonedNode is a node marked unschedulable
+type CordonedNode struct {
+	Node     string        `json:"node"`
+	Since    time.Time     `json:"since,omitempty"`    // when the unschedulable taint was added, if known
+	Duration time.Duration `json:"duration,omitempty"` // 0 if unknown
+}
+
+// TaintedNode is a node with NoSchedule or NoExecute taints outside StandardTaints
+type TaintedNode struct {
+	Node   string   `json:"node"`
+	Taints []string `json:"taints"` // "key=value:Effect"
+}
+
+// BlockedPod is a pending pod that the scheduler could not place because of taints
+type BlockedPod struct {
+	Namespace string   `json:"namespace"`
+	Name      string   `json:"name"`
+	Taints    []string `json:"taints"` // untolerated taints on nodes in the cluster
+}
+
+// StandardTaints are taint key prefixes set by Kubernetes itself or by cluster setup, which
+// the audit does not report
+var StandardTaints = []string{
+	"node.kubernetes.io/",
+	"node-role.kubernetes.io/control-plane",
+	"node-role.kubernetes.io/master",
+	"node.cloudprovider.kubernetes.io/",
+	"ToBeDeletedByClusterAutoscaler",
+	"DeletionCandidateOfClusterAutoscaler",
+	"virtual-kubelet.io/provider",    // keeps pods off virtual nodes unless they tolerate it
+	"eks.amazonaws.com/compute-type", // Fargate nodes
+}
+
+// CordonWarnAfter is how long a node may stay cordoned before the cordon is reported as
+// probably forgotten
+var CordonWarnAfter = 24 * time.Hour
+
+// isStandardTaint reports whether a taint key is covered by StandardTaints
+func isStandardTaint(key string) bool {
+	for _, prefix := range StandardTaints {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// formatTaint renders a taint as kubectl shows it
+func formatTaint(taint v1.Taint) string {
+	if taint.Value == "" {
+		return fmt.Sprintf("%s:%s", taint.Key, taint.Effect)
+	}
+	return fmt.Sprintf("%s=%s:%s", taint.Key, taint.Value, taint.Effect)
+}
+
+// TaintAudit finds cordoned nodes and how long they have been cordoned, nodes with custom
+// scheduling taints, and unscheduled pods whose scheduling failure names taints
+func TaintAudit(snap *snapshot.ClusterSnapshot, now time.Time) TaintStatus {
+	var status TaintStatus
+	custom := make(map[string]v1.Taint) // formatted taint -> taint, across nodes
+	for _, node := range snap.Nodes {
+		if node.Spec.Unschedulable {
+			cordoned := CordonedNode{Node: node.Name}
+			for _, taint := range node.Spec.Taints {
+				if taint.Key == v1.TaintNodeUnschedulable && taint.TimeAdded != nil {
+					cordoned.Since = taint.TimeAdded.Time
+					cordoned.Duration = now.Sub(cordoned.Since)
+				}
+			}
+			status.CordonedNodes = append(status.CordonedNodes, cordoned)
+			status.CordonedCPU += float64(node.Status.Allocatable.Cpu().MilliValue()) / 1000
+			status.CordonedMemory += float64(node.Status.Allocatable.Memory().Value()) / (1 << 30)
+		}
+
+		var taints []string
+		for _, taint := range node.Spec.Taints {
+			if taint.Effect == v1.TaintEffectPreferNoSchedule || isStandardTaint(taint.Key) {
+				continue
+			}
+			formatted := formatTaint(taint)
+			taints = append(taints, formatted)
+			custom[formatted] = taint
+		}
+		if len(taints) > 0 {
+			status.TaintedNodes = append(status.TaintedNodes, TaintedNode{Node: node.Name, Taints: taints})
+		}
+	}
+
+	for i := range snap.Pods {
+		pod := &snap.Pods[i]
+		if pod.Status.Phase != v1.PodPending || pod.Spec.NodeName != "" || !unschedulableForTaints(pod) {
+			continue
+		}
+		var taints []string
+		for formatted, taint := range custom {
+			if !toleratesTaint(pod.Spec.Tolerations, taint) {
+				taints = append(taints, formatted)
+			}
+		}
+		sort.Strings(taints)
+		status.BlockedPods = append(status.BlockedPods, BlockedPod{Namespace: pod.Namespace, Name: pod.Name, Taints: taints})
+	}
+
+	sort.Slice(status.CordonedNodes, func(i, j int) bool { return status.CordonedNodes[i].Node < status.CordonedNodes[j].Node })
+	sort.Slice(status.TaintedNodes, func(i, j int) bool { return status.TaintedNodes[i].Node < status.TaintedNodes[j].Node })
+	return status
+}
+
+// unschedulableForTaints reports whether the scheduler's last failure for a pod mentions taints
+func unschedulableForTaints(pod *v1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == v1.PodScheduled && condition.Status == v1.ConditionFalse &&
+			condition.Reason == v1.PodReasonUnschedulable && strings.Contains(condition.Message, "taint") {
+			return true
+		}
+	}
+	return false
+}
+
+// toleratesTaint reports whether any toleration matches the taint
+func toleratesTaint(tolerations []v1.Toleration, taint v1.Taint) bool {
+	for i := range tolerations {
+		if tolerations[i].ToleratesTaint(&taint) {
+			return true
+		}
+	}
+	return false
+}
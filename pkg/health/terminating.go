@@ -0,0 +1,209 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/retry"
+	"github.com/ochestra-tech/ochestra-ai/pkg/snapshot"
+)
+
+// TerminatingStuckAfter is how long a pod may stay Terminating past its grace period, and a
+// namespace past its deletion, before it is reported as stuck
+var TerminatingStuckAfter = 10 * time.Minute
+
+// Reasons a pod is stuck terminating
+const (
+	StuckFinalizers   = "finalizers"    // finalizers on the pod are not being removed
+	StuckNodeGone     = "node gone"     // the pod's node no longer exists
+	StuckNodeNotReady = "node NotReady" // the kubelet cannot confirm the containers stopped
+	StuckUnknown      = "unknown"
+)
+
+// TerminatingStatus lists pods and namespaces whose deletion is not completing
+type TerminatingStatus struct {
+	Pods       []StuckPod       `json:"pods,omitempty"`
+	Namespaces []StuckNamespace `json:"namespaces,omitempty"`
+}
+
+// StuckPod is a pod Terminating for longer than TerminatingStuckAfter past its grace period
+type StuckPod struct {
+	Namespace  string        `json:"namespace"`
+	Name       string        `json:"name"`
+	Node       string        `json:"node,omitempty"`
+	DeletedAt  time.Time     `json:"deletedAt"`
+	Duration   time.Duration `json:"duration"` // since deletion
+	Reason     string        `json:"reason"`
+	Finalizers []string      `json:"finalizers,omitempty"`
+}
+
+// StuckNamespace is a namespace Terminating for longer than TerminatingStuckAfter, with
+// what the namespace controller reports is blocking it
+type StuckNamespace struct {
+	Namespace string        `json:"namespace"`
+	DeletedAt time.Time     `json:"deletedAt"`
+	Duration  time.Duration `json:"duration"`
+
+	Finalizers      []string `json:"finalizers,omitempty"`      // finalizers on remaining content, and on the namespace itself
+	UnavailableAPIs []string `json:"unavailableAPIs,omitempty"` // API groups that failed discovery, usually an aggregated API whose backend is down
+	Content         string   `json:"content,omitempty"`         // the controller's message about remaining content
+	Errors          []string `json:"errors,omitempty"`          // content deletion failures
+}
+
+// checkTerminating finds stuck pods in the snapshot and lists namespaces to find stuck ones
+func checkTerminating(ctx context.Context, clientset kubernetes.Interface, snap *snapshot.ClusterSnapshot, now time.Time, status *TerminatingStatus) error {
+	status.Pods = stuckPods(snap, now)
+	if clientset == nil {
+		return nil
+	}
+	namespaces, err := retry.Value(ctx, retry.DefaultBackoff, func() (*v1.NamespaceList, error) {
+		return clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list namespaces: %w", err)
+	}
+	status.Namespaces = stuckNamespaces(namespaces.Items, now)
+	return nil
+}
+
+// stuckPods returns the pods Terminating past their grace period plus TerminatingStuckAfter
+func stuckPods(snap *snapshot.ClusterSnapshot, now time.Time) []StuckPod {
+	var stuck []StuckPod
+	for i := range snap.Pods {
+		pod := &snap.Pods[i]
+		if pod.DeletionTimestamp == nil {
+			continue
+		}
+		deadline := pod.DeletionTimestamp.Time
+		if pod.DeletionGracePeriodSeconds != nil {
+			// The deletion timestamp is already set the grace period ahead
+			deadline = deadline.Add(time.Duration(*pod.DeletionGracePeriodSeconds) * time.Second)
+		}
+		if now.Sub(deadline) < TerminatingStuckAfter {
+			continue
+		}
+
+		p := StuckPod{
+			Namespace:  pod.Namespace,
+			Name:       pod.Name,
+			Node:       pod.Spec.NodeName,
+			DeletedAt:  pod.DeletionTimestamp.Time,
+			Duration:   now.Sub(pod.DeletionTimestamp.Time),
+			Finalizers: pod.Finalizers,
+			Reason:     StuckUnknown,
+		}
+		switch node := snap.Node(pod.Spec.NodeName); {
+		case len(pod.Finalizers) > 0:
+			p.Reason = StuckFinalizers
+		case pod.Spec.NodeName != "" && node == nil:
+			p.Reason = StuckNodeGone
+		case node != nil && !nodeReady(node):
+			p.Reason = StuckNodeNotReady
+		}
+		stuck = append(stuck, p)
+	}
+	sort.Slice(stuck, func(i, j int) bool {
+		if stuck[i].Namespace != stuck[j].Namespace {
+			return stuck[i].Namespace < stuck[j].Namespace
+		}
+		return stuck[i].Name < stuck[j].Name
+	})
+	return stuck
+}
+
+// discoveryFailurePattern matches the group versions in a NamespaceDeletionDiscoveryFailure
+// message, e.g. "metrics.k8s.io/v1beta1: the server is currently unable to handle the request"
+var discoveryFailurePattern = regexp.MustCompile(`([a-z0-9][a-z0-9.-]*/v[0-9][a-z0-9]*): `)
+
+// stuckNamespaces returns the namespaces Terminating for longer than TerminatingStuckAfter,
+// reading what blocks them from the namespace controller's conditions
+func stuckNamespaces(namespaces []v1.Namespace, now time.Time) []StuckNamespace {
+	var stuck []StuckNamespace
+	for _, ns := range namespaces {
+		if ns.Status.Phase != v1.NamespaceTerminating || ns.DeletionTimestamp == nil ||
+			now.Sub(ns.DeletionTimestamp.Time) < TerminatingStuckAfter {
+			continue
+		}
+		s := StuckNamespace{
+			Namespace: ns.Name,
+			DeletedAt: ns.DeletionTimestamp.Time,
+			Duration:  now.Sub(ns.DeletionTimestamp.Time),
+		}
+		for _, condition := range ns.Status.Conditions {
+			if condition.Status != v1.ConditionTrue {
+				continue
+			}
+			switch condition.Type {
+			case v1.NamespaceDeletionDiscoveryFailure:
+				for _, match := range discoveryFailurePattern.FindAllStringSubmatch(condition.Message, -1) {
+					s.UnavailableAPIs = appendUnique(s.UnavailableAPIs, match[1])
+				}
+			case v1.NamespaceFinalizersRemaining:
+				s.Finalizers = append(s.Finalizers, remainingFinalizers(condition.Message)...)
+			case v1.NamespaceContentRemaining:
+				s.Content = condition.Message
+			case v1.NamespaceDeletionContentFailure, v1.NamespaceDeletionGVParsingFailure:
+				s.Errors = append(s.Errors, condition.Message)
+			}
+		}
+		for _, finalizer := range ns.Spec.Finalizers {
+			s.Finalizers = appendUnique(s.Finalizers, string(finalizer))
+		}
+		stuck = append(stuck, s)
+	}
+	sort.Slice(stuck, func(i, j int) bool { return stuck[i].Namespace < stuck[j].Namespace })
+	return stuck
+}
+
+// remainingFinalizers reads the finalizers from a NamespaceFinalizersRemaining message, e.g.
+// "Some content in the namespace has finalizers remaining: foo.io/cleanup in 2 resource instances"
+func remainingFinalizers(message string) []string {
+	_, list, ok := strings.Cut(message, "remaining: ")
+	if !ok {
+		return nil
+	}
+	var finalizers []string
+	for _, item := range strings.Split(list, ", ") {
+		finalizer, _, _ := strings.Cut(item, " in ")
+		if finalizer = strings.TrimSpace(finalizer); finalizer != "" {
+			finalizers = appendUnique(finalizers, finalizer)
+		}
+	}
+	return finalizers
+}
+
+// appendUnique appends value to values unless it is already there
+func appendUnique(values []string, value string) []string {
+	if slices.Contains(values, value) {
+		return values
+	}
+	return append(values, value)
+}
+
+// blockers describes what keeps a namespace Terminating, for its issue message
+func (s StuckNamespace) blockers() string {
+	var parts []string
+	if len(s.UnavailableAPIs) > 0 {
+		parts = append(parts, "unavailable APIs "+strings.Join(s.UnavailableAPIs, ", "))
+	}
+	if len(s.Finalizers) > 0 {
+		parts = append(parts, "finalizers "+strings.Join(s.Finalizers, ", "))
+	}
+	if len(parts) == 0 && s.Content != "" {
+		parts = append(parts, s.Content)
+	}
+	parts = append(parts, s.Errors...)
+	if len(parts) == 0 {
+		return "no blocker reported by the namespace controller"
+	}
+	return strings.Join(parts, "; ")
+}
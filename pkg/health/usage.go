@@ -0,0 +1,185 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/retry"
+	"github.com/ochestra-tech/ochestra-ai/pkg/snapshot"
+)
+
+// Node usage, as a fraction of allocatable, at which a node is reported as busy, and the
+// fraction of its allocatable CPU or memory left unrequested below which it is low on
+// resources
+var (
+	NodeUsageHigh        = 0.8
+	NodeUnrequestedLow   = 0.1
+	NamespaceRequestHigh = 0.9 // usage of what a namespace's pods request
+)
+
+// checkResourceUsage measures cluster and per-node CPU and memory usage from the
+// snapshot's node metrics, and finds nodes with little left to schedule and namespaces
+// using nearly all they request
+func checkResourceUsage(snap *snapshot.ClusterSnapshot, status *ResourceUsageStatus) error {
+	status.HighCPUNodes = make([]string, 0)
+	status.HighMemoryNodes = make([]string, 0)
+	status.LowResourceNodes = make([]string, 0)
+	status.HighUsageNamespaces = make([]string, 0)
+
+	requested := make(map[string]v1.ResourceList)
+	for _, pod := range snap.Pods {
+		if pod.Spec.NodeName == "" || pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+			continue
+		}
+		list, ok := requested[pod.Spec.NodeName]
+		if !ok {
+			list = v1.ResourceList{}
+			requested[pod.Spec.NodeName] = list
+		}
+		for _, c := range pod.Spec.Containers {
+			for _, name := range []v1.ResourceName{v1.ResourceCPU, v1.ResourceMemory} {
+				if q, ok := c.Resources.Requests[name]; ok {
+					total := list[name]
+					total.Add(q)
+					list[name] = total
+				}
+			}
+		}
+	}
+
+	usage := make(map[string]v1.ResourceList, len(snap.NodeMetrics))
+	for _, m := range snap.NodeMetrics {
+		usage[m.Name] = m.Usage
+	}
+
+	var cpuUsed, cpuAllocatable, memUsed, memAllocatable int64
+	for i := range snap.Nodes {
+		node := &snap.Nodes[i]
+		if snapshot.IsVirtualNode(node) {
+			continue
+		}
+		cpu, mem := node.Status.Allocatable.Cpu().MilliValue(), node.Status.Allocatable.Memory().Value()
+		if cpu == 0 || mem == 0 {
+			continue
+		}
+
+		if used, ok := usage[node.Name]; ok {
+			cpuUsed += used.Cpu().MilliValue()
+			memUsed += used.Memory().Value()
+			cpuAllocatable += cpu
+			memAllocatable += mem
+			if float64(used.Cpu().MilliValue())/float64(cpu) >= NodeUsageHigh {
+				status.HighCPUNodes = append(status.HighCPUNodes, node.Name)
+			}
+			if float64(used.Memory().Value())/float64(mem) >= NodeUsageHigh {
+				status.HighMemoryNodes = append(status.HighMemoryNodes, node.Name)
+			}
+		}
+
+		req := requested[node.Name]
+		if 1-float64(req.Cpu().MilliValue())/float64(cpu) < NodeUnrequestedLow ||
+			1-float64(req.Memory().Value())/float64(mem) < NodeUnrequestedLow {
+			status.LowResourceNodes = append(status.LowResourceNodes, node.Name)
+		}
+	}
+	if cpuAllocatable > 0 {
+		status.ClusterCPUUsage = float64(cpuUsed) / float64(cpuAllocatable) * 100
+	}
+	if memAllocatable > 0 {
+		status.ClusterMemoryUsage = float64(memUsed) / float64(memAllocatable) * 100
+	}
+
+	status.HighUsageNamespaces = highUsageNamespaces(snap)
+	sort.Strings(status.HighCPUNodes)
+	sort.Strings(status.HighMemoryNodes)
+	sort.Strings(status.LowResourceNodes)
+
+	if err := snap.Errors["nodeMetrics"]; err != nil {
+		return fmt.Errorf("node metrics unavailable: %w", err)
+	}
+	return nil
+}
+
+// highUsageNamespaces returns the namespaces whose pods use NamespaceRequestHigh or more of
+// the CPU or memory they request, ordered by name
+func highUsageNamespaces(snap *snapshot.ClusterSnapshot) []string {
+	type totals struct{ cpuUsed, cpuRequested, memUsed, memRequested int64 }
+	byNamespace := make(map[string]*totals)
+	get := func(namespace string) *totals {
+		t, ok := byNamespace[namespace]
+		if !ok {
+			t = &totals{}
+			byNamespace[namespace] = t
+		}
+		return t
+	}
+
+	measured := make(map[string]bool, len(snap.PodMetrics))
+	for _, m := range snap.PodMetrics {
+		t := get(m.Namespace)
+		for _, c := range m.Containers {
+			t.cpuUsed += c.Usage.Cpu().MilliValue()
+			t.memUsed += c.Usage.Memory().Value()
+		}
+		measured[m.Namespace+"/"+m.Name] = true
+	}
+	for _, pod := range snap.Pods {
+		if !measured[pod.Namespace+"/"+pod.Name] {
+			continue
+		}
+		t := get(pod.Namespace)
+		for _, c := range pod.Spec.Containers {
+			t.cpuRequested += c.Resources.Requests.Cpu().MilliValue()
+			t.memRequested += c.Resources.Requests.Memory().Value()
+		}
+	}
+
+	result := make([]string, 0)
+	for namespace, t := range byNamespace {
+		if (t.cpuRequested > 0 && float64(t.cpuUsed)/float64(t.cpuRequested) >= NamespaceRequestHigh) ||
+			(t.memRequested > 0 && float64(t.memUsed)/float64(t.memRequested) >= NamespaceRequestHigh) {
+			result = append(result, namespace)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// checkComponentStatuses reads the health of the scheduler, controller manager and etcd
+// from the componentstatuses API. It is deprecated, but still the only place some
+// clusters report these components.
+func checkComponentStatuses(ctx context.Context, clientset kubernetes.Interface, statuses *[]ComponentStatus) error {
+	if clientset == nil {
+		return nil
+	}
+	list, err := retry.Value(ctx, retry.DefaultBackoff, func() (*v1.ComponentStatusList, error) {
+		return clientset.CoreV1().ComponentStatuses().List(ctx, metav1.ListOptions{})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list component statuses: %w", err)
+	}
+
+	result := make([]ComponentStatus, 0, len(list.Items))
+	for _, item := range list.Items {
+		status := ComponentStatus{Name: item.Name}
+		for _, c := range item.Conditions {
+			if c.Type != v1.ComponentHealthy {
+				continue
+			}
+			status.Healthy = c.Status == v1.ConditionTrue
+			status.Message = c.Message
+			if c.Error != "" {
+				status.Message = c.Error
+			}
+		}
+		result = append(result, status)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	*statuses = result
+	return nil
+}
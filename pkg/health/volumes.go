@@ -0,0 +1,153 @@
+package health
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// VolumeUsage is a persistent volume claim's usage as the kubelet mounting it reports
+type VolumeUsage struct {
+	Namespace     string  `json:"namespace"`
+	PVC           string  `json:"pvc"`
+	Node          string  `json:"node"`
+	CapacityBytes int64   `json:"capacityBytes"`
+	UsedBytes     int64   `json:"usedBytes"`
+	Usage         float64 `json:"usage"` // fraction of capacity
+	Inodes        int64   `json:"inodes,omitempty"`
+	InodesUsed    int64   `json:"inodesUsed,omitempty"`
+	InodeUsage    float64 `json:"inodeUsage,omitempty"`
+}
+
+// StorageTrend is a namespace whose volumes are filling fast enough to run out within
+// StorageTrendHorizon
+type StorageTrend struct {
+	Namespace     string        `json:"namespace"`
+	CapacityBytes int64         `json:"capacityBytes"`
+	UsedBytes     int64         `json:"usedBytes"`
+	GrowthPerDay  int64         `json:"growthPerDay"` // bytes
+	FullIn        time.Duration `json:"fullIn"`
+}
+
+// Volume fullness, of bytes or inodes, at which a claim is a warning and critical
+var (
+	VolumeFullWarning  = 0.8
+	VolumeFullCritical = 0.95
+)
+
+// StorageTrendWindow is how far back namespace usage is compared to measure growth, and
+// StorageTrendHorizon how soon a namespace must be projected to fill to be reported
+var (
+	StorageTrendWindow  = 6 * time.Hour
+	StorageTrendHorizon = 7 * 24 * time.Hour
+)
+
+// storageSample is a namespace's volume usage at one check
+type storageSample struct {
+	time time.Time
+	used int64
+}
+
+// storageHistory keeps each namespace's samples within StorageTrendWindow
+var storageHistory = struct {
+	sync.Mutex
+	namespaces map[string][]storageSample
+}{namespaces: make(map[string][]storageSample)}
+
+// checkVolumeUsage reads per-claim volume stats from the nodes' kubelet summaries, records
+// claims above VolumeFullWarning, the cluster's storage usage, and namespaces whose usage
+// growth would fill their volumes within StorageTrendHorizon
+func checkVolumeUsage(summaries map[string]*kubeletSummary, status *ResourceUsageStatus) {
+	if len(summaries) == 0 {
+		return
+	}
+
+	volumes := make(map[string]VolumeUsage) // namespace/pvc -> usage, once per claim
+	for node, summary := range summaries {
+		for _, pod := range summary.Pods {
+			for _, volume := range pod.Volumes {
+				if volume.PVCRef == nil || statValue(volume.CapacityBytes) == 0 {
+					continue
+				}
+				usage := VolumeUsage{
+					Namespace:     volume.PVCRef.Namespace,
+					PVC:           volume.PVCRef.Name,
+					Node:          node,
+					CapacityBytes: statValue(volume.CapacityBytes),
+					UsedBytes:     statValue(volume.UsedBytes),
+					Inodes:        statValue(volume.Inodes),
+					InodesUsed:    statValue(volume.InodesUsed),
+				}
+				usage.Usage = float64(usage.UsedBytes) / float64(usage.CapacityBytes)
+				if usage.Inodes > 0 {
+					usage.InodeUsage = float64(usage.InodesUsed) / float64(usage.Inodes)
+				}
+				volumes[usage.Namespace+"/"+usage.PVC] = usage
+			}
+		}
+	}
+
+	var capacity, used int64
+	namespaces := make(map[string]*StorageTrend)
+	for _, usage := range volumes {
+		capacity += usage.CapacityBytes
+		used += usage.UsedBytes
+		if usage.Usage >= VolumeFullWarning || usage.InodeUsage >= VolumeFullWarning {
+			status.FullVolumes = append(status.FullVolumes, usage)
+		}
+
+		ns, ok := namespaces[usage.Namespace]
+		if !ok {
+			ns = &StorageTrend{Namespace: usage.Namespace}
+			namespaces[usage.Namespace] = ns
+		}
+		ns.CapacityBytes += usage.CapacityBytes
+		ns.UsedBytes += usage.UsedBytes
+	}
+	if capacity > 0 {
+		status.ClusterStorageUsage = float64(used) / float64(capacity) * 100
+	}
+	sort.Slice(status.FullVolumes, func(i, j int) bool {
+		return status.FullVolumes[i].Namespace+"/"+status.FullVolumes[i].PVC < status.FullVolumes[j].Namespace+"/"+status.FullVolumes[j].PVC
+	})
+
+	status.StorageTrends = storageTrends(namespaces, time.Now())
+}
+
+// storageTrends records each namespace's usage and projects when it fills, from growth
+// since the oldest sample within StorageTrendWindow
+func storageTrends(namespaces map[string]*StorageTrend, now time.Time) []StorageTrend {
+	storageHistory.Lock()
+	defer storageHistory.Unlock()
+
+	trends := make([]StorageTrend, 0)
+	for name, ns := range namespaces {
+		samples := storageHistory.namespaces[name]
+		for len(samples) > 0 && now.Sub(samples[0].time) > StorageTrendWindow {
+			samples = samples[1:]
+		}
+		if len(samples) > 0 {
+			oldest := samples[0]
+			// Growth over less than an hour is too noisy to project from
+			if elapsed := now.Sub(oldest.time); elapsed >= time.Hour && ns.UsedBytes > oldest.used {
+				ns.GrowthPerDay = int64(float64(ns.UsedBytes-oldest.used) / elapsed.Hours() * 24)
+				if ns.GrowthPerDay > 0 {
+					ns.FullIn = time.Duration(float64(ns.CapacityBytes-ns.UsedBytes) / float64(ns.GrowthPerDay) * float64(24*time.Hour))
+				}
+			}
+		}
+		storageHistory.namespaces[name] = append(samples, storageSample{time: now, used: ns.UsedBytes})
+
+		if ns.GrowthPerDay > 0 && ns.FullIn < StorageTrendHorizon {
+			trends = append(trends, *ns)
+		}
+	}
+	for name := range storageHistory.namespaces {
+		if namespaces[name] == nil {
+			delete(storageHistory.namespaces, name)
+		}
+	}
+
+	sort.Slice(trends, func(i, j int) bool { return trends[i].FullIn < trends[j].FullIn })
+	return trends
+}
@@ -0,0 +1,204 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	admissionv1 "k8s.io/api/admissionregistration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/retry"
+)
+
+// WebhookLatencyWarn is the mean admission latency of a webhook at or above which it is
+// reported as slow. A webhook averaging half its timeout is reported as well.
+var WebhookLatencyWarn = 500 * time.Millisecond
+
+// AdmissionStatus reports the latency and failures of admission webhooks since the
+// previous check, from the API server's admission metrics
+type AdmissionStatus struct {
+	MetricsVisible bool            `json:"metricsVisible"`
+	Webhooks       []WebhookStatus `json:"webhooks,omitempty"`
+}
+
+// WebhookStatus is one webhook's admission calls since the previous check, with the
+// configuration that registers it
+type WebhookStatus struct {
+	Name           string        `json:"name"`
+	Type           string        `json:"type"`                     // "mutating" or "validating"
+	Configuration  string        `json:"configuration,omitempty"`  // "<Kind>/<name>", empty if no configuration registers it any more
+	FailurePolicy  string        `json:"failurePolicy,omitempty"`  // "Fail" or "Ignore"
+	TimeoutSeconds int32         `json:"timeoutSeconds,omitempty"` // 0 if unknown
+	Requests       float64       `json:"requests"`
+	Rejected       float64       `json:"rejected"`   // denied by the webhook or failed with failurePolicy Fail
+	Errors         float64       `json:"errors"`     // calls that failed, e.g. timeouts or an unreachable service
+	FailedOpen     float64       `json:"failedOpen"` // failed calls admitted anyway under failurePolicy Ignore
+	MeanLatency    time.Duration `json:"meanLatency"`
+}
+
+// webhookCounters are a webhook's cumulative admission metrics
+type webhookCounters struct {
+	requests, rejected, errors, failedOpen, latencySum float64
+}
+
+// sub returns the counters accumulated since previous, or all of them after an API
+// server restart
+func (c webhookCounters) sub(previous webhookCounters) webhookCounters {
+	if c.requests < previous.requests {
+		return c
+	}
+	return webhookCounters{
+		requests:   c.requests - previous.requests,
+		rejected:   c.rejected - previous.rejected,
+		errors:     max(c.errors-previous.errors, 0),
+		failedOpen: max(c.failedOpen-previous.failedOpen, 0),
+		latencySum: c.latencySum - previous.latencySum,
+	}
+}
+
+// lastWebhookCounters holds each webhook's counters from the previous check, keyed by
+// "<type>/<name>"
+var lastWebhookCounters = struct {
+	sync.Mutex
+	webhooks map[string]webhookCounters
+}{}
+
+// webhookTypes maps the metrics' type label to the kind of webhook
+var webhookTypes = map[string]string{"admit": "mutating", "validating": "validating"}
+
+// checkAdmissionWebhooks reads the admission webhook metrics and ties each webhook to its
+// configuration. The first check only records the counters.
+func checkAdmissionWebhooks(ctx context.Context, clientset kubernetes.Interface, apiMetrics *apiServerMetrics, status *AdmissionStatus) error {
+	if clientset == nil {
+		return nil
+	}
+	samples, err := apiMetrics.get(ctx)
+	if err != nil {
+		return err
+	}
+	status.MetricsVisible = true
+	counters := parseWebhookMetrics(samples)
+
+	lastWebhookCounters.Lock()
+	previous := lastWebhookCounters.webhooks
+	lastWebhookCounters.webhooks = counters
+	lastWebhookCounters.Unlock()
+	if previous == nil {
+		return nil
+	}
+
+	configs, err := webhookConfigurations(ctx, clientset)
+	if err != nil {
+		return &PartialError{Errors: []error{err}}
+	}
+	for key, c := range counters {
+		delta := c.sub(previous[key])
+		if delta.requests == 0 && delta.errors == 0 {
+			continue
+		}
+		kind, name, _ := strings.Cut(key, "/")
+		webhook := configs[key]
+		webhook.Name, webhook.Type = name, kind
+		webhook.Requests = delta.requests
+		webhook.Rejected = delta.rejected
+		webhook.Errors = delta.errors
+		webhook.FailedOpen = delta.failedOpen
+		if delta.requests > 0 {
+			webhook.MeanLatency = time.Duration(delta.latencySum / delta.requests * float64(time.Second))
+		}
+		status.Webhooks = append(status.Webhooks, webhook)
+	}
+	sort.Slice(status.Webhooks, func(i, j int) bool { return status.Webhooks[i].MeanLatency > status.Webhooks[j].MeanLatency })
+	return nil
+}
+
+// parseWebhookMetrics reads the cumulative admission webhook metrics keyed by
+// "<type>/<webhook name>"
+func parseWebhookMetrics(samples []metricSample) map[string]webhookCounters {
+	counters := make(map[string]webhookCounters)
+	for _, sample := range samples {
+		if !strings.HasPrefix(sample.name, "apiserver_admission_webhook_") {
+			continue
+		}
+		kind, ok := webhookTypes[sample.labels["type"]]
+		if !ok {
+			continue
+		}
+		key := kind + "/" + sample.labels["name"]
+		value := sample.value
+		c := counters[key]
+		switch sample.name {
+		case "apiserver_admission_webhook_admission_duration_seconds_count":
+			c.requests += value
+			if sample.labels["rejected"] == "true" {
+				c.rejected += value
+			}
+		case "apiserver_admission_webhook_admission_duration_seconds_sum":
+			c.latencySum += value
+		case "apiserver_admission_webhook_rejection_count":
+			if sample.labels["error_type"] != "no_error" {
+				c.errors += value
+			}
+		case "apiserver_admission_webhook_fail_open_count":
+			c.failedOpen += value
+			c.errors += value
+		default:
+			continue
+		}
+		counters[key] = c
+	}
+	return counters
+}
+
+// webhookConfigurations maps "<type>/<webhook name>" to the configuration registering it
+func webhookConfigurations(ctx context.Context, clientset kubernetes.Interface) (map[string]WebhookStatus, error) {
+	mutating, err := retry.Value(ctx, retry.DefaultBackoff, func() (*admissionv1.MutatingWebhookConfigurationList, error) {
+		return clientset.AdmissionregistrationV1().MutatingWebhookConfigurations().List(ctx, metav1.ListOptions{})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list mutating webhook configurations: %w", err)
+	}
+	validating, err := retry.Value(ctx, retry.DefaultBackoff, func() (*admissionv1.ValidatingWebhookConfigurationList, error) {
+		return clientset.AdmissionregistrationV1().ValidatingWebhookConfigurations().List(ctx, metav1.ListOptions{})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list validating webhook configurations: %w", err)
+	}
+
+	configs := make(map[string]WebhookStatus)
+	add := func(kind, configuration, name string, policy *admissionv1.FailurePolicyType, timeout *int32) {
+		w := WebhookStatus{Configuration: configuration, FailurePolicy: string(admissionv1.Fail)}
+		if policy != nil {
+			w.FailurePolicy = string(*policy)
+		}
+		if timeout != nil {
+			w.TimeoutSeconds = *timeout
+		}
+		configs[kind+"/"+name] = w
+	}
+	for _, c := range mutating.Items {
+		for _, w := range c.Webhooks {
+			add("mutating", "MutatingWebhookConfiguration/"+c.Name, w.Name, w.FailurePolicy, w.TimeoutSeconds)
+		}
+	}
+	for _, c := range validating.Items {
+		for _, w := range c.Webhooks {
+			add("validating", "ValidatingWebhookConfiguration/"+c.Name, w.Name, w.FailurePolicy, w.TimeoutSeconds)
+		}
+	}
+	return configs, nil
+}
+
+// slow reports whether a webhook's mean latency is at or above WebhookLatencyWarn or half
+// its timeout
+func (w WebhookStatus) slow() bool {
+	if w.MeanLatency >= WebhookLatencyWarn {
+		return true
+	}
+	return w.TimeoutSeconds > 0 && w.MeanLatency >= time.Duration(w.TimeoutSeconds)*time.Second/2
+}
@@ -0,0 +1,226 @@
+package history
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// postgresMigrations create and evolve the history schema. Each runs once, in order, in
+// its own transaction; append new ones rather than editing applied ones.
+var postgresMigrations = []string{
+	// 1: snapshots, queried by cluster and time
+	`CREATE TABLE history_snapshots (
+		id       text PRIMARY KEY,
+		cluster  text NOT NULL,
+		taken_at timestamptz NOT NULL,
+		data     jsonb NOT NULL
+	);
+	CREATE INDEX history_snapshots_cluster_taken_at ON history_snapshots (cluster, taken_at);`,
+
+	// 2: views flattening the snapshot documents for SQL queries
+	`CREATE VIEW history_namespace_costs AS
+		SELECT s.id AS snapshot_id, s.cluster, s.taken_at,
+			c->>'Name' AS namespace,
+			(c->>'CPUCost')::double precision AS cpu_cost,
+			(c->>'MemoryCost')::double precision AS memory_cost,
+			(c->>'StorageCost')::double precision AS storage_cost,
+			(c->>'TotalCost')::double precision AS total_cost,
+			(c->>'PodCount')::integer AS pod_count
+		FROM history_snapshots s, jsonb_array_elements(COALESCE(s.data->'namespaceCosts', '[]')) c;
+	CREATE VIEW history_label_costs AS
+		SELECT s.id AS snapshot_id, s.cluster, s.taken_at, l.key AS label, l.value::double precision AS hourly_cost
+		FROM history_snapshots s, jsonb_each_text(COALESCE(s.data->'labelCosts', '{}')) l;
+	CREATE VIEW history_series AS
+		SELECT s.id AS snapshot_id, s.cluster, s.taken_at, e.key AS series, e.value::double precision AS value
+		FROM history_snapshots s, jsonb_each_text(COALESCE(s.data->'series', '{}')) e;
+	CREATE VIEW history_node_states AS
+		SELECT s.id AS snapshot_id, s.cluster, s.taken_at, n.key AS node, n.value AS state
+		FROM history_snapshots s, jsonb_each_text(COALESCE(s.data->'nodeStates', '{}')) n;`,
+}
+
+// postgresMigrationLock is the advisory lock key that serializes migrations across replicas
+const postgresMigrationLock = 7215430519
+
+// postgresTimeout bounds each statement when the context has no deadline
+const postgresTimeout = 30 * time.Second
+
+// PostgresStore keeps snapshots in PostgreSQL, so several monitor replicas can share
+// history and it can be queried with SQL
+type PostgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore connects to the database at a postgres:// URL and migrates its schema.
+// Connection settings missing from the URL, such as the password, are read from the
+// standard PG* environment variables.
+func NewPostgresStore(ctx context.Context, dsn string) (*PostgresStore, error) {
+	connector, err := pq.NewConnector(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid postgres URL: %w", err)
+	}
+	p := &PostgresStore{db: sql.OpenDB(connector)}
+	if err := p.migrate(ctx); err != nil {
+		p.Close()
+		return nil, err
+	}
+	return p, nil
+}
+
+// migrate applies pending migrations while holding the migration lock, which belongs to
+// the session, so everything runs on one connection
+func (p *PostgresStore) migrate(ctx context.Context) error {
+	ctx, cancel := postgresContext(ctx)
+	defer cancel()
+
+	conn, err := p.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to connect to postgres: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", postgresMigrationLock); err != nil {
+		return fmt.Errorf("failed to lock history schema: %w", err)
+	}
+	defer conn.ExecContext(context.WithoutCancel(ctx), "SELECT pg_advisory_unlock($1)", postgresMigrationLock)
+
+	_, err = conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS history_schema_migrations (
+		version    integer PRIMARY KEY,
+		applied_at timestamptz NOT NULL DEFAULT now()
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create migrations table: %w", err)
+	}
+	var applied int
+	if err := conn.QueryRowContext(ctx, "SELECT COALESCE(max(version), 0) FROM history_schema_migrations").Scan(&applied); err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+
+	for i := applied; i < len(postgresMigrations); i++ {
+		if err := applyMigration(ctx, conn, i+1, postgresMigrations[i]); err != nil {
+			return fmt.Errorf("failed to apply history migration %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// applyMigration runs a migration and records its version in one transaction
+func applyMigration(ctx context.Context, conn *sql.Conn, version int, migration string) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Without arguments the statements are sent as one simple query, which may hold several
+	if _, err := tx.ExecContext(ctx, migration); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "INSERT INTO history_schema_migrations (version) VALUES ($1)", version); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Save inserts a snapshot, replacing one with the same ID
+func (p *PostgresStore) Save(ctx context.Context, snapshot *Snapshot) error {
+	if snapshot.ID == "" {
+		snapshot.ID = NewSnapshotID(snapshot.Cluster, snapshot.Timestamp)
+	}
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("failed to marshal snapshot: %w", err)
+	}
+
+	ctx, cancel := postgresContext(ctx)
+	defer cancel()
+	_, err = p.db.ExecContext(ctx, `INSERT INTO history_snapshots (id, cluster, taken_at, data)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (id) DO UPDATE SET cluster = EXCLUDED.cluster, taken_at = EXCLUDED.taken_at, data = EXCLUDED.data`,
+		snapshot.ID, snapshot.Cluster, snapshot.Timestamp.UTC(), string(data))
+	if err != nil {
+		return fmt.Errorf("failed to save snapshot: %w", err)
+	}
+	return nil
+}
+
+// List returns snapshots matching the query, oldest first
+func (p *PostgresStore) List(ctx context.Context, query Query) ([]*Snapshot, error) {
+	var since, until, limit interface{}
+	if !query.Since.IsZero() {
+		since = query.Since.UTC()
+	}
+	if !query.Until.IsZero() {
+		until = query.Until.UTC()
+	}
+	if query.Limit > 0 {
+		limit = query.Limit
+	}
+
+	ctx, cancel := postgresContext(ctx)
+	defer cancel()
+	rows, err := p.db.QueryContext(ctx, `SELECT data FROM history_snapshots
+		WHERE ($1::text = '' OR cluster = $1::text)
+			AND ($2::timestamptz IS NULL OR taken_at >= $2::timestamptz)
+			AND ($3::timestamptz IS NULL OR taken_at <= $3::timestamptz)
+		ORDER BY taken_at DESC LIMIT $4::bigint`,
+		query.Cluster, since, until, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+	defer rows.Close()
+
+	result := make([]*Snapshot, 0)
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("failed to list snapshots: %w", err)
+		}
+		var snapshot Snapshot
+		if err := json.Unmarshal(data, &snapshot); err != nil {
+			return nil, fmt.Errorf("failed to parse snapshot: %w", err)
+		}
+		result = append(result, &snapshot)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+
+	// Rows come newest first so the limit keeps the latest snapshots
+	for i, j := 0, len(result)-1; i < j; i, j = i+1, j-1 {
+		result[i], result[j] = result[j], result[i]
+	}
+	return result, nil
+}
+
+// Delete removes snapshots by ID
+func (p *PostgresStore) Delete(ctx context.Context, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	ctx, cancel := postgresContext(ctx)
+	defer cancel()
+	if _, err := p.db.ExecContext(ctx, "DELETE FROM history_snapshots WHERE id = ANY($1::text[])", pq.Array(ids)); err != nil {
+		return fmt.Errorf("failed to delete snapshots: %w", err)
+	}
+	return nil
+}
+
+// Close closes the database connections
+func (p *PostgresStore) Close() error {
+	return p.db.Close()
+}
+
+// postgresContext applies postgresTimeout to a context without a deadline
+func postgresContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, postgresTimeout)
+}
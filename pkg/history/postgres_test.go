@@ -0,0 +1,85 @@
+package history
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+)
+
+// TestPostgresStore runs against the database at HISTORY_TEST_POSTGRES, e.g.
+// postgres://postgres@localhost:5432/postgres?sslmode=disable, and is skipped without it.
+// It drops the history tables first.
+func TestPostgresStore(t *testing.T) {
+	dsn := os.Getenv("HISTORY_TEST_POSTGRES")
+	if dsn == "" {
+		t.Skip("HISTORY_TEST_POSTGRES is not set")
+	}
+	ctx := context.Background()
+
+	store, err := NewPostgresStore(ctx, dsn)
+	if err != nil {
+		t.Fatalf("NewPostgresStore() error = %v", err)
+	}
+	_, err = store.db.ExecContext(ctx, `DROP VIEW IF EXISTS history_namespace_costs, history_label_costs, history_series, history_node_states;
+		DROP TABLE IF EXISTS history_snapshots, history_schema_migrations`)
+	store.Close()
+	if err != nil {
+		t.Fatalf("failed to reset schema: %v", err)
+	}
+
+	// Migrating twice leaves the schema as it is
+	for i := 0; i < 2; i++ {
+		if store, err = NewPostgresStore(ctx, dsn); err != nil {
+			t.Fatalf("NewPostgresStore() error = %v", err)
+		}
+		if i == 0 {
+			store.Close()
+		}
+	}
+	defer store.Close()
+
+	start := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		snapshot := &Snapshot{Cluster: "prod", Timestamp: start.Add(time.Duration(i) * time.Hour), LabelCosts: map[string]float64{"team=a": float64(i)}}
+		if err := store.Save(ctx, snapshot); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+	}
+	if err := store.Save(ctx, &Snapshot{Cluster: "staging", Timestamp: start}); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	// Saving an existing ID replaces it
+	replaced := &Snapshot{ID: NewSnapshotID("prod", start), Cluster: "prod", Timestamp: start, BudgetAlerts: map[string]float64{"prod": 80}}
+	if err := store.Save(ctx, replaced); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	all, err := store.List(ctx, Query{Cluster: "prod"})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(all) != 3 || !all[0].Timestamp.Equal(start) || all[0].BudgetAlerts["prod"] != 80 {
+		t.Fatalf("List() = %+v, want 3 snapshots oldest first with the replaced one", all)
+	}
+
+	latest, err := store.List(ctx, Query{Cluster: "prod", Since: start.Add(time.Hour), Limit: 1})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(latest) != 1 || !latest[0].Timestamp.Equal(start.Add(2*time.Hour)) {
+		t.Errorf("List() with a limit = %+v, want the latest snapshot", latest)
+	}
+
+	if err := store.Delete(ctx, []string{all[0].ID, all[1].ID, `with "quotes"`}); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	remaining, err := store.List(ctx, Query{})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(remaining) != 2 {
+		t.Errorf("List() after Delete() = %d snapshots, want 2", len(remaining))
+	}
+}
@@ -0,0 +1,212 @@
+package history
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/cost"
+)
+
+// Rollup resolutions
+const (
+	ResolutionHourly = "hourly"
+	ResolutionDaily  = "daily"
+)
+
+// CompactionInterval is how often history is compacted in the background
+const CompactionInterval = time.Hour
+
+// RetentionPolicy sets how long each resolution of history is kept. Raw snapshots older
+// than Raw are merged into hourly rollups, hourly rollups older than Hourly into daily
+// rollups, and daily rollups older than Daily are removed.
+type RetentionPolicy struct {
+	Raw    time.Duration
+	Hourly time.Duration
+	Daily  time.Duration
+}
+
+// DefaultRetention keeps raw snapshots for a week, hourly rollups for four weeks and daily
+// rollups for a year
+var DefaultRetention = RetentionPolicy{
+	Raw:    7 * 24 * time.Hour,
+	Hourly: 28 * 24 * time.Hour,
+	Daily:  365 * 24 * time.Hour,
+}
+
+// Validate checks that each resolution is kept at least as long as the finer one
+func (p RetentionPolicy) Validate() error {
+	if p.Raw < time.Hour {
+		return fmt.Errorf("raw retention must be at least an hour")
+	}
+	if p.Hourly < p.Raw {
+		return fmt.Errorf("hourly retention %s is shorter than raw retention %s", p.Hourly, p.Raw)
+	}
+	if p.Daily < p.Hourly {
+		return fmt.Errorf("daily retention %s is shorter than hourly retention %s", p.Daily, p.Hourly)
+	}
+	return nil
+}
+
+// CompactionResult counts the work done by one compaction
+type CompactionResult struct {
+	RolledUp int // rollups written
+	Deleted  int // snapshots and rollups removed
+}
+
+// Compact rolls up and expires history according to the policy. It only touches complete
+// hours and UTC days, and is safe to run from several replicas sharing a store, since
+// rollup IDs are deterministic and saving one replaces it.
+func Compact(ctx context.Context, store RetentionStore, policy RetentionPolicy, now time.Time) (CompactionResult, error) {
+	var result CompactionResult
+
+	// Raw snapshots into hourly rollups
+	cutoff := now.Add(-policy.Raw).Truncate(time.Hour)
+	if err := rollUp(ctx, store, cutoff, time.Hour, ResolutionHourly, []string{"", ResolutionHourly}, &result); err != nil {
+		return result, err
+	}
+
+	// Hourly rollups into daily rollups
+	cutoff = now.Add(-policy.Hourly).Truncate(24 * time.Hour)
+	if err := rollUp(ctx, store, cutoff, 24*time.Hour, ResolutionDaily, []string{"", ResolutionHourly, ResolutionDaily}, &result); err != nil {
+		return result, err
+	}
+
+	// Expire daily rollups
+	expired, err := store.List(ctx, Query{Until: now.Add(-policy.Daily)})
+	if err != nil {
+		return result, fmt.Errorf("failed to list expired history: %w", err)
+	}
+	ids := make([]string, 0, len(expired))
+	for _, s := range expired {
+		ids = append(ids, s.ID)
+	}
+	if err := store.Delete(ctx, ids); err != nil {
+		return result, err
+	}
+	result.Deleted += len(ids)
+	return result, nil
+}
+
+// rollUp merges the snapshots of each complete bucket before cutoff whose resolution is
+// one of from into a rollup, and removes the merged snapshots. Buckets already holding
+// only their rollup are left alone.
+func rollUp(ctx context.Context, store RetentionStore, cutoff time.Time, size time.Duration, resolution string, from []string, result *CompactionResult) error {
+	snapshots, err := store.List(ctx, Query{Until: cutoff.Add(-time.Nanosecond)})
+	if err != nil {
+		return fmt.Errorf("failed to list history for %s rollups: %w", resolution, err)
+	}
+
+	type bucket struct {
+		cluster string
+		start   time.Time
+	}
+	buckets := make(map[bucket][]*Snapshot)
+	var order []bucket
+	for _, s := range snapshots {
+		if !slices.Contains(from, s.Resolution) {
+			continue
+		}
+		b := bucket{cluster: s.Cluster, start: s.Timestamp.UTC().Truncate(size)}
+		if _, ok := buckets[b]; !ok {
+			order = append(order, b)
+		}
+		buckets[b] = append(buckets[b], s)
+	}
+
+	for _, b := range order {
+		members := buckets[b]
+		if len(members) == 1 && members[0].Resolution == resolution {
+			continue
+		}
+
+		merged := merge(members)
+		merged.ID = NewSnapshotID(b.cluster, b.start) + "-" + resolution
+		merged.Cluster = b.cluster
+		merged.Timestamp = b.start
+		merged.Resolution = resolution
+		if err := store.Save(ctx, merged); err != nil {
+			return fmt.Errorf("failed to save %s rollup: %w", resolution, err)
+		}
+		result.RolledUp++
+
+		ids := make([]string, 0, len(members))
+		for _, s := range members {
+			if s.ID != merged.ID {
+				ids = append(ids, s.ID)
+			}
+		}
+		if err := store.Delete(ctx, ids); err != nil {
+			return err
+		}
+		result.Deleted += len(ids)
+	}
+	return nil
+}
+
+// merge averages snapshots, weighting rollups by the samples they hold. Cost rates are
+// averaged over all samples, so a namespace missing from some counts as zero there;
+// series are averaged over the samples that have them; node states are the latest seen.
+func merge(snapshots []*Snapshot) *Snapshot {
+	sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].Timestamp.Before(snapshots[j].Timestamp) })
+
+	merged := &Snapshot{LabelCosts: map[string]float64{}, Series: map[string]float64{}, NodeStates: map[string]string{}}
+	namespaces := make(map[string]*cost.NamespaceCostData)
+	pods := make(map[string]float64)
+	seriesWeight := make(map[string]float64)
+	var total float64
+	for _, s := range snapshots {
+		w := float64(max(s.Samples, 1))
+		total += w
+		merged.Samples += max(s.Samples, 1)
+
+		for _, ns := range s.NamespaceCosts {
+			sum, ok := namespaces[ns.Name]
+			if !ok {
+				sum = &cost.NamespaceCostData{Name: ns.Name}
+				namespaces[ns.Name] = sum
+			}
+			sum.CPUCost += ns.CPUCost * w
+			sum.MemoryCost += ns.MemoryCost * w
+			sum.StorageCost += ns.StorageCost * w
+			sum.TotalCost += ns.TotalCost * w
+			pods[ns.Name] += float64(ns.PodCount) * w
+		}
+		for label, rate := range s.LabelCosts {
+			merged.LabelCosts[label] += rate * w
+		}
+		for key, value := range s.Series {
+			merged.Series[key] += value * w
+			seriesWeight[key] += w
+		}
+		for node, state := range s.NodeStates {
+			merged.NodeStates[node] = state
+		}
+		for name, threshold := range s.BudgetAlerts {
+			if merged.BudgetAlerts == nil {
+				merged.BudgetAlerts = map[string]float64{}
+			}
+			merged.BudgetAlerts[name] = max(merged.BudgetAlerts[name], threshold)
+		}
+	}
+
+	for name, sum := range namespaces {
+		sum.CPUCost /= total
+		sum.MemoryCost /= total
+		sum.StorageCost /= total
+		sum.TotalCost /= total
+		sum.PodCount = int(math.Round(pods[name] / total))
+		merged.NamespaceCosts = append(merged.NamespaceCosts, *sum)
+	}
+	sort.Slice(merged.NamespaceCosts, func(i, j int) bool { return merged.NamespaceCosts[i].Name < merged.NamespaceCosts[j].Name })
+	for label := range merged.LabelCosts {
+		merged.LabelCosts[label] /= total
+	}
+	for key := range merged.Series {
+		merged.Series[key] /= seriesWeight[key]
+	}
+	return merged
+}
@@ -0,0 +1,214 @@
+package history
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/cost"
+)
+
+// Snapshot is a point-in-time record of cluster data kept for trend analysis
+type Snapshot struct {
+	ID             string                   `json:"id"`
+	Cluster        string                   `json:"cluster"`
+	Timestamp      time.Time                `json:"timestamp"`
+	NamespaceCosts []cost.NamespaceCostData `json:"namespaceCosts,omitempty"` // hourly rates
+	LabelCosts     map[string]float64       `json:"labelCosts,omitempty"`     // "key=value" -> hourly rate
+	Series         map[string]float64       `json:"series,omitempty"`         // usage and health series for anomaly baselines
+	NodeStates     map[string]string        `json:"nodeStates,omitempty"`     // node -> "Ready", "NotReady" or "Unknown"
+	Resolution     string                   `json:"resolution,omitempty"`     // "hourly" or "daily" for rollups, empty for raw snapshots
+	Samples        int                      `json:"samples,omitempty"`        // raw snapshots merged into a rollup
+	BudgetAlerts   map[string]float64       `json:"budgetAlerts,omitempty"`   // budget -> highest threshold alerted this month
+}
+
+// Query selects snapshots from a store
+type Query struct {
+	Cluster string
+	Since   time.Time
+	Until   time.Time
+	Limit   int // most recent N snapshots, 0 for all
+}
+
+// Store persists snapshots across monitoring cycles
+type Store interface {
+	Save(ctx context.Context, snapshot *Snapshot) error
+	List(ctx context.Context, query Query) ([]*Snapshot, error)
+}
+
+// RetentionStore is a store whose old snapshots can be rolled up and removed
+type RetentionStore interface {
+	Store
+	Delete(ctx context.Context, ids []string) error
+}
+
+// NewSnapshotID returns a sortable identifier for a snapshot taken at t
+func NewSnapshotID(cluster string, t time.Time) string {
+	return fmt.Sprintf("%s-%s", cluster, t.UTC().Format("20060102T150405.000Z"))
+}
+
+// matches reports whether a snapshot satisfies the query's filters
+func (q Query) matches(s *Snapshot) bool {
+	if q.Cluster != "" && s.Cluster != q.Cluster {
+		return false
+	}
+	if !q.Since.IsZero() && s.Timestamp.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && s.Timestamp.After(q.Until) {
+		return false
+	}
+	return true
+}
+
+// apply sorts snapshots by time and applies the query limit
+func (q Query) apply(snapshots []*Snapshot) []*Snapshot {
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Timestamp.Before(snapshots[j].Timestamp)
+	})
+	if q.Limit > 0 && len(snapshots) > q.Limit {
+		snapshots = snapshots[len(snapshots)-q.Limit:]
+	}
+	return snapshots
+}
+
+// MemoryStore keeps snapshots in memory, useful for one-shot runs
+type MemoryStore struct {
+	mu        sync.RWMutex
+	snapshots []*Snapshot
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{snapshots: make([]*Snapshot, 0)}
+}
+
+// Save stores a snapshot, replacing one with the same ID
+func (m *MemoryStore) Save(ctx context.Context, snapshot *Snapshot) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, s := range m.snapshots {
+		if snapshot.ID != "" && s.ID == snapshot.ID {
+			m.snapshots[i] = snapshot
+			return nil
+		}
+	}
+	m.snapshots = append(m.snapshots, snapshot)
+	return nil
+}
+
+// Delete removes snapshots by ID
+func (m *MemoryStore) Delete(ctx context.Context, ids []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	kept := m.snapshots[:0]
+	for _, s := range m.snapshots {
+		if !slices.Contains(ids, s.ID) {
+			kept = append(kept, s)
+		}
+	}
+	m.snapshots = kept
+	return nil
+}
+
+// List returns snapshots matching the query, oldest first
+func (m *MemoryStore) List(ctx context.Context, query Query) ([]*Snapshot, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := make([]*Snapshot, 0)
+	for _, s := range m.snapshots {
+		if query.matches(s) {
+			result = append(result, s)
+		}
+	}
+	return query.apply(result), nil
+}
+
+// FileStore keeps one JSON file per snapshot in a directory
+type FileStore struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewFileStore creates a file-backed store, creating the directory if needed
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create history directory: %w", err)
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+// Save writes a snapshot to disk
+func (f *FileStore) Save(ctx context.Context, snapshot *Snapshot) error {
+	if snapshot.ID == "" {
+		snapshot.ID = NewSnapshotID(snapshot.Cluster, snapshot.Timestamp)
+	}
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("failed to marshal snapshot: %w", err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// Write to a temporary file first so readers never see partial snapshots
+	path := filepath.Join(f.dir, snapshot.ID+".json")
+	if err := os.WriteFile(path+".tmp", data, 0644); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// List reads snapshots matching the query from disk, oldest first
+func (f *FileStore) List(ctx context.Context, query Query) ([]*Snapshot, error) {
+	entries, err := os.ReadDir(f.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history directory: %w", err)
+	}
+
+	result := make([]*Snapshot, 0)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(f.dir, entry.Name()))
+		if os.IsNotExist(err) {
+			continue // removed by compaction since the directory was read
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read snapshot %s: %w", entry.Name(), err)
+		}
+
+		var snapshot Snapshot
+		if err := json.Unmarshal(data, &snapshot); err != nil {
+			return nil, fmt.Errorf("failed to parse snapshot %s: %w", entry.Name(), err)
+		}
+
+		if query.matches(&snapshot) {
+			result = append(result, &snapshot)
+		}
+	}
+	return query.apply(result), nil
+}
+
+// Delete removes snapshot files by ID
+func (f *FileStore) Delete(ctx context.Context, ids []string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, id := range ids {
+		if err := os.Remove(filepath.Join(f.dir, id+".json")); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to delete snapshot %s: %w", id, err)
+		}
+	}
+	return nil
+}
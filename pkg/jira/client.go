@@ -0,0 +1,161 @@
+package jira
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Client is a minimal Jira REST API v2 client authenticating with an email and API token
+type Client struct {
+	BaseURL string
+	Email   string
+	Token   string
+	HTTP    *http.Client
+}
+
+// NewClient creates a client for the Jira site at baseURL, e.g. https://example.atlassian.net
+func NewClient(baseURL, email, token string) *Client {
+	return &Client{
+		BaseURL: strings.TrimSuffix(baseURL, "/"),
+		Email:   email,
+		Token:   token,
+		HTTP:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// CreateIssue opens an issue and returns its key
+func (c *Client) CreateIssue(ctx context.Context, project, issueType, summary, description string, labels []string) (string, error) {
+	payload := map[string]interface{}{
+		"fields": map[string]interface{}{
+			"project":     map[string]string{"key": project},
+			"issuetype":   map[string]string{"name": issueType},
+			"summary":     summary,
+			"description": description,
+			"labels":      labels,
+		},
+	}
+
+	var created struct {
+		Key string `json:"key"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/rest/api/2/issue", payload, &created); err != nil {
+		return "", fmt.Errorf("failed to create Jira issue: %w", err)
+	}
+	return created.Key, nil
+}
+
+// AddComment adds a comment to an issue
+func (c *Client) AddComment(ctx context.Context, key, body string) error {
+	path := fmt.Sprintf("/rest/api/2/issue/%s/comment", url.PathEscape(key))
+	if err := c.do(ctx, http.MethodPost, path, map[string]string{"body": body}, nil); err != nil {
+		return fmt.Errorf("failed to comment on Jira issue %s: %w", key, err)
+	}
+	return nil
+}
+
+// Transition moves an issue through the workflow transition with the given name
+func (c *Client) Transition(ctx context.Context, key, name string) error {
+	path := fmt.Sprintf("/rest/api/2/issue/%s/transitions", url.PathEscape(key))
+
+	var available struct {
+		Transitions []struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"transitions"`
+	}
+	if err := c.do(ctx, http.MethodGet, path, nil, &available); err != nil {
+		return fmt.Errorf("failed to list transitions of Jira issue %s: %w", key, err)
+	}
+
+	for _, t := range available.Transitions {
+		if strings.EqualFold(t.Name, name) {
+			payload := map[string]interface{}{"transition": map[string]string{"id": t.ID}}
+			if err := c.do(ctx, http.MethodPost, path, payload, nil); err != nil {
+				return fmt.Errorf("failed to transition Jira issue %s: %w", key, err)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("jira issue %s has no transition named %q", key, name)
+}
+
+// Attach uploads a file to an issue
+func (c *Client) Attach(ctx context.Context, key, filename string, data []byte) error {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", filename)
+	if err != nil {
+		return fmt.Errorf("failed to create attachment: %w", err)
+	}
+	if _, err := part.Write(data); err != nil {
+		return fmt.Errorf("failed to write attachment: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("failed to write attachment: %w", err)
+	}
+
+	path := fmt.Sprintf("/rest/api/2/issue/%s/attachments", url.PathEscape(key))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+path, &body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("X-Atlassian-Token", "no-check")
+
+	if err := c.send(req, nil); err != nil {
+		return fmt.Errorf("failed to attach %s to Jira issue %s: %w", filename, key, err)
+	}
+	return nil
+}
+
+// do sends a JSON request and decodes the JSON response into out if it is not nil
+func (c *Client) do(ctx context.Context, method, path string, payload, out interface{}) error {
+	var body io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("failed to marshal payload: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return c.send(req, out)
+}
+
+// send authenticates and sends a request, treating non-2xx responses as errors
+func (c *Client) send(req *http.Request, out interface{}) error {
+	req.SetBasicAuth(c.Email, c.Token)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("unexpected response status %s: %s", resp.Status, strings.TrimSpace(string(detail)))
+	}
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,312 @@
+package jira
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/health"
+	"github.com/ochestra-tech/ochestra-ai/pkg/snapshot"
+)
+
+// Config controls which health issues become Jira tickets
+type Config struct {
+	URL             string   `json:"url"`
+	Project         string   `json:"project"`
+	IssueType       string   `json:"issueType,omitempty"`       // defaults to "Bug"
+	PersistFor      string   `json:"persistFor,omitempty"`      // how long an issue must persist, defaults to "30m"
+	MinSeverity     string   `json:"minSeverity,omitempty"`     // lowest severity ticketed, defaults to "warning"
+	CloseTransition string   `json:"closeTransition,omitempty"` // workflow transition on resolve, defaults to "Done"
+	Labels          []string `json:"labels,omitempty"`
+
+	persistFor time.Duration
+}
+
+// LoadConfig reads Jira integration settings from a JSON file
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Jira config: %w", err)
+	}
+
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse Jira config: %w", err)
+	}
+
+	if config.URL == "" || config.Project == "" {
+		return nil, fmt.Errorf("jira config must have a url and a project")
+	}
+	if config.IssueType == "" {
+		config.IssueType = "Bug"
+	}
+	if config.PersistFor == "" {
+		config.PersistFor = "30m"
+	}
+	if config.MinSeverity == "" {
+		config.MinSeverity = "warning"
+	}
+	if config.CloseTransition == "" {
+		config.CloseTransition = "Done"
+	}
+	if config.persistFor, err = time.ParseDuration(config.PersistFor); err != nil {
+		return nil, fmt.Errorf("jira config has an invalid persistFor: %w", err)
+	}
+
+	return &config, nil
+}
+
+// ticket is the tracked state of one health issue
+type ticket struct {
+	Issue     health.HealthIssue `json:"issue"`
+	FirstSeen time.Time          `json:"firstSeen"`
+	Key       string             `json:"key,omitempty"` // Jira issue key once opened
+}
+
+// Tracker opens Jira issues for health issues that persist, comments when they change and
+// closes them when they resolve
+type Tracker struct {
+	config  *Config
+	client  *Client
+	path    string
+	cluster string
+
+	mu      sync.Mutex
+	tickets map[string]*ticket // issue fingerprint -> state
+}
+
+// LoadTracker creates a tracker, restoring state persisted at path if it is not empty
+func LoadTracker(config *Config, client *Client, path, cluster string) (*Tracker, error) {
+	t := &Tracker{
+		config:  config,
+		client:  client,
+		path:    path,
+		cluster: cluster,
+		tickets: make(map[string]*ticket),
+	}
+	if path == "" {
+		return t, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return t, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Jira state: %w", err)
+	}
+	if err := json.Unmarshal(data, &t.tickets); err != nil {
+		return nil, fmt.Errorf("failed to parse Jira state: %w", err)
+	}
+	return t, nil
+}
+
+// Sync reconciles tickets with the issues found in the latest check. Failures for one
+// issue are logged and do not stop the others.
+func (t *Tracker) Sync(ctx context.Context, issues []health.HealthIssue, snap *snapshot.ClusterSnapshot, now time.Time) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	seen := make(map[string]bool)
+	for _, issue := range issues {
+		if health.SeverityRank(issue.Severity) > health.SeverityRank(t.config.MinSeverity) {
+			continue
+		}
+		fingerprint := issue.Fingerprint()
+		seen[fingerprint] = true
+
+		// Issues inside a maintenance window keep their tickets open but do not open new ones
+		if issue.Suppressed {
+			continue
+		}
+		tk, ok := t.tickets[fingerprint]
+		if !ok {
+			tk = &ticket{Issue: issue, FirstSeen: now}
+			t.tickets[fingerprint] = tk
+		}
+
+		switch {
+		case tk.Key == "" && now.Sub(tk.FirstSeen) >= t.config.persistFor:
+			if err := t.open(ctx, tk, issue, snap, now); err != nil {
+				log.Printf("Failed to open Jira issue for %s: %v", fingerprint, err)
+			}
+		case tk.Key != "" && (issue.Severity != tk.Issue.Severity || issue.Message != tk.Issue.Message):
+			comment := fmt.Sprintf("Issue changed: [%s] %s (was [%s] %s)", issue.Severity, issue.Message, tk.Issue.Severity, tk.Issue.Message)
+			if err := t.client.AddComment(ctx, tk.Key, comment); err != nil {
+				log.Printf("Failed to update Jira issue %s: %v", tk.Key, err)
+				continue
+			}
+			tk.Issue = issue
+		default:
+			tk.Issue = issue
+		}
+	}
+
+	for fingerprint, tk := range t.tickets {
+		if seen[fingerprint] {
+			continue
+		}
+		if tk.Key != "" {
+			if err := t.close(ctx, tk, now); err != nil {
+				log.Printf("Failed to close Jira issue %s: %v", tk.Key, err)
+				continue
+			}
+		}
+		delete(t.tickets, fingerprint)
+	}
+
+	return t.save()
+}
+
+// open creates the Jira issue for a persistent health issue and attaches a snapshot excerpt
+func (t *Tracker) open(ctx context.Context, tk *ticket, issue health.HealthIssue, snap *snapshot.ClusterSnapshot, now time.Time) error {
+	summary := fmt.Sprintf("[%s] %s %s", t.cluster, resourceName(issue), issue.Message)
+	if len(summary) > 250 {
+		summary = summary[:247] + "..."
+	}
+
+	var description strings.Builder
+	fmt.Fprintf(&description, "*Cluster:* %s\n", t.cluster)
+	fmt.Fprintf(&description, "*Resource:* %s\n", resourceName(issue))
+	fmt.Fprintf(&description, "*Severity:* %s\n", issue.Severity)
+	fmt.Fprintf(&description, "*First seen:* %s (persisted %s)\n\n", tk.FirstSeen.Format(time.RFC3339), now.Sub(tk.FirstSeen).Round(time.Minute))
+	description.WriteString(issue.Message + "\n")
+	if issue.Suggestion != "" {
+		fmt.Fprintf(&description, "\n*Suggestion:* %s\n", issue.Suggestion)
+	}
+	if issue.RunbookURL != "" {
+		fmt.Fprintf(&description, "*Runbook:* %s\n", issue.RunbookURL)
+	}
+	for i, step := range issue.Remediation {
+		fmt.Fprintf(&description, "%d. %s\n", i+1, step)
+	}
+	if len(issue.Children) > 0 {
+		fmt.Fprintf(&description, "\n*Correlated issues (%d):*\n", len(issue.Children))
+		for _, child := range issue.Children {
+			fmt.Fprintf(&description, "* [%s] %s: %s\n", child.Severity, resourceName(child), child.Message)
+		}
+	}
+
+	labels := append([]string{"ochestra-ai", "severity-" + issue.Severity}, t.config.Labels...)
+	key, err := t.client.CreateIssue(ctx, t.config.Project, t.config.IssueType, summary, description.String(), labels)
+	if err != nil {
+		return err
+	}
+	tk.Key = key
+	tk.Issue = issue
+	log.Printf("Opened Jira issue %s for %s", key, issue.Fingerprint())
+
+	if excerpt, ok := snapshotExcerpt(snap, issue); ok {
+		if err := t.client.Attach(ctx, key, "snapshot.yaml", excerpt); err != nil {
+			log.Printf("Failed to attach snapshot excerpt to %s: %v", key, err)
+		}
+	}
+	return nil
+}
+
+// close comments on and transitions the Jira issue of a resolved health issue
+func (t *Tracker) close(ctx context.Context, tk *ticket, now time.Time) error {
+	comment := fmt.Sprintf("Issue resolved at %s: %s no longer reports %q.", now.Format(time.RFC3339), resourceName(tk.Issue), tk.Issue.Message)
+	if err := t.client.AddComment(ctx, tk.Key, comment); err != nil {
+		return err
+	}
+	if err := t.client.Transition(ctx, tk.Key, t.config.CloseTransition); err != nil {
+		return err
+	}
+	log.Printf("Closed Jira issue %s", tk.Key)
+	return nil
+}
+
+// save persists ticket state to disk if the tracker has a state file
+func (t *Tracker) save() error {
+	if t.path == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(t.tickets)
+	if err != nil {
+		return fmt.Errorf("failed to marshal Jira state: %w", err)
+	}
+	if err := os.WriteFile(t.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write Jira state: %w", err)
+	}
+	return nil
+}
+
+// snapshotExcerpt renders the snapshot objects an issue refers to as YAML
+func snapshotExcerpt(snap *snapshot.ClusterSnapshot, issue health.HealthIssue) ([]byte, bool) {
+	if snap == nil || issue.Name == "" {
+		return nil, false
+	}
+
+	var excerpt interface{}
+	switch issue.Resource {
+	case "Node":
+		if node := snap.Node(issue.Name); node != nil {
+			excerpt = map[string]interface{}{
+				"name":   node.Name,
+				"labels": node.Labels,
+				"spec":   node.Spec,
+				"status": map[string]interface{}{
+					"conditions":  node.Status.Conditions,
+					"capacity":    node.Status.Capacity,
+					"allocatable": node.Status.Allocatable,
+				},
+			}
+		}
+	case "Pod":
+		for i := range snap.Pods {
+			pod := &snap.Pods[i]
+			if pod.Namespace == issue.Namespace && pod.Name == issue.Name {
+				excerpt = map[string]interface{}{
+					"namespace": pod.Namespace,
+					"name":      pod.Name,
+					"nodeName":  pod.Spec.NodeName,
+					"status":    pod.Status,
+				}
+				break
+			}
+		}
+	case "Deployment":
+		for i := range snap.Deployments {
+			d := &snap.Deployments[i]
+			if d.Namespace == issue.Namespace && d.Name == issue.Name {
+				excerpt = map[string]interface{}{
+					"namespace": d.Namespace,
+					"name":      d.Name,
+					"replicas":  d.Spec.Replicas,
+					"status":    d.Status,
+				}
+				break
+			}
+		}
+	}
+	if excerpt == nil {
+		return nil, false
+	}
+
+	data, err := yaml.Marshal(excerpt)
+	if err != nil {
+		return nil, false
+	}
+	return data, true
+}
+
+// resourceName formats an issue's resource as Kind/namespace/name
+func resourceName(issue health.HealthIssue) string {
+	parts := []string{issue.Resource}
+	if issue.Namespace != "" {
+		parts = append(parts, issue.Namespace)
+	}
+	if issue.Name != "" {
+		parts = append(parts, issue.Name)
+	}
+	return strings.Join(parts, "/")
+}
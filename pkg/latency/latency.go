@@ -0,0 +1,247 @@
+package latency
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/notify"
+)
+
+// Verbs probed against the API server
+const (
+	VerbGet   = "get"
+	VerbList  = "list"
+	VerbWatch = "watch" // time until a watch is established
+)
+
+var verbs = []string{VerbGet, VerbList, VerbWatch}
+
+// Config controls probing and alerting. Alerts consider percentiles over Window, so a
+// single slow call never alerts on its own.
+type Config struct {
+	ProbesPerCycle    int           // probes of each verb per check cycle
+	Window            time.Duration // recent window percentiles are computed over
+	Baseline          time.Duration // longer window the recent p95 is compared with
+	MinSamples        int           // samples the recent window needs before alerting
+	WarningP95        time.Duration // recent p95 at or above which a warning is raised
+	CriticalP99       time.Duration // recent p99 at or above which a critical alert is raised
+	DegradationFactor float64       // recent p95 this many times the baseline p95 raises a warning
+}
+
+// DefaultConfig probes three times per cycle and compares the last 15 minutes with the
+// last 6 hours
+var DefaultConfig = Config{
+	ProbesPerCycle:    3,
+	Window:            15 * time.Minute,
+	Baseline:          6 * time.Hour,
+	MinSamples:        10,
+	WarningP95:        500 * time.Millisecond,
+	CriticalP99:       2 * time.Second,
+	DegradationFactor: 3,
+}
+
+// Sample is one timed API server call
+type Sample struct {
+	Time     time.Time
+	Verb     string
+	Duration time.Duration
+	Failed   bool
+}
+
+// Status summarizes a verb's latency in milliseconds over the recent window
+type Status struct {
+	Verb        string  `json:"verb"`
+	Samples     int     `json:"samples"`
+	Failures    int     `json:"failures"`
+	P50         float64 `json:"p50"`
+	P95         float64 `json:"p95"`
+	P99         float64 `json:"p99"`
+	BaselineP95 float64 `json:"baselineP95"`
+	Severity    string  `json:"severity,omitempty"` // "critical" or "warning" while degraded
+	Reason      string  `json:"reason,omitempty"`
+}
+
+// Probe times one call of each verb: getting the kube-system namespace, listing
+// kube-system pods, and starting a watch on the kube-system namespace
+func Probe(ctx context.Context, clientset kubernetes.Interface, now time.Time) []Sample {
+	calls := map[string]func() error{
+		VerbGet: func() error {
+			_, err := clientset.CoreV1().Namespaces().Get(ctx, "kube-system", metav1.GetOptions{})
+			return err
+		},
+		VerbList: func() error {
+			_, err := clientset.CoreV1().Pods("kube-system").List(ctx, metav1.ListOptions{Limit: 100})
+			return err
+		},
+		VerbWatch: func() error {
+			w, err := clientset.CoreV1().Namespaces().Watch(ctx, metav1.ListOptions{FieldSelector: "metadata.name=kube-system"})
+			if err != nil {
+				return err
+			}
+			w.Stop()
+			return nil
+		},
+	}
+
+	samples := make([]Sample, 0, len(verbs))
+	for _, verb := range verbs {
+		start := time.Now()
+		err := calls[verb]()
+		samples = append(samples, Sample{Time: now, Verb: verb, Duration: time.Since(start), Failed: err != nil})
+	}
+	return samples
+}
+
+// Percentile returns the nearest-rank percentile p (0-100) of durations sorted ascending
+func Percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return sorted[rank]
+}
+
+// Evaluate computes each verb's percentiles over the recent window from samples ordered
+// oldest first, and whether the verb is degraded
+func Evaluate(config Config, samples []Sample, now time.Time) []Status {
+	statuses := make([]Status, 0, len(verbs))
+	for _, verb := range verbs {
+		var recent, baseline []time.Duration
+		status := Status{Verb: verb}
+		for _, s := range samples {
+			if s.Verb != verb || s.Time.Before(now.Add(-config.Baseline)) {
+				continue
+			}
+			recentSample := !s.Time.Before(now.Add(-config.Window))
+			if s.Failed {
+				if recentSample {
+					status.Failures++
+				}
+				continue
+			}
+			baseline = append(baseline, s.Duration)
+			if recentSample {
+				recent = append(recent, s.Duration)
+			}
+		}
+		sort.Slice(recent, func(i, j int) bool { return recent[i] < recent[j] })
+		sort.Slice(baseline, func(i, j int) bool { return baseline[i] < baseline[j] })
+
+		status.Samples = len(recent)
+		status.P50 = milliseconds(Percentile(recent, 50))
+		status.P95 = milliseconds(Percentile(recent, 95))
+		status.P99 = milliseconds(Percentile(recent, 99))
+		status.BaselineP95 = milliseconds(Percentile(baseline, 95))
+
+		if len(recent) >= config.MinSamples {
+			p95, p99 := Percentile(recent, 95), Percentile(recent, 99)
+			switch {
+			case p99 >= config.CriticalP99:
+				status.Severity = "critical"
+				status.Reason = fmt.Sprintf("p99 %.0fms is at or above %s", status.P99, config.CriticalP99)
+			case p95 >= config.WarningP95:
+				status.Severity = "warning"
+				status.Reason = fmt.Sprintf("p95 %.0fms is at or above %s", status.P95, config.WarningP95)
+			case len(baseline) > len(recent) && status.BaselineP95 > 0 && status.P95 >= config.DegradationFactor*status.BaselineP95:
+				status.Severity = "warning"
+				status.Reason = fmt.Sprintf("p95 %.0fms is %.1fx the %s baseline of %.0fms",
+					status.P95, status.P95/status.BaselineP95, config.Baseline, status.BaselineP95)
+			}
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// milliseconds converts a duration to fractional milliseconds
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// Tracker probes the API server each cycle, keeps samples for the baseline window and
+// alerts when a verb's latency percentiles degrade or recover
+type Tracker struct {
+	config    Config
+	clientset kubernetes.Interface
+	notifier  notify.Notifier
+	cluster   string
+
+	mu      sync.Mutex
+	samples []Sample
+	alerted map[string]string // verb -> severity of the open alert
+}
+
+// NewTracker creates a tracker probing the API server through clientset
+func NewTracker(config Config, clientset kubernetes.Interface, notifier notify.Notifier, cluster string) *Tracker {
+	return &Tracker{
+		config:    config,
+		clientset: clientset,
+		notifier:  notifier,
+		cluster:   cluster,
+		alerted:   make(map[string]string),
+	}
+}
+
+// Record runs the cycle's probes, evaluates every verb and sends degradation alerts
+func (t *Tracker) Record(ctx context.Context, now time.Time) ([]Status, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for i := 0; i < t.config.ProbesPerCycle; i++ {
+		t.samples = append(t.samples, Probe(ctx, t.clientset, now)...)
+	}
+
+	// Drop samples older than the baseline window
+	cutoff := now.Add(-t.config.Baseline)
+	for len(t.samples) > 0 && t.samples[0].Time.Before(cutoff) {
+		t.samples = t.samples[1:]
+	}
+
+	statuses := Evaluate(t.config, t.samples, now)
+	for _, status := range statuses {
+		if err := t.alert(ctx, status, now); err != nil {
+			return statuses, err
+		}
+	}
+	return statuses, nil
+}
+
+// alert sends an alert when a verb becomes degraded, changes severity, or recovers
+func (t *Tracker) alert(ctx context.Context, status Status, now time.Time) error {
+	if status.Severity == t.alerted[status.Verb] {
+		return nil
+	}
+
+	alert := notify.Alert{
+		Title:    fmt.Sprintf("API server %s latency degraded", status.Verb),
+		Severity: status.Severity,
+		Source:   "apiserver",
+		Message: fmt.Sprintf("%s over the last %s: p50 %.0fms, p95 %.0fms, p99 %.0fms from %d calls (%d failed)",
+			status.Reason, t.config.Window, status.P50, status.P95, status.P99, status.Samples, status.Failures),
+		Labels:      map[string]string{"verb": status.Verb, "cluster": t.cluster},
+		Timestamp:   now,
+		Fingerprint: "apiserver-latency/" + status.Verb,
+	}
+	if status.Severity == "" {
+		alert.Title = fmt.Sprintf("API server %s latency recovered", status.Verb)
+		alert.Message = fmt.Sprintf("p95 %.0fms, p99 %.0fms over the last %s", status.P95, status.P99, t.config.Window)
+		alert.Severity = "info"
+		alert.Resolved = true
+	}
+
+	t.alerted[status.Verb] = status.Severity
+	if err := t.notifier.Notify(ctx, alert); err != nil {
+		return fmt.Errorf("failed to send API latency alert: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,264 @@
+package loadsignal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	"k8s.io/client-go/kubernetes"
+	custommetrics "k8s.io/metrics/pkg/apis/custom_metrics/v1beta2"
+	externalmetrics "k8s.io/metrics/pkg/apis/external_metrics/v1beta1"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/anomaly"
+	"github.com/ochestra-tech/ochestra-ai/pkg/history"
+	"github.com/ochestra-tech/ochestra-ai/pkg/optimizer"
+	"github.com/ochestra-tech/ochestra-ai/pkg/promql"
+	"github.com/ochestra-tech/ochestra-ai/pkg/snapshot"
+)
+
+// Signal is a workload-specific measure of load, such as requests per second or queue
+// depth, read from exactly one of a Prometheus query, a custom.metrics.k8s.io metric or an
+// external.metrics.k8s.io metric
+type Signal struct {
+	Name             string  `json:"name"` // e.g. "rps" or "queueDepth"
+	Namespace        string  `json:"namespace"`
+	Kind             string  `json:"kind,omitempty"` // defaults to Deployment
+	Workload         string  `json:"workload"`
+	Query            string  `json:"query,omitempty"`            // PromQL; the samples are summed and an empty result is zero
+	Metric           string  `json:"metric,omitempty"`           // custom metric, summed over the workload's pods or read from Object
+	Object           string  `json:"object,omitempty"`           // "<resource>/<name>", e.g. "services/api", for a custom metric of another object
+	ExternalMetric   string  `json:"externalMetric,omitempty"`   // external metric in the workload's namespace, summed
+	Selector         string  `json:"selector,omitempty"`         // label selector for the external metric
+	TargetPerReplica float64 `json:"targetPerReplica,omitempty"` // load one replica handles; replicas are never recommended below peak/target
+}
+
+// subject names the signal's workload the way anomaly series do
+func (s Signal) subject() string {
+	return fmt.Sprintf("%s/%s/%s", s.Namespace, s.Kind, s.Workload)
+}
+
+// Config lists the load signals and how far back their peaks are taken
+type Config struct {
+	Signals  []Signal `json:"signals"`
+	Lookback string   `json:"lookback"` // defaults to a week
+	lookback time.Duration
+}
+
+// LoadConfig reads load signals from a JSON file
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read load signals: %w", err)
+	}
+
+	config := Config{Lookback: "168h"}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse load signals: %w", err)
+	}
+	if err := config.init(); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// init validates the signals, defaults their kind and parses the lookback
+func (c *Config) init() error {
+	for i := range c.Signals {
+		s := &c.Signals[i]
+		if s.Name == "" || strings.ContainsAny(s.Name, "|:/") {
+			return fmt.Errorf("load signal %d: name must be set and must not contain '|', ':' or '/'", i)
+		}
+		if s.Namespace == "" || s.Workload == "" {
+			return fmt.Errorf("load signal %s: namespace and workload must be set", s.Name)
+		}
+		if s.Kind == "" {
+			s.Kind = "Deployment"
+		}
+		sources := 0
+		for _, source := range []string{s.Query, s.Metric, s.ExternalMetric} {
+			if source != "" {
+				sources++
+			}
+		}
+		if sources != 1 {
+			return fmt.Errorf("load signal %s: set exactly one of query, metric and externalMetric", s.Name)
+		}
+		if s.Object != "" && (s.Metric == "" || !strings.Contains(s.Object, "/")) {
+			return fmt.Errorf("load signal %s: object must be <resource>/<name> and used with metric", s.Name)
+		}
+		if s.TargetPerReplica < 0 {
+			return fmt.Errorf("load signal %s: targetPerReplica must not be negative", s.Name)
+		}
+	}
+	lookback, err := time.ParseDuration(c.Lookback)
+	if err != nil || lookback <= 0 {
+		return fmt.Errorf("invalid lookback %q", c.Lookback)
+	}
+	c.lookback = lookback
+	return nil
+}
+
+// Collector reads load signals each cycle and compares them with their recorded peaks
+type Collector struct {
+	config        *Config
+	clientset     kubernetes.Interface
+	prometheusURL string
+	store         history.Store
+	cluster       string
+}
+
+// NewCollector creates a collector; signals defined by a query need a Prometheus URL
+func NewCollector(config *Config, clientset kubernetes.Interface, prometheusURL string, store history.Store, cluster string) (*Collector, error) {
+	for _, s := range config.Signals {
+		if s.Query != "" && prometheusURL == "" {
+			return nil, fmt.Errorf("load signal %s is a Prometheus query but no Prometheus URL is set", s.Name)
+		}
+	}
+	return &Collector{
+		config:        config,
+		clientset:     clientset,
+		prometheusURL: prometheusURL,
+		store:         store,
+		cluster:       cluster,
+	}, nil
+}
+
+// Collect reads the current value of each signal as an anomaly series, e.g.
+// "load:rps|shop/Deployment/api". Signals that cannot be read are left out and their
+// errors returned together.
+func (c *Collector) Collect(ctx context.Context, snap *snapshot.ClusterSnapshot) (map[string]float64, error) {
+	series := make(map[string]float64)
+	var errs []error
+	for _, s := range c.config.Signals {
+		value, err := c.read(ctx, snap, s)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("load signal %s of %s: %w", s.Name, s.subject(), err))
+			continue
+		}
+		series[anomaly.SeriesKey(anomaly.LoadMetric(s.Name), s.subject())] = value
+	}
+	return series, errors.Join(errs...)
+}
+
+// read returns a signal's current value from its source
+func (c *Collector) read(ctx context.Context, snap *snapshot.ClusterSnapshot, s Signal) (float64, error) {
+	switch {
+	case s.Query != "":
+		samples, err := promql.Query(ctx, c.prometheusURL, s.Query)
+		if err != nil {
+			return 0, err
+		}
+		total := 0.0
+		for _, sample := range samples {
+			total += sample.Value
+		}
+		return total, nil
+
+	case s.ExternalMetric != "":
+		// Ask for JSON, since the clientset may prefer protobuf, which the metrics APIs don't serve
+		request := c.clientset.CoreV1().RESTClient().Get().
+			AbsPath("/apis/external.metrics.k8s.io/v1beta1/namespaces", s.Namespace, s.ExternalMetric).
+			SetHeader("Accept", "application/json")
+		if s.Selector != "" {
+			request = request.Param("labelSelector", s.Selector)
+		}
+		data, err := request.DoRaw(ctx)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get external metric: %w", err)
+		}
+		var list externalmetrics.ExternalMetricValueList
+		if err := json.Unmarshal(data, &list); err != nil {
+			return 0, fmt.Errorf("failed to parse external metric: %w", err)
+		}
+		total := 0.0
+		for _, item := range list.Items {
+			total += item.Value.AsApproximateFloat64()
+		}
+		return total, nil
+
+	case s.Object != "":
+		data, err := c.clientset.CoreV1().RESTClient().Get().
+			AbsPath("/apis/custom.metrics.k8s.io/v1beta2/namespaces", s.Namespace, s.Object, s.Metric).
+			SetHeader("Accept", "application/json").DoRaw(ctx)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get custom metric: %w", err)
+		}
+		var list custommetrics.MetricValueList
+		if err := json.Unmarshal(data, &list); err != nil {
+			return 0, fmt.Errorf("failed to parse custom metric: %w", err)
+		}
+		if len(list.Items) == 0 {
+			return 0, fmt.Errorf("no value for custom metric %s of %s", s.Metric, s.Object)
+		}
+		return list.Items[0].Value.AsApproximateFloat64(), nil
+
+	default:
+		// Per-pod custom metrics, summed over the pods the workload owns
+		pods := make(map[string]bool)
+		for i := range snap.Pods {
+			pod := &snap.Pods[i]
+			if kind, name := snapshot.WorkloadOwner(pod); pod.Namespace == s.Namespace && kind == s.Kind && name == s.Workload {
+				pods[pod.Name] = true
+			}
+		}
+		if len(pods) == 0 {
+			return 0, fmt.Errorf("no pods found")
+		}
+		data, err := c.clientset.CoreV1().RESTClient().Get().
+			AbsPath("/apis/custom.metrics.k8s.io/v1beta2/namespaces", s.Namespace, "pods", "*", s.Metric).
+			SetHeader("Accept", "application/json").DoRaw(ctx)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get custom metric: %w", err)
+		}
+		var list custommetrics.MetricValueList
+		if err := json.Unmarshal(data, &list); err != nil {
+			return 0, fmt.Errorf("failed to parse custom metric: %w", err)
+		}
+		total, found := 0.0, false
+		for _, item := range list.Items {
+			if pods[item.DescribedObject.Name] {
+				total += item.Value.AsApproximateFloat64()
+				found = true
+			}
+		}
+		if !found {
+			return 0, fmt.Errorf("no value for custom metric %s on the workload's pods", s.Metric)
+		}
+		return total, nil
+	}
+}
+
+// Loads compares the signals read at now with their peaks over the lookback, keyed by
+// workload for optimizer.ApplyLoadToUsage and optimizer.ApplyLoadToReplicas. Signals
+// missing from current are left out, so their workloads are sized from usage alone.
+func (c *Collector) Loads(ctx context.Context, current map[string]float64, now time.Time) (map[string][]optimizer.WorkloadLoad, error) {
+	past, err := c.store.List(ctx, history.Query{Cluster: c.cluster, Since: now.Add(-c.config.lookback)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read load signal history: %w", err)
+	}
+
+	loads := make(map[string][]optimizer.WorkloadLoad)
+	for _, s := range c.config.Signals {
+		key := anomaly.SeriesKey(anomaly.LoadMetric(s.Name), s.subject())
+		value, ok := current[key]
+		if !ok {
+			continue
+		}
+		load := optimizer.WorkloadLoad{Signal: s.Name, Current: value, Peak: value}
+		for _, snap := range past {
+			if v, ok := snap.Series[key]; ok {
+				load.Peak = math.Max(load.Peak, v)
+			}
+		}
+		if s.TargetPerReplica > 0 {
+			load.MinReplicas = int(math.Ceil(load.Peak / s.TargetPerReplica))
+		}
+		loads[s.subject()] = append(loads[s.subject()], load)
+	}
+	return loads, nil
+}
@@ -0,0 +1,167 @@
+package maintenance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/notify"
+)
+
+// Window is a scheduled maintenance period, either recurring (Cron plus Duration) or a
+// single Start/End interval. Namespaces and Clusters limit its scope; empty means all.
+type Window struct {
+	Name       string     `json:"name"`
+	Cron       string     `json:"cron,omitempty"`     // e.g. "0 2 * * 6" for Saturdays at 02:00
+	Duration   string     `json:"duration,omitempty"` // length of each recurring window, e.g. "4h"
+	Timezone   string     `json:"timezone,omitempty"` // IANA name for Cron; defaults to UTC
+	Start      *time.Time `json:"start,omitempty"`
+	End        *time.Time `json:"end,omitempty"`
+	Namespaces []string   `json:"namespaces,omitempty"`
+	Clusters   []string   `json:"clusters,omitempty"`
+
+	schedule *cronSchedule
+	duration time.Duration
+	location *time.Location
+}
+
+// Schedule holds the maintenance windows loaded from a configuration file
+type Schedule struct {
+	Windows []Window `json:"windows"`
+}
+
+// maxDuration bounds recurring windows so activity checks stay cheap
+const maxDuration = 7 * 24 * time.Hour
+
+// LoadSchedule reads maintenance windows from a JSON file
+func LoadSchedule(path string) (*Schedule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read maintenance windows: %w", err)
+	}
+
+	var schedule Schedule
+	if err := json.Unmarshal(data, &schedule); err != nil {
+		return nil, fmt.Errorf("failed to parse maintenance windows: %w", err)
+	}
+
+	for i := range schedule.Windows {
+		if err := schedule.Windows[i].init(); err != nil {
+			return nil, fmt.Errorf("maintenance window %d: %w", i, err)
+		}
+	}
+	return &schedule, nil
+}
+
+// init validates a window and parses its schedule
+func (w *Window) init() error {
+	if w.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+
+	if w.Cron == "" {
+		if w.Start == nil || w.End == nil || !w.End.After(*w.Start) {
+			return fmt.Errorf("%s needs either cron and duration or a start before its end", w.Name)
+		}
+		return nil
+	}
+
+	schedule, err := parseCron(w.Cron)
+	if err != nil {
+		return fmt.Errorf("%s: %w", w.Name, err)
+	}
+	duration, err := time.ParseDuration(w.Duration)
+	if err != nil || duration <= 0 || duration > maxDuration {
+		return fmt.Errorf("%s needs a positive duration of at most %s", w.Name, maxDuration)
+	}
+	location := time.UTC
+	if w.Timezone != "" {
+		if location, err = time.LoadLocation(w.Timezone); err != nil {
+			return fmt.Errorf("%s: %w", w.Name, err)
+		}
+	}
+
+	w.schedule, w.duration, w.location = schedule, duration, location
+	return nil
+}
+
+// ActiveAt reports whether the window is open at t
+func (w *Window) ActiveAt(t time.Time) bool {
+	if w.schedule == nil {
+		return w.Start != nil && w.End != nil && !t.Before(*w.Start) && t.Before(*w.End)
+	}
+	_, ok := w.schedule.lastStart(t.In(w.location), w.duration-time.Nanosecond)
+	return ok
+}
+
+// covers reports whether the window applies to a cluster and namespace. An empty
+// namespace only matches cluster-wide windows.
+func (w *Window) covers(cluster, namespace string) bool {
+	if len(w.Clusters) > 0 && !slices.Contains(w.Clusters, cluster) {
+		return false
+	}
+	if len(w.Namespaces) == 0 {
+		return true
+	}
+	return namespace != "" && slices.Contains(w.Namespaces, namespace)
+}
+
+// Active returns the windows open at t for a cluster, including namespace-scoped ones
+func (s *Schedule) Active(t time.Time, cluster string) []Window {
+	active := make([]Window, 0)
+	for _, w := range s.Windows {
+		if len(w.Clusters) > 0 && !slices.Contains(w.Clusters, cluster) {
+			continue
+		}
+		if w.ActiveAt(t) {
+			active = append(active, w)
+		}
+	}
+	return active
+}
+
+// InWindow reports whether a namespace (or the whole cluster when namespace is empty)
+// is under an open maintenance window at t
+func (s *Schedule) InWindow(t time.Time, cluster, namespace string) bool {
+	for _, w := range s.Windows {
+		if w.covers(cluster, namespace) && w.ActiveAt(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// Names returns the names of windows
+func Names(windows []Window) []string {
+	names := make([]string, 0, len(windows))
+	for _, w := range windows {
+		names = append(names, w.Name)
+	}
+	return names
+}
+
+// SilencingNotifier drops alerts raised during maintenance windows covering their
+// cluster or namespace, forwarding everything else
+type SilencingNotifier struct {
+	Next     notify.Notifier
+	Schedule *Schedule
+	Cluster  string
+}
+
+// Notify forwards the alert unless a maintenance window silences it. Resolved alerts are
+// always forwarded so incidents opened before a window can close.
+func (n *SilencingNotifier) Notify(ctx context.Context, alert notify.Alert) error {
+	at := alert.Timestamp
+	if at.IsZero() {
+		at = time.Now()
+	}
+	if !alert.Resolved && n.Schedule.InWindow(at, n.Cluster, alert.Labels["namespace"]) {
+		log.Printf("Silenced alert during maintenance window: %s", alert.Title)
+		return nil
+	}
+	return n.Next.Notify(ctx, alert)
+}
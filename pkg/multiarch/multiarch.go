@@ -0,0 +1,263 @@
+package multiarch
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/cost"
+	"github.com/ochestra-tech/ochestra-ai/pkg/optimizer"
+	"github.com/ochestra-tech/ochestra-ai/pkg/snapshot"
+)
+
+// ResourceArchitecture is the resource type of ARM migration recommendations
+const ResourceArchitecture = "architecture"
+
+// TargetPlatform is the platform of ARM node pools such as AWS Graviton, Azure Cobalt and
+// Google Axion
+const TargetPlatform = "linux/arm64"
+
+// Options controls the ARM migration analysis
+type Options struct {
+	Discount    float64 // how much cheaper ARM capacity is than the x86 capacity it replaces, 0-1
+	Concurrency int     // registry lookups run at once
+}
+
+// DefaultOptions assumes ARM instances cost 20% less than x86 instances of the same size,
+// about what Graviton saves over comparable Intel instances
+var DefaultOptions = Options{
+	Discount:    0.2,
+	Concurrency: 8,
+}
+
+// Candidate is a workload on x86 nodes whose images are all published for linux/arm64
+type Candidate struct {
+	Namespace       string   `json:"namespace"`
+	Kind            string   `json:"kind"`
+	Name            string   `json:"name"`
+	Images          []string `json:"images"`
+	Pods            int      `json:"pods"`
+	CostPerHour     float64  `json:"costPerHour"` // requests at their current nodes' rates
+	SavingsPerMonth float64  `json:"savingsPerMonth"`
+}
+
+// BlockingImage is an image without a linux/arm64 build, or whose manifest could not be
+// read, and the workloads it keeps on x86 nodes
+type BlockingImage struct {
+	Image           string   `json:"image"`
+	Platforms       []string `json:"platforms,omitempty"` // platforms it is published for
+	Error           string   `json:"error,omitempty"`     // why its manifest could not be read
+	Workloads       []string `json:"workloads"`           // namespace/kind/name
+	SavingsPerMonth float64  `json:"savingsPerMonth"`     // of the workloads it blocks, which other images may block too
+}
+
+// Report lists the workloads that could move to ARM nodes and the images holding the rest back
+type Report struct {
+	ARMNodes        int             `json:"armNodes"` // nodes already running arm64
+	Candidates      []Candidate     `json:"candidates"`
+	Blocking        []BlockingImage `json:"blocking"`
+	SavingsPerMonth float64         `json:"savingsPerMonth"` // of the candidates
+}
+
+// platformLookup is the answer for one image
+type platformLookup struct {
+	platforms []string
+	err       error
+}
+
+// Analyze groups the running pods on x86 Linux nodes by workload and checks each of their
+// images for a linux/arm64 build. Images already running ready on arm64 nodes are taken as
+// published for it without a lookup. DaemonSets follow the nodes and are left out. The
+// saving is the workload's request cost at its nodes' rates times the ARM discount.
+func Analyze(ctx context.Context, resolver *Resolver, snap *snapshot.ClusterSnapshot, pricing map[string]cost.ResourcePricing, options Options) *Report {
+	report := &Report{Candidates: make([]Candidate, 0), Blocking: make([]BlockingImage, 0)}
+	nodes := make(map[string]*v1.Node, len(snap.Nodes))
+	for i := range snap.Nodes {
+		node := &snap.Nodes[i]
+		nodes[node.Name] = node
+		if snapshot.NodeArch(node) == "arm64" {
+			report.ARMNodes++
+		}
+	}
+	podCosts := make(map[string]float64)
+	for _, p := range cost.PodCostsFromSnapshot(snap, pricing) {
+		podCosts[p.Namespace+"/"+p.Name] = p.CPUCost + p.MemoryCost
+	}
+
+	type workload struct {
+		candidate Candidate
+		images    map[string]bool
+	}
+	workloads := make(map[string]*workload)
+	onARM := make(map[string]bool) // images running ready on arm64 nodes
+	for i := range snap.Pods {
+		pod := &snap.Pods[i]
+		node, ok := nodes[pod.Spec.NodeName]
+		if !ok || pod.Status.Phase != v1.PodRunning || snapshot.NodeOS(node) != snapshot.OSLinux {
+			continue
+		}
+		images := podImages(pod)
+		if snapshot.NodeArch(node) == "arm64" {
+			if ready(pod) {
+				for _, image := range images {
+					onARM[image] = true
+				}
+			}
+			continue
+		}
+		kind, name := snapshot.WorkloadOwner(pod)
+		if kind == "DaemonSet" {
+			continue
+		}
+
+		key := fmt.Sprintf("%s/%s/%s", pod.Namespace, kind, name)
+		w, ok := workloads[key]
+		if !ok {
+			w = &workload{candidate: Candidate{Namespace: pod.Namespace, Kind: kind, Name: name}, images: make(map[string]bool)}
+			workloads[key] = w
+		}
+		w.candidate.Pods++
+		w.candidate.CostPerHour += podCosts[pod.Namespace+"/"+pod.Name]
+		for _, image := range images {
+			w.images[image] = true
+		}
+	}
+
+	// Read the manifests of the images not already seen on ARM
+	pending := make(map[string]bool)
+	for _, w := range workloads {
+		for image := range w.images {
+			if !onARM[image] {
+				pending[image] = true
+			}
+		}
+	}
+	lookups := make(map[string]platformLookup, len(pending))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, max(options.Concurrency, 1))
+	for image := range pending {
+		wg.Add(1)
+		go func(image string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			platforms, err := resolver.Platforms(ctx, image)
+			mu.Lock()
+			lookups[image] = platformLookup{platforms: platforms, err: err}
+			mu.Unlock()
+		}(image)
+	}
+	wg.Wait()
+
+	blocking := make(map[string]*BlockingImage)
+	for key, w := range workloads {
+		c := w.candidate
+		saving := c.CostPerHour * options.Discount * 24 * 30
+		blocked := false
+		for image := range w.images {
+			c.Images = append(c.Images, image)
+			lookup, looked := lookups[image]
+			if !looked || supportsTarget(lookup.platforms) {
+				continue
+			}
+			blocked = true
+			b, ok := blocking[image]
+			if !ok {
+				b = &BlockingImage{Image: image, Platforms: lookup.platforms}
+				if lookup.err != nil {
+					b.Error = lookup.err.Error()
+				}
+				blocking[image] = b
+			}
+			b.Workloads = append(b.Workloads, key)
+			b.SavingsPerMonth += saving
+		}
+		if blocked {
+			continue
+		}
+		sort.Strings(c.Images)
+		c.SavingsPerMonth = saving
+		report.Candidates = append(report.Candidates, c)
+		report.SavingsPerMonth += saving
+	}
+	for _, b := range blocking {
+		sort.Strings(b.Workloads)
+		report.Blocking = append(report.Blocking, *b)
+	}
+
+	sort.Slice(report.Candidates, func(i, j int) bool {
+		a, b := report.Candidates[i], report.Candidates[j]
+		if a.SavingsPerMonth != b.SavingsPerMonth {
+			return a.SavingsPerMonth > b.SavingsPerMonth
+		}
+		return a.Namespace+"/"+a.Kind+"/"+a.Name < b.Namespace+"/"+b.Kind+"/"+b.Name
+	})
+	sort.Slice(report.Blocking, func(i, j int) bool {
+		a, b := report.Blocking[i], report.Blocking[j]
+		if a.SavingsPerMonth != b.SavingsPerMonth {
+			return a.SavingsPerMonth > b.SavingsPerMonth
+		}
+		return a.Image < b.Image
+	})
+	return report
+}
+
+// Recommendations turns the candidates with a saving into optimizer recommendations
+func (r *Report) Recommendations() []optimizer.Recommendation {
+	recs := make([]optimizer.Recommendation, 0, len(r.Candidates))
+	for _, c := range r.Candidates {
+		if c.SavingsPerMonth <= 0 {
+			continue
+		}
+		recs = append(recs, optimizer.Recommendation{
+			Type: "ARM Migration",
+			Description: fmt.Sprintf("Every image (%s) is published for %s; schedule the %d pods on ARM nodes with a kubernetes.io/arch: arm64 node selector",
+				strings.Join(c.Images, ", "), TargetPlatform, c.Pods),
+			PotentialSaving: c.SavingsPerMonth,
+			Namespace:       c.Namespace,
+			WorkloadKind:    c.Kind,
+			WorkloadName:    c.Name,
+			ResourceType:    ResourceArchitecture,
+			Replicas:        c.Pods,
+		})
+	}
+	return recs
+}
+
+// podImages returns the images of all of a pod's containers, init containers included
+// since they have to run on the new nodes too
+func podImages(pod *v1.Pod) []string {
+	images := make([]string, 0, len(pod.Spec.Containers)+len(pod.Spec.InitContainers))
+	for _, c := range pod.Spec.InitContainers {
+		images = append(images, c.Image)
+	}
+	for _, c := range pod.Spec.Containers {
+		images = append(images, c.Image)
+	}
+	return images
+}
+
+// supportsTarget reports whether the platforms include linux/arm64, in any variant
+func supportsTarget(platforms []string) bool {
+	for _, p := range platforms {
+		if p == TargetPlatform || strings.HasPrefix(p, TargetPlatform+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// ready reports whether a pod's Ready condition is true
+func ready(pod *v1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == v1.PodReady {
+			return condition.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
@@ -0,0 +1,236 @@
+package multiarch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// manifestTypes are the manifest and index media types a registry may answer with
+var manifestTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+// Reference is an image name split into the registry host, repository and tag or digest
+type Reference struct {
+	Registry   string // host the registry API is served on
+	Repository string
+	Reference  string // tag or digest
+}
+
+// ParseReference splits an image name the way the container runtime resolves it: without a
+// registry host the image is on Docker Hub, single-name Docker Hub images are under
+// library/, and the tag defaults to latest
+func ParseReference(image string) Reference {
+	ref := Reference{Registry: "registry-1.docker.io", Reference: "latest"}
+	name, digest, pinned := strings.Cut(image, "@")
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, ref.Reference = name[:i], name[i+1:]
+	}
+	if pinned {
+		ref.Reference = digest // a digest wins over a tag next to it
+	}
+	if host, rest, ok := strings.Cut(name, "/"); ok && (strings.ContainsAny(host, ".:") || host == "localhost") {
+		ref.Registry, name = host, rest
+		if host == "docker.io" || host == "index.docker.io" {
+			ref.Registry = "registry-1.docker.io"
+		}
+	}
+	if ref.Registry == "registry-1.docker.io" && !strings.Contains(name, "/") {
+		name = "library/" + name
+	}
+	ref.Repository = name
+	return ref
+}
+
+// Resolver reads the platforms images are published for from their registries' manifests,
+// remembering answers for a while since tags rarely gain or lose platforms. Only registries
+// that allow anonymous pulls can be read.
+type Resolver struct {
+	client *http.Client
+	ttl    time.Duration
+
+	mu    sync.Mutex
+	cache map[string]resolved
+}
+
+// resolved is a cached lookup
+type resolved struct {
+	platforms []string
+	err       error
+	at        time.Time
+}
+
+// NewResolver creates a resolver that looks images up again after ttl
+func NewResolver(ttl time.Duration) *Resolver {
+	return &Resolver{
+		client: &http.Client{Timeout: 15 * time.Second},
+		ttl:    ttl,
+		cache:  make(map[string]resolved),
+	}
+}
+
+// Platforms returns the os/architecture[/variant] platforms an image is published for
+func (r *Resolver) Platforms(ctx context.Context, image string) ([]string, error) {
+	r.mu.Lock()
+	c, ok := r.cache[image]
+	r.mu.Unlock()
+	if ok && time.Since(c.at) < r.ttl {
+		return c.platforms, c.err
+	}
+
+	platforms, err := r.lookup(ctx, ParseReference(image))
+	r.mu.Lock()
+	r.cache[image] = resolved{platforms: platforms, err: err, at: time.Now()}
+	r.mu.Unlock()
+	return platforms, err
+}
+
+// lookup reads an image's manifest, and for single-platform images its config, from the
+// registry
+func (r *Resolver) lookup(ctx context.Context, ref Reference) ([]string, error) {
+	base := fmt.Sprintf("https://%s/v2/%s", ref.Registry, ref.Repository)
+	var manifest struct {
+		Manifests []struct {
+			Platform struct {
+				OS           string `json:"os"`
+				Architecture string `json:"architecture"`
+				Variant      string `json:"variant"`
+			} `json:"platform"`
+		} `json:"manifests"`
+		Config struct {
+			Digest string `json:"digest"`
+		} `json:"config"`
+	}
+	token, err := r.get(ctx, base+"/manifests/"+ref.Reference, strings.Join(manifestTypes, ", "), "", &manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	var platforms []string
+	for _, m := range manifest.Manifests {
+		p := m.Platform
+		if p.OS == "" || p.OS == "unknown" {
+			continue // attestations and signatures
+		}
+		platform := p.OS + "/" + p.Architecture
+		if p.Variant != "" {
+			platform += "/" + p.Variant
+		}
+		platforms = append(platforms, platform)
+	}
+	if len(manifest.Manifests) > 0 || manifest.Config.Digest == "" {
+		return platforms, nil
+	}
+
+	// A single-platform image names its platform in its config blob
+	var config struct {
+		OS           string `json:"os"`
+		Architecture string `json:"architecture"`
+		Variant      string `json:"variant"`
+	}
+	if _, err := r.get(ctx, base+"/blobs/"+manifest.Config.Digest, "", token, &config); err != nil {
+		return nil, err
+	}
+	platform := config.OS + "/" + config.Architecture
+	if config.Variant != "" {
+		platform += "/" + config.Variant
+	}
+	return []string{platform}, nil
+}
+
+// get fetches a registry URL into out, answering a bearer challenge with an anonymous
+// token, and returns the token used
+func (r *Resolver) get(ctx context.Context, endpoint, accept, token string, out interface{}) (string, error) {
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+		if err != nil {
+			return "", fmt.Errorf("failed to create registry request: %w", err)
+		}
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := r.client.Do(req)
+		if err != nil {
+			return "", fmt.Errorf("failed to query registry: %w", err)
+		}
+
+		if resp.StatusCode == http.StatusUnauthorized && attempt == 0 {
+			challenge := resp.Header.Get("WWW-Authenticate")
+			resp.Body.Close()
+			if token, err = r.anonymousToken(ctx, challenge); err != nil {
+				return "", err
+			}
+			continue
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("registry returned HTTP %d for %s", resp.StatusCode, endpoint)
+		}
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return "", fmt.Errorf("failed to parse registry response: %w", err)
+		}
+		return token, nil
+	}
+}
+
+// challengeParam matches a key="value" parameter of a WWW-Authenticate challenge; scopes
+// can hold commas, so the parameters cannot be split on them
+var challengeParam = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// anonymousToken answers a Bearer WWW-Authenticate challenge from the token service it names
+func (r *Resolver) anonymousToken(ctx context.Context, challenge string) (string, error) {
+	params, ok := strings.CutPrefix(challenge, "Bearer ")
+	if !ok {
+		return "", fmt.Errorf("registry requires credentials")
+	}
+	values := make(map[string]string)
+	for _, match := range challengeParam.FindAllStringSubmatch(params, -1) {
+		values[match[1]] = match[2]
+	}
+	if values["realm"] == "" {
+		return "", fmt.Errorf("registry challenge names no token service")
+	}
+	query := url.Values{}
+	for _, key := range []string{"service", "scope"} {
+		if values[key] != "" {
+			query.Set(key, values[key])
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, values["realm"]+"?"+query.Encode(), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create token request: %w", err)
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to get registry token: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("registry token service returned HTTP %d; the image may need credentials", resp.StatusCode)
+	}
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("failed to parse registry token: %w", err)
+	}
+	if body.Token != "" {
+		return body.Token, nil
+	}
+	return body.AccessToken, nil
+}
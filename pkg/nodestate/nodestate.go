@@ -0,0 +1,205 @@
+package nodestate
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/health"
+	"github.com/ochestra-tech/ochestra-ai/pkg/history"
+	"github.com/ochestra-tech/ochestra-ai/pkg/notify"
+	"github.com/ochestra-tech/ochestra-ai/pkg/snapshot"
+)
+
+// Node readiness states recorded in history
+const (
+	Ready    = "Ready"
+	NotReady = "NotReady"
+	Unknown  = "Unknown"
+)
+
+// Config controls flapping detection
+type Config struct {
+	FlapWindow      time.Duration // window transitions are counted over
+	FlapTransitions int           // transitions within FlapWindow at which a node is flapping
+	DownAfter       time.Duration // time NotReady after which a node counts as down rather than flapping
+	Lookback        time.Duration // history read to find when the current state began
+}
+
+// DefaultConfig flags nodes changing readiness three times in an hour
+var DefaultConfig = Config{
+	FlapWindow:      time.Hour,
+	FlapTransitions: 3,
+	DownAfter:       30 * time.Minute,
+	Lookback:        24 * time.Hour,
+}
+
+// State is a node's readiness history as seen by the monitor
+type State struct {
+	Node        string        `json:"node"`
+	State       string        `json:"state"`
+	Since       time.Time     `json:"since"`    // earliest recorded sample of the current state
+	Duration    time.Duration `json:"duration"` // at least this long, limited by the history lookback
+	Transitions int           `json:"transitions"`
+	Flapping    bool          `json:"flapping"`
+	Down        bool          `json:"down"` // NotReady for at least DownAfter
+}
+
+// Collect returns the readiness state of every node in a snapshot
+func Collect(snap *snapshot.ClusterSnapshot) map[string]string {
+	states := make(map[string]string, len(snap.Nodes))
+	for _, node := range snap.Nodes {
+		state := Unknown
+		for _, condition := range node.Status.Conditions {
+			if condition.Type != v1.NodeReady {
+				continue
+			}
+			switch condition.Status {
+			case v1.ConditionTrue:
+				state = Ready
+			case v1.ConditionFalse:
+				state = NotReady
+			}
+		}
+		states[node.Name] = state
+	}
+	return states
+}
+
+// Analyze combines past snapshots, ordered oldest first, with the current states to find
+// how long each node has been in its state and how often it changed within the flap window
+func Analyze(config Config, past []*history.Snapshot, current map[string]string, now time.Time) []State {
+	states := make([]State, 0, len(current))
+	for node, state := range current {
+		s := State{Node: node, State: state, Since: now}
+
+		previous, previousTime, inRun := state, now, true
+		for i := len(past) - 1; i >= 0; i-- {
+			recorded, ok := past[i].NodeStates[node]
+			if !ok || (!inRun && previousTime.Before(now.Add(-config.FlapWindow))) {
+				break
+			}
+			if recorded != previous && previousTime.After(now.Add(-config.FlapWindow)) {
+				s.Transitions++
+			}
+			inRun = inRun && recorded == state
+			if inRun {
+				s.Since = past[i].Timestamp
+			}
+			previous, previousTime = recorded, past[i].Timestamp
+		}
+
+		s.Duration = now.Sub(s.Since)
+		s.Flapping = s.Transitions >= config.FlapTransitions
+		s.Down = state != Ready && s.Duration >= config.DownAfter
+		states = append(states, s)
+	}
+
+	sort.Slice(states, func(i, j int) bool { return states[i].Node < states[j].Node })
+	return states
+}
+
+// Issues returns a NodeFlapping issue for each flapping node. Nodes that are down are
+// reported by the NotReady check instead.
+func Issues(config Config, states []State, now time.Time) []health.HealthIssue {
+	issues := make([]health.HealthIssue, 0)
+	for _, s := range states {
+		if !s.Flapping || s.Down {
+			continue
+		}
+		issue := health.HealthIssue{
+			Type:       health.IssueNodeFlapping,
+			Severity:   "warning",
+			Resource:   "Node",
+			Name:       s.Node,
+			Message:    fmt.Sprintf("Node changed readiness %d times in the last %s, now %s", s.Transitions, config.FlapWindow, s.State),
+			Timestamp:  now,
+			Suggestion: "Check kubelet restarts, node network stability and resource pressure",
+		}
+		health.Suggest(&issue)
+		issues = append(issues, issue)
+	}
+	return issues
+}
+
+// Tracker reads node readiness history each cycle and alerts when nodes start or stop
+// flapping
+type Tracker struct {
+	config   Config
+	store    history.Store
+	notifier notify.Notifier
+	cluster  string
+
+	mu       sync.Mutex
+	flapping map[string]bool // node -> flapping alert open
+}
+
+// NewTracker creates a tracker reading node states from store
+func NewTracker(config Config, store history.Store, notifier notify.Notifier, cluster string) *Tracker {
+	return &Tracker{
+		config:   config,
+		store:    store,
+		notifier: notifier,
+		cluster:  cluster,
+		flapping: make(map[string]bool),
+	}
+}
+
+// Check analyzes current node states against history and sends flapping alerts. Call it
+// before recording the states so the current sample is not counted twice.
+func (t *Tracker) Check(ctx context.Context, current map[string]string, now time.Time) ([]State, []health.HealthIssue, error) {
+	past, err := t.store.List(ctx, history.Query{Cluster: t.cluster, Since: now.Add(-t.config.Lookback)})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read node state history: %w", err)
+	}
+	states := Analyze(t.config, past, current, now)
+	issues := Issues(t.config, states, now)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	open := make(map[string]bool, len(issues))
+	for i := range issues {
+		open[issues[i].Name] = true
+		if !t.flapping[issues[i].Name] {
+			t.notify(ctx, issues[i], false)
+		}
+	}
+	for node := range t.flapping {
+		if !open[node] {
+			t.notify(ctx, health.HealthIssue{Type: health.IssueNodeFlapping, Resource: "Node", Name: node, Timestamp: now,
+				Message: "Node readiness is stable"}, true)
+		}
+	}
+	t.flapping = open
+
+	return states, issues, nil
+}
+
+// notify sends an alert for a node that started or stopped flapping
+func (t *Tracker) notify(ctx context.Context, issue health.HealthIssue, resolved bool) {
+	alert := notify.Alert{
+		Title:       fmt.Sprintf("Node %s is flapping", issue.Name),
+		Message:     issue.Message,
+		Severity:    issue.Severity,
+		Source:      "nodestate",
+		Labels:      map[string]string{"cluster": t.cluster, "node": issue.Name},
+		Timestamp:   issue.Timestamp,
+		Fingerprint: "node-flapping/" + issue.Name,
+		Issue:       &issue,
+	}
+	if resolved {
+		alert.Title = fmt.Sprintf("Resolved: Node %s stopped flapping", issue.Name)
+		alert.Severity = "info"
+		alert.Resolved = true
+	}
+
+	if err := t.notifier.Notify(ctx, alert); err != nil {
+		log.Printf("Failed to send node flapping alert %q: %v", alert.Title, err)
+	}
+}
@@ -0,0 +1,190 @@
+package notify
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultAlertmanagerResend is how often firing alerts are pushed to Alertmanager again.
+// Alertmanager resolves alerts that are not repeated within its resolve_timeout (5 minutes
+// by default).
+const DefaultAlertmanagerResend = time.Minute
+
+// invalidLabelChars matches the characters Alertmanager does not allow in label names
+var invalidLabelChars = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// AlertmanagerNotifier pushes alerts to Prometheus Alertmanager through its v2 API, so
+// its silences, inhibitions and routes apply to them. Firing alerts are pushed again until
+// they resolve, see Run.
+type AlertmanagerNotifier struct {
+	URLs   []string // every instance of an Alertmanager cluster, e.g. http://alertmanager:9093
+	Client *http.Client
+
+	mu     sync.Mutex
+	firing map[string]alertmanagerAlert // incident key -> last pushed alert
+}
+
+// alertmanagerAlert is an alert in the Alertmanager v2 API
+type alertmanagerAlert struct {
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	StartsAt    time.Time         `json:"startsAt"`
+	EndsAt      *time.Time        `json:"endsAt,omitempty"`
+}
+
+// NewAlertmanagerNotifier creates a notifier for the Alertmanager instances at urls
+func NewAlertmanagerNotifier(urls []string) *AlertmanagerNotifier {
+	trimmed := make([]string, 0, len(urls))
+	for _, u := range urls {
+		trimmed = append(trimmed, strings.TrimSuffix(u, "/"))
+	}
+	return &AlertmanagerNotifier{
+		URLs:   trimmed,
+		Client: &http.Client{Timeout: 10 * time.Second},
+		firing: make(map[string]alertmanagerAlert),
+	}
+}
+
+// Notify pushes a firing alert, or ends it when the alert is resolved. Alerts with a
+// fingerprint are kept firing until they resolve; alerts without one are pushed once.
+func (a *AlertmanagerNotifier) Notify(ctx context.Context, alert Alert) error {
+	key := incidentKey(alert)
+	at := alert.Timestamp
+	if at.IsZero() {
+		at = time.Now()
+	}
+
+	a.mu.Lock()
+	am, ok := a.firing[key]
+	switch {
+	case alert.Resolved && ok:
+		// End the alert Alertmanager knows, with the labels it was pushed with
+		am.EndsAt = &at
+		delete(a.firing, key)
+	case alert.Resolved:
+		am = toAlertmanager(alert, at)
+		am.EndsAt = &at
+	default:
+		startsAt := at
+		if ok {
+			startsAt = am.StartsAt
+		}
+		am = toAlertmanager(alert, startsAt)
+		if alert.Fingerprint != "" {
+			a.firing[key] = am
+		}
+	}
+	a.mu.Unlock()
+
+	return a.push(ctx, []alertmanagerAlert{am})
+}
+
+// Run pushes the firing alerts every interval until ctx is done, so Alertmanager does not
+// resolve them while they are still open
+func (a *AlertmanagerNotifier) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		a.mu.Lock()
+		alerts := make([]alertmanagerAlert, 0, len(a.firing))
+		for _, am := range a.firing {
+			alerts = append(alerts, am)
+		}
+		a.mu.Unlock()
+		if len(alerts) == 0 {
+			continue
+		}
+		if err := a.push(ctx, alerts); err != nil {
+			log.Printf("Failed to resend alerts to Alertmanager: %v", err)
+		}
+	}
+}
+
+// push posts alerts to every Alertmanager instance, succeeding if any accepts them.
+// Alertmanager clusters share alerts between instances, but each must be told directly
+// in case the others are down.
+func (a *AlertmanagerNotifier) push(ctx context.Context, alerts []alertmanagerAlert) error {
+	var lastErr error
+	delivered := false
+	for _, u := range a.URLs {
+		if err := postJSON(ctx, a.Client, u+"/api/v2/alerts", alerts); err != nil {
+			log.Printf("Failed to send alerts to Alertmanager %s: %v", u, err)
+			lastErr = err
+			continue
+		}
+		delivered = true
+	}
+	if delivered {
+		return nil
+	}
+	return lastErr
+}
+
+// toAlertmanager converts an alert, labeling it with its issue's identity and owner so
+// Alertmanager routes and silences can select it
+func toAlertmanager(alert Alert, startsAt time.Time) alertmanagerAlert {
+	labels := map[string]string{
+		"alertname": alertName(alert),
+		"severity":  alert.Severity,
+		"source":    alert.Source,
+	}
+	if alert.Fingerprint != "" {
+		sum := sha256.Sum256([]byte(alert.Fingerprint))
+		labels["fingerprint"] = hex.EncodeToString(sum[:8])
+	}
+	annotations := map[string]string{
+		"summary":     alert.Title,
+		"description": alert.Message,
+	}
+	if issue := alert.Issue; issue != nil {
+		for key, value := range map[string]string{"namespace": issue.Namespace, "resource": issue.Resource, "name": issue.Name, "node": issue.Node} {
+			if value != "" {
+				labels[key] = value
+			}
+		}
+		if issue.Owner != nil {
+			for key, value := range issue.Owner.Labels() {
+				labels[key] = value
+			}
+		}
+		if issue.RunbookURL != "" {
+			annotations["runbook_url"] = issue.RunbookURL
+		}
+		if issue.Suggestion != "" {
+			annotations["suggestion"] = issue.Suggestion
+		}
+	}
+	for key, value := range alert.Labels {
+		labels[invalidLabelChars.ReplaceAllString(key, "_")] = value
+	}
+
+	return alertmanagerAlert{Labels: labels, Annotations: annotations, StartsAt: startsAt}
+}
+
+// alertName names an alert for Alertmanager by its issue type, or by its source for
+// alerts without an issue, e.g. "BudgetAlert" for budget alerts
+func alertName(alert Alert) string {
+	if alert.Issue != nil && alert.Issue.Type != "" {
+		return invalidLabelChars.ReplaceAllString(alert.Issue.Type, "_")
+	}
+	parts := strings.FieldsFunc(alert.Source, func(r rune) bool { return r == '-' || r == '_' || r == ':' || r == '/' })
+	var name strings.Builder
+	for _, part := range parts {
+		name.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	name.WriteString("Alert")
+	return invalidLabelChars.ReplaceAllString(name.String(), "_")
+}
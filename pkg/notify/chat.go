@@ -0,0 +1,189 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SlackNotifier posts alerts to a Slack incoming webhook
+type SlackNotifier struct {
+	WebhookURL string
+	Formatter  *Formatter
+	Client     *http.Client
+}
+
+// NewSlackNotifier creates a notifier posting to a Slack incoming webhook
+func NewSlackNotifier(webhookURL string, formatter *Formatter) *SlackNotifier {
+	return &SlackNotifier{
+		WebhookURL: webhookURL,
+		Formatter:  formatter,
+		Client:     &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Notify posts the alert as a Slack message
+func (s *SlackNotifier) Notify(ctx context.Context, alert Alert) error {
+	msg, err := s.Formatter.Format(alert)
+	if err != nil {
+		return err
+	}
+	payload := map[string]interface{}{
+		"text": fmt.Sprintf("%s *%s*\n%s", severityEmoji(msg.Severity), msg.Title, msg.Text),
+	}
+	return send(ctx, s.Client, s.WebhookURL, s.Formatter, "slack", msg, payload)
+}
+
+// TeamsNotifier posts alerts as adaptive cards to a Microsoft Teams incoming webhook or
+// workflow URL
+type TeamsNotifier struct {
+	WebhookURL string
+	Formatter  *Formatter
+	Client     *http.Client
+}
+
+// NewTeamsNotifier creates a notifier posting to a Microsoft Teams webhook
+func NewTeamsNotifier(webhookURL string, formatter *Formatter) *TeamsNotifier {
+	return &TeamsNotifier{
+		WebhookURL: webhookURL,
+		Formatter:  formatter,
+		Client:     &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Notify posts the alert as an adaptive card
+func (t *TeamsNotifier) Notify(ctx context.Context, alert Alert) error {
+	msg, err := t.Formatter.Format(alert)
+	if err != nil {
+		return err
+	}
+
+	facts := make([]map[string]string, 0, len(msg.Fields))
+	for _, f := range msg.Fields {
+		facts = append(facts, map[string]string{"title": f.Name, "value": f.Value})
+	}
+	body := []map[string]interface{}{
+		{"type": "TextBlock", "text": msg.Title, "weight": "Bolder", "size": "Medium", "color": teamsColor(msg.Severity), "wrap": true},
+		{"type": "TextBlock", "text": msg.Text, "wrap": true},
+	}
+	if len(facts) > 0 {
+		body = append(body, map[string]interface{}{"type": "FactSet", "facts": facts})
+	}
+
+	payload := map[string]interface{}{
+		"type": "message",
+		"attachments": []map[string]interface{}{{
+			"contentType": "application/vnd.microsoft.card.adaptive",
+			"content": map[string]interface{}{
+				"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+				"type":    "AdaptiveCard",
+				"version": "1.4",
+				"body":    body,
+			},
+		}},
+	}
+	return send(ctx, t.Client, t.WebhookURL, t.Formatter, "teams", msg, payload)
+}
+
+// teamsColor maps a severity to an adaptive card text color
+func teamsColor(severity string) string {
+	switch severity {
+	case "critical":
+		return "Attention"
+	case "warning":
+		return "Warning"
+	case "resolved":
+		return "Good"
+	default:
+		return "Accent"
+	}
+}
+
+// DiscordNotifier posts alerts as embeds to a Discord webhook
+type DiscordNotifier struct {
+	WebhookURL string
+	Formatter  *Formatter
+	Client     *http.Client
+}
+
+// NewDiscordNotifier creates a notifier posting to a Discord webhook
+func NewDiscordNotifier(webhookURL string, formatter *Formatter) *DiscordNotifier {
+	return &DiscordNotifier{
+		WebhookURL: webhookURL,
+		Formatter:  formatter,
+		Client:     &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Notify posts the alert as a Discord embed
+func (d *DiscordNotifier) Notify(ctx context.Context, alert Alert) error {
+	msg, err := d.Formatter.Format(alert)
+	if err != nil {
+		return err
+	}
+
+	fields := make([]map[string]interface{}, 0, len(msg.Fields))
+	for _, f := range msg.Fields {
+		fields = append(fields, map[string]interface{}{"name": f.Name, "value": f.Value, "inline": true})
+	}
+	color, _ := strconv.ParseInt(strings.TrimPrefix(msg.Color, "#"), 16, 32)
+	embed := map[string]interface{}{
+		"title":       msg.Title,
+		"description": msg.Text,
+		"color":       color,
+		"fields":      fields,
+	}
+	if !alert.Timestamp.IsZero() {
+		embed["timestamp"] = alert.Timestamp.Format(time.RFC3339)
+	}
+
+	payload := map[string]interface{}{
+		"username": "ochestra-ai",
+		"embeds":   []map[string]interface{}{embed},
+	}
+	return send(ctx, d.Client, d.WebhookURL, d.Formatter, "discord", msg, payload)
+}
+
+// MattermostNotifier posts alerts as message attachments to a Mattermost incoming webhook
+type MattermostNotifier struct {
+	WebhookURL string
+	Formatter  *Formatter
+	Client     *http.Client
+}
+
+// NewMattermostNotifier creates a notifier posting to a Mattermost incoming webhook
+func NewMattermostNotifier(webhookURL string, formatter *Formatter) *MattermostNotifier {
+	return &MattermostNotifier{
+		WebhookURL: webhookURL,
+		Formatter:  formatter,
+		Client:     &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Notify posts the alert as a Mattermost attachment
+func (m *MattermostNotifier) Notify(ctx context.Context, alert Alert) error {
+	msg, err := m.Formatter.Format(alert)
+	if err != nil {
+		return err
+	}
+
+	fields := make([]map[string]interface{}, 0, len(msg.Fields))
+	for _, f := range msg.Fields {
+		fields = append(fields, map[string]interface{}{"title": f.Name, "value": f.Value, "short": true})
+	}
+
+	payload := map[string]interface{}{
+		"username": "ochestra-ai",
+		"attachments": []map[string]interface{}{{
+			"fallback": fmt.Sprintf("%s: %s", msg.Title, msg.Text),
+			"color":    msg.Color,
+			"title":    msg.Title,
+			"text":     msg.Text,
+			"fields":   fields,
+		}},
+	}
+	return send(ctx, m.Client, m.WebhookURL, m.Formatter, "mattermost", msg, payload)
+}
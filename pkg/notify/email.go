@@ -0,0 +1,69 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// EmailNotifier sends alerts as HTML email through an SMTP server
+type EmailNotifier struct {
+	Addr      string // host:port of the SMTP server
+	From      string
+	To        []string
+	Username  string
+	Password  string
+	Formatter *Formatter
+}
+
+// NewEmailNotifier creates a notifier sending mail through the SMTP server at addr,
+// authenticating when username is not empty
+func NewEmailNotifier(addr, from string, to []string, username, password string, formatter *Formatter) *EmailNotifier {
+	return &EmailNotifier{
+		Addr:      addr,
+		From:      from,
+		To:        to,
+		Username:  username,
+		Password:  password,
+		Formatter: formatter,
+	}
+}
+
+// Notify sends the alert rendered with the email template
+func (e *EmailNotifier) Notify(ctx context.Context, alert Alert) error {
+	msg, err := e.Formatter.Format(alert)
+	if err != nil {
+		return err
+	}
+	body, err := e.Formatter.EmailHTML(msg)
+	if err != nil {
+		return err
+	}
+
+	var mail strings.Builder
+	fmt.Fprintf(&mail, "From: %s\r\n", e.From)
+	fmt.Fprintf(&mail, "To: %s\r\n", strings.Join(e.To, ", "))
+	fmt.Fprintf(&mail, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Title))
+	fmt.Fprintf(&mail, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	mail.WriteString("MIME-Version: 1.0\r\n")
+	mail.WriteString("Content-Type: text/html; charset=utf-8\r\n\r\n")
+	mail.WriteString(body)
+
+	var auth smtp.Auth
+	if e.Username != "" {
+		host, _, err := net.SplitHostPort(e.Addr)
+		if err != nil {
+			return fmt.Errorf("invalid SMTP address %q: %w", e.Addr, err)
+		}
+		auth = smtp.PlainAuth("", e.Username, e.Password, host)
+	}
+
+	if err := smtp.SendMail(e.Addr, auth, e.From, e.To, []byte(mail.String())); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,335 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/snapshot"
+)
+
+// Default templates used to render alert titles, text and email bodies
+const (
+	DefaultTitleTemplate = `{{if .Resolved}}[RESOLVED] {{end}}{{.Title}}`
+	DefaultTextTemplate  = `{{.Message}}{{if .Issue}}{{with .Issue.RunbookURL}}
+Runbook: {{.}}{{end}}{{end}}`
+	DefaultEmailTemplate = `<html><body>
+<h2 style="color: {{.Color}}">{{.RenderedTitle}}</h2>
+<p>{{.RenderedText}}</p>
+{{if .Issue}}{{with .Issue.Suggestion}}<p><b>Suggestion:</b> {{.}}</p>{{end}}
+{{with .Issue.Remediation}}<ol>{{range .}}<li>{{.}}</li>{{end}}</ol>{{end}}{{end}}
+<table>{{range .Fields}}<tr><td><b>{{.Name}}</b></td><td>{{.Value}}</td></tr>{{end}}</table>
+<p style="color: #5f6368">Cluster {{.Cluster.Name}}{{with .Cluster.Version}} ({{.}}){{end}}, {{.Snapshot.ReadyNodes}}/{{.Snapshot.Nodes}} nodes ready, {{.Snapshot.Pods}} pods</p>
+</body></html>`
+)
+
+// Channels whose whole payload can be replaced by a template. Every channel except email
+// must render valid JSON.
+var payloadChannels = []string{"webhook", "slack", "teams", "discord", "mattermost", "email"}
+
+// Templates holds the Go template sources used to render alerts, as loaded from a
+// templates file. Empty fields fall back to the defaults.
+type Templates struct {
+	Title string `json:"title,omitempty"`
+	Text  string `json:"text,omitempty"`
+
+	// Payloads replaces the body a channel posts, keyed by channel name ("webhook",
+	// "slack", "teams", "discord", "mattermost" or "email" for the HTML mail body)
+	Payloads map[string]string `json:"payloads,omitempty"`
+}
+
+// ClusterInfo is the cluster metadata available to templates
+type ClusterInfo struct {
+	Name    string
+	Version string
+}
+
+// SnapshotSummary is the latest cluster snapshot as seen by templates
+type SnapshotSummary struct {
+	TakenAt     time.Time
+	Nodes       int
+	ReadyNodes  int
+	Pods        int
+	PendingPods int
+	FailedPods  int
+	Large       bool
+}
+
+// TemplateData is what alert templates execute against. The alert's fields are promoted,
+// so {{.Title}} and {{.Labels}} work directly.
+type TemplateData struct {
+	Alert
+	Cluster  ClusterInfo
+	Snapshot SnapshotSummary
+
+	// Set for payload templates once the title and text are rendered
+	RenderedTitle string
+	RenderedText  string
+	Color         string
+	Fields        []Field
+}
+
+// Formatter renders alerts into the parts every channel displays, so Slack, Teams,
+// Discord, Mattermost, email and webhooks show issue summaries consistently
+type Formatter struct {
+	title    *template.Template
+	text     *template.Template
+	payloads map[string]*template.Template
+	email    *htmltemplate.Template
+
+	mu       sync.RWMutex
+	cluster  ClusterInfo
+	snapshot SnapshotSummary
+}
+
+// Field is a labelled value shown alongside an alert
+type Field struct {
+	Name  string
+	Value string
+}
+
+// Message is an alert rendered for a chat channel
+type Message struct {
+	Title    string
+	Text     string
+	Severity string
+	Color    string // hex color for the severity, e.g. "#d93025"
+	Fields   []Field
+	Alert    Alert
+}
+
+// templateFuncs are available to alert templates
+var templateFuncs = template.FuncMap{
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"label": func(data TemplateData, key string) string { return data.Labels[key] },
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"time": func(t time.Time) string { return t.Format(time.RFC3339) },
+}
+
+// NewFormatter parses title, text and payload templates
+func NewFormatter(templates Templates) (*Formatter, error) {
+	if templates.Title == "" {
+		templates.Title = DefaultTitleTemplate
+	}
+	if templates.Text == "" {
+		templates.Text = DefaultTextTemplate
+	}
+
+	title, err := template.New("title").Funcs(templateFuncs).Parse(templates.Title)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse title template: %w", err)
+	}
+	text, err := template.New("text").Funcs(templateFuncs).Parse(templates.Text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse text template: %w", err)
+	}
+	f := &Formatter{title: title, text: text, payloads: make(map[string]*template.Template)}
+
+	emailSource := DefaultEmailTemplate
+	for channel, source := range templates.Payloads {
+		if !slices.Contains(payloadChannels, channel) {
+			return nil, fmt.Errorf("unknown payload template channel %q", channel)
+		}
+		if channel == "email" {
+			emailSource = source
+			continue
+		}
+		if f.payloads[channel], err = template.New(channel).Funcs(templateFuncs).Parse(source); err != nil {
+			return nil, fmt.Errorf("failed to parse %s payload template: %w", channel, err)
+		}
+	}
+	if f.email, err = htmltemplate.New("email").Funcs(htmltemplate.FuncMap(templateFuncs)).Parse(emailSource); err != nil {
+		return nil, fmt.Errorf("failed to parse email template: %w", err)
+	}
+
+	return f, nil
+}
+
+// LoadFormatter reads alert templates from a JSON file
+func LoadFormatter(path string) (*Formatter, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read alert templates: %w", err)
+	}
+
+	var templates Templates
+	if err := json.Unmarshal(data, &templates); err != nil {
+		return nil, fmt.Errorf("failed to parse alert templates: %w", err)
+	}
+	return NewFormatter(templates)
+}
+
+// DefaultFormatter renders alerts with the default templates
+var DefaultFormatter, _ = NewFormatter(Templates{})
+
+// SetCluster sets the cluster metadata available to templates
+func (f *Formatter) SetCluster(info ClusterInfo) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.cluster = info
+}
+
+// UpdateSnapshot summarizes the latest cluster snapshot for templates
+func (f *Formatter) UpdateSnapshot(snap *snapshot.ClusterSnapshot) {
+	summary := SnapshotSummary{
+		TakenAt: snap.TakenAt,
+		Nodes:   len(snap.Nodes),
+		Pods:    len(snap.Pods),
+		Large:   snap.Large,
+	}
+	for _, node := range snap.Nodes {
+		for _, condition := range node.Status.Conditions {
+			if condition.Type == v1.NodeReady && condition.Status == v1.ConditionTrue {
+				summary.ReadyNodes++
+			}
+		}
+	}
+	for _, pod := range snap.Pods {
+		switch pod.Status.Phase {
+		case v1.PodPending:
+			summary.PendingPods++
+		case v1.PodFailed:
+			summary.FailedPods++
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.snapshot = summary
+}
+
+// data builds the template data for an alert
+func (f *Formatter) data(alert Alert) TemplateData {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return TemplateData{Alert: alert, Cluster: f.cluster, Snapshot: f.snapshot}
+}
+
+// Format renders an alert. Labels become fields sorted by name, after the source.
+func (f *Formatter) Format(alert Alert) (Message, error) {
+	if f == nil {
+		f = DefaultFormatter
+	}
+	data := f.data(alert)
+
+	var title, text bytes.Buffer
+	if err := f.title.Execute(&title, data); err != nil {
+		return Message{}, fmt.Errorf("failed to render alert title: %w", err)
+	}
+	if err := f.text.Execute(&text, data); err != nil {
+		return Message{}, fmt.Errorf("failed to render alert text: %w", err)
+	}
+
+	fields := make([]Field, 0, len(alert.Labels)+1)
+	if alert.Source != "" {
+		fields = append(fields, Field{Name: "source", Value: alert.Source})
+	}
+	keys := make([]string, 0, len(alert.Labels))
+	for k := range alert.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fields = append(fields, Field{Name: k, Value: alert.Labels[k]})
+	}
+
+	severity := alert.Severity
+	if alert.Resolved {
+		severity = "resolved"
+	}
+
+	return Message{
+		Title:    strings.TrimSpace(title.String()),
+		Text:     strings.TrimSpace(text.String()),
+		Severity: severity,
+		Color:    severityColor(severity),
+		Fields:   fields,
+		Alert:    alert,
+	}, nil
+}
+
+// Payload renders a channel's payload template. It returns false when the channel uses
+// its built-in payload.
+func (f *Formatter) Payload(channel string, msg Message) ([]byte, bool, error) {
+	if f == nil {
+		f = DefaultFormatter
+	}
+	tmpl, ok := f.payloads[channel]
+	if !ok {
+		return nil, false, nil
+	}
+
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, f.messageData(msg)); err != nil {
+		return nil, false, fmt.Errorf("failed to render %s payload: %w", channel, err)
+	}
+	if !json.Valid(body.Bytes()) {
+		return nil, false, fmt.Errorf("%s payload template did not render valid JSON", channel)
+	}
+	return body.Bytes(), true, nil
+}
+
+// EmailHTML renders the HTML body of an alert email
+func (f *Formatter) EmailHTML(msg Message) (string, error) {
+	if f == nil {
+		f = DefaultFormatter
+	}
+
+	var body bytes.Buffer
+	if err := f.email.Execute(&body, f.messageData(msg)); err != nil {
+		return "", fmt.Errorf("failed to render email: %w", err)
+	}
+	return body.String(), nil
+}
+
+// messageData builds the template data for a rendered message
+func (f *Formatter) messageData(msg Message) TemplateData {
+	data := f.data(msg.Alert)
+	data.RenderedTitle = msg.Title
+	data.RenderedText = msg.Text
+	data.Color = msg.Color
+	data.Fields = msg.Fields
+	return data
+}
+
+// severityColor returns the hex color used for a severity
+func severityColor(severity string) string {
+	switch severity {
+	case "critical":
+		return "#d93025"
+	case "warning":
+		return "#f9ab00"
+	case "resolved":
+		return "#1e8e3e"
+	default:
+		return "#1a73e8"
+	}
+}
+
+// severityEmoji returns a Slack-style emoji for a severity
+func severityEmoji(severity string) string {
+	switch severity {
+	case "critical":
+		return ":red_circle:"
+	case "warning":
+		return ":warning:"
+	case "resolved":
+		return ":white_check_mark:"
+	default:
+		return ":information_source:"
+	}
+}
@@ -0,0 +1,155 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultOpsgenieAPIURL is the Opsgenie API for the US region; EU accounts use
+// https://api.eu.opsgenie.com
+const DefaultOpsgenieAPIURL = "https://api.opsgenie.com"
+
+// DefaultSplunkOnCallURL is the Splunk On-Call (VictorOps) REST endpoint integration base URL
+const DefaultSplunkOnCallURL = "https://alert.victorops.com/integrations/generic/20131114/alert"
+
+// opsgenieMaxMessage is the longest message Opsgenie accepts
+const opsgenieMaxMessage = 130
+
+// incidentKey returns the deduplication key for an alert: its fingerprint, or its source
+// and title for alerts raised without one
+func incidentKey(alert Alert) string {
+	if alert.Fingerprint != "" {
+		return alert.Fingerprint
+	}
+	return alert.Source + "/" + alert.Title
+}
+
+// OpsgenieNotifier creates Opsgenie alerts, deduplicated by alias, and closes them when
+// the underlying issue resolves
+type OpsgenieNotifier struct {
+	APIURL     string
+	APIKey     string
+	Priorities map[string]string // severity -> Opsgenie priority (P1-P5)
+	Client     *http.Client
+}
+
+// DefaultOpsgeniePriorities maps alert severities to Opsgenie priorities
+var DefaultOpsgeniePriorities = map[string]string{
+	"critical": "P1",
+	"warning":  "P3",
+	"info":     "P5",
+}
+
+// NewOpsgenieNotifier creates a notifier for the Opsgenie Alert API
+func NewOpsgenieNotifier(apiURL, apiKey string) *OpsgenieNotifier {
+	if apiURL == "" {
+		apiURL = DefaultOpsgenieAPIURL
+	}
+	return &OpsgenieNotifier{
+		APIURL:     strings.TrimSuffix(apiURL, "/"),
+		APIKey:     apiKey,
+		Priorities: DefaultOpsgeniePriorities,
+		Client:     &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Notify creates an Opsgenie alert, or closes the open one when the alert is resolved.
+// Opsgenie increments the count of an open alert with the same alias instead of paging again.
+func (o *OpsgenieNotifier) Notify(ctx context.Context, alert Alert) error {
+	alias := incidentKey(alert)
+	header := http.Header{"Authorization": []string{"GenieKey " + o.APIKey}}
+
+	if alert.Resolved {
+		endpoint := fmt.Sprintf("%s/v2/alerts/%s/close?identifierType=alias", o.APIURL, url.PathEscape(alias))
+		payload := map[string]string{"source": "ochestra-ai", "note": alert.Message}
+		return postJSONWithHeaders(ctx, o.Client, endpoint, header, payload)
+	}
+
+	priority, ok := o.Priorities[alert.Severity]
+	if !ok {
+		priority = "P3"
+	}
+	message := alert.Title
+	if len(message) > opsgenieMaxMessage {
+		message = message[:opsgenieMaxMessage-3] + "..."
+	}
+	tags := []string{alert.Source, alert.Severity}
+	if cluster := alert.Labels["cluster"]; cluster != "" {
+		tags = append(tags, cluster)
+	}
+
+	payload := map[string]interface{}{
+		"message":     message,
+		"alias":       alias,
+		"description": alert.Message,
+		"priority":    priority,
+		"source":      "ochestra-ai",
+		"tags":        tags,
+		"details":     alert.Labels,
+	}
+	return postJSONWithHeaders(ctx, o.Client, o.APIURL+"/v2/alerts", header, payload)
+}
+
+// SplunkOnCallNotifier sends alerts to a Splunk On-Call (formerly VictorOps) REST endpoint
+// integration, keyed by entity ID so repeated alerts update one incident and recoveries
+// resolve it
+type SplunkOnCallNotifier struct {
+	URL          string
+	APIKey       string
+	RoutingKey   string
+	MessageTypes map[string]string // severity -> message type
+	Client       *http.Client
+}
+
+// DefaultSplunkOnCallMessageTypes maps alert severities to Splunk On-Call message types
+var DefaultSplunkOnCallMessageTypes = map[string]string{
+	"critical": "CRITICAL",
+	"warning":  "WARNING",
+	"info":     "INFO",
+}
+
+// NewSplunkOnCallNotifier creates a notifier for the Splunk On-Call REST endpoint
+func NewSplunkOnCallNotifier(apiKey, routingKey string) *SplunkOnCallNotifier {
+	return &SplunkOnCallNotifier{
+		URL:          DefaultSplunkOnCallURL,
+		APIKey:       apiKey,
+		RoutingKey:   routingKey,
+		MessageTypes: DefaultSplunkOnCallMessageTypes,
+		Client:       &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Notify posts the alert, sending a RECOVERY message when it is resolved
+func (s *SplunkOnCallNotifier) Notify(ctx context.Context, alert Alert) error {
+	messageType, ok := s.MessageTypes[alert.Severity]
+	if !ok {
+		messageType = "WARNING"
+	}
+	if alert.Resolved {
+		messageType = "RECOVERY"
+	}
+
+	timestamp := alert.Timestamp
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+
+	payload := map[string]interface{}{
+		"message_type":        messageType,
+		"entity_id":           incidentKey(alert),
+		"entity_display_name": alert.Title,
+		"state_message":       alert.Message,
+		"state_start_time":    timestamp.Unix(),
+		"monitoring_tool":     "ochestra-ai",
+	}
+	for k, v := range alert.Labels {
+		payload["label_"+k] = v
+	}
+
+	endpoint := fmt.Sprintf("%s/%s/%s", strings.TrimSuffix(s.URL, "/"), url.PathEscape(s.APIKey), url.PathEscape(s.RoutingKey))
+	return postJSON(ctx, s.Client, endpoint, payload)
+}
@@ -0,0 +1,163 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/health"
+	"github.com/ochestra-tech/ochestra-ai/pkg/owners"
+)
+
+// Alert is a notification raised by the monitor
+type Alert struct {
+	Title     string            `json:"title"`
+	Message   string            `json:"message"`
+	Severity  string            `json:"severity"` // "critical", "warning", "info"
+	Source    string            `json:"source"`   // subsystem raising the alert, e.g. "budget"
+	Labels    map[string]string `json:"labels,omitempty"`
+	Timestamp time.Time         `json:"timestamp"`
+
+	// Fingerprint identifies the underlying issue across repeated alerts; Resolved marks
+	// the alert sent when that issue clears
+	Fingerprint string `json:"fingerprint,omitempty"`
+	Resolved    bool   `json:"resolved,omitempty"`
+
+	// Issue is the health issue behind the alert, when there is one
+	Issue *health.HealthIssue `json:"issue,omitempty"`
+}
+
+// Notifier delivers alerts to an external channel
+type Notifier interface {
+	Notify(ctx context.Context, alert Alert) error
+}
+
+// MultiNotifier fans an alert out to several notifiers
+type MultiNotifier []Notifier
+
+// Notify sends the alert to every notifier, returning the last error encountered
+func (m MultiNotifier) Notify(ctx context.Context, alert Alert) error {
+	var lastErr error
+	for _, n := range m {
+		if err := n.Notify(ctx, alert); err != nil {
+			log.Printf("Failed to send alert %q: %v", alert.Title, err)
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+// OwnerNotifier adds the owner of an alert's issue to its labels, resolving the owner of
+// issues that have none, so channels and routes can tell which team the alert is for
+type OwnerNotifier struct {
+	Next Notifier
+}
+
+// Notify labels the alert with its owner and passes it on
+func (n OwnerNotifier) Notify(ctx context.Context, alert Alert) error {
+	if alert.Issue != nil {
+		issue := *alert.Issue
+		if issue.Owner == nil {
+			issue.Owner = owners.Resolve(issue.Resource, issue.Namespace, issue.Name)
+		}
+		if issue.Owner != nil {
+			labels := issue.Owner.Labels()
+			for k, v := range alert.Labels {
+				labels[k] = v
+			}
+			alert.Labels = labels
+			alert.Issue = &issue
+		}
+	}
+	return n.Next.Notify(ctx, alert)
+}
+
+// LogNotifier writes alerts to the standard logger
+type LogNotifier struct{}
+
+// Notify logs the alert
+func (LogNotifier) Notify(ctx context.Context, alert Alert) error {
+	log.Printf("ALERT [%s] %s: %s", alert.Severity, alert.Title, alert.Message)
+	return nil
+}
+
+// WebhookNotifier posts alerts as JSON to an HTTP endpoint
+type WebhookNotifier struct {
+	URL       string
+	Formatter *Formatter
+	Client    *http.Client
+}
+
+// NewWebhookNotifier creates a notifier posting to url
+func NewWebhookNotifier(url string, formatter *Formatter) *WebhookNotifier {
+	return &WebhookNotifier{
+		URL:       url,
+		Formatter: formatter,
+		Client:    &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Notify posts the alert as JSON, or the webhook payload template if one is configured
+func (w *WebhookNotifier) Notify(ctx context.Context, alert Alert) error {
+	msg, err := w.Formatter.Format(alert)
+	if err != nil {
+		return err
+	}
+	return send(ctx, w.Client, w.URL, w.Formatter, "webhook", msg, alert)
+}
+
+// send posts a channel's payload template when one is configured, otherwise its
+// built-in payload
+func send(ctx context.Context, client *http.Client, url string, formatter *Formatter, channel string, msg Message, builtin interface{}) error {
+	body, ok, err := formatter.Payload(channel, msg)
+	if err != nil {
+		return err
+	}
+	if ok {
+		return postBody(ctx, client, url, nil, body)
+	}
+	return postJSON(ctx, client, url, builtin)
+}
+
+// postJSON posts a JSON payload and treats non-2xx responses as errors
+func postJSON(ctx context.Context, client *http.Client, url string, payload interface{}) error {
+	return postJSONWithHeaders(ctx, client, url, nil, payload)
+}
+
+// postJSONWithHeaders posts a JSON payload with extra request headers
+func postJSONWithHeaders(ctx context.Context, client *http.Client, url string, header http.Header, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	return postBody(ctx, client, url, header, body)
+}
+
+// postBody posts an encoded JSON body and treats non-2xx responses as errors
+func postBody(ctx context.Context, client *http.Client, url string, header http.Header, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, values := range header {
+		for _, v := range values {
+			req.Header.Add(k, v)
+		}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected response status: %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,290 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"slices"
+	"sync"
+	"time"
+)
+
+// DefaultReceiver names the channels configured with flags, such as --slack-webhook-url
+const DefaultReceiver = "default"
+
+// RoutingConfig is a routing tree loaded from a JSON file. The root route matches every
+// alert, and its receiver gets the alerts none of its child routes match.
+type RoutingConfig struct {
+	Receivers []ReceiverConfig `json:"receivers"`
+	Route     Route            `json:"route"`
+}
+
+// ReceiverConfig is a named set of channels alerts can be routed to. Email is sent
+// through the SMTP server configured with flags.
+type ReceiverConfig struct {
+	Name          string   `json:"name"`
+	WebhookURL    string   `json:"webhookURL,omitempty"`
+	SlackURL      string   `json:"slackWebhookURL,omitempty"`
+	TeamsURL      string   `json:"teamsWebhookURL,omitempty"`
+	DiscordURL    string   `json:"discordWebhookURL,omitempty"`
+	MattermostURL string   `json:"mattermostWebhookURL,omitempty"`
+	Email         []string `json:"email,omitempty"`
+}
+
+// Route sends the alerts it matches to its receiver, unless one of its child routes
+// matches them first. Children are tried in order, and the first match wins unless it
+// sets Continue.
+type Route struct {
+	Name      string     `json:"name,omitempty"`
+	Receiver  string     `json:"receiver,omitempty"` // inherited from the parent route if empty
+	Match     Matcher    `json:"match,omitempty"`
+	Continue  bool       `json:"continue,omitempty"` // also try the following sibling routes
+	RateLimit *RateLimit `json:"rateLimit,omitempty"`
+	Routes    []Route    `json:"routes,omitempty"`
+
+	limiter *limiter
+}
+
+// Matcher selects alerts; every field that is set must match, and empty fields match all
+type Matcher struct {
+	Namespaces []string          `json:"namespaces,omitempty"` // path.Match patterns, e.g. "payments-*"
+	Severities []string          `json:"severities,omitempty"`
+	Sources    []string          `json:"sources,omitempty"` // e.g. "watch", "budget", "slo"
+	Teams      []string          `json:"teams,omitempty"`   // owning team, see OwnerNotifier
+	Labels     map[string]string `json:"labels,omitempty"`  // label -> path.Match pattern
+}
+
+// RateLimit bounds the firing alerts a route delivers. Resolved alerts are always
+// delivered, so incidents opened before the limit was reached can close.
+type RateLimit struct {
+	Alerts int    `json:"alerts"`
+	Per    string `json:"per"` // e.g. "1h"
+}
+
+// limiter counts the alerts a route delivered within its rate limit period
+type limiter struct {
+	alerts int
+	per    time.Duration
+
+	mu   sync.Mutex
+	sent []time.Time
+}
+
+// LoadRoutes reads and validates a routing tree from a JSON file
+func LoadRoutes(path string) (*RoutingConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read alert routes: %w", err)
+	}
+
+	var config RoutingConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse alert routes: %w", err)
+	}
+	if config.Route.Receiver == "" {
+		config.Route.Receiver = DefaultReceiver
+	}
+
+	names := map[string]bool{DefaultReceiver: true}
+	for _, r := range config.Receivers {
+		if r.Name == "" || names[r.Name] {
+			return nil, fmt.Errorf("receiver names must be unique and not empty, got %q", r.Name)
+		}
+		names[r.Name] = true
+	}
+	if err := config.Route.init(names, ""); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// init validates a route and its children, inheriting the parent's receiver
+func (r *Route) init(receivers map[string]bool, parent string) error {
+	if r.Receiver == "" {
+		r.Receiver = parent
+	}
+	if !receivers[r.Receiver] {
+		return fmt.Errorf("route %q: unknown receiver %q", r.Name, r.Receiver)
+	}
+	patterns := append([]string{}, r.Match.Namespaces...)
+	for _, pattern := range r.Match.Labels {
+		patterns = append(patterns, pattern)
+	}
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("route %q: invalid pattern %q: %w", r.Name, pattern, err)
+		}
+	}
+	if r.RateLimit != nil {
+		per, err := time.ParseDuration(r.RateLimit.Per)
+		if err != nil || per <= 0 || r.RateLimit.Alerts < 1 {
+			return fmt.Errorf("route %q: rate limit needs a positive number of alerts and period", r.Name)
+		}
+		r.limiter = &limiter{alerts: r.RateLimit.Alerts, per: per}
+	}
+	for i := range r.Routes {
+		if err := r.Routes[i].init(receivers, r.Receiver); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Notifier creates the notifier sending to the receiver's channels, using email for its
+// SMTP server and sender
+func (c ReceiverConfig) Notifier(formatter *Formatter, email *EmailNotifier) (Notifier, error) {
+	var notifiers MultiNotifier
+	if c.WebhookURL != "" {
+		notifiers = append(notifiers, NewWebhookNotifier(c.WebhookURL, formatter))
+	}
+	if c.SlackURL != "" {
+		notifiers = append(notifiers, NewSlackNotifier(c.SlackURL, formatter))
+	}
+	if c.TeamsURL != "" {
+		notifiers = append(notifiers, NewTeamsNotifier(c.TeamsURL, formatter))
+	}
+	if c.DiscordURL != "" {
+		notifiers = append(notifiers, NewDiscordNotifier(c.DiscordURL, formatter))
+	}
+	if c.MattermostURL != "" {
+		notifiers = append(notifiers, NewMattermostNotifier(c.MattermostURL, formatter))
+	}
+	if len(c.Email) > 0 {
+		if email == nil {
+			return nil, fmt.Errorf("receiver %s sends email, but no SMTP server is configured", c.Name)
+		}
+		notifiers = append(notifiers, NewEmailNotifier(email.Addr, email.From, c.Email, email.Username, email.Password, formatter))
+	}
+	return notifiers, nil
+}
+
+// Router delivers each alert to the receivers of the routes it matches
+type Router struct {
+	route     *Route
+	receivers map[string]Notifier
+}
+
+// NewRouter creates a router for a routing tree. The DefaultReceiver delivers to
+// fallback; the other receivers are created from the configuration.
+func NewRouter(config *RoutingConfig, fallback Notifier, formatter *Formatter, email *EmailNotifier) (*Router, error) {
+	receivers := map[string]Notifier{DefaultReceiver: fallback}
+	for _, r := range config.Receivers {
+		n, err := r.Notifier(formatter, email)
+		if err != nil {
+			return nil, err
+		}
+		receivers[r.Name] = n
+	}
+	return &Router{route: &config.Route, receivers: receivers}, nil
+}
+
+// Notify sends the alert to the receiver of each matching route, once per receiver,
+// returning the last delivery error
+func (r *Router) Notify(ctx context.Context, alert Alert) error {
+	now := alert.Timestamp
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	var lastErr error
+	delivered := make(map[string]bool)
+	for _, route := range r.route.match(alert) {
+		if delivered[route.Receiver] {
+			continue
+		}
+		if !alert.Resolved && !route.limiter.allow(now) {
+			log.Printf("Rate limited alert %q on route %q", alert.Title, route.Name)
+			continue
+		}
+		delivered[route.Receiver] = true
+		if err := r.receivers[route.Receiver].Notify(ctx, alert); err != nil {
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+// match returns the deepest routes matching an alert, or the route itself when none of
+// its children match
+func (r *Route) match(alert Alert) []*Route {
+	var matched []*Route
+	for i := range r.Routes {
+		child := &r.Routes[i]
+		if !child.Match.matches(alert) {
+			continue
+		}
+		matched = append(matched, child.match(alert)...)
+		if !child.Continue {
+			break
+		}
+	}
+	if len(matched) == 0 {
+		return []*Route{r}
+	}
+	return matched
+}
+
+// matches reports whether an alert satisfies every field of the matcher
+func (m Matcher) matches(alert Alert) bool {
+	namespace, team := alert.Labels["namespace"], alert.Labels["team"]
+	if alert.Issue != nil {
+		if namespace == "" {
+			namespace = alert.Issue.Namespace
+		}
+		if team == "" && alert.Issue.Owner != nil {
+			team = alert.Issue.Owner.Team
+		}
+	}
+
+	if len(m.Namespaces) > 0 && !matchAny(m.Namespaces, namespace) {
+		return false
+	}
+	if len(m.Severities) > 0 && !slices.Contains(m.Severities, alert.Severity) {
+		return false
+	}
+	if len(m.Sources) > 0 && !slices.Contains(m.Sources, alert.Source) {
+		return false
+	}
+	if len(m.Teams) > 0 && !slices.Contains(m.Teams, team) {
+		return false
+	}
+	for label, pattern := range m.Labels {
+		if ok, _ := path.Match(pattern, alert.Labels[label]); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// matchAny reports whether value matches any of the path.Match patterns
+func matchAny(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, value); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// allow records a delivery at now and reports whether it is within the rate limit. A nil
+// limiter allows everything.
+func (l *limiter) allow(now time.Time) bool {
+	if l == nil {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	cutoff := now.Add(-l.per)
+	for len(l.sent) > 0 && !l.sent[0].After(cutoff) {
+		l.sent = l.sent[1:]
+	}
+	if len(l.sent) >= l.alerts {
+		return false
+	}
+	l.sent = append(l.sent, now)
+	return true
+}
@@ -0,0 +1,220 @@
+package optimizer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/archive"
+	"github.com/ochestra-tech/ochestra-ai/pkg/resources"
+)
+
+// CleanupBackupRetention is how long local cleanup backups are kept
+var CleanupBackupRetention = 30 * 24 * time.Hour
+
+// CleanupBackup is the manifest of a resource as it was before cleanup deleted it
+type CleanupBackup struct {
+	ID        string          `json:"id"`
+	Rule      string          `json:"rule"`
+	Kind      string          `json:"kind"`
+	Namespace string          `json:"namespace"`
+	Name      string          `json:"name"`
+	DeletedAt time.Time       `json:"deletedAt"`
+	Manifest  json.RawMessage `json:"manifest"`
+}
+
+// CleanupBackups stores the manifests of resources before cleanup deletes them, in a
+// local directory and, with an archiver, in object storage
+type CleanupBackups struct {
+	Dir      string
+	Archiver *archive.Archiver
+}
+
+// CleanupBackupID names the backup of a resource deleted at t, e.g.
+// 20240501T020000Z-job-ci-web-build-1842
+func CleanupBackupID(rec CleanupRecommendation, t time.Time) string {
+	return fmt.Sprintf("%s-%s-%s-%s", t.UTC().Format("20060102T150405Z"), strings.ToLower(rec.ResourceType), rec.Namespace, rec.Name)
+}
+
+// Save reads the resource a cleanup recommendation selects and stores its manifest,
+// returning the backup ID. Deletion must not go ahead if it fails.
+func (b *CleanupBackups) Save(ctx context.Context, clientset kubernetes.Interface, rec CleanupRecommendation, now time.Time) (string, error) {
+	obj, err := getCleanupResource(ctx, clientset, rec)
+	if err != nil {
+		return "", err
+	}
+	return b.save(ctx, rec, obj, now)
+}
+
+// save stores the manifest of a resource already read
+func (b *CleanupBackups) save(ctx context.Context, rec CleanupRecommendation, obj metav1.Object, now time.Time) (string, error) {
+	manifest, err := json.Marshal(obj)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal %s %s/%s: %w", rec.ResourceType, rec.Namespace, rec.Name, err)
+	}
+
+	backup := CleanupBackup{
+		ID:        CleanupBackupID(rec, now),
+		Rule:      rec.Rule,
+		Kind:      rec.ResourceType,
+		Namespace: rec.Namespace,
+		Name:      rec.Name,
+		DeletedAt: now,
+		Manifest:  manifest,
+	}
+	data, err := json.MarshalIndent(backup, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal backup: %w", err)
+	}
+	if err := os.MkdirAll(b.Dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(b.Dir, backup.ID+".json"), data, 0600); err != nil {
+		return "", fmt.Errorf("failed to write backup: %w", err)
+	}
+	if b.Archiver != nil {
+		if _, err := b.Archiver.ArchiveObject(ctx, archive.KindCleanupBackup, backup.ID, backup); err != nil {
+			return "", err
+		}
+	}
+	return backup.ID, nil
+}
+
+// Prune removes local backups older than CleanupBackupRetention
+func (b *CleanupBackups) Prune(now time.Time) {
+	backups, err := ListCleanupBackups(b.Dir)
+	if err != nil {
+		log.Printf("Failed to list cleanup backups: %v", err)
+		return
+	}
+	for _, backup := range backups {
+		if now.Sub(backup.DeletedAt) > CleanupBackupRetention {
+			if err := os.Remove(filepath.Join(b.Dir, backup.ID+".json")); err != nil {
+				log.Printf("Failed to remove cleanup backup %s: %v", backup.ID, err)
+			}
+		}
+	}
+}
+
+// LoadCleanupBackup reads a backup from a local backup directory
+func LoadCleanupBackup(dir, id string) (*CleanupBackup, error) {
+	data, err := os.ReadFile(filepath.Join(dir, filepath.Base(id)+".json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup %s: %w", id, err)
+	}
+	var backup CleanupBackup
+	if err := json.Unmarshal(data, &backup); err != nil {
+		return nil, fmt.Errorf("failed to parse backup %s: %w", id, err)
+	}
+	return &backup, nil
+}
+
+// ListCleanupBackups returns the backups in a local directory, newest first
+func ListCleanupBackups(dir string) ([]CleanupBackup, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup directory: %w", err)
+	}
+
+	backups := make([]CleanupBackup, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		backup, err := LoadCleanupBackup(dir, strings.TrimSuffix(entry.Name(), ".json"))
+		if err != nil {
+			log.Printf("Skipping cleanup backup: %v", err)
+			continue
+		}
+		backup.Manifest = nil
+		backups = append(backups, *backup)
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].DeletedAt.After(backups[j].DeletedAt) })
+	return backups, nil
+}
+
+// RestoreCleanupBackup re-creates a deleted resource from its backup. Server-set metadata
+// and status are dropped, as are the fields the API server generates for Jobs and Pods.
+func RestoreCleanupBackup(ctx context.Context, clientset kubernetes.Interface, backup *CleanupBackup) error {
+	var err error
+	switch backup.Kind {
+	case "Pod":
+		var pod v1.Pod
+		if err = json.Unmarshal(backup.Manifest, &pod); err == nil {
+			restorableMeta(&pod.ObjectMeta)
+			pod.Spec.NodeName = "" // the node may be gone; let the scheduler place it
+			pod.Status = v1.PodStatus{}
+			_, err = clientset.CoreV1().Pods(backup.Namespace).Create(ctx, &pod, metav1.CreateOptions{})
+		}
+	case "Job":
+		var job batchv1.Job
+		if err = json.Unmarshal(backup.Manifest, &job); err == nil {
+			restorableMeta(&job.ObjectMeta)
+			// The selector and its labels carry the old Job's UID; the API server sets new ones
+			job.Spec.Selector = nil
+			for _, labels := range []map[string]string{job.Labels, job.Spec.Template.Labels} {
+				delete(labels, "controller-uid")
+				delete(labels, "batch.kubernetes.io/controller-uid")
+			}
+			job.Status = batchv1.JobStatus{}
+			_, err = clientset.BatchV1().Jobs(backup.Namespace).Create(ctx, &job, metav1.CreateOptions{})
+		}
+	case "ConfigMap":
+		var cm v1.ConfigMap
+		if err = json.Unmarshal(backup.Manifest, &cm); err == nil {
+			restorableMeta(&cm.ObjectMeta)
+			_, err = clientset.CoreV1().ConfigMaps(backup.Namespace).Create(ctx, &cm, metav1.CreateOptions{})
+		}
+	case "ReplicaSet":
+		var rs appsv1.ReplicaSet
+		if err = json.Unmarshal(backup.Manifest, &rs); err == nil {
+			restorableMeta(&rs.ObjectMeta)
+			rs.Status = appsv1.ReplicaSetStatus{}
+			_, err = clientset.AppsV1().ReplicaSets(backup.Namespace).Create(ctx, &rs, metav1.CreateOptions{})
+		}
+	default:
+		var client *resources.Client
+		var res resources.Resource
+		if client, res, err = resolveResource(backup.Kind); err != nil {
+			return err
+		}
+		var obj unstructured.Unstructured
+		if err = obj.UnmarshalJSON(backup.Manifest); err == nil {
+			restorableMeta(&obj)
+			unstructured.RemoveNestedField(obj.Object, "status")
+			err = client.Create(ctx, res, &obj)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to restore %s %s/%s: %w", backup.Kind, backup.Namespace, backup.Name, err)
+	}
+	return nil
+}
+
+// restorableMeta strips the metadata the API server sets, so a backup can be created again
+func restorableMeta(meta metav1.Object) {
+	meta.SetUID("")
+	meta.SetResourceVersion("")
+	meta.SetGeneration(0)
+	meta.SetCreationTimestamp(metav1.Time{})
+	meta.SetManagedFields(nil)
+	meta.SetDeletionTimestamp(nil)
+	meta.SetDeletionGracePeriodSeconds(nil)
+	meta.SetFinalizers(nil)
+}
@@ -0,0 +1,342 @@
+package optimizer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/flowcontrol"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/auth"
+	"github.com/ochestra-tech/ochestra-ai/pkg/protection"
+)
+
+// Cleanup batch item statuses
+const (
+	BatchPending = "pending"
+	BatchDeleted = "deleted"
+	BatchFailed  = "failed"
+	BatchSkipped = "skipped" // protected or recreated when it came to be deleted
+)
+
+// CleanupBatchOptions bounds how fast a batch deletes
+type CleanupBatchOptions struct {
+	Concurrency int     // deletions in flight
+	QPS         float64 // deletions started per second; 0 means unlimited
+	// OnDone is called as each deletion finishes, from the worker that ran it
+	OnDone func(item CleanupBatchItem)
+}
+
+// DefaultCleanupBatchOptions deletes four at a time, at most ten per second
+var DefaultCleanupBatchOptions = CleanupBatchOptions{Concurrency: 4, QPS: 10}
+
+// CleanupBatchItem is one resource in a deletion batch
+type CleanupBatchItem struct {
+	Rule      string    `json:"rule"`
+	Kind      string    `json:"kind"`
+	Namespace string    `json:"namespace"`
+	Name      string    `json:"name"`
+	UID       types.UID `json:"uid,omitempty"`
+	Status    string    `json:"status"`
+	BackupID  string    `json:"backupId,omitempty"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// Recommendation returns the cleanup recommendation the item deletes
+func (i CleanupBatchItem) Recommendation() CleanupRecommendation {
+	return CleanupRecommendation{Rule: i.Rule, ResourceType: i.Kind, Namespace: i.Namespace, Name: i.Name, UID: i.UID}
+}
+
+// CleanupBatchProgress reports how far the current batch has got
+type CleanupBatchProgress struct {
+	Running    bool               `json:"running"`
+	StartedAt  time.Time          `json:"startedAt"`
+	FinishedAt *time.Time         `json:"finishedAt,omitempty"`
+	Total      int                `json:"total"`
+	Deleted    int                `json:"deleted"`
+	Failed     int                `json:"failed"`
+	Pending    int                `json:"pending"`
+	Skipped    int                `json:"skipped"`
+	Rate       float64            `json:"rate"`                // deletions finished per second
+	Remaining  string             `json:"remaining,omitempty"` // estimated at the current rate
+	Failures   []CleanupBatchItem `json:"failures,omitempty"`
+}
+
+// CleanupBatch deletes what cleanup selected with a pool of workers, checkpointing its
+// progress to a state file so a batch interrupted by a restart resumes where it stopped
+type CleanupBatch struct {
+	path string
+
+	mu       sync.Mutex
+	running  bool
+	lastSave time.Time
+	state    struct {
+		StartedAt  time.Time           `json:"startedAt"`
+		FinishedAt *time.Time          `json:"finishedAt,omitempty"`
+		Items      []*CleanupBatchItem `json:"items"`
+	}
+}
+
+// LoadCleanupBatch creates a batch, restoring the one persisted at path if it is not empty
+func LoadCleanupBatch(path string) (*CleanupBatch, error) {
+	b := &CleanupBatch{path: path}
+	if path == "" {
+		return b, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return b, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cleanup batch: %w", err)
+	}
+	if err := json.Unmarshal(data, &b.state); err != nil {
+		return nil, fmt.Errorf("failed to parse cleanup batch: %w", err)
+	}
+	if pending := b.count(BatchPending); pending > 0 {
+		log.Printf("Cleanup batch started %s has %d pending deletions; resuming it on the next cleanup run",
+			b.state.StartedAt.Format(time.RFC3339), pending)
+	}
+	return b, nil
+}
+
+// Start begins a batch with the recommendations, leaving out protected ones. The pending
+// deletions of an unfinished batch, e.g. one interrupted by a restart, are carried over
+// only while the recommendations still select the same object: it may have left the
+// rule's condition, or been recreated, since the batch was persisted. It returns false,
+// leaving the batch alone, if one is running.
+func (b *CleanupBatch) Start(recs []CleanupRecommendation, now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.running {
+		return false
+	}
+	selected := make(map[string]bool, len(recs))
+	for _, rec := range recs {
+		if rec.Protected == "" {
+			selected[cleanupKey(rec)] = true
+		}
+	}
+	items := make([]*CleanupBatchItem, 0, len(recs))
+	queued := make(map[string]bool)
+	dropped := 0
+	for _, item := range b.state.Items {
+		if item.Status != BatchPending {
+			continue
+		}
+		key := cleanupKey(item.Recommendation())
+		if !selected[key] {
+			dropped++
+			continue
+		}
+		items = append(items, item)
+		queued[key] = true
+	}
+	if dropped > 0 {
+		log.Printf("Cleanup batch: dropped %d pending deletions the policy no longer selects", dropped)
+	}
+	for _, rec := range recs {
+		if rec.Protected != "" {
+			continue
+		}
+		if key := cleanupKey(rec); !queued[key] {
+			queued[key] = true
+			items = append(items, &CleanupBatchItem{
+				Rule:      rec.Rule,
+				Kind:      rec.ResourceType,
+				Namespace: rec.Namespace,
+				Name:      rec.Name,
+				UID:       rec.UID,
+				Status:    BatchPending,
+			})
+		}
+	}
+
+	b.state.StartedAt, b.state.FinishedAt, b.state.Items = now, nil, items
+	b.running = true
+	b.saveLocked(now)
+	return true
+}
+
+// Run deletes the pending items of the started batch, backing each up first when backups
+// is set, and returns how many it deleted. Resources already gone count as deleted, since
+// a restart can leave an item pending after its deletion went through.
+func (b *CleanupBatch) Run(ctx context.Context, clientset kubernetes.Interface, backups *CleanupBackups, opts CleanupBatchOptions) int {
+	b.mu.Lock()
+	pending := make([]*CleanupBatchItem, 0, len(b.state.Items))
+	for _, item := range b.state.Items {
+		if item.Status == BatchPending {
+			pending = append(pending, item)
+		}
+	}
+	b.mu.Unlock()
+
+	var limiter flowcontrol.RateLimiter
+	if opts.QPS > 0 {
+		limiter = flowcontrol.NewTokenBucketRateLimiter(float32(opts.QPS), max(int(opts.QPS), 1))
+	}
+	limit := max(opts.Concurrency, 1)
+
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for _, item := range pending {
+		if limiter != nil {
+			if err := limiter.Wait(ctx); err != nil {
+				break
+			}
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(item *CleanupBatchItem) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			b.delete(ctx, clientset, backups, item, opts.OnDone)
+		}(item)
+	}
+	wg.Wait()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := time.Now()
+	if b.count(BatchPending) == 0 {
+		b.state.FinishedAt = &now
+	}
+	b.running = false
+	b.lastSave = time.Time{}
+	b.saveLocked(now)
+	return b.count(BatchDeleted)
+}
+
+// delete runs one item and records its outcome
+func (b *CleanupBatch) delete(ctx context.Context, clientset kubernetes.Interface, backups *CleanupBackups, item *CleanupBatchItem, onDone func(CleanupBatchItem)) {
+	rec := item.Recommendation()
+	backupID, err := DeleteCleanupResource(ctx, clientset, rec, backups)
+	if apierrors.IsNotFound(err) {
+		err = nil
+	}
+
+	b.mu.Lock()
+	item.BackupID = backupID
+	if errors.Is(err, protection.ErrProtected) || errors.Is(err, ErrCleanupReplaced) {
+		item.Status, item.Error = BatchSkipped, err.Error()
+		log.Printf("Skipped deleting %v", err)
+	} else if err != nil {
+		item.Status, item.Error = BatchFailed, err.Error()
+		log.Printf("Failed to delete %s %s/%s: %v", item.Kind, item.Namespace, item.Name, err)
+	} else {
+		item.Status = BatchDeleted
+	}
+	done := *item
+	now := time.Now()
+	if now.Sub(b.lastSave) >= 10*time.Second {
+		progress := b.progressLocked(now, nil)
+		log.Printf("Cleanup batch: %d of %d deleted, %d failed, %s remaining",
+			progress.Deleted, progress.Total, progress.Failed, progress.Remaining)
+		b.saveLocked(now)
+	}
+	b.mu.Unlock()
+
+	if onDone != nil {
+		onDone(done)
+	}
+}
+
+// Progress reports the current batch. Failures are limited to the namespaces allowed
+// accepts; nil allows all.
+func (b *CleanupBatch) Progress(allowed func(namespace string) bool) CleanupBatchProgress {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.progressLocked(time.Now(), allowed)
+}
+
+// progressLocked builds the progress report. The caller holds the lock.
+func (b *CleanupBatch) progressLocked(now time.Time, allowed func(namespace string) bool) CleanupBatchProgress {
+	p := CleanupBatchProgress{
+		Running:    b.running,
+		StartedAt:  b.state.StartedAt,
+		FinishedAt: b.state.FinishedAt,
+		Total:      len(b.state.Items),
+		Deleted:    b.count(BatchDeleted),
+		Failed:     b.count(BatchFailed),
+		Pending:    b.count(BatchPending),
+		Skipped:    b.count(BatchSkipped),
+	}
+	for _, item := range b.state.Items {
+		if item.Status == BatchFailed && (allowed == nil || allowed(item.Namespace)) {
+			p.Failures = append(p.Failures, *item)
+		}
+	}
+
+	end := now
+	if p.FinishedAt != nil {
+		end = *p.FinishedAt
+	}
+	if elapsed := end.Sub(p.StartedAt).Seconds(); elapsed > 0 && !p.StartedAt.IsZero() {
+		p.Rate = float64(p.Deleted+p.Failed) / elapsed
+	}
+	if p.Running && p.Rate > 0 {
+		p.Remaining = (time.Duration(float64(p.Pending)/p.Rate) * time.Second).Round(time.Second).String()
+	}
+	return p
+}
+
+// ServeHTTP serves GET /cleanup/progress
+func (b *CleanupBatch) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var allowed func(string) bool
+	if scope, ok := auth.ScopeFrom(r.Context()); ok {
+		allowed = scope.Allows
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(b.Progress(allowed))
+}
+
+// cleanupKey identifies the object a recommendation selects
+func cleanupKey(rec CleanupRecommendation) string {
+	return rec.ResourceType + "/" + rec.Namespace + "/" + rec.Name + "/" + string(rec.UID)
+}
+
+// count returns the number of items with a status. The caller holds the lock.
+func (b *CleanupBatch) count(status string) int {
+	n := 0
+	for _, item := range b.state.Items {
+		if item.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// saveLocked checkpoints the batch if it has a state file. The caller holds the lock.
+func (b *CleanupBatch) saveLocked(now time.Time) {
+	b.lastSave = now
+	if b.path == "" {
+		return
+	}
+	data, err := json.MarshalIndent(b.state, "", "  ")
+	if err != nil {
+		log.Printf("Failed to marshal cleanup batch: %v", err)
+		return
+	}
+	if err := os.WriteFile(b.path, data, 0644); err != nil {
+		log.Printf("Failed to write cleanup batch: %v", err)
+	}
+}
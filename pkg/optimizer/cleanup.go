@@ -0,0 +1,568 @@
+package optimizer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"slices"
+	"sort"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/protection"
+	"github.com/ochestra-tech/ochestra-ai/pkg/resources"
+	"github.com/ochestra-tech/ochestra-ai/pkg/retry"
+	"github.com/ochestra-tech/ochestra-ai/pkg/rules"
+	"github.com/ochestra-tech/ochestra-ai/pkg/snapshot"
+)
+
+// CleanupRule selects resources of one kind in a condition, e.g. completed Jobs in ci-*
+// namespaces, for deletion once they have been in it longer than TTL
+type CleanupRule struct {
+	Name              string   `json:"name"`
+	Kind              string   `json:"kind"`                        // Pod, Job, ConfigMap, ReplicaSet or any namespaced kind, e.g. "Certificate.cert-manager.io"
+	Condition         string   `json:"condition,omitempty"`         // see cleanupConditions; defaults to the kind's first
+	Match             string   `json:"match,omitempty"`             // CEL expression on "object"; required for kinds without conditions
+	Namespaces        []string `json:"namespaces,omitempty"`        // path.Match patterns; empty means all
+	ExcludeNamespaces []string `json:"excludeNamespaces,omitempty"` // path.Match patterns
+	TTL               string   `json:"ttl"`                         // e.g. "24h"; "0s" matches at once
+
+	ttl   time.Duration
+	match *rules.Program
+}
+
+// CleanupPolicy is an ordered list of cleanup rules. A resource is attributed to the
+// first rule it matches.
+type CleanupPolicy struct {
+	Rules []CleanupRule `json:"rules"`
+}
+
+// cleanupConditions are the conditions each kind supports. Pods and Jobs age from when
+// they finished, ConfigMaps and ReplicaSets from their creation.
+var cleanupConditions = map[string][]string{
+	"Pod":        {"finished", "failed", "succeeded"},
+	"Job":        {"finished", "failed", "complete"},
+	"ConfigMap":  {"unreferenced"},
+	"ReplicaSet": {"scaled-down"}, // old Deployment revisions at zero replicas
+}
+
+// matchedCondition is the only condition of other kinds: the rule's match expression
+// selects them, and they age from their creation
+const matchedCondition = "matched"
+
+// DefaultCleanupPolicy is used without a policy file: finished pods after 7 days and
+// ConfigMaps no pod references outside the system namespaces
+var DefaultCleanupPolicy = CleanupPolicy{Rules: []CleanupRule{
+	{Name: "finished-pods", Kind: "Pod", Condition: "finished", TTL: "168h", ttl: 7 * 24 * time.Hour},
+	{Name: "unreferenced-configmaps", Kind: "ConfigMap", Condition: "unreferenced", TTL: "0s",
+		ExcludeNamespaces: []string{"kube-system", "kube-public", "kube-node-lease"}},
+}}
+
+// LoadCleanupPolicy reads cleanup rules from a JSON file
+func LoadCleanupPolicy(path string) (*CleanupPolicy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cleanup policy: %w", err)
+	}
+
+	var policy CleanupPolicy
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("failed to parse cleanup policy: %w", err)
+	}
+	for i := range policy.Rules {
+		if err := policy.Rules[i].init(); err != nil {
+			return nil, fmt.Errorf("cleanup rule %d: %w", i, err)
+		}
+	}
+	return &policy, nil
+}
+
+// init validates a rule and parses its TTL
+func (r *CleanupRule) init() error {
+	if r.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if r.Kind == "" {
+		return fmt.Errorf("%s: kind is required", r.Name)
+	}
+	conditions, ok := cleanupConditions[r.Kind]
+	if !ok {
+		// Deleting arbitrary kinds by age alone is too broad, so they need an expression
+		if r.Match == "" {
+			return fmt.Errorf("%s: kind %s needs a match expression", r.Name, r.Kind)
+		}
+		conditions = []string{matchedCondition}
+	}
+	if r.Match != "" {
+		program, err := rules.Compile(r.Match, rules.Variable)
+		if err != nil {
+			return fmt.Errorf("%s: invalid match: %w", r.Name, err)
+		}
+		r.match = program
+	}
+	if r.Condition == "" {
+		r.Condition = conditions[0]
+	}
+	if !slices.Contains(conditions, r.Condition) {
+		return fmt.Errorf("%s: unsupported condition %q for %s, want one of %v", r.Name, r.Condition, r.Kind, conditions)
+	}
+	for _, pattern := range append(append([]string{}, r.Namespaces...), r.ExcludeNamespaces...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("%s: invalid namespace pattern %q", r.Name, pattern)
+		}
+	}
+	ttl, err := time.ParseDuration(r.TTL)
+	if err != nil || ttl < 0 {
+		return fmt.Errorf("%s: invalid ttl %q", r.Name, r.TTL)
+	}
+	r.ttl = ttl
+	return nil
+}
+
+// covers reports whether the rule applies to a namespace
+func (r *CleanupRule) covers(namespace string) bool {
+	for _, pattern := range r.ExcludeNamespaces {
+		if ok, _ := path.Match(pattern, namespace); ok {
+			return false
+		}
+	}
+	if len(r.Namespaces) == 0 {
+		return true
+	}
+	for _, pattern := range r.Namespaces {
+		if ok, _ := path.Match(pattern, namespace); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// matches reports whether a candidate satisfies the rule's match expression, if any.
+// Candidates the expression fails to evaluate on do not match.
+func (r *CleanupRule) matches(c cleanupCandidate) bool {
+	if r.match == nil {
+		return true
+	}
+	fields := c.fields
+	if fields == nil {
+		fields = map[string]interface{}{"metadata": map[string]interface{}{"namespace": c.namespace, "name": c.name, "labels": c.labels}}
+	}
+	ok, err := r.match.EvalBool(map[string]interface{}{rules.Variable: fields})
+	return err == nil && ok
+}
+
+// ErrCleanupReplaced is returned for a resource deleted and created again under the same
+// name since cleanup selected it
+var ErrCleanupReplaced = errors.New("recreated since it was selected")
+
+// CleanupRecommendation is a resource a cleanup rule selects for deletion
+type CleanupRecommendation struct {
+	Rule         string
+	ResourceType string
+	Namespace    string
+	Name         string
+	UID          types.UID // of the selected object, which one recreated under its name does not share
+	Reason       string
+	Age          time.Duration // time in the rule's condition
+	Protected    string        // why the resource may not be deleted; reported, but never deleted
+}
+
+// cleanupCandidate is a resource in one of its kind's conditions
+type cleanupCandidate struct {
+	namespace, name string
+	uid             types.UID
+	conditions      []string
+	since           time.Time
+	reason          string
+	labels          map[string]string
+	fields          map[string]interface{} // the object, for match expressions
+}
+
+// Evaluate returns the resources the policy's rules select at now, reading pods from the
+// snapshot and listing the other kinds its rules need
+func (p *CleanupPolicy) Evaluate(ctx context.Context, clientset kubernetes.Interface, snap *snapshot.ClusterSnapshot, now time.Time) ([]CleanupRecommendation, error) {
+	candidates := make(map[string][]cleanupCandidate)
+	for _, rule := range p.Rules {
+		if _, ok := candidates[rule.Kind]; ok {
+			continue
+		}
+		var err error
+		switch rule.Kind {
+		case "Pod":
+			candidates[rule.Kind] = podCleanupCandidates(snap.Pods)
+		case "Job":
+			candidates[rule.Kind], err = jobCleanupCandidates(ctx, clientset)
+		case "ConfigMap":
+			candidates[rule.Kind], err = configMapCleanupCandidates(ctx, clientset, snap.Pods)
+		case "ReplicaSet":
+			candidates[rule.Kind], err = replicaSetCleanupCandidates(ctx, clientset)
+		default:
+			candidates[rule.Kind], err = resourceCleanupCandidates(ctx, rule.Kind)
+			if errors.Is(err, resources.ErrNotServed) {
+				log.Printf("Cleanup rule %s skipped: %v", rule.Name, err)
+				err = nil
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	recommendations := make([]CleanupRecommendation, 0)
+	seen := make(map[string]bool)
+	for _, rule := range p.Rules {
+		for _, c := range candidates[rule.Kind] {
+			key := rule.Kind + "/" + c.namespace + "/" + c.name
+			age := now.Sub(c.since)
+			if seen[key] || !slices.Contains(c.conditions, rule.Condition) || !rule.covers(c.namespace) || age < rule.ttl || !rule.matches(c) {
+				continue
+			}
+			seen[key] = true
+			recommendations = append(recommendations, CleanupRecommendation{
+				Rule:         rule.Name,
+				ResourceType: rule.Kind,
+				Namespace:    c.namespace,
+				Name:         c.name,
+				UID:          c.uid,
+				Reason:       c.reason,
+				Age:          age,
+				Protected:    protection.Reason(c.namespace, c.labels),
+			})
+		}
+	}
+	sort.SliceStable(recommendations, func(i, j int) bool {
+		a, b := recommendations[i], recommendations[j]
+		if a.ResourceType != b.ResourceType {
+			return a.ResourceType < b.ResourceType
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+	return recommendations, nil
+}
+
+// podCleanupCandidates returns finished pods, aged from their last container exit
+func podCleanupCandidates(pods []v1.Pod) []cleanupCandidate {
+	candidates := make([]cleanupCandidate, 0)
+	for _, pod := range pods {
+		if pod.Status.Phase != v1.PodFailed && pod.Status.Phase != v1.PodSucceeded {
+			continue
+		}
+		condition := "succeeded"
+		if pod.Status.Phase == v1.PodFailed {
+			condition = "failed"
+		}
+		since := pod.CreationTimestamp.Time
+		for _, status := range pod.Status.ContainerStatuses {
+			if t := status.State.Terminated; t != nil && t.FinishedAt.After(since) {
+				since = t.FinishedAt.Time
+			}
+		}
+		candidates = append(candidates, cleanupCandidate{
+			namespace:  pod.Namespace,
+			name:       pod.Name,
+			uid:        pod.UID,
+			conditions: []string{"finished", condition},
+			since:      since,
+			reason:     fmt.Sprintf("Pod %s", pod.Status.Phase),
+			labels:     pod.Labels,
+		})
+	}
+	return candidates
+}
+
+// jobCleanupCandidates returns finished Jobs, aged from their completion or failure
+func jobCleanupCandidates(ctx context.Context, clientset kubernetes.Interface) ([]cleanupCandidate, error) {
+	jobs, err := retry.Value(ctx, retry.DefaultBackoff, func() (*batchv1.JobList, error) {
+		return clientset.BatchV1().Jobs("").List(ctx, metav1.ListOptions{})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+
+	candidates := make([]cleanupCandidate, 0)
+	for _, job := range jobs.Items {
+		for _, c := range job.Status.Conditions {
+			if c.Status != v1.ConditionTrue || (c.Type != batchv1.JobComplete && c.Type != batchv1.JobFailed) {
+				continue
+			}
+			condition, reason := "complete", "Job completed"
+			if c.Type == batchv1.JobFailed {
+				condition, reason = "failed", fmt.Sprintf("Job failed (%s)", c.Reason)
+			}
+			candidates = append(candidates, cleanupCandidate{
+				namespace:  job.Namespace,
+				name:       job.Name,
+				uid:        job.UID,
+				conditions: []string{"finished", condition},
+				since:      c.LastTransitionTime.Time,
+				reason:     reason,
+				labels:     job.Labels,
+			})
+			break
+		}
+	}
+	return candidates, nil
+}
+
+// configMapCleanupCandidates returns ConfigMaps no pod mounts or reads into its environment
+func configMapCleanupCandidates(ctx context.Context, clientset kubernetes.Interface, pods []v1.Pod) ([]cleanupCandidate, error) {
+	// Only names and ages are needed, so skip transferring ConfigMap data
+	configMaps, err := snapshot.ListMetadata(ctx, clientset, "configmaps", "", metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list configmaps: %w", err)
+	}
+
+	inUse := make(map[string]bool)
+	for _, pod := range pods {
+		for _, volume := range pod.Spec.Volumes {
+			if volume.ConfigMap != nil {
+				inUse[pod.Namespace+"/"+volume.ConfigMap.Name] = true
+			}
+			if volume.Projected != nil {
+				for _, source := range volume.Projected.Sources {
+					if source.ConfigMap != nil {
+						inUse[pod.Namespace+"/"+source.ConfigMap.Name] = true
+					}
+				}
+			}
+		}
+		for _, container := range append(append([]v1.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...) {
+			for _, env := range container.Env {
+				if env.ValueFrom != nil && env.ValueFrom.ConfigMapKeyRef != nil {
+					inUse[pod.Namespace+"/"+env.ValueFrom.ConfigMapKeyRef.Name] = true
+				}
+			}
+			for _, from := range container.EnvFrom {
+				if from.ConfigMapRef != nil {
+					inUse[pod.Namespace+"/"+from.ConfigMapRef.Name] = true
+				}
+			}
+		}
+	}
+
+	candidates := make([]cleanupCandidate, 0)
+	for _, cm := range configMaps {
+		if inUse[cm.Namespace+"/"+cm.Name] {
+			continue
+		}
+		candidates = append(candidates, cleanupCandidate{
+			namespace:  cm.Namespace,
+			name:       cm.Name,
+			uid:        cm.UID,
+			conditions: []string{"unreferenced"},
+			since:      cm.CreationTimestamp.Time,
+			reason:     "Not referenced by any pod",
+			labels:     cm.Labels,
+		})
+	}
+	return candidates, nil
+}
+
+// replicaSetCleanupCandidates returns Deployment-owned ReplicaSets scaled to zero
+func replicaSetCleanupCandidates(ctx context.Context, clientset kubernetes.Interface) ([]cleanupCandidate, error) {
+	replicaSets, err := retry.Value(ctx, retry.DefaultBackoff, func() (*appsv1.ReplicaSetList, error) {
+		return clientset.AppsV1().ReplicaSets("").List(ctx, metav1.ListOptions{})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list replicasets: %w", err)
+	}
+
+	candidates := make([]cleanupCandidate, 0)
+	for _, rs := range replicaSets.Items {
+		owner := metav1.GetControllerOf(&rs)
+		if owner == nil || owner.Kind != "Deployment" || rs.Spec.Replicas == nil || *rs.Spec.Replicas != 0 || rs.Status.Replicas != 0 {
+			continue
+		}
+		candidates = append(candidates, cleanupCandidate{
+			namespace:  rs.Namespace,
+			name:       rs.Name,
+			uid:        rs.UID,
+			conditions: []string{"scaled-down"},
+			since:      rs.CreationTimestamp.Time,
+			reason:     fmt.Sprintf("Old revision of Deployment %s at zero replicas", owner.Name),
+			labels:     rs.Labels,
+		})
+	}
+	return candidates, nil
+}
+
+// resourceCleanupCandidates returns every object of a namespaced kind listed through
+// discovery, aged from its creation
+func resourceCleanupCandidates(ctx context.Context, kind string) ([]cleanupCandidate, error) {
+	client, err := resources.Shared()
+	if err != nil {
+		return nil, err
+	}
+	res, err := client.Resolve(kind)
+	if err != nil {
+		return nil, err
+	}
+	if !res.Namespaced {
+		return nil, fmt.Errorf("cleanup only deletes namespaced resources, and %s is cluster-scoped", kind)
+	}
+	items, err := client.List(ctx, res, "", metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make([]cleanupCandidate, 0, len(items))
+	for _, item := range items {
+		candidates = append(candidates, cleanupCandidate{
+			namespace:  item.GetNamespace(),
+			name:       item.GetName(),
+			uid:        item.GetUID(),
+			conditions: []string{matchedCondition},
+			since:      item.GetCreationTimestamp().Time,
+			reason:     fmt.Sprintf("%s matched by rule", res.Kind),
+			labels:     item.GetLabels(),
+			fields:     item.Object,
+		})
+	}
+	return candidates, nil
+}
+
+// ApplyCleanup deletes the selected resources, backing each up first if backups is set,
+// logs each deletion and failure, and returns how many were deleted
+func ApplyCleanup(ctx context.Context, clientset kubernetes.Interface, recs []CleanupRecommendation, backups *CleanupBackups) int {
+	deleted := 0
+	for _, rec := range recs {
+		if rec.Protected != "" {
+			log.Printf("Skipped %s %s/%s (rule %s): protected, %s", rec.ResourceType, rec.Namespace, rec.Name, rec.Rule, rec.Protected)
+			continue
+		}
+		backupID, err := DeleteCleanupResource(ctx, clientset, rec, backups)
+		if err != nil {
+			log.Printf("Failed to delete %s %s/%s: %v", rec.ResourceType, rec.Namespace, rec.Name, err)
+			continue
+		}
+		if backupID != "" {
+			log.Printf("Deleted %s %s/%s (rule %s, backup %s): %s", rec.ResourceType, rec.Namespace, rec.Name, rec.Rule, backupID, rec.Reason)
+		} else {
+			log.Printf("Deleted %s %s/%s (rule %s): %s", rec.ResourceType, rec.Namespace, rec.Name, rec.Rule, rec.Reason)
+		}
+		deleted++
+	}
+	return deleted
+}
+
+// DeleteCleanupResource deletes one selected resource, with background propagation so a
+// Job's pods go with it. The live resource is checked against the protection list first,
+// since it may have been labeled since it was selected. With backups, the resource is only
+// deleted once its manifest is saved, and the backup ID is returned. A recommendation with
+// a UID only deletes that object: a resource recreated under its name since is left alone,
+// and the deletion carries the UID as a precondition.
+func DeleteCleanupResource(ctx context.Context, clientset kubernetes.Interface, rec CleanupRecommendation, backups *CleanupBackups) (string, error) {
+	obj, err := getCleanupResource(ctx, clientset, rec)
+	if err != nil {
+		return "", err
+	}
+	if rec.UID != "" && obj.GetUID() != rec.UID {
+		return "", fmt.Errorf("%w: %s %s/%s", ErrCleanupReplaced, rec.ResourceType, rec.Namespace, rec.Name)
+	}
+	if err := protection.Check(rec.ResourceType, rec.Namespace, rec.Name, obj.GetLabels()); err != nil {
+		return "", err
+	}
+	var backupID string
+	if backups != nil {
+		if backupID, err = backups.save(ctx, rec, obj, time.Now()); err != nil {
+			return "", err
+		}
+	}
+
+	background := metav1.DeletePropagationBackground
+	options := metav1.DeleteOptions{PropagationPolicy: &background}
+	if rec.UID != "" {
+		uid := rec.UID
+		options.Preconditions = &metav1.Preconditions{UID: &uid}
+	}
+	switch rec.ResourceType {
+	case "Pod":
+		err = clientset.CoreV1().Pods(rec.Namespace).Delete(ctx, rec.Name, options)
+	case "Job":
+		err = clientset.BatchV1().Jobs(rec.Namespace).Delete(ctx, rec.Name, options)
+	case "ConfigMap":
+		err = clientset.CoreV1().ConfigMaps(rec.Namespace).Delete(ctx, rec.Name, options)
+	case "ReplicaSet":
+		err = clientset.AppsV1().ReplicaSets(rec.Namespace).Delete(ctx, rec.Name, options)
+	default:
+		var client *resources.Client
+		var res resources.Resource
+		if client, res, err = resolveResource(rec.ResourceType); err == nil {
+			err = client.Delete(ctx, res, rec.Namespace, rec.Name, options)
+		}
+	}
+	return backupID, err
+}
+
+// getCleanupResource reads the resource a cleanup recommendation selects
+func getCleanupResource(ctx context.Context, clientset kubernetes.Interface, rec CleanupRecommendation) (metav1.Object, error) {
+	var obj metav1.Object
+	var err error
+	switch rec.ResourceType {
+	case "Pod":
+		obj, err = clientset.CoreV1().Pods(rec.Namespace).Get(ctx, rec.Name, metav1.GetOptions{})
+	case "Job":
+		obj, err = clientset.BatchV1().Jobs(rec.Namespace).Get(ctx, rec.Name, metav1.GetOptions{})
+	case "ConfigMap":
+		obj, err = clientset.CoreV1().ConfigMaps(rec.Namespace).Get(ctx, rec.Name, metav1.GetOptions{})
+	case "ReplicaSet":
+		obj, err = clientset.AppsV1().ReplicaSets(rec.Namespace).Get(ctx, rec.Name, metav1.GetOptions{})
+	default:
+		client, res, resolveErr := resolveResource(rec.ResourceType)
+		if resolveErr != nil {
+			return nil, resolveErr
+		}
+		obj, err = client.Get(ctx, res, rec.Namespace, rec.Name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s %s/%s: %w", rec.ResourceType, rec.Namespace, rec.Name, err)
+	}
+	return obj, nil
+}
+
+// resolveResource finds the shared dynamic client and resource for a kind without a
+// typed client
+func resolveResource(kind string) (*resources.Client, resources.Resource, error) {
+	client, err := resources.Shared()
+	if err != nil {
+		return nil, resources.Resource{}, err
+	}
+	res, err := client.Resolve(kind)
+	if err != nil {
+		return nil, resources.Resource{}, err
+	}
+	return client, res, nil
+}
+
+// CleanupUnusedResources evaluates the default cleanup policy and, unless dryRun is set,
+// deletes what it selects
+func CleanupUnusedResources(ctx context.Context, clientset kubernetes.Interface, dryRun bool) ([]CleanupRecommendation, error) {
+	pods, err := snapshot.ListPods(ctx, clientset, "", metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	snap := &snapshot.ClusterSnapshot{}
+	snap.Pods = pods
+	recommendations, err := DefaultCleanupPolicy.Evaluate(ctx, clientset, snap, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if !dryRun {
+		ApplyCleanup(ctx, clientset, recommendations, nil)
+	}
+	return recommendations, nil
+}
@@ -0,0 +1,262 @@
+package optimizer
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/protection"
+	"github.com/ochestra-tech/ochestra-ai/pkg/snapshot"
+)
+
+var cleanupNow = time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+
+// objectMeta returns metadata for an object created age before cleanupNow
+func objectMeta(namespace, name string, age time.Duration, labels map[string]string) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Namespace:         namespace,
+		Name:              name,
+		UID:               types.UID(namespace + "/" + name),
+		CreationTimestamp: metav1.NewTime(cleanupNow.Add(-age)),
+		Labels:            labels,
+	}
+}
+
+// finishedJob returns a Job that completed or failed age before cleanupNow
+func finishedJob(namespace, name string, condition batchv1.JobConditionType, age time.Duration) *batchv1.Job {
+	job := &batchv1.Job{ObjectMeta: objectMeta(namespace, name, age+time.Hour, nil)}
+	job.Status.Conditions = []batchv1.JobCondition{{
+		Type:               condition,
+		Status:             v1.ConditionTrue,
+		Reason:             "BackoffLimitExceeded",
+		LastTransitionTime: metav1.NewTime(cleanupNow.Add(-age)),
+	}}
+	return job
+}
+
+// finishedPod returns a pod in phase whose container exited age before cleanupNow
+func finishedPod(namespace, name string, phase v1.PodPhase, age time.Duration, labels map[string]string) v1.Pod {
+	pod := v1.Pod{ObjectMeta: objectMeta(namespace, name, age+time.Hour, labels)}
+	pod.Status.Phase = phase
+	pod.Status.ContainerStatuses = []v1.ContainerStatus{{
+		State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{FinishedAt: metav1.NewTime(cleanupNow.Add(-age))}},
+	}}
+	return pod
+}
+
+// loadPolicy initializes rules as LoadCleanupPolicy does
+func loadPolicy(t *testing.T, rules ...CleanupRule) *CleanupPolicy {
+	t.Helper()
+	for i := range rules {
+		if err := rules[i].init(); err != nil {
+			t.Fatalf("init() error = %v", err)
+		}
+	}
+	return &CleanupPolicy{Rules: rules}
+}
+
+func TestCleanupRuleInit(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    CleanupRule
+		wantErr string
+	}{
+		{"default condition", CleanupRule{Name: "jobs", Kind: "Job", TTL: "1h"}, ""},
+		{"missing name", CleanupRule{Kind: "Job", TTL: "1h"}, "name is required"},
+		{"unsupported condition", CleanupRule{Name: "jobs", Kind: "Job", Condition: "unreferenced", TTL: "1h"}, "unsupported condition"},
+		{"other kind without match", CleanupRule{Name: "certs", Kind: "Certificate.cert-manager.io", TTL: "1h"}, "needs a match expression"},
+		{"invalid namespace pattern", CleanupRule{Name: "jobs", Kind: "Job", Namespaces: []string{"ci-["}, TTL: "1h"}, "invalid namespace pattern"},
+		{"negative ttl", CleanupRule{Name: "jobs", Kind: "Job", TTL: "-1h"}, "invalid ttl"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.rule.init()
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("init() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("init() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCleanupPolicyEvaluate(t *testing.T) {
+	controller, zero := true, int32(0)
+	clientset := fake.NewSimpleClientset(
+		finishedJob("ci-web", "build-1", batchv1.JobComplete, 48*time.Hour),
+		finishedJob("ci-web", "build-2", batchv1.JobComplete, time.Hour), // younger than the TTL
+		finishedJob("ci-api", "test-1", batchv1.JobFailed, 48*time.Hour),
+		finishedJob("prod", "migrate", batchv1.JobComplete, 48*time.Hour), // outside ci-*
+		&appsv1.ReplicaSet{
+			ObjectMeta: func() metav1.ObjectMeta {
+				meta := objectMeta("prod", "web-old", 48*time.Hour, nil)
+				meta.OwnerReferences = []metav1.OwnerReference{{Kind: "Deployment", Name: "web", Controller: &controller}}
+				return meta
+			}(),
+			Spec: appsv1.ReplicaSetSpec{Replicas: &zero},
+		},
+	)
+	snap := &snapshot.ClusterSnapshot{}
+	snap.Pods = []v1.Pod{
+		finishedPod("ci-web", "runner-1", v1.PodSucceeded, 48*time.Hour, nil),
+		finishedPod("ci-web", "runner-2", v1.PodFailed, 48*time.Hour, map[string]string{protection.Label: "true"}),
+		finishedPod("kube-system", "setup", v1.PodSucceeded, 48*time.Hour, nil),
+	}
+
+	policy := loadPolicy(t,
+		CleanupRule{Name: "failed-ci-jobs", Kind: "Job", Condition: "failed", Namespaces: []string{"ci-*"}, TTL: "0s"},
+		CleanupRule{Name: "ci-jobs", Kind: "Job", Namespaces: []string{"ci-*"}, TTL: "24h"},
+		CleanupRule{Name: "pods", Kind: "Pod", TTL: "24h"},
+		CleanupRule{Name: "old-revisions", Kind: "ReplicaSet", TTL: "24h"},
+	)
+	recs, err := policy.Evaluate(context.Background(), clientset, snap, cleanupNow)
+	if err != nil {
+		t.Fatalf("Evaluate() error = %v", err)
+	}
+
+	var got []string
+	for _, rec := range recs {
+		entry := rec.ResourceType + " " + rec.Namespace + "/" + rec.Name + " " + rec.Rule
+		if rec.Protected != "" {
+			entry += " protected"
+		}
+		got = append(got, entry)
+	}
+	want := []string{
+		"Job ci-api/test-1 failed-ci-jobs",
+		"Job ci-web/build-1 ci-jobs",
+		"Pod ci-web/runner-1 pods",
+		"Pod ci-web/runner-2 pods protected",
+		"Pod kube-system/setup pods protected",
+		"ReplicaSet prod/web-old old-revisions",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Evaluate() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	for _, rec := range recs {
+		if rec.UID != types.UID(rec.Namespace+"/"+rec.Name) {
+			t.Errorf("%s/%s UID = %q, want the selected object's", rec.Namespace, rec.Name, rec.UID)
+		}
+	}
+}
+
+// podExists reports whether the fake clientset still has the pod
+func podExists(t *testing.T, clientset *fake.Clientset, namespace, name string) bool {
+	t.Helper()
+	_, err := clientset.CoreV1().Pods(namespace).Get(context.Background(), name, metav1.GetOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		t.Fatalf("Get() error = %v", err)
+	}
+	return err == nil
+}
+
+func TestDeleteCleanupResource(t *testing.T) {
+	labeled := finishedPod("ci-web", "labeled", v1.PodSucceeded, 48*time.Hour, nil)
+	replaced := finishedPod("ci-web", "replaced", v1.PodSucceeded, 48*time.Hour, nil)
+
+	tests := []struct {
+		name    string
+		live    v1.Pod
+		rec     CleanupRecommendation
+		wantErr error
+	}{
+		{
+			name: "deleted",
+			live: finishedPod("ci-web", "runner", v1.PodSucceeded, 48*time.Hour, nil),
+			rec:  CleanupRecommendation{ResourceType: "Pod", Namespace: "ci-web", Name: "runner", UID: "ci-web/runner"},
+		},
+		{
+			name: "labeled protected since it was selected",
+			live: func() v1.Pod {
+				labeled.Labels = map[string]string{protection.Label: "true"}
+				return labeled
+			}(),
+			rec:     CleanupRecommendation{ResourceType: "Pod", Namespace: "ci-web", Name: "labeled", UID: "ci-web/labeled"},
+			wantErr: protection.ErrProtected,
+		},
+		{
+			name:    "in a system namespace",
+			live:    finishedPod("kube-system", "setup", v1.PodSucceeded, 48*time.Hour, nil),
+			rec:     CleanupRecommendation{ResourceType: "Pod", Namespace: "kube-system", Name: "setup", UID: "kube-system/setup"},
+			wantErr: protection.ErrProtected,
+		},
+		{
+			name: "recreated under the same name",
+			live: func() v1.Pod {
+				replaced.UID = "new-uid"
+				return replaced
+			}(),
+			rec:     CleanupRecommendation{ResourceType: "Pod", Namespace: "ci-web", Name: "replaced", UID: "ci-web/replaced"},
+			wantErr: ErrCleanupReplaced,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientset := fake.NewSimpleClientset(&tt.live)
+			backups := &CleanupBackups{Dir: t.TempDir()}
+			backupID, err := DeleteCleanupResource(context.Background(), clientset, tt.rec, backups)
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("DeleteCleanupResource() error = %v, want %v", err, tt.wantErr)
+				}
+				if !podExists(t, clientset, tt.rec.Namespace, tt.rec.Name) {
+					t.Error("pod was deleted")
+				}
+				if backups, _ := ListCleanupBackups(backups.Dir); len(backups) != 0 {
+					t.Errorf("backups = %d, want none for a refused deletion", len(backups))
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("DeleteCleanupResource() error = %v", err)
+			}
+			if podExists(t, clientset, tt.rec.Namespace, tt.rec.Name) {
+				t.Error("pod was not deleted")
+			}
+			backup, err := LoadCleanupBackup(backups.Dir, backupID)
+			if err != nil {
+				t.Fatalf("LoadCleanupBackup() error = %v", err)
+			}
+			if !strings.Contains(string(backup.Manifest), `"uid": "ci-web/runner"`) {
+				t.Errorf("backup manifest = %s, want the deleted pod", backup.Manifest)
+			}
+		})
+	}
+}
+
+func TestApplyCleanupSkipsProtected(t *testing.T) {
+	objects := []runtime.Object{}
+	for _, name := range []string{"a", "b", "c"} {
+		pod := finishedPod("ci-web", name, v1.PodSucceeded, 48*time.Hour, nil)
+		objects = append(objects, &pod)
+	}
+	clientset := fake.NewSimpleClientset(objects...)
+
+	recs := []CleanupRecommendation{
+		{Rule: "pods", ResourceType: "Pod", Namespace: "ci-web", Name: "a", UID: "ci-web/a"},
+		{Rule: "pods", ResourceType: "Pod", Namespace: "ci-web", Name: "b", UID: "ci-web/b", Protected: "labeled hc-monitor/protected=true"},
+		{Rule: "pods", ResourceType: "Pod", Namespace: "ci-web", Name: "c", UID: "stale-uid"},
+	}
+	if deleted := ApplyCleanup(context.Background(), clientset, recs, nil); deleted != 1 {
+		t.Errorf("ApplyCleanup() = %d, want 1", deleted)
+	}
+	for name, want := range map[string]bool{"a": false, "b": true, "c": true} {
+		if got := podExists(t, clientset, "ci-web", name); got != want {
+			t.Errorf("pod %s exists = %v, want %v", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,360 @@
+package optimizer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/protection"
+)
+
+// ExportFormat selects how recommendations are rendered for a Git repository
+type ExportFormat string
+
+const (
+	ExportKustomize ExportFormat = "kustomize" // strategic merge patches
+	ExportManifest  ExportFormat = "manifest"  // full workload manifests with updated requests
+)
+
+// PathMapping maps workloads to a directory in the GitOps repository. Namespace, Kind
+// and Name accept path.Match patterns; empty fields match everything.
+type PathMapping struct {
+	Namespace string `json:"namespace,omitempty"`
+	Kind      string `json:"kind,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Path      string `json:"path"`
+}
+
+// ExportConfig configures recommendation export
+type ExportConfig struct {
+	Format   ExportFormat  `json:"format"`
+	Mappings []PathMapping `json:"mappings"`
+}
+
+// ExportedFile is a file to be written to, or committed in, the GitOps repository
+type ExportedFile struct {
+	Path    string
+	Content []byte
+}
+
+// LoadExportConfig reads an export configuration from a JSON file
+func LoadExportConfig(configPath string) (*ExportConfig, error) {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read export config: %w", err)
+	}
+
+	var config ExportConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse export config: %w", err)
+	}
+	if config.Format == "" {
+		config.Format = ExportKustomize
+	}
+	if config.Format != ExportKustomize && config.Format != ExportManifest {
+		return nil, fmt.Errorf("unsupported export format: %s", config.Format)
+	}
+	return &config, nil
+}
+
+// pathFor returns the repository directory for a workload, or false if no mapping matches
+func (c *ExportConfig) pathFor(namespace, kind, name string) (string, bool) {
+	for _, m := range c.Mappings {
+		if globMatch(m.Namespace, namespace) && globMatch(m.Kind, kind) && globMatch(m.Name, name) {
+			return m.Path, true
+		}
+	}
+	return "", false
+}
+
+// globMatch matches value against a path.Match pattern, treating an empty pattern as a wildcard
+func globMatch(pattern, value string) bool {
+	if pattern == "" {
+		return true
+	}
+	ok, err := path.Match(pattern, value)
+	return err == nil && ok
+}
+
+// workloadRequests collects the recommended requests for one workload, keyed by container
+type workloadRequests struct {
+	Namespace  string
+	Kind       string
+	Name       string
+	Containers map[string]v1.ResourceList
+}
+
+// ExportRecommendations renders right-sizing recommendations as files for a GitOps repository.
+// Workloads without a path mapping, and protected workloads, are skipped.
+func ExportRecommendations(
+	ctx context.Context,
+	clientset kubernetes.Interface,
+	recs []Recommendation,
+	config *ExportConfig,
+) ([]ExportedFile, error) {
+	workloads := make(map[string]*workloadRequests)
+	for _, rec := range recs {
+		if rec.ResourceType == "" || rec.WorkloadKind == "Pod" {
+			continue // Only controller-managed workloads can be patched
+		}
+		if rec.Sidecar {
+			continue // Injected sidecars are not in the repository's manifests
+		}
+
+		key := fmt.Sprintf("%s/%s/%s", rec.Namespace, rec.WorkloadKind, rec.WorkloadName)
+		w, exists := workloads[key]
+		if !exists {
+			w = &workloadRequests{
+				Namespace:  rec.Namespace,
+				Kind:       rec.WorkloadKind,
+				Name:       rec.WorkloadName,
+				Containers: make(map[string]v1.ResourceList),
+			}
+			workloads[key] = w
+		}
+		if w.Containers[rec.ContainerName] == nil {
+			w.Containers[rec.ContainerName] = v1.ResourceList{}
+		}
+		w.Containers[rec.ContainerName][v1.ResourceName(rec.ResourceType)] = recommendedQuantity(rec)
+	}
+
+	keys := make([]string, 0, len(workloads))
+	for key := range workloads {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	files := make([]ExportedFile, 0, len(keys))
+	for _, key := range keys {
+		w := workloads[key]
+		dir, ok := config.pathFor(w.Namespace, w.Kind, w.Name)
+		if !ok {
+			continue
+		}
+		if err := checkWorkloadProtection(ctx, clientset, w); err != nil {
+			log.Printf("Skipped exporting %s: %v", key, err)
+			continue
+		}
+
+		var file ExportedFile
+		var err error
+		switch config.Format {
+		case ExportManifest:
+			file, err = renderManifest(ctx, clientset, w, dir)
+		default:
+			file, err = renderKustomizePatch(w, dir)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to export %s: %w", key, err)
+		}
+		files = append(files, file)
+	}
+
+	return files, nil
+}
+
+// checkWorkloadProtection returns an error if the workload may not be changed, or could not
+// be read to find out
+func checkWorkloadProtection(ctx context.Context, clientset kubernetes.Interface, w *workloadRequests) error {
+	if protection.Namespace(w.Namespace) != "" {
+		return protection.Check(w.Kind, w.Namespace, w.Name, nil) // no need to read the workload
+	}
+
+	var labels map[string]string
+	switch w.Kind {
+	case "Deployment":
+		d, err := clientset.AppsV1().Deployments(w.Namespace).Get(ctx, w.Name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		labels = d.Labels
+	case "StatefulSet":
+		s, err := clientset.AppsV1().StatefulSets(w.Namespace).Get(ctx, w.Name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		labels = s.Labels
+	case "DaemonSet":
+		ds, err := clientset.AppsV1().DaemonSets(w.Namespace).Get(ctx, w.Name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		labels = ds.Labels
+	}
+	return protection.Check(w.Kind, w.Namespace, w.Name, labels)
+}
+
+// recommendedQuantity converts a recommendation into a resource quantity, rounding memory up to Mi
+func recommendedQuantity(rec Recommendation) resource.Quantity {
+	if rec.ResourceType == "cpu" {
+		milli := rec.RecommendedRequest
+		if milli < 10 {
+			milli = 10
+		}
+		return *resource.NewMilliQuantity(milli, resource.DecimalSI)
+	}
+	mi := (rec.RecommendedRequest + 1024*1024 - 1) / (1024 * 1024)
+	if mi < 16 {
+		mi = 16
+	}
+	return resource.MustParse(fmt.Sprintf("%dMi", mi))
+}
+
+// renderKustomizePatch renders a strategic merge patch updating container requests
+func renderKustomizePatch(w *workloadRequests, dir string) (ExportedFile, error) {
+	containers := make([]map[string]interface{}, 0, len(w.Containers))
+	for _, name := range sortedContainerNames(w.Containers) {
+		containers = append(containers, map[string]interface{}{
+			"name":      name,
+			"resources": map[string]interface{}{"requests": w.Containers[name]},
+		})
+	}
+
+	patch := map[string]interface{}{
+		"apiVersion": apiVersionFor(w.Kind),
+		"kind":       w.Kind,
+		"metadata":   map[string]interface{}{"name": w.Name, "namespace": w.Namespace},
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{"containers": containers},
+			},
+		},
+	}
+
+	data, err := yaml.Marshal(patch)
+	if err != nil {
+		return ExportedFile{}, err
+	}
+
+	header := fmt.Sprintf("# Right-sizing patch generated by ochestra-ai.\n"+
+		"# Add to kustomization.yaml:\n#   patches:\n#     - path: patches/%s\n", patchFileName(w))
+	return ExportedFile{
+		Path:    filepath.Join(dir, "patches", patchFileName(w)),
+		Content: append([]byte(header), data...),
+	}, nil
+}
+
+// renderManifest fetches the live workload and renders it with updated requests
+func renderManifest(ctx context.Context, clientset kubernetes.Interface, w *workloadRequests, dir string) (ExportedFile, error) {
+	var obj interface{}
+	var podSpec *v1.PodSpec
+
+	switch w.Kind {
+	case "Deployment":
+		d, err := clientset.AppsV1().Deployments(w.Namespace).Get(ctx, w.Name, metav1.GetOptions{})
+		if err != nil {
+			return ExportedFile{}, err
+		}
+		d.TypeMeta = metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"}
+		cleanObjectMeta(&d.ObjectMeta)
+		d.Status = appsv1.DeploymentStatus{}
+		obj, podSpec = d, &d.Spec.Template.Spec
+	case "StatefulSet":
+		s, err := clientset.AppsV1().StatefulSets(w.Namespace).Get(ctx, w.Name, metav1.GetOptions{})
+		if err != nil {
+			return ExportedFile{}, err
+		}
+		s.TypeMeta = metav1.TypeMeta{APIVersion: "apps/v1", Kind: "StatefulSet"}
+		cleanObjectMeta(&s.ObjectMeta)
+		s.Status = appsv1.StatefulSetStatus{}
+		obj, podSpec = s, &s.Spec.Template.Spec
+	case "DaemonSet":
+		ds, err := clientset.AppsV1().DaemonSets(w.Namespace).Get(ctx, w.Name, metav1.GetOptions{})
+		if err != nil {
+			return ExportedFile{}, err
+		}
+		ds.TypeMeta = metav1.TypeMeta{APIVersion: "apps/v1", Kind: "DaemonSet"}
+		cleanObjectMeta(&ds.ObjectMeta)
+		ds.Status = appsv1.DaemonSetStatus{}
+		obj, podSpec = ds, &ds.Spec.Template.Spec
+	default:
+		return ExportedFile{}, fmt.Errorf("unsupported workload kind %s", w.Kind)
+	}
+
+	for i := range podSpec.Containers {
+		requests, ok := w.Containers[podSpec.Containers[i].Name]
+		if !ok {
+			continue
+		}
+		if podSpec.Containers[i].Resources.Requests == nil {
+			podSpec.Containers[i].Resources.Requests = v1.ResourceList{}
+		}
+		for name, quantity := range requests {
+			podSpec.Containers[i].Resources.Requests[name] = quantity
+		}
+	}
+
+	data, err := yaml.Marshal(obj)
+	if err != nil {
+		return ExportedFile{}, err
+	}
+	return ExportedFile{
+		Path:    filepath.Join(dir, fmt.Sprintf("%s-%s.yaml", strings.ToLower(w.Kind), w.Name)),
+		Content: data,
+	}, nil
+}
+
+// cleanObjectMeta strips server-populated metadata so the manifest can be committed
+func cleanObjectMeta(meta *metav1.ObjectMeta) {
+	meta.UID = ""
+	meta.ResourceVersion = ""
+	meta.Generation = 0
+	meta.CreationTimestamp = metav1.Time{}
+	meta.ManagedFields = nil
+	delete(meta.Annotations, "kubectl.kubernetes.io/last-applied-configuration")
+	delete(meta.Annotations, "deployment.kubernetes.io/revision")
+}
+
+// WriteExportedFiles writes exported files below a local directory (e.g. a repository checkout)
+func WriteExportedFiles(dir string, files []ExportedFile) error {
+	for _, file := range files {
+		target := filepath.Join(dir, file.Path)
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return fmt.Errorf("failed to create directory for %s: %w", file.Path, err)
+		}
+		if err := os.WriteFile(target, file.Content, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", file.Path, err)
+		}
+	}
+	return nil
+}
+
+// patchFileName returns the patch file name for a workload
+func patchFileName(w *workloadRequests) string {
+	return fmt.Sprintf("%s-%s-resources.yaml", strings.ToLower(w.Kind), w.Name)
+}
+
+// sortedContainerNames returns container names in a stable order
+func sortedContainerNames(containers map[string]v1.ResourceList) []string {
+	names := make([]string, 0, len(containers))
+	for name := range containers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// apiVersionFor returns the API version for a workload kind
+func apiVersionFor(kind string) string {
+	switch kind {
+	case "Deployment", "StatefulSet", "DaemonSet", "ReplicaSet":
+		return "apps/v1"
+	case "Job", "CronJob":
+		return "batch/v1"
+	default:
+		return "v1"
+	}
+}
@@ -0,0 +1,338 @@
+package optimizer
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/snapshot"
+)
+
+// ResourceEventScaling is the resource type of event-driven scaling recommendations
+const ResourceEventScaling = "event-scaling"
+
+// ScaledObject is a suggested KEDA ScaledObject for a workload running at a static replica count
+type ScaledObject struct {
+	Namespace   string
+	Kind        string
+	Name        string
+	Replicas    int // current, static
+	MinReplicas int
+	MaxReplicas int
+	Triggers    []ScaleTrigger
+	Saving      float64 // monthly; 0 when it depends on traffic
+}
+
+// ScaleTrigger is a KEDA trigger. Metadata values in angle brackets are placeholders to fill in.
+type ScaleTrigger struct {
+	Type     string
+	Metadata map[string]string
+}
+
+// queueConsumer recognizes a queue client from a container's environment
+type queueConsumer struct {
+	trigger string
+	match   func(name, value string) bool
+	// metadata builds the trigger metadata from the environment; env maps names to literal
+	// values, which are empty for variables read from secrets or config maps
+	metadata func(env map[string]string) map[string]string
+}
+
+// queueConsumers are the queue clients detected, in the order they are tried
+var queueConsumers = []queueConsumer{
+	{
+		trigger: "kafka",
+		match: func(name, value string) bool {
+			return strings.Contains(name, "KAFKA") || strings.Contains(value, ":9092")
+		},
+		metadata: func(env map[string]string) map[string]string {
+			return map[string]string{
+				"bootstrapServers": envValue(env, "<bootstrap servers>", "BOOTSTRAP", "BROKER"),
+				"consumerGroup":    envValue(env, "<consumer group>", "GROUP"),
+				"topic":            envValue(env, "<topic>", "TOPIC"),
+				"lagThreshold":     "10",
+			}
+		},
+	},
+	{
+		trigger: "rabbitmq",
+		match: func(name, value string) bool {
+			return strings.Contains(name, "RABBITMQ") || strings.Contains(name, "AMQP") ||
+				strings.HasPrefix(value, "amqp://") || strings.HasPrefix(value, "amqps://")
+		},
+		metadata: func(env map[string]string) map[string]string {
+			return map[string]string{
+				"hostFromEnv": envName(env, "<host env>", "AMQP", "RABBITMQ_URL", "RABBITMQ_HOST"),
+				"queueName":   envValue(env, "<queue>", "QUEUE"),
+				"mode":        "QueueLength",
+				"value":       "20",
+			}
+		},
+	},
+	{
+		trigger: "aws-sqs-queue",
+		match: func(name, value string) bool {
+			return strings.Contains(name, "SQS") || (strings.Contains(value, "sqs.") && strings.Contains(value, ".amazonaws.com"))
+		},
+		metadata: func(env map[string]string) map[string]string {
+			return map[string]string{
+				"queueURL":    envValue(env, "<queue URL>", "SQS", "QUEUE_URL"),
+				"awsRegion":   envValue(env, "<region>", "AWS_REGION", "AWS_DEFAULT_REGION"),
+				"queueLength": "5",
+			}
+		},
+	},
+	{
+		trigger: "azure-servicebus",
+		match: func(name, value string) bool {
+			return strings.Contains(name, "SERVICEBUS") || strings.Contains(value, ".servicebus.windows.net")
+		},
+		metadata: func(env map[string]string) map[string]string {
+			return map[string]string{
+				"connectionFromEnv": envName(env, "<connection env>", "SERVICEBUS"),
+				"queueName":         envValue(env, "<queue>", "QUEUE"),
+				"messageCount":      "5",
+			}
+		},
+	},
+	{
+		trigger: "gcp-pubsub",
+		match: func(name, value string) bool {
+			return strings.Contains(name, "PUBSUB")
+		},
+		metadata: func(env map[string]string) map[string]string {
+			return map[string]string{
+				"subscriptionName": envValue(env, "<subscription>", "SUBSCRIPTION"),
+				"mode":             "SubscriptionSize",
+				"value":            "5",
+			}
+		},
+	},
+	{
+		trigger: "redis",
+		match: func(name, value string) bool {
+			return strings.Contains(name, "REDIS") && (strings.Contains(name, "QUEUE") || strings.Contains(name, "LIST"))
+		},
+		metadata: func(env map[string]string) map[string]string {
+			return map[string]string{
+				"addressFromEnv": envName(env, "<address env>", "REDIS_ADDR", "REDIS_HOST", "REDIS_URL"),
+				"listName":       envValue(env, "<list>", "QUEUE", "LIST"),
+				"listLength":     "5",
+			}
+		},
+	},
+}
+
+// DetectQueueConsumers finds Deployments at a static replica count, with no HPA, whose
+// containers are configured as clients of a message queue, and suggests scaling them on
+// queue length with KEDA
+func DetectQueueConsumers(snap *snapshot.ClusterSnapshot) ([]ScaledObject, error) {
+	for _, resource := range []string{"deployments", "hpas"} {
+		if err := snap.Errors[resource]; err != nil {
+			return nil, fmt.Errorf("failed to get %s: %w", resource, err)
+		}
+	}
+
+	autoscaled := make(map[string]bool)
+	for _, hpa := range snap.HPAs {
+		ref := hpa.Spec.ScaleTargetRef
+		if ref.Kind == "Deployment" {
+			autoscaled[hpa.Namespace+"/"+ref.Name] = true // includes KEDA's own keda-hpa-* HPAs
+		}
+	}
+
+	result := make([]ScaledObject, 0)
+	for _, d := range snap.Deployments {
+		if autoscaled[d.Namespace+"/"+d.Name] {
+			continue
+		}
+		replicas := 1
+		if d.Spec.Replicas != nil {
+			replicas = int(*d.Spec.Replicas)
+		}
+		if replicas == 0 {
+			continue
+		}
+		trigger, ok := detectQueueTrigger(d)
+		if !ok {
+			continue
+		}
+		result = append(result, ScaledObject{
+			Namespace:   d.Namespace,
+			Kind:        "Deployment",
+			Name:        d.Name,
+			Replicas:    replicas,
+			MinReplicas: 0,
+			MaxReplicas: max(2*replicas, 2),
+			Triggers:    []ScaleTrigger{trigger},
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Namespace != result[j].Namespace {
+			return result[i].Namespace < result[j].Namespace
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result, nil
+}
+
+// detectQueueTrigger returns the trigger of the first queue client a Deployment's
+// containers are configured for
+func detectQueueTrigger(d appsv1.Deployment) (ScaleTrigger, bool) {
+	for _, c := range d.Spec.Template.Spec.Containers {
+		env := containerEnv(c)
+		for _, consumer := range queueConsumers {
+			for name, value := range env {
+				if consumer.match(strings.ToUpper(name), value) {
+					return ScaleTrigger{Type: consumer.trigger, Metadata: consumer.metadata(env)}, true
+				}
+			}
+		}
+	}
+	return ScaleTrigger{}, false
+}
+
+// containerEnv returns a container's environment variables with their literal values
+func containerEnv(c v1.Container) map[string]string {
+	env := make(map[string]string, len(c.Env))
+	for _, e := range c.Env {
+		env[e.Name] = e.Value
+	}
+	return env
+}
+
+// envValue returns the literal value of the first variable whose name contains one of the
+// fragments, or the placeholder
+func envValue(env map[string]string, placeholder string, fragments ...string) string {
+	for _, name := range sortedEnvNames(env) {
+		for _, fragment := range fragments {
+			if strings.Contains(strings.ToUpper(name), fragment) && env[name] != "" {
+				return env[name]
+			}
+		}
+	}
+	return placeholder
+}
+
+// envName returns the name of the first variable containing one of the fragments, for
+// triggers that read the value from the workload's environment, or the placeholder
+func envName(env map[string]string, placeholder string, fragments ...string) string {
+	for _, name := range sortedEnvNames(env) {
+		for _, fragment := range fragments {
+			if strings.Contains(strings.ToUpper(name), fragment) {
+				return name
+			}
+		}
+	}
+	return placeholder
+}
+
+// sortedEnvNames returns the variable names in a stable order
+func sortedEnvNames(env map[string]string) []string {
+	names := make([]string, 0, len(env))
+	for name := range env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ScheduleScaledObjects turns off-hours schedule recommendations into KEDA ScaledObjects with
+// a cron trigger that holds the current replicas during business hours
+func ScheduleScaledObjects(recs []Recommendation, hours BusinessHours) []ScaledObject {
+	start, end := hours.Cron()
+	result := make([]ScaledObject, 0)
+	for _, rec := range recs {
+		if rec.ResourceType != ResourceSchedule {
+			continue
+		}
+		result = append(result, ScaledObject{
+			Namespace:   rec.Namespace,
+			Kind:        rec.WorkloadKind,
+			Name:        rec.WorkloadName,
+			Replicas:    rec.Replicas,
+			MinReplicas: 0,
+			MaxReplicas: rec.Replicas,
+			Triggers: []ScaleTrigger{{
+				Type: "cron",
+				Metadata: map[string]string{
+					"timezone":        hours.Location.String(),
+					"start":           start,
+					"end":             end,
+					"desiredReplicas": strconv.Itoa(rec.Replicas),
+				},
+			}},
+			Saving: rec.PotentialSaving,
+		})
+	}
+	return result
+}
+
+// Recommendation describes a queue consumer's ScaledObject as an event-driven scaling
+// recommendation
+func (o ScaledObject) Recommendation() Recommendation {
+	types := make([]string, len(o.Triggers))
+	for i, t := range o.Triggers {
+		types[i] = t.Type
+	}
+	return Recommendation{
+		Type: "Event-Driven Scaling",
+		Description: fmt.Sprintf("Runs a fixed %d replicas as a %s consumer; a KEDA ScaledObject would scale it between %d and %d on queue length, "+
+			"saving whatever time the queue spends empty", o.Replicas, strings.Join(types, ", "), o.MinReplicas, o.MaxReplicas),
+		PotentialSaving:    o.Saving,
+		Namespace:          o.Namespace,
+		WorkloadKind:       o.Kind,
+		WorkloadName:       o.Name,
+		ResourceType:       ResourceEventScaling,
+		CurrentRequest:     int64(o.Replicas),
+		RecommendedRequest: int64(o.MinReplicas),
+		Replicas:           o.Replicas,
+	}
+}
+
+// RenderScaledObjects renders ScaledObjects as manifests below dir, one file per workload
+// at <namespace>/scaledobject-<name>.yaml
+func RenderScaledObjects(objects []ScaledObject, dir string) ([]ExportedFile, error) {
+	files := make([]ExportedFile, 0, len(objects))
+	for _, o := range objects {
+		triggers := make([]map[string]interface{}, len(o.Triggers))
+		for i, t := range o.Triggers {
+			triggers[i] = map[string]interface{}{"type": t.Type, "metadata": t.Metadata}
+		}
+		manifest := map[string]interface{}{
+			"apiVersion": "keda.sh/v1alpha1",
+			"kind":       "ScaledObject",
+			"metadata":   map[string]interface{}{"name": o.Name, "namespace": o.Namespace},
+			"spec": map[string]interface{}{
+				"scaleTargetRef": map[string]interface{}{
+					"apiVersion": apiVersionFor(o.Kind),
+					"kind":       o.Kind,
+					"name":       o.Name,
+				},
+				"minReplicaCount": o.MinReplicas,
+				"maxReplicaCount": o.MaxReplicas,
+				"triggers":        triggers,
+			},
+		}
+
+		data, err := yaml.Marshal(manifest)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render ScaledObject for %s/%s: %w", o.Namespace, o.Name, err)
+		}
+		header := "# KEDA ScaledObject generated by ochestra-ai.\n"
+		if strings.Contains(string(data), "<") {
+			header += "# Replace the <placeholders> and add a TriggerAuthentication if the scaler needs credentials.\n"
+		}
+		files = append(files, ExportedFile{
+			Path:    filepath.Join(dir, o.Namespace, fmt.Sprintf("scaledobject-%s.yaml", o.Name)),
+			Content: append([]byte(header), data...),
+		})
+	}
+	return files, nil
+}
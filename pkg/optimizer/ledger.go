@@ -0,0 +1,257 @@
+package optimizer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Ledger entry statuses
+const (
+	LedgerStatusOpen    = "open"    // recommendation not yet applied
+	LedgerStatusApplied = "applied" // workload request or replicas moved toward the recommendation
+	LedgerStatusGone    = "gone"    // workload no longer observed
+)
+
+// Measurement is the request, usage and cost of a workload container at a point in time
+type Measurement struct {
+	Request     int64     `json:"request"`
+	Usage       int64     `json:"usage"`
+	Replicas    int       `json:"replicas"`
+	MonthlyCost float64   `json:"monthlyCost"`
+	MeasuredAt  time.Time `json:"measuredAt"`
+}
+
+// LedgerEntry tracks a recommendation from the moment it was made until its savings are realized
+type LedgerEntry struct {
+	ID              string         `json:"id"`
+	Recommendation  Recommendation `json:"recommendation"`
+	Status          string         `json:"status"`
+	RecordedAt      time.Time      `json:"recordedAt"`
+	AppliedAt       *time.Time     `json:"appliedAt,omitempty"`
+	Before          Measurement    `json:"before"`
+	After           *Measurement   `json:"after,omitempty"`
+	ProjectedSaving float64        `json:"projectedSaving"` // monthly
+	RealizedSaving  float64        `json:"realizedSaving"`  // monthly
+}
+
+// LedgerSummary totals projected and realized savings across the ledger
+type LedgerSummary struct {
+	Open             int     `json:"open"`
+	Applied          int     `json:"applied"`
+	Gone             int     `json:"gone"`
+	ProjectedSavings float64 `json:"projectedSavings"` // monthly, open and applied entries
+	RealizedSavings  float64 `json:"realizedSavings"`  // monthly, applied entries
+}
+
+// Ledger records recommendations and detects when they are applied by observing workloads
+type Ledger struct {
+	path    string
+	mu      sync.Mutex
+	entries map[string]*LedgerEntry
+}
+
+// LoadLedger opens a ledger persisted at path, or an in-memory ledger if path is empty
+func LoadLedger(path string) (*Ledger, error) {
+	ledger := &Ledger{
+		path:    path,
+		entries: make(map[string]*LedgerEntry),
+	}
+	if path == "" {
+		return ledger, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return ledger, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read savings ledger: %w", err)
+	}
+
+	var entries []*LedgerEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse savings ledger: %w", err)
+	}
+	for _, entry := range entries {
+		ledger.entries[entry.ID] = entry
+	}
+	return ledger, nil
+}
+
+// Record adds new recommendations to the ledger; recommendations already being tracked are refreshed
+func (l *Ledger) Record(recs []Recommendation, now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, rec := range recs {
+		id := rec.ID()
+		if entry, exists := l.entries[id]; exists && entry.Status == LedgerStatusOpen {
+			entry.Recommendation = rec
+			entry.ProjectedSaving = rec.PotentialSaving
+			continue
+		} else if exists {
+			continue // Applied entries keep their original baseline
+		}
+
+		l.entries[id] = &LedgerEntry{
+			ID:             id,
+			Recommendation: rec,
+			Status:         LedgerStatusOpen,
+			RecordedAt:     now,
+			Before: Measurement{
+				Request:     rec.CurrentRequest,
+				Usage:       rec.Usage,
+				Replicas:    rec.Replicas,
+				MonthlyCost: recommendationCost(rec),
+				MeasuredAt:  now,
+			},
+			ProjectedSaving: rec.PotentialSaving,
+		}
+	}
+}
+
+// Observe compares current workload usage with the ledger to detect applied recommendations
+// and measure the savings they realized
+func (l *Ledger) Observe(usages []ContainerUsage, now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	current := make(map[string]Measurement)
+	for _, u := range usages {
+		// Replica measurements are per workload, with the cost of all its containers
+		workload := fmt.Sprintf("%s/%s/%s//%s", u.Namespace, u.WorkloadKind, u.WorkloadName, ResourceReplicas)
+		replicas := current[workload]
+		replicas.Request = int64(max(int(replicas.Request), u.Replicas))
+		replicas.Replicas = int(replicas.Request)
+		replicas.MonthlyCost += requestCost("cpu", u.CPURequest, u.Replicas) + requestCost("memory", u.MemoryRequest, u.Replicas)
+		replicas.MeasuredAt = now
+		current[workload] = replicas
+
+		base := fmt.Sprintf("%s/%s/%s/%s", u.Namespace, u.WorkloadKind, u.WorkloadName, u.ContainerName)
+		current[base+"/cpu"] = Measurement{
+			Request:     u.CPURequest,
+			Usage:       u.CPUUsage,
+			Replicas:    u.Replicas,
+			MonthlyCost: requestCost("cpu", u.CPURequest, u.Replicas),
+			MeasuredAt:  now,
+		}
+		current[base+"/memory"] = Measurement{
+			Request:     u.MemoryRequest,
+			Usage:       u.MemoryUsage,
+			Replicas:    u.Replicas,
+			MonthlyCost: requestCost("memory", u.MemoryRequest, u.Replicas),
+			MeasuredAt:  now,
+		}
+	}
+
+	for id, entry := range l.entries {
+		m, ok := current[id]
+		if !ok {
+			if entry.Status == LedgerStatusOpen {
+				entry.Status = LedgerStatusGone
+			}
+			continue
+		}
+
+		if entry.Status != LedgerStatusApplied {
+			// Applied once the request has moved at least halfway toward the recommendation
+			gap := entry.Before.Request - entry.Recommendation.RecommendedRequest
+			if gap <= 0 || entry.Before.Request-m.Request < gap/2 {
+				continue
+			}
+			entry.Status = LedgerStatusApplied
+			appliedAt := now
+			entry.AppliedAt = &appliedAt
+		}
+
+		after := m
+		entry.After = &after
+		if entry.Recommendation.ResourceType == ResourceReplicas {
+			// Count the replicas removed at the original cost per replica, so right-sizing is not counted twice
+			entry.RealizedSaving = entry.Before.MonthlyCost * float64(entry.Before.Request-m.Request) / float64(entry.Before.Request)
+			continue
+		}
+		// Compare at the original replica count so scaling changes are not counted as right-sizing savings
+		entry.RealizedSaving = entry.Before.MonthlyCost -
+			requestCost(entry.Recommendation.ResourceType, m.Request, entry.Before.Replicas)
+	}
+}
+
+// Entries returns all ledger entries ordered by when they were recorded
+func (l *Ledger) Entries() []LedgerEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	result := make([]LedgerEntry, 0, len(l.entries))
+	for _, entry := range l.entries {
+		result = append(result, *entry)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].RecordedAt.Equal(result[j].RecordedAt) {
+			return result[i].ID < result[j].ID
+		}
+		return result[i].RecordedAt.Before(result[j].RecordedAt)
+	})
+	return result
+}
+
+// Summary totals the ledger's projected and realized savings
+func (l *Ledger) Summary() LedgerSummary {
+	var summary LedgerSummary
+	for _, entry := range l.Entries() {
+		switch entry.Status {
+		case LedgerStatusOpen:
+			summary.Open++
+			summary.ProjectedSavings += entry.ProjectedSaving
+		case LedgerStatusApplied:
+			summary.Applied++
+			summary.ProjectedSavings += entry.ProjectedSaving
+			summary.RealizedSavings += entry.RealizedSaving
+		case LedgerStatusGone:
+			summary.Gone++
+		}
+	}
+	return summary
+}
+
+// Save persists the ledger to disk if it was loaded from a file
+func (l *Ledger) Save() error {
+	if l.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(l.Entries(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal savings ledger: %w", err)
+	}
+	if err := os.WriteFile(l.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write savings ledger: %w", err)
+	}
+	return nil
+}
+
+// recommendationCost returns the monthly cost of what a recommendation targets. For replica
+// recommendations it is the cost of all current replicas, derived from the saving per
+// replica removed.
+func recommendationCost(rec Recommendation) float64 {
+	if rec.ResourceType == ResourceReplicas {
+		removed := rec.CurrentRequest - rec.RecommendedRequest
+		if removed <= 0 {
+			return 0
+		}
+		return rec.PotentialSaving / float64(removed) * float64(rec.CurrentRequest)
+	}
+	return requestCost(rec.ResourceType, rec.CurrentRequest, rec.Replicas)
+}
+
+// requestCost returns the monthly cost of a request across replicas
+func requestCost(resourceType string, request int64, replicas int) float64 {
+	if resourceType == "cpu" {
+		return calculateCPUSaving(request) * float64(replicas)
+	}
+	return calculateMemorySaving(request) * float64(replicas)
+}
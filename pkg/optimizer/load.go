@@ -0,0 +1,92 @@
+package optimizer
+
+import (
+	"fmt"
+	"math"
+)
+
+// WorkloadLoad is how a workload's load signal, such as requests per second or queue
+// depth, compares with its recent peak
+type WorkloadLoad struct {
+	Signal      string  // name of the signal
+	Current     float64 // value at this cycle
+	Peak        float64 // highest recorded value over the lookback
+	MinReplicas int     // replicas the peak needs at the signal's per-replica target; 0 without a target
+}
+
+// Factor is how many times the current load the peak is; 0 when the signal is idle now
+// but was not before, so the current usage says nothing about the load the workload must
+// handle
+func (l WorkloadLoad) Factor() float64 {
+	if l.Peak <= 0 {
+		return 1
+	}
+	if l.Current <= 0 {
+		return 0
+	}
+	return math.Max(l.Peak/l.Current, 1)
+}
+
+// ApplyLoadToUsage scales the CPU usage of containers in workloads with a load signal up to
+// what it would be at the signal's peak, so right-sizing does not shrink a workload that is
+// measured at a quiet moment. Memory is left alone as it rarely scales with load. Workloads
+// whose signal is idle now are dropped, to be sized in a cycle that sees load. loads is
+// keyed by namespace/kind/name; a workload with several signals uses the largest factor.
+func ApplyLoadToUsage(usages []ContainerUsage, loads map[string][]WorkloadLoad) []ContainerUsage {
+	result := make([]ContainerUsage, 0, len(usages))
+	for _, u := range usages {
+		signals := loads[fmt.Sprintf("%s/%s/%s", u.Namespace, u.WorkloadKind, u.WorkloadName)]
+		if len(signals) == 0 {
+			result = append(result, u)
+			continue
+		}
+		busiest, idle := busiestLoad(signals)
+		if idle {
+			continue
+		}
+		u.LoadSignal = busiest.Signal
+		u.LoadFactor = busiest.Factor()
+		result = append(result, u)
+	}
+	return result
+}
+
+// ApplyLoadToReplicas scales the usage of deployments with a load signal to the signal's
+// peak and sets the replicas the peak needs as their floor. Deployments whose signal is idle
+// now are dropped, as in ApplyLoadToUsage.
+func ApplyLoadToReplicas(deployments []DeploymentLoad, loads map[string][]WorkloadLoad) []DeploymentLoad {
+	result := make([]DeploymentLoad, 0, len(deployments))
+	for _, d := range deployments {
+		signals := loads[fmt.Sprintf("%s/Deployment/%s", d.Namespace, d.Name)]
+		if len(signals) == 0 {
+			result = append(result, d)
+			continue
+		}
+		busiest, idle := busiestLoad(signals)
+		if idle {
+			continue
+		}
+		d.CPUUsage = int64(float64(d.CPUUsage) * busiest.Factor())
+		d.LoadSignal = busiest.Signal
+		for _, l := range signals {
+			d.LoadReplicas = max(d.LoadReplicas, l.MinReplicas)
+		}
+		result = append(result, d)
+	}
+	return result
+}
+
+// busiestLoad returns the signal with the largest peak factor, and whether any signal of
+// the workload is idle now
+func busiestLoad(signals []WorkloadLoad) (WorkloadLoad, bool) {
+	var busiest WorkloadLoad
+	for _, l := range signals {
+		if l.Factor() == 0 {
+			return l, true
+		}
+		if l.Factor() > busiest.Factor() {
+			busiest = l
+		}
+	}
+	return busiest, false
+}
@@ -4,158 +4,291 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"sort"
 	"time"
 
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
+	metricsapi "k8s.io/metrics/pkg/apis/metrics/v1beta1"
 	"k8s.io/metrics/pkg/client/clientset/versioned"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/owners"
+	"github.com/ochestra-tech/ochestra-ai/pkg/retry"
+	"github.com/ochestra-tech/ochestra-ai/pkg/snapshot"
 )
 
 type OptimizationReport struct {
+	GeneratedAt      time.Time
 	PotentialSavings float64
 	Recommendations  []Recommendation
 }
 
 type Recommendation struct {
-	Type            string
-	Description     string
-	PotentialSaving float64
+	Type               string
+	Description        string
+	PotentialSaving    float64
+	Namespace          string
+	WorkloadKind       string
+	WorkloadName       string
+	ContainerName      string
+	ResourceType       string // "cpu", "memory", "replicas", "schedule", "event-scaling", "storage-size", "storage-class" or "architecture"
+	CurrentRequest     int64  // millicores, bytes or replicas
+	RecommendedRequest int64  // millicores, bytes or replicas; off-hours or minimum replicas for schedules and event scaling
+	Usage              int64  // millicores, bytes, the replicas the load would fill, or off-hours millicores for schedules
+	Replicas           int
+	Sidecar            bool // the container is injected by a mesh or agent, or is a native sidecar
+	Owner              *owners.Owner
 }
 
-type ResourceOptimizer struct {
-	clientset     *kubernetes.Clientset
-	metricsClient *versioned.Clientset
+// ID returns a stable identifier for the workload container and resource a recommendation targets
+func (r Recommendation) ID() string {
+	return fmt.Sprintf("%s/%s/%s/%s/%s", r.Namespace, r.WorkloadKind, r.WorkloadName, r.ContainerName, r.ResourceType)
 }
 
-func (o *ResourceOptimizer) GenerateOptimizationReport(ctx context.Context) (*OptimizationReport, error) {
-	return &OptimizationReport{
-		PotentialSavings: 0.0,
-		Recommendations:  []Recommendation{},
-	}, nil
+// AttachOwners sets the owner of each recommendation's workload that has none
+func (r *OptimizationReport) AttachOwners() {
+	for i := range r.Recommendations {
+		rec := &r.Recommendations[i]
+		if rec.Owner == nil {
+			rec.Owner = owners.Resolve(rec.WorkloadKind, rec.Namespace, rec.WorkloadName)
+		}
+	}
 }
 
-func NewResourceOptimizer(clientset *kubernetes.Clientset, metricsClient *versioned.Clientset) *ResourceOptimizer {
-	return &ResourceOptimizer{
-		clientset:     clientset,
-		metricsClient: metricsClient,
-	}
+// ContainerUsage is the observed request and average usage of a container across a workload's pods
+type ContainerUsage struct {
+	Namespace     string
+	WorkloadKind  string
+	WorkloadName  string
+	ContainerName string
+	CPURequest    int64 // millicores
+	MemoryRequest int64 // bytes
+	CPUUsage      int64 // millicores, averaged over replicas
+	MemoryUsage   int64 // bytes, averaged over replicas
+	Replicas      int
+	Sidecar       bool
+	LoadSignal    string  // load signal the CPU usage is projected with, if any
+	LoadFactor    float64 // peak over current value of the load signal; CPU usage is sized at this multiple
 }
 
-func initKubernetesClients() (*kubernetes.Clientset, *versioned.Clientset) {
-	config, err := rest.InClusterConfig()
+type ResourceOptimizer struct {
+	clientset     kubernetes.Interface
+	metricsClient versioned.Interface
+}
+
+// GenerateOptimizationReport recommends request reductions for containers using less than half of what they request
+func (o *ResourceOptimizer) GenerateOptimizationReport(ctx context.Context) (*OptimizationReport, error) {
+	usages, err := o.CollectContainerUsage(ctx)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
+	return RecommendRightSizing(usages), nil
+}
 
-	clientset, err := kubernetes.NewForConfig(config)
-	if err != nil {
-		log.Fatal(err)
+// sidecarNote tells where a sidecar's requests are set, since they are rarely in the
+// workload's own manifest
+const sidecarNote = " (sidecar: set its requests through the injector's annotations or configuration)"
+
+// RecommendRightSizing builds request right-sizing recommendations from observed container usage
+func RecommendRightSizing(usages []ContainerUsage) *OptimizationReport {
+	report := &OptimizationReport{
+		GeneratedAt:     time.Now(),
+		Recommendations: make([]Recommendation, 0),
 	}
 
-	metricsClient, err := versioned.NewForConfig(config)
-	if err != nil {
-		log.Fatal(err)
+	for _, u := range usages {
+		// Check CPU over-provisioning, at the peak of the workload's load signal if it has one
+		cpuUsage := u.CPUUsage
+		if u.LoadFactor > 1 {
+			cpuUsage = int64(float64(u.CPUUsage) * u.LoadFactor)
+		}
+		if u.CPURequest > 0 && cpuUsage < u.CPURequest/2 {
+			rec := Recommendation{
+				Type:               "CPU Over-provisioning",
+				Description:        fmt.Sprintf("Using %dm CPU but requesting %dm", u.CPUUsage, u.CPURequest),
+				Namespace:          u.Namespace,
+				WorkloadKind:       u.WorkloadKind,
+				WorkloadName:       u.WorkloadName,
+				ContainerName:      u.ContainerName,
+				ResourceType:       "cpu",
+				CurrentRequest:     u.CPURequest,
+				RecommendedRequest: cpuUsage * 2,
+				Usage:              cpuUsage,
+				Replicas:           u.Replicas,
+				Sidecar:            u.Sidecar,
+			}
+			if cpuUsage != u.CPUUsage {
+				rec.Description = fmt.Sprintf("Using %dm CPU, %dm at the peak of its %s load signal, but requesting %dm",
+					u.CPUUsage, cpuUsage, u.LoadSignal, u.CPURequest)
+			}
+			if u.Sidecar {
+				rec.Description += sidecarNote
+			}
+			rec.PotentialSaving = calculateCPUSaving(rec.CurrentRequest-rec.RecommendedRequest) * float64(u.Replicas)
+			report.Recommendations = append(report.Recommendations, rec)
+			report.PotentialSavings += rec.PotentialSaving
+		}
+
+		// Check memory over-provisioning
+		if u.MemoryRequest > 0 && u.MemoryUsage < u.MemoryRequest/2 {
+			rec := Recommendation{
+				Type: "Memory Over-provisioning",
+				Description: fmt.Sprintf("Using %dMi memory but requesting %dMi",
+					u.MemoryUsage/(1024*1024), u.MemoryRequest/(1024*1024)),
+				Namespace:          u.Namespace,
+				WorkloadKind:       u.WorkloadKind,
+				WorkloadName:       u.WorkloadName,
+				ContainerName:      u.ContainerName,
+				ResourceType:       "memory",
+				CurrentRequest:     u.MemoryRequest,
+				RecommendedRequest: u.MemoryUsage * 2,
+				Usage:              u.MemoryUsage,
+				Replicas:           u.Replicas,
+				Sidecar:            u.Sidecar,
+			}
+			if u.Sidecar {
+				rec.Description += sidecarNote
+			}
+			rec.PotentialSaving = calculateMemorySaving(rec.CurrentRequest-rec.RecommendedRequest) * float64(u.Replicas)
+			report.Recommendations = append(report.Recommendations, rec)
+			report.PotentialSavings += rec.PotentialSaving
+		}
 	}
 
-	return clientset, metricsClient
-}
+	// Sort recommendations by potential savings
+	sort.Slice(report.Recommendations, func(i, j int) bool {
+		return report.Recommendations[i].PotentialSaving > report.Recommendations[j].PotentialSaving
+	})
 
-type CleanupRecommendation struct {
-	ResourceType string
-	Namespace    string
-	Name         string
-	Reason       string
-	Age          time.Duration
+	return report
 }
 
-func CleanupUnusedResources(ctx context.Context, clientset *kubernetes.Clientset, dryRun bool) ([]CleanupRecommendation, error) {
-	recommendations := make([]CleanupRecommendation, 0)
-
-	// Find unused ConfigMaps
-	configMaps, err := clientset.CoreV1().ConfigMaps("").List(ctx, metav1.ListOptions{})
+// CollectContainerUsage aggregates container requests and metrics-server usage per workload
+func (o *ResourceOptimizer) CollectContainerUsage(ctx context.Context) ([]ContainerUsage, error) {
+	podMetrics, err := retry.Value(ctx, retry.DefaultBackoff, func() (*metricsapi.PodMetricsList, error) {
+		return o.metricsClient.MetricsV1beta1().PodMetricses("").List(ctx, metav1.ListOptions{})
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to list configmaps: %w", err)
+		return nil, fmt.Errorf("failed to get pod metrics: %w", err)
 	}
 
-	pods, err := clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{})
+	// metrics-server only reports running pods
+	pods, err := snapshot.ListPods(ctx, o.clientset, "", metav1.ListOptions{
+		FieldSelector: snapshot.RunningPodsSelector,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to list pods: %w", err)
+		return nil, fmt.Errorf("failed to get pods: %w", err)
 	}
 
-	// Create a map of configmaps in use
-	configMapsInUse := make(map[string]bool)
-	for _, pod := range pods.Items {
-		for _, volume := range pod.Spec.Volumes {
-			if volume.ConfigMap != nil {
-				key := fmt.Sprintf("%s/%s", pod.Namespace, volume.ConfigMap.Name)
-				configMapsInUse[key] = true
-			}
-		}
+	return collectContainerUsage(pods, podMetrics.Items), nil
+}
 
-		for _, container := range pod.Spec.Containers {
-			for _, env := range container.Env {
-				if env.ValueFrom != nil && env.ValueFrom.ConfigMapKeyRef != nil {
-					key := fmt.Sprintf("%s/%s", pod.Namespace, env.ValueFrom.ConfigMapKeyRef.Name)
-					configMapsInUse[key] = true
-				}
-			}
+// CollectContainerUsageFromSnapshot aggregates container usage from a cluster snapshot
+func CollectContainerUsageFromSnapshot(snap *snapshot.ClusterSnapshot) ([]ContainerUsage, error) {
+	if err := snap.Errors["podMetrics"]; err != nil {
+		return nil, fmt.Errorf("failed to get pod metrics: %w", err)
+	}
+	return collectContainerUsage(snap.Pods, snap.PodMetrics), nil
+}
+
+// collectContainerUsage joins running pods with their metrics, averaging usage across
+// replicas. Native sidecars are measured like the pod's own containers.
+func collectContainerUsage(pods []v1.Pod, podMetrics []metricsapi.PodMetrics) []ContainerUsage {
+	// Index container usage by namespace/pod/container
+	usageByContainer := make(map[string]v1.ResourceList)
+	for _, metric := range podMetrics {
+		for _, container := range metric.Containers {
+			usageByContainer[fmt.Sprintf("%s/%s/%s", metric.Namespace, metric.Name, container.Name)] = container.Usage
 		}
 	}
 
-	// Find unused configmaps
-	for _, cm := range configMaps.Items {
-		key := fmt.Sprintf("%s/%s", cm.Namespace, cm.Name)
-		if !configMapsInUse[key] {
-			rec := CleanupRecommendation{
-				ResourceType: "ConfigMap",
-				Namespace:    cm.Namespace,
-				Name:         cm.Name,
-				Reason:       "Not referenced by any pod",
-				Age:          time.Since(cm.CreationTimestamp.Time),
+	usages := make(map[string]*ContainerUsage)
+	order := make([]string, 0)
+	for _, pod := range pods {
+		if pod.Status.Phase != v1.PodRunning {
+			continue
+		}
+		kind, name := snapshot.WorkloadOwner(&pod)
+
+		for _, container := range snapshot.Containers(&pod) {
+			usage, ok := usageByContainer[fmt.Sprintf("%s/%s/%s", pod.Namespace, pod.Name, container.Name)]
+			if !ok {
+				continue // Skip containers without metrics
 			}
-			recommendations = append(recommendations, rec)
-
-			if !dryRun {
-				// Delete unused configmap
-				err := clientset.CoreV1().ConfigMaps(cm.Namespace).Delete(ctx, cm.Name, metav1.DeleteOptions{})
-				if err != nil {
-					log.Printf("Failed to delete configmap %s/%s: %v", cm.Namespace, cm.Name, err)
-				} else {
-					log.Printf("Deleted unused configmap %s/%s", cm.Namespace, cm.Name)
+
+			key := fmt.Sprintf("%s/%s/%s/%s", pod.Namespace, kind, name, container.Name)
+			u, exists := usages[key]
+			if !exists {
+				u = &ContainerUsage{
+					Namespace:     pod.Namespace,
+					WorkloadKind:  kind,
+					WorkloadName:  name,
+					ContainerName: container.Name,
+					CPURequest:    container.Resources.Requests.Cpu().MilliValue(),
+					MemoryRequest: container.Resources.Requests.Memory().Value(),
+					Sidecar:       snapshot.Sidecar(&pod, container.Name),
 				}
+				usages[key] = u
+				order = append(order, key)
 			}
+			u.CPUUsage += usage.Cpu().MilliValue()
+			u.MemoryUsage += usage.Memory().Value()
+			u.Replicas++
 		}
 	}
 
-	// Find failed pods older than 7 days
-	for _, pod := range pods.Items {
-		if pod.Status.Phase == v1.PodFailed || pod.Status.Phase == v1.PodSucceeded {
-			age := time.Since(pod.CreationTimestamp.Time)
-			if age > 7*24*time.Hour {
-				rec := CleanupRecommendation{
-					ResourceType: "Pod",
-					Namespace:    pod.Namespace,
-					Name:         pod.Name,
-					Reason:       fmt.Sprintf("Failed/Completed pod older than 7 days (status: %s)", pod.Status.Phase),
-					Age:          age,
-				}
-				recommendations = append(recommendations, rec)
-
-				if !dryRun {
-					// Delete old failed/succeeded pod
-					err := clientset.CoreV1().Pods(pod.Namespace).Delete(ctx, pod.Name, metav1.DeleteOptions{})
-					if err != nil {
-						log.Printf("Failed to delete pod %s/%s: %v", pod.Namespace, pod.Name, err)
-					} else {
-						log.Printf("Deleted old pod %s/%s", pod.Namespace, pod.Name)
-					}
-				}
-			}
-		}
+	result := make([]ContainerUsage, 0, len(order))
+	for _, key := range order {
+		u := usages[key]
+		u.CPUUsage /= int64(u.Replicas)
+		u.MemoryUsage /= int64(u.Replicas)
+		result = append(result, *u)
 	}
-	return []CleanupRecommendation{}, nil
+	return result
+}
+
+// calculateCPUSaving calculates potential monthly savings for CPU reduction
+func calculateCPUSaving(milliCPUReduction int64) float64 {
+	cpuReduction := float64(milliCPUReduction) / 1000
+	// Assume $0.03 per CPU core hour
+	hourlySaving := cpuReduction * 0.03
+	return hourlySaving * 24 * 30 // Monthly saving
+}
+
+// calculateMemorySaving calculates potential monthly savings for memory reduction
+func calculateMemorySaving(memoryReductionBytes int64) float64 {
+	memoryReductionGB := float64(memoryReductionBytes) / (1024 * 1024 * 1024)
+	// Assume $0.004 per GB hour
+	hourlySaving := memoryReductionGB * 0.004
+	return hourlySaving * 24 * 30 // Monthly saving
+}
+
+func NewResourceOptimizer(clientset kubernetes.Interface, metricsClient versioned.Interface) *ResourceOptimizer {
+	return &ResourceOptimizer{
+		clientset:     clientset,
+		metricsClient: metricsClient,
+	}
+}
+
+func initKubernetesClients() (kubernetes.Interface, versioned.Interface) {
+	config, err := rest.InClusterConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	metricsClient, err := versioned.NewForConfig(config)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return clientset, metricsClient
 }
 
 func main() {
@@ -0,0 +1,21 @@
+package optimizer
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/snapshot"
+)
+
+func BenchmarkRecommendRightSizing(b *testing.B) {
+	for _, size := range []struct{ nodes, pods int }{{20, 200}, {500, 10000}} {
+		b.Run(fmt.Sprintf("%dnodes-%dpods", size.nodes, size.pods), func(b *testing.B) {
+			snap := snapshot.Synthetic(size.nodes, size.pods)
+			b.ReportAllocs()
+			for b.Loop() {
+				usages, _ := CollectContainerUsageFromSnapshot(snap)
+				RecommendRightSizing(usages)
+			}
+		})
+	}
+}
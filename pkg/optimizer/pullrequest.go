@@ -0,0 +1,200 @@
+package optimizer
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// PullRequestOptions configures opening a pull/merge request with exported files
+type PullRequestOptions struct {
+	Provider   string // "github" or "gitlab"
+	BaseURL    string // API base URL; defaults to the public service
+	Repository string // "owner/name" for GitHub, project ID or path for GitLab
+	Token      string
+	BaseBranch string
+	Branch     string
+	Title      string
+	Body       string
+}
+
+// OpenPullRequest commits the files to a new branch and opens a pull request (GitHub)
+// or merge request (GitLab), returning its web URL
+func OpenPullRequest(ctx context.Context, opts PullRequestOptions, files []ExportedFile) (string, error) {
+	if len(files) == 0 {
+		return "", fmt.Errorf("no files to commit")
+	}
+	if opts.Branch == "" {
+		opts.Branch = fmt.Sprintf("ochestra-ai/rightsizing-%s", time.Now().UTC().Format("20060102-150405"))
+	}
+	if opts.BaseBranch == "" {
+		opts.BaseBranch = "main"
+	}
+	if opts.Title == "" {
+		opts.Title = "Right-size workload resource requests"
+	}
+
+	client := &gitAPIClient{
+		http:  &http.Client{Timeout: 30 * time.Second},
+		token: opts.Token,
+	}
+
+	switch opts.Provider {
+	case "github":
+		if opts.BaseURL == "" {
+			opts.BaseURL = "https://api.github.com"
+		}
+		client.auth = "Bearer " + opts.Token
+		return openGitHubPullRequest(ctx, client, opts, files)
+	case "gitlab":
+		if opts.BaseURL == "" {
+			opts.BaseURL = "https://gitlab.com/api/v4"
+		}
+		return openGitLabMergeRequest(ctx, client, opts, files)
+	default:
+		return "", fmt.Errorf("unsupported pull request provider: %s", opts.Provider)
+	}
+}
+
+// openGitHubPullRequest creates a branch, commits each file through the contents API and opens a PR
+func openGitHubPullRequest(ctx context.Context, c *gitAPIClient, opts PullRequestOptions, files []ExportedFile) (string, error) {
+	repo := opts.BaseURL + "/repos/" + opts.Repository
+
+	var ref struct {
+		Object struct {
+			SHA string `json:"sha"`
+		} `json:"object"`
+	}
+	if err := c.do(ctx, http.MethodGet, repo+"/git/ref/heads/"+opts.BaseBranch, nil, &ref); err != nil {
+		return "", fmt.Errorf("failed to read base branch: %w", err)
+	}
+
+	branch := map[string]string{"ref": "refs/heads/" + opts.Branch, "sha": ref.Object.SHA}
+	if err := c.do(ctx, http.MethodPost, repo+"/git/refs", branch, nil); err != nil {
+		return "", fmt.Errorf("failed to create branch: %w", err)
+	}
+
+	for _, file := range files {
+		contentURL := repo + "/contents/" + file.Path
+
+		// Existing files must be updated with their current blob SHA
+		var existing struct {
+			SHA string `json:"sha"`
+		}
+		_ = c.do(ctx, http.MethodGet, contentURL+"?ref="+url.QueryEscape(opts.Branch), nil, &existing)
+
+		commit := map[string]string{
+			"message": fmt.Sprintf("Right-size %s", file.Path),
+			"content": base64.StdEncoding.EncodeToString(file.Content),
+			"branch":  opts.Branch,
+		}
+		if existing.SHA != "" {
+			commit["sha"] = existing.SHA
+		}
+		if err := c.do(ctx, http.MethodPut, contentURL, commit, nil); err != nil {
+			return "", fmt.Errorf("failed to commit %s: %w", file.Path, err)
+		}
+	}
+
+	var pr struct {
+		HTMLURL string `json:"html_url"`
+	}
+	body := map[string]string{"title": opts.Title, "body": opts.Body, "head": opts.Branch, "base": opts.BaseBranch}
+	if err := c.do(ctx, http.MethodPost, repo+"/pulls", body, &pr); err != nil {
+		return "", fmt.Errorf("failed to open pull request: %w", err)
+	}
+	return pr.HTMLURL, nil
+}
+
+// openGitLabMergeRequest commits all files in a single commit on a new branch and opens an MR
+func openGitLabMergeRequest(ctx context.Context, c *gitAPIClient, opts PullRequestOptions, files []ExportedFile) (string, error) {
+	project := opts.BaseURL + "/projects/" + url.PathEscape(opts.Repository)
+
+	actions := make([]map[string]string, 0, len(files))
+	for _, file := range files {
+		action := "create"
+		if err := c.do(ctx, http.MethodGet, project+"/repository/files/"+url.PathEscape(file.Path)+"?ref="+url.QueryEscape(opts.BaseBranch), nil, nil); err == nil {
+			action = "update"
+		}
+		actions = append(actions, map[string]string{
+			"action":    action,
+			"file_path": file.Path,
+			"content":   string(file.Content),
+		})
+	}
+
+	commit := map[string]interface{}{
+		"branch":         opts.Branch,
+		"start_branch":   opts.BaseBranch,
+		"commit_message": opts.Title,
+		"actions":        actions,
+	}
+	if err := c.do(ctx, http.MethodPost, project+"/repository/commits", commit, nil); err != nil {
+		return "", fmt.Errorf("failed to commit changes: %w", err)
+	}
+
+	var mr struct {
+		WebURL string `json:"web_url"`
+	}
+	body := map[string]string{
+		"source_branch": opts.Branch,
+		"target_branch": opts.BaseBranch,
+		"title":         opts.Title,
+		"description":   opts.Body,
+	}
+	if err := c.do(ctx, http.MethodPost, project+"/merge_requests", body, &mr); err != nil {
+		return "", fmt.Errorf("failed to open merge request: %w", err)
+	}
+	return mr.WebURL, nil
+}
+
+// gitAPIClient is a minimal JSON client for Git hosting APIs
+type gitAPIClient struct {
+	http  *http.Client
+	token string
+	auth  string // Authorization header value; GitLab uses PRIVATE-TOKEN instead
+}
+
+// do sends a JSON request and decodes the JSON response into out if non-nil
+func (c *gitAPIClient) do(ctx context.Context, method, endpoint string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.auth != "" {
+		req.Header.Set("Authorization", c.auth)
+	} else if c.token != "" {
+		req.Header.Set("PRIVATE-TOKEN", c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s %s: %s: %s", method, endpoint, resp.Status, bytes.TrimSpace(msg))
+	}
+	if out != nil {
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package optimizer
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/snapshot"
+)
+
+// ResourceReplicas is the resource type of replica count recommendations
+const ResourceReplicas = "replicas"
+
+// ReplicaOptions controls the replica efficiency analysis
+type ReplicaOptions struct {
+	LowUtilization    float64 // per-replica utilization of the busier resource below which a deployment is flagged
+	TargetUtilization float64 // utilization the recommended replica count runs at
+	MinReplicas       int     // fewest replicas recommended for deployments running at least that many
+}
+
+// DefaultReplicaOptions flags deployments whose replicas use less than 30% of their
+// requests and sizes them to run at 60%, keeping at least two replicas for availability
+var DefaultReplicaOptions = ReplicaOptions{
+	LowUtilization:    0.3,
+	TargetUtilization: 0.6,
+	MinReplicas:       2,
+}
+
+// DeploymentLoad is a Deployment's replica count, autoscaler bounds and observed load
+type DeploymentLoad struct {
+	Namespace      string
+	Name           string
+	Replicas       int    // desired
+	HPAName        string // empty when no HPA scales the deployment
+	HPAMinReplicas int
+	HPAMaxReplicas int
+	CPURequest     int64 // millicores per pod
+	MemoryRequest  int64 // bytes per pod
+	CPUUsage       int64 // millicores, averaged over measured pods
+	MemoryUsage    int64 // bytes, averaged over measured pods
+	MeasuredPods   int
+	LoadSignal     string // load signal the CPU usage is projected to the peak of, if any
+	LoadReplicas   int    // replicas the load signal's peak needs; never recommended below
+}
+
+// CollectDeploymentLoad joins deployments with their HPAs and the usage of their running pods
+func CollectDeploymentLoad(snap *snapshot.ClusterSnapshot) ([]DeploymentLoad, error) {
+	for _, resource := range []string{"deployments", "hpas", "podMetrics"} {
+		if err := snap.Errors[resource]; err != nil {
+			return nil, fmt.Errorf("failed to get %s: %w", resource, err)
+		}
+	}
+
+	// Sum the average container usage into per-pod usage for each deployment
+	type podLoad struct {
+		cpu, memory int64
+		pods        int
+	}
+	loads := make(map[string]*podLoad)
+	for _, u := range collectContainerUsage(snap.Pods, snap.PodMetrics) {
+		if u.WorkloadKind != "Deployment" {
+			continue
+		}
+		key := u.Namespace + "/" + u.WorkloadName
+		l, ok := loads[key]
+		if !ok {
+			l = &podLoad{}
+			loads[key] = l
+		}
+		l.cpu += u.CPUUsage
+		l.memory += u.MemoryUsage
+		l.pods = max(l.pods, u.Replicas)
+	}
+
+	result := make([]DeploymentLoad, 0, len(snap.Deployments))
+	for _, d := range snap.Deployments {
+		load := DeploymentLoad{Namespace: d.Namespace, Name: d.Name, Replicas: 1}
+		if d.Spec.Replicas != nil {
+			load.Replicas = int(*d.Spec.Replicas)
+		}
+		for _, c := range d.Spec.Template.Spec.Containers {
+			load.CPURequest += c.Resources.Requests.Cpu().MilliValue()
+			load.MemoryRequest += c.Resources.Requests.Memory().Value()
+		}
+		if l, ok := loads[d.Namespace+"/"+d.Name]; ok {
+			load.CPUUsage, load.MemoryUsage, load.MeasuredPods = l.cpu, l.memory, l.pods
+		}
+		for _, hpa := range snap.HPAs {
+			ref := hpa.Spec.ScaleTargetRef
+			if hpa.Namespace == d.Namespace && ref.Kind == "Deployment" && ref.Name == d.Name {
+				load.HPAName = hpa.Name
+				load.HPAMinReplicas, load.HPAMaxReplicas = 1, int(hpa.Spec.MaxReplicas)
+				if hpa.Spec.MinReplicas != nil {
+					load.HPAMinReplicas = int(*hpa.Spec.MinReplicas)
+				}
+			}
+		}
+		result = append(result, load)
+	}
+	return result, nil
+}
+
+// RecommendReplicas recommends replica reductions for deployments whose replicas are mostly
+// idle. Deployments an HPA has scaled above its floor are left to the HPA; for those held
+// at the floor, the recommendation is to lower the HPA's minReplicas.
+func RecommendReplicas(loads []DeploymentLoad, options ReplicaOptions) []Recommendation {
+	recs := make([]Recommendation, 0)
+	for _, l := range loads {
+		if l.MeasuredPods == 0 || l.Replicas <= 1 {
+			continue
+		}
+		if l.HPAName != "" && l.Replicas > l.HPAMinReplicas {
+			continue
+		}
+
+		// Size for the busier resource; the load is what the measured pods average times the
+		// desired replicas, so pods missing metrics do not hide load
+		var utilization, filled float64
+		for _, r := range []struct{ usage, request int64 }{{l.CPUUsage, l.CPURequest}, {l.MemoryUsage, l.MemoryRequest}} {
+			if r.request <= 0 {
+				continue
+			}
+			u := float64(r.usage) / float64(r.request)
+			utilization = math.Max(utilization, u)
+			filled = math.Max(filled, u*float64(l.Replicas))
+		}
+		if utilization == 0 || utilization >= options.LowUtilization {
+			continue
+		}
+		recommended := max(int(math.Ceil(filled/options.TargetUtilization)), min(l.Replicas, options.MinReplicas), l.LoadReplicas, 1)
+		if recommended >= l.Replicas {
+			continue
+		}
+
+		rec := Recommendation{
+			Type: "Replica Over-provisioning",
+			Description: fmt.Sprintf("Running %d replicas at %.0f%% utilization; %d would serve the load at %.0f%%",
+				l.Replicas, utilization*100, recommended, options.TargetUtilization*100),
+			Namespace:          l.Namespace,
+			WorkloadKind:       "Deployment",
+			WorkloadName:       l.Name,
+			ResourceType:       ResourceReplicas,
+			CurrentRequest:     int64(l.Replicas),
+			RecommendedRequest: int64(recommended),
+			Usage:              int64(math.Ceil(filled)),
+			Replicas:           l.Replicas,
+		}
+		if l.HPAName != "" {
+			rec.Type = "HPA Floor Too High"
+			rec.Description = fmt.Sprintf("HPA %s holds %d replicas at %.0f%% utilization; lower minReplicas to %d",
+				l.HPAName, l.Replicas, utilization*100, recommended)
+		}
+		if l.LoadSignal != "" {
+			rec.Description += fmt.Sprintf(" (CPU taken at the peak of its %s load signal)", l.LoadSignal)
+		}
+		rec.PotentialSaving = (calculateCPUSaving(l.CPURequest) + calculateMemorySaving(l.MemoryRequest)) *
+			float64(l.Replicas-recommended)
+		recs = append(recs, rec)
+	}
+	return recs
+}
+
+// Add appends recommendations to the report, keeping it sorted by potential savings
+func (r *OptimizationReport) Add(recs ...Recommendation) {
+	for _, rec := range recs {
+		r.Recommendations = append(r.Recommendations, rec)
+		r.PotentialSavings += rec.PotentialSaving
+	}
+	sort.SliceStable(r.Recommendations, func(i, j int) bool {
+		return r.Recommendations[i].PotentialSaving > r.Recommendations[j].PotentialSaving
+	})
+}
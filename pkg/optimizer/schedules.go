@@ -0,0 +1,275 @@
+package optimizer
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/anomaly"
+	"github.com/ochestra-tech/ochestra-ai/pkg/history"
+	"github.com/ochestra-tech/ochestra-ai/pkg/snapshot"
+)
+
+// ResourceSchedule is the resource type of off-hours scaling recommendations
+const ResourceSchedule = "schedule"
+
+// BusinessHours is the working week in a time zone, e.g. Monday to Friday 08:00-19:00
+type BusinessHours struct {
+	Location *time.Location
+	Days     []time.Weekday
+	Start    int // minutes after midnight
+	End      int // minutes after midnight, after Start
+}
+
+// DefaultBusinessHours are Monday to Friday, 08:00-19:00 UTC
+var DefaultBusinessHours = BusinessHours{
+	Location: time.UTC,
+	Days:     []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+	Start:    8 * 60,
+	End:      19 * 60,
+}
+
+// weekdayNames are the day abbreviations business hours are written with
+var weekdayNames = []string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"}
+
+// ParseBusinessHours parses days and hours such as "Mon-Fri 08:00-19:00" or
+// "Mon,Wed,Fri 09:30-17:00" in an IANA time zone
+func ParseBusinessHours(spec, timezone string) (BusinessHours, error) {
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return BusinessHours{}, fmt.Errorf("invalid business hours time zone: %w", err)
+	}
+	days, hours, ok := strings.Cut(strings.TrimSpace(spec), " ")
+	if !ok {
+		return BusinessHours{}, fmt.Errorf("invalid business hours %q: want days and hours, e.g. \"Mon-Fri 08:00-19:00\"", spec)
+	}
+
+	b := BusinessHours{Location: location}
+	for _, part := range strings.Split(days, ",") {
+		first, last, isRange := strings.Cut(part, "-")
+		from, to := weekday(first), weekday(last)
+		if !isRange {
+			to = from
+		}
+		if from < 0 || to < 0 {
+			return BusinessHours{}, fmt.Errorf("invalid business days %q", part)
+		}
+		for d := from; ; d = (d + 1) % 7 {
+			b.Days = append(b.Days, time.Weekday(d))
+			if d == to {
+				break
+			}
+		}
+	}
+
+	start, end, _ := strings.Cut(hours, "-")
+	if b.Start, err = minuteOfDay(start); err != nil {
+		return BusinessHours{}, err
+	}
+	if b.End, err = minuteOfDay(end); err != nil {
+		return BusinessHours{}, err
+	}
+	if b.End <= b.Start {
+		return BusinessHours{}, fmt.Errorf("business hours %q end before they start", hours)
+	}
+	return b, nil
+}
+
+// weekday returns the index of a day abbreviation, or -1
+func weekday(name string) int {
+	for i, n := range weekdayNames {
+		if strings.EqualFold(n, strings.TrimSpace(name)) {
+			return i
+		}
+	}
+	return -1
+}
+
+// minuteOfDay parses "HH:MM", allowing "24:00" for the end of the day
+func minuteOfDay(s string) (int, error) {
+	h, m, ok := strings.Cut(s, ":")
+	hour, err1 := strconv.Atoi(h)
+	minute, err2 := strconv.Atoi(m)
+	if !ok || err1 != nil || err2 != nil || hour < 0 || minute < 0 || minute > 59 || hour*60+minute > 24*60 {
+		return 0, fmt.Errorf("invalid business hours time %q", s)
+	}
+	return hour*60 + minute, nil
+}
+
+// Contains reports whether t falls within business hours
+func (b BusinessHours) Contains(t time.Time) bool {
+	t = t.In(b.Location)
+	minute := t.Hour()*60 + t.Minute()
+	for _, d := range b.Days {
+		if t.Weekday() == d {
+			return minute >= b.Start && minute < b.End
+		}
+	}
+	return false
+}
+
+// OffHoursFraction returns the share of the week outside business hours
+func (b BusinessHours) OffHoursFraction() float64 {
+	return 1 - float64(len(b.Days)*(b.End-b.Start))/float64(7*24*60)
+}
+
+// Cron returns the cron expressions that start and end business hours, as used by KEDA's
+// cron scaler and CronJobs
+func (b BusinessHours) Cron() (string, string) {
+	days := make([]string, len(b.Days))
+	for i, d := range b.Days {
+		days[i] = strconv.Itoa(int(d))
+	}
+	dow := strings.Join(days, ",")
+	return fmt.Sprintf("%d %d * * %s", b.Start%60, b.Start/60, dow), fmt.Sprintf("%d %d * * %s", b.End%60, b.End/60, dow)
+}
+
+// String formats business hours as ParseBusinessHours reads them
+func (b BusinessHours) String() string {
+	days := make([]string, len(b.Days))
+	for i, d := range b.Days {
+		days[i] = weekdayNames[d]
+	}
+	return fmt.Sprintf("%s %02d:%02d-%02d:%02d %s", strings.Join(days, ","), b.Start/60, b.Start%60, b.End/60, b.End%60, b.Location)
+}
+
+// OffHoursOptions controls the off-hours analysis
+type OffHoursOptions struct {
+	Hours        BusinessHours
+	Lookback     time.Duration // history analyzed
+	MinHistory   time.Duration // span of history a workload needs before it is judged
+	MinSamples   int           // samples needed both inside and outside business hours
+	IdleRatio    float64       // off-hours mean usage at most this share of the business-hours mean
+	PeakRatio    float64       // and no off-hours sample above this share of it
+	MinBusyUsage float64       // millicores; quieter workloads are idle rather than office-hours
+}
+
+// DefaultOffHoursOptions looks at two weeks of history, needs a week of it, and flags
+// workloads that use under 10% of their business-hours CPU off-hours and never spike
+// above a quarter of it
+var DefaultOffHoursOptions = OffHoursOptions{
+	Hours:        DefaultBusinessHours,
+	Lookback:     14 * 24 * time.Hour,
+	MinHistory:   7 * 24 * time.Hour,
+	MinSamples:   12,
+	IdleRatio:    0.1,
+	PeakRatio:    0.25,
+	MinBusyUsage: 20,
+}
+
+// OffHoursSeries returns the per-workload CPU usage series the off-hours analysis reads,
+// the same ones anomaly detection records
+func OffHoursSeries(snap *snapshot.ClusterSnapshot) map[string]float64 {
+	series := make(map[string]float64)
+	prefix := anomaly.SeriesKey(anomaly.MetricCPU, "")
+	for key, value := range anomaly.Collect(snap) {
+		if strings.HasPrefix(key, prefix) {
+			series[key] = value
+		}
+	}
+	return series
+}
+
+// RecommendOffHours finds Deployments and StatefulSets whose recorded CPU usage shows
+// activity only during business hours and recommends scaling them to zero outside them.
+// The saving is the workload's request cost for the off-hours share of the month.
+func RecommendOffHours(ctx context.Context, store history.Store, cluster string, usages []ContainerUsage, options OffHoursOptions, now time.Time) ([]Recommendation, error) {
+	snapshots, err := store.List(ctx, history.Query{Cluster: cluster, Since: now.Add(-options.Lookback)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+
+	// Current request cost of each scalable workload
+	type workload struct {
+		namespace, kind, name string
+		replicas              int
+		monthlyCost           float64
+	}
+	workloads := make(map[string]*workload)
+	var subjects []string
+	for _, u := range usages {
+		if u.WorkloadKind != "Deployment" && u.WorkloadKind != "StatefulSet" {
+			continue
+		}
+		subject := fmt.Sprintf("%s/%s/%s", u.Namespace, u.WorkloadKind, u.WorkloadName)
+		w, ok := workloads[subject]
+		if !ok {
+			w = &workload{namespace: u.Namespace, kind: u.WorkloadKind, name: u.WorkloadName}
+			workloads[subject] = w
+			subjects = append(subjects, subject)
+		}
+		w.replicas = max(w.replicas, u.Replicas)
+		w.monthlyCost += (calculateCPUSaving(u.CPURequest) + calculateMemorySaving(u.MemoryRequest)) * float64(u.Replicas)
+	}
+	sort.Strings(subjects)
+
+	start, end := options.Hours.Cron()
+	recs := make([]Recommendation, 0)
+	for _, subject := range subjects {
+		w := workloads[subject]
+		key := anomaly.SeriesKey(anomaly.MetricCPU, subject)
+
+		var busy, idle []float64
+		var first, last time.Time
+		for _, s := range snapshots {
+			value, ok := s.Series[key]
+			if !ok || s.Resolution == history.ResolutionDaily {
+				continue // daily rollups blur the pattern
+			}
+			if first.IsZero() {
+				first = s.Timestamp
+			}
+			last = s.Timestamp
+			if options.Hours.Contains(s.Timestamp) {
+				busy = append(busy, value)
+			} else {
+				idle = append(idle, value)
+			}
+		}
+		if last.Sub(first) < options.MinHistory || len(busy) < options.MinSamples || len(idle) < options.MinSamples {
+			continue
+		}
+
+		busyMean, idleMean := average(busy), average(idle)
+		if busyMean < options.MinBusyUsage || idleMean > options.IdleRatio*busyMean || maximum(idle) > options.PeakRatio*busyMean {
+			continue
+		}
+
+		saving := w.monthlyCost * options.Hours.OffHoursFraction()
+		recs = append(recs, Recommendation{
+			Type: "Off-Hours Schedule",
+			Description: fmt.Sprintf("Busy only during business hours (%s): off-hours CPU averages %.0fm against %.0fm. "+
+				"Scale to zero outside them, e.g. with a KEDA cron trigger (timezone %s, start %q, end %q, desiredReplicas %d) or scaling CronJobs",
+				options.Hours, idleMean, busyMean, options.Hours.Location, start, end, w.replicas),
+			PotentialSaving:    saving,
+			Namespace:          w.namespace,
+			WorkloadKind:       w.kind,
+			WorkloadName:       w.name,
+			ResourceType:       ResourceSchedule,
+			CurrentRequest:     int64(w.replicas),
+			RecommendedRequest: 0,
+			Usage:              int64(idleMean),
+			Replicas:           w.replicas,
+		})
+	}
+	return recs, nil
+}
+
+func average(values []float64) float64 {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+func maximum(values []float64) float64 {
+	var m float64
+	for _, v := range values {
+		m = max(m, v)
+	}
+	return m
+}
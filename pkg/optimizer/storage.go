@@ -0,0 +1,219 @@
+package optimizer
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/promql"
+	"github.com/ochestra-tech/ochestra-ai/pkg/retry"
+)
+
+// Resource types of storage recommendations
+const (
+	ResourceStorageClass = "storage-class"
+	ResourceStorageSize  = "storage-size"
+)
+
+// VolumeType is a disk type's price and the performance it gives without paying for more
+type VolumeType struct {
+	CostPerGBMonth float64 `json:"costPerGBMonth"`
+	IOPS           float64 `json:"iops"`           // baseline, 0 when provisioned separately
+	ThroughputMBps float64 `json:"throughputMBps"` // baseline, 0 when provisioned separately
+}
+
+// VolumeTypes are list prices and baseline performance of common disk types, keyed by the
+// type a StorageClass's "type" or "skuName" parameter names. Types whose performance is
+// provisioned separately are only ever moved from.
+var VolumeTypes = map[string]VolumeType{
+	"gp3":             {CostPerGBMonth: 0.08, IOPS: 3000, ThroughputMBps: 125},
+	"gp2":             {CostPerGBMonth: 0.10, IOPS: 100, ThroughputMBps: 128},
+	"io1":             {CostPerGBMonth: 0.125},
+	"io2":             {CostPerGBMonth: 0.125},
+	"st1":             {CostPerGBMonth: 0.045, IOPS: 500, ThroughputMBps: 40},
+	"sc1":             {CostPerGBMonth: 0.015, IOPS: 250, ThroughputMBps: 12},
+	"pd-ssd":          {CostPerGBMonth: 0.17, IOPS: 6000, ThroughputMBps: 240},
+	"pd-balanced":     {CostPerGBMonth: 0.10, IOPS: 3000, ThroughputMBps: 140},
+	"pd-standard":     {CostPerGBMonth: 0.04, IOPS: 75, ThroughputMBps: 12},
+	"Premium_LRS":     {CostPerGBMonth: 0.15, IOPS: 500, ThroughputMBps: 100},
+	"StandardSSD_LRS": {CostPerGBMonth: 0.075, IOPS: 500, ThroughputMBps: 60},
+	"Standard_LRS":    {CostPerGBMonth: 0.045, IOPS: 500, ThroughputMBps: 60},
+}
+
+// StorageOptions tune the storage recommendations
+type StorageOptions struct {
+	PrometheusURL   string
+	Lookback        time.Duration // usage window, and how old a claim must be
+	SizeUsageRatio  float64       // peak usage below this fraction of capacity is overprovisioned
+	MinSizeGB       float64       // claims smaller than this are not resized
+	Headroom        float64       // peak usage and I/O are multiplied by this to size the target
+	IOPSQuery       string        // PromQL giving peak IOPS per namespace and persistentvolumeclaim, "%s" is the window
+	ThroughputQuery string        // PromQL giving peak bytes per second the same way
+}
+
+// DefaultStorageOptions look at two weeks of usage and flag claims that never filled a
+// quarter of their capacity. Class moves need I/O queries, which depend on where disk
+// metrics come from, so none are set.
+var DefaultStorageOptions = StorageOptions{
+	Lookback:       14 * 24 * time.Hour,
+	SizeUsageRatio: 0.25,
+	MinSizeGB:      20,
+	Headroom:       2,
+}
+
+// peakUsedQuery is each claim's peak used bytes over the window
+const peakUsedQuery = `max by (namespace, persistentvolumeclaim) (max_over_time(kubelet_volume_stats_used_bytes[%s]))`
+
+// RecommendStorage recommends smaller claims for volumes that stayed mostly empty over the
+// lookback, and cheaper storage classes for volumes whose peak I/O fits them. Volumes cannot
+// shrink or change class in place, so both mean migrating the data to a new claim.
+func RecommendStorage(ctx context.Context, clientset kubernetes.Interface, options StorageOptions, now time.Time) ([]Recommendation, error) {
+	if options.PrometheusURL == "" {
+		return nil, nil
+	}
+	claims, err := retry.Value(ctx, retry.DefaultBackoff, func() (*v1.PersistentVolumeClaimList, error) {
+		return clientset.CoreV1().PersistentVolumeClaims("").List(ctx, metav1.ListOptions{})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list persistent volume claims: %w", err)
+	}
+	classes, err := retry.Value(ctx, retry.DefaultBackoff, func() (*storagev1.StorageClassList, error) {
+		return clientset.StorageV1().StorageClasses().List(ctx, metav1.ListOptions{})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list storage classes: %w", err)
+	}
+
+	window := promWindow(options.Lookback)
+	peakUsed, err := claimSamples(ctx, options.PrometheusURL, fmt.Sprintf(peakUsedQuery, window))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read volume usage: %w", err)
+	}
+	var peakIOPS, peakThroughput map[string]float64
+	if options.IOPSQuery != "" && options.ThroughputQuery != "" {
+		if peakIOPS, err = claimSamples(ctx, options.PrometheusURL, fmt.Sprintf(options.IOPSQuery, window)); err != nil {
+			return nil, fmt.Errorf("failed to read volume IOPS: %w", err)
+		}
+		if peakThroughput, err = claimSamples(ctx, options.PrometheusURL, fmt.Sprintf(options.ThroughputQuery, window)); err != nil {
+			return nil, fmt.Errorf("failed to read volume throughput: %w", err)
+		}
+	}
+
+	byName := make(map[string]*storagev1.StorageClass, len(classes.Items))
+	for i := range classes.Items {
+		byName[classes.Items[i].Name] = &classes.Items[i]
+	}
+
+	recs := make([]Recommendation, 0)
+	for _, pvc := range claims.Items {
+		if pvc.Status.Phase != v1.ClaimBound || pvc.Spec.StorageClassName == nil || now.Sub(pvc.CreationTimestamp.Time) < options.Lookback {
+			continue
+		}
+		class := byName[*pvc.Spec.StorageClassName]
+		if class == nil {
+			continue
+		}
+		current, ok := VolumeTypes[volumeType(class)]
+		if !ok {
+			continue
+		}
+		capacity := pvc.Status.Capacity[v1.ResourceStorage]
+		capacityGB := float64(capacity.Value()) / (1 << 30)
+		key := pvc.Namespace + "/" + pvc.Name
+
+		sizeGB := capacityGB
+		if used, ok := peakUsed[key]; ok && capacityGB >= options.MinSizeGB && used < options.SizeUsageRatio*float64(capacity.Value()) {
+			sizeGB = math.Max(math.Ceil(used*options.Headroom/(1<<30)), 1)
+			recs = append(recs, Recommendation{
+				Type: "Volume Over-provisioning",
+				Description: fmt.Sprintf("Claim of %.0fGi peaked at %.1fGi used over %s; migrate to a %.0fGi claim, volumes cannot shrink in place",
+					capacityGB, used/(1<<30), options.Lookback, sizeGB),
+				PotentialSaving:    (capacityGB - sizeGB) * current.CostPerGBMonth,
+				Namespace:          pvc.Namespace,
+				WorkloadKind:       "PersistentVolumeClaim",
+				WorkloadName:       pvc.Name,
+				ResourceType:       ResourceStorageSize,
+				CurrentRequest:     capacity.Value(),
+				RecommendedRequest: int64(sizeGB) << 30,
+				Usage:              int64(used),
+			})
+		}
+
+		iops, hasIOPS := peakIOPS[key]
+		throughput, hasThroughput := peakThroughput[key]
+		if !hasIOPS || !hasThroughput {
+			continue
+		}
+		target, targetType := cheaperClass(class, classes.Items, current, iops*options.Headroom, throughput*options.Headroom/(1<<20))
+		if target == "" {
+			continue
+		}
+		recs = append(recs, Recommendation{
+			Type: "Storage Class Over-provisioning",
+			Description: fmt.Sprintf("Volume peaked at %.0f IOPS and %.1f MB/s over %s; class %s (%s) serves that for less than %s (%s)",
+				iops, throughput/(1<<20), options.Lookback, target, volumeType(targetType), class.Name, volumeType(class)),
+			PotentialSaving: sizeGB * (current.CostPerGBMonth - VolumeTypes[volumeType(targetType)].CostPerGBMonth),
+			Namespace:       pvc.Namespace,
+			WorkloadKind:    "PersistentVolumeClaim",
+			WorkloadName:    pvc.Name,
+			ResourceType:    ResourceStorageClass,
+			Usage:           int64(iops),
+		})
+	}
+	return recs, nil
+}
+
+// cheaperClass returns the cheapest storage class of the same provisioner whose baseline
+// performance covers the needed IOPS and MB/s, if it is cheaper than the current one
+func cheaperClass(class *storagev1.StorageClass, classes []storagev1.StorageClass, current VolumeType, iops, throughputMBps float64) (string, *storagev1.StorageClass) {
+	best, bestCost := "", current.CostPerGBMonth
+	var bestClass *storagev1.StorageClass
+	for i := range classes {
+		candidate := &classes[i]
+		if candidate.Provisioner != class.Provisioner || candidate.Name == class.Name {
+			continue
+		}
+		t, ok := VolumeTypes[volumeType(candidate)]
+		if !ok || t.IOPS < iops || t.ThroughputMBps < throughputMBps || t.CostPerGBMonth >= bestCost {
+			continue
+		}
+		best, bestCost, bestClass = candidate.Name, t.CostPerGBMonth, candidate
+	}
+	return best, bestClass
+}
+
+// volumeType returns the disk type a storage class provisions
+func volumeType(class *storagev1.StorageClass) string {
+	for _, key := range []string{"type", "skuName", "skuname"} {
+		if t := class.Parameters[key]; t != "" {
+			return t
+		}
+	}
+	return ""
+}
+
+// claimSamples runs a query returning one value per claim, keyed by "<namespace>/<claim>"
+func claimSamples(ctx context.Context, baseURL, query string) (map[string]float64, error) {
+	samples, err := promql.Query(ctx, baseURL, query)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]float64, len(samples))
+	for _, s := range samples {
+		if claim := s.Labels["persistentvolumeclaim"]; claim != "" {
+			values[s.Labels["namespace"]+"/"+claim] = s.Value
+		}
+	}
+	return values, nil
+}
+
+// promWindow formats a duration as a PromQL range, in whole hours
+func promWindow(d time.Duration) string {
+	return fmt.Sprintf("%dh", max(int(d.Hours()), 1))
+}
@@ -0,0 +1,271 @@
+package orphans
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/protection"
+	"github.com/ochestra-tech/ochestra-ai/pkg/resources"
+)
+
+// BuiltinFinalizers are finalizers, or domains of finalizers, that Kubernetes itself
+// handles and that are never reported
+var BuiltinFinalizers = []string{"kubernetes", "foregroundDeletion", "orphan", "kubernetes.io", "k8s.io"}
+
+// FinalizerStuckAfter is how long an object may wait for its finalizers after deletion
+// before a finalizer whose API group is still served is reported as stuck
+var FinalizerStuckAfter = time.Hour
+
+// finalizerSkipResources are not scanned: there are many of them and they carry no finalizers
+var finalizerSkipResources = []string{"events", "leases", "endpointslices"}
+
+// StaleFinalizer is a finalizer on an object that no running controller is likely to remove
+type StaleFinalizer struct {
+	Kind      string     `json:"kind"` // as resources.Resolve accepts it, e.g. "Certificate.v1.cert-manager.io"
+	Namespace string     `json:"namespace,omitempty"`
+	Name      string     `json:"name"`
+	Finalizer string     `json:"finalizer"`
+	Reason    string     `json:"reason"`
+	DeletedAt *time.Time `json:"deletedAt,omitempty"` // set when the finalizer is blocking a deletion
+	Removed   bool       `json:"removed,omitempty"`   // removed by the scanner, see FinalizerScanner.Remove
+}
+
+// Blocking reports whether the finalizer is keeping a deleted object around
+func (f StaleFinalizer) Blocking() bool {
+	return f.DeletedAt != nil
+}
+
+// FindStaleFinalizers checks the finalizers on objects of one resource. A domain-qualified
+// finalizer ("<domain>/<name>") whose domain matches no served API group was most likely
+// left by an operator that has been uninstalled or a CRD that has been deleted. A finalizer
+// still holding an object FinalizerStuckAfter after its deletion is reported too, since its
+// controller may be gone even if its API group is left behind.
+func FindStaleFinalizers(res resources.Resource, objects []metav1.PartialObjectMetadata, groups map[string]bool, now time.Time) []StaleFinalizer {
+	var stale []StaleFinalizer
+	for i := range objects {
+		obj := &objects[i]
+		for _, finalizer := range obj.Finalizers {
+			if builtinFinalizer(finalizer) {
+				continue
+			}
+			f := StaleFinalizer{Kind: res.Ref(), Namespace: obj.Namespace, Name: obj.Name, Finalizer: finalizer}
+			if obj.DeletionTimestamp != nil {
+				deleted := obj.DeletionTimestamp.Time
+				f.DeletedAt = &deleted
+			}
+			domain := finalizerDomain(finalizer)
+			switch {
+			case domain != "" && !groupServed(domain, groups):
+				f.Reason = fmt.Sprintf("no API group for %s is served, its controller was probably uninstalled", domain)
+			case f.Blocking() && now.Sub(*f.DeletedAt) >= FinalizerStuckAfter:
+				f.Reason = fmt.Sprintf("deletion has waited %s for the finalizer's controller", now.Sub(*f.DeletedAt).Round(time.Minute))
+			default:
+				continue
+			}
+			stale = append(stale, f)
+		}
+	}
+	return stale
+}
+
+// builtinFinalizer reports whether a finalizer is handled by Kubernetes itself
+func builtinFinalizer(finalizer string) bool {
+	domain := finalizerDomain(finalizer)
+	for _, builtin := range BuiltinFinalizers {
+		if finalizer == builtin || domain == builtin || strings.HasSuffix(domain, "."+builtin) {
+			return true
+		}
+	}
+	return false
+}
+
+// finalizerDomain returns the domain of a finalizer such as "example.com/cleanup", or of a
+// dotted one such as "finalizers.example.com". Only a dotted DNS subdomain can name an API
+// group, so a bare name, or a prefix such as the "external-attacher" of
+// "external-attacher/ebs-csi-aws-com", has none and "" is returned.
+func finalizerDomain(finalizer string) string {
+	domain, _, _ := strings.Cut(finalizer, "/")
+	if !strings.Contains(domain, ".") || len(validation.IsDNS1123Subdomain(domain)) > 0 {
+		return ""
+	}
+	return domain
+}
+
+// groupServed reports whether a finalizer domain belongs to a served API group: the group
+// itself, a subdomain of it (finalizers.example.com for example.com) or a parent domain
+// of it (example.com for cache.example.com)
+func groupServed(domain string, groups map[string]bool) bool {
+	for group := range groups {
+		if group == "" {
+			continue
+		}
+		if domain == group || strings.HasSuffix(domain, "."+group) || strings.HasSuffix(group, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// FinalizerScanner looks for stale finalizers across every listable resource at most once
+// per interval, since it lists the metadata of every object in the cluster
+type FinalizerScanner struct {
+	client   *resources.Client
+	interval time.Duration
+	remove   bool
+
+	mu    sync.Mutex
+	last  time.Time
+	stale []StaleFinalizer
+}
+
+// NewFinalizerScanner creates a scanner. With remove set, stale finalizers whose API group
+// is no longer served are removed once they have blocked a deletion for
+// FinalizerStuckAfter, so the object is finally deleted.
+func NewFinalizerScanner(client *resources.Client, interval time.Duration, remove bool) *FinalizerScanner {
+	return &FinalizerScanner{client: client, interval: interval, remove: remove}
+}
+
+// Scan returns the stale finalizers from the latest scan, scanning again when the interval
+// passed. Resources that cannot be listed are skipped and reported in the error.
+func (s *FinalizerScanner) Scan(ctx context.Context, now time.Time) ([]StaleFinalizer, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.last.IsZero() && now.Sub(s.last) < s.interval {
+		return s.stale, nil
+	}
+	all, err := s.client.Resources()
+	if err != nil {
+		return s.stale, err
+	}
+	// A group whose discovery failed is still served, so its finalizers are not stale
+	groups, err := s.client.Groups()
+	if err != nil {
+		return s.stale, err
+	}
+
+	// Objects are listed once per resource, not once per version
+	listed := make(map[string]bool)
+	var scan []resources.Resource
+	for _, res := range all {
+		key := res.GVR.Group + "/" + res.GVR.Resource
+		if !res.Listable || listed[key] || slices.Contains(finalizerSkipResources, res.GVR.Resource) {
+			continue
+		}
+		listed[key] = true
+		scan = append(scan, res)
+	}
+
+	var stale []StaleFinalizer
+	var errs []error
+	for _, res := range scan {
+		if ctx.Err() != nil {
+			return s.stale, ctx.Err()
+		}
+		objects, err := s.client.ListMetadata(ctx, res, "", metav1.ListOptions{})
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		found := FindStaleFinalizers(res, objects, groups, now)
+		if s.remove {
+			s.removeBlocking(ctx, res, objects, found, groups, now)
+		}
+		stale = append(stale, found...)
+	}
+
+	sort.Slice(stale, func(i, j int) bool {
+		a, b := stale[i], stale[j]
+		if a.Blocking() != b.Blocking() {
+			return a.Blocking()
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+	s.stale = stale
+	s.last = now
+	return s.stale, errors.Join(errs...)
+}
+
+// removable reports whether a stale finalizer may be removed: it must belong to an API group
+// that is no longer served and have held a deletion for FinalizerStuckAfter. A finalizer
+// that is merely stuck may still have a controller that is slow or failing, and a young one
+// may belong to a controller that is just starting its cleanup.
+func removable(f StaleFinalizer, groups map[string]bool, now time.Time) bool {
+	if !f.Blocking() || now.Sub(*f.DeletedAt) < FinalizerStuckAfter {
+		return false
+	}
+	domain := finalizerDomain(f.Finalizer)
+	return domain != "" && !groupServed(domain, groups)
+}
+
+// removeBlocking removes the stale finalizers that block a deletion and are removable,
+// leaving objects that are not being deleted and protected objects alone
+func (s *FinalizerScanner) removeBlocking(ctx context.Context, res resources.Resource, objects []metav1.PartialObjectMetadata, found []StaleFinalizer, groups map[string]bool, now time.Time) {
+	labels := make(map[string]map[string]string, len(objects))
+	for i := range objects {
+		labels[objects[i].Namespace+"/"+objects[i].Name] = objects[i].Labels
+	}
+	for i := range found {
+		f := &found[i]
+		if !removable(*f, groups, now) {
+			continue
+		}
+		if reason := protection.Reason(f.Namespace, labels[f.Namespace+"/"+f.Name]); reason != "" {
+			log.Printf("Not removing finalizer %s from protected %s %s/%s: %s", f.Finalizer, f.Kind, f.Namespace, f.Name, reason)
+			continue
+		}
+		if err := removeFinalizer(ctx, s.client, res, f.Namespace, f.Name, f.Finalizer); err != nil {
+			log.Printf("Failed to remove finalizer %s from %s %s/%s: %v", f.Finalizer, f.Kind, f.Namespace, f.Name, err)
+			continue
+		}
+		f.Removed = true
+		log.Printf("Removed stale finalizer %s from %s %s/%s", f.Finalizer, f.Kind, f.Namespace, f.Name)
+	}
+}
+
+// removeFinalizer removes one finalizer from an object. The patch carries the object's
+// resource version, so it fails rather than dropping finalizers added in the meantime.
+func removeFinalizer(ctx context.Context, client *resources.Client, res resources.Resource, namespace, name, finalizer string) error {
+	obj, err := client.Get(ctx, res, namespace, name)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	remaining := make([]string, 0, len(obj.GetFinalizers()))
+	for _, f := range obj.GetFinalizers() {
+		if f != finalizer {
+			remaining = append(remaining, f)
+		}
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"finalizers":      remaining,
+			"resourceVersion": obj.GetResourceVersion(),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	return client.Patch(ctx, res, namespace, name, types.MergePatchType, patch)
+}
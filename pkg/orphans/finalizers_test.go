@@ -0,0 +1,215 @@
+package orphans
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	discoveryfake "k8s.io/client-go/discovery/fake"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	metadatafake "k8s.io/client-go/metadata/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/resources"
+)
+
+func TestFinalizerDomain(t *testing.T) {
+	tests := []struct {
+		finalizer string
+		want      string
+	}{
+		{"example.com/cleanup", "example.com"},
+		{"finalizers.example.com", "finalizers.example.com"},
+		{"service.k8s.aws/resources", "service.k8s.aws"},
+		{"external-attacher/ebs-csi-aws-com", ""},
+		{"cleanup", ""},
+		{"foregroundDeletion", ""},
+		{"Example.com/cleanup", ""},
+		{"example..com/cleanup", ""},
+		{"/cleanup", ""},
+	}
+	for _, tt := range tests {
+		if got := finalizerDomain(tt.finalizer); got != tt.want {
+			t.Errorf("finalizerDomain(%q) = %q, want %q", tt.finalizer, got, tt.want)
+		}
+	}
+}
+
+func TestGroupServed(t *testing.T) {
+	groups := map[string]bool{"": true, "apps": true, "cert-manager.io": true, "elbv2.k8s.aws": true}
+	tests := []struct {
+		domain string
+		want   bool
+	}{
+		{"cert-manager.io", true},
+		{"finalizers.cert-manager.io", true}, // subdomain of a group
+		{"k8s.aws", true},                    // parent domain of a group
+		{"example.com", false},
+		{"service.k8s.aws", false}, // sibling of a group
+	}
+	for _, tt := range tests {
+		if got := groupServed(tt.domain, groups); got != tt.want {
+			t.Errorf("groupServed(%q) = %v, want %v", tt.domain, got, tt.want)
+		}
+	}
+}
+
+func TestRemovable(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	groups := map[string]bool{"cert-manager.io": true}
+	deleted := func(ago time.Duration) *time.Time {
+		t := now.Add(-ago)
+		return &t
+	}
+	tests := []struct {
+		name string
+		f    StaleFinalizer
+		want bool
+	}{
+		{"unserved group, stuck", StaleFinalizer{Finalizer: "example.com/cleanup", DeletedAt: deleted(2 * time.Hour)}, true},
+		{"unserved group, just deleted", StaleFinalizer{Finalizer: "example.com/cleanup", DeletedAt: deleted(time.Minute)}, false},
+		{"unserved group, not deleted", StaleFinalizer{Finalizer: "example.com/cleanup"}, false},
+		{"served group, stuck", StaleFinalizer{Finalizer: "cert-manager.io/cleanup", DeletedAt: deleted(2 * time.Hour)}, false},
+		{"no domain, stuck", StaleFinalizer{Finalizer: "external-attacher/ebs-csi-aws-com", DeletedAt: deleted(48 * time.Hour)}, false},
+	}
+	for _, tt := range tests {
+		if got := removable(tt.f, groups, now); got != tt.want {
+			t.Errorf("%s: removable = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFindStaleFinalizers(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	groups := map[string]bool{"": true, "cert-manager.io": true}
+	object := func(name string, deletedAgo time.Duration, finalizers ...string) metav1.PartialObjectMetadata {
+		obj := metav1.PartialObjectMetadata{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, Finalizers: finalizers}}
+		if deletedAgo > 0 {
+			obj.DeletionTimestamp = &metav1.Time{Time: now.Add(-deletedAgo)}
+		}
+		return obj
+	}
+	objects := []metav1.PartialObjectMetadata{
+		object("builtin", 2*time.Hour, "kubernetes.io/pv-protection", "foregroundDeletion"),
+		object("uninstalled", 0, "example.com/cleanup"),
+		object("served", 0, "cert-manager.io/cleanup"),
+		object("stuck", 2*time.Hour, "cert-manager.io/cleanup"),
+		object("attacher", 10*time.Minute, "external-attacher/ebs-csi-aws-com"),
+	}
+
+	res := resources.Resource{GVR: schema.GroupVersionResource{Group: "example.org", Version: "v1", Resource: "widgets"}, Kind: "Widget"}
+	stale := FindStaleFinalizers(res, objects, groups, now)
+	got := make(map[string]string)
+	for _, f := range stale {
+		got[f.Name] = f.Finalizer
+	}
+	want := map[string]string{"uninstalled": "example.com/cleanup", "stuck": "cert-manager.io/cleanup"}
+	if len(got) != len(want) {
+		t.Fatalf("stale finalizers on %v, want %v", got, want)
+	}
+	for name, finalizer := range want {
+		if got[name] != finalizer {
+			t.Errorf("stale finalizer on %s = %q, want %q", name, got[name], finalizer)
+		}
+	}
+}
+
+// partialDiscovery serves the group failed but fails to list its resources, as for an
+// aggregated API whose backend is down
+type partialDiscovery struct {
+	*discoveryfake.FakeDiscovery
+	failed schema.GroupVersion
+}
+
+func (d partialDiscovery) ServerGroups() (*metav1.APIGroupList, error) {
+	groups, err := d.FakeDiscovery.ServerGroups()
+	if err != nil {
+		return nil, err
+	}
+	version := metav1.GroupVersionForDiscovery{GroupVersion: d.failed.String(), Version: d.failed.Version}
+	groups.Groups = append(groups.Groups, metav1.APIGroup{Name: d.failed.Group, Versions: []metav1.GroupVersionForDiscovery{version}, PreferredVersion: version})
+	return groups, nil
+}
+
+func (d partialDiscovery) ServerResourcesForGroupVersion(groupVersion string) (*metav1.APIResourceList, error) {
+	if groupVersion == d.failed.String() {
+		return nil, errors.New("the server is currently unable to handle the request")
+	}
+	return d.FakeDiscovery.ServerResourcesForGroupVersion(groupVersion)
+}
+
+func TestScanPartialDiscovery(t *testing.T) {
+	now := time.Now()
+	old := FinalizerStuckAfter
+	FinalizerStuckAfter = time.Hour
+	defer func() { FinalizerStuckAfter = old }()
+
+	widgets := schema.GroupVersionResource{Group: "example.org", Version: "v1", Resource: "widgets"}
+	disco := partialDiscovery{
+		FakeDiscovery: &discoveryfake.FakeDiscovery{Fake: &k8stesting.Fake{Resources: []*metav1.APIResourceList{{
+			GroupVersion: "example.org/v1",
+			APIResources: []metav1.APIResource{{Name: "widgets", Kind: "Widget", Namespaced: true, Verbs: metav1.Verbs{"get", "list", "patch"}}},
+		}}}},
+		failed: schema.GroupVersion{Group: "metrics.example.com", Version: "v1beta1"},
+	}
+
+	// Both widgets have waited two hours for their finalizers: one of a group whose discovery
+	// failed and one of a group that is not served at all
+	deleted := metav1.NewTime(now.Add(-2 * time.Hour))
+	widget := func(name, finalizer string) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion("example.org/v1")
+		obj.SetKind("Widget")
+		obj.SetNamespace("default")
+		obj.SetName(name)
+		obj.SetResourceVersion("1")
+		obj.SetFinalizers([]string{finalizer})
+		obj.SetDeletionTimestamp(&deleted)
+		return obj
+	}
+	objects := []*unstructured.Unstructured{
+		widget("unavailable", "metrics.example.com/cleanup"),
+		widget("uninstalled", "acme.io/cleanup"),
+	}
+
+	meta := metadatafake.NewSimpleMetadataClient(metadatafake.NewTestScheme())
+	meta.PrependReactor("list", "widgets", func(k8stesting.Action) (bool, runtime.Object, error) {
+		list := &metav1.List{}
+		for _, obj := range objects {
+			list.Items = append(list.Items, runtime.RawExtension{Object: &metav1.PartialObjectMetadata{ObjectMeta: metav1.ObjectMeta{
+				Namespace: obj.GetNamespace(), Name: obj.GetName(), Finalizers: obj.GetFinalizers(), DeletionTimestamp: obj.GetDeletionTimestamp(),
+			}}})
+		}
+		return true, list, nil
+	})
+	dyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{widgets: "WidgetList"}, objects[0], objects[1])
+
+	scanner := NewFinalizerScanner(resources.NewClientWith(disco, dyn, meta), time.Hour, true)
+	stale, err := scanner.Scan(context.Background(), now)
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+
+	removed := make(map[string]bool)
+	for _, f := range stale {
+		removed[f.Name] = f.Removed
+	}
+	if want := map[string]bool{"unavailable": false, "uninstalled": true}; len(removed) != len(want) || removed["unavailable"] || !removed["uninstalled"] {
+		t.Fatalf("removed = %v, want %v", removed, want)
+	}
+	for _, obj := range objects {
+		got, err := dyn.Resource(widgets).Namespace("default").Get(context.Background(), obj.GetName(), metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if keep := obj.GetName() == "unavailable"; (len(got.GetFinalizers()) == 1) != keep {
+			t.Errorf("%s finalizers = %v, want kept %v", obj.GetName(), got.GetFinalizers(), keep)
+		}
+	}
+}
@@ -0,0 +1,273 @@
+package orphans
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/retry"
+	"github.com/ochestra-tech/ochestra-ai/pkg/snapshot"
+)
+
+// Kinds of cloud resources the scan looks for
+const (
+	KindLoadBalancer = "LoadBalancer"
+	KindDisk         = "Disk"
+	KindAddress      = "Address"
+)
+
+// Resource is a cloud resource tagged as belonging to the cluster
+type Resource struct {
+	Kind        string            `json:"kind"`
+	ID          string            `json:"id"`
+	Name        string            `json:"name,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	MonthlyCost float64           `json:"monthlyCost,omitempty"` // 0 uses DefaultMonthlyCost
+}
+
+// Orphan is a cluster resource no Kubernetes object references any more
+type Orphan struct {
+	Resource
+	Owner  string `json:"owner"` // the Service or PersistentVolume its tags name
+	Reason string `json:"reason"`
+}
+
+// DefaultMonthlyCost estimates what an idle resource of each kind costs a month, for
+// resources whose inventory does not say; disk cost depends on size and is not estimated
+var DefaultMonthlyCost = map[string]float64{
+	KindLoadBalancer: 16.43,
+	KindAddress:      3.65,
+}
+
+// Tags cloud providers and their controllers put on resources they create for a Service
+// ("namespace/name") or a PersistentVolume (its name)
+var (
+	ServiceTags = []string{"kubernetes.io/service-name", "service.k8s.aws/stack"}
+	VolumeTags  = []string{"kubernetes.io/created-for/pv/name", "CSIVolumeName"}
+)
+
+// Source lists the cloud resources tagged for the cluster
+type Source interface {
+	Resources(ctx context.Context) ([]Resource, error)
+}
+
+// FileSource reads resources from a JSON file of the form {"resources": [...]}, for clouds
+// without a built-in source or inventories exported by other tools
+type FileSource struct {
+	Path string
+}
+
+// Resources reads the inventory file
+func (s FileSource) Resources(ctx context.Context) ([]Resource, error) {
+	data, err := os.ReadFile(s.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cloud inventory: %w", err)
+	}
+	var file struct {
+		Resources []Resource `json:"resources"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse cloud inventory: %w", err)
+	}
+	return file.Resources, nil
+}
+
+// AWSSource lists load balancers, EBS volumes and Elastic IPs tagged
+// kubernetes.io/cluster/<Cluster> with the AWS CLI, using its usual credentials
+type AWSSource struct {
+	Cluster string
+	Region  string // empty uses the CLI's configured region
+}
+
+// Resources runs the resource groups tagging API through the AWS CLI
+func (s AWSSource) Resources(ctx context.Context) ([]Resource, error) {
+	args := []string{"resourcegroupstaggingapi", "get-resources", "--output", "json",
+		"--tag-filters", "Key=kubernetes.io/cluster/" + s.Cluster,
+		"--resource-type-filters", "elasticloadbalancing:loadbalancer", "ec2:volume", "ec2:elastic-ip"}
+	if s.Region != "" {
+		args = append(args, "--region", s.Region)
+	}
+	out, err := exec.CommandContext(ctx, "aws", args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list AWS resources: %w", err)
+	}
+
+	var response struct {
+		ResourceTagMappingList []struct {
+			ResourceARN string `json:"ResourceARN"`
+			Tags        []struct {
+				Key   string `json:"Key"`
+				Value string `json:"Value"`
+			} `json:"Tags"`
+		} `json:"ResourceTagMappingList"`
+	}
+	if err := json.Unmarshal(out, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse AWS resources: %w", err)
+	}
+
+	resources := make([]Resource, 0, len(response.ResourceTagMappingList))
+	for _, mapping := range response.ResourceTagMappingList {
+		r := Resource{ID: mapping.ResourceARN, Tags: make(map[string]string, len(mapping.Tags))}
+		for _, tag := range mapping.Tags {
+			r.Tags[tag.Key] = tag.Value
+		}
+		r.Name = r.Tags["Name"]
+		switch {
+		case strings.Contains(r.ID, ":elasticloadbalancing:"):
+			r.Kind = KindLoadBalancer
+		case strings.Contains(r.ID, ":volume/"):
+			r.Kind = KindDisk
+		case strings.Contains(r.ID, ":elastic-ip/"):
+			r.Kind = KindAddress
+		default:
+			continue
+		}
+		resources = append(resources, r)
+	}
+	return resources, nil
+}
+
+// Find returns the resources whose tags name a Service or PersistentVolume that no longer
+// exists, or a Service that is no longer of type LoadBalancer. Resources without these
+// tags are left alone since their owner cannot be told.
+func Find(resources []Resource, services []v1.Service, volumes []v1.PersistentVolume) []Orphan {
+	loadBalancers := make(map[string]bool, len(services))
+	for _, svc := range services {
+		if svc.Spec.Type == v1.ServiceTypeLoadBalancer {
+			loadBalancers[svc.Namespace+"/"+svc.Name] = true
+		}
+	}
+	pvs := make(map[string]bool, len(volumes))
+	handles := make(map[string]bool, len(volumes))
+	for _, pv := range volumes {
+		pvs[pv.Name] = true
+		if pv.Spec.CSI != nil {
+			handles[pv.Spec.CSI.VolumeHandle] = true
+		}
+		if pv.Spec.AWSElasticBlockStore != nil {
+			handles[pv.Spec.AWSElasticBlockStore.VolumeID] = true
+		}
+		if pv.Spec.GCEPersistentDisk != nil {
+			handles[pv.Spec.GCEPersistentDisk.PDName] = true
+		}
+		if pv.Spec.AzureDisk != nil {
+			handles[pv.Spec.AzureDisk.DataDiskURI] = true
+		}
+	}
+
+	orphans := make([]Orphan, 0)
+	for _, r := range resources {
+		if r.MonthlyCost == 0 {
+			r.MonthlyCost = DefaultMonthlyCost[r.Kind]
+		}
+		switch r.Kind {
+		case KindLoadBalancer, KindAddress:
+			if owner := firstTag(r.Tags, ServiceTags); owner != "" && !loadBalancers[owner] {
+				orphans = append(orphans, Orphan{Resource: r, Owner: "Service " + owner,
+					Reason: "Service no longer exists or is no longer a LoadBalancer"})
+			}
+		case KindDisk:
+			if referenced(r.ID, handles) {
+				continue
+			}
+			if owner := firstTag(r.Tags, VolumeTags); owner != "" && !pvs[owner] {
+				orphans = append(orphans, Orphan{Resource: r, Owner: "PersistentVolume " + owner,
+					Reason: "PersistentVolume no longer exists"})
+			}
+		}
+	}
+
+	sort.Slice(orphans, func(i, j int) bool {
+		if orphans[i].MonthlyCost != orphans[j].MonthlyCost {
+			return orphans[i].MonthlyCost > orphans[j].MonthlyCost
+		}
+		return orphans[i].ID < orphans[j].ID
+	})
+	return orphans
+}
+
+// firstTag returns the value of the first of keys present in tags
+func firstTag(tags map[string]string, keys []string) string {
+	for _, key := range keys {
+		if value := tags[key]; value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// referenced reports whether a resource ID, or its last path segment as in an ARN, is a
+// volume handle of some PersistentVolume
+func referenced(id string, handles map[string]bool) bool {
+	if handles[id] {
+		return true
+	}
+	if i := strings.LastIndexByte(id, '/'); i >= 0 {
+		return handles[id[i+1:]]
+	}
+	return false
+}
+
+// Scanner looks for orphaned resources at most once per interval, since listing cloud
+// resources is slower and more expensive than reading the cluster
+type Scanner struct {
+	source    Source
+	clientset kubernetes.Interface
+	interval  time.Duration
+
+	mu      sync.Mutex
+	last    time.Time
+	orphans []Orphan
+}
+
+// NewScanner creates a scanner reading cloud resources from source
+func NewScanner(source Source, clientset kubernetes.Interface, interval time.Duration) *Scanner {
+	return &Scanner{source: source, clientset: clientset, interval: interval}
+}
+
+// Scan returns the orphans from the latest scan, scanning again when the interval passed
+func (s *Scanner) Scan(ctx context.Context, snap *snapshot.ClusterSnapshot, now time.Time) ([]Orphan, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.last.IsZero() && now.Sub(s.last) < s.interval {
+		return s.orphans, nil
+	}
+	if err := snap.Errors["services"]; err != nil {
+		return s.orphans, fmt.Errorf("services are needed to find orphaned load balancers: %w", err)
+	}
+
+	resources, err := s.source.Resources(ctx)
+	if err != nil {
+		return s.orphans, err
+	}
+	volumes, err := retry.Value(ctx, retry.DefaultBackoff, func() (*v1.PersistentVolumeList, error) {
+		return s.clientset.CoreV1().PersistentVolumes().List(ctx, metav1.ListOptions{})
+	})
+	if err != nil {
+		return s.orphans, fmt.Errorf("failed to list persistent volumes: %w", err)
+	}
+
+	s.orphans = Find(resources, snap.Services, volumes.Items)
+	s.last = now
+	return s.orphans, nil
+}
+
+// MonthlyCost sums the estimated monthly cost of orphans
+func MonthlyCost(orphans []Orphan) float64 {
+	total := 0.0
+	for _, o := range orphans {
+		total += o.MonthlyCost
+	}
+	return total
+}
@@ -0,0 +1,208 @@
+package owners
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/snapshot"
+)
+
+// Keys lists the annotation and label keys owner details are read from, in order of
+// precedence. Annotations are checked before labels with the same key.
+type Keys struct {
+	Team  []string `json:"team"`
+	Slack []string `json:"slack"`
+	Email []string `json:"email"`
+}
+
+// DefaultKeys reads the monitor's own annotations, then common team labels
+var DefaultKeys = Keys{
+	Team:  []string{"hc-monitor/team", "team", "owner"},
+	Slack: []string{"hc-monitor/slack-channel"},
+	Email: []string{"hc-monitor/owner-email"},
+}
+
+// maxDepth bounds the owner reference chains followed, in case of cycles
+const maxDepth = 10
+
+// Owner is the top-level controller of a resource and the team responsible for it
+type Owner struct {
+	Kind  string `json:"kind"`
+	Name  string `json:"name"`
+	Team  string `json:"team,omitempty"`
+	Slack string `json:"slack,omitempty"` // Slack channel, e.g. "#payments-oncall"
+	Email string `json:"email,omitempty"`
+}
+
+// Labels returns the owner details as alert labels, leaving out those that are not known
+func (o *Owner) Labels() map[string]string {
+	labels := map[string]string{"owner": o.Kind + "/" + o.Name}
+	for key, value := range map[string]string{"team": o.Team, "slack_channel": o.Slack, "owner_email": o.Email} {
+		if value != "" {
+			labels[key] = value
+		}
+	}
+	return labels
+}
+
+// object is the metadata of a resource that owner resolution reads
+type object struct {
+	labels      map[string]string
+	annotations map[string]string
+	controller  *metav1.OwnerReference
+}
+
+// groupResources are the owner kinds the snapshot does not hold, listed by their metadata
+var groupResources = []struct {
+	kind     string
+	resource string
+	client   func(kubernetes.Interface) rest.Interface
+}{
+	{"ReplicaSet", "replicasets", func(c kubernetes.Interface) rest.Interface { return c.AppsV1().RESTClient() }},
+	{"StatefulSet", "statefulsets", func(c kubernetes.Interface) rest.Interface { return c.AppsV1().RESTClient() }},
+	{"Job", "jobs", func(c kubernetes.Interface) rest.Interface { return c.BatchV1().RESTClient() }},
+	{"CronJob", "cronjobs", func(c kubernetes.Interface) rest.Interface { return c.BatchV1().RESTClient() }},
+}
+
+var (
+	mu         sync.RWMutex
+	keys       = DefaultKeys
+	objects    = make(map[string]object) // "<kind>/<namespace>/<name>" -> metadata
+	namespaces = make(map[string]object)
+)
+
+// Configure sets the keys owner details are read from
+func Configure(k Keys) {
+	mu.Lock()
+	defer mu.Unlock()
+	keys = k
+}
+
+// Refresh reloads the metadata owners are resolved from: the snapshot's pods, Deployments
+// and DaemonSets, and the namespaces, ReplicaSets, StatefulSets, Jobs and CronJobs of the
+// cluster. Resources that cannot be listed are skipped, and the error returned after the
+// rest is loaded.
+func Refresh(ctx context.Context, clientset kubernetes.Interface, snap *snapshot.ClusterSnapshot) error {
+	found := make(map[string]object, len(snap.Pods))
+	add := func(kind string, meta metav1.ObjectMeta) {
+		found[key(kind, meta.Namespace, meta.Name)] = object{
+			labels:      meta.Labels,
+			annotations: meta.Annotations,
+			controller:  metav1.GetControllerOfNoCopy(&meta),
+		}
+	}
+	for i := range snap.Pods {
+		add("Pod", snap.Pods[i].ObjectMeta)
+	}
+	for i := range snap.Deployments {
+		add("Deployment", snap.Deployments[i].ObjectMeta)
+	}
+	for i := range snap.DaemonSets {
+		add("DaemonSet", snap.DaemonSets[i].ObjectMeta)
+	}
+
+	var errs []string
+	for _, group := range groupResources {
+		items, err := snapshot.ListGroupMetadata(ctx, group.client(clientset), group.resource, "", metav1.ListOptions{})
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		for i := range items {
+			add(group.kind, items[i].ObjectMeta)
+		}
+	}
+
+	foundNamespaces := make(map[string]object)
+	items, err := snapshot.ListMetadata(ctx, clientset, "namespaces", "", metav1.ListOptions{})
+	if err != nil {
+		errs = append(errs, err.Error())
+	}
+	for _, ns := range items {
+		foundNamespaces[ns.Name] = object{labels: ns.Labels, annotations: ns.Annotations}
+	}
+
+	mu.Lock()
+	objects = found
+	if err == nil {
+		namespaces = foundNamespaces
+	}
+	mu.Unlock()
+
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to load owner metadata: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// Resolve follows a resource's controller references to its top-level controller and
+// reads the owner details from the controller, the objects it owns down to the resource,
+// and finally the namespace. It returns nil for cluster-scoped resources other than
+// namespaces, and for resources nothing is known about.
+func Resolve(kind, namespace, name string) *Owner {
+	if kind == "Namespace" && namespace == "" {
+		namespace = name
+	}
+	if namespace == "" {
+		return nil
+	}
+
+	mu.RLock()
+	defer mu.RUnlock()
+
+	// chain holds the resource and its controllers, top-level controller last
+	var chain []object
+	owner := &Owner{Kind: kind, Name: name}
+	for depth := 0; depth < maxDepth; depth++ {
+		obj, ok := objects[key(owner.Kind, namespace, owner.Name)]
+		if !ok {
+			break
+		}
+		chain = append(chain, obj)
+		if obj.controller == nil {
+			break
+		}
+		owner.Kind, owner.Name = obj.controller.Kind, obj.controller.Name
+	}
+	ns, nsKnown := namespaces[namespace]
+	if len(chain) == 0 && !nsKnown {
+		return nil
+	}
+
+	// Precedence runs from the top-level controller down, then to the namespace
+	sources := make([]object, 0, len(chain)+1)
+	for i := len(chain) - 1; i >= 0; i-- {
+		sources = append(sources, chain[i])
+	}
+	sources = append(sources, ns)
+	owner.Team = lookup(sources, keys.Team)
+	owner.Slack = lookup(sources, keys.Slack)
+	owner.Email = lookup(sources, keys.Email)
+	return owner
+}
+
+// lookup returns the first value set for any of the keys, checking each source in turn
+func lookup(sources []object, keys []string) string {
+	for _, source := range sources {
+		for _, k := range keys {
+			if value := source.annotations[k]; value != "" {
+				return value
+			}
+			if value := source.labels[k]; value != "" {
+				return value
+			}
+		}
+	}
+	return ""
+}
+
+// key identifies an object across kinds
+func key(kind, namespace, name string) string {
+	return kind + "/" + namespace + "/" + name
+}
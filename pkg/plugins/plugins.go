@@ -0,0 +1,337 @@
+package plugins
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/health"
+	"github.com/ochestra-tech/ochestra-ai/pkg/notify"
+	"github.com/ochestra-tech/ochestra-ai/pkg/protection"
+	"github.com/ochestra-tech/ochestra-ai/pkg/resources"
+)
+
+// Plugin kinds
+const (
+	KindCheck       = "check"       // returns extra health issues each cycle
+	KindSink        = "sink"        // receives each cycle's health report and every alert
+	KindRemediation = "remediation" // acts on open issues of the types it lists
+)
+
+// Events sent to sinks
+const (
+	EventHealth = "health"
+	EventAlert  = "alert"
+)
+
+// DefaultTimeout bounds a plugin run when its config does not
+var DefaultTimeout = 30 * time.Second
+
+// maxOutput bounds what is read from a plugin's stdout and kept of its stderr
+const maxOutput = 4 << 20
+
+// Plugin is an external executable speaking the JSON protocol: it reads one Request from
+// stdin, writes one Response to stdout and exits, non-zero on failure
+type Plugin struct {
+	Name       string   `json:"name"`
+	Kind       string   `json:"kind"`
+	Command    []string `json:"command"`              // executable and arguments
+	Timeout    string   `json:"timeout,omitempty"`    // e.g. "30s", defaults to DefaultTimeout
+	IssueTypes []string `json:"issueTypes,omitempty"` // remediations only
+	DryRun     bool     `json:"dryRun,omitempty"`     // remediations only: report actions without taking them
+
+	timeout time.Duration
+}
+
+// Config lists the plugins to run
+type Config struct {
+	Plugins []Plugin `json:"plugins"`
+}
+
+// LoadConfig reads plugin definitions from a JSON file
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plugins: %w", err)
+	}
+
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse plugins: %w", err)
+	}
+	for i := range config.Plugins {
+		if err := config.Plugins[i].init(); err != nil {
+			return nil, fmt.Errorf("plugin %d: %w", i, err)
+		}
+	}
+	return &config, nil
+}
+
+// init validates a plugin and parses its timeout
+func (p *Plugin) init() error {
+	if p.Name == "" || len(p.Command) == 0 {
+		return fmt.Errorf("name and command are required")
+	}
+	switch p.Kind {
+	case KindCheck, KindSink:
+	case KindRemediation:
+		if len(p.IssueTypes) == 0 {
+			return fmt.Errorf("%s must list the issueTypes it remediates", p.Name)
+		}
+	default:
+		return fmt.Errorf("%s has unknown kind %q", p.Name, p.Kind)
+	}
+
+	p.timeout = DefaultTimeout
+	if p.Timeout != "" {
+		timeout, err := time.ParseDuration(p.Timeout)
+		if err != nil || timeout <= 0 {
+			return fmt.Errorf("%s has invalid timeout %q", p.Name, p.Timeout)
+		}
+		p.timeout = timeout
+	}
+	return nil
+}
+
+// Request is written to a plugin's stdin
+type Request struct {
+	Kind    string                `json:"kind"`
+	Event   string                `json:"event,omitempty"` // sinks: EventHealth or EventAlert
+	Cluster string                `json:"cluster"`
+	Time    time.Time             `json:"time"`
+	Health  *health.ClusterHealth `json:"health,omitempty"` // sinks, health events
+	Alert   *notify.Alert         `json:"alert,omitempty"`  // sinks, alert events
+	Issue   *health.HealthIssue   `json:"issue,omitempty"`  // remediations
+	DryRun  bool                  `json:"dryRun,omitempty"` // remediations
+}
+
+// Response is read from a plugin's stdout; sinks may write nothing
+type Response struct {
+	Issues  []health.HealthIssue `json:"issues,omitempty"`  // checks: issues found
+	Actions []string             `json:"actions,omitempty"` // remediations: what was done, or would be in a dry run
+}
+
+// Run executes the plugin with a request
+func (p *Plugin) Run(ctx context.Context, req Request) (*Response, error) {
+	input, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request for plugin %s: %w", p.Name, err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, p.Command[0], p.Command[1:]...)
+	cmd.Stdin = bytes.NewReader(input)
+	stdout := &limitedBuffer{limit: maxOutput}
+	stderr := &limitedBuffer{limit: maxOutput}
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	// Don't wait on children of a killed plugin that still hold its output open
+	cmd.WaitDelay = time.Second
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			err = fmt.Errorf("timed out after %s", p.timeout)
+		}
+		return nil, fmt.Errorf("plugin %s failed: %w: %s", p.Name, err, strings.TrimSpace(stderr.String()))
+	}
+
+	var resp Response
+	if output := bytes.TrimSpace(stdout.Bytes()); len(output) > 0 {
+		if stdout.truncated {
+			return nil, fmt.Errorf("plugin %s wrote more than %d bytes", p.Name, maxOutput)
+		}
+		if err := json.Unmarshal(output, &resp); err != nil {
+			return nil, fmt.Errorf("failed to parse response of plugin %s: %w", p.Name, err)
+		}
+	}
+	return &resp, nil
+}
+
+// limitedBuffer keeps up to limit bytes written to it and drops the rest, so a noisy
+// plugin cannot exhaust memory
+type limitedBuffer struct {
+	bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.Len(); len(p) > room {
+		b.truncated = true
+		b.Buffer.Write(p[:max(room, 0)])
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
+
+// Manager runs the configured plugins for a cluster
+type Manager struct {
+	plugins []Plugin
+	cluster string
+
+	mu         sync.Mutex
+	remediated map[string]bool // plugin name + issue fingerprint -> remediation ran
+}
+
+// NewManager creates a manager for the config's plugins
+func NewManager(config *Config, cluster string) *Manager {
+	return &Manager{plugins: config.Plugins, cluster: cluster, remediated: make(map[string]bool)}
+}
+
+// Check runs the check plugins and returns their issues. Failing plugins are logged and
+// skipped so one broken check does not hide the others.
+func (m *Manager) Check(ctx context.Context, now time.Time) []health.HealthIssue {
+	issues := make([]health.HealthIssue, 0)
+	for i := range m.plugins {
+		p := &m.plugins[i]
+		if p.Kind != KindCheck {
+			continue
+		}
+		resp, err := p.Run(ctx, Request{Kind: KindCheck, Cluster: m.cluster, Time: now})
+		if err != nil {
+			log.Printf("Plugin check failed: %v", err)
+			continue
+		}
+		for _, issue := range resp.Issues {
+			switch issue.Severity {
+			case "critical", "warning", "info":
+			default:
+				issue.Severity = "warning"
+			}
+			if issue.Timestamp.IsZero() {
+				issue.Timestamp = now
+			}
+			issue.Detector = "plugin:" + p.Name
+			issues = append(issues, issue)
+		}
+	}
+	return issues
+}
+
+// Publish sends a cycle's health report to the sink plugins
+func (m *Manager) Publish(ctx context.Context, h *health.ClusterHealth) {
+	for i := range m.plugins {
+		p := &m.plugins[i]
+		if p.Kind != KindSink {
+			continue
+		}
+		if _, err := p.Run(ctx, Request{Kind: KindSink, Event: EventHealth, Cluster: m.cluster, Time: time.Now(), Health: h}); err != nil {
+			log.Printf("Plugin sink failed: %v", err)
+		}
+	}
+}
+
+// Notify sends an alert to the sink plugins, so they can be used as a notifier
+func (m *Manager) Notify(ctx context.Context, alert notify.Alert) error {
+	var lastErr error
+	for i := range m.plugins {
+		p := &m.plugins[i]
+		if p.Kind != KindSink {
+			continue
+		}
+		if _, err := p.Run(ctx, Request{Kind: KindSink, Event: EventAlert, Cluster: m.cluster, Time: time.Now(), Alert: &alert}); err != nil {
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+// Remediate runs the remediation plugins for open issues of their types, including
+// correlated children. Each plugin runs once per issue until the issue clears. Issues
+// suppressed by a maintenance window, and issues about protected namespaces or objects,
+// are left alone.
+func (m *Manager) Remediate(ctx context.Context, issues []health.HealthIssue) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	open := make(map[string]bool)
+	var visit func(issue health.HealthIssue)
+	visit = func(issue health.HealthIssue) {
+		for _, child := range issue.Children {
+			visit(child)
+		}
+		if issue.Suppressed {
+			return
+		}
+		for i := range m.plugins {
+			p := &m.plugins[i]
+			if p.Kind != KindRemediation || !slices.Contains(p.IssueTypes, issue.Type) {
+				continue
+			}
+			key := p.Name + "|" + issue.Fingerprint()
+			open[key] = true
+			if m.remediated[key] {
+				continue
+			}
+			m.remediated[key] = true
+			if err := checkTarget(ctx, issue); errors.Is(err, protection.ErrProtected) {
+				log.Printf("Plugin %s skipped %v", p.Name, err)
+				continue
+			} else if err != nil {
+				// Try again next cycle
+				delete(m.remediated, key)
+				log.Printf("Plugin %s skipped %s %s/%s: %v", p.Name, issue.Resource, issue.Namespace, issue.Name, err)
+				continue
+			}
+
+			issue := issue
+			resp, err := p.Run(ctx, Request{Kind: KindRemediation, Cluster: m.cluster, Time: time.Now(), Issue: &issue, DryRun: p.DryRun})
+			if err != nil {
+				// Try again next cycle
+				delete(m.remediated, key)
+				log.Printf("Plugin remediation of %s %s/%s failed: %v", issue.Resource, issue.Namespace, issue.Name, err)
+				continue
+			}
+			verb := "took"
+			if p.DryRun {
+				verb = "would take"
+			}
+			log.Printf("Plugin %s %s actions for %s %s/%s: %s", p.Name, verb, issue.Resource, issue.Namespace, issue.Name,
+				strings.Join(resp.Actions, "; "))
+		}
+	}
+	for _, issue := range issues {
+		visit(issue)
+	}
+
+	for key := range m.remediated {
+		if !open[key] {
+			delete(m.remediated, key)
+		}
+	}
+}
+
+// checkTarget returns an error wrapping protection.ErrProtected if the object an issue is
+// about may not be mutated. The live object is read, since it may have been labeled since
+// the issue was raised. Issues about something that is not an object, such as the cluster,
+// are checked by namespace only.
+func checkTarget(ctx context.Context, issue health.HealthIssue) error {
+	if issue.Name == "" {
+		return protection.Check(issue.Resource, issue.Namespace, issue.Name, nil)
+	}
+	client, err := resources.Shared()
+	if err != nil {
+		return err
+	}
+	res, err := client.Resolve(issue.Resource)
+	if errors.Is(err, resources.ErrNotServed) {
+		return protection.Check(issue.Resource, issue.Namespace, issue.Name, nil)
+	}
+	if err != nil {
+		return err
+	}
+	obj, err := client.Get(ctx, res, issue.Namespace, issue.Name)
+	if err != nil {
+		return fmt.Errorf("failed to read %s %s/%s: %w", issue.Resource, issue.Namespace, issue.Name, err)
+	}
+	return protection.Check(issue.Resource, issue.Namespace, issue.Name, obj.GetLabels())
+}
@@ -0,0 +1,68 @@
+package promql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Sample is one series of an instant vector query result
+type Sample struct {
+	Labels map[string]string
+	Value  float64
+}
+
+// Query runs an instant query against the Prometheus HTTP API
+func Query(ctx context.Context, baseURL, query string) ([]Sample, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	endpoint := strings.TrimSuffix(baseURL, "/") + "/api/v1/query?" + url.Values{"query": {query}}.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Prometheus request: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query Prometheus: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		Status string `json:"status"`
+		Error  string `json:"error"`
+		Data   struct {
+			ResultType string `json:"resultType"`
+			Result     []struct {
+				Metric map[string]string `json:"metric"`
+				Value  [2]interface{}    `json:"value"` // [unix time, "value"]
+			} `json:"result"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to parse Prometheus response (HTTP %d): %w", resp.StatusCode, err)
+	}
+	if body.Status != "success" {
+		return nil, fmt.Errorf("prometheus query failed: %s", body.Error)
+	}
+	if body.Data.ResultType != "vector" {
+		return nil, fmt.Errorf("unexpected Prometheus result type %q", body.Data.ResultType)
+	}
+
+	samples := make([]Sample, 0, len(body.Data.Result))
+	for _, r := range body.Data.Result {
+		s, _ := r.Value[1].(string)
+		value, err := strconv.ParseFloat(s, 64)
+		if err != nil || math.IsNaN(value) {
+			continue // NaN for containers without CFS periods
+		}
+		samples = append(samples, Sample{Labels: r.Metric, Value: value})
+	}
+	return samples, nil
+}
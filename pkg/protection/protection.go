@@ -0,0 +1,100 @@
+package protection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"sync"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/retry"
+)
+
+// Label marks an object, or a namespace and everything in it, as off limits to every
+// path that mutates the cluster: cleanup, remediation and exported changes
+const Label = "hc-monitor/protected"
+
+// ErrProtected is returned, wrapped with the reason, when a mutation is refused
+var ErrProtected = errors.New("protected")
+
+// SystemNamespaces are always protected
+var SystemNamespaces = []string{"kube-system"}
+
+var (
+	mu         sync.RWMutex
+	namespaces = append([]string{}, SystemNamespaces...) // path.Match patterns
+	labeled    = make(map[string]bool)                   // namespaces carrying Label
+)
+
+// Configure protects the namespaces matching the patterns in addition to SystemNamespaces
+func Configure(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid protected namespace pattern %q: %w", pattern, err)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	namespaces = append(append([]string{}, SystemNamespaces...), patterns...)
+	return nil
+}
+
+// Refresh reloads which namespaces carry Label
+func Refresh(ctx context.Context, clientset kubernetes.Interface) error {
+	list, err := retry.Value(ctx, retry.DefaultBackoff, func() (*v1.NamespaceList, error) {
+		return clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{LabelSelector: Label + "=true"})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list protected namespaces: %w", err)
+	}
+
+	found := make(map[string]bool, len(list.Items))
+	for _, ns := range list.Items {
+		found[ns.Name] = true
+	}
+	mu.Lock()
+	labeled = found
+	mu.Unlock()
+	return nil
+}
+
+// Namespace returns why a namespace is protected, or "" if it is not
+func Namespace(namespace string) string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	if labeled[namespace] {
+		return fmt.Sprintf("namespace %s is labeled %s=true", namespace, Label)
+	}
+	for _, pattern := range namespaces {
+		if ok, _ := path.Match(pattern, namespace); ok {
+			return fmt.Sprintf("namespace %s is protected", namespace)
+		}
+	}
+	return ""
+}
+
+// Reason returns why an object with the labels in namespace is protected, or "" if it may
+// be mutated
+func Reason(namespace string, labels map[string]string) string {
+	if reason := Namespace(namespace); reason != "" {
+		return reason
+	}
+	if labels[Label] == "true" {
+		return fmt.Sprintf("labeled %s=true", Label)
+	}
+	return ""
+}
+
+// Check returns an error wrapping ErrProtected if the object may not be mutated
+func Check(kind, namespace, name string, labels map[string]string) error {
+	if reason := Reason(namespace, labels); reason != "" {
+		return fmt.Errorf("%w: %s %s/%s: %s", ErrProtected, kind, namespace, name, reason)
+	}
+	return nil
+}
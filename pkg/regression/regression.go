@@ -0,0 +1,273 @@
+package regression
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/health"
+	"github.com/ochestra-tech/ochestra-ai/pkg/notify"
+)
+
+// Config controls namespace score regression alerts
+type Config struct {
+	Drop   int           // points a namespace's score must fall from its best within Window
+	Window time.Duration // how far back the best score is looked for
+}
+
+// DefaultConfig alerts on a 15 point drop within 6 hours
+var DefaultConfig = Config{
+	Drop:   15,
+	Window: 6 * time.Hour,
+}
+
+// maxCauses bounds the issues listed in an alert
+const maxCauses = 5
+
+// Cause is an issue that appeared in a namespace since its best score, with the points it costs
+type Cause struct {
+	Issue   health.HealthIssue `json:"issue"`
+	Penalty float64            `json:"penalty"`
+}
+
+// Regression is a namespace whose health score fell by at least Config.Drop within the window
+type Regression struct {
+	Namespace string    `json:"namespace"`
+	From      int       `json:"from"` // best score in the window
+	To        int       `json:"to"`
+	FromTime  time.Time `json:"fromTime"`
+	Causes    []Cause   `json:"causes"` // new issues since the best score, costliest first
+}
+
+// sample is a namespace's score at a check, with the fingerprints of its issues
+type sample struct {
+	Time   time.Time `json:"time"`
+	Score  int       `json:"score"`
+	Issues []string  `json:"issues,omitempty"`
+}
+
+// Tracker keeps each namespace's recent scores and alerts when one regresses, naming the
+// issues that appeared since its best score
+type Tracker struct {
+	config   Config
+	path     string
+	notifier notify.Notifier
+	cluster  string
+
+	mu    sync.Mutex
+	state struct {
+		Samples map[string][]sample   `json:"samples"` // namespace -> samples in the window, oldest first
+		Open    map[string]Regression `json:"open,omitempty"`
+	}
+}
+
+// LoadTracker creates a tracker, restoring the samples persisted at path if it is not empty
+func LoadTracker(config Config, path string, notifier notify.Notifier, cluster string) (*Tracker, error) {
+	t := &Tracker{config: config, path: path, notifier: notifier, cluster: cluster}
+	t.state.Samples = make(map[string][]sample)
+	t.state.Open = make(map[string]Regression)
+	if path == "" {
+		return t, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return t, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read score history: %w", err)
+	}
+	if err := json.Unmarshal(data, &t.state); err != nil {
+		return nil, fmt.Errorf("failed to parse score history: %w", err)
+	}
+	if t.state.Samples == nil {
+		t.state.Samples = make(map[string][]sample)
+	}
+	if t.state.Open == nil {
+		t.state.Open = make(map[string]Regression)
+	}
+	return t, nil
+}
+
+// Check records each namespace's score from a detailed health report and returns the
+// namespaces that regressed, alerting when a regression opens or clears
+func (t *Tracker) Check(ctx context.Context, h *health.ClusterHealth, now time.Time) []Regression {
+	scores := health.NamespaceScores(h)
+	issues := make(map[string][]health.HealthIssue)
+	for _, issue := range h.Issues {
+		if issue.Namespace != "" {
+			issues[issue.Namespace] = append(issues[issue.Namespace], issue)
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	cutoff := now.Add(-t.config.Window)
+	current := make(map[string]Regression)
+	for ns, score := range scores {
+		fingerprints := make([]string, len(issues[ns]))
+		for i, issue := range issues[ns] {
+			fingerprints[i] = issue.Fingerprint()
+		}
+		sort.Strings(fingerprints)
+		samples := t.state.Samples[ns]
+		if n := len(samples); n > 0 && samples[n-1].Score == score && equal(samples[n-1].Issues, fingerprints) {
+			samples[n-1].Time = now // unchanged; keep one sample per state to bound the history
+		} else {
+			samples = append(samples, sample{Time: now, Score: score, Issues: fingerprints})
+		}
+		for len(samples) > 0 && samples[0].Time.Before(cutoff) {
+			samples = samples[1:]
+		}
+		t.state.Samples[ns] = samples
+
+		best := samples[0]
+		for _, s := range samples[1:] {
+			if s.Score >= best.Score {
+				best = s // the latest of equal scores, so the causes are the most recent changes
+			}
+		}
+		if best.Score-score < t.config.Drop {
+			continue
+		}
+		current[ns] = Regression{
+			Namespace: ns,
+			From:      best.Score,
+			To:        score,
+			FromTime:  best.Time,
+			Causes:    newIssues(issues[ns], best.Issues),
+		}
+	}
+	for ns := range t.state.Samples {
+		if _, ok := scores[ns]; !ok {
+			delete(t.state.Samples, ns) // deleted, or its health data is missing this check
+		}
+	}
+
+	result := make([]Regression, 0, len(current))
+	for _, r := range current {
+		if _, ok := t.state.Open[r.Namespace]; !ok {
+			t.notify(ctx, r, "", now)
+		}
+		result = append(result, r)
+	}
+	for ns, r := range t.state.Open {
+		if _, ok := current[ns]; ok {
+			continue
+		}
+		resolution := fmt.Sprintf("Namespace %s is no longer reported", ns)
+		if score, ok := scores[ns]; ok {
+			resolution = fmt.Sprintf("Health score of namespace %s is %d, within %d points of its best in the last %s",
+				ns, score, t.config.Drop, t.config.Window)
+		}
+		t.notify(ctx, r, resolution, now)
+	}
+	t.state.Open = current
+	sort.Slice(result, func(i, j int) bool { return result[i].Namespace < result[j].Namespace })
+
+	if err := t.save(); err != nil {
+		log.Printf("Failed to save score history: %v", err)
+	}
+	return result
+}
+
+// newIssues returns the issues whose fingerprints were not present before, costliest first
+func newIssues(issues []health.HealthIssue, before []string) []Cause {
+	seen := make(map[string]bool, len(before))
+	for _, fingerprint := range before {
+		seen[fingerprint] = true
+	}
+
+	causes := make([]Cause, 0)
+	for _, issue := range issues {
+		if !seen[issue.Fingerprint()] {
+			causes = append(causes, Cause{Issue: issue, Penalty: health.IssuePenalty(issue)})
+		}
+	}
+	sort.SliceStable(causes, func(i, j int) bool { return causes[i].Penalty > causes[j].Penalty })
+	return causes
+}
+
+// equal reports whether two sorted fingerprint lists are the same
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Summary describes a regression and the issues behind it
+func (r Regression) Summary() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Health score of namespace %s fell from %d to %d since %s", r.Namespace, r.From, r.To, r.FromTime.Format(time.RFC3339))
+	if len(r.Causes) == 0 {
+		b.WriteString("; no new issues, existing ones got worse")
+		return b.String()
+	}
+	b.WriteString(". New issues:")
+	for i, c := range r.Causes {
+		if i == maxCauses {
+			fmt.Fprintf(&b, "\n- and %d more", len(r.Causes)-i)
+			break
+		}
+		fmt.Fprintf(&b, "\n- [%s] %s %s: %s (-%.0f)", c.Issue.Severity, c.Issue.Resource, c.Issue.Name, c.Issue.Message, c.Penalty)
+	}
+	return b.String()
+}
+
+// notify sends an alert for a regression that opened, or cleared if resolution is set
+func (t *Tracker) notify(ctx context.Context, r Regression, resolution string, now time.Time) {
+	severity := "warning"
+	for _, c := range r.Causes {
+		if c.Issue.Severity == "critical" {
+			severity = "critical"
+			break
+		}
+	}
+	alert := notify.Alert{
+		Title:       fmt.Sprintf("Namespace %s health score dropped %d points", r.Namespace, r.From-r.To),
+		Message:     r.Summary(),
+		Severity:    severity,
+		Source:      "regression",
+		Labels:      map[string]string{"cluster": t.cluster, "namespace": r.Namespace},
+		Timestamp:   now,
+		Fingerprint: "score-regression/" + r.Namespace,
+	}
+	if resolution != "" {
+		alert.Title = fmt.Sprintf("Resolved: Namespace %s health score regression", r.Namespace)
+		alert.Message = resolution
+		alert.Severity = "info"
+		alert.Resolved = true
+	}
+
+	if err := t.notifier.Notify(ctx, alert); err != nil {
+		log.Printf("Failed to send score regression alert %q: %v", alert.Title, err)
+	}
+}
+
+// save persists the samples if the tracker has a state file. The caller holds the lock.
+func (t *Tracker) save() error {
+	if t.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(t.state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal score history: %w", err)
+	}
+	if err := os.WriteFile(t.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write score history: %w", err)
+	}
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"html/template"
 	"io"
+	"log"
 	"sort"
 	"time"
 
@@ -15,6 +16,8 @@ import (
 
 	"github.com/ochestra-tech/ochestra-ai/pkg/cost"
 	"github.com/ochestra-tech/ochestra-ai/pkg/health"
+	"github.com/ochestra-tech/ochestra-ai/pkg/history"
+	"github.com/ochestra-tech/ochestra-ai/pkg/trends"
 )
 
 // ReportFormat specifies the output format for reports
@@ -54,6 +57,42 @@ const (
 		</tr>
 		{{end}}
 	</table>
+	{{if .Trends}}
+	<h2>Trends</h2>
+	<table>
+		<tr>
+			<th>Metric</th>
+			<th>Latest</th>
+			<th>Last 7 Days</th>
+			<th>Previous 7 Days</th>
+			<th>Change</th>
+			<th>Forecast</th>
+			<th>Per Day</th>
+		</tr>
+		{{range .Trends}}
+		<tr>
+			<td>{{.Metric}}</td>
+			<td>{{with .Latest}}{{printf "%.2f" .Value}}{{end}}</td>
+			{{with .WeekOverWeek}}
+			<td>{{printf "%.2f" .Current}}</td>
+			<td>{{printf "%.2f" .Previous}}</td>
+			<td>{{.Summary}}</td>
+			{{else}}
+			<td></td>
+			<td></td>
+			<td></td>
+			{{end}}
+			{{with .Forecast}}
+			<td>{{printf "%.2f" .Value}} on {{.At.Format "2006-01-02"}}</td>
+			<td>{{printf "%+.2f" .SlopePerDay}} (R² {{printf "%.2f" .R2}})</td>
+			{{else}}
+			<td></td>
+			<td></td>
+			{{end}}
+		</tr>
+		{{end}}
+	</table>
+	{{end}}
 </body>
 </html>`
 
@@ -72,7 +111,46 @@ const (
 	<ul>
 		<li>Total Nodes: {{.NodeStatus.TotalNodes}}</li>
 		<li>Ready Nodes: {{.NodeStatus.ReadyNodes}}</li>
+		{{if gt (len .NodeStatus.ByOS) 1}}{{range .NodeStatus.ByOS}}
+		<li>{{.OS}}: {{.Nodes}} nodes, {{.ReadyNodes}} ready, {{.PressureNodes}} under pressure</li>
+		{{end}}{{end}}
 	</ul>
+	{{if .Trends}}
+	<h2>Trends</h2>
+	<table>
+		<tr>
+			<th>Metric</th>
+			<th>Latest</th>
+			<th>Last 7 Days</th>
+			<th>Previous 7 Days</th>
+			<th>Change</th>
+			<th>Forecast</th>
+			<th>Per Day</th>
+		</tr>
+		{{range .Trends}}
+		<tr>
+			<td>{{.Metric}}</td>
+			<td>{{with .Latest}}{{printf "%.2f" .Value}}{{end}}</td>
+			{{with .WeekOverWeek}}
+			<td>{{printf "%.2f" .Current}}</td>
+			<td>{{printf "%.2f" .Previous}}</td>
+			<td>{{.Summary}}</td>
+			{{else}}
+			<td></td>
+			<td></td>
+			<td></td>
+			{{end}}
+			{{with .Forecast}}
+			<td>{{printf "%.2f" .Value}} on {{.At.Format "2006-01-02"}}</td>
+			<td>{{printf "%+.2f" .SlopePerDay}} (R² {{printf "%.2f" .R2}})</td>
+			{{else}}
+			<td></td>
+			<td></td>
+			{{end}}
+		</tr>
+		{{end}}
+	</table>
+	{{end}}
 </body>
 </html>`
 
@@ -111,14 +189,16 @@ const (
 
 // ReportGenerator handles report generation
 type ReportGenerator struct {
-	clientset     *kubernetes.Clientset
-	metricsClient *metricsv.Clientset
+	clientset     kubernetes.Interface
+	metricsClient metricsv.Interface
 	format        ReportFormat
 	writer        io.Writer
+	history       history.Store // adds trends to HTML reports when set
+	cluster       string
 }
 
 // NewReportGenerator creates a new report generator
-func NewReportGenerator(clientset *kubernetes.Clientset, metricsClient *metricsv.Clientset, format ReportFormat, writer io.Writer) *ReportGenerator {
+func NewReportGenerator(clientset kubernetes.Interface, metricsClient metricsv.Interface, format ReportFormat, writer io.Writer) *ReportGenerator {
 	return &ReportGenerator{
 		clientset:     clientset,
 		metricsClient: metricsClient,
@@ -127,6 +207,25 @@ func NewReportGenerator(clientset *kubernetes.Clientset, metricsClient *metricsv
 	}
 }
 
+// SetHistory adds the trends of the cluster's history to HTML reports
+func (r *ReportGenerator) SetHistory(store history.Store, cluster string) {
+	r.history = store
+	r.cluster = cluster
+}
+
+// loadTrends analyzes the history for the HTML trend section, or returns nil without one
+func (r *ReportGenerator) loadTrends() []trends.Report {
+	if r.history == nil {
+		return nil
+	}
+	reports, err := trends.Load(context.Background(), r.history, r.cluster, trends.Metrics, "", trends.DefaultOptions, time.Now())
+	if err != nil {
+		log.Printf("Failed to analyze trends: %v", err)
+		return nil
+	}
+	return reports
+}
+
 // GenerateHealthReport generates a comprehensive health report
 func (r *ReportGenerator) GenerateHealthReport(ctx context.Context) error {
 	healthData, err := health.GetClusterHealth(ctx, r.clientset, r.metricsClient)
@@ -218,7 +317,14 @@ func (r *ReportGenerator) generateHealthReportJSON(healthData *health.ClusterHea
 // generateHealthReportHTML generates an HTML health report
 func (r *ReportGenerator) generateHealthReportHTML(healthData *health.ClusterHealth) error {
 	tmpl := template.Must(template.New("health").Parse(healthReportHTMLTemplate))
-	return tmpl.Execute(r.writer, healthData)
+	data := struct {
+		*health.ClusterHealth
+		Trends []trends.Report
+	}{
+		ClusterHealth: healthData,
+		Trends:        r.loadTrends(),
+	}
+	return tmpl.Execute(r.writer, data)
 }
 
 // generateCostReportJSON generates a JSON cost report
@@ -253,7 +359,13 @@ func (r *ReportGenerator) generateHealthReportText(healthData *health.ClusterHea
 	fmt.Fprintf(r.writer, "Disk Pressure Nodes:            %d\n", healthData.NodeStatus.DiskPressureNodes)
 	fmt.Fprintf(r.writer, "PID Pressure Nodes:             %d\n", healthData.NodeStatus.PIDPressureNodes)
 	fmt.Fprintf(r.writer, "Network Unavailable Nodes:      %d\n", healthData.NodeStatus.NetworkUnavailableNodes)
-	fmt.Fprintf(r.writer, "Average Node Load:              %.2f\n\n", healthData.NodeStatus.AverageLoad)
+	fmt.Fprintf(r.writer, "Average Node Load:              %.2f\n", healthData.NodeStatus.AverageLoad)
+	if len(healthData.NodeStatus.ByOS) > 1 {
+		for _, p := range healthData.NodeStatus.ByOS {
+			fmt.Fprintf(r.writer, "  %-28s %d nodes, %d ready, %d under pressure\n", p.OS+":", p.Nodes, p.ReadyNodes, p.PressureNodes)
+		}
+	}
+	fmt.Fprintln(r.writer)
 
 	// Pod Health Summary
 	fmt.Fprintf(r.writer, "--- Pod Health ---\n")
@@ -266,6 +378,9 @@ func (r *ReportGenerator) generateHealthReportText(healthData *health.ClusterHea
 
 	// Control Plane Status
 	fmt.Fprintf(r.writer, "--- Control Plane Status ---\n")
+	if cp := healthData.ControlPlaneStatus; cp.Distribution != "" {
+		fmt.Fprintf(r.writer, "Distribution:                   %s (embedded control plane: %v)\n", cp.Distribution, cp.Embedded)
+	}
 	fmt.Fprintf(r.writer, "API Server Healthy:             %v\n", healthData.ControlPlaneStatus.APIServerHealthy)
 	fmt.Fprintf(r.writer, "Controller Manager Healthy:     %v\n", healthData.ControlPlaneStatus.ControllerHealthy)
 	fmt.Fprintf(r.writer, "Scheduler Healthy:              %v\n", healthData.ControlPlaneStatus.SchedulerHealthy)
@@ -273,11 +388,167 @@ func (r *ReportGenerator) generateHealthReportText(healthData *health.ClusterHea
 	fmt.Fprintf(r.writer, "CoreDNS Healthy:                %v\n", healthData.ControlPlaneStatus.CoreDNSHealthy)
 	fmt.Fprintf(r.writer, "API Server Latency:             %.2f ms\n\n", healthData.ControlPlaneStatus.APIServerLatency)
 
+	// CoreDNS Metrics
+	if dns := healthData.NetworkStatus.DNS; dns.MetricsVisible {
+		fmt.Fprintf(r.writer, "--- CoreDNS ---\n")
+		fmt.Fprintf(r.writer, "Responses:                      %.0f\n", dns.Responses)
+		fmt.Fprintf(r.writer, "SERVFAIL Rate:                  %.1f%%\n", dns.ServfailRate*100)
+		fmt.Fprintf(r.writer, "Cache Hit Ratio:                %.1f%%\n", dns.CacheHitRatio*100)
+		fmt.Fprintf(r.writer, "Forward Latency:                %s\n", dns.ForwardLatency.Round(time.Millisecond))
+		fmt.Fprintf(r.writer, "Panics:                         %d\n\n", dns.Panics)
+	}
+
+	// Etcd Pressure
+	fmt.Fprintf(r.writer, "--- Etcd Pressure ---\n")
+	for _, resource := range []struct{ key, label string }{
+		{"pods", "Pods:"}, {"services", "Services:"}, {"endpoints", "Endpoints:"},
+		{"secrets", "Secrets:"}, {"configmaps", "ConfigMaps:"}, {"events", "Events:"},
+	} {
+		if count, ok := healthData.EtcdStatus.ObjectCounts[resource.key]; ok {
+			fmt.Fprintf(r.writer, "%-32s%d (%.0f%% of limit)\n", resource.label, count, healthData.EtcdStatus.Usage[resource.key]*100)
+		}
+	}
+	if healthData.EtcdStatus.DBSizeBytes > 0 {
+		fmt.Fprintf(r.writer, "Database Size:                  %d MiB of %d MiB\n", healthData.EtcdStatus.DBSizeBytes>>20, healthData.EtcdStatus.QuotaBytes>>20)
+	}
+	fmt.Fprintln(r.writer)
+
+	// Node Software Inventory
+	fmt.Fprintf(r.writer, "--- Node Software Inventory ---\n")
+	for _, component := range []struct{ key, label string }{
+		{health.ComponentKubelet, "Kubelet"}, {health.ComponentRuntime, "Container Runtime"},
+		{health.ComponentOSImage, "OS Image"}, {health.ComponentKernel, "Kernel"},
+	} {
+		versions := healthData.Inventory.Versions[component.key]
+		names := make([]string, 0, len(versions))
+		for version := range versions {
+			names = append(names, version)
+		}
+		sort.Strings(names)
+		for _, version := range names {
+			fmt.Fprintf(r.writer, "%-32s%s (%d nodes)\n", component.label+":", version, len(versions[version]))
+		}
+	}
+	for _, f := range healthData.Inventory.Denied {
+		fmt.Fprintf(r.writer, "Denylisted: %s runs %s %s: %s\n", f.Node, f.Component, f.Version, f.Reason)
+	}
+	for _, f := range healthData.Inventory.Outliers {
+		fmt.Fprintf(r.writer, "Outlier: %s runs %s %s (%s)\n", f.Node, f.Component, f.Version, f.Reason)
+	}
+	fmt.Fprintln(r.writer)
+
+	// Cordons and Taints
+	fmt.Fprintf(r.writer, "--- Cordons and Taints ---\n")
+	fmt.Fprintf(r.writer, "Cordoned Nodes:                 %d (%.1f cores, %.1f GiB)\n", len(healthData.TaintStatus.CordonedNodes),
+		healthData.TaintStatus.CordonedCPU, healthData.TaintStatus.CordonedMemory)
+	for _, c := range healthData.TaintStatus.CordonedNodes {
+		since := "unknown"
+		if !c.Since.IsZero() {
+			since = c.Since.Format(time.RFC3339)
+		}
+		fmt.Fprintf(r.writer, "Cordoned: %s since %s\n", c.Node, since)
+	}
+	for _, t := range healthData.TaintStatus.TaintedNodes {
+		fmt.Fprintf(r.writer, "Tainted: %s %v\n", t.Node, t.Taints)
+	}
+	for _, p := range healthData.TaintStatus.BlockedPods {
+		fmt.Fprintf(r.writer, "Blocked: %s/%s does not tolerate %v\n", p.Namespace, p.Name, p.Taints)
+	}
+	fmt.Fprintln(r.writer)
+
+	// Node Filesystems
+	fmt.Fprintf(r.writer, "--- Node Filesystems ---\n")
+	for _, fs := range healthData.Filesystems {
+		fmt.Fprintf(r.writer, "%s: nodefs %.0f%%, imagefs %.0f%%, %d MiB of images, %d disk pressure events\n", fs.Node,
+			fs.NodefsUsage*100, fs.ImagefsUsage*100, fs.CachedImageBytes>>20, fs.DiskPressureEvents)
+		for _, image := range fs.LargestImages {
+			fmt.Fprintf(r.writer, "  %s (%d MiB)\n", image.Name, image.SizeBytes>>20)
+		}
+	}
+	fmt.Fprintln(r.writer)
+
+	// Pod IP Capacity
+	fmt.Fprintf(r.writer, "--- Pod IP Capacity ---\n")
+	fmt.Fprintf(r.writer, "Pod IPs In Use:                 %d of %d (%.0f%%)\n", healthData.IPStatus.Used, healthData.IPStatus.Capacity,
+		healthData.IPStatus.Usage*100)
+	for _, n := range healthData.IPStatus.AtRisk {
+		fmt.Fprintf(r.writer, "At Risk: %s %d/%d (%s limit)\n", n.Node, n.Used, n.Capacity, n.Limit)
+	}
+	fmt.Fprintln(r.writer)
+
+	// Service Provisioning
+	fmt.Fprintf(r.writer, "--- Service Provisioning ---\n")
+	fmt.Fprintf(r.writer, "Node Ports Allocated:           %d of %d\n", healthData.NetworkStatus.NodePortsUsed, healthData.NetworkStatus.NodePortsTotal)
+	for _, lb := range healthData.NetworkStatus.PendingLoadBalancers {
+		fmt.Fprintf(r.writer, "Pending LoadBalancer: %s/%s since %s\n", lb.Namespace, lb.Name, lb.Created.Format(time.RFC3339))
+	}
+	fmt.Fprintln(r.writer)
+
+	// Network Policies
+	fmt.Fprintf(r.writer, "--- Network Policies ---\n")
+	fmt.Fprintf(r.writer, "Policies:                       %d\n", healthData.NetworkStatus.NetworkPoliciesCount)
+	fmt.Fprintf(r.writer, "Namespaces Without Policies:    %d\n", len(healthData.NetworkStatus.Policies.OpenNamespaces))
+	for _, key := range healthData.NetworkStatus.Policies.UnusedPolicies {
+		fmt.Fprintf(r.writer, "Unused: %s\n", key)
+	}
+	for _, c := range healthData.NetworkStatus.Policies.Conflicts {
+		fmt.Fprintf(r.writer, "Conflict: %s/%s %s deny-all overridden by %s\n", c.Namespace, c.Deny, c.Direction, c.Allow)
+	}
+	fmt.Fprintln(r.writer)
+
+	// kube-proxy
+	fmt.Fprintf(r.writer, "--- kube-proxy ---\n")
+	if !healthData.KubeProxyStatus.Installed {
+		fmt.Fprintf(r.writer, "Not installed (replaced by the CNI or not visible)\n")
+	} else {
+		fmt.Fprintf(r.writer, "Configured Mode:                %s\n", healthData.KubeProxyStatus.Mode)
+		fmt.Fprintf(r.writer, "Metrics Visible:                %t\n", healthData.KubeProxyStatus.MetricsVisible)
+		for _, n := range healthData.KubeProxyStatus.Nodes {
+			fmt.Fprintf(r.writer, "%s: ready=%t mode=%s restarts=%d", n.Node, n.Ready, n.Mode, n.Restarts)
+			if n.MetricsVisible {
+				fmt.Fprintf(r.writer, " sync=%s pending=%d failures=%d stale=%t", n.SyncDuration.Round(time.Millisecond),
+					n.PendingChanges, n.RestoreFailures, n.StaleRules)
+			}
+			fmt.Fprintln(r.writer)
+		}
+	}
+	fmt.Fprintln(r.writer)
+
+	// DaemonSet Coverage
+	fmt.Fprintf(r.writer, "--- DaemonSet Coverage ---\n")
+	for _, c := range healthData.DaemonSetCoverage {
+		fmt.Fprintf(r.writer, "%s/%s: %d/%d eligible nodes\n", c.Namespace, c.Name, c.CoveredNodes, c.EligibleNodes)
+		for _, node := range c.MissingNodes {
+			fmt.Fprintf(r.writer, "  Missing: %s\n", node)
+		}
+	}
+	fmt.Fprintln(r.writer)
+
+	// Event Volume
+	fmt.Fprintf(r.writer, "--- Events ---\n")
+	fmt.Fprintf(r.writer, "Stored Events:                  %d\n", healthData.EventStatus.TotalEvents)
+	fmt.Fprintf(r.writer, "Events in Last Hour:            %.0f\n", healthData.EventStatus.EventsPerHour)
+	for _, storm := range healthData.EventStatus.Storms {
+		fmt.Fprintf(r.writer, "Storm: %s from %s for %s %s/%s at %.0f/hour\n",
+			storm.Reason, storm.Source, storm.Kind, storm.Namespace, storm.Name, storm.PerHour)
+	}
+	for _, source := range healthData.EventStatus.NoisySources {
+		fmt.Fprintf(r.writer, "Noisy Source: %s at %.0f/hour\n", source.Source, source.PerHour)
+	}
+	fmt.Fprintln(r.writer)
+
 	// Resource Usage
 	fmt.Fprintf(r.writer, "--- Resource Usage ---\n")
 	fmt.Fprintf(r.writer, "Cluster CPU Usage:              %.1f%%\n", healthData.ResourceUsage.ClusterCPUUsage)
 	fmt.Fprintf(r.writer, "Cluster Memory Usage:           %.1f%%\n", healthData.ResourceUsage.ClusterMemoryUsage)
-	fmt.Fprintf(r.writer, "Cluster Storage Usage:          %.1f%%\n\n", healthData.ResourceUsage.ClusterStorageUsage)
+	fmt.Fprintf(r.writer, "Cluster Storage Usage:          %.1f%%\n", healthData.ResourceUsage.ClusterStorageUsage)
+	for _, v := range healthData.ResourceUsage.FullVolumes {
+		fmt.Fprintf(r.writer, "Full Volume: %s/%s on %s %.0f%% space, %.0f%% inodes\n", v.Namespace, v.PVC, v.Node, v.Usage*100, v.InodeUsage*100)
+	}
+	for _, t := range healthData.ResourceUsage.StorageTrends {
+		fmt.Fprintf(r.writer, "Filling: %s grows %d MiB/day, full in %s\n", t.Namespace, t.GrowthPerDay>>20, t.FullIn.Round(time.Hour))
+	}
+	fmt.Fprintln(r.writer)
 
 	// Health Issues
 	if len(healthData.Issues) > 0 {
@@ -287,9 +558,15 @@ func (r *ReportGenerator) generateHealthReportText(healthData *health.ClusterHea
 				break
 			}
 			fmt.Fprintf(r.writer, "[%s] %s: %s\n", issue.Severity, issue.Resource, issue.Message)
+			if len(issue.Children) > 0 {
+				fmt.Fprintf(r.writer, "Correlated issues: %d\n", len(issue.Children))
+			}
 			if issue.Suggestion != "" {
 				fmt.Fprintf(r.writer, "Suggestion: %s\n", issue.Suggestion)
 			}
+			if issue.RunbookURL != "" {
+				fmt.Fprintf(r.writer, "Runbook: %s\n", issue.RunbookURL)
+			}
 		}
 	}
 
@@ -353,12 +630,14 @@ func (r *ReportGenerator) generateCombinedReportHTML(healthData *health.ClusterH
 		Pods       []cost.PodCostData
 		Nodes      []cost.NodeCostData
 		Namespaces []cost.NamespaceCostData
+		Trends     []trends.Report
 		Timestamp  time.Time
 	}{
 		Health:     healthData,
 		Pods:       podCosts,
 		Nodes:      nodeCosts,
 		Namespaces: namespaceCosts,
+		Trends:     r.loadTrends(),
 		Timestamp:  time.Now(),
 	}
 	return tmpl.Execute(r.writer, data)
@@ -382,7 +661,7 @@ func (r *ReportGenerator) generateCostReportText(podCosts []cost.PodCostData, no
 	// Node Cost Summary
 	fmt.Fprintf(r.writer, "--- Node Cost Summary ---\n")
 	table := tablewriter.NewWriter(r.writer)
-	table.SetHeader([]string{"Node", "Instance Type", "Hourly Cost", "CPU Cost", "Memory Cost", "Utilization"})
+	table.Header("Node", "Instance Type", "Hourly Cost", "CPU Cost", "Memory Cost", "Utilization")
 
 	// Sort by cost descending
 	sort.Slice(nodeCosts, func(i, j int) bool {
@@ -408,7 +687,7 @@ func (r *ReportGenerator) generateCostReportText(podCosts []cost.PodCostData, no
 	// Namespace Cost Summary
 	fmt.Fprintf(r.writer, "--- Namespace Cost Summary ---\n")
 	table = tablewriter.NewWriter(r.writer)
-	table.SetHeader([]string{"Namespace", "Total Cost", "Pod Count", "CPU Cost", "Memory Cost"})
+	table.Header("Namespace", "Total Cost", "Pod Count", "CPU Cost", "Memory Cost")
 
 	// Sort namespaces by cost
 	sort.Slice(namespaceCosts, func(i, j int) bool {
@@ -417,7 +696,7 @@ func (r *ReportGenerator) generateCostReportText(podCosts []cost.PodCostData, no
 
 	for _, ns := range namespaceCosts {
 		table.Append([]string{
-			ns.Namespace,
+			ns.Name,
 			fmt.Sprintf("$%.2f", ns.TotalCost),
 			fmt.Sprintf("%d", ns.PodCount),
 			fmt.Sprintf("$%.2f", ns.CPUCost),
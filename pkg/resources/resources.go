@@ -0,0 +1,335 @@
+package resources
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/metadata"
+	"k8s.io/client-go/rest"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/retry"
+)
+
+// ErrNotServed is returned, wrapped, for kinds the API server does not serve, e.g. custom
+// resources whose CRD is not installed
+var ErrNotServed = errors.New("not served by the cluster")
+
+// rediscoverAfter is how long discovery results are trusted before an unknown kind
+// triggers discovery again, in case its CRD was installed since
+const rediscoverAfter = time.Minute
+
+// pageSize is the list page size for resources read through the dynamic client
+const pageSize = 500
+
+// Resource is an API resource found through discovery
+type Resource struct {
+	GVR        schema.GroupVersionResource
+	Kind       string
+	Namespaced bool
+	Listable   bool // serves the list verb
+}
+
+// Ref names the resource the way Resolve accepts it: the kind alone for the core group,
+// otherwise "<Kind>.<version>.<group>", e.g. "Certificate.v1.cert-manager.io"
+func (r Resource) Ref() string {
+	if r.GVR.Group == "" {
+		return r.Kind
+	}
+	return r.Kind + "." + r.GVR.Version + "." + r.GVR.Group
+}
+
+// Client reads and deletes resources of any kind the cluster serves, custom resources
+// included, without typed clients
+type Client struct {
+	discovery discovery.DiscoveryInterface
+	dynamic   dynamic.Interface
+	metadata  metadata.Interface
+
+	mu         sync.Mutex
+	resources  []Resource      // preferred versions first
+	groups     map[string]bool // served API groups, including those that failed discovery
+	discovered time.Time
+}
+
+// NewClient creates a client for the cluster of config. Discovery runs on first use.
+func NewClient(config *rest.Config) (*Client, error) {
+	disco, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create discovery client: %w", err)
+	}
+	dyn, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dynamic client: %w", err)
+	}
+	meta, err := metadata.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create metadata client: %w", err)
+	}
+	return NewClientWith(disco, dyn, meta), nil
+}
+
+// NewClientWith creates a client from existing discovery, dynamic and metadata clients
+func NewClientWith(disco discovery.DiscoveryInterface, dyn dynamic.Interface, meta metadata.Interface) *Client {
+	return &Client{discovery: disco, dynamic: dyn, metadata: meta}
+}
+
+var (
+	mu     sync.RWMutex
+	shared *Client
+)
+
+// Configure sets the client that custom rules, cleanup and restores use for kinds without
+// typed clients
+func Configure(c *Client) {
+	mu.Lock()
+	defer mu.Unlock()
+	shared = c
+}
+
+// Shared returns the configured client, or an error if there is none
+func Shared() (*Client, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	if shared == nil {
+		return nil, fmt.Errorf("no client for arbitrary resources is configured")
+	}
+	return shared, nil
+}
+
+// Resolve finds the resource for a kind given as "<Kind>", "<Kind>.<group>" or
+// "<Kind>.<version>.<group>", the forms kubectl accepts. Without a version the group's
+// preferred version is used. A kind served by several groups resolves to the core group,
+// and is otherwise ambiguous.
+func (c *Client) Resolve(ref string) (Resource, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	res, err := c.find(ref)
+	if errors.Is(err, ErrNotServed) && time.Since(c.discovered) > rediscoverAfter {
+		if err := c.discover(); err != nil {
+			return Resource{}, err
+		}
+		res, err = c.find(ref)
+	}
+	return res, err
+}
+
+// Resources returns every resource the API server serves, preferred versions first,
+// discovering them if that has not been done within a minute
+func (c *Client) Resources() ([]Resource, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if time.Since(c.discovered) > rediscoverAfter {
+		if err := c.discover(); err != nil {
+			return nil, err
+		}
+	}
+	return append([]Resource(nil), c.resources...), nil
+}
+
+// Groups returns the names of the API groups the API server serves, "" for the core group.
+// Unlike Resources, it includes groups whose discovery failed, such as an aggregated API
+// whose backend is down: they are still served, only their resources are unknown.
+func (c *Client) Groups() (map[string]bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if time.Since(c.discovered) > rediscoverAfter {
+		if err := c.discover(); err != nil {
+			return nil, err
+		}
+	}
+	groups := make(map[string]bool, len(c.groups))
+	for g := range c.groups {
+		groups[g] = true
+	}
+	return groups, nil
+}
+
+// find looks a kind up in the discovered resources
+func (c *Client) find(ref string) (Resource, error) {
+	gvk, gk := schema.ParseKindArg(ref)
+	if gvk != nil {
+		for _, r := range c.resources {
+			if strings.EqualFold(r.Kind, gvk.Kind) && r.GVR.Group == gvk.Group && r.GVR.Version == gvk.Version {
+				return r, nil
+			}
+		}
+	}
+
+	var matches []Resource
+	groups := make(map[string]bool)
+	for _, r := range c.resources {
+		if !strings.EqualFold(r.Kind, gk.Kind) || (gk.Group != "" && r.GVR.Group != gk.Group) || groups[r.GVR.Group] {
+			continue
+		}
+		// The first version listed for a group is its preferred one
+		groups[r.GVR.Group] = true
+		matches = append(matches, r)
+	}
+	switch {
+	case len(matches) == 0:
+		return Resource{}, fmt.Errorf("kind %s: %w", ref, ErrNotServed)
+	case len(matches) == 1:
+		return matches[0], nil
+	}
+	names := make([]string, 0, len(matches))
+	for _, r := range matches {
+		if r.GVR.Group == "" {
+			return r, nil
+		}
+		names = append(names, r.Ref())
+	}
+	return Resource{}, fmt.Errorf("kind %s is ambiguous, use one of %s", ref, strings.Join(names, ", "))
+}
+
+// discover reloads the resources the API server serves. The resources of groups that fail
+// discovery, such as an aggregated API whose backend is down, are logged and left out, but
+// the groups themselves are still recorded as served.
+func (c *Client) discover() error {
+	groups, lists, err := discovery.ServerGroupsAndResources(c.discovery)
+	served := make(map[string]bool, len(groups))
+	if err != nil {
+		var failed *discovery.ErrGroupDiscoveryFailed
+		if !errors.As(err, &failed) {
+			return fmt.Errorf("failed to discover API resources: %w", err)
+		}
+		log.Printf("Partial API discovery: %v", err)
+		for gv := range failed.Groups {
+			served[gv.Group] = true
+		}
+	}
+
+	preferred := make(map[string]string, len(groups))
+	for _, g := range groups {
+		preferred[g.Name] = g.PreferredVersion.Version
+		served[g.Name] = true
+	}
+
+	var found []Resource
+	for _, list := range lists {
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			continue
+		}
+		for _, r := range list.APIResources {
+			if strings.Contains(r.Name, "/") {
+				continue // subresources such as pods/log
+			}
+			found = append(found, Resource{
+				GVR:        gv.WithResource(r.Name),
+				Kind:       r.Kind,
+				Namespaced: r.Namespaced,
+				Listable:   slices.Contains(r.Verbs, "list"),
+			})
+		}
+	}
+	sort.SliceStable(found, func(i, j int) bool {
+		a, b := found[i].GVR, found[j].GVR
+		if a.Group != b.Group {
+			return a.Group < b.Group
+		}
+		return a.Version == preferred[a.Group] && b.Version != preferred[b.Group]
+	})
+
+	for _, r := range found {
+		served[r.GVR.Group] = true
+	}
+
+	c.resources = found
+	c.groups = served
+	c.discovered = time.Now()
+	return nil
+}
+
+// resource returns the dynamic client for a resource in a namespace (all if empty)
+func (c *Client) resource(res Resource, namespace string) dynamic.ResourceInterface {
+	if !res.Namespaced {
+		return c.dynamic.Resource(res.GVR)
+	}
+	return c.dynamic.Resource(res.GVR).Namespace(namespace)
+}
+
+// List lists a resource in a namespace (all namespaces if empty) page by page
+func (c *Client) List(ctx context.Context, res Resource, namespace string, opts metav1.ListOptions) ([]unstructured.Unstructured, error) {
+	if opts.Limit == 0 {
+		opts.Limit = pageSize
+	}
+
+	var items []unstructured.Unstructured
+	for {
+		page, err := retry.Value(ctx, retry.DefaultBackoff, func() (*unstructured.UnstructuredList, error) {
+			return c.resource(res, namespace).List(ctx, opts)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %w", res.GVR.Resource, err)
+		}
+		items = append(items, page.Items...)
+		if page.GetContinue() == "" {
+			return items, nil
+		}
+		opts.Continue = page.GetContinue()
+	}
+}
+
+// ListMetadata lists only the metadata of a resource's objects, page by page
+func (c *Client) ListMetadata(ctx context.Context, res Resource, namespace string, opts metav1.ListOptions) ([]metav1.PartialObjectMetadata, error) {
+	if opts.Limit == 0 {
+		opts.Limit = pageSize
+	}
+	client := c.metadata.Resource(res.GVR)
+
+	var items []metav1.PartialObjectMetadata
+	for {
+		page, err := retry.Value(ctx, retry.DefaultBackoff, func() (*metav1.PartialObjectMetadataList, error) {
+			if !res.Namespaced {
+				return client.List(ctx, opts)
+			}
+			return client.Namespace(namespace).List(ctx, opts)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s metadata: %w", res.GVR.Resource, err)
+		}
+		items = append(items, page.Items...)
+		if page.Continue == "" {
+			return items, nil
+		}
+		opts.Continue = page.Continue
+	}
+}
+
+// Get reads one object of a resource
+func (c *Client) Get(ctx context.Context, res Resource, namespace, name string) (*unstructured.Unstructured, error) {
+	return c.resource(res, namespace).Get(ctx, name, metav1.GetOptions{})
+}
+
+// Create creates an object of a resource
+func (c *Client) Create(ctx context.Context, res Resource, obj *unstructured.Unstructured) error {
+	_, err := c.resource(res, obj.GetNamespace()).Create(ctx, obj, metav1.CreateOptions{})
+	return err
+}
+
+// Patch applies a patch to one object of a resource
+func (c *Client) Patch(ctx context.Context, res Resource, namespace, name string, patchType types.PatchType, data []byte) error {
+	_, err := c.resource(res, namespace).Patch(ctx, name, patchType, data, metav1.PatchOptions{})
+	return err
+}
+
+// Delete deletes one object of a resource
+func (c *Client) Delete(ctx context.Context, res Resource, namespace, name string, opts metav1.DeleteOptions) error {
+	return c.resource(res, namespace).Delete(ctx, name, opts)
+}
@@ -0,0 +1,99 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+)
+
+// Backoff configures exponential backoff between attempts
+type Backoff struct {
+	Initial  time.Duration // delay before the first retry
+	Max      time.Duration // upper bound for a single delay
+	Factor   float64       // multiplier applied after each attempt
+	Jitter   float64       // random fraction added to each delay (0.2 = up to +20%)
+	Attempts int           // total attempts including the first
+}
+
+// DefaultBackoff is used by health, cost and optimizer API calls
+var DefaultBackoff = Backoff{
+	Initial:  200 * time.Millisecond,
+	Max:      5 * time.Second,
+	Factor:   2.0,
+	Jitter:   0.2,
+	Attempts: 4,
+}
+
+// IsRetryable reports whether an error is transient: apiserver throttling, timeouts,
+// unavailability or dropped connections. Errors with a Retryable method decide for
+// themselves.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if apierrors.IsTooManyRequests(err) || apierrors.IsServerTimeout(err) || apierrors.IsTimeout(err) ||
+		apierrors.IsServiceUnavailable(err) || apierrors.IsInternalError(err) {
+		return true
+	}
+	if utilnet.IsConnectionReset(err) || utilnet.IsConnectionRefused(err) || utilnet.IsProbableEOF(err) {
+		return true
+	}
+	var retryable interface{ Retryable() bool }
+	if errors.As(err, &retryable) {
+		return retryable.Retryable()
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// Do calls fn until it succeeds, returns a non-retryable error, the attempts are
+// exhausted or the context is done
+func Do(ctx context.Context, b Backoff, fn func() error) error {
+	delay := b.Initial
+	var err error
+
+	for attempt := 1; ; attempt++ {
+		if err = fn(); err == nil || !IsRetryable(err) || attempt >= b.Attempts {
+			return err
+		}
+
+		// Honor the server's Retry-After hint for throttled requests
+		wait := delay
+		if seconds, ok := apierrors.SuggestsClientDelay(err); ok && time.Duration(seconds)*time.Second > wait {
+			wait = time.Duration(seconds) * time.Second
+		}
+		if b.Jitter > 0 {
+			wait += time.Duration(rand.Float64() * b.Jitter * float64(wait))
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+
+		delay = time.Duration(float64(delay) * b.Factor)
+		if b.Max > 0 && delay > b.Max {
+			delay = b.Max
+		}
+	}
+}
+
+// Value is like Do for functions that return a result
+func Value[T any](ctx context.Context, b Backoff, fn func() (T, error)) (T, error) {
+	var result T
+	err := Do(ctx, b, func() error {
+		var err error
+		result, err = fn()
+		return err
+	})
+	return result, err
+}
@@ -0,0 +1,92 @@
+package rules
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/common/types/traits"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// Program is a compiled CEL expression. Expressions get the standard CEL library, with
+// numbers of different types comparable to each other, plus a quantity function that
+// turns resource quantities such as "500m" or "2Gi" into doubles.
+type Program struct {
+	expr    string
+	program cel.Program
+}
+
+// quantityLibrary declares quantity(string), quantity(int) and quantity(double)
+var quantityLibrary = cel.Function("quantity",
+	cel.Overload("quantity_string", []*cel.Type{cel.StringType}, cel.DoubleType,
+		cel.UnaryBinding(func(v ref.Val) ref.Val {
+			q, err := resource.ParseQuantity(string(v.(types.String)))
+			if err != nil {
+				return types.NewErr("quantity: invalid value %q", string(v.(types.String)))
+			}
+			return types.Double(q.AsApproximateFloat64())
+		})),
+	cel.Overload("quantity_int", []*cel.Type{cel.IntType}, cel.DoubleType,
+		cel.UnaryBinding(func(v ref.Val) ref.Val {
+			return types.Double(v.(types.Int))
+		})),
+	cel.Overload("quantity_double", []*cel.Type{cel.DoubleType}, cel.DoubleType,
+		cel.UnaryBinding(func(v ref.Val) ref.Val {
+			return v
+		})),
+)
+
+// Compile parses and checks an expression that may refer to the given variables
+func Compile(expr string, variables ...string) (*Program, error) {
+	opts := []cel.EnvOption{cel.CrossTypeNumericComparisons(true), quantityLibrary}
+	for _, v := range variables {
+		opts = append(opts, cel.Variable(v, cel.DynType))
+	}
+	env, err := cel.NewEnv(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CEL environment: %w", err)
+	}
+
+	ast, issues := env.Compile(expr)
+	if issues.Err() != nil {
+		return nil, fmt.Errorf("failed to parse %q: %w", expr, issues.Err())
+	}
+	program, err := env.Program(ast)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %q: %w", expr, err)
+	}
+	return &Program{expr: expr, program: program}, nil
+}
+
+// Eval evaluates the program with values for its variables. Values are nil, bool, int64,
+// float64, string, []interface{} or map[string]interface{}, as decoded from JSON or
+// converted from Kubernetes objects, and so is the result.
+func (p *Program) Eval(vars map[string]interface{}) (interface{}, error) {
+	out, _, err := p.program.Eval(vars)
+	if err != nil {
+		return nil, err
+	}
+	switch out.(type) {
+	case traits.Lister:
+		return out.ConvertToNative(reflect.TypeOf([]interface{}{}))
+	case traits.Mapper:
+		return out.ConvertToNative(reflect.TypeOf(map[string]interface{}{}))
+	}
+	return out.Value(), nil
+}
+
+// EvalBool evaluates a program that must produce a bool
+func (p *Program) EvalBool(vars map[string]interface{}) (bool, error) {
+	out, _, err := p.program.Eval(vars)
+	if err != nil {
+		return false, err
+	}
+	b, ok := out.(types.Bool)
+	if !ok {
+		return false, fmt.Errorf("%q evaluated to %s, not bool", p.expr, out.Type().TypeName())
+	}
+	return bool(b), nil
+}
@@ -0,0 +1,101 @@
+package rules
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestProgramEval(t *testing.T) {
+	object := map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "web", "labels": map[string]interface{}{"tier": "frontend"}},
+		"spec": map[string]interface{}{
+			"replicas": int64(3),
+			"containers": []interface{}{
+				map[string]interface{}{"name": "app", "resources": map[string]interface{}{"limits": map[string]interface{}{"memory": "512Mi"}}},
+				map[string]interface{}{"name": "sidecar", "resources": map[string]interface{}{"limits": map[string]interface{}{"memory": "3Gi"}}},
+			},
+		},
+	}
+
+	tests := []struct {
+		name string
+		expr string
+		want interface{}
+		err  string // substring of the compile or evaluation error
+	}{
+		// Lexing and escapes
+		{name: "unicode escape", expr: `'\u00e9'`, want: "é"},
+		{name: "long unicode escape", expr: `'\U0001F600'`, want: "😀"},
+		{name: "hex escape", expr: `'\x41'`, want: "A"},
+		{name: "octal escape", expr: `'\101'`, want: "A"},
+		{name: "raw string", expr: `r'\d+'`, want: `\d+`},
+		{name: "triple quoted", expr: `"""a"b"""`, want: `a"b`},
+		{name: "unsigned literal", expr: `uint(7u)`, want: uint64(7)},
+		{name: "unterminated string", expr: `'abc`, err: "failed to parse"},
+
+		// Precedence
+		{name: "multiplication first", expr: `1 + 2 * 3`, want: int64(7)},
+		{name: "parentheses", expr: `(1 + 2) * 3`, want: int64(9)},
+		{name: "and before or", expr: `true || false && false`, want: true},
+		{name: "ternary", expr: `1 < 2 ? 'a' : 'b'`, want: "a"},
+		{name: "unary minus", expr: `-2 * 3`, want: int64(-6)},
+
+		// Overflow
+		{name: "smallest int literal", expr: `-9223372036854775808`, want: int64(-9223372036854775808)},
+		{name: "addition overflow", expr: `9223372036854775807 + 1`, err: "overflow"},
+		{name: "multiplication overflow", expr: `4611686018427387904 * 2`, err: "overflow"},
+		{name: "negation overflow", expr: `-(-9223372036854775807 - 1)`, err: "overflow"},
+		{name: "division by zero", expr: `1 / 0`, err: "division by zero"},
+
+		// Macros
+		{name: "all", expr: `object.spec.containers.all(c, c.name != '')`, want: true},
+		{name: "exists", expr: `object.spec.containers.exists(c, c.name == 'sidecar')`, want: true},
+		{name: "exists_one", expr: `object.spec.containers.exists_one(c, c.name.startsWith('s'))`, want: true},
+		{name: "filter", expr: `object.spec.containers.filter(c, c.name == 'app').size()`, want: int64(1)},
+		{name: "map", expr: `object.spec.containers.map(c, c.name)`, want: []interface{}{"app", "sidecar"}},
+		{name: "has", expr: `has(object.spec.affinity)`, want: false},
+
+		// Functions and objects
+		{name: "quantity", expr: `object.spec.containers.all(c, quantity(c.resources.limits.memory) <= quantity('2Gi'))`, want: false},
+		{name: "invalid quantity", expr: `quantity('lots') > 0.0`, err: "invalid value"},
+		{name: "mixed numbers", expr: `object.spec.replicas >= 2.5`, want: true},
+		{name: "matches", expr: `object.metadata.name.matches('^w[a-z]+$')`, want: true},
+		{name: "in", expr: `'tier' in object.metadata.labels`, want: true},
+		{name: "missing field", expr: `object.spec.affinity.nodeAffinity == null`, err: "no such key"},
+		{name: "unknown function", expr: `frobnicate(object)`, err: "undeclared reference"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			program, err := Compile(tt.expr, Variable)
+			var got interface{}
+			if err == nil {
+				got, err = program.Eval(map[string]interface{}{Variable: object})
+			}
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("%s: got error %v, want one containing %q", tt.expr, err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("%s: %v", tt.expr, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("%s = %#v, want %#v", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvalBoolRejectsOtherTypes(t *testing.T) {
+	program, err := Compile(`object.spec.replicas`, Variable)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = program.EvalBool(map[string]interface{}{Variable: map[string]interface{}{"spec": map[string]interface{}{"replicas": int64(3)}}})
+	if err == nil || !strings.Contains(err.Error(), "not bool") {
+		t.Errorf("got error %v, want a not bool error", err)
+	}
+}
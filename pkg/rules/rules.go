@@ -0,0 +1,265 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/resources"
+	"github.com/ochestra-tech/ochestra-ai/pkg/snapshot"
+)
+
+// Kinds of objects rules check from the snapshot. Other kinds, custom resources included,
+// are listed through discovery; they are named as kubectl names them, e.g. "Certificate",
+// "Certificate.cert-manager.io" or "Certificate.v1.cert-manager.io".
+const (
+	KindPod        = "Pod"
+	KindNode       = "Node"
+	KindDeployment = "Deployment"
+	KindDaemonSet  = "DaemonSet"
+	KindService    = "Service"
+)
+
+var kinds = []string{KindPod, KindNode, KindDeployment, KindDaemonSet, KindService}
+
+// Variable is the name rule expressions use for the object being checked
+const Variable = "object"
+
+// Rule is a custom health or compliance check. Expression is evaluated against every
+// object of Kind in Namespaces that matches Selector and Match; objects for which it is
+// false violate the rule.
+type Rule struct {
+	Name       string   `json:"name"`
+	Kind       string   `json:"kind"`                 // a snapshot kind or any kind the cluster serves
+	Namespaces []string `json:"namespaces,omitempty"` // empty for all namespaces
+	Selector   string   `json:"selector,omitempty"`   // label selector, e.g. "tier=frontend"
+	Match      string   `json:"match,omitempty"`      // optional CEL expression choosing the objects to check
+	Expression string   `json:"expression"`           // CEL expression true for compliant objects
+	Severity   string   `json:"severity"`             // "critical", "warning" or "info"
+	Message    string   `json:"message"`
+	Suggestion string   `json:"suggestion,omitempty"`
+
+	selector   labels.Selector
+	match      *Program
+	expression *Program
+}
+
+// RuleSet is a list of compiled rules
+type RuleSet struct {
+	Rules []Rule `json:"rules"`
+}
+
+// LoadRules reads and compiles rules from a JSON file of the form {"rules": [...]}
+func LoadRules(path string) (*RuleSet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rules: %w", err)
+	}
+
+	var set RuleSet
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("failed to parse rules: %w", err)
+	}
+	for i := range set.Rules {
+		if err := set.Rules[i].init(); err != nil {
+			return nil, fmt.Errorf("rule %d: %w", i, err)
+		}
+	}
+	return &set, nil
+}
+
+// init validates a rule and compiles its selector and expressions
+func (r *Rule) init() error {
+	if r.Name == "" || r.Expression == "" || r.Message == "" {
+		return fmt.Errorf("name, expression and message are required")
+	}
+	if strings.TrimSpace(r.Kind) == "" {
+		return fmt.Errorf("%s needs a kind, e.g. one of %s", r.Name, strings.Join(kinds, ", "))
+	}
+	switch r.Severity {
+	case "critical", "warning", "info":
+	default:
+		return fmt.Errorf("%s has invalid severity %q", r.Name, r.Severity)
+	}
+
+	var err error
+	if r.selector, err = labels.Parse(r.Selector); err != nil {
+		return fmt.Errorf("%s has invalid selector: %w", r.Name, err)
+	}
+	if r.Match != "" {
+		if r.match, err = Compile(r.Match, Variable); err != nil {
+			return fmt.Errorf("%s has invalid match: %w", r.Name, err)
+		}
+	}
+	if r.expression, err = Compile(r.Expression, Variable); err != nil {
+		return fmt.Errorf("%s has invalid expression: %w", r.Name, err)
+	}
+	return nil
+}
+
+// Violation is an object that does not satisfy a rule
+type Violation struct {
+	Rule       string `json:"rule"`
+	Severity   string `json:"severity"`
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name"`
+	Message    string `json:"message"`
+	Suggestion string `json:"suggestion,omitempty"`
+}
+
+// ruleObject is an object converted for evaluation
+type ruleObject struct {
+	meta   metav1.Object
+	fields map[string]interface{}
+}
+
+// Evaluate checks the snapshot's objects, and the listed objects of other kinds, against
+// every rule. Objects a rule fails to evaluate on, e.g. because the expression reads a
+// field they lack without has(), are skipped and reported in the returned errors, one per
+// rule. Rules for kinds the cluster does not serve check nothing.
+func (s *RuleSet) Evaluate(ctx context.Context, snap *snapshot.ClusterSnapshot) ([]Violation, []error) {
+	violations := make([]Violation, 0)
+	var errs []error
+	converted := make(map[string][]ruleObject)
+
+	for i := range s.Rules {
+		r := &s.Rules[i]
+		objects, ok := converted[r.Kind]
+		if !ok {
+			var err error
+			if objects, err = convert(ctx, snap, r.Kind); errors.Is(err, resources.ErrNotServed) {
+				objects = nil
+			} else if err != nil {
+				errs = append(errs, fmt.Errorf("rule %s: %w", r.Name, err))
+				continue
+			}
+			converted[r.Kind] = objects
+		}
+
+		failed := 0
+		var firstErr error
+		for _, obj := range objects {
+			compliant, err := r.check(obj)
+			if err != nil {
+				if failed++; firstErr == nil {
+					firstErr = fmt.Errorf("%s/%s: %w", obj.meta.GetNamespace(), obj.meta.GetName(), err)
+				}
+				continue
+			}
+			if !compliant {
+				violations = append(violations, Violation{
+					Rule:       r.Name,
+					Severity:   r.Severity,
+					Kind:       r.Kind,
+					Namespace:  obj.meta.GetNamespace(),
+					Name:       obj.meta.GetName(),
+					Message:    r.Message,
+					Suggestion: r.Suggestion,
+				})
+			}
+		}
+		if firstErr != nil {
+			errs = append(errs, fmt.Errorf("rule %s failed to evaluate on %d objects, first %v", r.Name, failed, firstErr))
+		}
+	}
+	return violations, errs
+}
+
+// check reports whether an object complies with the rule; objects the rule does not
+// select comply
+func (r *Rule) check(obj ruleObject) (bool, error) {
+	if len(r.Namespaces) > 0 && !slices.Contains(r.Namespaces, obj.meta.GetNamespace()) {
+		return true, nil
+	}
+	if !r.selector.Matches(labels.Set(obj.meta.GetLabels())) {
+		return true, nil
+	}
+	vars := map[string]interface{}{Variable: obj.fields}
+	if r.match != nil {
+		selected, err := r.match.EvalBool(vars)
+		if err != nil || !selected {
+			return true, err
+		}
+	}
+	return r.expression.EvalBool(vars)
+}
+
+// convert returns the objects of a kind as the maps rules are evaluated on, from the
+// snapshot or else listed through discovery
+func convert(ctx context.Context, snap *snapshot.ClusterSnapshot, kind string) ([]ruleObject, error) {
+	var objects []runtime.Object
+	switch kind {
+	case KindPod:
+		for i := range snap.Pods {
+			objects = append(objects, &snap.Pods[i])
+		}
+	case KindNode:
+		for i := range snap.Nodes {
+			objects = append(objects, &snap.Nodes[i])
+		}
+	case KindDeployment:
+		if err := snap.Errors["deployments"]; err != nil {
+			return nil, fmt.Errorf("deployments unavailable: %w", err)
+		}
+		for i := range snap.Deployments {
+			objects = append(objects, &snap.Deployments[i])
+		}
+	case KindDaemonSet:
+		if err := snap.Errors["daemonsets"]; err != nil {
+			return nil, fmt.Errorf("daemonsets unavailable: %w", err)
+		}
+		for i := range snap.DaemonSets {
+			objects = append(objects, &snap.DaemonSets[i])
+		}
+	case KindService:
+		if err := snap.Errors["services"]; err != nil {
+			return nil, fmt.Errorf("services unavailable: %w", err)
+		}
+		for i := range snap.Services {
+			objects = append(objects, &snap.Services[i])
+		}
+	default:
+		return listObjects(ctx, kind)
+	}
+
+	converted := make([]ruleObject, 0, len(objects))
+	for _, obj := range objects {
+		fields, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert %s: %w", kind, err)
+		}
+		converted = append(converted, ruleObject{meta: obj.(metav1.Object), fields: fields})
+	}
+	return converted, nil
+}
+
+// listObjects lists the objects of a kind the snapshot does not hold
+func listObjects(ctx context.Context, kind string) ([]ruleObject, error) {
+	client, err := resources.Shared()
+	if err != nil {
+		return nil, err
+	}
+	res, err := client.Resolve(kind)
+	if err != nil {
+		return nil, err
+	}
+	items, err := client.List(ctx, res, "", metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	converted := make([]ruleObject, 0, len(items))
+	for i := range items {
+		converted = append(converted, ruleObject{meta: &items[i], fields: items[i].Object})
+	}
+	return converted, nil
+}
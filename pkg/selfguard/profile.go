@@ -0,0 +1,85 @@
+package selfguard
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime/pprof"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxCPUProfile bounds the duration of a requested CPU profile
+const maxCPUProfile = 2 * time.Minute
+
+// ProfileHandler serves the monitor's runtime profiles in pprof format, for `go tool pprof`:
+//
+//	GET /debug/pprof/                       list of profiles
+//	GET /debug/pprof/<profile>              heap, allocs, goroutine, block, mutex or threadcreate
+//	GET /debug/pprof/profile?seconds=30     CPU profile over the given duration
+//
+// Unlike importing net/http/pprof it registers nothing by itself, so it can be mounted
+// behind authentication.
+type ProfileHandler struct{}
+
+func (ProfileHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/debug/pprof/")
+	switch name {
+	case "":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		for _, p := range pprof.Profiles() {
+			fmt.Fprintf(w, "%s\t%d\n", p.Name(), p.Count())
+		}
+		fmt.Fprintln(w, "profile\tCPU profile, ?seconds=<n>")
+
+	case "profile":
+		seconds, err := strconv.Atoi(r.URL.Query().Get("seconds"))
+		if err != nil || seconds <= 0 {
+			seconds = 30
+		}
+		duration := min(time.Duration(seconds)*time.Second, maxCPUProfile)
+		w.Header().Set("Content-Type", "application/octet-stream")
+		if err := pprof.StartCPUProfile(w); err != nil {
+			// Only one CPU profile can run at a time
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		select {
+		case <-time.After(duration):
+		case <-r.Context().Done():
+		}
+		pprof.StopCPUProfile()
+
+	default:
+		profile := pprof.Lookup(name)
+		if profile == nil {
+			http.Error(w, "unknown profile", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		if err := profile.WriteTo(w, 0); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}
+}
+
+// writeHeapProfile writes a heap profile to dir, named by its time
+func writeHeapProfile(dir string, now time.Time) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	path := filepath.Join(dir, "heap-"+now.UTC().Format("20060102T150405Z")+".pb.gz")
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := pprof.WriteHeapProfile(f); err != nil {
+		f.Close()
+		return err
+	}
+	log.Printf("Wrote heap profile to %s", path)
+	return f.Close()
+}
@@ -0,0 +1,168 @@
+package selfguard
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultDegradeAt is the fraction of a limit at which the monitor switches to lean scans
+const DefaultDegradeAt = 0.8
+
+// clockTicks is the kernel's USER_HZ, the unit of CPU times in /proc
+const clockTicks = 100
+
+// Modes the monitor runs in, by how close it is to its limits
+const (
+	ModeNormal   = "normal"
+	ModeDegraded = "degraded" // near a limit: lean scans only
+	ModeAbort    = "abort"    // over a limit: the cycle is skipped
+)
+
+// Limits bounds the monitor's own resource usage. Zero values are not enforced.
+type Limits struct {
+	MemoryBytes int64   // resident Go memory, e.g. the container's memory limit
+	CPUCores    float64 // average cores used between samples
+	DegradeAt   float64 // fraction of a limit at which to degrade, DefaultDegradeAt if zero
+
+	// HeapProfileDir receives a heap profile each time the monitor leaves normal mode
+	HeapProfileDir string
+}
+
+// Usage is the monitor's own resource usage at a sample
+type Usage struct {
+	Timestamp   time.Time `json:"timestamp"`
+	MemoryBytes uint64    `json:"memoryBytes"` // memory obtained from the OS and not yet returned
+	HeapBytes   uint64    `json:"heapBytes"`
+	CPUCores    float64   `json:"cpuCores"` // average since the previous sample; 0 where /proc is not available
+	Goroutines  int       `json:"goroutines"`
+	Mode        string    `json:"mode"`
+	Reason      string    `json:"reason,omitempty"` // limit that caused a degraded or abort mode
+
+	MemoryLimitBytes int64   `json:"memoryLimitBytes,omitempty"`
+	CPULimitCores    float64 `json:"cpuLimitCores,omitempty"`
+}
+
+// Guard samples the monitor's own usage and decides how much work the next cycle may do
+type Guard struct {
+	limits Limits
+
+	mu      sync.Mutex
+	lastCPU time.Duration
+	lastAt  time.Time
+	last    Usage
+}
+
+// New creates a guard for limits. A memory limit is also set as the Go runtime's soft
+// memory limit, so garbage collection works harder before the guard has to degrade.
+func New(limits Limits) *Guard {
+	if limits.DegradeAt <= 0 || limits.DegradeAt > 1 {
+		limits.DegradeAt = DefaultDegradeAt
+	}
+	if limits.MemoryBytes > 0 {
+		debug.SetMemoryLimit(limits.MemoryBytes)
+	}
+	g := &Guard{limits: limits, lastAt: time.Now()}
+	g.lastCPU, _ = processCPU()
+	g.last = Usage{Mode: ModeNormal}
+	return g
+}
+
+// Sample reads the monitor's usage and compares it with the limits. Memory over a limit is
+// first returned to the OS and read again, so only memory still in use aborts a cycle.
+func (g *Guard) Sample(now time.Time) Usage {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	usage := Usage{
+		Timestamp:        now,
+		Goroutines:       runtime.NumGoroutine(),
+		MemoryLimitBytes: g.limits.MemoryBytes,
+		CPULimitCores:    g.limits.CPUCores,
+	}
+	if cpu, err := processCPU(); err == nil {
+		if elapsed := now.Sub(g.lastAt); elapsed > 0 {
+			usage.CPUCores = (cpu - g.lastCPU).Seconds() / elapsed.Seconds()
+		}
+		g.lastCPU, g.lastAt = cpu, now
+	}
+	readMemory(&usage)
+	if g.limits.MemoryBytes > 0 && usage.MemoryBytes >= uint64(g.limits.MemoryBytes) {
+		debug.FreeOSMemory()
+		readMemory(&usage)
+	}
+
+	usage.Mode = ModeNormal
+	check := func(resource string, used, limit float64) {
+		if limit <= 0 {
+			return
+		}
+		mode := ""
+		switch {
+		case used >= limit:
+			mode = ModeAbort
+		case used >= limit*g.limits.DegradeAt:
+			mode = ModeDegraded
+		default:
+			return
+		}
+		if usage.Mode != ModeAbort {
+			usage.Mode = mode
+			usage.Reason = fmt.Sprintf("%s at %.0f%% of its limit", resource, used/limit*100)
+		}
+	}
+	check("memory", float64(usage.MemoryBytes), float64(g.limits.MemoryBytes))
+	check("CPU", usage.CPUCores, g.limits.CPUCores)
+
+	if usage.Mode != g.last.Mode {
+		if usage.Mode == ModeNormal {
+			log.Printf("Self guard: back to normal mode")
+		} else {
+			log.Printf("Self guard: switching to %s mode, %s", usage.Mode, usage.Reason)
+		}
+		if g.last.Mode == ModeNormal && g.limits.HeapProfileDir != "" {
+			if err := writeHeapProfile(g.limits.HeapProfileDir, now); err != nil {
+				log.Printf("Failed to write heap profile: %v", err)
+			}
+		}
+	}
+	g.last = usage
+	return usage
+}
+
+// readMemory fills in the memory the Go runtime holds
+func readMemory(usage *Usage) {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	usage.MemoryBytes = stats.Sys - stats.HeapReleased
+	usage.HeapBytes = stats.HeapAlloc
+}
+
+// processCPU returns the user and system CPU time of the process from /proc
+func processCPU() (time.Duration, error) {
+	data, err := os.ReadFile("/proc/self/stat")
+	if err != nil {
+		return 0, err
+	}
+	// The command name may contain spaces, so fields are counted from its closing parenthesis
+	stat := string(data)
+	fields := strings.Fields(stat[strings.LastIndexByte(stat, ')')+1:])
+	if len(fields) < 13 {
+		return 0, fmt.Errorf("unexpected /proc/self/stat format")
+	}
+	utime, err := strconv.ParseInt(fields[11], 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	stime, err := strconv.ParseInt(fields[12], 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(utime+stime) * time.Second / clockTicks, nil
+}
@@ -0,0 +1,290 @@
+package showback
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/auth"
+	"github.com/ochestra-tech/ochestra-ai/pkg/cost"
+	"github.com/ochestra-tech/ochestra-ai/pkg/history"
+	"github.com/ochestra-tech/ochestra-ai/pkg/snapshot"
+	"github.com/ochestra-tech/ochestra-ai/pkg/trends"
+)
+
+// DefaultLabelKey is the pod label whose value names a workload's team
+const DefaultLabelKey = "team"
+
+// Unlabeled is the team of workloads without the team label
+const Unlabeled = "(unlabeled)"
+
+// TopWorkloads is how many of a team's most expensive workloads are listed
+var TopWorkloads = 10
+
+// Workload is the cost of a workload's running pods
+type Workload struct {
+	Namespace    string  `json:"namespace"`
+	Kind         string  `json:"kind"`
+	Name         string  `json:"name"`
+	Team         string  `json:"-"`
+	Pods         int     `json:"pods"`
+	CostPerHour  float64 `json:"costPerHour"`
+	WastePerHour float64 `json:"wastePerHour"` // cost of requests the pods did not use
+	measured     float64 // cost of the pods with usage metrics, which waste is measured against
+}
+
+// Team is one team's showback
+type Team struct {
+	Name         string             `json:"name"`
+	Namespaces   []string           `json:"namespaces"`
+	CostPerHour  float64            `json:"costPerHour"` // current burn rate
+	CostPerMonth float64            `json:"costPerMonth"`
+	WastePercent *float64           `json:"wastePercent,omitempty"` // unset without usage metrics
+	Trend        *trends.Comparison `json:"trend,omitempty"`        // week over week, unset when the caller cannot see all the team's namespaces
+	Forecast     *trends.Projection `json:"forecast,omitempty"`     // hourly rate a week ahead
+	History      []trends.Point     `json:"history,omitempty"`      // daily average hourly rate
+	Workloads    []Workload         `json:"topWorkloads"`
+}
+
+// Collect prices each workload's running pods and measures how much of their requests
+// they left unused, from the snapshot's pod metrics
+func Collect(snap *snapshot.ClusterSnapshot, pricing map[string]cost.ResourcePricing, labelKey string) []Workload {
+	pods := make(map[string]*v1.Pod, len(snap.Pods))
+	for i := range snap.Pods {
+		pods[snap.Pods[i].Namespace+"/"+snap.Pods[i].Name] = &snap.Pods[i]
+	}
+	usage := make(map[string][2]float64) // pod -> CPU cores and memory GB used
+	if snap.Errors["podMetrics"] == nil {
+		for _, pm := range snap.PodMetrics {
+			var u [2]float64
+			for _, c := range pm.Containers {
+				u[0] += c.Usage.Cpu().AsApproximateFloat64()
+				u[1] += float64(c.Usage.Memory().Value()) / (1024 * 1024 * 1024)
+			}
+			usage[pm.Namespace+"/"+pm.Name] = u
+		}
+	}
+
+	byWorkload := make(map[string]*Workload)
+	for _, pc := range cost.PodCostsFromSnapshot(snap, pricing) {
+		pod, ok := pods[pc.Namespace+"/"+pc.Name]
+		if !ok {
+			continue
+		}
+		kind, name := snapshot.WorkloadOwner(pod)
+		key := pc.Namespace + "/" + kind + "/" + name
+		w, ok := byWorkload[key]
+		if !ok {
+			team := pod.Labels[labelKey]
+			if team == "" {
+				team = Unlabeled
+			}
+			w = &Workload{Namespace: pc.Namespace, Kind: kind, Name: name, Team: team}
+			byWorkload[key] = w
+		}
+		w.Pods++
+		w.CostPerHour += pc.TotalCost
+		if u, ok := usage[pc.Namespace+"/"+pc.Name]; ok {
+			w.measured += pc.TotalCost
+			w.WastePerHour += unused(pc.CPUCost, pc.CPURequest, u[0]) + unused(pc.MemoryCost, pc.MemRequest, u[1])
+		}
+	}
+
+	workloads := make([]Workload, 0, len(byWorkload))
+	for _, w := range byWorkload {
+		workloads = append(workloads, *w)
+	}
+	return workloads
+}
+
+// unused is the cost of the part of a request that went unused
+func unused(cost, request, used float64) float64 {
+	if request <= 0 || used >= request {
+		return 0
+	}
+	return cost * (request - used) / request
+}
+
+// Build aggregates the workloads a scope allows into teams and adds each team's trend from
+// the history's label costs. A nil scope allows everything.
+func Build(workloads []Workload, scope *auth.Scope, snapshots []*history.Snapshot, labelKey string, now time.Time) []Team {
+	teams := make(map[string]*Team)
+	namespaces := make(map[string]map[string]bool) // team -> namespaces
+	hidden := make(map[string]bool)                // teams with workloads outside the scope
+	for i := range workloads {
+		w := workloads[i]
+		if scope != nil && !scope.Allows(w.Namespace) {
+			hidden[w.Team] = true
+			continue
+		}
+		t, ok := teams[w.Team]
+		if !ok {
+			t = &Team{Name: w.Team}
+			teams[w.Team] = t
+			namespaces[w.Team] = make(map[string]bool)
+		}
+		namespaces[w.Team][w.Namespace] = true
+		t.CostPerHour += w.CostPerHour
+		t.Workloads = append(t.Workloads, w)
+	}
+
+	result := make([]Team, 0, len(teams))
+	for _, t := range teams {
+		for namespace := range namespaces[t.Name] {
+			t.Namespaces = append(t.Namespaces, namespace)
+		}
+		sort.Strings(t.Namespaces)
+		t.CostPerMonth = t.CostPerHour * 24 * 30
+
+		var waste, measured float64
+		for _, w := range t.Workloads {
+			waste += w.WastePerHour
+			measured += w.measured
+		}
+		if measured > 0 {
+			percent := 100 * waste / measured
+			t.WastePercent = &percent
+		}
+		sort.Slice(t.Workloads, func(i, j int) bool { return t.Workloads[i].CostPerHour > t.Workloads[j].CostPerHour })
+		if len(t.Workloads) > TopWorkloads {
+			t.Workloads = t.Workloads[:TopWorkloads]
+		}
+
+		// Label costs are recorded for the whole cluster, so only callers who can see
+		// every namespace of the team get its trend
+		if !hidden[t.Name] && t.Name != Unlabeled {
+			points := labelPoints(snapshots, labelKey+"="+t.Name)
+			t.Trend = trends.WeekOverWeek(points, now)
+			t.Forecast = trends.Forecast(points, now.Add(trends.DefaultOptions.Horizon))
+			t.History = trends.MovingAverage(points, 24*time.Hour, 24*time.Hour)
+		}
+		result = append(result, *t)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].CostPerHour > result[j].CostPerHour })
+	return result
+}
+
+// labelPoints returns the recorded hourly rate of a "key=value" label from snapshots
+// ordered oldest first. Snapshots recording other values of any label count it as zero.
+func labelPoints(snapshots []*history.Snapshot, label string) []trends.Point {
+	points := make([]trends.Point, 0, len(snapshots))
+	for _, s := range snapshots {
+		if len(s.LabelCosts) == 0 {
+			continue
+		}
+		points = append(points, trends.Point{Time: s.Timestamp, Value: s.LabelCosts[label]})
+	}
+	return points
+}
+
+// Handler serves showback at /showback as JSON and at /showback/dashboard as a page. Team
+// callers only see the workloads in their own namespaces.
+type Handler struct {
+	store    history.Store
+	cluster  string
+	labelKey string
+
+	mu        sync.RWMutex
+	workloads []Workload
+	updated   time.Time
+}
+
+// NewHandler creates a showback handler reading trends from store
+func NewHandler(store history.Store, cluster, labelKey string) *Handler {
+	return &Handler{store: store, cluster: cluster, labelKey: labelKey}
+}
+
+// Update replaces the workload costs served, once per monitoring cycle
+func (h *Handler) Update(workloads []Workload, now time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.workloads, h.updated = workloads, now
+}
+
+// Teams returns the teams the request's caller may see, or the one named by the team
+// parameter
+func (h *Handler) Teams(ctx context.Context, team string, now time.Time) ([]Team, error) {
+	h.mu.RLock()
+	workloads := h.workloads
+	h.mu.RUnlock()
+
+	var scope *auth.Scope
+	if s, ok := auth.ScopeFrom(ctx); ok && !s.Admin {
+		scope = &s
+	}
+	since := now.Add(-2 * 7 * 24 * time.Hour)
+	snapshots, err := h.store.List(ctx, history.Query{Cluster: h.cluster, Since: since})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	teams := Build(workloads, scope, snapshots, h.labelKey, now)
+	if team == "" {
+		return teams, nil
+	}
+	for _, t := range teams {
+		if t.Name == team {
+			return []Team{t}, nil
+		}
+	}
+	return []Team{}, nil
+}
+
+// ServeHTTP handles /showback?team=payments and /showback/dashboard?team=payments
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	teams, err := h.Teams(r.Context(), r.URL.Query().Get("team"), now)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if strings.HasSuffix(r.URL.Path, "/dashboard") {
+		h.mu.RLock()
+		updated := h.updated
+		h.mu.RUnlock()
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := dashboard.Execute(w, map[string]interface{}{"Cluster": h.cluster, "Updated": updated, "Teams": teams}); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(teams)
+}
+
+// dashboard renders teams as a page of tables
+var dashboard = template.Must(template.New("showback").Funcs(template.FuncMap{
+	"money": func(v float64) string { return fmt.Sprintf("$%.2f", v) },
+}).Parse(`<!DOCTYPE html>
+<html><head><title>Showback - {{.Cluster}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; }
+</style></head><body>
+<h1>Showback for {{.Cluster}}</h1>
+<p>Updated {{.Updated.Format "2006-01-02 15:04 MST"}}</p>
+{{range .Teams}}
+<h2>{{.Name}}</h2>
+<p>Namespaces: {{range $i, $ns := .Namespaces}}{{if $i}}, {{end}}{{$ns}}{{end}}</p>
+<p>Burn rate {{money .CostPerHour}}/hour, {{money .CostPerMonth}}/month.
+{{with .WastePercent}} Waste {{printf "%.0f" .}}% of requests.{{end}}
+{{with .Trend}} Week over week {{.Summary}}.{{end}}
+{{with .Forecast}} Forecast {{money .Value}}/hour by {{.At.Format "2006-01-02"}}.{{end}}</p>
+{{if .History}}<table><tr><th>Day</th><th>Average per hour</th></tr>
+{{range .History}}<tr><td>{{.Time.Format "2006-01-02"}}</td><td>{{money .Value}}</td></tr>
+{{end}}</table>{{end}}
+<table><tr><th>Workload</th><th>Pods</th><th>Per hour</th><th>Unused per hour</th></tr>
+{{range .Workloads}}<tr><td>{{.Namespace}}/{{.Kind}}/{{.Name}}</td><td>{{.Pods}}</td><td>{{money .CostPerHour}}</td><td>{{money .WastePerHour}}</td></tr>
+{{end}}</table>
+{{else}}<p>No workloads to show.</p>{{end}}
+</body></html>
+`))
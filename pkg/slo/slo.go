@@ -0,0 +1,348 @@
+package slo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/notify"
+	"github.com/ochestra-tech/ochestra-ai/pkg/snapshot"
+)
+
+// Burn rate thresholds for the 1h (fast) and 6h (slow) windows. A burn rate of 1 spends
+// exactly the error budget over the SLO window; 14.4 spends 2% of a 30d budget in an hour.
+const (
+	FastBurnThreshold = 14.4
+	SlowBurnThreshold = 6.0
+)
+
+// Objective is an availability target for a Deployment or Service
+type Objective struct {
+	Name       string  `json:"name"`
+	Namespace  string  `json:"namespace"`
+	Deployment string  `json:"deployment,omitempty"`
+	Service    string  `json:"service,omitempty"`
+	Target     float64 `json:"target"`           // e.g. 0.999
+	Window     string  `json:"window,omitempty"` // e.g. "30d"; defaults to 30d
+
+	window time.Duration
+}
+
+// Config holds the objectives loaded from an SLO file
+type Config struct {
+	Objectives []Objective `json:"slos"`
+}
+
+// Sample is one availability observation of an objective
+type Sample struct {
+	Time   time.Time `json:"time"`
+	Good   bool      `json:"good"`
+	Reason string    `json:"reason,omitempty"`
+}
+
+// Status is the evaluated state of an objective over its window
+type Status struct {
+	Objective            Objective     `json:"objective"`
+	Available            bool          `json:"available"` // latest sample
+	Reason               string        `json:"reason,omitempty"`
+	Availability         float64       `json:"availability"`         // over the window
+	ErrorBudgetRemaining float64       `json:"errorBudgetRemaining"` // fraction of the budget left, negative when exhausted
+	BurnRate1h           float64       `json:"burnRate1h"`
+	BurnRate6h           float64       `json:"burnRate6h"`
+	Downtime             time.Duration `json:"downtime"`
+	Met                  bool          `json:"met"`
+}
+
+// LoadConfig reads SLO objectives from a JSON file
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SLO config: %w", err)
+	}
+
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse SLO config: %w", err)
+	}
+
+	for i := range config.Objectives {
+		o := &config.Objectives[i]
+		if o.Name == "" || o.Namespace == "" || (o.Deployment == "") == (o.Service == "") {
+			return nil, fmt.Errorf("SLO %d must have a name, a namespace and exactly one of deployment or service", i)
+		}
+		if o.Target <= 0 || o.Target >= 1 {
+			return nil, fmt.Errorf("SLO %s target must be between 0 and 1", o.Name)
+		}
+		if o.Window == "" {
+			o.Window = "30d"
+		}
+		window, err := parseWindow(o.Window)
+		if err != nil {
+			return nil, fmt.Errorf("SLO %s has an invalid window: %w", o.Name, err)
+		}
+		o.window = window
+	}
+
+	return &config, nil
+}
+
+// parseWindow parses a duration, additionally accepting a "d" suffix for days
+func parseWindow(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid number of days %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(s)
+}
+
+// Probe observes whether an objective's workload is available in a snapshot. A
+// Deployment is available when all desired replicas pass their readiness probes; a
+// Service when it has at least one ready endpoint address.
+func Probe(o Objective, snap *snapshot.ClusterSnapshot) Sample {
+	sample := Sample{Time: snap.TakenAt, Good: true}
+
+	if o.Deployment != "" {
+		if err := snap.Errors["deployments"]; err != nil {
+			return Sample{Time: snap.TakenAt, Good: false, Reason: err.Error()}
+		}
+		for _, d := range snap.Deployments {
+			if d.Namespace != o.Namespace || d.Name != o.Deployment {
+				continue
+			}
+			desired := int32(1)
+			if d.Spec.Replicas != nil {
+				desired = *d.Spec.Replicas
+			}
+			if d.Status.ReadyReplicas < desired {
+				sample.Good = false
+				sample.Reason = fmt.Sprintf("%d/%d replicas ready", d.Status.ReadyReplicas, desired)
+			}
+			return sample
+		}
+		return Sample{Time: snap.TakenAt, Good: false, Reason: "deployment not found"}
+	}
+
+	if err := snap.Errors["endpoints"]; err != nil {
+		return Sample{Time: snap.TakenAt, Good: false, Reason: err.Error()}
+	}
+	for _, ep := range snap.Endpoints {
+		if ep.Namespace != o.Namespace || ep.Name != o.Service {
+			continue
+		}
+		if ready, notReady := countAddresses(ep.Subsets); ready == 0 {
+			sample.Good = false
+			sample.Reason = fmt.Sprintf("no ready endpoints (%d not ready)", notReady)
+		}
+		return sample
+	}
+	return Sample{Time: snap.TakenAt, Good: false, Reason: "service has no endpoints"}
+}
+
+// countAddresses counts ready and not-ready endpoint addresses
+func countAddresses(subsets []v1.EndpointSubset) (int, int) {
+	ready, notReady := 0, 0
+	for _, subset := range subsets {
+		ready += len(subset.Addresses)
+		notReady += len(subset.NotReadyAddresses)
+	}
+	return ready, notReady
+}
+
+// Evaluate computes availability, error budget and burn rates from samples ordered oldest
+// first. Each sample is assumed to hold until the next one.
+func Evaluate(o Objective, samples []Sample, now time.Time) Status {
+	status := Status{Objective: o, Available: true, Availability: 1}
+	if len(samples) > 0 {
+		latest := samples[len(samples)-1]
+		status.Available = latest.Good
+		status.Reason = latest.Reason
+	}
+
+	window := o.window
+	if window == 0 {
+		window, _ = parseWindow(o.Window)
+	}
+
+	windowBad, windowTotal := badTime(samples, now.Add(-window), now)
+	if windowTotal > 0 {
+		status.Availability = 1 - windowBad.Seconds()/windowTotal.Seconds()
+	}
+	status.Downtime = windowBad
+
+	budget := 1 - o.Target
+	status.ErrorBudgetRemaining = 1 - (1-status.Availability)/budget
+	status.BurnRate1h = burnRate(samples, now, time.Hour, budget)
+	status.BurnRate6h = burnRate(samples, now, 6*time.Hour, budget)
+	status.Met = status.Availability >= o.Target
+
+	return status
+}
+
+// burnRate is the rate the error budget was spent at over the trailing period
+func burnRate(samples []Sample, now time.Time, period time.Duration, budget float64) float64 {
+	bad, total := badTime(samples, now.Add(-period), now)
+	if total == 0 {
+		return 0
+	}
+	return bad.Seconds() / total.Seconds() / budget
+}
+
+// badTime returns the time spent unavailable and the observed time between since and until
+func badTime(samples []Sample, since, until time.Time) (time.Duration, time.Duration) {
+	var bad, total time.Duration
+	for i, s := range samples {
+		start := s.Time
+		end := until
+		if i+1 < len(samples) {
+			end = samples[i+1].Time
+		}
+		if end.Before(since) || !start.Before(until) {
+			continue
+		}
+		if start.Before(since) {
+			start = since
+		}
+		if end.After(until) {
+			end = until
+		}
+		total += end.Sub(start)
+		if !s.Good {
+			bad += end.Sub(start)
+		}
+	}
+	return bad, total
+}
+
+// Tracker records availability samples each cycle, persists them and alerts on fast or
+// slow error budget burn
+type Tracker struct {
+	config   *Config
+	path     string
+	notifier notify.Notifier
+	cluster  string
+
+	mu      sync.Mutex
+	samples map[string][]Sample // objective name -> samples within its window
+	alerted map[string]string   // objective name -> severity of the open burn alert
+}
+
+// LoadTracker creates a tracker, restoring samples persisted at path if it is not empty
+func LoadTracker(config *Config, path string, notifier notify.Notifier, cluster string) (*Tracker, error) {
+	t := &Tracker{
+		config:   config,
+		path:     path,
+		notifier: notifier,
+		cluster:  cluster,
+		samples:  make(map[string][]Sample),
+		alerted:  make(map[string]string),
+	}
+	if path == "" {
+		return t, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return t, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SLO state: %w", err)
+	}
+	if err := json.Unmarshal(data, &t.samples); err != nil {
+		return nil, fmt.Errorf("failed to parse SLO state: %w", err)
+	}
+	return t, nil
+}
+
+// Record probes every objective in the snapshot, evaluates it and sends burn alerts
+func (t *Tracker) Record(ctx context.Context, snap *snapshot.ClusterSnapshot) ([]Status, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := snap.TakenAt
+	statuses := make([]Status, 0, len(t.config.Objectives))
+	for _, o := range t.config.Objectives {
+		samples := append(t.samples[o.Name], Probe(o, snap))
+
+		// Drop samples that no longer affect the window, keeping the one that spans its start
+		cutoff := now.Add(-o.window)
+		for len(samples) > 1 && !samples[1].Time.After(cutoff) {
+			samples = samples[1:]
+		}
+		t.samples[o.Name] = samples
+
+		status := Evaluate(o, samples, now)
+		statuses = append(statuses, status)
+
+		if err := t.alert(ctx, status, now); err != nil {
+			return statuses, err
+		}
+	}
+
+	return statuses, t.save()
+}
+
+// alert sends an alert when an objective starts burning its budget too fast, escalates,
+// or recovers
+func (t *Tracker) alert(ctx context.Context, status Status, now time.Time) error {
+	o := status.Objective
+	severity := ""
+	switch {
+	case status.BurnRate1h >= FastBurnThreshold:
+		severity = "critical"
+	case status.BurnRate6h >= SlowBurnThreshold:
+		severity = "warning"
+	}
+
+	previous := t.alerted[o.Name]
+	if severity == previous || (severity == "warning" && previous == "critical") {
+		return nil
+	}
+
+	alert := notify.Alert{
+		Title:    fmt.Sprintf("SLO %s burning error budget at %.1fx", o.Name, status.BurnRate1h),
+		Severity: severity,
+		Source:   "slo",
+		Message: fmt.Sprintf("Availability %.3f%% against a %.3f%% target, %.0f%% of the error budget left (1h burn %.1fx, 6h burn %.1fx)",
+			status.Availability*100, o.Target*100, status.ErrorBudgetRemaining*100, status.BurnRate1h, status.BurnRate6h),
+		Labels:      map[string]string{"slo": o.Name, "namespace": o.Namespace, "cluster": t.cluster},
+		Timestamp:   now,
+		Fingerprint: "slo/" + o.Name,
+	}
+	if severity == "" {
+		alert.Title = fmt.Sprintf("SLO %s burn rate recovered", o.Name)
+		alert.Severity = "info"
+		alert.Resolved = true
+	}
+
+	t.alerted[o.Name] = severity
+	if err := t.notifier.Notify(ctx, alert); err != nil {
+		return fmt.Errorf("failed to send SLO alert: %w", err)
+	}
+	return nil
+}
+
+// save persists samples to disk if the tracker has a state file
+func (t *Tracker) save() error {
+	if t.path == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(t.samples)
+	if err != nil {
+		return fmt.Errorf("failed to marshal SLO state: %w", err)
+	}
+	if err := os.WriteFile(t.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write SLO state: %w", err)
+	}
+	return nil
+}
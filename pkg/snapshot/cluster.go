@@ -0,0 +1,265 @@
+package snapshot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	metricsapi "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+	metricsv "k8s.io/metrics/pkg/client/clientset/versioned"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/retry"
+)
+
+// LargeClusterConfig controls when a cluster is treated as large and how snapshots are
+// taken in that case
+type LargeClusterConfig struct {
+	NodeThreshold int   // node count above which the cluster is large
+	PodThreshold  int   // pod count above which the cluster is large
+	PageSize      int64 // list page size used for large clusters
+	Parallelism   int   // concurrent list calls for large clusters
+}
+
+// LargeCluster is the large-cluster configuration used by Take
+var LargeCluster = LargeClusterConfig{
+	NodeThreshold: 200,
+	PodThreshold:  5000,
+	PageSize:      2000,
+	Parallelism:   4,
+}
+
+// Lean makes Take read only nodes and pods, skipping the optional resources and metrics,
+// and mark the snapshot Large so per-object work is skipped too. The monitor sets it while
+// it is short of memory or CPU.
+var Lean bool
+
+// ClusterSnapshot holds the cluster objects read once per monitoring cycle so health
+// checks, cost tracking and optimizer analyses work from the same data
+type ClusterSnapshot struct {
+	PodSnapshot
+	Nodes       []v1.Node
+	Deployments []appsv1.Deployment
+	DaemonSets  []appsv1.DaemonSet
+	Services    []v1.Service
+	Endpoints   []v1.Endpoints
+	HPAs        []autoscalingv2.HorizontalPodAutoscaler
+	PodMetrics  []metricsapi.PodMetrics
+	NodeMetrics []metricsapi.NodeMetrics
+
+	// Errors records optional resources that could not be read, keyed by resource name
+	// ("deployments", "daemonsets", "services", "endpoints", "hpas", "podMetrics", "nodeMetrics")
+	Errors map[string]error
+
+	// Large is set when the cluster exceeds the LargeCluster thresholds. Callers should
+	// skip expensive per-object work, such as per-pod metric series, for large clusters.
+	Large bool
+
+	// APILatency is how long the apiserver took to list nodes, a cheap probe of its latency
+	APILatency time.Duration
+
+	mu sync.Mutex
+}
+
+// Take reads nodes, pods, deployments, daemonsets, services, endpoints, HPAs and metrics.
+// Nodes and pods are required; failures reading the other resources are recorded in Errors.
+func Take(ctx context.Context, clientset kubernetes.Interface, metricsClient metricsv.Interface) (*ClusterSnapshot, error) {
+	start := time.Now()
+	nodes, err := retry.Value(ctx, retry.DefaultBackoff, func() (*v1.NodeList, error) {
+		return clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+	apiLatency := time.Since(start)
+
+	// Large clusters are listed with bigger pages and concurrent calls
+	large := len(nodes.Items) > LargeCluster.NodeThreshold
+	pageSize, parallelism := PageSize, 1
+	if large {
+		pageSize, parallelism = LargeCluster.PageSize, LargeCluster.Parallelism
+	}
+
+	pods, err := ListPods(ctx, clientset, "", metav1.ListOptions{Limit: pageSize})
+	if err != nil {
+		return nil, err
+	}
+
+	snap := &ClusterSnapshot{
+		PodSnapshot: PodSnapshot{Pods: pods, TakenAt: time.Now()},
+		Nodes:       nodes.Items,
+		Errors:      make(map[string]error),
+		Large:       large || len(pods) > LargeCluster.PodThreshold || Lean,
+		APILatency:  apiLatency,
+	}
+	if Lean {
+		log.Printf("Lean snapshot: %d nodes, %d pods, skipping other resources", len(snap.Nodes), len(snap.Pods))
+		for _, resource := range []string{"deployments", "daemonsets", "services", "endpoints", "hpas", "podMetrics", "nodeMetrics"} {
+			snap.Errors[resource] = fmt.Errorf("skipped %s in a lean snapshot", resource)
+		}
+		return snap, nil
+	}
+	if snap.Large {
+		log.Printf("Large cluster mode: %d nodes, %d pods", len(snap.Nodes), len(snap.Pods))
+	}
+
+	loaders := []func(){
+		func() {
+			deployments, err := retry.Value(ctx, retry.DefaultBackoff, func() (*appsv1.DeploymentList, error) {
+				return clientset.AppsV1().Deployments("").List(ctx, metav1.ListOptions{})
+			})
+			snap.store("deployments", err, func() { snap.Deployments = deployments.Items })
+		},
+		func() {
+			daemonSets, err := retry.Value(ctx, retry.DefaultBackoff, func() (*appsv1.DaemonSetList, error) {
+				return clientset.AppsV1().DaemonSets("").List(ctx, metav1.ListOptions{})
+			})
+			snap.store("daemonsets", err, func() { snap.DaemonSets = daemonSets.Items })
+		},
+		func() {
+			services, err := retry.Value(ctx, retry.DefaultBackoff, func() (*v1.ServiceList, error) {
+				return clientset.CoreV1().Services("").List(ctx, metav1.ListOptions{})
+			})
+			snap.store("services", err, func() { snap.Services = services.Items })
+		},
+		func() {
+			endpoints, err := retry.Value(ctx, retry.DefaultBackoff, func() (*v1.EndpointsList, error) {
+				return clientset.CoreV1().Endpoints("").List(ctx, metav1.ListOptions{})
+			})
+			snap.store("endpoints", err, func() { snap.Endpoints = endpoints.Items })
+		},
+		func() {
+			hpas, err := retry.Value(ctx, retry.DefaultBackoff, func() (*autoscalingv2.HorizontalPodAutoscalerList, error) {
+				return clientset.AutoscalingV2().HorizontalPodAutoscalers("").List(ctx, metav1.ListOptions{})
+			})
+			snap.store("hpas", err, func() { snap.HPAs = hpas.Items })
+		},
+	}
+	if metricsClient != nil {
+		loaders = append(loaders,
+			func() {
+				podMetrics, err := retry.Value(ctx, retry.DefaultBackoff, func() (*metricsapi.PodMetricsList, error) {
+					return metricsClient.MetricsV1beta1().PodMetricses("").List(ctx, metav1.ListOptions{})
+				})
+				snap.store("podMetrics", err, func() { snap.PodMetrics = podMetrics.Items })
+			},
+			func() {
+				nodeMetrics, err := retry.Value(ctx, retry.DefaultBackoff, func() (*metricsapi.NodeMetricsList, error) {
+					return metricsClient.MetricsV1beta1().NodeMetricses().List(ctx, metav1.ListOptions{})
+				})
+				snap.store("nodeMetrics", err, func() { snap.NodeMetrics = nodeMetrics.Items })
+			},
+		)
+	}
+	runLimited(loaders, parallelism)
+
+	return snap, nil
+}
+
+// store applies a loaded optional resource to the snapshot, or records why it failed
+func (s *ClusterSnapshot) store(resource string, err error, apply func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err != nil {
+		log.Printf("Failed to list %s for cluster snapshot: %v", resource, err)
+		s.Errors[resource] = fmt.Errorf("failed to list %s: %w", resource, err)
+		return
+	}
+	apply()
+}
+
+// runLimited runs the functions with at most limit running at once
+func runLimited(fns []func(), limit int) {
+	if limit < 1 {
+		limit = 1
+	}
+
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for _, fn := range fns {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(fn func()) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			fn()
+		}(fn)
+	}
+	wg.Wait()
+}
+
+// Node returns the node with the given name, or nil if it is not in the snapshot
+func (s *ClusterSnapshot) Node(name string) *v1.Node {
+	for i := range s.Nodes {
+		if s.Nodes[i].Name == name {
+			return &s.Nodes[i]
+		}
+	}
+	return nil
+}
+
+// Operating systems nodes report in their kubernetes.io/os label
+const (
+	OSLinux   = "linux"
+	OSWindows = "windows"
+)
+
+// NodeOS returns a node's operating system from its kubernetes.io/os label, falling back to
+// what the kubelet reports, and to Linux when neither is set
+func NodeOS(node *v1.Node) string {
+	if os := node.Labels[v1.LabelOSStable]; os != "" {
+		return os
+	}
+	if os := node.Status.NodeInfo.OperatingSystem; os != "" {
+		return os
+	}
+	return OSLinux
+}
+
+// NodeArch returns a node's CPU architecture from its kubernetes.io/arch label, falling back
+// to what the kubelet reports, and to amd64 when neither is set
+func NodeArch(node *v1.Node) string {
+	if arch := node.Labels[v1.LabelArchStable]; arch != "" {
+		return arch
+	}
+	if arch := node.Status.NodeInfo.Architecture; arch != "" {
+		return arch
+	}
+	return "amd64"
+}
+
+// VirtualNodeLabel lets virtual nodes that their provider does not label be marked with
+// ochestra.io/virtual-node=true
+const VirtualNodeLabel = "ochestra.io/virtual-node"
+
+// virtualNodeLabels are the labels virtual kubelets and serverless node pools set
+var virtualNodeLabels = map[string]string{
+	"type":                           "virtual-kubelet", // virtual-kubelet providers, e.g. AKS virtual nodes on ACI
+	"eks.amazonaws.com/compute-type": "fargate",
+	VirtualNodeLabel:                 "true",
+}
+
+// IsVirtualNode reports whether a node is a virtual kubelet or a serverless node, such as
+// an AKS virtual node or an EKS Fargate node, rather than a machine. Its capacity is
+// nominal, pods on it are billed by their requests, and it has no host, disks or
+// DaemonSet pods.
+func IsVirtualNode(node *v1.Node) bool {
+	for label, value := range virtualNodeLabels {
+		if node.Labels[label] == value {
+			return true
+		}
+	}
+	for _, taint := range node.Spec.Taints {
+		if taint.Key == "virtual-kubelet.io/provider" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,272 @@
+package snapshot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/retry"
+)
+
+// PageSize is the number of objects requested per list call. Paging keeps the
+// apiserver response and decode buffers small on large clusters.
+var PageSize int64 = 500
+
+// metadataAccept asks the apiserver for PartialObjectMetadataList instead of full objects
+const metadataAccept = "application/json;as=PartialObjectMetadataList;g=meta.k8s.io;v=v1,application/json"
+
+// Field selectors for pod lists that only need a subset of pods, so the apiserver filters
+// them instead of sending every pod
+const (
+	// RunningPodsSelector selects running pods
+	RunningPodsSelector = "status.phase=Running"
+	// ActivePodsSelector selects scheduled pods that have not finished, the pods that hold
+	// their node's resources
+	ActivePodsSelector = "spec.nodeName!=,status.phase!=Succeeded,status.phase!=Failed"
+)
+
+// ListPods lists pods page by page. Managed fields and the last-applied annotation are
+// dropped from every pod since no check reads them and they often dominate object size.
+func ListPods(ctx context.Context, clientset kubernetes.Interface, namespace string, opts metav1.ListOptions) ([]v1.Pod, error) {
+	if opts.Limit == 0 {
+		opts.Limit = PageSize
+	}
+
+	var pods []v1.Pod
+	for {
+		page, err := retry.Value(ctx, retry.DefaultBackoff, func() (*v1.PodList, error) {
+			return clientset.CoreV1().Pods(namespace).List(ctx, opts)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list pods: %w", err)
+		}
+
+		if pods == nil {
+			pods = make([]v1.Pod, 0, len(page.Items))
+		}
+		for i := range page.Items {
+			trimObjectMeta(&page.Items[i].ObjectMeta)
+			pods = append(pods, page.Items[i])
+		}
+
+		if page.Continue == "" {
+			return pods, nil
+		}
+		opts.Continue = page.Continue
+	}
+}
+
+// ListEvents lists core/v1 events page by page, dropping managed fields
+func ListEvents(ctx context.Context, clientset kubernetes.Interface, namespace string, opts metav1.ListOptions) ([]v1.Event, error) {
+	if opts.Limit == 0 {
+		opts.Limit = PageSize
+	}
+
+	var events []v1.Event
+	for {
+		page, err := retry.Value(ctx, retry.DefaultBackoff, func() (*v1.EventList, error) {
+			return clientset.CoreV1().Events(namespace).List(ctx, opts)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list events: %w", err)
+		}
+
+		for i := range page.Items {
+			page.Items[i].ManagedFields = nil
+		}
+		events = append(events, page.Items...)
+
+		if page.Continue == "" {
+			return events, nil
+		}
+		opts.Continue = page.Continue
+	}
+}
+
+// ListMetadata lists only the metadata of a core/v1 resource (e.g. "pods", "configmaps"),
+// for callers that need names, labels, owners or timestamps but not specs or data
+func ListMetadata(
+	ctx context.Context,
+	clientset kubernetes.Interface,
+	resource string,
+	namespace string,
+	opts metav1.ListOptions,
+) ([]metav1.PartialObjectMetadata, error) {
+	return ListGroupMetadata(ctx, clientset.CoreV1().RESTClient(), resource, namespace, opts)
+}
+
+// ListGroupMetadata is ListMetadata for a resource of the API group the REST client
+// serves, e.g. clientset.AppsV1().RESTClient() for "replicasets"
+func ListGroupMetadata(
+	ctx context.Context,
+	client rest.Interface,
+	resource string,
+	namespace string,
+	opts metav1.ListOptions,
+) ([]metav1.PartialObjectMetadata, error) {
+	if opts.Limit == 0 {
+		opts.Limit = PageSize
+	}
+
+	var items []metav1.PartialObjectMetadata
+	for {
+		page, err := retry.Value(ctx, retry.DefaultBackoff, func() (*metav1.PartialObjectMetadataList, error) {
+			data, err := client.Get().
+				Namespace(namespace).
+				Resource(resource).
+				VersionedParams(&opts, metav1.ParameterCodec).
+				SetHeader("Accept", metadataAccept).
+				DoRaw(ctx)
+			if err != nil {
+				return nil, err
+			}
+
+			// Servers that ignore the Accept header return full objects, which decode the same way
+			var list metav1.PartialObjectMetadataList
+			if err := json.Unmarshal(data, &list); err != nil {
+				return nil, fmt.Errorf("failed to decode %s metadata: %w", resource, err)
+			}
+			return &list, nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s metadata: %w", resource, err)
+		}
+
+		for i := range page.Items {
+			trimObjectMeta(&page.Items[i].ObjectMeta)
+		}
+		items = append(items, page.Items...)
+
+		if page.Continue == "" {
+			return items, nil
+		}
+		opts.Continue = page.Continue
+	}
+}
+
+// trimObjectMeta drops metadata fields that are large and unused by any check
+func trimObjectMeta(meta *metav1.ObjectMeta) {
+	meta.ManagedFields = nil
+	delete(meta.Annotations, "kubectl.kubernetes.io/last-applied-configuration")
+}
+
+// PodSnapshot is a single cluster-wide pod listing shared by all checks of one run
+type PodSnapshot struct {
+	Pods    []v1.Pod
+	TakenAt time.Time
+}
+
+// NewPodSnapshot lists every pod in the cluster once
+func NewPodSnapshot(ctx context.Context, clientset kubernetes.Interface) (*PodSnapshot, error) {
+	pods, err := ListPods(ctx, clientset, "", metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return &PodSnapshot{Pods: pods, TakenAt: time.Now()}, nil
+}
+
+// Select returns the pods in a namespace (all namespaces if empty) matching a label
+// selector (everything if nil). The returned pointers refer to the snapshot and must not be modified.
+func (s *PodSnapshot) Select(namespace string, selector labels.Selector) []*v1.Pod {
+	result := make([]*v1.Pod, 0)
+	for i := range s.Pods {
+		pod := &s.Pods[i]
+		if namespace != "" && pod.Namespace != namespace {
+			continue
+		}
+		if selector != nil && !selector.Matches(labels.Set(pod.Labels)) {
+			continue
+		}
+		result = append(result, pod)
+	}
+	return result
+}
+
+// SelectString is like Select but parses the label selector; it panics on an invalid
+// selector and is intended for selectors that are constants in the code
+func (s *PodSnapshot) SelectString(namespace, selector string) []*v1.Pod {
+	parsed, err := labels.Parse(selector)
+	if err != nil {
+		panic(fmt.Sprintf("invalid label selector %q: %v", selector, err))
+	}
+	return s.Select(namespace, parsed)
+}
+
+// WorkloadOwner resolves the controller that owns a pod, mapping ReplicaSets to their Deployment
+func WorkloadOwner(pod *v1.Pod) (string, string) {
+	for _, ref := range pod.OwnerReferences {
+		if ref.Controller == nil || !*ref.Controller {
+			continue
+		}
+		if ref.Kind == "ReplicaSet" {
+			if hash, ok := pod.Labels["pod-template-hash"]; ok && strings.HasSuffix(ref.Name, "-"+hash) {
+				return "Deployment", strings.TrimSuffix(ref.Name, "-"+hash)
+			}
+		}
+		return ref.Kind, ref.Name
+	}
+	return "Pod", pod.Name
+}
+
+// PodOS returns the operating system a pod template asks for through spec.os, a
+// kubernetes.io/os node selector or a required node affinity on that label, or "" when it
+// asks for none
+func PodOS(spec *v1.PodSpec) string {
+	if spec.OS != nil {
+		return string(spec.OS.Name)
+	}
+	if os := spec.NodeSelector[v1.LabelOSStable]; os != "" {
+		return os
+	}
+	if affinity := spec.Affinity; affinity != nil && affinity.NodeAffinity != nil {
+		if required := affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution; required != nil {
+			for _, term := range required.NodeSelectorTerms {
+				for _, req := range term.MatchExpressions {
+					if req.Key == v1.LabelOSStable && req.Operator == v1.NodeSelectorOpIn && len(req.Values) == 1 {
+						return req.Values[0]
+					}
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// SidecarNames are the containers service meshes, proxies and agents inject next to an
+// application's own containers
+var SidecarNames = []string{
+	"istio-proxy", "linkerd-proxy", "envoy", "envoy-sidecar", "consul-dataplane", "kuma-sidecar",
+	"cloud-sql-proxy", "cloudsql-proxy", "vault-agent", "aws-otel-collector", "otc-container",
+}
+
+// Containers returns the containers that run for a pod's lifetime: its containers and its
+// native sidecars, the init containers restarted until the pod stops
+func Containers(pod *v1.Pod) []v1.Container {
+	containers := pod.Spec.Containers
+	for _, c := range pod.Spec.InitContainers {
+		if c.RestartPolicy != nil && *c.RestartPolicy == v1.ContainerRestartPolicyAlways {
+			containers = append(containers[:len(containers):len(containers)], c)
+		}
+	}
+	return containers
+}
+
+// Sidecar reports whether a pod's container is a sidecar: a native sidecar, or a container
+// named in SidecarNames running next to others. A proxy running alone is the application.
+func Sidecar(pod *v1.Pod, name string) bool {
+	for _, c := range pod.Spec.InitContainers {
+		if c.Name == name {
+			return c.RestartPolicy != nil && *c.RestartPolicy == v1.ContainerRestartPolicyAlways
+		}
+	}
+	return len(Containers(pod)) > 1 && slices.Contains(SidecarNames, name)
+}
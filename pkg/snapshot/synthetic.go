@@ -0,0 +1,139 @@
+package snapshot
+
+import (
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	metricsapi "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+)
+
+// Synthetic builds a deterministic snapshot of a cluster with the given number of nodes
+// and pods, for benchmarking checks without an apiserver. Pods are spread across 50
+// namespaces in deployments of 10 replicas with one service each; roughly 5% are pending,
+// 1% crash-looping, and every running pod uses a third of its requests.
+func Synthetic(nodeCount, podCount int) *ClusterSnapshot {
+	const (
+		namespaces = 50
+		replicas   = 10
+	)
+
+	controller := true
+	snap := &ClusterSnapshot{
+		PodSnapshot: PodSnapshot{
+			Pods:    make([]v1.Pod, 0, podCount),
+			TakenAt: time.Now(),
+		},
+		Nodes:  make([]v1.Node, 0, nodeCount),
+		Errors: make(map[string]error),
+	}
+	snap.Large = nodeCount > LargeCluster.NodeThreshold || podCount > LargeCluster.PodThreshold
+
+	for i := 0; i < nodeCount; i++ {
+		snap.Nodes = append(snap.Nodes, v1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: fmt.Sprintf("node-%04d", i),
+				Labels: map[string]string{
+					"node.kubernetes.io/instance-type": []string{"m5.xlarge", "m5.2xlarge", "c5.2xlarge"}[i%3],
+					"topology.kubernetes.io/region":    "us-east-1",
+				},
+			},
+			Status: v1.NodeStatus{
+				Capacity: v1.ResourceList{
+					v1.ResourceCPU:    resource.MustParse("8"),
+					v1.ResourceMemory: resource.MustParse("32Gi"),
+				},
+				Allocatable: v1.ResourceList{
+					v1.ResourceCPU:    resource.MustParse("7800m"),
+					v1.ResourceMemory: resource.MustParse("30Gi"),
+				},
+				Conditions: []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionTrue}},
+			},
+		})
+	}
+
+	for i := 0; i < podCount; i++ {
+		namespace := fmt.Sprintf("team-%02d", i%namespaces)
+		app := fmt.Sprintf("app-%04d", i/(namespaces*replicas))
+		hash := "5d4f8b9c7"
+		phase := v1.PodRunning
+		var waiting *v1.ContainerStateWaiting
+		switch {
+		case i%20 == 0:
+			phase = v1.PodPending
+		case i%100 == 1:
+			waiting = &v1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}
+		}
+
+		pod := v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      fmt.Sprintf("%s-%s-%05d", app, hash, i),
+				Namespace: namespace,
+				Labels:    map[string]string{"app": app, "pod-template-hash": hash},
+				OwnerReferences: []metav1.OwnerReference{{
+					APIVersion: "apps/v1",
+					Kind:       "ReplicaSet",
+					Name:       app + "-" + hash,
+					Controller: &controller,
+				}},
+			},
+			Spec: v1.PodSpec{
+				Containers: []v1.Container{{
+					Name: "main",
+					Resources: v1.ResourceRequirements{
+						Requests: v1.ResourceList{
+							v1.ResourceCPU:    resource.MustParse("300m"),
+							v1.ResourceMemory: resource.MustParse("768Mi"),
+						},
+						Limits: v1.ResourceList{
+							v1.ResourceCPU:    resource.MustParse("1"),
+							v1.ResourceMemory: resource.MustParse("1Gi"),
+						},
+					},
+				}},
+			},
+			Status: v1.PodStatus{
+				Phase:             phase,
+				QOSClass:          v1.PodQOSBurstable,
+				ContainerStatuses: []v1.ContainerStatus{{Name: "main", State: v1.ContainerState{Waiting: waiting}}},
+			},
+		}
+		if phase == v1.PodRunning && nodeCount > 0 {
+			pod.Spec.NodeName = snap.Nodes[i%nodeCount].Name
+			snap.PodMetrics = append(snap.PodMetrics, metricsapi.PodMetrics{
+				ObjectMeta: metav1.ObjectMeta{Name: pod.Name, Namespace: namespace},
+				Containers: []metricsapi.ContainerMetrics{{
+					Name: "main",
+					Usage: v1.ResourceList{
+						v1.ResourceCPU:    resource.MustParse("100m"),
+						v1.ResourceMemory: resource.MustParse("256Mi"),
+					},
+				}},
+			})
+		}
+		snap.Pods = append(snap.Pods, pod)
+
+		// One deployment and service per app and namespace
+		if (i/namespaces)%replicas == 0 {
+			replicaCount := int32(replicas)
+			snap.Deployments = append(snap.Deployments, appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: app, Namespace: namespace, Labels: map[string]string{"app": app}},
+				Spec:       appsv1.DeploymentSpec{Replicas: &replicaCount},
+				Status:     appsv1.DeploymentStatus{ReadyReplicas: replicaCount},
+			})
+			snap.Services = append(snap.Services, v1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: app, Namespace: namespace},
+				Spec:       v1.ServiceSpec{Selector: map[string]string{"app": app}},
+			})
+			snap.Endpoints = append(snap.Endpoints, v1.Endpoints{
+				ObjectMeta: metav1.ObjectMeta{Name: app, Namespace: namespace},
+				Subsets:    []v1.EndpointSubset{{Addresses: []v1.EndpointAddress{{IP: "10.0.0.1"}}}},
+			})
+		}
+	}
+
+	return snap
+}
@@ -0,0 +1,358 @@
+package trends
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/auth"
+	"github.com/ochestra-tech/ochestra-ai/pkg/history"
+	"github.com/ochestra-tech/ochestra-ai/pkg/snapshot"
+)
+
+// Metrics with trends. Cost comes from the recorded namespace cost rates; the others from
+// the series Collect and ScoreSeries add to each snapshot.
+const (
+	MetricScore      = "score"      // cluster or namespace health score, 0-100
+	MetricCost       = "cost"       // hourly cost rate
+	MetricCPU        = "cpu"        // CPU usage in cores
+	MetricMemory     = "memory"     // memory usage in bytes
+	MetricEfficiency = "efficiency" // cluster or namespace efficiency score, 0-100
+)
+
+// Metrics lists every metric with a trend
+var Metrics = []string{MetricScore, MetricCost, MetricCPU, MetricMemory, MetricEfficiency}
+
+// ClusterSubject is the series subject for cluster-wide values
+const ClusterSubject = "cluster"
+
+// SeriesKey names a trend series in a history snapshot, e.g. "cpu|cluster" or
+// "memory|payments"
+func SeriesKey(metric, subject string) string {
+	return metric + "|" + subject
+}
+
+// ScoreSeries returns the series recording a health score
+func ScoreSeries(score int) map[string]float64 {
+	return map[string]float64{SeriesKey(MetricScore, ClusterSubject): float64(score)}
+}
+
+// NamespaceScoreSeries returns the series recording namespace health scores
+func NamespaceScoreSeries(scores map[string]int) map[string]float64 {
+	series := make(map[string]float64, len(scores))
+	for ns, score := range scores {
+		series[SeriesKey(MetricScore, ns)] = float64(score)
+	}
+	return series
+}
+
+// EfficiencySeries returns the series recording the cluster's and namespaces' efficiency
+// scores
+func EfficiencySeries(cluster int, namespaces map[string]int) map[string]float64 {
+	series := make(map[string]float64, len(namespaces)+1)
+	series[SeriesKey(MetricEfficiency, ClusterSubject)] = float64(cluster)
+	for ns, score := range namespaces {
+		series[SeriesKey(MetricEfficiency, ns)] = float64(score)
+	}
+	return series
+}
+
+// Collect computes cluster and per-namespace CPU and memory usage from a snapshot
+func Collect(snap *snapshot.ClusterSnapshot) map[string]float64 {
+	series := make(map[string]float64)
+	if snap.Errors["podMetrics"] != nil {
+		return series
+	}
+	for _, pm := range snap.PodMetrics {
+		for _, c := range pm.Containers {
+			cpu := c.Usage.Cpu().AsApproximateFloat64()
+			memory := float64(c.Usage.Memory().Value())
+			series[SeriesKey(MetricCPU, ClusterSubject)] += cpu
+			series[SeriesKey(MetricMemory, ClusterSubject)] += memory
+			series[SeriesKey(MetricCPU, pm.Namespace)] += cpu
+			series[SeriesKey(MetricMemory, pm.Namespace)] += memory
+		}
+	}
+	return series
+}
+
+// Point is a metric value at a time
+type Point struct {
+	Time  time.Time `json:"time"`
+	Value float64   `json:"value"`
+}
+
+// Extract returns a metric's values from snapshots ordered oldest first, for a namespace
+// or for the cluster if namespace is empty. Snapshots without the metric are skipped.
+func Extract(snapshots []*history.Snapshot, metric, namespace string) []Point {
+	subject := namespace
+	if subject == "" {
+		subject = ClusterSubject
+	}
+
+	points := make([]Point, 0, len(snapshots))
+	for _, s := range snapshots {
+		var value float64
+		var ok bool
+		if metric == MetricCost {
+			for _, ns := range s.NamespaceCosts {
+				if namespace == "" || ns.Name == namespace {
+					value += ns.TotalCost
+					ok = true
+				}
+			}
+			// Recorded namespaces that lack this one cost nothing at that time
+			ok = ok || (namespace != "" && len(s.NamespaceCosts) > 0)
+		} else {
+			value, ok = s.Series[SeriesKey(metric, subject)]
+		}
+		if ok {
+			points = append(points, Point{Time: s.Timestamp, Value: value})
+		}
+	}
+	return points
+}
+
+// MovingAverage returns the mean of the points in the trailing window at every step from
+// the first point to the last. Steps with no points in their window are left out.
+func MovingAverage(points []Point, window, step time.Duration) []Point {
+	if len(points) == 0 || window <= 0 || step <= 0 {
+		return nil
+	}
+
+	var result []Point
+	var sum float64
+	first, last := 0, 0 // points[first:last] are in the window
+	end := points[len(points)-1].Time
+	for t := points[0].Time; !t.After(end.Add(step - 1)); t = t.Add(step) {
+		if t.After(end) {
+			t = end
+		}
+		for last < len(points) && !points[last].Time.After(t) {
+			sum += points[last].Value
+			last++
+		}
+		for first < last && !points[first].Time.After(t.Add(-window)) {
+			sum -= points[first].Value
+			first++
+		}
+		if last > first {
+			result = append(result, Point{Time: t, Value: sum / float64(last-first)})
+		}
+	}
+	return result
+}
+
+// Comparison compares a metric's mean over the last week with the week before
+type Comparison struct {
+	Current       float64  `json:"current"`
+	Previous      float64  `json:"previous"`
+	Change        float64  `json:"change"`
+	ChangePercent *float64 `json:"changePercent,omitempty"` // unset when the previous week averaged zero
+}
+
+// WeekOverWeek compares the mean of the seven days before now with the seven days before
+// that, or returns nil if either week has no points
+func WeekOverWeek(points []Point, now time.Time) *Comparison {
+	week := 7 * 24 * time.Hour
+	current, currentOK := mean(points, now.Add(-week), now)
+	previous, previousOK := mean(points, now.Add(-2*week), now.Add(-week))
+	if !currentOK || !previousOK {
+		return nil
+	}
+
+	c := &Comparison{Current: current, Previous: previous, Change: current - previous}
+	if previous != 0 {
+		percent := 100 * c.Change / math.Abs(previous)
+		c.ChangePercent = &percent
+	}
+	return c
+}
+
+// Summary formats the change, as a percentage when the previous week was not zero
+func (c *Comparison) Summary() string {
+	if c.ChangePercent != nil {
+		return fmt.Sprintf("%+.1f%%", *c.ChangePercent)
+	}
+	return fmt.Sprintf("%+.2f", c.Change)
+}
+
+// mean averages the points in (from, to]
+func mean(points []Point, from, to time.Time) (float64, bool) {
+	var sum float64
+	var n int
+	for _, p := range points {
+		if p.Time.After(from) && !p.Time.After(to) {
+			sum += p.Value
+			n++
+		}
+	}
+	if n == 0 {
+		return 0, false
+	}
+	return sum / float64(n), true
+}
+
+// Projection is a linear regression fitted to a metric and extended to a future time
+type Projection struct {
+	SlopePerDay float64   `json:"slopePerDay"`
+	R2          float64   `json:"r2"` // fraction of variance the line explains; low values mean a weak trend
+	At          time.Time `json:"at"`
+	Value       float64   `json:"value"`
+}
+
+// Forecast fits a least-squares line to the points and projects it to at, or returns nil
+// with fewer than two distinct times
+func Forecast(points []Point, at time.Time) *Projection {
+	if len(points) < 2 {
+		return nil
+	}
+
+	// Regress on days since the first point to keep the sums well conditioned
+	origin := points[0].Time
+	n := float64(len(points))
+	var sumX, sumY float64
+	for _, p := range points {
+		sumX += p.Time.Sub(origin).Hours() / 24
+		sumY += p.Value
+	}
+	meanX, meanY := sumX/n, sumY/n
+	var sxx, sxy, syy float64
+	for _, p := range points {
+		dx, dy := p.Time.Sub(origin).Hours()/24-meanX, p.Value-meanY
+		sxx += dx * dx
+		sxy += dx * dy
+		syy += dy * dy
+	}
+	if sxx == 0 {
+		return nil
+	}
+
+	slope := sxy / sxx
+	projection := &Projection{
+		SlopePerDay: slope,
+		R2:          1,
+		At:          at,
+		Value:       meanY + slope*(at.Sub(origin).Hours()/24-meanX),
+	}
+	if syy > 0 {
+		projection.R2 = sxy * sxy / (sxx * syy)
+	}
+	return projection
+}
+
+// Report is the trend of one metric
+type Report struct {
+	Metric        string      `json:"metric"`
+	Namespace     string      `json:"namespace,omitempty"`
+	Latest        *Point      `json:"latest,omitempty"`
+	MovingAverage []Point     `json:"movingAverage,omitempty"`
+	WeekOverWeek  *Comparison `json:"weekOverWeek,omitempty"`
+	Forecast      *Projection `json:"forecast,omitempty"`
+}
+
+// Options controls a trend analysis
+type Options struct {
+	Since   time.Duration // history analyzed
+	Window  time.Duration // moving average window
+	Step    time.Duration // spacing of moving average points
+	Horizon time.Duration // how far ahead of now to forecast
+}
+
+// DefaultOptions analyzes 30 days with a daily moving average every 6 hours, and
+// forecasts a week ahead
+var DefaultOptions = Options{
+	Since:   30 * 24 * time.Hour,
+	Window:  24 * time.Hour,
+	Step:    6 * time.Hour,
+	Horizon: 7 * 24 * time.Hour,
+}
+
+// Analyze computes the trend of a metric from snapshots ordered oldest first
+func Analyze(snapshots []*history.Snapshot, metric, namespace string, options Options, now time.Time) Report {
+	points := Extract(snapshots, metric, namespace)
+	report := Report{
+		Metric:        metric,
+		Namespace:     namespace,
+		MovingAverage: MovingAverage(points, options.Window, options.Step),
+		WeekOverWeek:  WeekOverWeek(points, now),
+		Forecast:      Forecast(points, now.Add(options.Horizon)),
+	}
+	if len(points) > 0 {
+		report.Latest = &points[len(points)-1]
+	}
+	return report
+}
+
+// Load reads the history the options cover and analyzes each metric
+func Load(ctx context.Context, store history.Store, cluster string, metrics []string, namespace string, options Options, now time.Time) ([]Report, error) {
+	snapshots, err := store.List(ctx, history.Query{Cluster: cluster, Since: now.Add(-options.Since)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	reports := make([]Report, 0, len(metrics))
+	for _, metric := range metrics {
+		reports = append(reports, Analyze(snapshots, metric, namespace, options, now))
+	}
+	return reports, nil
+}
+
+// Handler serves trends from the history store at /trends
+type Handler struct {
+	store   history.Store
+	cluster string
+}
+
+// NewHandler creates a handler for the /trends endpoint
+func NewHandler(store history.Store, cluster string) *Handler {
+	return &Handler{store: store, cluster: cluster}
+}
+
+// ServeHTTP handles /trends?metric=cost,cpu&namespace=payments&since=720h&window=24h&step=6h&horizon=168h.
+// All parameters are optional; metric defaults to every metric and namespace to the cluster.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	namespace := query.Get("namespace")
+
+	metrics := Metrics
+	if m := query.Get("metric"); m != "" {
+		metrics = strings.Split(m, ",")
+	}
+	for _, metric := range metrics {
+		if !slices.Contains(Metrics, metric) {
+			http.Error(w, fmt.Sprintf("unknown metric %q", metric), http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Team callers only see trends of their own namespaces
+	if scope, ok := auth.ScopeFrom(r.Context()); ok && !scope.Allows(namespace) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	options := DefaultOptions
+	for name, d := range map[string]*time.Duration{"since": &options.Since, "window": &options.Window, "step": &options.Step, "horizon": &options.Horizon} {
+		if v := query.Get(name); v != "" {
+			parsed, err := time.ParseDuration(v)
+			if err != nil || parsed <= 0 {
+				http.Error(w, fmt.Sprintf("invalid %s %q", name, v), http.StatusBadRequest)
+				return
+			}
+			*d = parsed
+		}
+	}
+
+	reports, err := Load(r.Context(), h.store, h.cluster, metrics, namespace, options, time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reports)
+}
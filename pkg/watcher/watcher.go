@@ -0,0 +1,385 @@
+package watcher
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/health"
+	"github.com/ochestra-tech/ochestra-ai/pkg/notify"
+	"github.com/ochestra-tech/ochestra-ai/pkg/snapshot"
+)
+
+// waitingReasons are container waiting reasons reported as issues, with their severity
+var waitingReasons = map[string]string{
+	"CrashLoopBackOff":           "critical",
+	"ImagePullBackOff":           "warning",
+	"ErrImagePull":               "warning",
+	"CreateContainerConfigError": "warning",
+}
+
+// eventReasons are warning event reasons reported as issues until the event expires
+var eventReasons = map[string]string{
+	"FailedScheduling": "warning",
+	"OOMKilling":       "critical",
+	"Evicted":          "warning",
+	"FailedMount":      "warning",
+}
+
+// Watcher keeps issue state current between full snapshots by watching pods, nodes and
+// events, raising an alert when an issue opens and again when it clears
+type Watcher struct {
+	clientset kubernetes.Interface
+	notifier  notify.Notifier
+	cluster   string
+
+	mu         sync.Mutex
+	issues     map[string]health.HealthIssue // fingerprint -> open issue
+	correlated map[string]string             // fingerprint -> root cause fingerprint, alert held back
+}
+
+// NewWatcher creates a watcher that sends issue transitions to notifier
+func NewWatcher(clientset kubernetes.Interface, notifier notify.Notifier, cluster string) *Watcher {
+	return &Watcher{
+		clientset:  clientset,
+		notifier:   notifier,
+		cluster:    cluster,
+		issues:     make(map[string]health.HealthIssue),
+		correlated: make(map[string]string),
+	}
+}
+
+// Run starts the pod, node and event informers and blocks until ctx is done
+func (w *Watcher) Run(ctx context.Context) error {
+	factory := informers.NewSharedInformerFactoryWithOptions(w.clientset, 0,
+		informers.WithTransform(trimObject))
+
+	podInformer := factory.Core().V1().Pods().Informer()
+	podInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { w.onPod(ctx, obj) },
+		UpdateFunc: func(_, obj interface{}) { w.onPod(ctx, obj) },
+		DeleteFunc: func(obj interface{}) { w.onPodDeleted(ctx, obj) },
+	})
+
+	nodeInformer := factory.Core().V1().Nodes().Informer()
+	nodeInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { w.onNode(ctx, obj) },
+		UpdateFunc: func(_, obj interface{}) { w.onNode(ctx, obj) },
+		DeleteFunc: func(obj interface{}) { w.onNodeDeleted(ctx, obj) },
+	})
+
+	eventInformer := factory.Core().V1().Events().Informer()
+	eventInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { w.onEvent(ctx, obj) },
+		UpdateFunc: func(_, obj interface{}) { w.onEvent(ctx, obj) },
+		DeleteFunc: func(obj interface{}) { w.onEventDeleted(ctx, obj) },
+	})
+
+	factory.Start(ctx.Done())
+	for informerType, synced := range factory.WaitForCacheSync(ctx.Done()) {
+		if !synced {
+			return fmt.Errorf("failed to sync %v informer", informerType)
+		}
+	}
+	log.Printf("Watching pods, nodes and events for incremental issue detection")
+
+	<-ctx.Done()
+	factory.Shutdown()
+	return nil
+}
+
+// Issues returns the currently open issues ordered by severity and resource
+func (w *Watcher) Issues() []health.HealthIssue {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	result := make([]health.HealthIssue, 0, len(w.issues))
+	for _, issue := range w.issues {
+		result = append(result, issue)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if health.SeverityRank(result[i].Severity) != health.SeverityRank(result[j].Severity) {
+			return health.SeverityRank(result[i].Severity) < health.SeverityRank(result[j].Severity)
+		}
+		return issueKey(result[i]) < issueKey(result[j])
+	})
+	return result
+}
+
+// Resync reconciles pod and node issues with a full snapshot, clearing issues the watch
+// may have missed (e.g. after a dropped connection) and opening ones it has not seen
+func (w *Watcher) Resync(ctx context.Context, snap *snapshot.ClusterSnapshot) {
+	var issues []health.HealthIssue
+	for i := range snap.Pods {
+		issues = append(issues, podIssues(&snap.Pods[i])...)
+	}
+	for i := range snap.Nodes {
+		if issue, notReady := nodeIssue(&snap.Nodes[i]); notReady {
+			issues = append(issues, issue)
+		}
+	}
+
+	// Events are not part of the snapshot
+	w.replace(ctx, func(_ string, issue health.HealthIssue) bool { return issue.Resource != "Event" }, issues)
+}
+
+// onPod updates the issues of a pod from its container statuses
+func (w *Watcher) onPod(ctx context.Context, obj interface{}) {
+	pod, ok := obj.(*v1.Pod)
+	if !ok {
+		return
+	}
+	w.reconcile(ctx, "Pod/"+pod.Namespace+"/"+pod.Name+"/", podIssues(pod))
+}
+
+// onPodDeleted clears every issue of a deleted pod
+func (w *Watcher) onPodDeleted(ctx context.Context, obj interface{}) {
+	if pod, ok := deletedObject(obj).(*v1.Pod); ok {
+		w.reconcile(ctx, "Pod/"+pod.Namespace+"/"+pod.Name+"/", nil)
+	}
+}
+
+// onNode updates the readiness issue of a node
+func (w *Watcher) onNode(ctx context.Context, obj interface{}) {
+	node, ok := obj.(*v1.Node)
+	if !ok {
+		return
+	}
+	var issues []health.HealthIssue
+	if issue, notReady := nodeIssue(node); notReady {
+		issues = append(issues, issue)
+	}
+	w.reconcile(ctx, "Node//"+node.Name+"/", issues)
+}
+
+// onNodeDeleted clears the issues of a deleted node
+func (w *Watcher) onNodeDeleted(ctx context.Context, obj interface{}) {
+	if node, ok := deletedObject(obj).(*v1.Node); ok {
+		w.reconcile(ctx, "Node//"+node.Name+"/", nil)
+	}
+}
+
+// onEvent opens an issue for warning events with a tracked reason
+func (w *Watcher) onEvent(ctx context.Context, obj interface{}) {
+	event, ok := obj.(*v1.Event)
+	if !ok || event.Type != v1.EventTypeWarning {
+		return
+	}
+	severity, tracked := eventReasons[event.Reason]
+	if !tracked {
+		return
+	}
+
+	issue := health.HealthIssue{
+		Type:      event.Reason,
+		Severity:  severity,
+		Resource:  "Event",
+		Namespace: event.InvolvedObject.Namespace,
+		Name:      fmt.Sprintf("%s/%s/%s", event.InvolvedObject.Kind, event.InvolvedObject.Name, event.Reason),
+		Message:   event.Message,
+		Timestamp: time.Now(),
+		Node:      event.Source.Host,
+	}
+	health.Suggest(&issue)
+	w.reconcile(ctx, eventPrefix(event), []health.HealthIssue{issue})
+}
+
+// onEventDeleted clears an event issue once the event expires
+func (w *Watcher) onEventDeleted(ctx context.Context, obj interface{}) {
+	if event, ok := deletedObject(obj).(*v1.Event); ok {
+		w.reconcile(ctx, eventPrefix(event), nil)
+	}
+}
+
+// reconcile replaces the open issues under a key prefix with issues, notifying on changes
+func (w *Watcher) reconcile(ctx context.Context, prefix string, issues []health.HealthIssue) {
+	w.replace(ctx, func(key string, _ health.HealthIssue) bool { return strings.HasPrefix(key, prefix) }, issues)
+}
+
+// replace swaps the open issues selected by inScope for issues, sending an alert for each
+// issue that opened or resolved. Issues on a NotReady node are correlated with the node's
+// issue: their alerts are held back while it is open and sent if they outlast it.
+func (w *Watcher) replace(ctx context.Context, inScope func(string, health.HealthIssue) bool, issues []health.HealthIssue) {
+	current := make(map[string]health.HealthIssue, len(issues))
+	for _, issue := range issues {
+		current[issueKey(issue)] = issue
+	}
+
+	w.mu.Lock()
+	var opened, resolved []health.HealthIssue
+	for key, issue := range w.issues {
+		if !inScope(key, issue) {
+			continue
+		}
+		if _, ok := current[key]; !ok {
+			delete(w.issues, key)
+			if _, held := w.correlated[key]; held {
+				delete(w.correlated, key)
+				continue
+			}
+			resolved = append(resolved, issue)
+
+			// Symptoms that outlast their root cause are alerted on their own
+			for child, root := range w.correlated {
+				if root == key {
+					delete(w.correlated, child)
+					opened = append(opened, w.issues[child])
+				}
+			}
+		}
+	}
+	for key, issue := range current {
+		if _, ok := w.issues[key]; ok {
+			continue
+		}
+		w.issues[key] = issue
+		opened = append(opened, issue)
+	}
+	for i := 0; i < len(opened); i++ {
+		key := issueKey(opened[i])
+		if root := w.rootCause(opened[i]); root != "" && root != key {
+			w.correlated[key] = root
+			log.Printf("Holding back alert for %s, correlated with %s", key, root)
+			opened = append(opened[:i], opened[i+1:]...)
+			i--
+		}
+	}
+	w.mu.Unlock()
+
+	for _, issue := range opened {
+		w.notify(ctx, issue, false)
+	}
+	for _, issue := range resolved {
+		w.notify(ctx, issue, true)
+	}
+}
+
+// rootCause returns the key of an open issue that explains issue, or "" if there is none.
+// Callers must hold w.mu.
+func (w *Watcher) rootCause(issue health.HealthIssue) string {
+	if issue.Node == "" {
+		return ""
+	}
+	key := issueKey(health.HealthIssue{Resource: "Node", Name: issue.Node})
+	if _, ok := w.issues[key]; ok {
+		return key
+	}
+	return ""
+}
+
+// notify sends an alert for an issue that opened or resolved
+func (w *Watcher) notify(ctx context.Context, issue health.HealthIssue, resolved bool) {
+	alert := notify.Alert{
+		Title:       fmt.Sprintf("%s %s", issue.Resource, issue.Name),
+		Message:     issue.Message,
+		Severity:    issue.Severity,
+		Source:      "watch",
+		Fingerprint: issueKey(issue),
+		Labels:      map[string]string{"cluster": w.cluster},
+		Timestamp:   time.Now(),
+		Issue:       &issue,
+	}
+	if issue.Namespace != "" {
+		alert.Title = fmt.Sprintf("%s %s/%s", issue.Resource, issue.Namespace, issue.Name)
+		alert.Labels["namespace"] = issue.Namespace
+	}
+	if resolved {
+		alert.Title = "Resolved: " + alert.Title
+		alert.Severity = "info"
+		alert.Resolved = true
+	}
+
+	if err := w.notifier.Notify(ctx, alert); err != nil {
+		log.Printf("Failed to send watch alert %q: %v", alert.Title, err)
+	}
+}
+
+// podIssues returns the container issues of a pod
+func podIssues(pod *v1.Pod) []health.HealthIssue {
+	var issues []health.HealthIssue
+	statuses := append(append([]v1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+	for _, cs := range statuses {
+		if cs.State.Waiting == nil {
+			continue
+		}
+		severity, tracked := waitingReasons[cs.State.Waiting.Reason]
+		if !tracked {
+			continue
+		}
+		issue := health.HealthIssue{
+			Type:       cs.State.Waiting.Reason,
+			Severity:   severity,
+			Resource:   "Pod",
+			Namespace:  pod.Namespace,
+			Name:       pod.Name + "/" + cs.Name,
+			Message:    fmt.Sprintf("Container %s is in %s (%d restarts)", cs.Name, cs.State.Waiting.Reason, cs.RestartCount),
+			Timestamp:  time.Now(),
+			Suggestion: "Check the container logs and recent events for the failure cause",
+			Node:       pod.Spec.NodeName,
+		}
+		health.Suggest(&issue)
+		issues = append(issues, issue)
+	}
+	return issues
+}
+
+// nodeIssue returns a NotReady issue for a node whose Ready condition is not true
+func nodeIssue(node *v1.Node) (health.HealthIssue, bool) {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type != v1.NodeReady {
+			continue
+		}
+		if condition.Status == v1.ConditionTrue {
+			return health.HealthIssue{}, false
+		}
+		issue := health.HealthIssue{
+			Type:       health.IssueNodeNotReady,
+			Severity:   "critical",
+			Resource:   "Node",
+			Name:       node.Name,
+			Message:    fmt.Sprintf("Node is NotReady: %s", condition.Message),
+			Timestamp:  time.Now(),
+			Suggestion: "Check kubelet status and node connectivity",
+		}
+		health.Suggest(&issue)
+		return issue, true
+	}
+	return health.HealthIssue{}, false
+}
+
+// issueKey identifies an issue as "<resource>/<namespace>/<name>/"
+func issueKey(issue health.HealthIssue) string {
+	return fmt.Sprintf("%s/%s/%s/", issue.Resource, issue.Namespace, issue.Name)
+}
+
+// eventPrefix is the issue key of an event issue
+func eventPrefix(event *v1.Event) string {
+	return fmt.Sprintf("Event/%s/%s/%s/%s/", event.InvolvedObject.Namespace,
+		event.InvolvedObject.Kind, event.InvolvedObject.Name, event.Reason)
+}
+
+// deletedObject unwraps the tombstone informers deliver for missed deletions
+func deletedObject(obj interface{}) interface{} {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		return tombstone.Obj
+	}
+	return obj
+}
+
+// trimObject drops managed fields from cached objects to keep informer memory down
+func trimObject(obj interface{}) (interface{}, error) {
+	if accessor, ok := obj.(metav1.ObjectMetaAccessor); ok {
+		accessor.GetObjectMeta().SetManagedFields(nil)
+	}
+	return obj, nil
+}
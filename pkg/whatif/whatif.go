@@ -0,0 +1,511 @@
+package whatif
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/ochestra-tech/ochestra-ai/pkg/cost"
+	"github.com/ochestra-tech/ochestra-ai/pkg/health"
+	"github.com/ochestra-tech/ochestra-ai/pkg/snapshot"
+)
+
+// DefaultNamespace is the namespace of manifests that do not set one
+const DefaultNamespace = "default"
+
+// Workload is a workload read from a manifest, with what each of its pods requests
+type Workload struct {
+	Kind      string  `json:"kind"`
+	Namespace string  `json:"namespace"`
+	Name      string  `json:"name"`
+	Replicas  int     `json:"replicas"`            // unset for DaemonSets, which run a pod per eligible node
+	CPU       float64 `json:"cpu"`                 // cores per pod
+	MemoryGB  float64 `json:"memoryGB"`            // per pod
+	StorageGB float64 `json:"storageGB,omitempty"` // claimed per pod, or by the claim itself
+	spec      *v1.PodSpec
+}
+
+// WorkloadCost is what a workload would cost once deployed
+type WorkloadCost struct {
+	Workload
+	Pods          int     `json:"pods"`
+	NewNodePods   int     `json:"newNodePods"`             // pods that only fit on added nodes
+	Unschedulable int     `json:"unschedulable,omitempty"` // pods no node of the cluster's shapes can hold
+	CostPerHour   float64 `json:"costPerHour"`
+	CostPerMonth  float64 `json:"costPerMonth"`
+}
+
+// NewNode is a node the cluster would have to add, shaped like one of its existing nodes
+type NewNode struct {
+	InstanceType string  `json:"instanceType,omitempty"`
+	Template     string  `json:"template"` // existing node the new one is modeled on
+	Pods         int     `json:"pods"`
+	CostPerHour  float64 `json:"costPerHour"`
+}
+
+// Report is the estimated cost of deploying a set of manifests onto the cluster
+type Report struct {
+	Workloads    []WorkloadCost `json:"workloads"`
+	NewNodes     []NewNode      `json:"newNodes,omitempty"`
+	Skipped      []string       `json:"skipped,omitempty"` // workloads that cannot be priced, such as Jobs
+	CostPerHour  float64        `json:"costPerHour"`
+	CostPerMonth float64        `json:"costPerMonth"`
+}
+
+// Unschedulable returns the number of pods that would not fit on any node
+func (r *Report) Unschedulable() int {
+	n := 0
+	for _, w := range r.Workloads {
+		n += w.Unschedulable
+	}
+	return n
+}
+
+// manifest holds the fields shared by every manifest
+type manifest struct {
+	Kind     string `json:"kind"`
+	Metadata struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	} `json:"metadata"`
+}
+
+// Parse reads the workloads and volume claims from YAML or JSON manifests, such as a
+// rendered Helm chart, separated by "---". Manifests without a namespace are placed in
+// namespace. Kinds that do not run pods are ignored; Jobs and CronJobs, which run for an
+// unknown time, are returned as skipped.
+func Parse(data []byte, namespace string) ([]Workload, []string, error) {
+	var workloads []Workload
+	var skipped []string
+	for i, doc := range strings.Split("\n"+string(data), "\n---") {
+		if strings.TrimSpace(doc) == "" {
+			continue
+		}
+		var m manifest
+		if err := yaml.Unmarshal([]byte(doc), &m); err != nil {
+			return nil, nil, fmt.Errorf("document %d: %w", i+1, err)
+		}
+		if m.Kind == "" {
+			continue
+		}
+		w := Workload{Kind: m.Kind, Namespace: m.Metadata.Namespace, Name: m.Metadata.Name, Replicas: 1}
+		if w.Namespace == "" {
+			w.Namespace = namespace
+		}
+
+		var err error
+		switch m.Kind {
+		case "Deployment":
+			var obj appsv1.Deployment
+			if err = yaml.Unmarshal([]byte(doc), &obj); err == nil {
+				w.spec, w.Replicas = &obj.Spec.Template.Spec, replicas(obj.Spec.Replicas)
+			}
+		case "ReplicaSet":
+			var obj appsv1.ReplicaSet
+			if err = yaml.Unmarshal([]byte(doc), &obj); err == nil {
+				w.spec, w.Replicas = &obj.Spec.Template.Spec, replicas(obj.Spec.Replicas)
+			}
+		case "StatefulSet":
+			var obj appsv1.StatefulSet
+			if err = yaml.Unmarshal([]byte(doc), &obj); err == nil {
+				w.spec, w.Replicas = &obj.Spec.Template.Spec, replicas(obj.Spec.Replicas)
+				for _, claim := range obj.Spec.VolumeClaimTemplates {
+					w.StorageGB += claimSize(&claim)
+				}
+			}
+		case "DaemonSet":
+			var obj appsv1.DaemonSet
+			if err = yaml.Unmarshal([]byte(doc), &obj); err == nil {
+				w.spec, w.Replicas = &obj.Spec.Template.Spec, 0
+			}
+		case "Pod":
+			var obj v1.Pod
+			if err = yaml.Unmarshal([]byte(doc), &obj); err == nil {
+				w.spec = &obj.Spec
+			}
+		case "PersistentVolumeClaim":
+			var obj v1.PersistentVolumeClaim
+			if err = yaml.Unmarshal([]byte(doc), &obj); err == nil {
+				w.Replicas, w.StorageGB = 0, claimSize(&obj)
+			}
+		case "Job", "CronJob":
+			skipped = append(skipped, fmt.Sprintf("%s %s/%s: runs for an unknown time", m.Kind, w.Namespace, w.Name))
+			continue
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s %s/%s: %w", m.Kind, w.Namespace, w.Name, err)
+		}
+		if w.spec != nil {
+			w.CPU, w.MemoryGB = podRequests(w.spec)
+		}
+		workloads = append(workloads, w)
+	}
+	return workloads, skipped, nil
+}
+
+// replicas returns a workload's replicas, defaulting to one like the apiserver does
+func replicas(n *int32) int {
+	if n == nil {
+		return 1
+	}
+	return int(*n)
+}
+
+// claimSize returns the storage a claim requests in GB
+func claimSize(claim *v1.PersistentVolumeClaim) float64 {
+	size := claim.Spec.Resources.Requests[v1.ResourceStorage]
+	return float64(size.Value()) / (1024 * 1024 * 1024)
+}
+
+// podRequests returns the cores and memory GB a pod requests, as the scheduler counts them:
+// the larger of its containers' sum and any init container, plus the pod's overhead
+func podRequests(spec *v1.PodSpec) (float64, float64) {
+	var cpu, mem float64
+	for _, c := range spec.Containers {
+		cpu += c.Resources.Requests.Cpu().AsApproximateFloat64()
+		mem += float64(c.Resources.Requests.Memory().Value()) / (1024 * 1024 * 1024)
+	}
+	for _, c := range spec.InitContainers {
+		cpu = max(cpu, c.Resources.Requests.Cpu().AsApproximateFloat64())
+		mem = max(mem, float64(c.Resources.Requests.Memory().Value())/(1024*1024*1024))
+	}
+	cpu += spec.Overhead.Cpu().AsApproximateFloat64()
+	mem += float64(spec.Overhead.Memory().Value()) / (1024 * 1024 * 1024)
+	return cpu, mem
+}
+
+// defaultMaxPods is the kubelet's pod limit, for nodes that do not report allocatable pods
+const defaultMaxPods = 110
+
+// bin is a node pods are packed onto: an existing node with its free capacity, or a node
+// the cluster would add
+type bin struct {
+	node     *v1.Node // the existing node, or the template of an added one
+	pricing  cost.ResourcePricing
+	cpu, mem float64 // free
+	pods     int     // free pod slots
+	added    bool
+	placed   []placement
+}
+
+// placement is a pod of a workload packed onto a bin
+type placement struct {
+	workload int
+	cpu, mem float64
+}
+
+// fits reports whether a pod of the workload may run on the bin and fits in its free capacity
+func (b *bin) fits(w *Workload) bool {
+	return b.pods > 0 && w.CPU <= b.cpu+1e-9 && w.MemoryGB <= b.mem+1e-9 && health.NodeEligible(w.spec, b.node)
+}
+
+// place takes a pod of the workload's requests from the bin's free capacity
+func (b *bin) place(i int, w *Workload) {
+	b.cpu -= w.CPU
+	b.mem -= w.MemoryGB
+	b.pods--
+	b.placed = append(b.placed, placement{i, w.CPU, w.MemoryGB})
+}
+
+// Estimate packs the workloads' pods onto the free capacity of the cluster's ready nodes,
+// largest first, and adds nodes shaped like existing ones for pods that do not fit. Pods on
+// existing nodes cost what they request at their node's prices; added nodes cost in full,
+// split between their pods by requests. Virtual nodes are not packed, since only pods
+// tolerating them are placed there.
+func Estimate(snap *snapshot.ClusterSnapshot, pricing map[string]cost.ResourcePricing, workloads []Workload) *Report {
+	var existing []*bin
+	byNode := make(map[string]*bin)
+	shapes := make(map[string]*bin) // instance type -> template of an added node
+	for i := range snap.Nodes {
+		node := &snap.Nodes[i]
+		if node.Spec.Unschedulable || !ready(node) || snapshot.IsVirtualNode(node) {
+			continue
+		}
+		b := &bin{
+			node:    node,
+			pricing: nodePricing(node, pricing),
+			cpu:     node.Status.Allocatable.Cpu().AsApproximateFloat64(),
+			mem:     float64(node.Status.Allocatable.Memory().Value()) / (1024 * 1024 * 1024),
+			pods:    int(node.Status.Allocatable.Pods().Value()),
+		}
+		if b.pods == 0 {
+			b.pods = defaultMaxPods
+		}
+		existing = append(existing, b)
+		byNode[node.Name] = b
+	}
+	sort.Slice(existing, func(i, j int) bool { return existing[i].node.Name < existing[j].node.Name })
+
+	// Added nodes start with the capacity of their template less its DaemonSet pods
+	for _, b := range existing {
+		if _, ok := shapes[instanceType(b.node)]; !ok {
+			shape := *b
+			shapes[instanceType(b.node)] = &shape
+		}
+	}
+	for i := range snap.Pods {
+		pod := &snap.Pods[i]
+		b, ok := byNode[pod.Spec.NodeName]
+		if !ok || pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+			continue
+		}
+		cpu, mem := podRequests(&pod.Spec)
+		b.cpu, b.mem, b.pods = b.cpu-cpu, b.mem-mem, b.pods-1
+		if kind, _ := snapshot.WorkloadOwner(pod); kind == "DaemonSet" {
+			if shape := shapes[instanceType(b.node)]; shape.node == b.node {
+				shape.cpu, shape.mem, shape.pods = shape.cpu-cpu, shape.mem-mem, shape.pods-1
+			}
+		}
+	}
+
+	report := &Report{Workloads: make([]WorkloadCost, len(workloads))}
+	storagePrice := pricing["default"].Storage
+	var daemonSets, pods []int // workload indexes, pods once per replica
+	for i := range workloads {
+		w := &workloads[i]
+		report.Workloads[i] = WorkloadCost{Workload: *w}
+		if w.spec == nil {
+			// A bare claim costs its storage alone
+			report.Workloads[i].CostPerHour = w.StorageGB * storagePrice
+			continue
+		}
+		if w.Kind == "DaemonSet" {
+			daemonSets = append(daemonSets, i)
+			continue
+		}
+		for range w.Replicas {
+			pods = append(pods, i)
+		}
+	}
+
+	// DaemonSet pods run on every eligible node, whether or not they fit
+	for _, i := range daemonSets {
+		for _, b := range existing {
+			if !health.NodeEligible(workloads[i].spec, b.node) {
+				continue
+			}
+			if !b.fits(&workloads[i]) {
+				report.Workloads[i].Unschedulable++
+				continue
+			}
+			b.place(i, &workloads[i])
+		}
+	}
+
+	sort.SliceStable(pods, func(a, b int) bool {
+		wa, wb := &workloads[pods[a]], &workloads[pods[b]]
+		return wa.CPU+wa.MemoryGB/4 > wb.CPU+wb.MemoryGB/4
+	})
+	var added []*bin
+	for _, i := range pods {
+		w := &workloads[i]
+		target := firstFit(existing, w)
+		if target == nil {
+			target = firstFit(added, w)
+		}
+		if target == nil {
+			target = addNode(shapes, w, workloads, daemonSets)
+			if target != nil {
+				added = append(added, target)
+			}
+		}
+		if target == nil {
+			report.Workloads[i].Unschedulable++
+			continue
+		}
+		target.place(i, w)
+	}
+
+	// Price the placed pods
+	for _, b := range existing {
+		for _, p := range b.placed {
+			wc := &report.Workloads[p.workload]
+			wc.Pods++
+			wc.CostPerHour += p.cpu*b.pricing.CPU + p.mem*b.pricing.Memory
+		}
+	}
+	for _, b := range added {
+		nodeCost := b.node.Status.Capacity.Cpu().AsApproximateFloat64()*b.pricing.CPU +
+			float64(b.node.Status.Capacity.Memory().Value())/(1024*1024*1024)*b.pricing.Memory
+		var cpu, mem float64
+		for _, p := range b.placed {
+			cpu += p.cpu
+			mem += p.mem
+		}
+		for _, p := range b.placed {
+			wc := &report.Workloads[p.workload]
+			wc.Pods++
+			wc.NewNodePods++
+			wc.CostPerHour += nodeCost * share(p.cpu, cpu, p.mem, mem, len(b.placed))
+		}
+		report.NewNodes = append(report.NewNodes, NewNode{
+			InstanceType: instanceType(b.node),
+			Template:     b.node.Name,
+			Pods:         len(b.placed),
+			CostPerHour:  nodeCost,
+		})
+	}
+
+	for i := range report.Workloads {
+		wc := &report.Workloads[i]
+		if wc.spec != nil {
+			wc.CostPerHour += float64(wc.Pods) * wc.StorageGB * storagePrice
+		}
+		wc.CostPerMonth = wc.CostPerHour * 24 * 30
+		report.CostPerHour += wc.CostPerHour
+	}
+	report.CostPerMonth = report.CostPerHour * 24 * 30
+	sort.SliceStable(report.Workloads, func(i, j int) bool {
+		return report.Workloads[i].CostPerHour > report.Workloads[j].CostPerHour
+	})
+	return report
+}
+
+// firstFit returns the first bin the workload's pod fits on
+func firstFit(bins []*bin, w *Workload) *bin {
+	for _, b := range bins {
+		if b.fits(w) {
+			return b
+		}
+	}
+	return nil
+}
+
+// addNode adds the cheapest node shape the workload's pod fits on, with a pod of each new
+// DaemonSet eligible for it. It returns nil when no shape holds the pod.
+func addNode(shapes map[string]*bin, w *Workload, workloads []Workload, daemonSets []int) *bin {
+	var cheapest *bin
+	var cheapestCost float64
+	for _, shape := range shapes {
+		b := *shape
+		b.added, b.placed = true, nil
+		for _, i := range daemonSets {
+			if health.NodeEligible(workloads[i].spec, b.node) && b.fits(&workloads[i]) {
+				b.place(i, &workloads[i])
+			}
+		}
+		if !b.fits(w) {
+			continue
+		}
+		nodeCost := b.node.Status.Capacity.Cpu().AsApproximateFloat64()*b.pricing.CPU +
+			float64(b.node.Status.Capacity.Memory().Value())/(1024*1024*1024)*b.pricing.Memory
+		if cheapest == nil || nodeCost < cheapestCost || (nodeCost == cheapestCost && b.node.Name < cheapest.node.Name) {
+			cheapest, cheapestCost = &b, nodeCost
+		}
+	}
+	return cheapest
+}
+
+// share is a pod's part of its node, the average of its CPU and memory shares of the
+// node's pods, or an equal part when they request nothing
+func share(cpu, totalCPU, mem, totalMem float64, pods int) float64 {
+	switch {
+	case totalCPU > 0 && totalMem > 0:
+		return (cpu/totalCPU + mem/totalMem) / 2
+	case totalCPU > 0:
+		return cpu / totalCPU
+	case totalMem > 0:
+		return mem / totalMem
+	}
+	return 1 / float64(pods)
+}
+
+// ready reports whether a node's Ready condition is true
+func ready(node *v1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == v1.NodeReady {
+			return condition.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// instanceType returns a node's instance type label
+func instanceType(node *v1.Node) string {
+	return node.Labels["node.kubernetes.io/instance-type"]
+}
+
+// nodePricing returns the prices of a node's instance type, or the default prices
+func nodePricing(node *v1.Node, pricing map[string]cost.ResourcePricing) cost.ResourcePricing {
+	if p, ok := pricing[instanceType(node)]; ok {
+		return p
+	}
+	return pricing["default"]
+}
+
+// maxManifestSize bounds the manifests posted to the handler
+const maxManifestSize = 16 << 20
+
+// Handler serves POST /whatif with a body of manifests and answers with the Report of
+// deploying them onto the cluster as of the last monitoring cycle
+type Handler struct {
+	pricing map[string]cost.ResourcePricing
+
+	mu   sync.RWMutex
+	snap *snapshot.ClusterSnapshot
+}
+
+// NewHandler creates a what-if handler pricing nodes with pricing
+func NewHandler(pricing map[string]cost.ResourcePricing) *Handler {
+	return &Handler{pricing: pricing}
+}
+
+// Update replaces the cluster manifests are packed onto, once per monitoring cycle
+func (h *Handler) Update(snap *snapshot.ClusterSnapshot) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.snap = snap
+}
+
+// ServeHTTP estimates the posted manifests, placing those without a namespace in the
+// namespace parameter
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "POST manifests as YAML or JSON", http.StatusMethodNotAllowed)
+		return
+	}
+	h.mu.RLock()
+	snap := h.snap
+	h.mu.RUnlock()
+	if snap == nil {
+		http.Error(w, "no cluster snapshot yet", http.StatusServiceUnavailable)
+		return
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxManifestSize))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		http.Error(w, fmt.Sprintf("manifests exceed %d bytes", tooLarge.Limit), http.StatusRequestEntityTooLarge)
+		return
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to read manifests: %v", err), http.StatusBadRequest)
+		return
+	}
+	namespace := r.URL.Query().Get("namespace")
+	if namespace == "" {
+		namespace = DefaultNamespace
+	}
+	workloads, skipped, err := Parse(data, namespace)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid manifests: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	report := Estimate(snap, h.pricing, workloads)
+	report.Skipped = skipped
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}